@@ -22,6 +22,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
@@ -36,6 +37,7 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"gopkg.in/yaml.v3"
 
@@ -47,15 +49,22 @@ import (
 
 // config represents application configuration.
 type config struct {
-	Log      *log.Config                             `yaml:"log"`
-	Timeouts *timeoutConfig                          `yaml:"timeouts"`
-	Server   *serverConfig                           `yaml:"server"`
-	DB       *repository.Config                      `yaml:"db"`
-	NPClient *client.NPClientConfig                  `yaml:"npClient"`
-	Admin    *service.AdminConfig                    `yaml:"admin"`
-	Event    *event.Config                           `yaml:"event"`
-	Setup    *service.RegistrySelfRegistrationConfig `yaml:"setup"`
-	Auth     *oidcauth.Config                        `yaml:"auth"`
+	Log         *log.Config                             `yaml:"log"`
+	Timeouts    *timeoutConfig                          `yaml:"timeouts"`
+	Server      *serverConfig                           `yaml:"server"`
+	DB          *repository.Config                      `yaml:"db"`
+	NPClient    *client.NPClientConfig                  `yaml:"npClient"`
+	Admin       *service.AdminConfig                    `yaml:"admin"`
+	Event       *event.Config                           `yaml:"event"`
+	Setup       *service.RegistrySelfRegistrationConfig `yaml:"setup"`
+	Auth        *oidcauth.Config                        `yaml:"auth"`
+	DebugErrors *model.ErrorDebugConfig                 `yaml:"debugErrors"`
+	AccessLog   *log.AccessLogConfig                    `yaml:"accessLog"`
+	Sweeper     *service.SweeperConfig                  `yaml:"sweeper"`
+	Webhooks    *event.WebhookConfig                    `yaml:"webhooks"`
+
+	// DependencyTimeouts sets the timeouts of all external dependencies in one place.
+	DependencyTimeouts *model.DependencyTimeouts `yaml:"dependencyTimeouts"`
 }
 
 type serverConfig struct {
@@ -84,11 +93,20 @@ func initConfig(filePath string) (*config, error) {
 	}
 	if cfg.NPClient == nil {
 		c := client.DefaultNPClientConfig()
+		c.Timeout = 0 // Set from dependencyTimeouts.
 		cfg.NPClient = &c
 	}
 	if err := cfg.valid(); err != nil {
 		return nil, err
 	}
+	warnings, err := cfg.checkConsistency()
+	if err != nil {
+		return nil, fmt.Errorf("inconsistent config: %w", err)
+	}
+	for _, w := range warnings {
+		slog.Warn("Config consistency check found a likely misconfiguration", "warning", w)
+	}
+	cfg.applyDependencyTimeouts()
 	return &cfg, nil
 }
 
@@ -106,9 +124,15 @@ func (c *config) valid() error {
 	if c.Timeouts == nil {
 		return fmt.Errorf("missing required config section: timeouts")
 	}
+	if err := c.DependencyTimeouts.Validate(); err != nil {
+		return err
+	}
 	if c.DB == nil {
 		return fmt.Errorf("missing required config section: db")
 	}
+	if err := c.DB.Validate(); err != nil {
+		return fmt.Errorf("invalid db config: %w", err)
+	}
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
@@ -118,9 +142,61 @@ func (c *config) valid() error {
 	if c.Admin.OperationRetryMax <= 0 {
 		return fmt.Errorf("admin.OperationRetryMax must be greater than zero")
 	}
+	if !c.Admin.OperationIDFormat.Valid() {
+		return fmt.Errorf("invalid admin.operationIDFormat: %q, must be one of ANY, UUID", c.Admin.OperationIDFormat)
+	}
+	if !c.Admin.ChallengeVerification.Valid() {
+		return fmt.Errorf("invalid admin.challengeVerification: %q, must be one of STRICT, TOLERANT", c.Admin.ChallengeVerification)
+	}
+	if c.Admin.ChallengeVerification == service.ChallengeVerificationTolerant {
+		slog.Warn("Config validation: admin.challengeVerification is TOLERANT, challenge answers are normalized before comparison.")
+	}
+	if err := (service.ChallengePolicy{Length: c.Admin.ChallengeLength, Algorithm: c.Admin.ChallengeAlgorithm}).Validate(); err != nil {
+		return fmt.Errorf("invalid admin.challengeLength or admin.challengeAlgorithm: %w", err)
+	}
+	for domain, p := range c.Admin.ChallengePolicies {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid admin.challengePolicies[%q]: %w", domain, err)
+		}
+		if p.Verification == service.ChallengeVerificationTolerant {
+			slog.Warn("Config validation: admin.challengePolicies verification is TOLERANT, challenge answers are normalized before comparison.", "domain", domain)
+		}
+	}
+	if c.Admin.MaxConcurrentApprovalsPerSubscriber < 0 {
+		return fmt.Errorf("admin.maxConcurrentApprovalsPerSubscriber must not be negative")
+	}
+	if !c.Admin.ApprovalMode.Valid() {
+		return fmt.Errorf("invalid admin.approvalMode: %q, must be one of SYNC, ASYNC", c.Admin.ApprovalMode)
+	}
+	if c.Admin.ApprovalWorkers < 0 {
+		return fmt.Errorf("admin.approvalWorkers must not be negative")
+	}
+	if c.Admin.ApprovalQueueSize < 0 {
+		return fmt.Errorf("admin.approvalQueueSize must not be negative")
+	}
+	if c.Admin.ApprovalClaimTimeout < 0 {
+		return fmt.Errorf("admin.approvalClaimTimeout must not be negative")
+	}
+	if c.Admin.StaleOperationAge < 0 {
+		return fmt.Errorf("admin.staleOperationAge must not be negative")
+	}
+	if c.Admin.StaleOperationAge > 0 && c.Sweeper == nil {
+		return fmt.Errorf("admin.staleOperationAge requires the sweeper section")
+	}
+	if c.Sweeper != nil && c.Sweeper.Interval <= 0 {
+		return fmt.Errorf("invalid sweeper interval: %s, must be positive", c.Sweeper.Interval)
+	}
 	if c.Event == nil {
 		return fmt.Errorf("missing required config section: event")
 	}
+	if c.Webhooks != nil {
+		if c.Webhooks.ProjectID == "" {
+			c.Webhooks.ProjectID = c.Event.ProjectID
+		}
+		if err := c.Webhooks.Validate(); err != nil {
+			return fmt.Errorf("invalid webhooks config: %w", err)
+		}
+	}
 	if c.Setup == nil {
 		return fmt.Errorf("missing required config section: setup")
 	}
@@ -135,9 +211,29 @@ func (c *config) valid() error {
 			return fmt.Errorf("missing auth allowedIssuers when auth is enabled")
 		}
 	}
+	if c.DebugErrors != nil && c.DebugErrors.Enabled {
+		slog.Warn("Config validation: debugErrors is enabled, internal error details will be returned to clients. Do not use in production.")
+	}
 	return nil
 }
 
+// checkConsistency cross-checks the self-registration setup. A relative setup URL is an
+// error, and a setup subscriber ID other than the host of the setup URL a warning, since
+// subscribers take the registry's regID from it.
+func (c *config) checkConsistency() ([]string, error) {
+	var warnings []string
+	if c.Setup.URL != "" {
+		setupURL, err := url.Parse(c.Setup.URL)
+		if err != nil || setupURL.Scheme == "" || setupURL.Host == "" {
+			return nil, fmt.Errorf("setup url %q must be an absolute URL", c.Setup.URL)
+		}
+		if c.Setup.SubscriberID != "" && setupURL.Hostname() != c.Setup.SubscriberID {
+			warnings = append(warnings, fmt.Sprintf("setup subscriberID %q does not match setup url host %q, subscribers configure regID from the registry's subscriberID", c.Setup.SubscriberID, setupURL.Hostname()))
+		}
+	}
+	return warnings, nil
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -165,10 +261,11 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create secret manager client for encryption service: %w", err)
 	}
 	defer sm.Close()
-	server, err := newServer(ctx, cfg, db, encry, sm)
+	server, stopWorkers, err := newServer(ctx, cfg, db, encry, sm)
 	if err != nil {
 		return err
 	}
+	defer stopWorkers()
 
 	serverErr := make(chan error, 1)
 	go func() {
@@ -204,49 +301,74 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+// applyDependencyTimeouts applies the dependencyTimeouts section to the clients of the admin service.
+func (c *config) applyDependencyTimeouts() {
+	c.DependencyTimeouts.Apply(model.ClientTimeouts{DB: &c.DB.Timeout, Event: &c.Event.Timeout, NPCallback: &c.NPClient.Timeout})
+}
+
 var configPath string
 var newConnectionPool = repository.NewConnectionPool
 
-func newServer(ctx context.Context, cfg *config, db *sql.DB, encyr definition.Encrypter, sm *secretmanager.Client) (*http.Server, error) {
+// newServer creates the admin server and starts its background workers: the approval workers
+// of ASYNC approvals and the sweeper if it is configured. The returned function stops them.
+func newServer(ctx context.Context, cfg *config, db *sql.DB, encyr definition.Encrypter, sm *secretmanager.Client) (*http.Server, func(), error) {
 
 	regRepo, err := repository.NewRegistry(db)
 	if err != nil {
 		slog.Error("Failed to create registry repository", "error", err)
-		return nil, fmt.Errorf("failed to create registry repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to create registry repository: %w", err)
 	}
+	regRepo.SetQueryTimeout(cfg.DB.Timeout)
 	encSrv, err := service.NewEcryptionService(ctx, encyr, sm, cfg.Event.ProjectID, cfg.Setup.KeyID)
 	if err != nil {
 		slog.Error("Failed to create encryption service", "error", err)
-		return nil, fmt.Errorf("failed to create encryption service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create encryption service: %w", err)
 	}
 	setup, err := service.NewRegistrySetupService(regRepo, encSrv, cfg.Setup)
 	if err != nil {
 		slog.Error("Failed to create registry setup service", "error", err)
-		return nil, fmt.Errorf("failed to create registry setup service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create registry setup service: %w", err)
 	}
 	if err := setup.SelfRegister(ctx); err != nil {
 		slog.Error("Failed to self register", "error", err)
-		return nil, fmt.Errorf("failed to self register: %w", err)
+		return nil, nil, fmt.Errorf("failed to self register: %w", err)
 	}
 	evPub, _, err := event.NewPublisher(ctx, cfg.Event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+		return nil, nil, fmt.Errorf("failed to create event publisher: %w", err)
+	}
+	adminPub := event.WithWebhooks(evPub, nil)
+	closeWebhooks := func() {}
+	if cfg.Webhooks != nil {
+		webhooks, err := event.NewWebhookNotifier(ctx, cfg.Webhooks, nil, sm)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create webhook notifier: %w", err)
+		}
+		adminPub = event.WithWebhooks(evPub, webhooks)
+		closeWebhooks = webhooks.Close
+	}
+	npClient, err := client.NewNPClient(*cfg.NPClient)
+	if err != nil {
+		slog.Error("Failed to create NP client", "error", err)
+		return nil, nil, fmt.Errorf("failed to create NP client: %w", err)
 	}
 	adminSrv, err := service.NewAdminService(regRepo,
 		service.NewChallengeService(),
 		encSrv,
-		client.NewNPClient(*cfg.NPClient),
-		evPub,
+		npClient,
+		adminPub,
 		cfg.Admin)
 	if err != nil {
 		slog.Error("Failed to create admin service", "error", err)
-		return nil, fmt.Errorf("failed to create admin service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create admin service: %w", err)
 	}
 	h, err := handler.NewAdminHandler(adminSrv)
 	if err != nil {
 		slog.Error("Failed to create admin handler", "error", err)
-		return nil, fmt.Errorf("failed to create admin handler: %w", err)
+		return nil, nil, fmt.Errorf("failed to create admin handler: %w", err)
 	}
+	h.SetErrorDebug(cfg.DebugErrors)
+	h.SetWriteTimeout(cfg.Timeouts.Write)
 
 	var oidcMW func(http.Handler) http.Handler
 	if cfg.Auth != nil {
@@ -260,17 +382,53 @@ func newServer(ctx context.Context, cfg *config, db *sql.DB, encyr definition.En
 
 		oidcMW, err = oidcauth.New(ctx, cfg.Auth)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create oidc auth middleware: %w", err)
+			return nil, nil, fmt.Errorf("failed to create oidc auth middleware: %w", err)
 		}
 	}
 
+	accessLog, err := log.NewAccessLogMiddleware(cfg.AccessLog)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create access log middleware: %w", err)
+	}
+
+	stopSweeper, err := startSweeper(ctx, cfg.Sweeper, map[string]service.SweepFunc{
+		"reject_stale_operations": adminSrv.RejectStaleOperations,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	adminSrv.StartApprovalWorkers(context.WithoutCancel(ctx))
+	stopWorkers := func() {
+		stopSweeper()
+		adminSrv.StopApprovalWorkers()
+		closeWebhooks()
+	}
+
 	return &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      admin.NewRouter(h, oidcMW),
+		Handler:      accessLog(admin.NewRouter(h, oidcMW)),
 		ReadTimeout:  cfg.Timeouts.Read,
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
-	}, nil
+	}, stopWorkers, nil
+}
+
+// startSweeper starts a sweeper running jobs by name if cfg is set, and returns a function stopping it.
+func startSweeper(ctx context.Context, cfg *service.SweeperConfig, jobs map[string]service.SweepFunc) (func(), error) {
+	if cfg == nil {
+		return func() {}, nil
+	}
+	sw, err := service.NewSweeper(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sweeper: %w", err)
+	}
+	for name, fn := range jobs {
+		if err := sw.Register(name, fn); err != nil {
+			return nil, fmt.Errorf("failed to register sweep job: %w", err)
+		}
+	}
+	sw.Start(context.WithoutCancel(ctx))
+	return sw.Stop, nil
 }
 
 func main() {
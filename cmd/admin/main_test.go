@@ -15,7 +15,9 @@
 package main
 
 import (
+	"context"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +26,7 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/oidcauth"
 )
 
@@ -79,6 +82,63 @@ func TestInitConfig_Success_DefaultNPClient(t *testing.T) {
 	if cfg.NPClient == nil {
 		t.Fatal("cfg.NPClient is nil, want default NPClientConfig")
 	}
+	if cfg.NPClient.Timeout != model.DefaultNPCallbackTimeout {
+		t.Errorf("cfg.NPClient.Timeout = %s, want %s", cfg.NPClient.Timeout, model.DefaultNPCallbackTimeout)
+	}
+}
+
+func TestConfig_ApplyDependencyTimeouts(t *testing.T) {
+	tests := []struct {
+		name         string
+		deps         *model.DependencyTimeouts
+		npTimeout    time.Duration
+		wantDB       time.Duration
+		wantEvent    time.Duration
+		wantNPClient time.Duration
+	}{
+		{
+			name:         "central defaults when unset",
+			wantDB:       model.DefaultDBTimeout,
+			wantEvent:    model.DefaultEventTimeout,
+			wantNPClient: model.DefaultNPCallbackTimeout,
+		},
+		{
+			name:         "central timeouts",
+			deps:         &model.DependencyTimeouts{DB: 5 * time.Second, Event: 6 * time.Second, NPCallback: 7 * time.Second},
+			wantDB:       5 * time.Second,
+			wantEvent:    6 * time.Second,
+			wantNPClient: 7 * time.Second,
+		},
+		{
+			name:         "client timeout overrides central timeout",
+			deps:         &model.DependencyTimeouts{NPCallback: 7 * time.Second},
+			npTimeout:    2 * time.Second,
+			wantDB:       model.DefaultDBTimeout,
+			wantEvent:    model.DefaultEventTimeout,
+			wantNPClient: 2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				DependencyTimeouts: tt.deps,
+				DB:                 &repository.Config{},
+				Event:              &event.Config{},
+				NPClient:           &client.NPClientConfig{Timeout: tt.npTimeout},
+			}
+			cfg.applyDependencyTimeouts()
+			if cfg.DB.Timeout != tt.wantDB {
+				t.Errorf("DB.Timeout = %s, want %s", cfg.DB.Timeout, tt.wantDB)
+			}
+			if cfg.Event.Timeout != tt.wantEvent {
+				t.Errorf("Event.Timeout = %s, want %s", cfg.Event.Timeout, tt.wantEvent)
+			}
+			if cfg.NPClient.Timeout != tt.wantNPClient {
+				t.Errorf("NPClient.Timeout = %s, want %s", cfg.NPClient.Timeout, tt.wantNPClient)
+			}
+		})
+	}
 }
 
 func TestInitConfig_Error(t *testing.T) {
@@ -182,6 +242,11 @@ func TestConfig_Valid_Error(t *testing.T) {
 			},
 			expectedError: "missing required config section: db",
 		},
+		{
+			name:          "invalid db config",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: &repository.Config{MaxOpenConns: -1}, NPClient: validNPClientCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "invalid db config: maxOpenConns -1 must not be negative",
+		},
 		{
 			name:          "invalid server port (0)",
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: &serverConfig{Port: 0}, DB: validDBCfg, NPClient: validNPClientCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg},
@@ -202,6 +267,76 @@ func TestConfig_Valid_Error(t *testing.T) {
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 0}, Event: validEventCfg, Setup: validSetupCfg},
 			expectedError: "admin.OperationRetryMax must be greater than zero",
 		},
+		{
+			name:          "unknown admin.operationIDFormat",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, OperationIDFormat: "ULID"}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: `invalid admin.operationIDFormat: "ULID", must be one of ANY, UUID`,
+		},
+		{
+			name:          "unknown admin.challengeVerification",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ChallengeVerification: "LOOSE"}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: `invalid admin.challengeVerification: "LOOSE", must be one of STRICT, TOLERANT`,
+		},
+		{
+			name:          "short admin.challengeLength",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ChallengeLength: 8}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "invalid admin.challengeLength or admin.challengeAlgorithm: length 8 must be at least 16",
+		},
+		{
+			name:          "negative dependencyTimeouts.db",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, DependencyTimeouts: &model.DependencyTimeouts{DB: -time.Second}, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "invalid dependencyTimeouts.db: -1s, must not be negative",
+		},
+		{
+			name:          "negative admin.maxConcurrentApprovalsPerSubscriber",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, MaxConcurrentApprovalsPerSubscriber: -1}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.maxConcurrentApprovalsPerSubscriber must not be negative",
+		},
+		{
+			name:          "unknown admin.approvalMode",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ApprovalMode: "LATER"}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: `invalid admin.approvalMode: "LATER", must be one of SYNC, ASYNC`,
+		},
+		{
+			name:          "negative admin.approvalWorkers",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ApprovalWorkers: -1}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.approvalWorkers must not be negative",
+		},
+		{
+			name:          "negative admin.approvalQueueSize",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ApprovalQueueSize: -1}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.approvalQueueSize must not be negative",
+		},
+		{
+			name:          "negative admin.approvalClaimTimeout",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ApprovalClaimTimeout: -time.Minute}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.approvalClaimTimeout must not be negative",
+		},
+		{
+			name:          "negative admin.staleOperationAge",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, StaleOperationAge: -time.Hour}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.staleOperationAge must not be negative",
+		},
+		{
+			name:          "admin.staleOperationAge without sweeper",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, StaleOperationAge: time.Hour}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: "admin.staleOperationAge requires the sweeper section",
+		},
+		{
+			name:          "non-positive sweeper interval",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, StaleOperationAge: time.Hour}, Event: validEventCfg, Setup: validSetupCfg, Sweeper: &service.SweeperConfig{}},
+			expectedError: "invalid sweeper interval: 0s, must be positive",
+		},
+		{
+			name:          "webhooks without signing secret",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: validAdminCfg, Event: validEventCfg, Setup: validSetupCfg, Webhooks: &event.WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}}},
+			expectedError: "invalid webhooks config: missing webhook signing secret id",
+		},
+		{
+			name:          "unknown admin.challengePolicies algorithm",
+			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, NPClient: validNPClientCfg, Admin: &service.AdminConfig{OperationRetryMax: 3, ChallengePolicies: map[string]service.ChallengePolicy{"retail": {Algorithm: "SHA256"}}}, Event: validEventCfg, Setup: validSetupCfg},
+			expectedError: `invalid admin.challengePolicies["retail"]: algorithm "SHA256" must be one of HEX, BASE64`,
+		},
 		{
 			name:          "missing event config",
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Admin: validAdminCfg, Setup: validSetupCfg, NPClient: validNPClientCfg},
@@ -270,3 +405,118 @@ func TestConfig_Valid_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_Valid_WebhooksProjectID(t *testing.T) {
+	tests := []struct {
+		name      string
+		projectID string
+		want      string
+	}{
+		{name: "defaults to event project", want: "test-project"},
+		{name: "own project", projectID: "secrets-project", want: "secrets-project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				Log:      &log.Config{Level: "INFO"},
+				Timeouts: &timeoutConfig{Read: 5 * time.Second, Write: 10 * time.Second, Idle: 120 * time.Second, Shutdown: 15 * time.Second},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				DB:       &repository.Config{User: "test", Name: "test", ConnectionName: "test-conn"},
+				Event:    &event.Config{ProjectID: "test-project", TopicID: "test-topic"},
+				Admin:    &service.AdminConfig{OperationRetryMax: 3},
+				Setup:    &service.RegistrySelfRegistrationConfig{KeyID: "test-key-id"},
+				NPClient: &client.NPClientConfig{Timeout: 10 * time.Second},
+				Webhooks: &event.WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}, SigningSecretID: "webhook-secret", ProjectID: tt.projectID},
+			}
+			if err := cfg.valid(); err != nil {
+				t.Fatalf("config.valid() error = %v, wantErr nil", err)
+			}
+			if got := cfg.Webhooks.ProjectID; got != tt.want {
+				t.Errorf("webhooks.projectID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_CheckConsistency(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        *service.RegistrySelfRegistrationConfig
+		wantWarnings []string
+	}{
+		{
+			name:  "consistent config",
+			setup: &service.RegistrySelfRegistrationConfig{KeyID: "key", SubscriberID: "registry.beckn.org", URL: "https://registry.beckn.org/v1", Domain: "beckn_network"},
+		},
+		{
+			name:  "setup url not configured",
+			setup: &service.RegistrySelfRegistrationConfig{KeyID: "key"},
+		},
+		{
+			name:         "subscriberID does not match url host",
+			setup:        &service.RegistrySelfRegistrationConfig{KeyID: "key", SubscriberID: "registry.beckn.org", URL: "https://other.beckn.org"},
+			wantWarnings: []string{`setup subscriberID "registry.beckn.org" does not match setup url host "other.beckn.org"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{Setup: tt.setup}
+			warnings, err := cfg.checkConsistency()
+			if err != nil {
+				t.Fatalf("checkConsistency() error = %v, want nil", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("checkConsistency() warnings = %q, want %d warnings", warnings, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("checkConsistency() warning[%d] = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_CheckConsistency_Error(t *testing.T) {
+	cfg := &config{Setup: &service.RegistrySelfRegistrationConfig{KeyID: "key", SubscriberID: "registry.beckn.org", URL: "registry.beckn.org"}}
+	_, err := cfg.checkConsistency()
+	if err == nil || !strings.Contains(err.Error(), "must be an absolute URL") {
+		t.Errorf("checkConsistency() error = %v, want error containing %q", err, "must be an absolute URL")
+	}
+}
+
+func TestStartSweeper(t *testing.T) {
+	var runs atomic.Int32
+	stop, err := startSweeper(context.Background(), &service.SweeperConfig{Interval: time.Millisecond}, map[string]service.SweepFunc{
+		"job": func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("startSweeper() error = %v, want nil", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	if runs.Load() == 0 {
+		t.Error("startSweeper() did not run the job")
+	}
+}
+
+func TestStartSweeper_NotConfigured(t *testing.T) {
+	stop, err := startSweeper(context.Background(), nil, map[string]service.SweepFunc{
+		"job": func(ctx context.Context) error {
+			t.Error("job run without a sweeper configured")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("startSweeper() error = %v, want nil", err)
+	}
+	stop()
+}
@@ -21,18 +21,25 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/gateway"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/gateway/handler"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/metrics"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	keyManager "github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/oidcauth"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
 
 	yaml "gopkg.in/yaml.v3"
@@ -44,18 +51,74 @@ import (
 
 // config represents application configuration.
 type config struct {
-	Log                      *log.Config                  `yaml:"log"`
-	Timeouts                 *timeoutConfig               `yaml:"timeouts"`
-	Server                   *serverConfig                `yaml:"server"`
-	ProjectID                string                       `yaml:"projectID"`
-	KeyManagerCacheTTL       *keyManager.CacheTTL         `yaml:"keyManagerCacheTTL"`
-	Registry                 *client.RegistryClientConfig `yaml:"registry"`
-	RedisAddr                string                       `yaml:"redisAddr"`
-	MaxConcurrentFanoutTasks int                          `yaml:"maxConcurrentFanoutTasks"`
-	TaskQueueWorkersCount    int                          `yaml:"taskQueueWorkersCount"`
-	TaskQueueBufferSize      int                          `yaml:"taskQueueBufferSize"`
-	SubscriberID             string                       `yaml:"subscriberID"`
-	HTTPClientRetry          *service.RetryConfig         `yaml:"httpClientRetry"`
+	Log                      *log.Config                   `yaml:"log"`
+	Timeouts                 *timeoutConfig                `yaml:"timeouts"`
+	Server                   *serverConfig                 `yaml:"server"`
+	ProjectID                string                        `yaml:"projectID"`
+	KeyManagerCacheTTL       *keyManager.CacheTTL          `yaml:"keyManagerCacheTTL"`
+	Registry                 *client.RegistryClientConfig  `yaml:"registry"`
+	RedisAddr                string                        `yaml:"redisAddr"`
+	MaxConcurrentFanoutTasks int                           `yaml:"maxConcurrentFanoutTasks"`
+	ProxyTasksPerSecond      float64                       `yaml:"proxyTasksPerSecond"`
+	MaxConcurrentLookups     int                           `yaml:"maxConcurrentLookups"`
+	ProxyDispatchConcurrency int                           `yaml:"proxyDispatchConcurrency"`
+	TaskQueueWorkersCount    int                           `yaml:"taskQueueWorkersCount"`
+	TaskQueueBufferSize      int                           `yaml:"taskQueueBufferSize"`
+	TaskQueueMaxBytes        int64                         `yaml:"taskQueueMaxBytes"`
+	TaskQueueDropOnFull      bool                          `yaml:"taskQueueDropOnFull"`
+	TaskQueueMaxRetries      int                           `yaml:"taskQueueMaxRetries"`
+	TaskQueueRetryDelay      time.Duration                 `yaml:"taskQueueRetryDelay"`
+	SubscriberID             string                        `yaml:"subscriberID"`
+	HTTPClientRetry          *service.RetryConfig          `yaml:"httpClientRetry"`
+	KeyCacheWarmUp           []service.KeyCacheWarmUpEntry `yaml:"keyCacheWarmUp"`
+	KeyRotationGracePeriod   time.Duration                 `yaml:"keyRotationGracePeriod"`
+	ProxyTaskArchiveTTL      time.Duration                 `yaml:"proxyTaskArchiveTTL"`
+	DeadLetterTTL            time.Duration                 `yaml:"deadLetterTTL"`
+	TaskDedupTTL             time.Duration                 `yaml:"taskDedupTTL"`
+	DefaultOnSearchLocation  *model.Location               `yaml:"defaultOnSearchLocation"`
+	// ProxyTaskArchiveAPIEnabled serves the endpoints that read and replay the archived proxy tasks.
+	// Requires ProxyTaskArchiveTTL and QueueControlAuth.
+	ProxyTaskArchiveAPIEnabled bool `yaml:"proxyTaskArchiveAPIEnabled"`
+	// ExpiredSubscriptionPolicy is OFF, WARN or REJECT. Defaults to OFF.
+	ExpiredSubscriptionPolicy service.ExpiredSubscriptionPolicy `yaml:"expiredSubscriptionPolicy"`
+	// MaxConcurrentCallbacksPerTarget caps the in-flight proxy calls to each target host. 0 means no limit.
+	MaxConcurrentCallbacksPerTarget int `yaml:"maxConcurrentCallbacksPerTarget"`
+	// SignatureHeader is the header requests carry their signature in. Defaults to Authorization.
+	SignatureHeader string `yaml:"signatureHeader"`
+	// AllowedAlgorithms restricts the signature algorithms accepted network-wide, e.g. to ed25519. Empty accepts any.
+	AllowedAlgorithms []string `yaml:"allowedAlgorithms"`
+	// SignatureClockSkew is how far participant clocks may be off when the created and expires
+	// parameters of RSA, secp256k1 and header-bound signatures are checked. Defaults to service.DefaultSignatureClockSkew.
+	SignatureClockSkew time.Duration `yaml:"signatureClockSkew"`
+	// BindRequestID binds signatures to the X-Request-ID of their request, both of the transactions
+	// the gateway receives and of the requests it sends.
+	BindRequestID bool `yaml:"bindRequestID"`
+	// SkipLookupForSingleBPP proxies requests naming both a bpp_id and a bpp_uri without a registry lookup.
+	SkipLookupForSingleBPP bool `yaml:"skipLookupForSingleBPP"`
+	// StrictVersionMatch proxies requests only to the looked up BPPs advertising the request's version.
+	StrictVersionMatch bool `yaml:"strictVersionMatch"`
+	// TaskQueueRedis, if set, queues tasks in a Redis list instead of in memory, so that they survive restarts.
+	TaskQueueRedis *service.RedisTaskQueueConfig `yaml:"taskQueueRedis"`
+	// TargetURL configures how the targets of proxied requests are built from participant URIs.
+	TargetURL service.TargetURLConfig `yaml:"targetURL"`
+	// QueueControlEnabled serves the endpoints that pause and resume the task queue workers.
+	QueueControlEnabled bool `yaml:"queueControlEnabled"`
+	// QueueControlAuth authenticates the callers of the queue control endpoints. Required with QueueControlEnabled.
+	QueueControlAuth *oidcauth.Config `yaml:"queueControlAuth"`
+	// KeyCache caches the signing keys of participants in the gateway, refreshing hot keys before they expire.
+	KeyCache *service.KeyCacheConfig `yaml:"keyCache"`
+	// ForwardedHeaderLimits bounds the headers of a request forwarded to each target of its fan-out.
+	ForwardedHeaderLimits *service.HeaderLimits `yaml:"forwardedHeaderLimits"`
+	// NegativeLookupCacheTTL is how long lookups finding no subscribers are cached. 0 disables the cache.
+	NegativeLookupCacheTTL time.Duration `yaml:"negativeLookupCacheTTL"`
+	// StaleLookupMaxAge is how old a last-known lookup result served when the registry fails may be. 0 disables it.
+	StaleLookupMaxAge time.Duration `yaml:"staleLookupMaxAge"`
+	// SignatureFailureAlerts raises an alert when the transactions of a participant repeatedly fail signature validation.
+	SignatureFailureAlerts *service.SignatureFailureAlertConfig `yaml:"signatureFailureAlerts"`
+	// Event is the topic signature failure alerts are published to. Optional, alerts are only logged without it.
+	Event *event.Config `yaml:"event"`
+	// DependencyTimeouts sets the timeouts of all external dependencies in one place.
+	DependencyTimeouts *model.DependencyTimeouts `yaml:"dependencyTimeouts"`
 }
 
 type serverConfig struct {
@@ -85,6 +148,14 @@ func initConfig(filePath string) (*config, error) {
 	if err := cfg.valid(); err != nil {
 		return nil, err
 	}
+	warnings, err := cfg.checkConsistency()
+	if err != nil {
+		return nil, fmt.Errorf("inconsistent config: %w", err)
+	}
+	for _, w := range warnings {
+		slog.Warn("Config consistency check found a likely misconfiguration", "warning", w)
+	}
+	cfg.applyDependencyTimeouts()
 	return &cfg, nil
 }
 
@@ -102,6 +173,9 @@ func (c *config) valid() error {
 	if c.Timeouts == nil {
 		return fmt.Errorf("missing required config section: timeouts")
 	}
+	if err := c.DependencyTimeouts.Validate(); err != nil {
+		return err
+	}
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
@@ -120,6 +194,100 @@ func (c *config) valid() error {
 	if c.SubscriberID == "" {
 		return fmt.Errorf("missing subscriber ID")
 	}
+	if c.ProxyTasksPerSecond < 0 {
+		return fmt.Errorf("invalid proxyTasksPerSecond: %v", c.ProxyTasksPerSecond)
+	}
+	if c.MaxConcurrentLookups < 0 {
+		return fmt.Errorf("invalid maxConcurrentLookups: %d", c.MaxConcurrentLookups)
+	}
+	if c.ProxyDispatchConcurrency < 0 {
+		return fmt.Errorf("invalid proxyDispatchConcurrency: %d", c.ProxyDispatchConcurrency)
+	}
+	if c.KeyRotationGracePeriod < 0 {
+		return fmt.Errorf("invalid keyRotationGracePeriod: %s", c.KeyRotationGracePeriod)
+	}
+	if c.KeyCache != nil {
+		if err := c.KeyCache.Validate(); err != nil {
+			return fmt.Errorf("invalid keyCache: %w", err)
+		}
+	}
+	if c.ProxyTaskArchiveTTL < 0 {
+		return fmt.Errorf("invalid proxyTaskArchiveTTL: %s", c.ProxyTaskArchiveTTL)
+	}
+	if c.DeadLetterTTL < 0 {
+		return fmt.Errorf("invalid deadLetterTTL: %s", c.DeadLetterTTL)
+	}
+	if c.TaskDedupTTL < 0 {
+		return fmt.Errorf("invalid taskDedupTTL: %s", c.TaskDedupTTL)
+	}
+	if c.ForwardedHeaderLimits != nil {
+		if err := c.ForwardedHeaderLimits.Validate(); err != nil {
+			return fmt.Errorf("invalid forwardedHeaderLimits: %w", err)
+		}
+	}
+	if c.NegativeLookupCacheTTL < 0 {
+		return fmt.Errorf("invalid negativeLookupCacheTTL: %s", c.NegativeLookupCacheTTL)
+	}
+	if c.StaleLookupMaxAge < 0 {
+		return fmt.Errorf("invalid staleLookupMaxAge: %s", c.StaleLookupMaxAge)
+	}
+	if c.SignatureFailureAlerts != nil {
+		if err := c.SignatureFailureAlerts.Validate(); err != nil {
+			return fmt.Errorf("invalid signatureFailureAlerts: %w", err)
+		}
+	}
+	if c.TaskQueueMaxBytes < 0 {
+		return fmt.Errorf("invalid taskQueueMaxBytes: %d", c.TaskQueueMaxBytes)
+	}
+	if c.TaskQueueMaxRetries < 0 {
+		return fmt.Errorf("invalid taskQueueMaxRetries: %d", c.TaskQueueMaxRetries)
+	}
+	if c.TaskQueueRetryDelay < 0 {
+		return fmt.Errorf("invalid taskQueueRetryDelay: %s", c.TaskQueueRetryDelay)
+	}
+	if c.TaskQueueRedis != nil {
+		if err := c.TaskQueueRedis.Validate(); err != nil {
+			return fmt.Errorf("invalid taskQueueRedis: %w", err)
+		}
+		// These options only apply to the in-memory task queue.
+		for option, set := range map[string]bool{
+			"taskQueueMaxBytes":   c.TaskQueueMaxBytes > 0,
+			"taskQueueDropOnFull": c.TaskQueueDropOnFull,
+			"taskQueueMaxRetries": c.TaskQueueMaxRetries > 0,
+			"taskDedupTTL":        c.TaskDedupTTL > 0,
+			"queueControlEnabled": c.QueueControlEnabled,
+		} {
+			if set {
+				return fmt.Errorf("taskQueueRedis cannot be combined with %s", option)
+			}
+		}
+	}
+	if c.QueueControlEnabled && c.QueueControlAuth == nil {
+		return fmt.Errorf("queueControlEnabled requires queueControlAuth")
+	}
+	if c.ProxyTaskArchiveAPIEnabled && c.ProxyTaskArchiveTTL == 0 {
+		return fmt.Errorf("proxyTaskArchiveAPIEnabled requires proxyTaskArchiveTTL")
+	}
+	if c.ProxyTaskArchiveAPIEnabled && c.QueueControlAuth == nil {
+		return fmt.Errorf("proxyTaskArchiveAPIEnabled requires queueControlAuth")
+	}
+	if !c.ExpiredSubscriptionPolicy.Valid() {
+		return fmt.Errorf("invalid expiredSubscriptionPolicy: %q, must be one of OFF, WARN, REJECT", c.ExpiredSubscriptionPolicy)
+	}
+	if c.MaxConcurrentCallbacksPerTarget < 0 {
+		return fmt.Errorf("invalid maxConcurrentCallbacksPerTarget: %d", c.MaxConcurrentCallbacksPerTarget)
+	}
+	if c.SignatureHeader != "" && !model.ValidHeaderName(c.SignatureHeader) {
+		return fmt.Errorf("invalid signatureHeader: %q", c.SignatureHeader)
+	}
+	for _, alg := range c.AllowedAlgorithms {
+		if strings.TrimSpace(alg) == "" {
+			return fmt.Errorf("allowedAlgorithms must not contain empty algorithms")
+		}
+	}
+	if c.SignatureClockSkew < 0 {
+		return fmt.Errorf("signatureClockSkew must not be negative")
+	}
 	if c.HTTPClientRetry == nil {
 		slog.Warn("Config validation: httpClientRetry section missing, using default retry values.")
 		// Provide default values or handle as an error if strict config is required
@@ -134,6 +302,79 @@ func (c *config) valid() error {
 	return nil
 }
 
+// checkConsistency cross-checks the registry URL, the retry waits and the key cache warm-up
+// entries. It returns an error for values the gateway cannot run with, and warnings for
+// warm-up entries of the gateway itself, whose keys it never looks up.
+func (c *config) checkConsistency() ([]string, error) {
+	var warnings []string
+	regURL, err := url.Parse(c.Registry.BaseURL)
+	if err != nil || regURL.Scheme == "" || regURL.Host == "" {
+		return nil, fmt.Errorf("registry base URL %q must be an absolute URL", c.Registry.BaseURL)
+	}
+	if c.HTTPClientRetry.RetryWaitMax > 0 && c.HTTPClientRetry.RetryWaitMin > c.HTTPClientRetry.RetryWaitMax {
+		return nil, fmt.Errorf("httpClientRetry waitMin %s is greater than waitMax %s", c.HTTPClientRetry.RetryWaitMin, c.HTTPClientRetry.RetryWaitMax)
+	}
+	for i, e := range c.KeyCacheWarmUp {
+		if e.SubscriberID == "" || e.KeyID == "" {
+			return nil, fmt.Errorf("keyCacheWarmUp[%d] must set both subscriberID and keyID", i)
+		}
+		if e.SubscriberID == c.SubscriberID {
+			warnings = append(warnings, fmt.Sprintf("keyCacheWarmUp[%d] refers to the gateway's own subscriberID %q", i, e.SubscriberID))
+		}
+	}
+	return warnings, nil
+}
+
+// features returns the optional features of the gateway and whether the config enables them,
+// as reported in its capability manifest.
+func (c *config) features() map[string]bool {
+	return map[string]bool{
+		"bind_request_id":            c.BindRequestID,
+		"skip_lookup_for_single_bpp": c.SkipLookupForSingleBPP,
+		"queue_control":              c.QueueControlEnabled,
+		"task_dedup":                 c.TaskDedupTTL > 0,
+		"proxy_task_archive":         c.ProxyTaskArchiveTTL > 0,
+		"proxy_task_archive_api":     c.ProxyTaskArchiveAPIEnabled,
+		"dead_letter_sink":           c.DeadLetterTTL > 0,
+		"target_url_drop_query":      c.TargetURL.DropQuery,
+		"target_url_trailing_slash":  c.TargetURL.TrailingSlash,
+		"proxy_rate_limit":           c.ProxyTasksPerSecond > 0,
+		"callback_concurrency_limit": c.MaxConcurrentCallbacksPerTarget > 0,
+		"key_rotation_grace_period":  c.KeyRotationGracePeriod > 0,
+		"expired_subscription_check": c.ExpiredSubscriptionPolicy == service.ExpiredSubscriptionPolicyWarn || c.ExpiredSubscriptionPolicy == service.ExpiredSubscriptionPolicyReject,
+		"default_on_search_location": c.DefaultOnSearchLocation != nil,
+		"negative_lookup_cache":      c.NegativeLookupCacheTTL > 0,
+		"stale_lookup_on_error":      c.StaleLookupMaxAge > 0,
+		"key_cache_refresh":          c.KeyCache != nil && c.KeyCache.MaxAge > 0 && c.KeyCache.RefreshBefore > 0,
+		"forwarded_header_limits":    c.ForwardedHeaderLimits != nil && (c.ForwardedHeaderLimits.MaxBytes > 0 || c.ForwardedHeaderLimits.MaxCount > 0),
+		"signature_failure_alerts":   c.SignatureFailureAlerts != nil && c.SignatureFailureAlerts.Threshold > 0,
+		"task_queue_drop_on_full":    c.TaskQueueDropOnFull,
+		"task_queue_redis":           c.TaskQueueRedis != nil,
+		"lookup_concurrency_limit":   c.MaxConcurrentLookups > 0,
+		"task_retries":               c.TaskQueueMaxRetries > 0,
+		"concurrent_proxy_dispatch":  c.ProxyDispatchConcurrency > 1,
+		"strict_version_match":       c.StrictVersionMatch,
+		"allowed_algorithms":         len(c.AllowedAlgorithms) > 0,
+	}
+}
+
+// signatureAlgorithms returns the signature algorithms the gateway accepts: the supported ones,
+// restricted to allowedAlgorithms if set.
+func (c *config) signatureAlgorithms() []string {
+	algs := []string{}
+	for _, alg := range model.SupportedSignatureAlgorithms() {
+		if len(c.AllowedAlgorithms) == 0 || slices.ContainsFunc(c.AllowedAlgorithms, func(a string) bool { return strings.EqualFold(a, alg) }) {
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// cacheConfig returns the configuration of the Redis cache.
+func (c *config) cacheConfig() map[string]string {
+	return map[string]string{"addr": c.RedisAddr, "timeout": c.DependencyTimeouts.WithDefaults().Cache.String()}
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -157,7 +398,7 @@ func run(ctx context.Context) error {
 		}()
 	}
 
-	redis, closeRedis, err := rediscache.New(ctx, map[string]string{"addr": cfg.RedisAddr})
+	redis, closeRedis, err := rediscache.New(ctx, cfg.cacheConfig())
 	if err != nil {
 		return fmt.Errorf("failed to create redis cache: %w", err)
 	}
@@ -192,47 +433,189 @@ func run(ctx context.Context) error {
 	}
 
 	// Initialize TxnSignValidator
-	txnValidator, err := service.NewTxnSignValidator(sv, km)
+	algSV, err := service.NewAlgSignValidator(sv)
+	if err != nil {
+		return fmt.Errorf("failed to create sign validator: %w", err)
+	}
+	if cfg.SignatureClockSkew > 0 {
+		algSV.SetClockSkew(cfg.SignatureClockSkew)
+	}
+	txnValidator, err := service.NewTxnSignValidator(algSV, km)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction sign validator: %w", err)
 	}
+	txnValidator.SetKeyRotationGracePeriod(cfg.KeyRotationGracePeriod)
+	txnValidator.SetKeyRotationStore(redis)
+	txnValidator.SetSignatureHeader(cfg.SignatureHeader)
+	txnValidator.SetAllowedAlgorithms(cfg.AllowedAlgorithms)
+	if cfg.SignatureClockSkew > 0 {
+		txnValidator.SetClockSkew(cfg.SignatureClockSkew)
+	}
+	txnValidator.SetBindRequestID(cfg.BindRequestID)
+	if cfg.KeyCache != nil {
+		txnValidator.SetKeyCache(*cfg.KeyCache)
+	}
+	if sfa := cfg.SignatureFailureAlerts; sfa != nil {
+		if cfg.Event != nil {
+			evPub, closeEvPub, err := event.NewPublisher(ctx, cfg.Event)
+			if err != nil {
+				return fmt.Errorf("failed to create event publisher: %w", err)
+			}
+			defer closeEvPub()
+			txnValidator.SetSignatureFailureAlerts(*sfa, evPub)
+		} else {
+			txnValidator.SetSignatureFailureAlerts(*sfa, nil)
+		}
+	}
+	if len(cfg.KeyCacheWarmUp) > 0 {
+		// Warm-up is best-effort and must not delay server startup.
+		go txnValidator.WarmUp(ctx, cfg.KeyCacheWarmUp)
+	}
 
 	authGen, err := service.NewAuthGenService(km, signer)
 	if err != nil {
 		return fmt.Errorf("failed to create auth gen service: %w", err)
 	}
+	authGen.SetBindRequestID(cfg.BindRequestID)
 
 	pTaskProcessor, err := service.NewProxyTaskProcessor(authGen, cfg.SubscriberID, *cfg.HTTPClientRetry)
 	if err != nil {
 		return fmt.Errorf("failed to create proxy task processor: %w", err)
 	}
+	metricsCollector := metrics.NewCollector()
+	pTaskProcessor.SetMetrics(metricsCollector)
+	if cfg.ProxyTaskArchiveTTL > 0 {
+		archive, err := service.NewCacheTaskArchive(redis, cfg.ProxyTaskArchiveTTL)
+		if err != nil {
+			return fmt.Errorf("failed to create proxy task archive: %w", err)
+		}
+		pTaskProcessor.SetTaskArchive(archive)
+	}
 	registryClient, err := client.NewRegistryClient(cfg.Registry)
 	if err != nil {
 		return fmt.Errorf("failed to create registry client: %w", err)
 	}
-	channelTaskQ, err := service.NewChannelTaskQueue(ctx, cfg.TaskQueueWorkersCount, pTaskProcessor, nil, cfg.TaskQueueBufferSize) // Lookup processor will be set later
-	if err != nil {
-		return fmt.Errorf("failed to create channel task queue: %w", err)
+	pTaskProcessor.SetExpiredSubscriptionGuard(registryClient, cfg.ExpiredSubscriptionPolicy)
+	callbackLimiter := service.NewCallbackLimiter(cfg.MaxConcurrentCallbacksPerTarget)
+	pTaskProcessor.SetCallbackLimiter(callbackLimiter)
+	var deadLetters service.DeadLetterSink
+	if cfg.DeadLetterTTL > 0 {
+		deadLetters, err = service.NewCacheDeadLetterSink(redis, cfg.DeadLetterTTL)
+		if err != nil {
+			return fmt.Errorf("failed to create dead-letter sink: %w", err)
+		}
 	}
-	channelTaskQ.StartWorkers()
-	defer channelTaskQ.StopWorkers() // Add to graceful shutdown logic
-
-	lTaskProcessor, err := service.NewChannelLookupProcessor(registryClient, authGen, channelTaskQ, cfg.SubscriberID, cfg.MaxConcurrentFanoutTasks)
+	// Tasks are queued in memory, or in Redis if they must survive restarts.
+	var (
+		taskQ interface {
+			QueueTxn(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header) (*model.AsyncTask, error)
+			Depth() int
+		}
+		channelTaskQ *service.ChannelTaskQueue
+		redisTaskQ   *service.RedisTaskQueue
+	)
+	if cfg.TaskQueueRedis != nil {
+		redisTaskQ, err = service.NewRedisTaskQueue(ctx, cfg.TaskQueueWorkersCount, pTaskProcessor, nil, redis, *cfg.TaskQueueRedis) // Lookup processor will be set later
+		if err != nil {
+			return fmt.Errorf("failed to create redis task queue: %w", err)
+		}
+		redisTaskQ.SetDeadLetterSink(deadLetters)
+		redisTaskQ.SetTargetURLConfig(cfg.TargetURL)
+		redisTaskQ.SetDefaultOnSearchLocation(cfg.DefaultOnSearchLocation)
+		taskQ = redisTaskQ
+	} else {
+		channelTaskQ, err = service.NewChannelTaskQueue(ctx, cfg.TaskQueueWorkersCount, pTaskProcessor, nil, cfg.TaskQueueBufferSize, cfg.TaskQueueDropOnFull) // Lookup processor will be set later
+		if err != nil {
+			return fmt.Errorf("failed to create channel task queue: %w", err)
+		}
+		if cfg.TaskDedupTTL > 0 {
+			channelTaskQ.SetDeduplication(redis, cfg.TaskDedupTTL)
+		}
+		channelTaskQ.SetDefaultOnSearchLocation(cfg.DefaultOnSearchLocation)
+		channelTaskQ.SetMaxQueuedBytes(cfg.TaskQueueMaxBytes)
+		channelTaskQ.SetTaskRetries(cfg.TaskQueueMaxRetries, cfg.TaskQueueRetryDelay)
+		channelTaskQ.SetDeadLetterSink(deadLetters)
+		channelTaskQ.SetTargetURLConfig(cfg.TargetURL)
+		metricsCollector.SetGauge("task_queue_bytes", func() float64 { return float64(channelTaskQ.QueuedBytes()) })
+		metricsCollector.SetGauge("task_dedup_duplicates_dropped", func() float64 { return float64(channelTaskQ.DuplicatesDropped()) })
+		metricsCollector.SetGauge("task_queue_tasks_dropped", func() float64 { return float64(channelTaskQ.TasksDropped()) })
+		channelTaskQ.StartWorkers()
+		defer channelTaskQ.StopWorkers() // Add to graceful shutdown logic
+		taskQ = channelTaskQ
+	}
+	metricsCollector.SetGauge("task_queue_depth", func() float64 { return float64(taskQ.Depth()) })
+
+	lTaskProcessor, err := service.NewChannelLookupProcessor(registryClient, authGen, taskQ, cfg.SubscriberID, cfg.MaxConcurrentFanoutTasks, cfg.ProxyDispatchConcurrency)
 	if err != nil {
 		return fmt.Errorf("failed to create lookup task processor: %w", err)
 	}
-	channelTaskQ.SetLookupProcessor(lTaskProcessor)
+	lTaskProcessor.SetProxyTaskRateLimit(cfg.ProxyTasksPerSecond)
+	lTaskProcessor.SetMaxConcurrentLookups(cfg.MaxConcurrentLookups)
+	lTaskProcessor.SetExpiredSubscriptionPolicy(cfg.ExpiredSubscriptionPolicy)
+	lTaskProcessor.SetCallbackLimiter(callbackLimiter)
+	lTaskProcessor.SetSkipLookupForSingleBPP(cfg.SkipLookupForSingleBPP)
+	lTaskProcessor.SetStrictVersionMatch(cfg.StrictVersionMatch)
+	lTaskProcessor.SetNegativeLookupCacheTTL(cfg.NegativeLookupCacheTTL)
+	lTaskProcessor.SetStaleLookupMaxAge(cfg.StaleLookupMaxAge)
+	if cfg.ForwardedHeaderLimits != nil {
+		lTaskProcessor.SetForwardedHeaderLimits(*cfg.ForwardedHeaderLimits, cfg.SignatureHeader)
+	}
+	if redisTaskQ != nil {
+		redisTaskQ.SetLookupProcessor(lTaskProcessor)
+		// Workers start once both processors are set, since tasks left by a previous run are processed right away.
+		redisTaskQ.StartWorkers()
+		defer redisTaskQ.StopWorkers()
+	} else {
+		channelTaskQ.SetLookupProcessor(lTaskProcessor)
+	}
 
 	// Initialize Gateway Handler
-	gwHandler, err := handler.NewGatewayHandler(txnValidator, channelTaskQ)
+	gwHandler, err := handler.NewGatewayHandler(txnValidator, taskQ)
 	if err != nil {
 		return fmt.Errorf("failed to create gateway handler: %w", err)
 	}
+	gwHandler.SetSignatureHeader(cfg.SignatureHeader)
+
+	// The router takes a nil interface, not a nil *ChannelTaskQueue, when queue control is disabled.
+	var queueControl interface {
+		Pause()
+		Resume()
+		Paused() bool
+		Depth() int
+	}
+	var queueAuth func(http.Handler) http.Handler
+	if cfg.QueueControlEnabled {
+		queueControl = channelTaskQ
+	}
+	if cfg.QueueControlEnabled || cfg.ProxyTaskArchiveAPIEnabled {
+		// Remove leading and trailing whitespace from allowed issuers and service accounts.
+		for i, iss := range cfg.QueueControlAuth.AllowedIssuers {
+			cfg.QueueControlAuth.AllowedIssuers[i] = strings.TrimSpace(iss)
+		}
+		for i, sa := range cfg.QueueControlAuth.AllowedSAs {
+			cfg.QueueControlAuth.AllowedSAs[i] = strings.TrimSpace(sa)
+		}
+		queueAuth, err = oidcauth.New(ctx, cfg.QueueControlAuth)
+		if err != nil {
+			return fmt.Errorf("failed to create queue control auth middleware: %w", err)
+		}
+	}
+	// As for queue control, the router takes a nil interface when the archive API is disabled.
+	var archiveAPI interface {
+		Records(ctx context.Context, transactionID, messageID string) ([]service.ProxyTaskRecord, error)
+		Replay(ctx context.Context, transactionID, messageID, target string) error
+	}
+	if cfg.ProxyTaskArchiveAPIEnabled {
+		archiveAPI, err = service.NewTaskArchiveReader(redis, pTaskProcessor)
+		if err != nil {
+			return fmt.Errorf("failed to create proxy task archive reader: %w", err)
+		}
+	}
 
 	// Initialize HTTP Server
 	server := &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      gateway.NewRouter(gwHandler),
+		Handler:      gateway.NewRouter(gwHandler, metricsCollector, queueControl, queueAuth, archiveAPI, cfg.features(), cfg.signatureAlgorithms()),
 		ReadTimeout:  cfg.Timeouts.Read,
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
@@ -271,6 +654,15 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+// applyDependencyTimeouts applies the dependencyTimeouts section to the clients of the gateway.
+func (c *config) applyDependencyTimeouts() {
+	timeouts := model.ClientTimeouts{Registry: &c.Registry.Timeout, NPCallback: &c.HTTPClientRetry.Timeout}
+	if c.Event != nil {
+		timeouts.Event = &c.Event.Timeout
+	}
+	c.DependencyTimeouts.Apply(timeouts)
+}
+
 var configPath string
 
 func main() {
@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +24,8 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/oidcauth"
 )
 
 func TestConfig_Valid_Success(t *testing.T) {
@@ -280,6 +283,478 @@ func TestConfig_Valid_Error(t *testing.T) {
 			},
 			expectedError: "missing subscriber ID",
 		},
+		{
+			name: "negative proxyTasksPerSecond",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				ProxyTasksPerSecond:      -1,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+			},
+			expectedError: "invalid proxyTasksPerSecond: -1",
+		},
+		{
+			name: "negative keyRotationGracePeriod",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				KeyRotationGracePeriod:   -time.Minute,
+			},
+			expectedError: "invalid keyRotationGracePeriod: -1m0s",
+		},
+		{
+			name: "negative proxyTaskArchiveTTL",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				ProxyTaskArchiveTTL:      -time.Hour,
+			},
+			expectedError: "invalid proxyTaskArchiveTTL: -1h0m0s",
+		},
+		{
+			name: "negative deadLetterTTL",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				DeadLetterTTL:            -time.Hour,
+			},
+			expectedError: "invalid deadLetterTTL: -1h0m0s",
+		},
+		{
+			name: "negative taskDedupTTL",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskDedupTTL:             -time.Second,
+			},
+			expectedError: "invalid taskDedupTTL: -1s",
+		},
+		{
+			name: "invalid keyCache",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				KeyCache:                 &service.KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: time.Hour},
+			},
+			expectedError: "invalid keyCache: refreshBefore 1h0m0s must not be negative and must be less than maxAge 1m0s",
+		},
+		{
+			name: "invalid forwardedHeaderLimits",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				ForwardedHeaderLimits:    &service.HeaderLimits{MaxBytes: 1024, Policy: "DROP"},
+			},
+			expectedError: `invalid forwardedHeaderLimits: policy "DROP" must be one of REJECT, TRUNCATE`,
+		},
+		{
+			name: "invalid signatureFailureAlerts",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				SignatureFailureAlerts:   &service.SignatureFailureAlertConfig{Threshold: 5},
+			},
+			expectedError: "invalid signatureFailureAlerts: window 0s must be positive",
+		},
+		{
+			name: "negative negativeLookupCacheTTL",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				NegativeLookupCacheTTL:   -time.Second,
+			},
+			expectedError: "invalid negativeLookupCacheTTL: -1s",
+		},
+		{
+			name: "negative staleLookupMaxAge",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				StaleLookupMaxAge:        -time.Second,
+			},
+			expectedError: "invalid staleLookupMaxAge: -1s",
+		},
+		{
+			name: "negative taskQueueMaxBytes",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueMaxBytes:        -1,
+			},
+			expectedError: "invalid taskQueueMaxBytes: -1",
+		},
+		{
+			name: "negative taskQueueMaxRetries",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueMaxRetries:      -1,
+			},
+			expectedError: "invalid taskQueueMaxRetries: -1",
+		},
+		{
+			name: "negative taskQueueRetryDelay",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueRetryDelay:      -time.Second,
+			},
+			expectedError: "invalid taskQueueRetryDelay: -1s",
+		},
+		{
+			name: "negative maxConcurrentLookups",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				MaxConcurrentLookups:     -1,
+			},
+			expectedError: "invalid maxConcurrentLookups: -1",
+		},
+		{
+			name: "negative proxyDispatchConcurrency",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				ProxyDispatchConcurrency: -1,
+			},
+			expectedError: "invalid proxyDispatchConcurrency: -1",
+		},
+		{
+			name: "invalid taskQueueRedis",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueRedis:           &service.RedisTaskQueueConfig{MaxRetries: -1},
+			},
+			expectedError: "invalid taskQueueRedis: maxRetries -1 must not be negative",
+		},
+		{
+			name: "taskQueueRedis with taskQueueDropOnFull",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueRedis:           &service.RedisTaskQueueConfig{},
+				TaskQueueDropOnFull:      true,
+			},
+			expectedError: "taskQueueRedis cannot be combined with taskQueueDropOnFull",
+		},
+		{
+			name: "taskQueueRedis with queueControlEnabled",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				TaskQueueRedis:           &service.RedisTaskQueueConfig{},
+				QueueControlEnabled:      true,
+			},
+			expectedError: "taskQueueRedis cannot be combined with queueControlEnabled",
+		},
+		{
+			name: "queueControlEnabled without queueControlAuth",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				QueueControlEnabled:      true,
+			},
+			expectedError: "queueControlEnabled requires queueControlAuth",
+		},
+		{
+			name: "proxyTaskArchiveAPIEnabled without proxyTaskArchiveTTL",
+			cfg: &config{
+				Log:                        validLogCfg,
+				Timeouts:                   validTimeoutsCfg,
+				Server:                     validServerCfg,
+				ProjectID:                  "proj",
+				Registry:                   validRegistryCfg,
+				RedisAddr:                  "redis",
+				MaxConcurrentFanoutTasks:   10,
+				TaskQueueWorkersCount:      5,
+				TaskQueueBufferSize:        100,
+				SubscriberID:               "sub-id",
+				HTTPClientRetry:            validRetryCfg,
+				QueueControlAuth:           &oidcauth.Config{},
+				ProxyTaskArchiveAPIEnabled: true,
+			},
+			expectedError: "proxyTaskArchiveAPIEnabled requires proxyTaskArchiveTTL",
+		},
+		{
+			name: "proxyTaskArchiveAPIEnabled without queueControlAuth",
+			cfg: &config{
+				Log:                        validLogCfg,
+				Timeouts:                   validTimeoutsCfg,
+				Server:                     validServerCfg,
+				ProjectID:                  "proj",
+				Registry:                   validRegistryCfg,
+				RedisAddr:                  "redis",
+				MaxConcurrentFanoutTasks:   10,
+				TaskQueueWorkersCount:      5,
+				TaskQueueBufferSize:        100,
+				SubscriberID:               "sub-id",
+				HTTPClientRetry:            validRetryCfg,
+				ProxyTaskArchiveTTL:        time.Hour,
+				ProxyTaskArchiveAPIEnabled: true,
+			},
+			expectedError: "proxyTaskArchiveAPIEnabled requires queueControlAuth",
+		},
+		{
+			name: "unknown expiredSubscriptionPolicy",
+			cfg: &config{
+				Log:                       validLogCfg,
+				Timeouts:                  validTimeoutsCfg,
+				Server:                    validServerCfg,
+				ProjectID:                 "proj",
+				Registry:                  validRegistryCfg,
+				RedisAddr:                 "redis",
+				MaxConcurrentFanoutTasks:  10,
+				TaskQueueWorkersCount:     5,
+				TaskQueueBufferSize:       100,
+				SubscriberID:              "sub-id",
+				HTTPClientRetry:           validRetryCfg,
+				ExpiredSubscriptionPolicy: "DROP",
+			},
+			expectedError: `invalid expiredSubscriptionPolicy: "DROP", must be one of OFF, WARN, REJECT`,
+		},
+		{
+			name: "negative maxConcurrentCallbacksPerTarget",
+			cfg: &config{
+				Log:                             validLogCfg,
+				Timeouts:                        validTimeoutsCfg,
+				Server:                          validServerCfg,
+				ProjectID:                       "proj",
+				Registry:                        validRegistryCfg,
+				RedisAddr:                       "redis",
+				MaxConcurrentFanoutTasks:        10,
+				TaskQueueWorkersCount:           5,
+				TaskQueueBufferSize:             100,
+				SubscriberID:                    "sub-id",
+				HTTPClientRetry:                 validRetryCfg,
+				MaxConcurrentCallbacksPerTarget: -1,
+			},
+			expectedError: "invalid maxConcurrentCallbacksPerTarget: -1",
+		},
+		{
+			name: "invalid signatureHeader",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				SignatureHeader:          "X Signature",
+			},
+			expectedError: `invalid signatureHeader: "X Signature"`,
+		},
+		{
+			name: "empty allowedAlgorithms entry",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				AllowedAlgorithms:        []string{"ed25519", " "},
+			},
+			expectedError: "allowedAlgorithms must not contain empty algorithms",
+		},
+		{
+			name: "negative signatureClockSkew",
+			cfg: &config{
+				Log:                      validLogCfg,
+				Timeouts:                 validTimeoutsCfg,
+				Server:                   validServerCfg,
+				ProjectID:                "proj",
+				Registry:                 validRegistryCfg,
+				RedisAddr:                "redis",
+				MaxConcurrentFanoutTasks: 10,
+				TaskQueueWorkersCount:    5,
+				TaskQueueBufferSize:      100,
+				SubscriberID:             "sub-id",
+				HTTPClientRetry:          validRetryCfg,
+				SignatureClockSkew:       -time.Second,
+			},
+			expectedError: "signatureClockSkew must not be negative",
+		},
 		{
 			name: "nil HTTPClientRetry (should not error, but set defaults)",
 			cfg: &config{
@@ -327,6 +802,62 @@ func TestConfig_Valid_Error(t *testing.T) {
 	}
 }
 
+func TestConfig_ApplyDependencyTimeouts(t *testing.T) {
+	tests := []struct {
+		name            string
+		deps            *model.DependencyTimeouts
+		registryTimeout time.Duration
+		proxyTimeout    time.Duration
+		wantRegistry    time.Duration
+		wantProxy       time.Duration
+		wantCache       string
+	}{
+		{
+			name:         "central defaults when unset",
+			wantRegistry: model.DefaultRegistryTimeout,
+			wantProxy:    model.DefaultNPCallbackTimeout,
+			wantCache:    model.DefaultCacheTimeout.String(),
+		},
+		{
+			name:         "central timeouts",
+			deps:         &model.DependencyTimeouts{Registry: 5 * time.Second, NPCallback: 6 * time.Second, Cache: time.Second},
+			wantRegistry: 5 * time.Second,
+			wantProxy:    6 * time.Second,
+			wantCache:    "1s",
+		},
+		{
+			name:            "client timeouts override central timeouts",
+			deps:            &model.DependencyTimeouts{Registry: 5 * time.Second, NPCallback: 6 * time.Second},
+			registryTimeout: 2 * time.Second,
+			proxyTimeout:    3 * time.Second,
+			wantRegistry:    2 * time.Second,
+			wantProxy:       3 * time.Second,
+			wantCache:       model.DefaultCacheTimeout.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				DependencyTimeouts: tt.deps,
+				Registry:           &client.RegistryClientConfig{Timeout: tt.registryTimeout},
+				HTTPClientRetry:    &service.RetryConfig{Timeout: tt.proxyTimeout},
+				RedisAddr:          "redis:6379",
+			}
+			cfg.applyDependencyTimeouts()
+			if cfg.Registry.Timeout != tt.wantRegistry {
+				t.Errorf("Registry.Timeout = %s, want %s", cfg.Registry.Timeout, tt.wantRegistry)
+			}
+			if cfg.HTTPClientRetry.Timeout != tt.wantProxy {
+				t.Errorf("HTTPClientRetry.Timeout = %s, want %s", cfg.HTTPClientRetry.Timeout, tt.wantProxy)
+			}
+			if got := cfg.cacheConfig(); got["addr"] != "redis:6379" || got["timeout"] != tt.wantCache {
+				t.Errorf("cacheConfig() = %v, want addr redis:6379 and timeout %s", got, tt.wantCache)
+			}
+		})
+	}
+}
+
 func TestRun_InitializationErrors(t *testing.T) {
 	// Save the original configPath and restore it after the test
 	oldPath := configPath
@@ -434,3 +965,163 @@ func TestInitConfig_UnmarshalFailure(t *testing.T) {
 		t.Errorf("initConfig(%q) = %v, want error containing %q", path, err, "failed to unmarshal config data")
 	}
 }
+
+func TestConfig_CheckConsistency(t *testing.T) {
+	newCfg := func() *config {
+		return &config{
+			Registry:        &client.RegistryClientConfig{BaseURL: "https://registry.beckn.org"},
+			SubscriberID:    "gateway.beckn.org",
+			HTTPClientRetry: &service.RetryConfig{RetryWaitMin: time.Second, RetryWaitMax: 5 * time.Second},
+			KeyCacheWarmUp: []service.KeyCacheWarmUpEntry{
+				{SubscriberID: "bpp.beckn.org", KeyID: "bpp-key"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		mutate       func(*config)
+		wantWarnings []string
+	}{
+		{
+			name:   "consistent config",
+			mutate: func(*config) {},
+		},
+		{
+			name: "warm-up entry for own subscriberID",
+			mutate: func(c *config) {
+				c.KeyCacheWarmUp = append(c.KeyCacheWarmUp, service.KeyCacheWarmUpEntry{SubscriberID: "gateway.beckn.org", KeyID: "gw-key"})
+			},
+			wantWarnings: []string{`keyCacheWarmUp[1] refers to the gateway's own subscriberID "gateway.beckn.org"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCfg()
+			tt.mutate(cfg)
+			warnings, err := cfg.checkConsistency()
+			if err != nil {
+				t.Fatalf("checkConsistency() error = %v, want nil", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("checkConsistency() warnings = %q, want %d warnings", warnings, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("checkConsistency() warning[%d] = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_CheckConsistency_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *config
+		wantErr string
+	}{
+		{
+			name: "relative registry URL",
+			cfg: &config{
+				Registry:        &client.RegistryClientConfig{BaseURL: "registry.beckn.org"},
+				HTTPClientRetry: &service.RetryConfig{},
+			},
+			wantErr: "must be an absolute URL",
+		},
+		{
+			name: "retry waitMin greater than waitMax",
+			cfg: &config{
+				Registry:        &client.RegistryClientConfig{BaseURL: "https://registry.beckn.org"},
+				HTTPClientRetry: &service.RetryConfig{RetryWaitMin: 10 * time.Second, RetryWaitMax: time.Second},
+			},
+			wantErr: "waitMin 10s is greater than waitMax 1s",
+		},
+		{
+			name: "warm-up entry without keyID",
+			cfg: &config{
+				Registry:        &client.RegistryClientConfig{BaseURL: "https://registry.beckn.org"},
+				HTTPClientRetry: &service.RetryConfig{},
+				KeyCacheWarmUp:  []service.KeyCacheWarmUpEntry{{SubscriberID: "bpp.beckn.org"}},
+			},
+			wantErr: "keyCacheWarmUp[0] must set both subscriberID and keyID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.checkConsistency()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkConsistency() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Features(t *testing.T) {
+	cfg := &config{
+		BindRequestID:             true,
+		TaskDedupTTL:              time.Minute,
+		ExpiredSubscriptionPolicy: service.ExpiredSubscriptionPolicyWarn,
+		TaskQueueDropOnFull:       true,
+		TargetURL:                 service.TargetURLConfig{TrailingSlash: true},
+	}
+	want := map[string]bool{
+		"bind_request_id":            true,
+		"skip_lookup_for_single_bpp": false,
+		"queue_control":              false,
+		"task_dedup":                 true,
+		"proxy_task_archive":         false,
+		"proxy_task_archive_api":     false,
+		"dead_letter_sink":           false,
+		"target_url_drop_query":      false,
+		"target_url_trailing_slash":  true,
+		"proxy_rate_limit":           false,
+		"callback_concurrency_limit": false,
+		"key_rotation_grace_period":  false,
+		"expired_subscription_check": true,
+		"default_on_search_location": false,
+		"negative_lookup_cache":      false,
+		"stale_lookup_on_error":      false,
+		"key_cache_refresh":          false,
+		"forwarded_header_limits":    false,
+		"signature_failure_alerts":   false,
+		"task_queue_drop_on_full":    true,
+		"task_queue_redis":           false,
+		"lookup_concurrency_limit":   false,
+		"task_retries":               false,
+		"concurrent_proxy_dispatch":  false,
+		"strict_version_match":       false,
+		"allowed_algorithms":         false,
+	}
+	got := cfg.features()
+	if len(got) != len(want) {
+		t.Errorf("features() = %v, want %v", got, want)
+	}
+	for name, enabled := range want {
+		if got[name] != enabled {
+			t.Errorf("features()[%q] = %t, want %t", name, got[name], enabled)
+		}
+	}
+}
+
+func TestConfig_SignatureAlgorithms(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{name: "all supported by default", want: model.SupportedSignatureAlgorithms()},
+		{name: "restricted to the allowed ones", allowed: []string{"ED25519", "secp256k1"}, want: []string{model.SignatureAlgorithmEd25519, model.SignatureAlgorithmSecp256k1}},
+		{name: "none supported allowed", allowed: []string{"dsa"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{AllowedAlgorithms: tt.allowed}
+			if got := cfg.signatureAlgorithms(); !slices.Equal(got, tt.want) {
+				t.Errorf("signatureAlgorithms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -25,28 +25,68 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/registry/handler"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/api/registry"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/registrypb"
+	"github.com/google/dpi-accelerator-beckn-onix/plugins/rediscache"
 
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"google.golang.org/grpc"
 	"gopkg.in/yaml.v3"
 )
 
 // config represents application configuration.
 type config struct {
-	Log      *log.Config        `yaml:"log"`
-	Timeouts *timeoutConfig     `yaml:"timeouts"`
-	Server   *serverConfig      `yaml:"server"`
-	DB       *repository.Config `yaml:"db"`
-	Event    *event.Config      `yaml:"event"`
+	Log             *log.Config                    `yaml:"log"`
+	Timeouts        *timeoutConfig                 `yaml:"timeouts"`
+	Server          *serverConfig                  `yaml:"server"`
+	DB              *repository.Config             `yaml:"db"`
+	Event           *event.Config                  `yaml:"event"`
+	Subscription    *service.SubscriptionConfig    `yaml:"subscription"`
+	DebugErrors     *model.ErrorDebugConfig        `yaml:"debugErrors"`
+	ResponseSigning *service.ResponseSigningConfig `yaml:"responseSigning"`
+	GRPC            *grpcConfig                    `yaml:"grpc"`
+	// SignatureHeader is the header requests carry their signature in. Defaults to Authorization.
+	SignatureHeader string `yaml:"signatureHeader"`
+	// AllowedAlgorithms restricts the signature algorithms accepted network-wide, e.g. to ed25519. Empty accepts any.
+	AllowedAlgorithms []string `yaml:"allowedAlgorithms"`
+	// RequireSignedCreate requires subscription creates to be signed with the signing key they register.
+	RequireSignedCreate bool `yaml:"requireSignedCreate"`
+	// SignatureClockSkew is how far subscriber clocks may be off when the created and expires
+	// parameters of request signatures are checked. Defaults to service.DefaultSignatureClockSkew.
+	SignatureClockSkew time.Duration `yaml:"signatureClockSkew"`
+	// ReplayProtection rejects authenticated subscription updates repeating the nonce of an earlier one.
+	ReplayProtection *replayProtectionConfig `yaml:"replayProtection"`
+	// SignatureFailureAlerts publishes an alert when the requests of a subscriber repeatedly fail signature validation.
+	SignatureFailureAlerts *service.SignatureFailureAlertConfig `yaml:"signatureFailureAlerts"`
+	// OperationRetryMax is the admin service's admin.operationRetryMax, reported with the
+	// retry count of operations. Optional, 0 reports neither.
+	OperationRetryMax int `yaml:"operationRetryMax"`
+	// DefaultAPIVersion is the API version served to requests without an Accept-Version header,
+	// e.g. to keep clients on the original response shape. Defaults to model.APIVersionCurrent.
+	DefaultAPIVersion string `yaml:"defaultAPIVersion"`
+
+	// DependencyTimeouts sets the timeouts of all external dependencies in one place.
+	DependencyTimeouts *model.DependencyTimeouts `yaml:"dependencyTimeouts"`
+}
+
+// replayProtectionConfig configures the Redis store remembering the nonces of authenticated subscription updates.
+type replayProtectionConfig struct {
+	RedisAddr string        `yaml:"redisAddr"`
+	NonceTTL  time.Duration `yaml:"nonceTTL"` // How long nonces are remembered, defaults to service.DefaultNonceTTL.
 }
 
 type serverConfig struct {
@@ -54,6 +94,12 @@ type serverConfig struct {
 	Port int    `yaml:"port"`
 }
 
+// grpcConfig configures the gRPC server exposing the lookup.
+type grpcConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+}
+
 type timeoutConfig struct {
 	Read     time.Duration `yaml:"read"`
 	Write    time.Duration `yaml:"write"`
@@ -76,6 +122,7 @@ func initConfig(filePath string) (*config, error) {
 	if err := cfg.valid(); err != nil {
 		return nil, err
 	}
+	cfg.applyDependencyTimeouts()
 	return &cfg, nil
 }
 
@@ -93,18 +140,88 @@ func (c *config) valid() error {
 	if c.Timeouts == nil {
 		return fmt.Errorf("missing required config section: timeouts")
 	}
+	if err := c.DependencyTimeouts.Validate(); err != nil {
+		return err
+	}
 	if c.DB == nil {
 		return fmt.Errorf("missing required config section: db")
 	}
+	if err := c.DB.Validate(); err != nil {
+		return fmt.Errorf("invalid db config: %w", err)
+	}
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 	if c.Event == nil {
 		return fmt.Errorf("missing required config section: event")
 	}
+	if c.Subscription == nil {
+		slog.Warn("Subscription config not found, pending operations per subscriber will not be limited.")
+		c.Subscription = &service.SubscriptionConfig{}
+	}
+	if c.Subscription.MaxValidFromSkew < 0 {
+		return fmt.Errorf("subscription.maxValidFromSkew must not be negative")
+	}
+	if c.SignatureFailureAlerts != nil {
+		if err := c.SignatureFailureAlerts.Validate(); err != nil {
+			return fmt.Errorf("invalid signatureFailureAlerts: %w", err)
+		}
+	}
+	if c.OperationRetryMax < 0 {
+		return fmt.Errorf("operationRetryMax must not be negative")
+	}
+	if c.DefaultAPIVersion != "" && !apiversion.IsSupported(c.DefaultAPIVersion) {
+		return fmt.Errorf("unsupported defaultAPIVersion %q, supported versions: %v", c.DefaultAPIVersion, apiversion.Supported())
+	}
+	if c.DebugErrors != nil && c.DebugErrors.Enabled {
+		slog.Warn("Config validation: debugErrors is enabled, internal error details will be returned to clients. Do not use in production.")
+	}
+	if rs := c.ResponseSigning; rs != nil && rs.Enabled {
+		if rs.SubscriberID == "" {
+			return fmt.Errorf("missing responseSigning.subscriberID")
+		}
+		if rs.KeyID == "" {
+			return fmt.Errorf("missing responseSigning.keyID")
+		}
+		if rs.ProjectID == "" {
+			rs.ProjectID = c.Event.ProjectID
+		}
+	}
+	if g := c.GRPC; g != nil && g.Enabled {
+		if g.Port <= 0 || g.Port > 65535 {
+			return fmt.Errorf("invalid grpc port: %d", g.Port)
+		}
+		if g.Port == c.Server.Port {
+			return fmt.Errorf("grpc port %d must differ from the server port", g.Port)
+		}
+	}
+	if c.SignatureHeader != "" && !model.ValidHeaderName(c.SignatureHeader) {
+		return fmt.Errorf("invalid signatureHeader: %q", c.SignatureHeader)
+	}
+	for _, alg := range c.AllowedAlgorithms {
+		if strings.TrimSpace(alg) == "" {
+			return fmt.Errorf("allowedAlgorithms must not contain empty algorithms")
+		}
+	}
+	if c.SignatureClockSkew < 0 {
+		return fmt.Errorf("signatureClockSkew must not be negative")
+	}
+	if r := c.ReplayProtection; r != nil {
+		if r.RedisAddr == "" {
+			return fmt.Errorf("missing replayProtection.redisAddr")
+		}
+		if r.NonceTTL < 0 {
+			return fmt.Errorf("replayProtection.nonceTTL must not be negative")
+		}
+	}
 	return nil
 }
 
+// nonceCacheConfig returns the configuration of the Redis cache storing nonces.
+func (c *config) nonceCacheConfig() map[string]string {
+	return map[string]string{"addr": c.ReplayProtection.RedisAddr, "timeout": c.DependencyTimeouts.WithDefaults().Cache.String()}
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -135,18 +252,52 @@ func run(ctx context.Context) error {
 			}
 		}()
 	}
-	server, err := newServer(ctx, cfg, db, sv)
+	var nonces service.NonceStore
+	if cfg.ReplayProtection != nil {
+		redis, closeRedis, err := rediscache.New(ctx, cfg.nonceCacheConfig())
+		if err != nil {
+			return fmt.Errorf("failed to create redis nonce store: %w", err)
+		}
+		defer func() {
+			if err := closeRedis(); err != nil {
+				slog.Error("failed to close redis", "error", err)
+			}
+		}()
+		nonces = redis
+	}
+	var secrets *secretmanager.Client
+	if cfg.ResponseSigning != nil && cfg.ResponseSigning.Enabled {
+		secrets, err = secretmanager.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create secret manager client for response signing: %w", err)
+		}
+		defer secrets.Close()
+	}
+	server, grpcServer, err := newServer(ctx, cfg, db, sv, nonces, secrets)
 	if err != nil {
 		return err
 	}
 
-	serverErr := make(chan error, 1)
+	serverErr := make(chan error, 2)
 	go func() {
 		slog.Info("Registry server starting...", "address", net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)))
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
 	}()
+	if grpcServer != nil {
+		addr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.GRPC.Port))
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on %s: %w", addr, err)
+		}
+		go func() {
+			slog.Info("Registry gRPC server starting...", "address", addr)
+			if err := grpcServer.Serve(lis); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
 
 	//Graceful Shutdown
 	quit := make(chan os.Signal, 1)
@@ -169,57 +320,127 @@ func run(ctx context.Context) error {
 	} else {
 		slog.Info("Registry server shut down gracefully.")
 	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
 
 	slog.Info("Registry service has stopped.")
 	return nil
 }
 
+// applyDependencyTimeouts applies the dependencyTimeouts section to the clients of the registry.
+func (c *config) applyDependencyTimeouts() {
+	c.DependencyTimeouts.Apply(model.ClientTimeouts{DB: &c.DB.Timeout, Event: &c.Event.Timeout})
+}
+
+// stopGRPC stops srv gracefully, forcing it to stop once ctx is done.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		slog.Info("Registry gRPC server shut down gracefully.")
+	case <-ctx.Done():
+		srv.Stop()
+		slog.Error("Graceful gRPC server shutdown timed out, forced stop")
+	}
+}
+
 var configPath string
 var newConnectionPool = repository.NewConnectionPool
 
-func newServer(ctx context.Context, cfg *config, db *sql.DB, sv definition.SignValidator) (*http.Server, error) {
+// newServer creates the registry HTTP server and, if enabled in cfg, the gRPC server exposing the lookup.
+// The gRPC server is nil when it is not enabled. Authenticated subscription updates are checked for
+// replays with nonces, unless it is nil. The key signing responses, if enabled, is read from secrets.
+func newServer(ctx context.Context, cfg *config, db *sql.DB, sv definition.SignValidator, nonces service.NonceStore, secrets *secretmanager.Client) (*http.Server, *grpc.Server, error) {
 	regRep, err := repository.NewRegistry(db)
 	if err != nil {
 		slog.Error("Failed to create registry repository", "error", err)
-		return nil, fmt.Errorf("failed to create registry repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to create registry repository: %w", err)
 	}
+	regRep.SetQueryTimeout(cfg.DB.Timeout)
 	lroSrv, err := service.NewLROService(regRep)
 	if err != nil {
 		slog.Error("Failed to create LRO service", "error", err)
-		return nil, fmt.Errorf("failed to create LRO service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create LRO service: %w", err)
 	}
 
 	evPub, _, err := event.NewPublisher(ctx, cfg.Event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create event publisher: %w", err)
+		return nil, nil, fmt.Errorf("failed to create event publisher: %w", err)
 	}
-	subSrv, err := service.NewSubscriptionService(lroSrv, regRep, evPub)
+	subSrv, err := service.NewSubscriptionService(lroSrv, regRep, evPub, cfg.Subscription)
 	if err != nil {
 		slog.Error("Failed to create subscription service", "error", err)
-		return nil, fmt.Errorf("failed to create subscription service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create subscription service: %w", err)
+	}
+	algSV, err := service.NewAlgSignValidator(sv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sign validator: %w", err)
+	}
+	if cfg.SignatureClockSkew > 0 {
+		algSV.SetClockSkew(cfg.SignatureClockSkew)
 	}
-	auth, err := service.NewAuthService(subSrv, sv)
+	auth, err := service.NewAuthService(subSrv, algSV)
 	if err != nil {
 		slog.Error("Failed to create auth service", "error", err)
-		return nil, fmt.Errorf("failed to create auth service: %w", err)
+		return nil, nil, fmt.Errorf("failed to create auth service: %w", err)
+	}
+	auth.SetSignatureHeader(cfg.SignatureHeader)
+	auth.SetAllowedAlgorithms(cfg.AllowedAlgorithms)
+	if cfg.SignatureClockSkew > 0 {
+		auth.SetClockSkew(cfg.SignatureClockSkew)
+	}
+	if cfg.SignatureFailureAlerts != nil {
+		auth.SetSignatureFailureAlerts(*cfg.SignatureFailureAlerts, evPub)
+	}
+	if nonces != nil {
+		auth.SetNonceStore(nonces, cfg.ReplayProtection.NonceTTL)
 	}
 	subHandler, err := handler.NewSubscriptionHandler(subSrv, auth)
 	if err != nil {
 		slog.Error("Failed to create subscription handler", "error", err)
-		return nil, fmt.Errorf("failed to create subscription handler: %w", err)
+		return nil, nil, fmt.Errorf("failed to create subscription handler: %w", err)
 	}
 	lroHandler, err := handler.NewLROHandler(lroSrv)
 	if err != nil {
 		slog.Error("Failed to create LRO handler", "error", err)
-		return nil, fmt.Errorf("failed to create LRO handler: %w", err)
+		return nil, nil, fmt.Errorf("failed to create LRO handler: %w", err)
+	}
+	subHandler.SetErrorDebug(cfg.DebugErrors)
+	subHandler.SetSignatureHeader(cfg.SignatureHeader)
+	subHandler.SetRequireSignedCreate(cfg.RequireSignedCreate)
+	lroHandler.SetErrorDebug(cfg.DebugErrors)
+	lroHandler.SetRetryMax(cfg.OperationRetryMax)
+	lookupHandler := handler.NewLookupHandler(subSrv)
+	var router http.Handler = registry.NewRouter(subHandler, lookupHandler, lroHandler)
+	if cfg.ResponseSigning != nil && cfg.ResponseSigning.Enabled {
+		bodySigner, _, err := signer.New(ctx, &signer.Config{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create signer: %w", err)
+		}
+		respSigner, err := service.NewResponseSigner(ctx, cfg.ResponseSigning, bodySigner, secrets)
+		if err != nil {
+			slog.Error("Failed to create response signer", "error", err)
+			return nil, nil, fmt.Errorf("failed to create response signer: %w", err)
+		}
+		router = handler.SignResponses(respSigner)(router)
+	}
+	var grpcServer *grpc.Server
+	if cfg.GRPC != nil && cfg.GRPC.Enabled {
+		grpcServer = grpc.NewServer()
+		registrypb.RegisterRegistryLookupServer(grpcServer, handler.NewLookupGRPCServer(subSrv))
 	}
 	return &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      registry.NewRouter(subHandler, handler.NewLookupHandler(subSrv), lroHandler),
+		Handler:      apiversion.Default(cfg.DefaultAPIVersion)(router),
 		ReadTimeout:  cfg.Timeouts.Read,
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
-	}, nil
+	}, grpcServer, nil
 }
 
 func main() {
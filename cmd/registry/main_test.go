@@ -28,6 +28,8 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	pubsubpb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
 	"cloud.google.com/go/pubsub/pstest"
@@ -76,8 +78,28 @@ func TestInitConfigSuccess(t *testing.T) {
 					User:           "user",
 					Name:           "dbname",
 					ConnectionName: "host:port",
+					Timeout:        model.DefaultDBTimeout,
 				},
-				Event: &event.Config{ProjectID: "test-project", TopicID: "test-topic"},
+				Event:        &event.Config{ProjectID: "test-project", TopicID: "test-topic", Timeout: model.DefaultEventTimeout},
+				Subscription: &service.SubscriptionConfig{MaxPendingOperations: 5},
+			},
+		},
+		{
+			name:     "dependency timeouts with db override",
+			filePath: filepath.Join(testdataDir, "config_valid_dependency_timeouts.yaml"),
+			expectedConfig: &config{
+				Log:                &log.Config{Level: "INFO"},
+				Server:             &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts:           &timeoutConfig{Read: 5 * time.Second, Write: 10 * time.Second, Idle: 120 * time.Second, Shutdown: 15 * time.Second},
+				DependencyTimeouts: &model.DependencyTimeouts{DB: 5 * time.Second, Event: 2 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+					Timeout:        time.Second,
+				},
+				Event:        &event.Config{ProjectID: "test-project", TopicID: "test-topic", Timeout: 2 * time.Second},
+				Subscription: &service.SubscriptionConfig{MaxPendingOperations: 5},
 			},
 		},
 	}
@@ -184,6 +206,66 @@ func TestConfigValidSuccess(t *testing.T) {
 				Event: &event.Config{ProjectID: "test", TopicID: "test"},
 			},
 		},
+		{
+			name: "response signing disabled without key",
+			cfg: &config{
+				Log:      &log.Config{Level: "INFO"},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts: &timeoutConfig{Read: 1 * time.Second, Write: 1 * time.Second, Idle: 1 * time.Second, Shutdown: 1 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+				},
+				Event:           &event.Config{ProjectID: "test", TopicID: "test"},
+				ResponseSigning: &service.ResponseSigningConfig{Enabled: false},
+			},
+		},
+		{
+			name: "grpc enabled",
+			cfg: &config{
+				Log:      &log.Config{Level: "INFO"},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts: &timeoutConfig{Read: 1 * time.Second, Write: 1 * time.Second, Idle: 1 * time.Second, Shutdown: 1 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+				},
+				Event: &event.Config{ProjectID: "test", TopicID: "test"},
+				GRPC:  &grpcConfig{Enabled: true, Port: 9090},
+			},
+		},
+		{
+			name: "operation retry max",
+			cfg: &config{
+				Log:      &log.Config{Level: "INFO"},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts: &timeoutConfig{Read: 1 * time.Second, Write: 1 * time.Second, Idle: 1 * time.Second, Shutdown: 1 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+				},
+				Event:             &event.Config{ProjectID: "test", TopicID: "test"},
+				OperationRetryMax: 3,
+			},
+		},
+		{
+			name: "default api version",
+			cfg: &config{
+				Log:      &log.Config{Level: "INFO"},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts: &timeoutConfig{Read: 1 * time.Second, Write: 1 * time.Second, Idle: 1 * time.Second, Shutdown: 1 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+				},
+				Event:             &event.Config{ProjectID: "test", TopicID: "test"},
+				DefaultAPIVersion: model.APIVersion1,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -253,6 +335,90 @@ func TestConfigValidError(t *testing.T) {
 			cfg:           &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg},
 			expectedError: "missing required config section: event",
 		},
+		{
+			name: "responseSigning missing subscriberID",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				ResponseSigning: &service.ResponseSigningConfig{Enabled: true, KeyID: "k1"}},
+			expectedError: "missing responseSigning.subscriberID",
+		},
+		{
+			name: "responseSigning missing keyID",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				ResponseSigning: &service.ResponseSigningConfig{Enabled: true, SubscriberID: "registry.example.com"}},
+			expectedError: "missing responseSigning.keyID",
+		},
+		{
+			name: "grpc enabled with invalid port",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				GRPC: &grpcConfig{Enabled: true}},
+			expectedError: "invalid grpc port: 0",
+		},
+		{
+			name: "grpc port same as server port",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				GRPC: &grpcConfig{Enabled: true, Port: 8080}},
+			expectedError: "grpc port 8080 must differ from the server port",
+		},
+		{
+			name: "invalid signatureHeader",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				SignatureHeader: "X-Signature:"},
+			expectedError: `invalid signatureHeader: "X-Signature:"`,
+		},
+		{
+			name: "empty allowedAlgorithms entry",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				AllowedAlgorithms: []string{""}},
+			expectedError: "allowedAlgorithms must not contain empty algorithms",
+		},
+		{
+			name: "negative signatureClockSkew",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				SignatureClockSkew: -time.Second},
+			expectedError: "signatureClockSkew must not be negative",
+		},
+		{
+			name: "replayProtection without redisAddr",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				ReplayProtection: &replayProtectionConfig{NonceTTL: time.Hour}},
+			expectedError: "missing replayProtection.redisAddr",
+		},
+		{
+			name: "negative replayProtection.nonceTTL",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				ReplayProtection: &replayProtectionConfig{RedisAddr: "localhost:6379", NonceTTL: -time.Hour}},
+			expectedError: "replayProtection.nonceTTL must not be negative",
+		},
+		{
+			name: "invalid db pool",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, Event: validEventCfg,
+				DB: &repository.Config{User: "u", Name: "n", ConnectionName: "c", MaxOpenConns: 2, MaxIdleConns: 5}},
+			expectedError: "invalid db config: maxIdleConns 5 must not be negative or greater than maxOpenConns 2",
+		},
+		{
+			name: "negative subscription.maxValidFromSkew",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				Subscription: &service.SubscriptionConfig{MaxValidFromSkew: -time.Minute}},
+			expectedError: "subscription.maxValidFromSkew must not be negative",
+		},
+		{
+			name: "negative operationRetryMax",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				OperationRetryMax: -1},
+			expectedError: "operationRetryMax must not be negative",
+		},
+		{
+			name: "unsupported defaultAPIVersion",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				DefaultAPIVersion: "99"},
+			expectedError: `unsupported defaultAPIVersion "99", supported versions: [1 2]`,
+		},
+		{
+			name: "invalid signatureFailureAlerts",
+			cfg: &config{Log: validLogCfg, Timeouts: validTimeoutsCfg, Server: validServerCfg, DB: validDBCfg, Event: validEventCfg,
+				SignatureFailureAlerts: &service.SignatureFailureAlertConfig{Threshold: 5, Window: time.Minute, BlockFor: -time.Minute}},
+			expectedError: "invalid signatureFailureAlerts: blockFor -1m0s must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -268,6 +434,40 @@ func TestConfigValidError(t *testing.T) {
 	}
 }
 
+func TestConfigValid_ResponseSigningProjectID(t *testing.T) {
+	tests := []struct {
+		name      string
+		projectID string
+		want      string
+	}{
+		{name: "defaults to event project", want: "test"},
+		{name: "own project", projectID: "secrets-project", want: "secrets-project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				Log:      &log.Config{Level: "INFO"},
+				Server:   &serverConfig{Host: "localhost", Port: 8080},
+				Timeouts: &timeoutConfig{Read: 1 * time.Second, Write: 1 * time.Second, Idle: 1 * time.Second, Shutdown: 1 * time.Second},
+				DB: &repository.Config{
+					User:           "user",
+					Name:           "dbname",
+					ConnectionName: "host:port",
+				},
+				Event:           &event.Config{ProjectID: "test", TopicID: "test"},
+				ResponseSigning: &service.ResponseSigningConfig{Enabled: true, SubscriberID: "registry.example.com", KeyID: "k1", ProjectID: tt.projectID},
+			}
+			if err := cfg.valid(); err != nil {
+				t.Fatalf("config.valid() error = %v, wantErr nil", err)
+			}
+			if got := cfg.ResponseSigning.ProjectID; got != tt.want {
+				t.Errorf("responseSigning.projectID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNewServerSuccess(t *testing.T) {
 	ctx := context.Background()
 	_, clientOpts, cleanupPubsub := setUpTestPubsub(ctx, t, "test-topic")
@@ -282,7 +482,8 @@ func TestNewServerSuccess(t *testing.T) {
 			Name:           "dbname",
 			ConnectionName: "host:port",
 		},
-		Event: &event.Config{ProjectID: testProject, TopicID: "test-topic", Opts: clientOpts},
+		Event:        &event.Config{ProjectID: testProject, TopicID: "test-topic", Opts: clientOpts},
+		Subscription: &service.SubscriptionConfig{},
 	}
 
 	mockDB, _, err := sqlmock.New()
@@ -293,13 +494,16 @@ func TestNewServerSuccess(t *testing.T) {
 
 	mockSV := &mockSignValidator{}
 
-	server, err := newServer(ctx, cfg, mockDB, mockSV)
+	server, grpcServer, err := newServer(ctx, cfg, mockDB, mockSV, nil, nil)
 	if err != nil {
 		t.Fatalf("newServer() error = %v, wantErr nil", err)
 	}
 	if server == nil {
 		t.Fatal("newServer() returned nil server with no error")
 	}
+	if grpcServer != nil {
+		t.Error("newServer() returned a gRPC server, want nil when grpc is not enabled")
+	}
 
 	expectedAddr := net.JoinHostPort(cfg.Server.Host, fmt.Sprintf("%d", cfg.Server.Port))
 	if server.Addr != expectedAddr {
@@ -319,6 +523,42 @@ func TestNewServerSuccess(t *testing.T) {
 	}
 }
 
+func TestNewServerWithGRPC(t *testing.T) {
+	ctx := context.Background()
+	_, clientOpts, cleanupPubsub := setUpTestPubsub(ctx, t, "test-topic")
+	defer cleanupPubsub()
+
+	cfg := &config{
+		Log:      &log.Config{Level: "DEBUG"},
+		Server:   &serverConfig{Host: "127.0.0.1", Port: 9090},
+		Timeouts: &timeoutConfig{Read: 5 * time.Second, Write: 10 * time.Second, Idle: 15 * time.Second, Shutdown: 20 * time.Second},
+		DB: &repository.Config{
+			User:           "user",
+			Name:           "dbname",
+			ConnectionName: "host:port",
+		},
+		Event:        &event.Config{ProjectID: testProject, TopicID: "test-topic", Opts: clientOpts},
+		Subscription: &service.SubscriptionConfig{},
+		GRPC:         &grpcConfig{Enabled: true, Port: 9091},
+	}
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer mockDB.Close()
+
+	_, grpcServer, err := newServer(ctx, cfg, mockDB, &mockSignValidator{}, nil, nil)
+	if err != nil {
+		t.Fatalf("newServer() error = %v, wantErr nil", err)
+	}
+	if grpcServer == nil {
+		t.Fatal("newServer() returned nil gRPC server, want non-nil when grpc is enabled")
+	}
+	if _, ok := grpcServer.GetServiceInfo()["registry.v1.RegistryLookup"]; !ok {
+		t.Error("gRPC server does not serve registry.v1.RegistryLookup")
+	}
+}
+
 func TestNewServerError(t *testing.T) {
 	cfg := &config{ // A minimal valid config for other parts
 		Log:      &log.Config{Level: "INFO"},
@@ -349,7 +589,7 @@ func TestNewServerError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			server, err := newServer(context.Background(), cfg, tt.db, tt.sv)
+			server, _, err := newServer(context.Background(), cfg, tt.db, tt.sv, nil, nil)
 			if err == nil {
 				t.Fatalf("newServer() error = nil, wantErr containing %q", tt.expectedError)
 			}
@@ -21,6 +21,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
@@ -33,7 +34,9 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/readiness"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	decryption "github.com/google/dpi-accelerator-beckn-onix/plugins/decrypter"
 	keyManager "github.com/google/dpi-accelerator-beckn-onix/plugins/inmemorysecretkeymanager"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/oidcauth"
@@ -56,6 +59,31 @@ type config struct {
 	RegKeyID           string                       `yaml:"regKeyID"` // Registry's public key ID for decryption
 	Event              *event.Config                `yaml:"event"`
 	Auth               *oidcauth.Config             `yaml:"auth"`
+	AccessLog          *log.AccessLogConfig         `yaml:"accessLog"`
+	KeyStoreRetry      *service.KeyStoreRetryConfig `yaml:"keyStoreRetry"`
+	RegistryRetryAfter time.Duration                `yaml:"registryRetryAfter"` // Retry-After of requests failed by a Registry outage.
+	// SubscriptionValidity is how long subscriptions are valid for when requests have no valid_until.
+	SubscriptionValidity time.Duration `yaml:"subscriptionValidity"`
+	// OperationLocation answers accepted subscription requests with the Location of their operation
+	// in the Registry and whether their message ID was generated.
+	OperationLocation bool `yaml:"operationLocation"`
+	// GatewaySubscriberID is the subscriber ID of the gateway whose keyset signs GATEWAY-scheme updates.
+	GatewaySubscriberID string `yaml:"gatewaySubscriberID"`
+	// Readiness configures the checks of the /ready endpoint.
+	Readiness *readinessConfig `yaml:"readiness"`
+
+	// DependencyTimeouts sets the timeouts of all external dependencies in one place.
+	DependencyTimeouts *model.DependencyTimeouts `yaml:"dependencyTimeouts"`
+}
+
+// readinessConfig configures the checks of the /ready endpoint, which always checks Redis.
+type readinessConfig struct {
+	Timeout time.Duration `yaml:"timeout"` // How long each check may take, defaults to readiness.DefaultTimeout.
+	// KeyManager also checks that the key manager's Secret Manager backend is reachable,
+	// since subscriptions cannot be created or answered without it.
+	KeyManager bool `yaml:"keyManager"`
+	// KeyManagerCacheTTL is how long the result of a key manager check is reused, 0 checks on every probe.
+	KeyManagerCacheTTL time.Duration `yaml:"keyManagerCacheTTL"`
 }
 
 type serverConfig struct {
@@ -64,10 +92,11 @@ type serverConfig struct {
 }
 
 type timeoutConfig struct {
-	Read     time.Duration `yaml:"read"`
-	Write    time.Duration `yaml:"write"`
-	Idle     time.Duration `yaml:"idle"`
-	Shutdown time.Duration `yaml:"shutdown"`
+	Read        time.Duration `yaml:"read"`
+	Write       time.Duration `yaml:"write"`
+	Idle        time.Duration `yaml:"idle"`
+	Shutdown    time.Duration `yaml:"shutdown"`
+	OnSubscribe time.Duration `yaml:"onSubscribe"`
 }
 
 // initConfig reads configuration from a YAML file.
@@ -85,6 +114,14 @@ func initConfig(filePath string) (*config, error) {
 	if err := cfg.valid(); err != nil {
 		return nil, err
 	}
+	warnings, err := cfg.checkConsistency()
+	if err != nil {
+		return nil, fmt.Errorf("inconsistent config: %w", err)
+	}
+	for _, w := range warnings {
+		slog.Warn("Config consistency check found a likely misconfiguration", "warning", w)
+	}
+	cfg.applyDependencyTimeouts()
 	return &cfg, nil
 }
 
@@ -102,6 +139,9 @@ func (c *config) valid() error {
 	if c.Timeouts == nil {
 		return fmt.Errorf("missing required config section: timeouts")
 	}
+	if err := c.DependencyTimeouts.Validate(); err != nil {
+		return err
+	}
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
@@ -139,10 +179,72 @@ func (c *config) valid() error {
 			return fmt.Errorf("missing auth allowedIssuers when auth is enabled")
 		}
 	}
+	if r := c.KeyStoreRetry; r != nil {
+		if r.RetryMax < 0 {
+			return fmt.Errorf("invalid keyStoreRetry retryMax: %d", r.RetryMax)
+		}
+		if r.RetryWaitMin < 0 || r.RetryWaitMax < 0 {
+			return fmt.Errorf("invalid keyStoreRetry wait: waitMin %s, waitMax %s", r.RetryWaitMin, r.RetryWaitMax)
+		}
+	}
+	if c.RegistryRetryAfter < 0 {
+		return fmt.Errorf("invalid registryRetryAfter: %s", c.RegistryRetryAfter)
+	}
+	if c.SubscriptionValidity < 0 {
+		return fmt.Errorf("invalid subscriptionValidity: %s", c.SubscriptionValidity)
+	}
+	if r := c.Readiness; r != nil {
+		if r.Timeout < 0 {
+			return fmt.Errorf("invalid readiness timeout: %s", r.Timeout)
+		}
+		if r.KeyManagerCacheTTL < 0 {
+			return fmt.Errorf("invalid readiness keyManagerCacheTTL: %s", r.KeyManagerCacheTTL)
+		}
+	}
 
 	return nil
 }
 
+// checkConsistency cross-checks the registry settings, the event project and the timeouts.
+// A relative registry URL is an error. A regID other than the registry host, which breaks the
+// decryption of on_subscribe challenges, and the other likely mistakes are returned as warnings.
+func (c *config) checkConsistency() ([]string, error) {
+	var warnings []string
+	regURL, err := url.Parse(c.Registry.BaseURL)
+	if err != nil || regURL.Scheme == "" || regURL.Host == "" {
+		return nil, fmt.Errorf("registry base URL %q must be an absolute URL", c.Registry.BaseURL)
+	}
+	if regURL.Hostname() != c.RegID {
+		warnings = append(warnings, fmt.Sprintf("regID %q does not match registry base URL host %q, on_subscribe requests from the registry may fail to decrypt", c.RegID, regURL.Hostname()))
+	}
+	if c.Event.ProjectID != "" && c.Event.ProjectID != c.ProjectID {
+		warnings = append(warnings, fmt.Sprintf("event projectID %q differs from projectID %q", c.Event.ProjectID, c.ProjectID))
+	}
+	if c.Timeouts.OnSubscribe > 0 && c.Timeouts.Write > 0 && c.Timeouts.OnSubscribe >= c.Timeouts.Write {
+		warnings = append(warnings, fmt.Sprintf("onSubscribe timeout %s is not less than server write timeout %s", c.Timeouts.OnSubscribe, c.Timeouts.Write))
+	}
+	return warnings, nil
+}
+
+// readinessChecker returns the checker of the /ready endpoint, checking Redis with pingRedis and,
+// if cfg enables it, the key manager with pingKeyManager.
+func readinessChecker(cfg *readinessConfig, pingRedis, pingKeyManager readiness.Check) *readiness.Checker {
+	if cfg == nil {
+		cfg = &readinessConfig{}
+	}
+	checker := readiness.NewChecker(cfg.Timeout)
+	checker.Add("redis", pingRedis)
+	if cfg.KeyManager {
+		checker.Add("key_manager", readiness.Cached(pingKeyManager, cfg.KeyManagerCacheTTL))
+	}
+	return checker
+}
+
+// cacheConfig returns the configuration of the Redis cache.
+func (c *config) cacheConfig() map[string]string {
+	return map[string]string{"addr": c.RedisAddr, "timeout": c.DependencyTimeouts.WithDefaults().Cache.String()}
+}
+
 // run starts the HTTP server and handles graceful shutdown.
 func run(ctx context.Context) error {
 	cfg, err := initConfig(configPath)
@@ -153,7 +255,7 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	redis, closeRedis, err := rediscache.New(ctx, map[string]string{"addr": cfg.RedisAddr})
+	redis, closeRedis, err := rediscache.New(ctx, cfg.cacheConfig())
 	if err != nil {
 		return fmt.Errorf("failed to create redis cache: %w", err)
 	}
@@ -214,16 +316,24 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create auth gen service: %w", err)
 	}
 	// Initialize Subscriber Service
-	subService, err := service.NewSubscriberService(registryClient, km, dec, evPub, authGen, cfg.RegID, cfg.RegKeyID)
+	subService, err := service.NewSubscriberService(registryClient, km, dec, evPub, authGen, cfg.RegID, cfg.RegKeyID, cfg.Timeouts.OnSubscribe, cfg.SubscriptionValidity)
 	if err != nil {
 		return fmt.Errorf("failed to create subscriber service: %w", err)
 	}
+	if cfg.KeyStoreRetry != nil {
+		subService.SetKeyStoreRetry(*cfg.KeyStoreRetry)
+	}
+	subService.SetGatewaySubscriberID(cfg.GatewaySubscriberID)
 
 	// Initialize Subscriber Handler
 	subHandler, err := handler.NewSubscriberHandler(subService)
 	if err != nil {
 		return fmt.Errorf("failed to create subscriber handler: %w", err)
 	}
+	subHandler.SetRegistryRetryAfter(cfg.RegistryRetryAfter)
+	if cfg.OperationLocation {
+		subHandler.SetOperationLocation(cfg.Registry.BaseURL)
+	}
 
 	var oidcMW func(http.Handler) http.Handler
 	if cfg.Auth != nil {
@@ -240,10 +350,19 @@ func run(ctx context.Context) error {
 		}
 	}
 
+	accessLog, err := log.NewAccessLogMiddleware(cfg.AccessLog)
+	if err != nil {
+		return fmt.Errorf("failed to create access log middleware: %w", err)
+	}
+
+	router := subscriber.NewRouter(subHandler, oidcMW)
+	pingRedis := func(ctx context.Context) error { return redis.GetClient().Ping(ctx).Err() }
+	router.Get("/ready", readinessChecker(cfg.Readiness, pingRedis, km.Ping).ServeHTTP)
+
 	// Initialize HTTP Server
 	server := &http.Server{
 		Addr:         net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
-		Handler:      subscriber.NewRouter(subHandler, oidcMW),
+		Handler:      accessLog(router),
 		ReadTimeout:  cfg.Timeouts.Read,
 		WriteTimeout: cfg.Timeouts.Write,
 		IdleTimeout:  cfg.Timeouts.Idle,
@@ -282,6 +401,11 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+// applyDependencyTimeouts applies the dependencyTimeouts section to the clients of the subscriber.
+func (c *config) applyDependencyTimeouts() {
+	c.DependencyTimeouts.Apply(model.ClientTimeouts{Registry: &c.Registry.Timeout, Event: &c.Event.Timeout})
+}
+
 var configPath string
 
 func main() {
@@ -15,6 +15,10 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -22,6 +26,8 @@ import (
 	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	"github.com/google/dpi-accelerator-beckn-onix/plugins/oidcauth"
 )
 
@@ -309,6 +315,102 @@ func TestConfig_Valid_Error(t *testing.T) {
 			},
 			expectedError: "missing auth allowedIssuers when auth is enabled",
 		},
+		{
+			name: "negative keyStoreRetry retryMax",
+			cfg: &config{
+				Log:           validLogCfg,
+				Timeouts:      validTimeoutsCfg,
+				Server:        validServerCfg,
+				ProjectID:     "proj",
+				Registry:      validRegistryCfg,
+				RedisAddr:     "redis",
+				RegID:         "reg",
+				RegKeyID:      "key",
+				Event:         validEventCfg,
+				KeyStoreRetry: &service.KeyStoreRetryConfig{RetryMax: -1},
+			},
+			expectedError: "invalid keyStoreRetry retryMax: -1",
+		},
+		{
+			name: "negative keyStoreRetry wait",
+			cfg: &config{
+				Log:           validLogCfg,
+				Timeouts:      validTimeoutsCfg,
+				Server:        validServerCfg,
+				ProjectID:     "proj",
+				Registry:      validRegistryCfg,
+				RedisAddr:     "redis",
+				RegID:         "reg",
+				RegKeyID:      "key",
+				Event:         validEventCfg,
+				KeyStoreRetry: &service.KeyStoreRetryConfig{RetryMax: 2, RetryWaitMin: -time.Second},
+			},
+			expectedError: "invalid keyStoreRetry wait",
+		},
+		{
+			name: "negative registryRetryAfter",
+			cfg: &config{
+				Log:                validLogCfg,
+				Timeouts:           validTimeoutsCfg,
+				Server:             validServerCfg,
+				ProjectID:          "proj",
+				Registry:           validRegistryCfg,
+				RedisAddr:          "redis",
+				RegID:              "reg",
+				RegKeyID:           "key",
+				Event:              validEventCfg,
+				RegistryRetryAfter: -time.Second,
+			},
+			expectedError: "invalid registryRetryAfter: -1s",
+		},
+		{
+			name: "negative subscriptionValidity",
+			cfg: &config{
+				Log:                  validLogCfg,
+				Timeouts:             validTimeoutsCfg,
+				Server:               validServerCfg,
+				ProjectID:            "proj",
+				Registry:             validRegistryCfg,
+				RedisAddr:            "redis",
+				RegID:                "reg",
+				RegKeyID:             "key",
+				Event:                validEventCfg,
+				SubscriptionValidity: -time.Hour,
+			},
+			expectedError: "invalid subscriptionValidity: -1h0m0s",
+		},
+		{
+			name: "negative readiness timeout",
+			cfg: &config{
+				Log:       validLogCfg,
+				Timeouts:  validTimeoutsCfg,
+				Server:    validServerCfg,
+				ProjectID: "proj",
+				Registry:  validRegistryCfg,
+				RedisAddr: "redis",
+				RegID:     "reg",
+				RegKeyID:  "key",
+				Event:     validEventCfg,
+				Readiness: &readinessConfig{Timeout: -time.Second},
+			},
+			expectedError: "invalid readiness timeout: -1s",
+		},
+		{
+			name: "negative readiness keyManagerCacheTTL",
+			cfg: &config{
+				Log:       validLogCfg,
+				Timeouts:  validTimeoutsCfg,
+				Server:    validServerCfg,
+				ProjectID: "proj",
+				Registry:  validRegistryCfg,
+				RedisAddr: "redis",
+				RegID:     "reg",
+				RegKeyID:  "key",
+				Event:     validEventCfg,
+				Readiness: &readinessConfig{KeyManager: true, KeyManagerCacheTTL: -time.Second},
+			},
+			expectedError: "invalid readiness keyManagerCacheTTL: -1s",
+		},
 	}
 
 	for _, tt := range tests {
@@ -323,3 +425,181 @@ func TestConfig_Valid_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_ApplyDependencyTimeouts(t *testing.T) {
+	tests := []struct {
+		name            string
+		deps            *model.DependencyTimeouts
+		registryTimeout time.Duration
+		wantRegistry    time.Duration
+		wantEvent       time.Duration
+		wantCache       string
+	}{
+		{
+			name:         "central defaults when unset",
+			wantRegistry: model.DefaultRegistryTimeout,
+			wantEvent:    model.DefaultEventTimeout,
+			wantCache:    model.DefaultCacheTimeout.String(),
+		},
+		{
+			name:         "central timeouts",
+			deps:         &model.DependencyTimeouts{Registry: 5 * time.Second, Event: 6 * time.Second, Cache: 500 * time.Millisecond},
+			wantRegistry: 5 * time.Second,
+			wantEvent:    6 * time.Second,
+			wantCache:    "500ms",
+		},
+		{
+			name:            "client timeout overrides central timeout",
+			deps:            &model.DependencyTimeouts{Registry: 5 * time.Second},
+			registryTimeout: 2 * time.Second,
+			wantRegistry:    2 * time.Second,
+			wantEvent:       model.DefaultEventTimeout,
+			wantCache:       model.DefaultCacheTimeout.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				DependencyTimeouts: tt.deps,
+				Registry:           &client.RegistryClientConfig{Timeout: tt.registryTimeout},
+				Event:              &event.Config{},
+				RedisAddr:          "redis:6379",
+			}
+			cfg.applyDependencyTimeouts()
+			if cfg.Registry.Timeout != tt.wantRegistry {
+				t.Errorf("Registry.Timeout = %s, want %s", cfg.Registry.Timeout, tt.wantRegistry)
+			}
+			if cfg.Event.Timeout != tt.wantEvent {
+				t.Errorf("Event.Timeout = %s, want %s", cfg.Event.Timeout, tt.wantEvent)
+			}
+			if got := cfg.cacheConfig(); got["addr"] != "redis:6379" || got["timeout"] != tt.wantCache {
+				t.Errorf("cacheConfig() = %v, want addr redis:6379 and timeout %s", got, tt.wantCache)
+			}
+		})
+	}
+}
+
+func TestConfig_CheckConsistency(t *testing.T) {
+	newCfg := func() *config {
+		return &config{
+			Log:       &log.Config{Level: "INFO"},
+			Timeouts:  &timeoutConfig{Write: 10 * time.Second, OnSubscribe: 5 * time.Second},
+			Server:    &serverConfig{Host: "localhost", Port: 8080},
+			ProjectID: "test-project",
+			Registry:  &client.RegistryClientConfig{BaseURL: "https://registry.beckn.org/v1"},
+			RedisAddr: "localhost:6379",
+			RegID:     "registry.beckn.org",
+			RegKeyID:  "registry-key-id",
+			Event:     &event.Config{ProjectID: "test-project", TopicID: "test-topic"},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		mutate       func(*config)
+		wantWarnings []string
+	}{
+		{
+			name:   "consistent config",
+			mutate: func(*config) {},
+		},
+		{
+			name:   "empty event projectID is not flagged",
+			mutate: func(c *config) { c.Event.ProjectID = "" },
+		},
+		{
+			name:         "regID does not match registry host",
+			mutate:       func(c *config) { c.RegID = "other-registry.org" },
+			wantWarnings: []string{`regID "other-registry.org" does not match registry base URL host "registry.beckn.org"`},
+		},
+		{
+			name:         "event projectID mismatch",
+			mutate:       func(c *config) { c.Event.ProjectID = "other-project" },
+			wantWarnings: []string{`event projectID "other-project" differs from projectID "test-project"`},
+		},
+		{
+			name:         "onSubscribe timeout not less than write timeout",
+			mutate:       func(c *config) { c.Timeouts.OnSubscribe = 10 * time.Second },
+			wantWarnings: []string{"onSubscribe timeout 10s is not less than server write timeout 10s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCfg()
+			tt.mutate(cfg)
+			warnings, err := cfg.checkConsistency()
+			if err != nil {
+				t.Fatalf("checkConsistency() error = %v, want nil", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("checkConsistency() warnings = %q, want %d warnings", warnings, len(tt.wantWarnings))
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("checkConsistency() warning[%d] = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_CheckConsistency_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+	}{
+		{name: "relative registry URL", baseURL: "registry.beckn.org"},
+		{name: "unparsable registry URL", baseURL: "http://[::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				Timeouts:  &timeoutConfig{},
+				ProjectID: "test-project",
+				Registry:  &client.RegistryClientConfig{BaseURL: tt.baseURL},
+				RegID:     "registry.beckn.org",
+				Event:     &event.Config{},
+			}
+			_, err := cfg.checkConsistency()
+			if err == nil || !strings.Contains(err.Error(), "must be an absolute URL") {
+				t.Errorf("checkConsistency() error = %v, want error containing %q", err, "must be an absolute URL")
+			}
+		})
+	}
+}
+
+func TestReadinessChecker(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	kmDown := func(ctx context.Context) error { return errors.New("failed to reach secret manager: denied") }
+
+	tests := []struct {
+		name     string
+		cfg      *readinessConfig
+		wantCode int
+		wantBody string
+	}{
+		{name: "key manager not checked by default", cfg: nil, wantCode: http.StatusOK, wantBody: `{"status":"ready"}`},
+		{
+			name:     "key manager probe fails",
+			cfg:      &readinessConfig{KeyManager: true},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"status":"not_ready","failed":{"key_manager":"failed to reach secret manager: denied"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			readinessChecker(tt.cfg, ok, kmDown).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if got := strings.TrimSpace(rr.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
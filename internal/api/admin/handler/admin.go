@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
@@ -31,11 +33,15 @@ import (
 type adminService interface {
 	ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error)
 	RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error)
+	ApproveNextPendingSubscription(ctx context.Context) (*model.Subscription, *model.LRO, error)
 }
 
 // adminHandler handles admin-specific Long-Running Operation (LRO) actions.
 type adminHandler struct {
-	srv adminService
+	srv      adminService
+	errDebug *model.ErrorDebugConfig
+	// batchTimeout is how long a batch may process its actions, zero for no deadline.
+	batchTimeout time.Duration
 }
 
 // NewAdminHandler creates a new AdminLROHandler.
@@ -47,72 +53,240 @@ func NewAdminHandler(srv adminService) (*adminHandler, error) {
 	return &adminHandler{srv: srv}, nil
 }
 
+// SetErrorDebug enables internal error details in 500 responses. Must not be used in production.
+func (h *adminHandler) SetErrorDebug(cfg *model.ErrorDebugConfig) {
+	h.errDebug = cfg
+}
+
+// SetWriteTimeout sets the write timeout of the server, so that a batch stops processing its
+// actions in time to write its response. Batches have no deadline by default.
+func (h *adminHandler) SetWriteTimeout(d time.Duration) {
+	h.batchTimeout = d * batchTimeoutPercent / 100
+}
+
+const (
+	// maxBatchActions is the maximum number of actions accepted in a single batch request.
+	maxBatchActions = 100
+	// batchConcurrency is the maximum number of actions of a batch processed at a time.
+	batchConcurrency = 10
+	// batchTimeoutPercent is the share of the write timeout a batch may spend processing its
+	// actions, leaving the rest to write the response.
+	batchTimeoutPercent = 80
+)
+
 // writeAdminJSONError is a helper function to construct and write standardized JSON error responses for admin API.
 func writeAdminJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg string) {
+	writeAdminError(w, &actionError{
+		status: statusCode,
+		err:    model.Error{Type: errType, Code: errCode, Message: errMsg},
+	})
+}
+
+// writeAdminError writes the JSON error response for a failed action.
+func writeAdminError(w http.ResponseWriter, ae *actionError) {
 	w.Header().Set("Content-Type", "application/json")
-	errResp := model.ErrorResponse{
-		Error: model.Error{
-			Type:    errType,
-			Code:    errCode,
-			Message: errMsg,
-		},
-	}
-	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(errResp); err != nil {
+	w.WriteHeader(ae.status)
+	if err := json.NewEncoder(w).Encode(model.ErrorResponse{Error: ae.err}); err != nil {
 		slog.Error("AdminLROHandler: Failed to encode error response", "error", err)
 	}
 }
 
-// HandleSubscriptionAction processes APPROVE/REJECT actions for a subscription LRO.
-func (h *adminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var req model.OperationActionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.ErrorContext(ctx, "AdminLROHandler: Failed to decode request body for action", "error", err)
-		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
-		return
-	}
-	defer r.Body.Close()
+// actionError is a failed subscription action along with the HTTP status it maps to.
+type actionError struct {
+	status int
+	err    model.Error
+}
 
+// subscriptionAction validates and performs a single APPROVE/REJECT action.
+func (h *adminHandler) subscriptionAction(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, *actionError) {
 	var lro *model.LRO
 	var err error
 
 	switch req.Action {
 	case model.OperationActionApproveSubscription:
 		slog.InfoContext(ctx, "AdminLROHandler: Approving subscription", "operation_id", req.OperationID)
-		_, lro, err = h.srv.ApproveSubscription(ctx, &req)
+		_, lro, err = h.srv.ApproveSubscription(ctx, req)
 	case model.OperationActionRejectSubscription:
 		if req.Reason == "" {
 			slog.WarnContext(ctx, "AdminLROHandler: Reason missing for REJECT action", "operation_id", req.OperationID)
-			writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeTypeInvalidAction, "Reason is required for REJECT action.")
-			return
+			return nil, &actionError{http.StatusBadRequest, model.Error{Type: model.ErrorTypeValidationError, Code: model.ErrorCodeTypeInvalidAction, Message: "Reason is required for REJECT action."}}
 		}
 		slog.InfoContext(ctx, "AdminLROHandler: Rejecting subscription", "operation_id", req.OperationID, "reason", req.Reason)
-		lro, err = h.srv.RejectSubscription(ctx, &req)
+		lro, err = h.srv.RejectSubscription(ctx, req)
 	default:
 		slog.WarnContext(ctx, "AdminLROHandler: Invalid action specified", "operation_id", req.OperationID, "action", req.Action)
-		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeTypeInvalidAction, "Invalid action specified. Must be 'APPROVE_SUBSCRIPTION' or 'REJECT_SUBSCRIPTION'.")
-		return
+		return nil, &actionError{http.StatusBadRequest, model.Error{Type: model.ErrorTypeValidationError, Code: model.ErrorCodeTypeInvalidAction, Message: "Invalid action specified. Must be 'APPROVE_SUBSCRIPTION' or 'REJECT_SUBSCRIPTION'."}}
 	}
 
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminLROHandler: Error processing subscription action", "operation_id", req.OperationID, "action", req.Action, "error", err)
-		if errors.Is(err, repository.ErrOperationNotFound) {
-			writeAdminJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", req.OperationID))
-			return
-		}
-		if errors.Is(err, service.ErrLROAlreadyProcessed) {
-			writeAdminJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, fmt.Sprintf("Operation %s has already been processed.", req.OperationID))
-			return
+		return nil, h.actionErrorFor(err, req.OperationID)
+	}
+	return lro, nil
+}
+
+// actionErrorFor maps err, returned by an action on the operation operationID, to its HTTP status and error.
+func (h *adminHandler) actionErrorFor(err error, operationID string) *actionError {
+	if errors.Is(err, service.ErrInvalidOperationID) {
+		return &actionError{http.StatusBadRequest, model.Error{Type: model.ErrorTypeValidationError, Code: model.ErrorCodeBadRequest, Message: fmt.Sprintf("Malformed operation id: %v", err)}}
+	}
+	if errors.Is(err, repository.ErrOperationNotFound) {
+		return &actionError{http.StatusNotFound, model.Error{Type: model.ErrorTypeNotFoundError, Code: model.ErrorCodeOperationNotFound, Message: fmt.Sprintf("Operation with id %s not found.", operationID)}}
+	}
+	// A conflict means a concurrent action processed the operation first.
+	if errors.Is(err, service.ErrLROAlreadyProcessed) || errors.Is(err, repository.ErrOperationConflict) {
+		return &actionError{http.StatusConflict, model.Error{Type: model.ErrorTypeConflictError, Code: model.ErrorCodeDuplicateRequest, Message: fmt.Sprintf("Operation %s has already been processed.", operationID)}}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return batchDeadlineError
+	}
+	if errors.Is(err, service.ErrApprovalQueueFull) {
+		return &actionError{http.StatusServiceUnavailable, model.Error{Type: model.ErrorTypeInternalError, Code: model.ErrorCodeServiceUnavailable, Message: "Too many approvals are queued, retry later."}}
+	}
+	return &actionError{http.StatusInternalServerError, model.Error{
+		Type:    model.ErrorTypeInternalError,
+		Code:    model.ErrorCodeInternalServerError,
+		Message: "Failed to process subscription action due to an internal error.",
+		Details: h.errDebug.Details(err),
+	}}
+}
+
+// actionStatus is the HTTP status of an action that resulted in lro: 202 Accepted if the
+// action was queued and its operation is not final yet, PENDING until an approval worker
+// claims it and IN_PROGRESS while it runs, 200 OK otherwise.
+func actionStatus(lro *model.LRO) int {
+	if lro != nil && (lro.Status == model.LROStatusPending || lro.Status == model.LROStatusInProgress) {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// HandleSubscriptionAction processes APPROVE/REJECT actions for a subscription LRO.
+// It answers 202 Accepted if the approval was queued.
+func (h *adminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.OperationActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to decode request body for action", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	lro, ae := h.subscriptionAction(ctx, &req)
+	if ae != nil {
+		writeAdminError(w, ae)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(actionStatus(lro))
+	if err := json.NewEncoder(w).Encode(lro); err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode LRO response for action", "error", err, "operation_id", lro.OperationID)
+		// Client has already received the status, this error is server-side logging.
+	}
+}
+
+// HandleApproveNextSubscription claims and approves the oldest pending subscription operation, for
+// approval workers polling the service. It answers 204 No Content if no operation is pending.
+func (h *adminHandler) HandleApproveNextSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, lro, err := h.srv.ApproveNextPendingSubscription(ctx)
+	if errors.Is(err, repository.ErrNoPendingOperation) {
+		slog.DebugContext(ctx, "AdminLROHandler: No pending subscription to approve")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		var operationID string
+		if lro != nil {
+			operationID = lro.OperationID
 		}
-		writeAdminJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription action due to an internal error.")
+		slog.ErrorContext(ctx, "AdminLROHandler: Error approving next pending subscription", "operation_id", operationID, "error", err)
+		writeAdminError(w, h.actionErrorFor(err, operationID))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(lro); err != nil {
-		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode LRO response for action", "error", err, "operation_id", lro.OperationID)
-		// Client has already received 200 OK, this error is server-side logging.
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode LRO response for next approval", "error", err, "operation_id", lro.OperationID)
+	}
+}
+
+// batchDeadlineError is the error of an action that did not complete before the deadline of its batch.
+var batchDeadlineError = &actionError{http.StatusServiceUnavailable, model.Error{Type: model.ErrorTypeInternalError, Code: model.ErrorCodeServiceUnavailable, Message: "The action did not complete in time, retry it."}}
+
+// batchActions performs the actions of a batch, up to batchConcurrency at a time, and
+// returns the resulting operation or error of each one in the order of actions.
+// Actions not completed by the deadline of the batch fail with batchDeadlineError.
+func (h *adminHandler) batchActions(ctx context.Context, actions []model.OperationActionRequest) ([]*model.LRO, []*actionError) {
+	if h.batchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.batchTimeout)
+		defer cancel()
+	}
+	lros := make([]*model.LRO, len(actions))
+	errs := make([]*actionError, len(actions))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := range actions {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = batchDeadlineError
+			continue
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = batchDeadlineError
+				return
+			}
+			lros[i], errs[i] = h.subscriptionAction(ctx, &actions[i])
+		})
+	}
+	wg.Wait()
+	return lros, errs
+}
+
+// HandleBatchSubscriptionAction processes a batch of APPROVE/REJECT actions.
+// Every action is attempted, concurrently and within the deadline set by SetWriteTimeout;
+// the response reports the outcome of each one along with a summary, so a failed action
+// does not fail the whole batch. It answers 202 Accepted if any approval was queued.
+func (h *adminHandler) HandleBatchSubscriptionAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req model.BatchOperationActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to decode request body for batch action", "error", err)
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+	if len(req.Actions) == 0 || len(req.Actions) > maxBatchActions {
+		slog.WarnContext(ctx, "AdminLROHandler: Invalid batch size", "count", len(req.Actions))
+		writeAdminJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, fmt.Sprintf("A batch must contain between 1 and %d actions.", maxBatchActions))
+		return
+	}
+
+	lros, errs := h.batchActions(ctx, req.Actions)
+	result := &model.BatchResult[model.LRO]{}
+	status := http.StatusOK
+	for i, action := range req.Actions {
+		if errs[i] != nil {
+			result.AddError(action.OperationID, errs[i].err)
+			continue
+		}
+		if actionStatus(lros[i]) == http.StatusAccepted {
+			status = http.StatusAccepted
+		}
+		result.AddResult(action.OperationID, lros[i])
+	}
+	slog.InfoContext(ctx, "AdminLROHandler: Batch action processed", "total", result.Summary.Total, "succeeded", result.Summary.Succeeded, "failed", result.Summary.Failed)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.ErrorContext(ctx, "AdminLROHandler: Failed to encode batch action response", "error", err)
 	}
 }
@@ -24,6 +24,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
@@ -36,14 +37,37 @@ import (
 type mockAdminService struct {
 	lro *model.LRO
 	err error
+	// opErrs overrides err for specific operation ids.
+	opErrs map[string]error
+	// block makes actions wait for their context to be done.
+	block bool
+}
+
+func (m *mockAdminService) result(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error) {
+	if m.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err, ok := m.opErrs[req.OperationID]; ok {
+		return nil, err
+	}
+	if m.lro == nil && m.err == nil {
+		return &model.LRO{OperationID: req.OperationID, Status: model.LROStatusApproved}, nil
+	}
+	return m.lro, m.err
 }
 
 func (m *mockAdminService) ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error) {
-	return nil, m.lro, m.err
+	lro, err := m.result(ctx, req)
+	return nil, lro, err
 }
 
 func (m *mockAdminService) RejectSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.LRO, error) {
-	return m.lro, m.err
+	return m.result(ctx, req)
+}
+
+func (m *mockAdminService) ApproveNextPendingSubscription(ctx context.Context) (*model.Subscription, *model.LRO, error) {
+	return nil, m.lro, m.err
 }
 
 // TestNewAdminHandler_Success tests successful creation of AdminHandler.
@@ -150,6 +174,8 @@ func TestAdminHandler_HandleSubscriptionAction_Success(t *testing.T) {
 	operationID := "test-op-123"
 	approvedLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusApproved, Type: model.OperationTypeCreateSubscription}
 	rejectedLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusRejected, Type: model.OperationTypeCreateSubscription, ErrorDataJSON: []byte(`{"reason":"admin rejected"}`)}
+	queuedLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusPending, Type: model.OperationTypeCreateSubscription}
+	inProgressLRO := &model.LRO{OperationID: operationID, Status: model.LROStatusInProgress, Type: model.OperationTypeCreateSubscription}
 
 	tests := []struct {
 		name             string
@@ -170,6 +196,30 @@ func TestAdminHandler_HandleSubscriptionAction_Success(t *testing.T) {
 			wantStatusCode: http.StatusOK,
 			wantLROBody:    approvedLRO,
 		},
+		{
+			name: "approve subscription queued",
+			actionRequest: model.OperationActionRequest{
+				OperationID: operationID,
+				Action:      model.OperationActionApproveSubscription,
+			},
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.lro = queuedLRO
+			},
+			wantStatusCode: http.StatusAccepted,
+			wantLROBody:    queuedLRO,
+		},
+		{
+			name: "approve subscription in progress",
+			actionRequest: model.OperationActionRequest{
+				OperationID: operationID,
+				Action:      model.OperationActionApproveSubscription,
+			},
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.lro = inProgressLRO
+			},
+			wantStatusCode: http.StatusAccepted,
+			wantLROBody:    inProgressLRO,
+		},
 		{
 			name: "reject subscription success",
 			actionRequest: model.OperationActionRequest{
@@ -277,6 +327,21 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeOperationNotFound,
 			wantErrorMessage: fmt.Sprintf("Operation with id %s not found.", operationID),
 		},
+		{
+			name: "service returns ErrInvalidOperationID on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: "not a uuid", Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("%w: %q is not a UUID", service.ErrInvalidOperationID, "not a uuid")
+			},
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorType:    model.ErrorTypeValidationError,
+			wantErrorCode:    model.ErrorCodeBadRequest,
+			wantErrorMessage: `Malformed operation id: invalid operation id: "not a uuid" is not a UUID`,
+		},
 		{
 			name: "service returns ErrOperationNotFound on reject",
 			requestBody: func() []byte {
@@ -307,6 +372,36 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeDuplicateRequest,
 			wantErrorMessage: fmt.Sprintf("Operation %s has already been processed.", operationID),
 		},
+		{
+			name: "service returns ErrOperationConflict on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("%w: LRO %s is APPROVED, expected PENDING", repository.ErrOperationConflict, operationID)
+			},
+			wantStatusCode:   http.StatusConflict,
+			wantErrorType:    model.ErrorTypeConflictError,
+			wantErrorCode:    model.ErrorCodeDuplicateRequest,
+			wantErrorMessage: fmt.Sprintf("Operation %s has already been processed.", operationID),
+		},
+		{
+			name: "service returns ErrApprovalQueueFull on approve",
+			requestBody: func() []byte {
+				ar := model.OperationActionRequest{OperationID: operationID, Action: model.OperationActionApproveSubscription}
+				b, _ := json.Marshal(ar)
+				return b
+			}(),
+			mockServiceSetup: func(ms *mockAdminService) {
+				ms.err = fmt.Errorf("%w: operation %s", service.ErrApprovalQueueFull, operationID)
+			},
+			wantStatusCode:   http.StatusServiceUnavailable,
+			wantErrorType:    model.ErrorTypeInternalError,
+			wantErrorCode:    model.ErrorCodeServiceUnavailable,
+			wantErrorMessage: "Too many approvals are queued, retry later.",
+		},
 		{
 			name: "service returns generic error on approve",
 			requestBody: func() []byte {
@@ -364,3 +459,275 @@ func TestAdminHandler_HandleSubscriptionAction_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestAdminHandler_HandleSubscriptionAction_ErrorDebug(t *testing.T) {
+	svcErr := fmt.Errorf("failed to approve: %w", errors.New("encryption failed"))
+	tests := []struct {
+		name        string
+		errDebug    *model.ErrorDebugConfig
+		wantDetails *model.ErrorDetails
+	}{
+		{
+			name:        "debug disabled",
+			errDebug:    nil,
+			wantDetails: nil,
+		},
+		{
+			name:     "debug enabled",
+			errDebug: &model.ErrorDebugConfig{Enabled: true},
+			wantDetails: &model.ErrorDetails{
+				Chain: []string{"failed to approve: encryption failed", "encryption failed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewAdminHandler(&mockAdminService{err: svcErr})
+			if err != nil {
+				t.Fatalf("NewAdminHandler() error = %v", err)
+			}
+			h.SetErrorDebug(tt.errDebug)
+			body := `{"operation_id":"op-1","action":"APPROVE_SUBSCRIPTION"}`
+			req := httptest.NewRequest(http.MethodPost, "/operations/action", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			h.HandleSubscriptionAction(rr, req)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Fatalf("HandleSubscriptionAction() status code = %v, want %v", rr.Code, http.StatusInternalServerError)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantDetails, resp.Error.Details); diff != "" {
+				t.Errorf("HandleSubscriptionAction() details mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_HandleBatchSubscriptionAction(t *testing.T) {
+	mockSrv := &mockAdminService{opErrs: map[string]error{
+		"op-missing":   repository.ErrOperationNotFound,
+		"op-processed": service.ErrLROAlreadyProcessed,
+		"op-broken":    errors.New("internal service failure"),
+	}}
+	h, err := NewAdminHandler(mockSrv)
+	if err != nil {
+		t.Fatalf("NewAdminHandler() error = %v", err)
+	}
+	body := `{"actions":[
+		{"operation_id":"op-1","action":"APPROVE_SUBSCRIPTION"},
+		{"operation_id":"op-missing","action":"APPROVE_SUBSCRIPTION"},
+		{"operation_id":"op-2","action":"REJECT_SUBSCRIPTION"},
+		{"operation_id":"op-processed","action":"REJECT_SUBSCRIPTION","reason":"duplicate"},
+		{"operation_id":"op-broken","action":"APPROVE_SUBSCRIPTION"},
+		{"operation_id":"op-3","action":"REJECT_SUBSCRIPTION","reason":"invalid domain"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/operations/actions/batch", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.HandleBatchSubscriptionAction(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleBatchSubscriptionAction() status code = %v, want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var got model.BatchResult[model.LRO]
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v. Body: %s", err, rr.Body.String())
+	}
+	want := model.BatchResult[model.LRO]{
+		Items: []model.BatchItemResult[model.LRO]{
+			{ID: "op-1", Status: model.BatchItemStatusOK, Result: &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved}},
+			{ID: "op-missing", Status: model.BatchItemStatusError, Error: &model.Error{
+				Type: model.ErrorTypeNotFoundError, Code: model.ErrorCodeOperationNotFound, Message: "Operation with id op-missing not found.",
+			}},
+			{ID: "op-2", Status: model.BatchItemStatusError, Error: &model.Error{
+				Type: model.ErrorTypeValidationError, Code: model.ErrorCodeTypeInvalidAction, Message: "Reason is required for REJECT action.",
+			}},
+			{ID: "op-processed", Status: model.BatchItemStatusError, Error: &model.Error{
+				Type: model.ErrorTypeConflictError, Code: model.ErrorCodeDuplicateRequest, Message: "Operation op-processed has already been processed.",
+			}},
+			{ID: "op-broken", Status: model.BatchItemStatusError, Error: &model.Error{
+				Type: model.ErrorTypeInternalError, Code: model.ErrorCodeInternalServerError, Message: "Failed to process subscription action due to an internal error.",
+			}},
+			{ID: "op-3", Status: model.BatchItemStatusOK, Result: &model.LRO{OperationID: "op-3", Status: model.LROStatusApproved}},
+		},
+		Summary: model.BatchSummary{Total: 6, Succeeded: 2, Failed: 4},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("HandleBatchSubscriptionAction() response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminHandler_HandleBatchSubscriptionAction_Queued(t *testing.T) {
+	h, err := NewAdminHandler(&mockAdminService{lro: &model.LRO{OperationID: "op-1", Status: model.LROStatusPending}})
+	if err != nil {
+		t.Fatalf("NewAdminHandler() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/operations/actions/batch", strings.NewReader(`{"actions":[{"operation_id":"op-1","action":"APPROVE_SUBSCRIPTION"}]}`))
+	rr := httptest.NewRecorder()
+	h.HandleBatchSubscriptionAction(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("HandleBatchSubscriptionAction() status code = %v, want %v. Body: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+}
+
+func TestAdminHandler_HandleBatchSubscriptionAction_Deadline(t *testing.T) {
+	h, err := NewAdminHandler(&mockAdminService{block: true})
+	if err != nil {
+		t.Fatalf("NewAdminHandler() error = %v", err)
+	}
+	h.SetWriteTimeout(100 * time.Millisecond)
+	batch := model.BatchOperationActionRequest{}
+	for i := range batchConcurrency + 2 {
+		batch.Actions = append(batch.Actions, model.OperationActionRequest{OperationID: fmt.Sprintf("op-%d", i), Action: model.OperationActionApproveSubscription})
+	}
+	body, _ := json.Marshal(batch)
+	req := httptest.NewRequest(http.MethodPost, "/operations/actions/batch", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	start := time.Now()
+	h.HandleBatchSubscriptionAction(rr, req)
+
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("HandleBatchSubscriptionAction() took %s, want less than the write timeout", elapsed)
+	}
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleBatchSubscriptionAction() status code = %v, want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var got model.BatchResult[model.LRO]
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v. Body: %s", err, rr.Body.String())
+	}
+	if want := (model.BatchSummary{Total: len(batch.Actions), Failed: len(batch.Actions)}); got.Summary != want {
+		t.Errorf("HandleBatchSubscriptionAction() summary = %+v, want %+v", got.Summary, want)
+	}
+	for _, item := range got.Items {
+		if item.Error == nil || item.Error.Code != model.ErrorCodeServiceUnavailable {
+			t.Errorf("HandleBatchSubscriptionAction() item %s error = %+v, want %s", item.ID, item.Error, model.ErrorCodeServiceUnavailable)
+		}
+	}
+}
+
+func TestAdminHandler_HandleBatchSubscriptionAction_Error(t *testing.T) {
+	tooMany := model.BatchOperationActionRequest{}
+	for i := range maxBatchActions + 1 {
+		tooMany.Actions = append(tooMany.Actions, model.OperationActionRequest{OperationID: fmt.Sprintf("op-%d", i), Action: model.OperationActionApproveSubscription})
+	}
+	tooManyBody, _ := json.Marshal(tooMany)
+
+	tests := []struct {
+		name          string
+		body          string
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:          "invalid JSON",
+			body:          "not json",
+			wantErrorCode: model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:          "empty batch",
+			body:          `{"actions":[]}`,
+			wantErrorCode: model.ErrorCodeBadRequest,
+		},
+		{
+			name:          "batch too large",
+			body:          string(tooManyBody),
+			wantErrorCode: model.ErrorCodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewAdminHandler(&mockAdminService{})
+			if err != nil {
+				t.Fatalf("NewAdminHandler() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/operations/actions/batch", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			h.HandleBatchSubscriptionAction(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("HandleBatchSubscriptionAction() status code = %v, want %v", rr.Code, http.StatusBadRequest)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Error.Code != tt.wantErrorCode {
+				t.Errorf("HandleBatchSubscriptionAction() Error.Code = %s, want %s", resp.Error.Code, tt.wantErrorCode)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_HandleApproveNextSubscription(t *testing.T) {
+	tests := []struct {
+		name          string
+		srv           *mockAdminService
+		wantStatus    int
+		wantOperation string
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:          "approved",
+			srv:           &mockAdminService{lro: &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved}},
+			wantStatus:    http.StatusOK,
+			wantOperation: "op-1",
+		},
+		{
+			name:       "nothing pending",
+			srv:        &mockAdminService{err: repository.ErrNoPendingOperation},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:          "claimed by another action",
+			srv:           &mockAdminService{lro: &model.LRO{OperationID: "op-1"}, err: service.ErrLROAlreadyProcessed},
+			wantStatus:    http.StatusConflict,
+			wantErrorCode: model.ErrorCodeDuplicateRequest,
+		},
+		{
+			name:          "approval fails",
+			srv:           &mockAdminService{err: errors.New("callback failed")},
+			wantStatus:    http.StatusInternalServerError,
+			wantErrorCode: model.ErrorCodeInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewAdminHandler(tt.srv)
+			if err != nil {
+				t.Fatalf("NewAdminHandler() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/operations/actions/approve-next", nil)
+			rr := httptest.NewRecorder()
+			h.HandleApproveNextSubscription(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("HandleApproveNextSubscription() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantOperation != "" {
+				var lro model.LRO
+				if err := json.Unmarshal(rr.Body.Bytes(), &lro); err != nil {
+					t.Fatalf("Failed to unmarshal response body: %v", err)
+				}
+				if lro.OperationID != tt.wantOperation {
+					t.Errorf("HandleApproveNextSubscription() operation = %q, want %q", lro.OperationID, tt.wantOperation)
+				}
+			}
+			if tt.wantErrorCode != "" {
+				var resp model.ErrorResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("Failed to unmarshal response body: %v", err)
+				}
+				if resp.Error.Code != tt.wantErrorCode {
+					t.Errorf("HandleApproveNextSubscription() Error.Code = %s, want %s", resp.Error.Code, tt.wantErrorCode)
+				}
+			}
+		})
+	}
+}
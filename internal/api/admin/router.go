@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -25,6 +28,8 @@ import (
 // adminHandler defines the interface for admin LRO handlers.
 type adminHandler interface {
 	HandleSubscriptionAction(w http.ResponseWriter, r *http.Request)
+	HandleBatchSubscriptionAction(w http.ResponseWriter, r *http.Request)
+	HandleApproveNextSubscription(w http.ResponseWriter, r *http.Request)
 }
 
 // NewRouter configures and returns the Chi router for the Admin service functionalities.
@@ -34,6 +39,8 @@ func NewRouter(lroh adminHandler, oidcMiddleware func(http.Handler) http.Handler
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.RequestID)
+	router.Use(apiversion.Middleware)
+	router.Use(correlation.Middleware)
 
 	// Health check endpoint (good practice)
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -44,8 +51,12 @@ func NewRouter(lroh adminHandler, oidcMiddleware func(http.Handler) http.Handler
 
 	if oidcMiddleware != nil {
 		router.With(oidcMiddleware).Post("/operations/action", lroh.HandleSubscriptionAction)
+		router.With(oidcMiddleware).Post("/operations/actions/batch", lroh.HandleBatchSubscriptionAction)
+		router.With(oidcMiddleware).Post("/operations/actions/approve-next", lroh.HandleApproveNextSubscription)
 	} else {
 		router.Post("/operations/action", lroh.HandleSubscriptionAction)
+		router.Post("/operations/actions/batch", lroh.HandleBatchSubscriptionAction)
+		router.Post("/operations/actions/approve-next", lroh.HandleApproveNextSubscription)
 	}
 	return router
 }
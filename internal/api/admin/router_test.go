@@ -23,7 +23,9 @@ import (
 )
 
 type mockAdminHandler struct {
-	handleSubscriptionActionCalled bool
+	handleSubscriptionActionCalled      bool
+	handleBatchSubscriptionActionCalled bool
+	handleApproveNextCalled             bool
 }
 
 func (m *mockAdminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *http.Request) {
@@ -31,6 +33,16 @@ func (m *mockAdminHandler) HandleSubscriptionAction(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockAdminHandler) HandleBatchSubscriptionAction(w http.ResponseWriter, r *http.Request) {
+	m.handleBatchSubscriptionActionCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockAdminHandler) HandleApproveNextSubscription(w http.ResponseWriter, r *http.Request) {
+	m.handleApproveNextCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func TestRouter_Routes(t *testing.T) {
 	h := &mockAdminHandler{}
 
@@ -51,7 +63,7 @@ func TestRouter_Routes(t *testing.T) {
 			path:            "/health",
 			expectedStatus:  http.StatusOK,
 			expectedBody:    `{"status":"ok"}`,
-			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}, "X-Api-Version": []string{"2"}},
 			handlerCheck:    func(t *testing.T) { /* No specific handler mock to check */ },
 		},
 		{
@@ -65,6 +77,28 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "BatchSubscriptionAction",
+			method:         http.MethodPost,
+			path:           "/operations/actions/batch",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !h.handleBatchSubscriptionActionCalled {
+					t.Error("HandleBatchSubscriptionAction was not called")
+				}
+			},
+		},
+		{
+			name:           "ApproveNextSubscription",
+			method:         http.MethodPost,
+			path:           "/operations/actions/approve-next",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !h.handleApproveNextCalled {
+					t.Error("HandleApproveNextSubscription was not called")
+				}
+			},
+		},
 	}
 
 	for _, tc := range tests {
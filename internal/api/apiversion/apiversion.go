@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apiversion negotiates the API version of HTTP responses.
+package apiversion
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// versionKey is the context key of the API version negotiated for a request.
+type versionKey struct{}
+
+// defaultKey is the context key of the API version served by Middleware when a request does not name one.
+type defaultKey struct{}
+
+// supported are the API versions a client can request.
+var supported = map[string]bool{
+	model.APIVersion1: true,
+	model.APIVersion2: true,
+}
+
+// Supported returns the API versions a client can request, in ascending order.
+func Supported() []string {
+	versions := make([]string, 0, len(supported))
+	for v := range supported {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// IsSupported reports whether a client can request version v.
+func IsSupported(v string) bool {
+	return supported[v]
+}
+
+// Default returns a middleware making Middleware serve version, instead of the current version,
+// to the requests that do not request a supported one. It must run before Middleware.
+// An empty version keeps the current version.
+func Default(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if version == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), defaultKey{}, version)))
+		})
+	}
+}
+
+// Middleware selects the API version of each request from its Accept-Version header,
+// defaulting to the current version or the one set by Default, and reports it in the
+// X-API-Version response header. An unsupported version is served as the default version.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := model.APIVersionCurrent
+		if v, ok := r.Context().Value(defaultKey{}).(string); ok {
+			version = v
+		}
+		if requested := strings.TrimSpace(r.Header.Get(model.AcceptVersionHeader)); requested != "" {
+			if supported[requested] {
+				version = requested
+			} else {
+				slog.DebugContext(r.Context(), "Unsupported API version requested, serving default version", "requested", requested, "version", version)
+			}
+		}
+		// Set before the handler runs, since headers set after WriteHeader are not sent.
+		w.Header().Set(model.APIVersionHeader, version)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	})
+}
+
+// FromContext returns the API version negotiated for the request of ctx,
+// or the current version if the request did not pass through Middleware.
+func FromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(versionKey{}).(string); ok {
+		return v
+	}
+	return model.APIVersionCurrent
+}
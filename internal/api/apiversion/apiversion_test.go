@@ -0,0 +1,116 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+	}{
+		{name: "default to current version", want: model.APIVersionCurrent},
+		{name: "older version", requested: model.APIVersion1, want: model.APIVersion1},
+		{name: "current version", requested: model.APIVersion2, want: model.APIVersion2},
+		{name: "surrounding spaces", requested: " 1 ", want: model.APIVersion1},
+		{name: "unsupported version", requested: "99", want: model.APIVersionCurrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCtx string
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCtx = FromContext(r.Context())
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+			if tt.requested != "" {
+				req.Header.Set(model.AcceptVersionHeader, tt.requested)
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get(model.APIVersionHeader); got != tt.want {
+				t.Errorf("%s header = %q, want %q", model.APIVersionHeader, got, tt.want)
+			}
+			if gotCtx != tt.want {
+				t.Errorf("FromContext() = %q, want %q", gotCtx, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultVersion string
+		requested      string
+		want           string
+	}{
+		{name: "configured default", defaultVersion: model.APIVersion1, want: model.APIVersion1},
+		{name: "empty default keeps current version", want: model.APIVersionCurrent},
+		{name: "requested version wins", defaultVersion: model.APIVersion1, requested: model.APIVersion2, want: model.APIVersion2},
+		{name: "unsupported version served as default", defaultVersion: model.APIVersion1, requested: "99", want: model.APIVersion1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCtx string
+			h := Default(tt.defaultVersion)(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCtx = FromContext(r.Context())
+			})))
+			req := httptest.NewRequest(http.MethodGet, "/operations/op1", nil)
+			if tt.requested != "" {
+				req.Header.Set(model.AcceptVersionHeader, tt.requested)
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get(model.APIVersionHeader); got != tt.want {
+				t.Errorf("%s header = %q, want %q", model.APIVersionHeader, got, tt.want)
+			}
+			if gotCtx != tt.want {
+				t.Errorf("FromContext() = %q, want %q", gotCtx, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSupported(t *testing.T) {
+	for _, v := range Supported() {
+		if !IsSupported(v) {
+			t.Errorf("IsSupported(%q) = false, want true", v)
+		}
+	}
+	if IsSupported("99") {
+		t.Errorf("IsSupported(%q) = true, want false", "99")
+	}
+}
+
+func TestFromContext_NoMiddleware(t *testing.T) {
+	if got := FromContext(context.Background()); got != model.APIVersionCurrent {
+		t.Errorf("FromContext() = %q, want %q", got, model.APIVersionCurrent)
+	}
+}
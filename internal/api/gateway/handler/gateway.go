@@ -22,11 +22,12 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 type gatewayAuthValidator interface {
-	Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError
+	Validate(ctx context.Context, body []byte, authHeader, requestID string) *model.AuthError
 }
 
 type taskQueuer interface {
@@ -36,6 +37,7 @@ type taskQueuer interface {
 type gatewayHandler struct {
 	authValidator gatewayAuthValidator
 	taskQueuer    taskQueuer
+	authHeader    string
 }
 
 func NewGatewayHandler(authValidator gatewayAuthValidator, taskQueuer taskQueuer) (*gatewayHandler, error) {
@@ -47,7 +49,16 @@ func NewGatewayHandler(authValidator gatewayAuthValidator, taskQueuer taskQueuer
 		slog.Error("NewGatewayHandler: taskQueuer dependency is nil.")
 		return nil, errors.New("taskQueuer dependency is nil")
 	}
-	return &gatewayHandler{authValidator: authValidator, taskQueuer: taskQueuer}, nil
+	return &gatewayHandler{authValidator: authValidator, taskQueuer: taskQueuer, authHeader: model.AuthHeaderSubscriber}, nil
+}
+
+// SetSignatureHeader sets the name of the header requests carry their signature in,
+// for networks that do not use Authorization. An empty name restores Authorization.
+func (h *gatewayHandler) SetSignatureHeader(name string) {
+	if name == "" {
+		name = model.AuthHeaderSubscriber
+	}
+	h.authHeader = name
 }
 
 func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
@@ -61,9 +72,12 @@ func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	authHeader := r.Header.Get(model.AuthHeaderSubscriber)
-	if authErr := h.authValidator.Validate(ctx, bodyBytes, authHeader); authErr != nil {
+	authHeader := r.Header.Get(h.authHeader)
+	if authErr := h.authValidator.Validate(ctx, bodyBytes, authHeader, r.Header.Get(model.RequestIDHeader)); authErr != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Authentication failed", "error", authErr)
+		if authErr.StatusCode == http.StatusUnauthorized {
+			w.Header().Set(model.ChallengeHeaderFor(h.authHeader), service.UnauthorizedHeader(authErr.SubscriberID))
+		}
 		writeGatewayError(w, authErr.StatusCode, string(authErr.ErrorCode), authErr.Message)
 		return
 	}
@@ -75,9 +89,18 @@ func (h *gatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 		writeGatewayError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body.")
 		return
 	}
+	if err := service.ValidateLookupCriteria(&txnReq.Context); err != nil {
+		slog.ErrorContext(ctx, "GatewayHandler: Rejecting search with ambiguous lookup criteria", "error", err, "bpp_id", txnReq.Context.BppID)
+		writeGatewayError(w, http.StatusBadRequest, "INVALID_LOOKUP_CRITERIA", err.Error())
+		return
+	}
 	queuedTask, err := h.taskQueuer.QueueTxn(ctx, &txnReq.Context, bodyBytes, r.Header.Clone())
 	if err != nil {
 		slog.ErrorContext(ctx, "GatewayHandler: Failed to queue task via QueueTxn", "error", err)
+		if errors.Is(err, service.ErrQueueFull) {
+			writeGatewayError(w, http.StatusServiceUnavailable, "QUEUE_FULL", "Task queue is full, retry later.")
+			return
+		}
 		writeGatewayError(w, http.StatusInternalServerError, "QUEUEING_FAILED", "Failed to queue task.")
 		return
 	}
@@ -19,20 +19,26 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 // mockGatewayAuthValidator is a mock implementation of gatewayAuthValidator.
 type mockGatewayAuthValidator struct {
-	validateErr *model.AuthError
+	validateErr   *model.AuthError
+	gotAuthHeader string
+	gotRequestID  string
 }
 
-func (m *mockGatewayAuthValidator) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
+func (m *mockGatewayAuthValidator) Validate(ctx context.Context, body []byte, authHeader, requestID string) *model.AuthError {
+	m.gotAuthHeader = authHeader
+	m.gotRequestID = requestID
 	return m.validateErr
 }
 
@@ -159,6 +165,7 @@ func TestServeHttp_Success(t *testing.T) {
 	reqBody := `{"context":{"action":"search"},"message":{}}`
 	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBufferString(reqBody))
 	req.Header.Set(model.AuthHeaderSubscriber, "test-auth-header")
+	req.Header.Set(model.RequestIDHeader, "req-1")
 	rr := httptest.NewRecorder()
 
 	handler.ServeHttp(rr, req)
@@ -166,6 +173,9 @@ func TestServeHttp_Success(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Errorf("ServeHttp() status code = %v, want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
 	}
+	if mockAuth.gotRequestID != "req-1" {
+		t.Errorf("Validate() called with request id %q, want %q", mockAuth.gotRequestID, "req-1")
+	}
 
 	var resp model.TxnResponse
 	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
@@ -231,6 +241,46 @@ func TestServeHttp_AuthValidationError(t *testing.T) {
 	}
 }
 
+func TestServeHttp_CustomSignatureHeader(t *testing.T) {
+	authErr := model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid signature.", "test-sub")
+	tests := []struct {
+		name          string
+		validateErr   *model.AuthError
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "valid signature", wantStatus: http.StatusOK},
+		{name: "invalid signature", validateErr: authErr, wantStatus: http.StatusUnauthorized, wantChallenge: `Signature realm="test-sub",headers="(created) (expires) digest"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := &mockGatewayAuthValidator{validateErr: tt.validateErr}
+			handler, _ := NewGatewayHandler(mockAuth, &mockTaskQueuer{queueTxnTask: &model.AsyncTask{}})
+			handler.SetSignatureHeader("X-Beckn-Authorization")
+
+			req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"context":{"action":"search"},"message":{}}`))
+			req.Header.Set(model.AuthHeaderSubscriber, "standard-header")
+			req.Header.Set("X-Beckn-Authorization", "custom-header")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHttp(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("ServeHttp() status code = %v, want %v", rr.Code, tt.wantStatus)
+			}
+			if mockAuth.gotAuthHeader != "custom-header" {
+				t.Errorf("Validate() called with %q, want the custom header value", mockAuth.gotAuthHeader)
+			}
+			if got := rr.Header().Get("X-Beckn-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("X-Beckn-Authenticate header = %q, want %q", got, tt.wantChallenge)
+			}
+			if got := rr.Header().Get(model.UnauthorizedHeaderSubscriber); got != "" {
+				t.Errorf("%s header = %q, want none", model.UnauthorizedHeaderSubscriber, got)
+			}
+		})
+	}
+}
+
 // TestServeHttp_UnmarshalBodyError tests when unmarshalling the request body fails.
 func TestServeHttp_UnmarshalBodyError(t *testing.T) {
 	mockAuth := &mockGatewayAuthValidator{}
@@ -254,6 +304,32 @@ func TestServeHttp_UnmarshalBodyError(t *testing.T) {
 	}
 }
 
+// TestServeHttp_AmbiguousLookupCriteria tests that a search with ambiguous lookup criteria is NACKed
+// before it is queued.
+func TestServeHttp_AmbiguousLookupCriteria(t *testing.T) {
+	mockQueuer := &mockTaskQueuer{queueTxnErr: errors.New("QueueTxn() must not be called")}
+	handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+
+	reqBody := `{"context":{"action":"search","domain":"retail","location":{}},"message":{}}`
+	req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(reqBody))
+	req.Header.Set(model.AuthHeaderSubscriber, "test-auth-header")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHttp(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("ServeHttp() status code = %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+	var errResp model.TxnResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &errResp)
+	if errResp.Message.Ack.Status != model.StatusNACK {
+		t.Errorf("Response Ack Status = %q, want %q", errResp.Message.Ack.Status, model.StatusNACK)
+	}
+	if errResp.Message.Error == nil || errResp.Message.Error.Code != "INVALID_LOOKUP_CRITERIA" {
+		t.Errorf("Error = %+v, want code %q", errResp.Message.Error, "INVALID_LOOKUP_CRITERIA")
+	}
+}
+
 // TestServeHttp_QueueTaskError tests when queuing a task fails.
 func TestServeHttp_QueueTaskError(t *testing.T) {
 	mockAuth := &mockGatewayAuthValidator{}
@@ -279,6 +355,29 @@ func TestServeHttp_QueueTaskError(t *testing.T) {
 	}
 }
 
+// TestServeHttp_QueueFull tests that a task dropped by a full queue is answered with 503.
+func TestServeHttp_QueueFull(t *testing.T) {
+	mockQueuer := &mockTaskQueuer{
+		queueTxnErr: fmt.Errorf("%w: 100 tasks queued", service.ErrQueueFull),
+	}
+	handler, _ := NewGatewayHandler(&mockGatewayAuthValidator{}, mockQueuer)
+
+	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBufferString(`{"context":{"action":"search"},"message":{}}`))
+	req.Header.Set(model.AuthHeaderSubscriber, "test-auth-header")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHttp(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("ServeHttp() status code = %v, want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+	var errResp model.TxnResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &errResp)
+	if errResp.Message.Error.Code != "QUEUE_FULL" {
+		t.Errorf("Error Code = %q, want %q", errResp.Message.Error.Code, "QUEUE_FULL")
+	}
+}
+
 // TestServeHttp_EncodeResponseError tests when encoding the successful response fails.
 func TestServeHttp_EncodeResponseError(t *testing.T) {
 	mockAuth := &mockGatewayAuthValidator{}
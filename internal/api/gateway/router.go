@@ -15,9 +15,18 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/clientaddr"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -27,24 +36,171 @@ type gatewayHandler interface {
 	ServeHttp(w http.ResponseWriter, r *http.Request)
 }
 
+// metricsCollector counts requests and serves snapshots of the metrics of the service.
+type metricsCollector interface {
+	http.Handler
+	Middleware(next http.Handler) http.Handler
+}
+
+// queueController pauses and resumes the processing of queued tasks.
+type queueController interface {
+	Pause()
+	Resume()
+	Paused() bool
+	Depth() int
+}
+
+// queueState is the response of the task queue admin endpoints.
+type queueState struct {
+	Paused bool `json:"paused"`
+	Depth  int  `json:"depth"`
+}
+
+// proxyTaskArchive retrieves and replays the archived payloads of proxied tasks.
+type proxyTaskArchive interface {
+	Records(ctx context.Context, transactionID, messageID string) ([]service.ProxyTaskRecord, error)
+	Replay(ctx context.Context, transactionID, messageID, target string) error
+}
+
+// replayRequest is the request of the archive replay endpoint.
+type replayRequest struct {
+	Target string `json:"target"` // Target of the archived record to replay, as returned by the retrieval endpoint.
+}
+
+// becknActions are the Beckn actions the gateway accepts, each served at POST /<action>.
+var becknActions = []string{"search", "on_search"}
+
+// capabilitiesHandler returns a handler responding with the capability manifest of the gateway.
+func capabilitiesHandler(features map[string]bool, algs []string) http.HandlerFunc {
+	if features == nil {
+		features = map[string]bool{}
+	}
+	if algs == nil {
+		algs = []string{}
+	}
+	caps := model.Capabilities{
+		Service:             "gateway",
+		APIVersion:          model.APIVersionCurrent,
+		APIVersions:         apiversion.Supported(),
+		Actions:             becknActions,
+		SignatureAlgorithms: algs,
+		Features:            features,
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(caps)
+	}
+}
+
+// queueStateHandler returns a handler that applies change, if any, and responds with the state of qc.
+func queueStateHandler(qc queueController, change func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if change != nil {
+			change()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(queueState{Paused: qc.Paused(), Depth: qc.Depth()})
+	}
+}
+
+// archiveRecordsHandler returns a handler responding with the archived records of the message
+// in the transaction_id and message_id URL parameters.
+func archiveRecordsHandler(archive proxyTaskArchive) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		records, err := archive.Records(r.Context(), chi.URLParam(r, "transaction_id"), chi.URLParam(r, "message_id"))
+		if errors.Is(err, service.ErrArchiveRecordNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Router: Failed to read proxy task archive", "error", err)
+			http.Error(w, "Failed to read proxy task archive", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(records)
+	}
+}
+
+// archiveReplayHandler returns a handler replaying the archived record of the message in the
+// transaction_id and message_id URL parameters to the target in the request body.
+// A failed replay responds with a 502, or a 503 if the target host is at its callback limit.
+func archiveReplayHandler(archive proxyTaskArchive) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req replayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Target == "" {
+			http.Error(w, "Request body must be a JSON object with a target", http.StatusBadRequest)
+			return
+		}
+		err := archive.Replay(r.Context(), chi.URLParam(r, "transaction_id"), chi.URLParam(r, "message_id"), req.Target)
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case errors.Is(err, service.ErrArchiveRecordNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrCallbackLimitReached):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			var proxyErr *service.ProxyTaskError
+			if errors.As(err, &proxyErr) {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			slog.ErrorContext(r.Context(), "Router: Failed to replay proxy task", "error", err)
+			http.Error(w, "Failed to replay proxy task", http.StatusInternalServerError)
+		}
+	}
+}
+
 // NewRouter configures and returns the Chi router for the Registry service.
-func NewRouter(gh gatewayHandler) *chi.Mux {
+// If mc is not nil, requests are counted in it and its snapshot is served at GET /debug/metrics.
+// If qc and queueAuth are not nil, the task queue can be inspected at GET /admin/queue and paused or
+// resumed with POST /admin/queue/pause and POST /admin/queue/resume. These endpoints are
+// only served behind queueAuth, since they can halt all processing of the gateway.
+// If archive and queueAuth are not nil, the archived records of a message can be read at
+// GET /admin/archive/{transaction_id}/{message_id} and replayed to one of their targets with
+// POST /admin/archive/{transaction_id}/{message_id}/replay, behind queueAuth as they expose payloads.
+// The capability manifest, reporting features as the optional features of the deployment and
+// algs as the signature algorithms it accepts, is served at GET /capabilities and GET /.well-known/beckn-onix.
+func NewRouter(gh gatewayHandler, mc metricsCollector, qc queueController, queueAuth func(http.Handler) http.Handler, archive proxyTaskArchive, features map[string]bool, algs []string) *chi.Mux {
 	router := chi.NewRouter()
 
 	// Standard middleware stack
 	router.Use(middleware.RealIP)
+	router.Use(clientaddr.Middleware)
 	router.Use(middleware.Logger) // Chi's structured logger
 	router.Use(middleware.Recoverer)
+	router.Use(apiversion.Middleware)
+	if mc != nil {
+		router.Use(mc.Middleware)
+		router.Method(http.MethodGet, "/debug/metrics", mc)
+	}
+	if qc != nil && queueAuth != nil {
+		router.With(queueAuth).Get("/admin/queue", queueStateHandler(qc, nil))
+		router.With(queueAuth).Post("/admin/queue/pause", queueStateHandler(qc, qc.Pause))
+		router.With(queueAuth).Post("/admin/queue/resume", queueStateHandler(qc, qc.Resume))
+	}
+	if archive != nil && queueAuth != nil {
+		router.With(queueAuth).Get("/admin/archive/{transaction_id}/{message_id}", archiveRecordsHandler(archive))
+		router.With(queueAuth).Post("/admin/archive/{transaction_id}/{message_id}/replay", archiveReplayHandler(archive))
+	}
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, `{"status":"ok"}`)
 	})
+	caps := capabilitiesHandler(features, algs)
+	router.Get("/capabilities", caps)
+	router.Get("/.well-known/beckn-onix", caps)
 
 	// Beckn specific routes
 	// Group for routes that might share common Beckn-specific middleware or prefixes
-	router.Post("/search", gh.ServeHttp)
-	router.Post("/on_search", gh.ServeHttp)
+	for _, action := range becknActions {
+		router.Post("/"+action, gh.ServeHttp)
+	}
 
 	return router
 }
@@ -15,10 +15,18 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/metrics"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -34,16 +42,16 @@ func (m *mockGatewayHandler) ServeHttp(w http.ResponseWriter, r *http.Request) {
 
 func TestNewRouter(t *testing.T) {
 	gh := &mockGatewayHandler{}
-	router := NewRouter(gh)
+	router := NewRouter(gh, nil, nil, nil, nil, nil, nil)
 
 	if router == nil {
-		t.Fatal("NewRouter() returned nil, expected a chi.Mux router")
+		t.Fatal("NewRouter() returned nil, expected a chi.Mux router", nil)
 	}
 }
 
 func TestRouter_Middleware_Recoverer(t *testing.T) {
 	gh := &mockGatewayHandler{}
-	router := NewRouter(gh)
+	router := NewRouter(gh, nil, nil, nil, nil, nil, nil)
 
 	// Add a temporary route that panics
 	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +73,7 @@ func TestRouter_Middleware_Recoverer(t *testing.T) {
 
 func TestRouter_Routes(t *testing.T) {
 	gh := &mockGatewayHandler{}
-	router := NewRouter(gh)
+	router := NewRouter(gh, nil, nil, nil, nil, nil, nil)
 
 	tests := []struct {
 		name            string
@@ -82,7 +90,7 @@ func TestRouter_Routes(t *testing.T) {
 			path:            "/health",
 			expectedStatus:  http.StatusOK,
 			expectedBody:    `{"status":"ok"}`,
-			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}, "X-Api-Version": []string{"2"}},
 			handlerCheck: func(t *testing.T, h *mockGatewayHandler) {
 				if h.serveHttpCalled {
 					t.Error("ServeHttp was called for /health, but should not have been")
@@ -155,3 +163,304 @@ func TestRouter_Routes(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_DebugMetrics(t *testing.T) {
+	gh := &mockGatewayHandler{}
+	router := NewRouter(gh, metrics.NewCollector(), nil, nil, nil, nil, nil)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/search", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/on_search", nil))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /debug/metrics status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var got metrics.Snapshot
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+	}
+	for _, name := range []string{
+		"http_requests_total{method=POST,route=/search,status=200}",
+		"http_requests_total{method=POST,route=/on_search,status=200}",
+	} {
+		if got.Counters[name] != 1 {
+			t.Errorf("counter %q = %d, want 1", name, got.Counters[name])
+		}
+	}
+}
+
+func TestRouter_DebugMetrics_Disabled(t *testing.T) {
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, nil, nil, nil, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET /debug/metrics status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+// mockQueueController is a mock implementation of the queueController interface.
+type mockQueueController struct {
+	paused bool
+	depth  int
+}
+
+func (m *mockQueueController) Pause()       { m.paused = true }
+func (m *mockQueueController) Resume()      { m.paused = false }
+func (m *mockQueueController) Paused() bool { return m.paused }
+func (m *mockQueueController) Depth() int   { return m.depth }
+
+// tokenAuth is a queue auth middleware admitting requests with the Authorization header "Bearer ok".
+func tokenAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRouter_QueueAdmin(t *testing.T) {
+	qc := &mockQueueController{depth: 3}
+	router := NewRouter(&mockGatewayHandler{}, nil, qc, tokenAuth, nil, nil, nil)
+
+	tests := []struct {
+		method string
+		path   string
+		want   queueState
+	}{
+		{method: http.MethodGet, path: "/admin/queue", want: queueState{Paused: false, Depth: 3}},
+		{method: http.MethodPost, path: "/admin/queue/pause", want: queueState{Paused: true, Depth: 3}},
+		{method: http.MethodGet, path: "/admin/queue", want: queueState{Paused: true, Depth: 3}},
+		{method: http.MethodPost, path: "/admin/queue/resume", want: queueState{Paused: false, Depth: 3}},
+	}
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer ok")
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s %s status = %d, want %d", tc.method, tc.path, rr.Code, http.StatusOK)
+		}
+		var got queueState
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s %s response mismatch (-want +got):\n%s", tc.method, tc.path, diff)
+		}
+	}
+}
+
+func TestRouter_QueueAdmin_Unauthorized(t *testing.T) {
+	qc := &mockQueueController{depth: 3}
+	router := NewRouter(&mockGatewayHandler{}, nil, qc, tokenAuth, nil, nil, nil)
+
+	for _, path := range []string{"/admin/queue/pause", "/admin/queue/resume"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("POST %s status = %d, want %d", path, rr.Code, http.StatusUnauthorized)
+		}
+	}
+	if qc.paused {
+		t.Errorf("unauthenticated request paused the queue")
+	}
+}
+
+func TestRouter_QueueAdmin_NoAuth(t *testing.T) {
+	qc := &mockQueueController{}
+	router := NewRouter(&mockGatewayHandler{}, nil, qc, nil, nil, nil, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/queue/pause", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("POST /admin/queue/pause status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if qc.paused {
+		t.Errorf("queue paused without queue auth")
+	}
+}
+
+func TestRouter_QueueAdmin_Disabled(t *testing.T) {
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, nil, nil, nil, nil)
+	for _, path := range []string{"/admin/queue", "/admin/queue/pause", "/admin/queue/resume"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if rr.Code == http.StatusOK {
+			t.Errorf("POST %s status = %d, want it not to be served", path, rr.Code)
+		}
+	}
+}
+
+// mockProxyTaskArchive is a mock implementation of the proxyTaskArchive interface.
+type mockProxyTaskArchive struct {
+	records   []service.ProxyTaskRecord
+	err       error
+	replayErr error
+	replayed  string
+}
+
+func (m *mockProxyTaskArchive) Records(ctx context.Context, transactionID, messageID string) ([]service.ProxyTaskRecord, error) {
+	return m.records, m.err
+}
+
+func (m *mockProxyTaskArchive) Replay(ctx context.Context, transactionID, messageID, target string) error {
+	m.replayed = transactionID + "/" + messageID + "/" + target
+	return m.replayErr
+}
+
+func TestRouter_ArchiveRecords(t *testing.T) {
+	records := []service.ProxyTaskRecord{{TransactionID: "txn-1", MessageID: "msg-1", Action: "on_search", Target: "http://bap.com/on_search", StatusCode: http.StatusOK}}
+	tests := []struct {
+		name     string
+		archive  *mockProxyTaskArchive
+		wantCode int
+		want     []service.ProxyTaskRecord
+	}{
+		{name: "found", archive: &mockProxyTaskArchive{records: records}, wantCode: http.StatusOK, want: records},
+		{name: "not found", archive: &mockProxyTaskArchive{err: service.ErrArchiveRecordNotFound}, wantCode: http.StatusNotFound},
+		{name: "store error", archive: &mockProxyTaskArchive{err: errors.New("redis down")}, wantCode: http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(&mockGatewayHandler{}, nil, nil, tokenAuth, tc.archive, nil, nil)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin/archive/txn-1/msg-1", nil)
+			req.Header.Set("Authorization", "Bearer ok")
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantCode {
+				t.Fatalf("GET /admin/archive status = %d, want %d, body %s", rr.Code, tc.wantCode, rr.Body)
+			}
+			if tc.want == nil {
+				return
+			}
+			var got []service.ProxyTaskRecord
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GET /admin/archive response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRouter_ArchiveReplay(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		replayErr    error
+		wantCode     int
+		wantReplayed string
+	}{
+		{name: "replayed", body: `{"target":"http://bap.com/on_search"}`, wantCode: http.StatusNoContent, wantReplayed: "txn-1/msg-1/http://bap.com/on_search"},
+		{name: "invalid body", body: `{`, wantCode: http.StatusBadRequest},
+		{name: "missing target", body: `{}`, wantCode: http.StatusBadRequest},
+		{name: "not found", body: `{"target":"http://bap.com/on_search"}`, replayErr: service.ErrArchiveRecordNotFound, wantCode: http.StatusNotFound, wantReplayed: "txn-1/msg-1/http://bap.com/on_search"},
+		{name: "callback limit", body: `{"target":"http://bap.com/on_search"}`, replayErr: &service.ProxyTaskError{Target: "http://bap.com/on_search", Err: service.ErrCallbackLimitReached}, wantCode: http.StatusServiceUnavailable, wantReplayed: "txn-1/msg-1/http://bap.com/on_search"},
+		{name: "proxy failure", body: `{"target":"http://bap.com/on_search"}`, replayErr: &service.ProxyTaskError{Target: "http://bap.com/on_search", StatusCode: http.StatusInternalServerError, Err: errors.New("500")}, wantCode: http.StatusBadGateway, wantReplayed: "txn-1/msg-1/http://bap.com/on_search"},
+		{name: "store error", body: `{"target":"http://bap.com/on_search"}`, replayErr: errors.New("redis down"), wantCode: http.StatusInternalServerError, wantReplayed: "txn-1/msg-1/http://bap.com/on_search"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			archive := &mockProxyTaskArchive{replayErr: tc.replayErr}
+			router := NewRouter(&mockGatewayHandler{}, nil, nil, tokenAuth, archive, nil, nil)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/archive/txn-1/msg-1/replay", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer ok")
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantCode {
+				t.Errorf("POST /admin/archive/replay status = %d, want %d, body %s", rr.Code, tc.wantCode, rr.Body)
+			}
+			if archive.replayed != tc.wantReplayed {
+				t.Errorf("replayed = %q, want %q", archive.replayed, tc.wantReplayed)
+			}
+		})
+	}
+}
+
+func TestRouter_Archive_Unauthorized(t *testing.T) {
+	archive := &mockProxyTaskArchive{}
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, tokenAuth, archive, nil, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/archive/txn-1/msg-1/replay", strings.NewReader(`{"target":"http://bap.com"}`)))
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("POST /admin/archive/replay status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if archive.replayed != "" {
+		t.Errorf("unauthenticated request replayed %q", archive.replayed)
+	}
+}
+
+func TestRouter_Archive_NoAuth(t *testing.T) {
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, nil, &mockProxyTaskArchive{}, nil, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/archive/txn-1/msg-1", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET /admin/archive status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRouter_Capabilities(t *testing.T) {
+	features := map[string]bool{"bind_request_id": true, "queue_control": false}
+	algs := []string{model.SignatureAlgorithmEd25519, model.SignatureAlgorithmRSA}
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, nil, nil, features, algs)
+	want := model.Capabilities{
+		Service:             "gateway",
+		APIVersion:          model.APIVersionCurrent,
+		APIVersions:         []string{model.APIVersion1, model.APIVersion2},
+		Actions:             []string{"search", "on_search"},
+		SignatureAlgorithms: algs,
+		Features:            features,
+	}
+
+	for _, path := range []string{"/capabilities", "/.well-known/beckn-onix"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want %d", path, rr.Code, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s Content-Type = %q, want %q", path, ct, "application/json")
+		}
+		var got model.Capabilities
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("GET %s response mismatch (-want +got):\n%s", path, diff)
+		}
+	}
+}
+
+func TestRouter_Capabilities_ActionsAreServed(t *testing.T) {
+	router := NewRouter(&mockGatewayHandler{}, nil, nil, nil, nil, nil, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+	var caps model.Capabilities
+	if err := json.Unmarshal(rr.Body.Bytes(), &caps); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+	}
+	if caps.Features == nil {
+		t.Error("Features = nil, want an empty map")
+	}
+
+	for _, action := range caps.Actions {
+		gh := &mockGatewayHandler{}
+		r := NewRouter(gh, nil, nil, nil, nil, nil, nil)
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/"+action, nil))
+		if !gh.serveHttpCalled {
+			t.Errorf("POST /%s was not served by the gateway handler", action)
+		}
+	}
+}
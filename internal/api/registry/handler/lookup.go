@@ -17,9 +17,11 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -51,12 +53,21 @@ func (h *lookupHandler) Lookup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	subscriptions, err := h.lhService.Lookup(r.Context(), &lookupReq)
+	if errors.Is(err, repository.ErrInvalidGeoFilter) {
+		slog.Error("Handler: Invalid lookup location", "error", err, "request", lookupReq)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		slog.Error("Handler: Failed to perform lookup", "error", err, "request", lookupReq)
 		http.Error(w, "Failed to lookup subscriptions", http.StatusInternalServerError)
 		return
 	}
 
+	// No match is an empty list, not null.
+	if subscriptions == nil {
+		subscriptions = []model.Subscription{}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(subscriptions); err != nil {
 		slog.Error("Handler: Failed to encode lookup response", "error", err)
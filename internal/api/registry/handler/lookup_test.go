@@ -19,11 +19,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/go-chi/chi/v5"
@@ -191,6 +194,15 @@ func TestLookupHandlerLookupError(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   "Failed to lookup subscriptions\n",
 		},
+		{
+			name:        "InvalidGeoFilter",
+			requestBody: bytes.NewBufferString(`{"location":{"circle":{"gps":"north"}}}`),
+			mockService: &mockLookupService{
+				err: fmt.Errorf("failed to lookup subscriptions: %w: both gps and radius are required", repository.ErrInvalidGeoFilter),
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "failed to lookup subscriptions: invalid location circle: both gps and radius are required\n",
+		},
 	}
 
 	for _, tc := range tests {
@@ -272,3 +284,44 @@ func TestLookupHandlerLookupErrorEncodeFailure(t *testing.T) {
 		t.Errorf("handler.Lookup WriteHeader status code = %d, want %d", ew.StatusCode, http.StatusInternalServerError)
 	}
 }
+
+func TestLookupHandlerLookupNoMatch(t *testing.T) {
+	handler := NewLookupHandler(&mockLookupService{})
+
+	req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewBufferString(`{}`))
+	rr := httptest.NewRecorder()
+	handler.Lookup(rr, req)
+
+	if got := strings.TrimSpace(rr.Body.String()); got != "[]" {
+		t.Errorf("response body = %s, want []", got)
+	}
+}
+
+func TestLookupHandlerLookupPublicKeys(t *testing.T) {
+	handler := NewLookupHandler(&mockLookupService{subscriptions: []model.Subscription{{
+		Subscriber:       model.Subscriber{SubscriberID: "bpp.example.com"},
+		KeyID:            "key1",
+		SigningPublicKey: "sign-pub",
+		EncrPublicKey:    "encr-pub",
+	}}})
+
+	req := httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewBufferString(`{}`))
+	rr := httptest.NewRecorder()
+	handler.Lookup(rr, req)
+
+	var got []map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v. Body: %s", err, rr.Body.String())
+	}
+	if len(got) != 1 {
+		t.Fatalf("handler.Lookup returned %d subscriptions, want 1", len(got))
+	}
+	if got[0]["signing_public_key"] != "sign-pub" || got[0]["encr_public_key"] != "encr-pub" {
+		t.Errorf("handler.Lookup public keys = %v, %v, want sign-pub, encr-pub", got[0]["signing_public_key"], got[0]["encr_public_key"])
+	}
+	for field := range got[0] {
+		if strings.Contains(field, "private") {
+			t.Errorf("handler.Lookup response has private field %q", field)
+		}
+	}
+}
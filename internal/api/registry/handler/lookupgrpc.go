@@ -0,0 +1,150 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/registrypb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// lookupGRPCServer serves subscriber lookups over gRPC, using the same service as lookupHandler.
+type lookupGRPCServer struct {
+	registrypb.UnimplementedRegistryLookupServer
+	lhService lookupService
+}
+
+// NewLookupGRPCServer creates a new gRPC lookup server.
+func NewLookupGRPCServer(svc lookupService) *lookupGRPCServer {
+	return &lookupGRPCServer{lhService: svc}
+}
+
+// Lookup implements registrypb.RegistryLookupServer.
+func (s *lookupGRPCServer) Lookup(ctx context.Context, req *registrypb.LookupRequest) (*registrypb.LookupResponse, error) {
+	slog.Info("Handler: Received gRPC lookup request")
+
+	filter, err := subscriptionFromProto(req.GetFilter())
+	if err != nil {
+		slog.Error("Handler: Invalid gRPC lookup filter", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	subscriptions, err := s.lhService.Lookup(ctx, filter)
+	if errors.Is(err, repository.ErrInvalidGeoFilter) {
+		slog.Error("Handler: Invalid gRPC lookup location", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		slog.Error("Handler: Failed to perform lookup", "error", err, "request", filter)
+		return nil, status.Error(codes.Internal, "failed to lookup subscriptions")
+	}
+
+	resp := &registrypb.LookupResponse{Subscriptions: make([]*registrypb.Subscription, 0, len(subscriptions))}
+	for i := range subscriptions {
+		sub, err := subscriptionToProto(&subscriptions[i])
+		if err != nil {
+			slog.Error("Handler: Failed to encode lookup response", "error", err)
+			return nil, status.Error(codes.Internal, "failed to encode response")
+		}
+		resp.Subscriptions = append(resp.Subscriptions, sub)
+	}
+
+	slog.Info("Handler: gRPC lookup request processed successfully", "count", len(subscriptions))
+	return resp, nil
+}
+
+// subscriptionToProto converts a subscription to its protobuf form.
+func subscriptionToProto(s *model.Subscription) (*registrypb.Subscription, error) {
+	pb := &registrypb.Subscription{
+		SubscriberId:       s.SubscriberID,
+		Url:                s.URL,
+		Type:               string(s.Type),
+		Domain:             s.Domain,
+		KeyId:              s.KeyID,
+		SigningPublicKey:   s.SigningPublicKey,
+		EncrPublicKey:      s.EncrPublicKey,
+		ValidFrom:          timestampProto(s.ValidFrom),
+		ValidUntil:         timestampProto(s.ValidUntil),
+		Status:             string(s.Status),
+		Created:            timestampProto(s.Created),
+		Updated:            timestampProto(s.Updated),
+		Nonce:              s.Nonce,
+		ExtendedAttributes: s.ExtendedAttributes,
+	}
+	if s.Location != nil {
+		loc, err := json.Marshal(s.Location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal location: %w", err)
+		}
+		pb.Location = loc
+	}
+	return pb, nil
+}
+
+// subscriptionFromProto converts a protobuf subscription to a subscription. A nil pb returns an empty subscription.
+func subscriptionFromProto(pb *registrypb.Subscription) (*model.Subscription, error) {
+	s := &model.Subscription{
+		Subscriber: model.Subscriber{
+			SubscriberID: pb.GetSubscriberId(),
+			URL:          pb.GetUrl(),
+			Type:         model.Role(pb.GetType()),
+			Domain:       pb.GetDomain(),
+		},
+		KeyID:              pb.GetKeyId(),
+		SigningPublicKey:   pb.GetSigningPublicKey(),
+		EncrPublicKey:      pb.GetEncrPublicKey(),
+		ValidFrom:          timeFromProto(pb.GetValidFrom()),
+		ValidUntil:         timeFromProto(pb.GetValidUntil()),
+		Status:             model.SubscriptionStatus(pb.GetStatus()),
+		Created:            timeFromProto(pb.GetCreated()),
+		Updated:            timeFromProto(pb.GetUpdated()),
+		Nonce:              pb.GetNonce(),
+		ExtendedAttributes: pb.GetExtendedAttributes(),
+	}
+	if loc := pb.GetLocation(); len(loc) > 0 {
+		s.Location = &model.Location{}
+		if err := json.Unmarshal(loc, s.Location); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal location: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// timestampProto converts t to a timestamp, leaving the zero time unset.
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timeFromProto converts ts to a time, returning the zero time if ts is unset.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
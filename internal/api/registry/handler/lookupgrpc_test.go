@@ -0,0 +1,195 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/registrypb"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// recordingLookupService records the filters it is called with.
+type recordingLookupService struct {
+	mockLookupService
+	filters []*model.Subscription
+}
+
+func (m *recordingLookupService) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	m.filters = append(m.filters, filter)
+	return m.mockLookupService.Lookup(ctx, filter)
+}
+
+// newLookupGRPCClient serves svc over an in-process gRPC connection and returns a client for it.
+func newLookupGRPCClient(t *testing.T, svc lookupService) registrypb.RegistryLookupClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	registrypb.RegisterRegistryLookupServer(srv, NewLookupGRPCServer(svc))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return registrypb.NewRegistryLookupClient(conn)
+}
+
+func TestLookupGRPCServer_LookupParity(t *testing.T) {
+	validFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	subscriptions := []model.Subscription{
+		{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-1",
+				URL:          "http://example.com/api/1",
+				Type:         model.RoleBAP,
+				Domain:       "test.domain.com",
+				Location:     &model.Location{City: &model.City{Code: "std:080"}, Country: &model.Country{Code: "IND"}},
+			},
+			KeyID:              "key1",
+			SigningPublicKey:   "sign-pub-1",
+			EncrPublicKey:      "encr-pub-1",
+			ValidFrom:          validFrom,
+			ValidUntil:         validFrom.AddDate(1, 0, 0),
+			Status:             model.SubscriptionStatusSubscribed,
+			Created:            validFrom,
+			Updated:            validFrom.Add(time.Hour),
+			Nonce:              "nonce-1",
+			ExtendedAttributes: json.RawMessage(`{"tier":"gold"}`),
+		},
+		{
+			Subscriber: model.Subscriber{
+				SubscriberID: "test-sub-2",
+				URL:          "http://example.org/api/2",
+				Type:         model.RoleBPP,
+				Domain:       "test.domain.com",
+			},
+			KeyID: "key2",
+		},
+	}
+	filter := &model.Subscription{Subscriber: model.Subscriber{Domain: "test.domain.com", Type: model.RoleBAP}}
+	svc := &recordingLookupService{mockLookupService: mockLookupService{subscriptions: subscriptions}}
+
+	// HTTP lookup.
+	body, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	rr := httptest.NewRecorder()
+	NewLookupHandler(svc).Lookup(rr, httptest.NewRequest(http.MethodPost, "/lookup", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HTTP Lookup() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var httpResults []model.Subscription
+	if err := json.NewDecoder(rr.Body).Decode(&httpResults); err != nil {
+		t.Fatalf("failed to decode HTTP lookup response: %v", err)
+	}
+
+	// gRPC lookup.
+	pbFilter, err := subscriptionToProto(filter)
+	if err != nil {
+		t.Fatalf("subscriptionToProto() error = %v", err)
+	}
+	resp, err := newLookupGRPCClient(t, svc).Lookup(context.Background(), &registrypb.LookupRequest{Filter: pbFilter})
+	if err != nil {
+		t.Fatalf("gRPC Lookup() error = %v", err)
+	}
+	var grpcResults []model.Subscription
+	for _, pb := range resp.GetSubscriptions() {
+		sub, err := subscriptionFromProto(pb)
+		if err != nil {
+			t.Fatalf("subscriptionFromProto() error = %v", err)
+		}
+		grpcResults = append(grpcResults, *sub)
+	}
+
+	if diff := cmp.Diff(httpResults, grpcResults); diff != "" {
+		t.Errorf("gRPC lookup results differ from HTTP lookup results (-http +grpc):\n%s", diff)
+	}
+	if diff := cmp.Diff(subscriptions, grpcResults); diff != "" {
+		t.Errorf("gRPC lookup results mismatch (-want +got):\n%s", diff)
+	}
+	if len(svc.filters) != 2 {
+		t.Fatalf("service called %d times, want 2", len(svc.filters))
+	}
+	if diff := cmp.Diff(svc.filters[0], svc.filters[1]); diff != "" {
+		t.Errorf("gRPC filter differs from HTTP filter (-http +grpc):\n%s", diff)
+	}
+}
+
+func TestLookupGRPCServer_LookupEmptyFilter(t *testing.T) {
+	svc := &recordingLookupService{}
+	resp, err := newLookupGRPCClient(t, svc).Lookup(context.Background(), &registrypb.LookupRequest{})
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if len(resp.GetSubscriptions()) != 0 {
+		t.Errorf("Lookup() returned %d subscriptions, want 0", len(resp.GetSubscriptions()))
+	}
+	if diff := cmp.Diff([]*model.Subscription{{}}, svc.filters); diff != "" {
+		t.Errorf("service filters mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLookupGRPCServer_LookupError(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      lookupService
+		req      *registrypb.LookupRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "service error",
+			svc:      &mockLookupService{err: errors.New("db down")},
+			req:      &registrypb.LookupRequest{},
+			wantCode: codes.Internal,
+		},
+		{
+			name:     "invalid location filter",
+			svc:      &mockLookupService{},
+			req:      &registrypb.LookupRequest{Filter: &registrypb.Subscription{Location: []byte("not json")}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newLookupGRPCClient(t, tt.svc).Lookup(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("Lookup() code = %v, want %v (error %v)", got, tt.wantCode, err)
+			}
+		})
+	}
+}
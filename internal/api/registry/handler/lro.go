@@ -33,7 +33,16 @@ type lroService interface {
 
 // LROHandler handles Long-Running Operation (LRO) status requests.
 type LROHandler struct {
-	srv lroService
+	srv      lroService
+	errDebug *model.ErrorDebugConfig
+	retryMax int // Reported with the retry count of operations if greater than 0.
+}
+
+// lroStatusResponse is an LRO along with its progress toward the maximum number of retries.
+type lroStatusResponse struct {
+	*model.LRO
+	RetryCount int `json:"retry_count"` // Shadows the LRO's to report a count of 0.
+	RetryMax   int `json:"retry_max"`
 }
 
 // NewLROHandler creates a new LROHandler.
@@ -45,6 +54,18 @@ func NewLROHandler(srv lroService) (*LROHandler, error) {
 	return &LROHandler{srv: srv}, nil
 }
 
+// SetErrorDebug enables internal error details in 500 responses. Must not be used in production.
+func (h *LROHandler) SetErrorDebug(cfg *model.ErrorDebugConfig) {
+	h.errDebug = cfg
+}
+
+// SetRetryMax reports the retry count of operations, along with retryMax, the number of retries
+// after which an operation is rejected, so that clients can tell how many retries remain.
+// A retryMax of 0 or less reports neither.
+func (h *LROHandler) SetRetryMax(retryMax int) {
+	h.retryMax = retryMax
+}
+
 // Get retrieves the status of a Long-Running Operation.
 func (h *LROHandler) Get(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -57,13 +78,16 @@ func (h *LROHandler) Get(w http.ResponseWriter, r *http.Request) {
 				model.ErrorCodeOperationNotFound, fmt.Sprintf("Operation with id %s not found.", operationID), "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError,
-			"Failed to retrieve operation status due to an internal error.", "", "")
+		writeInternalError(w, "Failed to retrieve operation status due to an internal error.", err, h.errDebug)
 		return
 	}
+	var resp any = lro
+	if h.retryMax > 0 {
+		resp = &lroStatusResponse{LRO: lro, RetryCount: lro.RetryCount, RetryMax: h.retryMax}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(lro); err != nil {
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.ErrorContext(ctx, "LROHandler: Failed to encode LRO response for get", "error", err, "operation_id", lro.OperationID)
 	}
 }
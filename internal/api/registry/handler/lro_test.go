@@ -180,3 +180,95 @@ func TestLROHandler_Get_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestLROHandler_Get_ErrorDebug(t *testing.T) {
+	h, err := NewLROHandler(&mockLROService{err: errors.New("db timeout")})
+	if err != nil {
+		t.Fatalf("NewLROHandler() error = %v", err)
+	}
+	h.SetErrorDebug(&model.ErrorDebugConfig{Enabled: true, StackTrace: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/operations/op-1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("operation_id", "op-1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+	h.Get(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Get() status code = %v, want %v", rr.Code, http.StatusInternalServerError)
+	}
+	var resp model.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if resp.Error.Details == nil {
+		t.Fatal("Get() details = nil, want details when debug is enabled")
+	}
+	if diff := cmp.Diff([]string{"db timeout"}, resp.Error.Details.Chain); diff != "" {
+		t.Errorf("Get() details chain mismatch (-want +got):\n%s", diff)
+	}
+	if resp.Error.Details.Stack == "" {
+		t.Error("Get() details stack is empty, want stack trace")
+	}
+}
+
+func TestLROHandler_Get_RetryMax(t *testing.T) {
+	lro := &model.LRO{
+		OperationID: "op-1",
+		Status:      model.LROStatusFailure,
+		RetryCount:  2,
+	}
+	tests := []struct {
+		name     string
+		retryMax int
+		want     map[string]any
+	}{
+		{
+			name:     "retry max set",
+			retryMax: 3,
+			want:     map[string]any{"retry_count": float64(2), "retry_max": float64(3)},
+		},
+		{
+			name: "retry max not set",
+			want: map[string]any{"retry_count": float64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewLROHandler(&mockLROService{lro: lro})
+			if err != nil {
+				t.Fatalf("NewLROHandler() error = %v", err)
+			}
+			h.SetRetryMax(tt.retryMax)
+
+			req := httptest.NewRequest(http.MethodGet, "/operations/op-1", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("operation_id", "op-1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+			h.Get(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Get() status code = %v, want %v", rr.Code, http.StatusOK)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp["operation_id"] != "op-1" || resp["status"] != string(model.LROStatusFailure) {
+				t.Errorf("Get() response = %v, want the operation's fields", resp)
+			}
+			got := map[string]any{}
+			for _, k := range []string{"retry_count", "retry_max"} {
+				if v, ok := resp[k]; ok {
+					got[k] = v
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Get() retry fields mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// responseSigner signs response bodies on behalf of the registry.
+type responseSigner interface {
+	Sign(ctx context.Context, body []byte) (string, error)
+}
+
+// bufferedResponseWriter holds back the status and body of a response, so that the body can be signed before it is sent.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status of the response, keeping the first one like net/http.
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b as part of the response body.
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// SignResponses returns a middleware signing the body of every response with signer, in the
+// model.AuthHeaderRegistry header, so that clients can verify that any response of the registry
+// comes from it. Responses are buffered so that the signature covers the exact bytes sent.
+// A response that cannot be signed is replaced with a 500.
+func SignResponses(signer responseSigner) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bw := &bufferedResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(bw, r)
+			signature, err := signer.Sign(r.Context(), bw.body.Bytes())
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Handler: Failed to sign response", "method", r.Method, "path", r.URL.Path, "error", err)
+				http.Error(w, "Failed to sign response", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set(model.AuthHeaderRegistry, signature)
+			if bw.status != 0 {
+				w.WriteHeader(bw.status)
+			}
+			if _, err := w.Write(bw.body.Bytes()); err != nil {
+				slog.ErrorContext(r.Context(), "Handler: Failed to write signed response", "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+		})
+	}
+}
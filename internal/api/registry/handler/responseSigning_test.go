@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockResponseSigner is a mock implementation of the responseSigner interface.
+type mockResponseSigner struct {
+	signature string
+	err       error
+	gotBody   []byte
+}
+
+func (m *mockResponseSigner) Sign(ctx context.Context, body []byte) (string, error) {
+	m.gotBody = body
+	return m.signature, m.err
+}
+
+func TestSignResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "lookup response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `[{"subscriber_id":"bap.example.com"}]`)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"subscriber_id":"bap.example.com"}]`,
+		},
+		{
+			name: "subscription response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				io.WriteString(w, `{"message_id":"m1","status":"UNDER_SUBSCRIPTION"}`)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"message_id":"m1","status":"UNDER_SUBSCRIPTION"}`,
+		},
+		{
+			name: "error response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Invalid request body\n",
+		},
+		{
+			name: "empty response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &mockResponseSigner{signature: `Signature keyId="registry.example.com|k1|ed25519"`}
+			h := SignResponses(signer)(tt.handler)
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("response body mismatch (-want +got):\n%s", diff)
+			}
+			if got := rr.Header().Get(model.AuthHeaderRegistry); got != signer.signature {
+				t.Errorf("%s header = %q, want %q", model.AuthHeaderRegistry, got, signer.signature)
+			}
+			if diff := cmp.Diff(rr.Body.String(), string(signer.gotBody)); diff != "" {
+				t.Errorf("signed body differs from response body (-sent +signed):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSignResponses_SignError(t *testing.T) {
+	h := SignResponses(&mockResponseSigner{err: errors.New("signing failed")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "[]")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/lookup", nil)
+	rr := httptest.NewRecorder()
+
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status code = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if got := rr.Header().Get(model.AuthHeaderRegistry); got != "" {
+		t.Errorf("%s header = %q, want none on signing failure", model.AuthHeaderRegistry, got)
+	}
+}
@@ -22,26 +22,39 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // subscriptionService defines the interface for subscription-related operations.
 type subscriptionService interface {
 	Create(context.Context, *model.SubscriptionRequest) (*model.LRO, error)
 	Update(context.Context, *model.SubscriptionRequest) (*model.LRO, error)
+	Delete(ctx context.Context, subscriberID, keyID string) error
+	Unsubscribe(context.Context, *model.SubscriptionRequest) error
+	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
 }
 
 type authenticator interface {
 	AuthenticatedReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError)
+	AuthenticatedCreateReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError)
+	AuthenticatedGatewayReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError)
+	AuthenticatedDeleteReq(ctx context.Context, subscriberID, keyID string, body []byte, authHeader string) *model.AuthError
 }
 
 // subscriptionHandler handles HTTP requests for the /subscribe endpoint.
 type subscriptionHandler struct {
 	subService subscriptionService
 	// signValidator service.signValidator // Type from service package
-	auth authenticator // Type from service package
+	auth       authenticator // Type from service package
+	errDebug   *model.ErrorDebugConfig
+	authHeader string
+	// signedCreate requires creates to be signed with the signing key they register.
+	signedCreate bool
 }
 
 // NewSubscriptionHandler creates a new SubscribeHandler.
@@ -55,14 +68,41 @@ func NewSubscriptionHandler(ss subscriptionService, auth authenticator) (*subscr
 		slog.Error("NewSubscriptionHandler: authenticator dependency is nil.")
 		return nil, errors.New("authenticator dependency is nil")
 	}
-	return &subscriptionHandler{subService: ss, auth: auth}, nil
+	return &subscriptionHandler{subService: ss, auth: auth, authHeader: model.AuthHeaderSubscriber}, nil
+}
+
+// SetSignatureHeader sets the name of the header requests carry their signature in,
+// for networks that do not use Authorization. An empty name restores Authorization.
+// Failed authentications are challenged in the matching header, see model.ChallengeHeaderFor.
+func (h *subscriptionHandler) SetSignatureHeader(name string) {
+	if name == "" {
+		name = model.AuthHeaderSubscriber
+	}
+	h.authHeader = name
+}
+
+// SetRequireSignedCreate requires requests creating a subscription to be signed with the
+// private key of the signing public key they register, and to name the subscriber and key ID
+// of the body in the signature, so that a subscription cannot be created on behalf of another subscriber. Creates are not authenticated by default.
+func (h *subscriptionHandler) SetRequireSignedCreate(enabled bool) {
+	h.signedCreate = enabled
+}
+
+// SetErrorDebug enables internal error details in 500 responses. Must not be used in production.
+func (h *subscriptionHandler) SetErrorDebug(cfg *model.ErrorDebugConfig) {
+	h.errDebug = cfg
 }
 
 // writeJSONError is a helper function to construct and write standardized JSON error responses.
 func writeJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg, errPath, realmForAuthHeader string) {
+	writeChallengeJSONError(w, model.UnauthorizedHeaderSubscriber, statusCode, errType, errCode, errMsg, errPath, realmForAuthHeader)
+}
+
+// writeChallengeJSONError is writeJSONError with 401 responses challenged in challengeHeader.
+func writeChallengeJSONError(w http.ResponseWriter, challengeHeader string, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg, errPath, realmForAuthHeader string) {
 	w.Header().Set("Content-Type", "application/json")
 	if statusCode == http.StatusUnauthorized {
-		w.Header().Set(model.UnauthorizedHeaderSubscriber, service.UnauthorizedHeader(realmForAuthHeader))
+		w.Header().Set(challengeHeader, service.UnauthorizedHeader(realmForAuthHeader))
 	}
 	errResp := model.ErrorResponse{
 		Error: model.Error{
@@ -78,23 +118,93 @@ func writeJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorTy
 	}
 }
 
+// subscriptionResponse builds the response for an accepted subscription request.
+// Created, Updated and ValidUntil are those of the stored subscription the request is for,
+// and are omitted if none is stored yet, e.g. for a create awaiting approval.
+// Clients on API version 1 get the original shape with only the status and message ID.
+func (h *subscriptionHandler) subscriptionResponse(ctx context.Context, lro *model.LRO, req *model.SubscriptionRequest) model.SubscriptionResponse {
+	resp := model.SubscriptionResponse{
+		Status:    model.SubscriptionStatusUnderSubscription,
+		MessageID: lro.OperationID,
+	}
+	if apiversion.FromContext(ctx) == model.APIVersion1 {
+		return resp
+	}
+	subs, err := h.subService.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: req.SubscriberID, Domain: req.Domain, Type: req.Type}})
+	if err != nil {
+		// The request is accepted, so the response is sent without the stored values.
+		slog.WarnContext(ctx, "SubscribeHandler: Failed to look up the stored subscription for the response", "error", err, "subscriber_id", req.SubscriberID)
+		return resp
+	}
+	if len(subs) > 0 {
+		resp.Created = subs[0].Created
+		resp.Updated = subs[0].Updated
+		resp.ValidUntil = subs[0].ValidUntil
+	}
+	return resp
+}
+
+// writeInternalError writes a 500 response with a generic message.
+// The underlying error chain is added to the response details only if debug is enabled.
+func writeInternalError(w http.ResponseWriter, errMsg string, err error, debug *model.ErrorDebugConfig) {
+	w.Header().Set("Content-Type", "application/json")
+	errResp := model.ErrorResponse{
+		Error: model.Error{
+			Type:    model.ErrorTypeInternalError,
+			Code:    model.ErrorCodeInternalServerError,
+			Message: errMsg,
+			Details: debug.Details(err),
+		},
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(errResp); err != nil {
+		slog.Error("Failed to encode error response", "error", err)
+	}
+}
+
+// createReq decodes the body of a create request, authenticating it if signed creates are required.
+// It writes the error response and returns false if the request is rejected.
+func (h *subscriptionHandler) createReq(w http.ResponseWriter, r *http.Request) (*model.SubscriptionRequest, bool) {
+	ctx := r.Context()
+	defer r.Body.Close()
+	if !h.signedCreate {
+		slog.DebugContext(ctx, "SubscribeHandler: Attempting to decode create request body")
+		var subReq model.SubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&subReq); err != nil {
+			slog.ErrorContext(ctx, "SubscribeHandler: Failed to decode request body for create", "error", err)
+			writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), "", "")
+			return nil, false
+		}
+		return &subReq, true
+	}
+
+	slog.DebugContext(ctx, "SubscribeHandler: Starting authenticatedReq for create")
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Failed to read request body for create", "error", err)
+		writeInternalError(w, "Failed to read request body.", err, h.errDebug)
+		return nil, false
+	}
+	subReq, authErr := h.auth.AuthenticatedCreateReq(ctx, bodyBytes, r.Header.Get(h.authHeader))
+	if authErr != nil {
+		writeChallengeJSONError(w, model.ChallengeHeaderFor(h.authHeader), authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
+		return nil, false
+	}
+	return subReq, true
+}
+
 // Create handles POST requests to the /subscribe endpoint to create a new subscription.
 func (h *subscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	slog.InfoContext(ctx, "SubscribeHandler: Received create request", "method", r.Method, "path", r.URL.Path)
-	slog.DebugContext(ctx, "SubscribeHandler: Attempting to decode create request body")
-
-	var subReq model.SubscriptionRequest
-	if err := json.NewDecoder(r.Body).Decode(&subReq); err != nil {
-		slog.ErrorContext(ctx, "SubscribeHandler: Failed to decode request body for create", "error", err)
-		writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), "", "")
+	subReq, ok := h.createReq(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 	slog.DebugContext(ctx, "SubscribeHandler: Create request body decoded", "subscriber_id", subReq.SubscriberID, "message_id", subReq.MessageID)
 
 	// Call the subscription service
-	lro, err := h.subService.Create(ctx, &subReq)
+	lro, err := h.subService.Create(ctx, subReq)
 
 	// Write JSON response
 	w.Header().Set("Content-Type", "application/json")
@@ -104,16 +214,25 @@ func (h *subscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, "Duplicate request: An operation with this message_id already exists or is in progress.", "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription request.", "", "")
+		if errors.Is(err, service.ErrTooManyPendingOperations) {
+			writeJSONError(w, http.StatusTooManyRequests, model.ErrorTypeConflictError, model.ErrorCodeTooManyPendingOperations, "Too many pending operations: Wait for existing operations to complete before raising new requests.", "", "")
+			return
+		}
+		if errors.Is(err, service.ErrRequestTooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, model.ErrorTypeValidationError, model.ErrorCodeRequestTooLarge, "Request too large: "+err.Error(), "", "")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidLocation) {
+			writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "Invalid request: "+err.Error(), "", "")
+			return
+		}
+		writeInternalError(w, "Failed to process subscription request.", err, h.errDebug)
 		return
 	}
 	slog.DebugContext(ctx, "SubscribeHandler: LRO created successfully for create request", "operation_id", lro.OperationID, "status", lro.Status)
 
 	w.WriteHeader(http.StatusOK)
-	response := model.SubscriptionResponse{
-		Status:    model.SubscriptionStatusUnderSubscription,
-		MessageID: lro.OperationID,
-	}
+	response := h.subscriptionResponse(ctx, lro, subReq)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		slog.ErrorContext(ctx, "SubscribeHandler: Failed to encode subscription response for create", "error", err, "message_id", lro.OperationID)
 	}
@@ -129,15 +248,19 @@ func (h *subscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscribeHandler: Failed to read request body for update", "error", err)
 		// Not using newAuthError here as this is an I/O error before auth logic.
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to read request body.", "", "")
+		writeInternalError(w, "Failed to read request body.", err, h.errDebug)
 		return
 	}
 	r.Body.Close()
 
-	authHeader := r.Header.Get("Authorization")
-	subReq, authErr := h.auth.AuthenticatedReq(ctx, bodyBytes, authHeader)
+	// Updates signed by a gateway on behalf of the subscriber carry only the gateway header.
+	headerName, authenticate := h.authHeader, h.auth.AuthenticatedReq
+	if r.Header.Get(h.authHeader) == "" && r.Header.Get(model.AuthHeaderGateway) != "" {
+		headerName, authenticate = model.AuthHeaderGateway, h.auth.AuthenticatedGatewayReq
+	}
+	subReq, authErr := authenticate(ctx, bodyBytes, r.Header.Get(headerName))
 	if authErr != nil {
-		writeJSONError(w, authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
+		writeChallengeJSONError(w, model.ChallengeHeaderFor(headerName), authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
 		return
 	}
 
@@ -151,19 +274,98 @@ func (h *subscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, model.ErrorTypeConflictError, model.ErrorCodeDuplicateRequest, "Duplicate request: An operation with this message_id already exists or is in progress for update.", "", "")
 			return
 		}
-		writeJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, "Failed to process subscription update request.", "", "")
+		if errors.Is(err, service.ErrTooManyPendingOperations) {
+			writeJSONError(w, http.StatusTooManyRequests, model.ErrorTypeConflictError, model.ErrorCodeTooManyPendingOperations, "Too many pending operations: Wait for existing operations to complete before raising new requests.", "", "")
+			return
+		}
+		if errors.Is(err, service.ErrRequestTooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, model.ErrorTypeValidationError, model.ErrorCodeRequestTooLarge, "Request too large: "+err.Error(), "", "")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidLocation) {
+			writeJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "Invalid request: "+err.Error(), "", "")
+			return
+		}
+		writeInternalError(w, "Failed to process subscription update request.", err, h.errDebug)
 
 		return
 	}
 	slog.DebugContext(ctx, "SubscribeHandler: LRO created successfully for update request", "operation_id", lro.OperationID, "status", lro.Status)
 
 	w.WriteHeader(http.StatusOK)
-	response := model.SubscriptionResponse{
-		Status:    model.SubscriptionStatusUnderSubscription,
-		MessageID: lro.OperationID,
-	}
+	response := h.subscriptionResponse(ctx, lro, subReq)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		slog.ErrorContext(ctx, "SubscribeHandler: Failed to encode subscription response for update", "error", err, "message_id", lro.OperationID)
 	}
 }
+
+// Unsubscribe handles POST requests to the /unsubscribe endpoint to move a subscription to UNSUBSCRIBED.
+// The request must be signed by the subscriber. A subscription the Registry does not hold is reported with
+// a 404 carrying ErrorCodeSubscriptionNotFound.
+func (h *subscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	slog.InfoContext(ctx, "SubscribeHandler: Received unsubscribe request", "method", r.Method, "path", r.URL.Path)
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Failed to read request body for unsubscribe", "error", err)
+		writeInternalError(w, "Failed to read request body.", err, h.errDebug)
+		return
+	}
+	r.Body.Close()
+
+	subReq, authErr := h.auth.AuthenticatedReq(ctx, bodyBytes, r.Header.Get(h.authHeader))
+	if authErr != nil {
+		writeChallengeJSONError(w, model.ChallengeHeaderFor(h.authHeader), authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
+		return
+	}
+	if err := h.subService.Unsubscribe(ctx, subReq); err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Error from SubscriptionService during unsubscribe", "error", err, "message_id", subReq.MessageID)
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			writeJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, "Subscription not found.", "", "")
+			return
+		}
+		writeInternalError(w, "Failed to process unsubscribe request.", err, h.errDebug)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := model.SubscriptionResponse{Status: model.SubscriptionStatusUnsubscribed, MessageID: subReq.MessageID}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Failed to encode subscription response for unsubscribe", "error", err, "message_id", subReq.MessageID)
+	}
+}
+
+// Delete handles DELETE requests to the /subscribe/{subscriber_id}/{key_id} endpoint to delete a subscription.
+// The request must carry a model.DeleteSubscriptionRequest signed by the subscriber. A subscription the
+// Registry does not hold is reported with a 404 carrying ErrorCodeSubscriptionNotFound, so that clients can tell it from an unknown route.
+func (h *subscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriberID, keyID := chi.URLParam(r, "subscriber_id"), chi.URLParam(r, "key_id")
+	slog.InfoContext(ctx, "SubscribeHandler: Received delete request", "subscriber_id", subscriberID, "key_id", keyID)
+
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Failed to read request body for delete", "error", err)
+		writeInternalError(w, "Failed to read request body.", err, h.errDebug)
+		return
+	}
+	r.Body.Close()
+
+	if authErr := h.auth.AuthenticatedDeleteReq(ctx, subscriberID, keyID, bodyBytes, r.Header.Get(h.authHeader)); authErr != nil {
+		writeChallengeJSONError(w, model.ChallengeHeaderFor(h.authHeader), authErr.StatusCode, authErr.ErrorType, authErr.ErrorCode, authErr.Message, "", authErr.SubscriberID)
+		return
+	}
+	if err := h.subService.Delete(ctx, subscriberID, keyID); err != nil {
+		slog.ErrorContext(ctx, "SubscribeHandler: Error from SubscriptionService during delete", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			writeJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, "Subscription not found.", "", "")
+			return
+		}
+		writeInternalError(w, "Failed to delete subscription.", err, h.errDebug)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
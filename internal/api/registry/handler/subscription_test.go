@@ -25,17 +25,47 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
 )
 
 type mockAuthenticator struct {
-	req *model.SubscriptionRequest
-	err *model.AuthError
+	req           *model.SubscriptionRequest
+	err           *model.AuthError
+	gotAuthHeader string
+	gotCreate     bool // Whether AuthenticatedCreateReq was called.
+	gotGateway    bool // Whether AuthenticatedGatewayReq was called.
+	deleteErr     *model.AuthError
+	gotBody       []byte
+}
+
+func (m *mockAuthenticator) AuthenticatedDeleteReq(ctx context.Context, subscriberID, keyID string, body []byte, authHeader string) *model.AuthError {
+	m.gotBody = body
+	m.gotAuthHeader = authHeader
+	return m.deleteErr
 }
 
 func (m *mockAuthenticator) AuthenticatedReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	m.gotAuthHeader = authHeader
+	return m.req, m.err
+}
+
+func (m *mockAuthenticator) AuthenticatedGatewayReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	m.gotAuthHeader = authHeader
+	m.gotGateway = true
+	return m.req, m.err
+}
+
+func (m *mockAuthenticator) AuthenticatedCreateReq(ctx context.Context, bodyBytes []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	m.gotAuthHeader = authHeader
+	m.gotCreate = true
 	return m.req, m.err
 }
 
@@ -45,9 +75,35 @@ type mockSubscriptionService struct {
 	key       string
 	createErr error // Specific error for Create
 	updateErr error // Specific error for Update
+	gotCreate *model.SubscriptionRequest
+	deleteErr error
+	gotDelete string // subscriber_id|key_id of the deleted subscription.
+
+	unsubscribeErr error
+	gotUnsubscribe *model.SubscriptionRequest
+
+	stored    []model.Subscription // Subscriptions returned by Lookup.
+	lookupErr error
+	gotLookup *model.Subscription
+}
+
+func (m *mockSubscriptionService) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	m.gotLookup = filter
+	return m.stored, m.lookupErr
+}
+
+func (m *mockSubscriptionService) Unsubscribe(ctx context.Context, req *model.SubscriptionRequest) error {
+	m.gotUnsubscribe = req
+	return m.unsubscribeErr
+}
+
+func (m *mockSubscriptionService) Delete(ctx context.Context, subscriberID, keyID string) error {
+	m.gotDelete = subscriberID + "|" + keyID
+	return m.deleteErr
 }
 
 func (m *mockSubscriptionService) Create(ctx context.Context, req *model.SubscriptionRequest) (*model.LRO, error) {
+	m.gotCreate = req
 	return m.lro, m.createErr
 }
 func (m *mockSubscriptionService) Update(ctx context.Context, req *model.SubscriptionRequest) (*model.LRO, error) {
@@ -194,14 +250,17 @@ func TestWriteJSONError(t *testing.T) {
 }
 
 func TestSubscriptionHandler_Create_Success(t *testing.T) {
-	defaultLRO := &model.LRO{OperationID: "test-op-id", Status: "PENDING"}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	defaultLRO := &model.LRO{OperationID: "test-op-id", Status: "PENDING", CreatedAt: created, UpdatedAt: created}
 	defaultSubReq := model.SubscriptionRequest{
 		Subscription: model.Subscription{
 			Subscriber: model.Subscriber{
 				SubscriberID: "test-subscriber",
 				Domain:       "test-domain",
 				Type:         model.RoleBAP, // Example role
-			}},
+			},
+			ValidUntil: created.AddDate(1, 0, 0),
+		},
 		MessageID: "test-msg-id",
 	}
 	defaultSubReqBytes, _ := json.Marshal(defaultSubReq)
@@ -237,6 +296,70 @@ func TestSubscriptionHandler_Create_Success(t *testing.T) {
 	if resp.MessageID != defaultLRO.OperationID {
 		t.Errorf("Expected messageID %s, got %s", defaultLRO.OperationID, resp.MessageID)
 	}
+	// The subscription is only stored once approved.
+	if !resp.Created.IsZero() || !resp.Updated.IsZero() || !resp.ValidUntil.IsZero() {
+		t.Errorf("Expected no created, updated and valid_until before approval, got %v, %v, %v", resp.Created, resp.Updated, resp.ValidUntil)
+	}
+	wantLookup := &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-subscriber", Domain: "test-domain", Type: model.RoleBAP}}
+	if diff := cmp.Diff(wantLookup, subSrv.gotLookup); diff != "" {
+		t.Errorf("Lookup() filter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriptionHandler_Create_APIVersion(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	lro := &model.LRO{OperationID: "test-op-id", Status: "PENDING", CreatedAt: created, UpdatedAt: created}
+	subReq := model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{SubscriberID: "test-subscriber", Domain: "test-domain", Type: model.RoleBAP},
+			ValidUntil: created.AddDate(1, 0, 0),
+		},
+		MessageID: "test-msg-id",
+	}
+	body, _ := json.Marshal(subReq)
+
+	tests := []struct {
+		name        string
+		requested   string
+		wantVersion string
+		wantBody    string
+	}{
+		{
+			name:        "current version",
+			wantVersion: model.APIVersion2,
+			wantBody:    `{"status":"UNDER_SUBSCRIPTION","message_id":"test-op-id","created":"2026-01-02T03:04:05Z","updated":"2026-01-02T03:04:05Z","valid_until":"2027-01-02T03:04:05Z"}`,
+		},
+		{
+			name:        "older version",
+			requested:   model.APIVersion1,
+			wantVersion: model.APIVersion1,
+			wantBody:    `{"status":"UNDER_SUBSCRIPTION","message_id":"test-op-id"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := model.Subscription{Created: created, Updated: created, ValidUntil: created.AddDate(1, 0, 0)}
+			h, err := NewSubscriptionHandler(&mockSubscriptionService{lro: lro, stored: []model.Subscription{stored}}, &mockAuthenticator{req: &subReq})
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", bytes.NewReader(body))
+			if tt.requested != "" {
+				req.Header.Set(model.AcceptVersionHeader, tt.requested)
+			}
+			rr := httptest.NewRecorder()
+
+			apiversion.Middleware(http.HandlerFunc(h.Create)).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get(model.APIVersionHeader); got != tt.wantVersion {
+				t.Errorf("%s header = %q, want %q", model.APIVersionHeader, got, tt.wantVersion)
+			}
+			if got := strings.TrimSpace(rr.Body.String()); got != tt.wantBody {
+				t.Errorf("Create() body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
 }
 
 func TestSubscriptionHandler_Create_Error(t *testing.T) {
@@ -275,6 +398,30 @@ func TestSubscriptionHandler_Create_Error(t *testing.T) {
 			wantContentType:  "application/json",
 			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeDuplicateRequest), `"message":"Duplicate request: An operation with this message_id already exists or is in progress."`},
 		},
+		{
+			name:             "service returns ErrTooManyPendingOperations",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("%w: limit reached", service.ErrTooManyPendingOperations)},
+			wantStatusCode:   http.StatusTooManyRequests,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"code":"%s"`, model.ErrorCodeTooManyPendingOperations)},
+		},
+		{
+			name:             "service returns ErrRequestTooLarge",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("%w: 2048 bytes (max 1024)", service.ErrRequestTooLarge)},
+			wantStatusCode:   http.StatusRequestEntityTooLarge,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeValidationError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeRequestTooLarge), "2048 bytes (max 1024)"},
+		},
+		{
+			name:             "service returns ErrInvalidLocation",
+			requestBody:      defaultSubReqBytes,
+			subSrv:           &mockSubscriptionService{createErr: fmt.Errorf("%w: gps \"abc\" is not of the form lat,lon", service.ErrInvalidLocation)},
+			wantStatusCode:   http.StatusBadRequest,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeValidationError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeBadRequest), "is not of the form lat,lon"},
+		},
 		{
 			name:             "service returns generic error",
 			requestBody:      defaultSubReqBytes,
@@ -313,23 +460,29 @@ func TestSubscriptionHandler_Create_Error(t *testing.T) {
 }
 
 func TestSubscriptionHandler_Update_Success(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.AddDate(0, 6, 0)
+	validUntil := created.AddDate(1, 0, 0)
 	defaultSubReq := model.SubscriptionRequest{
 		Subscription: model.Subscription{
 			Subscriber: model.Subscriber{
 				SubscriberID: "test.subscriber.com",
 				Domain:       "test-domain",
 				Type:         model.RoleBAP,
-			}},
+			},
+			ValidUntil: validUntil.AddDate(1, 0, 0), // Not stored until the update is approved.
+		},
 		MessageID: "update-msg-id",
 	}
 	defaultSubReqBytes, _ := json.Marshal(defaultSubReq)
 	validAuthHeader := `Signature keyId="test.subscriber.com|key1|ed25519",algorithm="ed25519",signature="testsignature"`
 	validPublicKey := "base64PublicKey"
-	defaultLRO := &model.LRO{OperationID: "update-op-id", Status: "PENDING"}
+	defaultLRO := &model.LRO{OperationID: "update-op-id", Status: "PENDING", CreatedAt: time.Now(), UpdatedAt: time.Now()}
 
 	subServ := &mockSubscriptionService{
-		key: validPublicKey, // For authenticatedReq
-		lro: defaultLRO,
+		key:    validPublicKey, // For authenticatedReq
+		lro:    defaultLRO,
+		stored: []model.Subscription{{Created: created, Updated: updated, ValidUntil: validUntil}},
 	}
 	sValidator := &mockAuthenticator{
 		req: &defaultSubReq,
@@ -366,6 +519,38 @@ func TestSubscriptionHandler_Update_Success(t *testing.T) {
 	if resp.MessageID != defaultLRO.OperationID {
 		t.Errorf("Expected messageID %s, got %s", defaultLRO.OperationID, resp.MessageID)
 	}
+	if !resp.Created.Equal(created) {
+		t.Errorf("Expected created %v, got %v", created, resp.Created)
+	}
+	if !resp.Updated.Equal(updated) {
+		t.Errorf("Expected updated %v, got %v", updated, resp.Updated)
+	}
+	if !resp.ValidUntil.Equal(validUntil) {
+		t.Errorf("Expected valid_until %v, got %v", validUntil, resp.ValidUntil)
+	}
+}
+
+func TestSubscriptionHandler_Update_StoredSubscriptionLookupError(t *testing.T) {
+	subReq := model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test.subscriber.com", Domain: "test-domain", Type: model.RoleBAP}},
+		MessageID:    "update-msg-id",
+	}
+	subServ := &mockSubscriptionService{lro: &model.LRO{OperationID: "update-op-id"}, lookupErr: errors.New("db down")}
+	handler, err := NewSubscriptionHandler(subServ, &mockAuthenticator{req: &subReq})
+	if err != nil {
+		t.Fatalf("NewSubscriptionHandler failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPatch, "/subscribe", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+
+	handler.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Update() status code = %v, want %v. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if want := `{"status":"UNDER_SUBSCRIPTION","message_id":"update-op-id"}`; strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("Update() body = %s, want %s", rr.Body.String(), want)
+	}
 }
 
 func TestSubscriptionHandler_Update_Error(t *testing.T) {
@@ -428,6 +613,18 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 			wantContentType:  "application/json",
 			wantBodyContains: []string{fmt.Sprintf(`"type":"%s"`, model.ErrorTypeConflictError), fmt.Sprintf(`"code":"%s"`, model.ErrorCodeDuplicateRequest), `"message":"Duplicate request: An operation with this message_id already exists or is in progress for update."`},
 		},
+		{
+			name: "service returns ErrTooManyPendingOperations after successful auth",
+			requestSetup: func(r *http.Request) {
+				r.Header.Set("Authorization", validAuthHeader)
+				r.Body = io.NopCloser(bytes.NewBuffer(defaultSubReqBytes))
+			},
+			auth:             mockAuth,
+			subSrv:           &mockSubscriptionService{updateErr: service.ErrTooManyPendingOperations},
+			wantStatusCode:   http.StatusTooManyRequests,
+			wantContentType:  "application/json",
+			wantBodyContains: []string{fmt.Sprintf(`"code":"%s"`, model.ErrorCodeTooManyPendingOperations)},
+		},
 		{
 			name: "service returns generic error after successful auth (mocking auth success)",
 			requestSetup: func(r *http.Request) {
@@ -444,7 +641,7 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := &subscriptionHandler{tt.subSrv, tt.auth}
+			handler := &subscriptionHandler{subService: tt.subSrv, auth: tt.auth}
 			req := httptest.NewRequest(http.MethodPatch, "/subscribe", nil)
 			tt.requestSetup(req)
 			rr := httptest.NewRecorder()
@@ -468,3 +665,394 @@ func TestSubscriptionHandler_Update_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionHandler_Update_CustomSignatureHeader(t *testing.T) {
+	auth := &mockAuthenticator{err: model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", "test-sub")}
+	h, err := NewSubscriptionHandler(&mockSubscriptionService{}, auth)
+	if err != nil {
+		t.Fatalf("NewSubscriptionHandler() error = %v", err)
+	}
+	h.SetSignatureHeader("X-Beckn-Authorization")
+
+	req := httptest.NewRequest(http.MethodPatch, "/subscribe", bytes.NewBufferString(`{}`))
+	req.Header.Set(model.AuthHeaderSubscriber, "standard-header")
+	req.Header.Set("X-Beckn-Authorization", "custom-header")
+	rr := httptest.NewRecorder()
+
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Update() status code = %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+	if auth.gotAuthHeader != "custom-header" {
+		t.Errorf("AuthenticatedReq() called with %q, want the custom header value", auth.gotAuthHeader)
+	}
+	want := `Signature realm="test-sub",headers="(created) (expires) digest"`
+	if got := rr.Header().Get("X-Beckn-Authenticate"); got != want {
+		t.Errorf("X-Beckn-Authenticate header = %q, want %q", got, want)
+	}
+	if got := rr.Header().Get(model.UnauthorizedHeaderSubscriber); got != "" {
+		t.Errorf("%s header = %q, want none", model.UnauthorizedHeaderSubscriber, got)
+	}
+}
+
+func TestSubscriptionHandler_Create_ErrorDebug(t *testing.T) {
+	svcErr := fmt.Errorf("failed to initiate LRO: %w", errors.New("db connection refused"))
+	tests := []struct {
+		name        string
+		errDebug    *model.ErrorDebugConfig
+		wantDetails *model.ErrorDetails
+	}{
+		{
+			name:        "debug not configured",
+			errDebug:    nil,
+			wantDetails: nil,
+		},
+		{
+			name:        "debug disabled",
+			errDebug:    &model.ErrorDebugConfig{StackTrace: true},
+			wantDetails: nil,
+		},
+		{
+			name:     "debug enabled",
+			errDebug: &model.ErrorDebugConfig{Enabled: true},
+			wantDetails: &model.ErrorDetails{
+				Chain: []string{"failed to initiate LRO: db connection refused", "db connection refused"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewSubscriptionHandler(&mockSubscriptionService{createErr: svcErr}, &mockAuthenticator{})
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler failed: %v", err)
+			}
+			handler.SetErrorDebug(tt.errDebug)
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(`{"message_id":"msg-1"}`))
+			rr := httptest.NewRecorder()
+			handler.Create(rr, req)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Fatalf("Create() status code = %v, want %v", rr.Code, http.StatusInternalServerError)
+			}
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if resp.Error.Message != "Failed to process subscription request." {
+				t.Errorf("Create() message = %q, want generic message", resp.Error.Message)
+			}
+			if diff := cmp.Diff(tt.wantDetails, resp.Error.Details); diff != "" {
+				t.Errorf("Create() details mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantDetails == nil && strings.Contains(rr.Body.String(), "db connection refused") {
+				t.Errorf("Create() body = %s, leaks the underlying error", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandler_Create_RequireSignedCreate(t *testing.T) {
+	signedReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "signed-sub"}, SigningPublicKey: "pub"},
+		MessageID:    "msg-1",
+	}
+	body := `{"subscriber_id":"body-sub","message_id":"msg-1"}`
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		authErr       *model.AuthError
+		wantStatus    int
+		wantAuth      bool
+		wantCreatedID string // Subscriber ID passed to the service, empty if it must not be called.
+	}{
+		{
+			name:          "disabled creates without authentication",
+			wantStatus:    http.StatusOK,
+			wantCreatedID: "body-sub",
+		},
+		{
+			name:          "signed create accepted",
+			enabled:       true,
+			wantStatus:    http.StatusOK,
+			wantAuth:      true,
+			wantCreatedID: "signed-sub",
+		},
+		{
+			name:       "unsigned create rejected",
+			enabled:    true,
+			authErr:    model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, "Authorization header missing.", "unknown"),
+			wantStatus: http.StatusUnauthorized,
+			wantAuth:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mockAuthenticator{req: signedReq, err: tt.authErr}
+			subSrv := &mockSubscriptionService{lro: &model.LRO{OperationID: "op-1"}}
+			h, err := NewSubscriptionHandler(subSrv, auth)
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler() error = %v", err)
+			}
+			h.SetRequireSignedCreate(tt.enabled)
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", bytes.NewBufferString(body))
+			req.Header.Set(model.AuthHeaderSubscriber, "signature")
+			rr := httptest.NewRecorder()
+
+			h.Create(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Create() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if auth.gotCreate != tt.wantAuth {
+				t.Errorf("AuthenticatedCreateReq() called = %v, want %v", auth.gotCreate, tt.wantAuth)
+			}
+			if tt.wantAuth && auth.gotAuthHeader != "signature" {
+				t.Errorf("AuthenticatedCreateReq() called with %q, want %q", auth.gotAuthHeader, "signature")
+			}
+			var gotCreatedID string
+			if subSrv.gotCreate != nil {
+				gotCreatedID = subSrv.gotCreate.SubscriberID
+			}
+			if gotCreatedID != tt.wantCreatedID {
+				t.Errorf("Create() created subscriber %q, want %q", gotCreatedID, tt.wantCreatedID)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rr.Header().Get(model.UnauthorizedHeaderSubscriber) == "" {
+				t.Errorf("Create() response missing %s header", model.UnauthorizedHeaderSubscriber)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandler_Update_GatewayHeader(t *testing.T) {
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test.subscriber.com", Domain: "test-domain", Type: model.RoleBAP}},
+		MessageID:    "update-msg-id",
+	}
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		authErr       *model.AuthError
+		wantStatus    int
+		wantGateway   bool
+		wantHeader    string
+		wantChallenge string
+	}{
+		{
+			name:       "subscriber signature",
+			headers:    map[string]string{model.AuthHeaderSubscriber: "subscriber-signature"},
+			wantStatus: http.StatusOK,
+			wantHeader: "subscriber-signature",
+		},
+		{
+			name:        "gateway signature",
+			headers:     map[string]string{model.AuthHeaderGateway: "gateway-signature"},
+			wantStatus:  http.StatusOK,
+			wantGateway: true,
+			wantHeader:  "gateway-signature",
+		},
+		{
+			name:       "subscriber signature preferred over gateway signature",
+			headers:    map[string]string{model.AuthHeaderSubscriber: "subscriber-signature", model.AuthHeaderGateway: "gateway-signature"},
+			wantStatus: http.StatusOK,
+			wantHeader: "subscriber-signature",
+		},
+		{
+			name:          "gateway signature rejected",
+			headers:       map[string]string{model.AuthHeaderGateway: "gateway-signature"},
+			authErr:       model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", "gateway.example.com"),
+			wantStatus:    http.StatusUnauthorized,
+			wantGateway:   true,
+			wantHeader:    "gateway-signature",
+			wantChallenge: model.ChallengeHeaderFor(model.AuthHeaderGateway),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &mockAuthenticator{req: subReq, err: tt.authErr}
+			h, err := NewSubscriptionHandler(&mockSubscriptionService{lro: &model.LRO{OperationID: "update-msg-id"}}, auth)
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPatch, "/subscribe", bytes.NewBufferString("{}"))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			h.Update(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Update() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if auth.gotGateway != tt.wantGateway {
+				t.Errorf("AuthenticatedGatewayReq() called = %v, want %v", auth.gotGateway, tt.wantGateway)
+			}
+			if auth.gotAuthHeader != tt.wantHeader {
+				t.Errorf("authenticated with header %q, want %q", auth.gotAuthHeader, tt.wantHeader)
+			}
+			if tt.wantChallenge != "" && rr.Header().Get(tt.wantChallenge) == "" {
+				t.Errorf("Update() response missing %s header", tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		authErr    *model.AuthError
+		deleteErr  error
+		wantStatus int
+		wantCode   model.ErrorCode
+		wantDelete string
+	}{
+		{
+			name:       "deleted",
+			wantStatus: http.StatusOK,
+			wantDelete: "np.com|key1",
+		},
+		{
+			name:       "unauthenticated",
+			authErr:    model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "mismatch", "other.com"),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   model.ErrorCodeIDMismatch,
+		},
+		{
+			name:       "not found",
+			deleteErr:  fmt.Errorf("failed to delete subscription: %w", repository.ErrSubscriptionNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   model.ErrorCodeSubscriptionNotFound,
+			wantDelete: "np.com|key1",
+		},
+		{
+			name:       "service error",
+			deleteErr:  errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   model.ErrorCodeInternalServerError,
+			wantDelete: "np.com|key1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subSrv := &mockSubscriptionService{deleteErr: tt.deleteErr}
+			auth := &mockAuthenticator{deleteErr: tt.authErr}
+			h, err := NewSubscriptionHandler(subSrv, auth)
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler() error = %v", err)
+			}
+			router := chi.NewRouter()
+			router.Delete("/subscribe/{subscriber_id}/{key_id}", h.Delete)
+			body := `{"method":"DELETE","subscriber_id":"np.com","key_id":"key1","nonce":"n1"}`
+			req := httptest.NewRequest(http.MethodDelete, "/subscribe/np.com/key1", strings.NewReader(body))
+			req.Header.Set(model.AuthHeaderSubscriber, "signature")
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Delete() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if auth.gotAuthHeader != "signature" || string(auth.gotBody) != body {
+				t.Errorf("AuthenticatedDeleteReq() called with %q and body %s, want %q and body %s", auth.gotAuthHeader, auth.gotBody, "signature", body)
+			}
+			if subSrv.gotDelete != tt.wantDelete {
+				t.Errorf("Delete() deleted %q, want %q", subSrv.gotDelete, tt.wantDelete)
+			}
+			if tt.wantCode != "" {
+				var errResp model.ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if errResp.Error.Code != tt.wantCode {
+					t.Errorf("Delete() error code = %s, want %s", errResp.Error.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandler_Unsubscribe(t *testing.T) {
+	subReq := &model.SubscriptionRequest{
+		MessageID:    "msg-1",
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np.com", Domain: "retail", Type: model.RoleBAP}, KeyID: "key1"},
+	}
+	tests := []struct {
+		name            string
+		authErr         *model.AuthError
+		unsubscribeErr  error
+		wantStatus      int
+		wantCode        model.ErrorCode
+		wantUnsubscribe bool
+	}{
+		{
+			name:            "unsubscribed",
+			wantStatus:      http.StatusOK,
+			wantUnsubscribe: true,
+		},
+		{
+			name:       "unauthenticated",
+			authErr:    model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "invalid", "np.com"),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:            "not found",
+			unsubscribeErr:  fmt.Errorf("failed to unsubscribe subscription: %w", repository.ErrSubscriptionNotFound),
+			wantStatus:      http.StatusNotFound,
+			wantCode:        model.ErrorCodeSubscriptionNotFound,
+			wantUnsubscribe: true,
+		},
+		{
+			name:            "service error",
+			unsubscribeErr:  errors.New("db down"),
+			wantStatus:      http.StatusInternalServerError,
+			wantCode:        model.ErrorCodeInternalServerError,
+			wantUnsubscribe: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subSrv := &mockSubscriptionService{unsubscribeErr: tt.unsubscribeErr}
+			auth := &mockAuthenticator{req: subReq, err: tt.authErr}
+			h, err := NewSubscriptionHandler(subSrv, auth)
+			if err != nil {
+				t.Fatalf("NewSubscriptionHandler() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/unsubscribe", strings.NewReader(`{}`))
+			req.Header.Set(model.AuthHeaderSubscriber, "signature")
+			rr := httptest.NewRecorder()
+
+			h.Unsubscribe(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Unsubscribe() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if gotUnsubscribe := subSrv.gotUnsubscribe != nil; gotUnsubscribe != tt.wantUnsubscribe {
+				t.Errorf("Unsubscribe() called service = %v, want %v", gotUnsubscribe, tt.wantUnsubscribe)
+			}
+			if tt.wantCode != "" {
+				var errResp model.ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if errResp.Error.Code != tt.wantCode {
+					t.Errorf("Unsubscribe() error code = %s, want %s", errResp.Error.Code, tt.wantCode)
+				}
+				return
+			}
+			var got model.SubscriptionResponse
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			want := model.SubscriptionResponse{Status: model.SubscriptionStatusUnsubscribed, MessageID: "msg-1"}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Unsubscribe() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,6 +18,10 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/clientaddr"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -25,6 +29,8 @@ import (
 type subscriptionHandler interface {
 	Create(http.ResponseWriter, *http.Request)
 	Update(http.ResponseWriter, *http.Request)
+	Delete(http.ResponseWriter, *http.Request)
+	Unsubscribe(http.ResponseWriter, *http.Request)
 }
 
 type lroHandler interface {
@@ -45,8 +51,11 @@ func NewRouter(
 
 	// Standard middleware stack
 	router.Use(middleware.RealIP)
+	router.Use(clientaddr.Middleware)
 	router.Use(middleware.Logger) // Chi's structured logger
 	router.Use(middleware.Recoverer)
+	router.Use(apiversion.Middleware)
+	router.Use(correlation.Middleware)
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -58,6 +67,8 @@ func NewRouter(
 	router.Group(func(r chi.Router) {
 		r.Post("/subscribe", sh.Create)
 		r.Patch("/subscribe", sh.Update)
+		r.Delete("/subscribe/{subscriber_id}/{key_id}", sh.Delete)
+		r.Post("/unsubscribe", sh.Unsubscribe)
 		r.Post("/lookup", lh.Lookup)
 	})
 
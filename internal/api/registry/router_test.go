@@ -19,6 +19,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/google/go-cmp/cmp"
 )
@@ -27,6 +29,10 @@ import (
 type mockSubscriptionHandler struct {
 	createCalled bool
 	updateCalled bool
+	deleteCalled bool
+	deleteKey    string // subscriber_id|key_id of the deleted subscription.
+
+	unsubscribeCalled bool
 }
 
 func (m *mockSubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +45,17 @@ func (m *mockSubscriptionHandler) Update(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockSubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	m.deleteCalled = true
+	m.deleteKey = chi.URLParam(r, "subscriber_id") + "|" + chi.URLParam(r, "key_id")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	m.unsubscribeCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 // mockLookupHandler is a mock implementation of the lookupHandler interface.
 type mockLookupHandler struct {
 	lookupCalled bool
@@ -98,6 +115,20 @@ func TestRouter_Middleware_Recoverer(t *testing.T) {
 	}
 }
 
+func TestRouter_CorrelationID(t *testing.T) {
+	sh := &mockSubscriptionHandler{}
+	router := NewRouter(sh, &mockLookupHandler{}, &mockLROHandler{})
+
+	req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+	req.Header.Set(correlation.Header, "corr-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(correlation.Header); got != "corr-1" {
+		t.Errorf("%s header = %q, want %q", correlation.Header, got, "corr-1")
+	}
+}
+
 func TestRouter_Routes(t *testing.T) {
 	sh := &mockSubscriptionHandler{}
 	lh := &mockLookupHandler{}
@@ -120,7 +151,7 @@ func TestRouter_Routes(t *testing.T) {
 			path:            "/health",
 			expectedStatus:  http.StatusOK,
 			expectedBody:    `{"status":"ok"}`,
-			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}, "X-Api-Version": []string{"2"}},
 			handlerCheck:    func(t *testing.T) { /* No specific handler mock to check */ },
 		},
 		{
@@ -145,6 +176,31 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "SubscribeDelete",
+			method:         http.MethodDelete,
+			path:           "/subscribe/np.com/key1",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !sh.deleteCalled {
+					t.Error("subscriptionHandler.Delete was not called")
+				}
+				if sh.deleteKey != "np.com|key1" {
+					t.Errorf("subscriptionHandler.Delete received wrong subscription: got %q, want %q", sh.deleteKey, "np.com|key1")
+				}
+			},
+		},
+		{
+			name:           "Unsubscribe",
+			method:         http.MethodPost,
+			path:           "/unsubscribe",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T) {
+				if !sh.unsubscribeCalled {
+					t.Error("subscriptionHandler.Unsubscribe was not called")
+				}
+			},
+		},
 		{
 			name:           "Lookup",
 			method:         http.MethodPost,
@@ -175,7 +231,7 @@ func TestRouter_Routes(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Reset mock states for each test
-			sh.createCalled, sh.updateCalled = false, false
+			sh.createCalled, sh.updateCalled, sh.deleteCalled, sh.unsubscribeCalled = false, false, false, false
 			lh.lookupCalled = false
 			lroh.getCalled, lroh.operationID = false, ""
 
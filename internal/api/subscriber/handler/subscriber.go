@@ -19,9 +19,16 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/log"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
@@ -29,6 +36,7 @@ import (
 type subscriberService interface {
 	CreateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error)
 	UpdateSubscription(ctx context.Context, req *model.NpSubscriptionRequest) (string, error)
+	Unsubscribe(ctx context.Context, req *model.NpSubscriptionRequest) (string, error)
 	UpdateStatus(ctx context.Context, opID string) (model.LROStatus, error)
 	OnSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error)
 }
@@ -36,6 +44,11 @@ type subscriberService interface {
 // subscriberHandler handles HTTP requests for subscriber operations.
 type subscriberHandler struct {
 	srv subscriberService
+	// registryRetryAfter is sent as the Retry-After of requests failed by a Registry outage. Zero omits the header.
+	registryRetryAfter time.Duration
+	// operationsURL is the URL of the Registry's operations. If set, accepted requests are answered with the
+	// Location of their operation and whether their message ID was generated. Empty omits both headers.
+	operationsURL string
 }
 
 // NewSubscriberHandler creates a new subscriberHandler.
@@ -47,6 +60,46 @@ func NewSubscriberHandler(srv subscriberService) (*subscriberHandler, error) {
 	return &subscriberHandler{srv: srv}, nil
 }
 
+// SetRegistryRetryAfter sets the delay clients are asked to wait before retrying
+// a subscription request that failed because the Registry was unavailable.
+func (h *subscriberHandler) SetRegistryRetryAfter(d time.Duration) {
+	h.registryRetryAfter = d
+}
+
+// SetOperationLocation answers accepted subscription requests with a Location header pointing to their
+// operation under registryURL, where clients poll its status, and a MessageIDGeneratedHeader telling
+// whether the message ID, which is the operation ID, was generated because the request had none.
+func (h *subscriberHandler) SetOperationLocation(registryURL string) {
+	h.operationsURL = strings.TrimSuffix(registryURL, "/") + "/operations/"
+}
+
+// setOperationHeaders sets the Location and MessageIDGeneratedHeader of an accepted request, if enabled.
+func (h *subscriberHandler) setOperationHeaders(w http.ResponseWriter, operationID string, generated bool) {
+	if h.operationsURL == "" {
+		return
+	}
+	w.Header().Set("Location", h.operationsURL+url.PathEscape(operationID))
+	w.Header().Set(model.MessageIDGeneratedHeader, strconv.FormatBool(generated))
+}
+
+// writeSubscriptionError writes the error of a subscription request. Registry outages are reported
+// as 503 so that clients retry them, and failures of the subscriber's own key store or signing as 500.
+func (h *subscriberHandler) writeSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrRegistryUnavailable):
+		if h.registryRetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(h.registryRetryAfter.Seconds()))))
+		}
+		writeSubscriberJSONError(w, http.StatusServiceUnavailable, model.ErrorTypeInternalError, model.ErrorCodeServiceUnavailable, err.Error())
+	case errors.Is(err, service.ErrSubscriptionNotFound):
+		writeSubscriberJSONError(w, http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, err.Error())
+	case errors.Is(err, service.ErrKeyStoreFailed), errors.Is(err, service.ErrKeyGenerationFailed), errors.Is(err, service.ErrSigningFailed):
+		writeSubscriberJSONError(w, http.StatusInternalServerError, model.ErrorTypeInternalError, model.ErrorCodeInternalServerError, err.Error())
+	default:
+		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+	}
+}
+
 // writeSubscriberJSONError is a helper function to construct and write standardized JSON error responses.
 func writeSubscriberJSONError(w http.ResponseWriter, statusCode int, errType model.ErrorType, errCode model.ErrorCode, errMsg string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -75,15 +128,17 @@ func (h *subscriberHandler) CreateSubscription(w http.ResponseWriter, r *http.Re
 	}
 	defer r.Body.Close()
 
+	log.SetSubscriberID(ctx, req.SubscriberID)
 	slog.InfoContext(ctx, "SubscriberHandler: Received create subscription request")
+	generated := req.MessageID == ""
 	operationID, err := h.srv.CreateSubscription(ctx, &req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberHandler: Error creating subscription", "error", err)
-		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
-
+		h.writeSubscriptionError(w, err)
 		return
 	}
 
+	h.setOperationHeaders(w, operationID, generated)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted) // 202 Accepted for LRO
 	if err := json.NewEncoder(w).Encode(operationID); err != nil {
@@ -103,14 +158,17 @@ func (h *subscriberHandler) UpdateSubscription(w http.ResponseWriter, r *http.Re
 	}
 	defer r.Body.Close()
 
+	log.SetSubscriberID(ctx, req.SubscriberID)
 	slog.InfoContext(ctx, "SubscriberHandler: Received update subscription request")
+	generated := req.MessageID == ""
 	lroID, err := h.srv.UpdateSubscription(ctx, &req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberHandler: Error updating subscription", "error", err)
-		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, err.Error())
+		h.writeSubscriptionError(w, err)
 		return
 	}
 
+	h.setOperationHeaders(w, lroID, generated)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted) // 202 Accepted for LRO
 	if err := json.NewEncoder(w).Encode(lroID); err != nil {
@@ -118,6 +176,36 @@ func (h *subscriberHandler) UpdateSubscription(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// Unsubscribe handles POST /unsubscribe requests.
+func (h *subscriberHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req model.NpSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to decode unsubscribe request", "error", err)
+		writeSubscriberJSONError(w, http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+
+	log.SetSubscriberID(ctx, req.SubscriberID)
+	slog.InfoContext(ctx, "SubscriberHandler: Received unsubscribe request")
+	generated := req.MessageID == ""
+	lroID, err := h.srv.Unsubscribe(ctx, &req)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Error unsubscribing", "error", err)
+		h.writeSubscriptionError(w, err)
+		return
+	}
+
+	h.setOperationHeaders(w, lroID, generated)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted) // 202 Accepted for LRO
+	if err := json.NewEncoder(w).Encode(lroID); err != nil {
+		slog.ErrorContext(ctx, "SubscriberHandler: Failed to encode LRO response for unsubscribe", "error", err, "message_id", lroID)
+	}
+}
+
 // StatusUpdate handles POST /statusUpdate requests.
 func (h *subscriberHandler) StatusUpdate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -19,12 +19,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/google/go-cmp/cmp"
@@ -48,6 +51,8 @@ type mockSubscriberService struct {
 	createSubErr    error
 	updateSubLroID  string
 	updateSubErr    error
+	unsubLroID      string
+	unsubErr        error
 	statusToReturn  model.LROStatus
 	updateStatusErr error
 	onSubscribeResp *model.OnSubscribeResponse
@@ -62,6 +67,10 @@ func (m *mockSubscriberService) UpdateSubscription(ctx context.Context, req *mod
 	return m.updateSubLroID, m.updateSubErr
 }
 
+func (m *mockSubscriberService) Unsubscribe(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
+	return m.unsubLroID, m.unsubErr
+}
+
 func (m *mockSubscriberService) UpdateStatus(ctx context.Context, opID string) (model.LROStatus, error) {
 	return m.statusToReturn, m.updateStatusErr
 }
@@ -236,6 +245,16 @@ func TestSubscriberHandler_CreateSubscription_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeBadRequest,
 			wantErrorMessage: "service layer error",
 		},
+		{
+			name:        "registry unavailable",
+			requestBody: []byte(`{"subscriber_id":"test"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.createSubErr = fmt.Errorf("%w: connection refused", service.ErrRegistryUnavailable)
+			},
+			wantStatusCode:   http.StatusServiceUnavailable,
+			wantErrorCode:    model.ErrorCodeServiceUnavailable,
+			wantErrorMessage: "connection refused",
+		},
 	}
 
 	for _, tt := range tests {
@@ -268,7 +287,83 @@ func TestSubscriberHandler_CreateSubscription_Error(t *testing.T) {
 	}
 }
 
+func TestSubscriberHandler_CreateSubscription_RegistryRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		want       string
+	}{
+		{name: "not configured", want: ""},
+		{name: "whole seconds", retryAfter: 30 * time.Second, want: "30"},
+		{name: "rounded up", retryAfter: 1500 * time.Millisecond, want: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSrv := &mockSubscriberService{createSubErr: fmt.Errorf("%w: connection refused", service.ErrRegistryUnavailable)}
+			handler, _ := NewSubscriberHandler(mockSrv)
+			handler.SetRegistryRetryAfter(tt.retryAfter)
+
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", bytes.NewBufferString(`{"subscriber_id":"test"}`))
+			rr := httptest.NewRecorder()
+			handler.CreateSubscription(rr, req)
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Errorf("CreateSubscription() status code = %v, want %v", rr.Code, http.StatusServiceUnavailable)
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.want {
+				t.Errorf("Retry-After = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestSubscriberHandler_CreateSubscription_EncodeError tests the JSON encoding failure path.
+func TestSubscriberHandler_OperationLocation(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		messageID     string
+		registryURL   string
+		wantLocation  string
+		wantGenerated string
+	}{
+		{name: "create with client-supplied id", method: http.MethodPost, messageID: "op-123", registryURL: "https://registry.example.com", wantLocation: "https://registry.example.com/operations/op-123", wantGenerated: "false"},
+		{name: "create with generated id", method: http.MethodPost, registryURL: "https://registry.example.com/", wantLocation: "https://registry.example.com/operations/op-123", wantGenerated: "true"},
+		{name: "update with client-supplied id", method: http.MethodPatch, messageID: "op-123", registryURL: "https://registry.example.com", wantLocation: "https://registry.example.com/operations/op-123", wantGenerated: "false"},
+		{name: "update with generated id", method: http.MethodPatch, registryURL: "https://registry.example.com", wantLocation: "https://registry.example.com/operations/op-123", wantGenerated: "true"},
+		{name: "disabled", method: http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := NewSubscriberHandler(&mockSubscriberService{createSubOpID: "op-123", updateSubLroID: "op-123"})
+			if tt.registryURL != "" {
+				h.SetOperationLocation(tt.registryURL)
+			}
+			reqBytes, _ := json.Marshal(&model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "test-sub"}, MessageID: tt.messageID})
+			req := httptest.NewRequest(tt.method, "/subscribe", bytes.NewBuffer(reqBytes))
+			rr := httptest.NewRecorder()
+
+			if tt.method == http.MethodPatch {
+				h.UpdateSubscription(rr, req)
+			} else {
+				h.CreateSubscription(rr, req)
+			}
+
+			if rr.Code != http.StatusAccepted {
+				t.Fatalf("status code = %v, want %v. Body: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if got := rr.Header().Get(model.MessageIDGeneratedHeader); got != tt.wantGenerated {
+				t.Errorf("%s = %q, want %q", model.MessageIDGeneratedHeader, got, tt.wantGenerated)
+			}
+		})
+	}
+}
+
 func TestSubscriberHandler_CreateSubscription_EncodeError(t *testing.T) {
 	mockSrv := &mockSubscriberService{createSubOpID: "op-123"}
 	handler, _ := NewSubscriberHandler(mockSrv)
@@ -388,6 +483,16 @@ func TestSubscriberHandler_UpdateSubscription_Error(t *testing.T) {
 			wantErrorCode:    model.ErrorCodeBadRequest,
 			wantErrorMessage: "update failed",
 		},
+		{
+			name:        "registry unavailable",
+			requestBody: []byte(`{"subscriber_id":"test"}`),
+			mockServiceSetup: func(ms *mockSubscriberService) {
+				ms.updateSubErr = fmt.Errorf("%w: connection refused", service.ErrRegistryUnavailable)
+			},
+			wantStatusCode:   http.StatusServiceUnavailable,
+			wantErrorCode:    model.ErrorCodeServiceUnavailable,
+			wantErrorMessage: "connection refused",
+		},
 	}
 
 	for _, tt := range tests {
@@ -420,6 +525,108 @@ func TestSubscriberHandler_UpdateSubscription_Error(t *testing.T) {
 	}
 }
 
+// TestSubscriberHandler_Unsubscribe_Success tests a successful unsubscribe.
+func TestSubscriberHandler_Unsubscribe_Success(t *testing.T) {
+	mockSrv := &mockSubscriberService{unsubLroID: "op-789"}
+	handler, _ := NewSubscriberHandler(mockSrv)
+
+	reqBytes, _ := json.Marshal(&model.NpSubscriptionRequest{
+		Subscriber: model.Subscriber{SubscriberID: "test-sub", Domain: "test.com", Type: model.RoleBAP},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/unsubscribe", bytes.NewBuffer(reqBytes))
+	rr := httptest.NewRecorder()
+
+	handler.Unsubscribe(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Errorf("Unsubscribe() status code = %v, want %v. Body: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+	var gotLroID string
+	if err := json.Unmarshal(rr.Body.Bytes(), &gotLroID); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if gotLroID != "op-789" {
+		t.Errorf("Unsubscribe() got LRO ID %q, want %q", gotLroID, "op-789")
+	}
+}
+
+// TestSubscriberHandler_Unsubscribe_Error tests error cases.
+func TestSubscriberHandler_Unsubscribe_Error(t *testing.T) {
+	tests := []struct {
+		name             string
+		requestBody      []byte
+		unsubErr         error
+		wantStatusCode   int
+		wantErrorCode    model.ErrorCode
+		wantErrorMessage string
+	}{
+		{
+			name:             "invalid JSON request body",
+			requestBody:      []byte("{not-json"),
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeInvalidJSON,
+			wantErrorMessage: "Invalid request body",
+		},
+		{
+			name:             "service returns error",
+			requestBody:      []byte(`{"subscriber_id":"test"}`),
+			unsubErr:         service.ErrMissingDomain,
+			wantStatusCode:   http.StatusBadRequest,
+			wantErrorCode:    model.ErrorCodeBadRequest,
+			wantErrorMessage: "domain is required",
+		},
+		{
+			name:             "registry unavailable",
+			requestBody:      []byte(`{"subscriber_id":"test"}`),
+			unsubErr:         fmt.Errorf("%w: connection refused", service.ErrRegistryUnavailable),
+			wantStatusCode:   http.StatusServiceUnavailable,
+			wantErrorCode:    model.ErrorCodeServiceUnavailable,
+			wantErrorMessage: "connection refused",
+		},
+		{
+			name:             "subscription not found",
+			requestBody:      []byte(`{"subscriber_id":"test"}`),
+			unsubErr:         fmt.Errorf("%w: subscriber_id test", service.ErrSubscriptionNotFound),
+			wantStatusCode:   http.StatusNotFound,
+			wantErrorCode:    model.ErrorCodeSubscriptionNotFound,
+			wantErrorMessage: "subscription not found",
+		},
+		{
+			name:             "keyset delete fails after registry success",
+			requestBody:      []byte(`{"subscriber_id":"test"}`),
+			unsubErr:         fmt.Errorf("%w: permission denied", service.ErrKeyStoreFailed),
+			wantStatusCode:   http.StatusInternalServerError,
+			wantErrorCode:    model.ErrorCodeInternalServerError,
+			wantErrorMessage: "permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := NewSubscriberHandler(&mockSubscriberService{unsubErr: tt.unsubErr})
+
+			req := httptest.NewRequest(http.MethodPost, "/unsubscribe", bytes.NewBuffer(tt.requestBody))
+			rr := httptest.NewRecorder()
+
+			handler.Unsubscribe(rr, req)
+
+			if rr.Code != tt.wantStatusCode {
+				t.Errorf("Unsubscribe() status code = %v, want %v. Body: %s", rr.Code, tt.wantStatusCode, rr.Body.String())
+			}
+			var gotErrorResp model.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &gotErrorResp); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v. Body: %s", err, rr.Body.String())
+			}
+			if gotErrorResp.Error.Code != tt.wantErrorCode {
+				t.Errorf("Unsubscribe() Error.Code = %s, want %s", gotErrorResp.Error.Code, tt.wantErrorCode)
+			}
+			if !strings.Contains(gotErrorResp.Error.Message, tt.wantErrorMessage) {
+				t.Errorf("Unsubscribe() Error.Message = %q, want to contain %q", gotErrorResp.Error.Message, tt.wantErrorMessage)
+			}
+		})
+	}
+}
+
 // TestSubscriberHandler_StatusUpdate_Success tests successful status update.
 func TestSubscriberHandler_StatusUpdate_Success(t *testing.T) {
 	mockSrv := &mockSubscriberService{statusToReturn: model.LROStatusApproved}
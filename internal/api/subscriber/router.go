@@ -19,6 +19,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/api/apiversion"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -27,6 +30,7 @@ import (
 type subscriberHandler interface {
 	CreateSubscription(w http.ResponseWriter, r *http.Request)
 	UpdateSubscription(w http.ResponseWriter, r *http.Request)
+	Unsubscribe(w http.ResponseWriter, r *http.Request)
 	StatusUpdate(w http.ResponseWriter, r *http.Request)
 	OnSubscribe(w http.ResponseWriter, r *http.Request)
 }
@@ -38,6 +42,8 @@ func NewRouter(sh subscriberHandler, oidcMiddleware func(http.Handler) http.Hand
 	router.Use(middleware.Logger)    // Log API requests
 	router.Use(middleware.Recoverer) // Recover from panics
 	router.Use(middleware.RequestID) // Add a request ID to the context
+	router.Use(apiversion.Middleware)
+	router.Use(correlation.Middleware)
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -51,6 +57,7 @@ func NewRouter(sh subscriberHandler, oidcMiddleware func(http.Handler) http.Hand
 		}
 		r.Post("/subscribe", sh.CreateSubscription)
 		r.Patch("/subscribe", sh.UpdateSubscription)
+		r.Post("/unsubscribe", sh.Unsubscribe)
 		r.Post("/updateStatus", sh.StatusUpdate)
 	})
 
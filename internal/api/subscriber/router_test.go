@@ -26,6 +26,7 @@ import (
 type mockSubscriberHandler struct {
 	createSubscriptionCalled bool
 	updateSubscriptionCalled bool
+	unsubscribeCalled        bool
 	statusUpdateCalled       bool
 	onSubscribeCalled        bool
 }
@@ -40,6 +41,11 @@ func (m *mockSubscriberHandler) UpdateSubscription(w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 }
 
+func (m *mockSubscriberHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	m.unsubscribeCalled = true
+	w.WriteHeader(http.StatusOK)
+}
+
 func (m *mockSubscriberHandler) StatusUpdate(w http.ResponseWriter, r *http.Request) {
 	m.statusUpdateCalled = true
 	w.WriteHeader(http.StatusOK)
@@ -69,7 +75,7 @@ func TestRouter_Routes(t *testing.T) {
 			path:            "/health",
 			expectedStatus:  http.StatusOK,
 			expectedBody:    `{"status":"ok","service":"subscriber"}`,
-			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}},
+			expectedHeaders: http.Header{"Content-Type": []string{"application/json"}, "X-Api-Version": []string{"2"}},
 			handlerCheck:    func(t *testing.T, h *mockSubscriberHandler) { /* No specific handler mock to check */ },
 		},
 		{
@@ -94,6 +100,17 @@ func TestRouter_Routes(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "Unsubscribe",
+			method:         http.MethodPost,
+			path:           "/unsubscribe",
+			expectedStatus: http.StatusOK,
+			handlerCheck: func(t *testing.T, h *mockSubscriberHandler) {
+				if !h.unsubscribeCalled {
+					t.Error("Unsubscribe was not called")
+				}
+			},
+		},
 		{
 			name:           "StatusUpdate",
 			method:         http.MethodPost,
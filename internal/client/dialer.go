@@ -0,0 +1,59 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ApplyHostOverrides makes transport connect to a fixed IP for the given hostnames
+// instead of resolving them through DNS, e.g. {"registry.example.com": "10.0.0.5"}.
+// The port of the request is kept. Hostnames are matched case-insensitively and
+// hosts without an override are dialed as usual. A nil or empty map leaves transport unchanged.
+func ApplyHostOverrides(transport *http.Transport, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	resolved := make(map[string]string, len(overrides))
+	for host, ip := range overrides {
+		if host == "" {
+			return fmt.Errorf("host override has an empty hostname")
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("host override for %q: %q is not a valid IP address", host, ip)
+		}
+		resolved[strings.ToLower(host)] = ip
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err == nil {
+			if ip, ok := resolved[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+	return nil
+}
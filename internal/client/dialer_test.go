@@ -0,0 +1,157 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// recordingDialer records the address it was asked to dial and fails the dial.
+type recordingDialer struct {
+	addr string
+}
+
+var errDialRecorded = errors.New("dial recorded")
+
+func (d *recordingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.addr = addr
+	return nil, errDialRecorded
+}
+
+func TestApplyHostOverrides(t *testing.T) {
+	overrides := map[string]string{
+		"registry.example.com": "10.0.0.5",
+		"np.example.com":       "2001:db8::1",
+	}
+	tests := []struct {
+		name     string
+		addr     string
+		wantAddr string
+	}{
+		{
+			name:     "overridden host",
+			addr:     "registry.example.com:443",
+			wantAddr: "10.0.0.5:443",
+		},
+		{
+			name:     "overridden host is case-insensitive",
+			addr:     "Registry.Example.COM:8080",
+			wantAddr: "10.0.0.5:8080",
+		},
+		{
+			name:     "IPv6 override",
+			addr:     "np.example.com:443",
+			wantAddr: "[2001:db8::1]:443",
+		},
+		{
+			name:     "host without override",
+			addr:     "other.example.com:443",
+			wantAddr: "other.example.com:443",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &recordingDialer{}
+			transport := &http.Transport{DialContext: d.DialContext}
+			if err := ApplyHostOverrides(transport, overrides); err != nil {
+				t.Fatalf("ApplyHostOverrides() error = %v", err)
+			}
+			if _, err := transport.DialContext(context.Background(), "tcp", tt.addr); !errors.Is(err, errDialRecorded) {
+				t.Fatalf("DialContext() error = %v, want %v", err, errDialRecorded)
+			}
+			if d.addr != tt.wantAddr {
+				t.Errorf("dialed address = %q, want %q", d.addr, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestApplyHostOverrides_Empty(t *testing.T) {
+	transport := &http.Transport{}
+	if err := ApplyHostOverrides(transport, nil); err != nil {
+		t.Fatalf("ApplyHostOverrides() error = %v", err)
+	}
+	if transport.DialContext != nil {
+		t.Error("ApplyHostOverrides() with no overrides changed DialContext, want unchanged")
+	}
+}
+
+func TestApplyHostOverrides_Error(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		wantErr   string
+	}{
+		{
+			name:      "invalid IP",
+			overrides: map[string]string{"registry.example.com": "not-an-ip"},
+			wantErr:   `"not-an-ip" is not a valid IP address`,
+		},
+		{
+			name:      "hostname instead of IP",
+			overrides: map[string]string{"registry.example.com": "localhost"},
+			wantErr:   `"localhost" is not a valid IP address`,
+		},
+		{
+			name:      "empty hostname",
+			overrides: map[string]string{"": "10.0.0.5"},
+			wantErr:   "empty hostname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplyHostOverrides(&http.Transport{}, tt.overrides)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ApplyHostOverrides() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyHostOverrides_RoutesRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if err := ApplyHostOverrides(transport, map[string]string{"registry.invalid": serverURL.Hostname()}); err != nil {
+		t.Fatalf("ApplyHostOverrides() error = %v", err)
+	}
+	client := &http.Client{Transport: transport}
+
+	// The .invalid TLD never resolves, so the request only succeeds through the override.
+	resp, err := client.Get("http://registry.invalid:" + serverURL.Port() + "/lookup")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Get() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
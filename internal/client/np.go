@@ -18,48 +18,305 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
+const onSubscribeAction = "on_subscribe"
+
+// ErrUnknownAction is returned when no route is configured for a Beckn action.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ErrResponseTooLarge is returned when a Network Participant's response body exceeds MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("NP response too large")
+
+// ErrUnrecognizedChallengeResponse is returned when an /on_subscribe response holds no answer in the expected format.
+var ErrUnrecognizedChallengeResponse = errors.New("unrecognized on_subscribe response")
+
+// CallbackStatusError is returned when a Network Participant answers an /on_subscribe callback with a status other than 200 OK.
+type CallbackStatusError struct {
+	StatusCode int
+	Body       []byte // The participant's response body.
+}
+
+func (e *CallbackStatusError) Error() string {
+	return fmt.Sprintf("NP callback failed with status %d", e.StatusCode)
+}
+
+// ChallengeResponseFormat is the shape of the response of a Network Participant to /on_subscribe.
+type ChallengeResponseFormat string
+
 const (
-	onSubscribePath = "/on_subscribe"
+	// ChallengeResponseFormatPlain is a bare {"answer": "..."} object. It is the default.
+	ChallengeResponseFormatPlain ChallengeResponseFormat = "plain"
+	// ChallengeResponseFormatBeckn is a Beckn response envelope carrying the answer in its message, {"message": {"answer": "..."}}.
+	ChallengeResponseFormatBeckn ChallengeResponseFormat = "beckn"
+	// ChallengeResponseFormatAuto accepts either shape, preferring the plain one.
+	ChallengeResponseFormatAuto ChallengeResponseFormat = "auto"
 )
 
+// valid reports whether f is a known format. The empty format means plain.
+func (f ChallengeResponseFormat) valid() bool {
+	switch f {
+	case "", ChallengeResponseFormatPlain, ChallengeResponseFormatBeckn, ChallengeResponseFormatAuto:
+		return true
+	}
+	return false
+}
+
+// defaultMaxResponseBytes bounds NP response bodies when MaxResponseBytes is not configured.
+const defaultMaxResponseBytes = 1 << 20 // 1 MiB
+
+// becknActions are the Beckn actions routed to POST /<action> by default.
+var becknActions = []string{
+	"search", "select", "init", "confirm", "status", "track", "cancel", "update", "rating", "support",
+	"on_search", "on_select", "on_init", "on_confirm", "on_status", "on_track", "on_cancel", "on_update", "on_rating", "on_support",
+	onSubscribeAction,
+}
+
+// ActionRoute is the HTTP method and path used to call a Beckn action on a Network Participant.
+type ActionRoute struct {
+	Method string `yaml:"method"` // HTTP method, e.g. POST.
+	Path   string `yaml:"path"`   // Path appended to the participant's base URL, e.g. /search.
+}
+
 // NPClientConfig holds configuration for the retryable HTTP client.
 type NPClientConfig struct {
-	Timeout time.Duration `yaml:"timeout"` // Timeout for each individual HTTP request attempt.
+	Timeout          time.Duration          `yaml:"timeout"`          // Timeout for each individual HTTP request attempt.
+	HostOverrides    map[string]string      `yaml:"hostOverrides"`    // Optional static hostname to IP mapping, bypasses DNS for the listed hosts.
+	ActionRoutes     map[string]ActionRoute `yaml:"actionRoutes"`     // Optional per-action routes, overriding the default POST /<action>.
+	MaxResponseBytes int64                  `yaml:"maxResponseBytes"` // Optional maximum size of a response body in bytes, 1 MiB by default.
+	// ChallengeResponseFormat is the expected shape of /on_subscribe responses, plain by default.
+	ChallengeResponseFormat ChallengeResponseFormat `yaml:"challengeResponseFormat"`
+
+	// Retry settings, as in RegistryClientConfig. Calls are not retried if RetryMax is 0.
+	RetryMax             int           `yaml:"retryMax"`
+	RetryWaitMin         time.Duration `yaml:"waitMin"`
+	RetryWaitMax         time.Duration `yaml:"waitMax"`
+	RetryableStatusCodes []int         `yaml:"retryableStatusCodes"`
+	RetryJitter          float64       `yaml:"jitter"`
+}
+
+// NPResponse is the raw response of a Network Participant to an action call.
+type NPResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
 }
 
 // DefaultNPClientConfig provides a sensible default configuration.
 func DefaultNPClientConfig() NPClientConfig {
 	return NPClientConfig{ //nolint:gomnd // Default configuration values
-		Timeout: 10 * time.Second, // Timeout for each attempt
+		Timeout:          10 * time.Second, // Timeout for each attempt
+		MaxResponseBytes: defaultMaxResponseBytes,
 	}
 }
 
 type httpNPClient struct {
-	client *http.Client
+	client           *http.Client
+	routes           map[string]ActionRoute
+	maxResponseBytes int64
+	challengeFormat  ChallengeResponseFormat
+	retry            RetryPolicy // Nil means requests are not retried.
+}
+
+// actionRoutes returns the default routing table with the configured overrides applied.
+func actionRoutes(overrides map[string]ActionRoute) (map[string]ActionRoute, error) {
+	routes := make(map[string]ActionRoute, len(becknActions)+len(overrides))
+	for _, action := range becknActions {
+		routes[action] = ActionRoute{Method: http.MethodPost, Path: "/" + action}
+	}
+	for action, route := range overrides {
+		if action == "" {
+			return nil, errors.New("action route has an empty action")
+		}
+		if route.Method == "" {
+			route.Method = http.MethodPost
+		}
+		route.Method = strings.ToUpper(route.Method)
+		if !strings.HasPrefix(route.Path, "/") {
+			return nil, fmt.Errorf("path %q for action %q must start with /", route.Path, action)
+		}
+		routes[action] = route
+	}
+	return routes, nil
 }
 
 // NewNPClient creates a new NPClient that uses a retryable HTTP client.
-func NewNPClient(cfg NPClientConfig) *httpNPClient {
+func NewNPClient(cfg NPClientConfig) (*httpNPClient, error) {
 	client := &http.Client{
 		Timeout: cfg.Timeout,
 	}
-	return &httpNPClient{
-		client: client,
+	if len(cfg.HostOverrides) > 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if err := ApplyHostOverrides(transport, cfg.HostOverrides); err != nil {
+			return nil, fmt.Errorf("invalid hostOverrides in NPClientConfig: %w", err)
+		}
+		client.Transport = transport
+	}
+	routes, err := actionRoutes(cfg.ActionRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid actionRoutes in NPClientConfig: %w", err)
+	}
+	if cfg.MaxResponseBytes < 0 {
+		return nil, fmt.Errorf("invalid maxResponseBytes in NPClientConfig: %d", cfg.MaxResponseBytes)
+	}
+	if !cfg.ChallengeResponseFormat.valid() {
+		return nil, fmt.Errorf("invalid challengeResponseFormat in NPClientConfig: %q, must be one of plain, beckn, auto", cfg.ChallengeResponseFormat)
+	}
+	challengeFormat := cfg.ChallengeResponseFormat
+	if challengeFormat == "" {
+		challengeFormat = ChallengeResponseFormatPlain
+	}
+	maxResponseBytes := cfg.MaxResponseBytes
+	if maxResponseBytes == 0 {
+		maxResponseBytes = defaultMaxResponseBytes
+	}
+	c := &httpNPClient{
+		client:           client,
+		routes:           routes,
+		maxResponseBytes: maxResponseBytes,
+		challengeFormat:  challengeFormat,
+	}
+	retry := &BackoffRetryPolicy{
+		RetryMax:    cfg.RetryMax,
+		WaitMin:     cfg.RetryWaitMin,
+		WaitMax:     cfg.RetryWaitMax,
+		StatusCodes: cfg.RetryableStatusCodes,
+		Jitter:      cfg.RetryJitter,
 	}
+	if err := retry.validate(); err != nil {
+		return nil, fmt.Errorf("invalid retry settings in NPClientConfig: %w", err)
+	}
+	if retry.RetryMax > 0 {
+		retry.applyDefaults()
+		c.retry = retry
+	}
+	return c, nil
+}
+
+// SetRetryPolicy sets the policy deciding which failed calls are retried, replacing the one
+// built from the retry settings of the config. Calls are not retried if p is nil. A response larger than MaxResponseBytes is never retried.
+func (c *httpNPClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
 }
 
 var jsonMarshal = json.Marshal
 
+// Call sends body to the endpoint of a Network Participant for the given Beckn action.
+// The method and path are resolved from the action routing table and the path is appended to baseURL.
+// The response is returned whatever its status code; it is up to the caller to interpret it.
+func (c *httpNPClient) Call(ctx context.Context, baseURL, action string, body []byte) (*NPResponse, error) {
+	route, ok := c.routes[action]
+	if !ok {
+		slog.ErrorContext(ctx, "NPClient: No route for action", "action", action)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, route.Method, baseURL+route.Path, bytes.NewReader(body))
+	if err != nil {
+		slog.ErrorContext(ctx, "NPClient: Failed to create HTTP request", "action", action, "error", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var (
+		resp     *http.Response
+		respBody []byte
+	)
+	for attempt := 0; ; attempt++ {
+		slog.InfoContext(ctx, "NPClient: Sending request", "action", action, "method", route.Method, "url", req.URL.String(), "attempt", attempt+1)
+		resp, respBody, err = c.send(req, action)
+		if c.retry == nil || errors.Is(err, ErrResponseTooLarge) || ctx.Err() != nil {
+			break
+		}
+		retry, wait := c.retry.ShouldRetry(req, resp, err, attempt)
+		if !retry {
+			break
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "NPClient: Retrying failed request", "action", action, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "error", err)
+		} else {
+			slog.WarnContext(ctx, "NPClient: Retrying request after status", "action", action, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "status_code", resp.StatusCode)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("HTTP request to NP failed: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NPResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// send makes a single attempt of req and returns the response along with its body.
+func (c *httpNPClient) send(req *http.Request, action string) (*http.Response, []byte, error) {
+	ctx := req.Context()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to rewind NP request body: %w", err)
+		}
+		req = req.Clone(ctx)
+		req.Body = body
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "NPClient: Failed to send request", "action", action, "url", req.URL.String(), "error", err)
+		return nil, nil, fmt.Errorf("HTTP request to NP failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read one byte past the limit to tell a body of exactly the limit from a larger one.
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseBytes+1))
+	if err != nil {
+		slog.ErrorContext(ctx, "NPClient: Failed to read response body", "action", action, "url", req.URL.String(), "error", err)
+		return nil, nil, fmt.Errorf("failed to read NP response: %w", err)
+	}
+	if int64(len(respBody)) > c.maxResponseBytes {
+		slog.ErrorContext(ctx, "NPClient: Response body exceeds limit", "action", action, "url", req.URL.String(), "limit_bytes", c.maxResponseBytes)
+		return nil, nil, fmt.Errorf("%w: body exceeds %d bytes", ErrResponseTooLarge, c.maxResponseBytes)
+	}
+	return resp, respBody, nil
+}
+
+// Probe checks that the Network Participant at baseURL is reachable with an OPTIONS request to it.
+// Any response, whatever its status, means the participant is reachable. Probes are not retried.
+func (c *httpNPClient) Probe(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, baseURL, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "NPClient: Failed to create probe request", "url", baseURL, "error", err)
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.WarnContext(ctx, "NPClient: Probe failed", "url", baseURL, "error", err)
+		return fmt.Errorf("HTTP probe of NP failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxResponseBytes))
+	slog.DebugContext(ctx, "NPClient: Probe succeeded", "url", baseURL, "status_code", resp.StatusCode)
+	return nil
+}
+
 // OnSubscribe sends a request to the Network Participant's (NP) /on_subscribe endpoint.
-// It handles request marshaling, sending the HTTP request with retries, and decoding the response.
+// It handles request marshaling, sending the HTTP request, and decoding the response.
 func (c *httpNPClient) OnSubscribe(ctx context.Context, callbackURL string, request *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
 	slog.InfoContext(ctx, "NPClient: Preparing /on_subscribe request", "url", callbackURL)
 
@@ -69,32 +326,64 @@ func (c *httpNPClient) OnSubscribe(ctx context.Context, callbackURL string, requ
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL+onSubscribePath, bytes.NewBuffer(requestBody))
+	resp, err := c.Call(ctx, callbackURL, onSubscribeAction, requestBody)
 	if err != nil {
-		slog.ErrorContext(ctx, "NPClient: Failed to create /on_subscribe HTTP request", "error", err)
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		slog.WarnContext(ctx, "NPClient: /on_subscribe callback returned non-OK status", "url", callbackURL, "status_code", resp.StatusCode)
+		return nil, &CallbackStatusError{StatusCode: resp.StatusCode, Body: resp.Body}
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	slog.InfoContext(ctx, "NPClient: Sending /on_subscribe request", "url", callbackURL)
-	resp, err := c.client.Do(req)
+	onSubscribeResponse, err := parseOnSubscribeResponse(resp.Body, c.challengeFormat)
 	if err != nil {
-		slog.ErrorContext(ctx, "NPClient: Failed to send /on_subscribe request", "url", callbackURL, "error", err)
-		return nil, fmt.Errorf("HTTP request to NP failed: %w", err)
+		slog.ErrorContext(ctx, "NPClient: Failed to decode /on_subscribe response", "url", callbackURL, "format", c.challengeFormat, "error", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		slog.WarnContext(ctx, "NPClient: /on_subscribe callback returned non-OK status", "url", callbackURL, "status_code", resp.StatusCode)
-		return nil, fmt.Errorf("NP callback failed with status %d", resp.StatusCode)
-	}
+	slog.InfoContext(ctx, "NPClient: Successfully received /on_subscribe response", "url", callbackURL)
+	return onSubscribeResponse, nil
+}
 
-	var onSubscribeResponse model.OnSubscribeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&onSubscribeResponse); err != nil {
-		slog.ErrorContext(ctx, "NPClient: Failed to decode /on_subscribe response", "url", callbackURL, "error", err)
+// parseOnSubscribeResponse extracts the answer of an /on_subscribe response of the given format.
+func parseOnSubscribeResponse(body []byte, format ChallengeResponseFormat) (*model.OnSubscribeResponse, error) {
+	var raw struct {
+		Answer  *string         `json:"answer"`
+		Message json.RawMessage `json:"message"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
 		return nil, fmt.Errorf("failed to decode NP response: %w", err)
 	}
 
-	slog.InfoContext(ctx, "NPClient: Successfully received /on_subscribe response", "url", callbackURL)
-	return &onSubscribeResponse, nil
+	// The message is only decoded for the formats that read it, so that a plain
+	// response is not rejected for carrying an unrelated message field.
+	var messageAnswer *string
+	if format == ChallengeResponseFormatBeckn || format == ChallengeResponseFormatAuto {
+		var msg struct {
+			Answer *string `json:"answer"`
+		}
+		if len(raw.Message) > 0 && json.Unmarshal(raw.Message, &msg) == nil {
+			messageAnswer = msg.Answer
+		}
+	}
+	switch format {
+	case ChallengeResponseFormatBeckn:
+		if messageAnswer != nil {
+			return &model.OnSubscribeResponse{Answer: *messageAnswer}, nil
+		}
+		return nil, fmt.Errorf("%w: expected the answer in message.answer", ErrUnrecognizedChallengeResponse)
+	case ChallengeResponseFormatAuto:
+		if raw.Answer != nil {
+			return &model.OnSubscribeResponse{Answer: *raw.Answer}, nil
+		}
+		if messageAnswer != nil {
+			return &model.OnSubscribeResponse{Answer: *messageAnswer}, nil
+		}
+		return nil, fmt.Errorf("%w: expected the answer in answer or message.answer", ErrUnrecognizedChallengeResponse)
+	default:
+		if raw.Answer != nil {
+			return &model.OnSubscribeResponse{Answer: *raw.Answer}, nil
+		}
+		return nil, fmt.Errorf("%w: expected the answer in answer", ErrUnrecognizedChallengeResponse)
+	}
 }
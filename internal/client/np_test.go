@@ -17,10 +17,12 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -37,8 +39,8 @@ func testRetryConfig() NPClientConfig {
 func TestHttpNPClient_OnSubscribe_Success(t *testing.T) {
 	expectedResponse := &model.OnSubscribeResponse{Answer: "correct_answer"}
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != onSubscribePath {
-			t.Errorf("expected path %q, got %q", onSubscribePath, r.URL.Path)
+		if r.URL.Path != "/on_subscribe" {
+			t.Errorf("expected path %q, got %q", "/on_subscribe", r.URL.Path)
 		}
 		if r.Method != http.MethodPost {
 			t.Errorf("expected method %q, got %q", http.MethodPost, r.Method)
@@ -52,7 +54,10 @@ func TestHttpNPClient_OnSubscribe_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewNPClient(testRetryConfig())
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
 	request := &model.OnSubscribeRequest{Challenge: "test_challenge"}
 
 	resp, err := client.OnSubscribe(context.Background(), server.URL, request)
@@ -147,7 +152,10 @@ func TestHttpNPClient_OnSubscribe_Error(t *testing.T) {
 				serverURL = server.URL
 			}
 
-			client := NewNPClient(testRetryConfig())
+			client, err := NewNPClient(testRetryConfig())
+			if err != nil {
+				t.Fatalf("NewNPClient() error = %v", err)
+			}
 			resp, err := client.OnSubscribe(tc.ctx, serverURL, tc.request)
 
 			if err == nil {
@@ -164,7 +172,10 @@ func TestHttpNPClient_OnSubscribe_Error(t *testing.T) {
 }
 
 func TestHttpNPClient_OnSubscribe_MarshalError(t *testing.T) {
-	client := NewNPClient(testRetryConfig())
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
 	request := &model.OnSubscribeRequest{Challenge: "test_challenge"}
 	wantErrMsg := "failed to marshal request"
 
@@ -186,3 +197,517 @@ func TestHttpNPClient_OnSubscribe_MarshalError(t *testing.T) {
 		t.Errorf("OnSubscribe() response should be nil on error, but got %+v", resp)
 	}
 }
+
+func TestNewNPClient_HostOverrides(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(model.OnSubscribeResponse{Answer: "test_answer"})
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	cfg := testRetryConfig()
+	cfg.HostOverrides = map[string]string{"np.invalid": serverURL.Hostname()}
+	client, err := NewNPClient(cfg)
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+
+	resp, err := client.OnSubscribe(context.Background(), "http://np.invalid:"+serverURL.Port(), &model.OnSubscribeRequest{Challenge: "test_challenge"})
+	if err != nil {
+		t.Fatalf("OnSubscribe() through host override error = %v", err)
+	}
+	if resp.Answer != "test_answer" {
+		t.Errorf("OnSubscribe() answer = %q, want %q", resp.Answer, "test_answer")
+	}
+}
+
+func TestNewNPClient_InvalidHostOverrides(t *testing.T) {
+	cfg := testRetryConfig()
+	cfg.HostOverrides = map[string]string{"np.example.com": "not-an-ip"}
+	if _, err := NewNPClient(cfg); err == nil || !strings.Contains(err.Error(), "invalid hostOverrides") {
+		t.Errorf("NewNPClient() error = %v, want error containing %q", err, "invalid hostOverrides")
+	}
+}
+
+func TestHttpNPClient_Call(t *testing.T) {
+	tests := []struct {
+		name       string
+		routes     map[string]ActionRoute
+		action     string
+		wantMethod string
+		wantPath   string
+	}{
+		{
+			name:       "default route for search",
+			action:     "search",
+			wantMethod: http.MethodPost,
+			wantPath:   "/search",
+		},
+		{
+			name:       "default route for on_confirm",
+			action:     "on_confirm",
+			wantMethod: http.MethodPost,
+			wantPath:   "/on_confirm",
+		},
+		{
+			name:       "configured route",
+			routes:     map[string]ActionRoute{"status": {Method: "put", Path: "/v2/status"}},
+			action:     "status",
+			wantMethod: http.MethodPut,
+			wantPath:   "/v2/status",
+		},
+		{
+			name:       "configured route for a custom action defaults to POST",
+			routes:     map[string]ActionRoute{"issue": {Path: "/igm/issue"}},
+			action:     "issue",
+			wantMethod: http.MethodPost,
+			wantPath:   "/igm/issue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.wantMethod {
+					t.Errorf("method = %q, want %q", r.Method, tt.wantMethod)
+				}
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %q, want %q", r.URL.Path, tt.wantPath)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != `{"k":"v"}` {
+					t.Errorf("body = %s, want %s", body, `{"k":"v"}`)
+				}
+				w.Header().Set("X-Test", "ok")
+				w.WriteHeader(http.StatusAccepted)
+				io.WriteString(w, `{"message":{"ack":{"status":"ACK"}}}`)
+			}))
+			defer server.Close()
+
+			cfg := testRetryConfig()
+			cfg.ActionRoutes = tt.routes
+			client, err := NewNPClient(cfg)
+			if err != nil {
+				t.Fatalf("NewNPClient() error = %v", err)
+			}
+			resp, err := client.Call(context.Background(), server.URL, tt.action, []byte(`{"k":"v"}`))
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if resp.StatusCode != http.StatusAccepted {
+				t.Errorf("Call() status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+			}
+			if resp.Header.Get("X-Test") != "ok" {
+				t.Errorf("Call() header X-Test = %q, want %q", resp.Header.Get("X-Test"), "ok")
+			}
+			if string(resp.Body) != `{"message":{"ack":{"status":"ACK"}}}` {
+				t.Errorf("Call() body = %s", resp.Body)
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_Call_RetryPolicy(t *testing.T) {
+	// Retries conflicts, which BackoffRetryPolicy never retries, but not 503, which it does.
+	conflictsOnly := RetryPolicyFunc(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+		return attempt < 2 && resp != nil && resp.StatusCode == http.StatusConflict, time.Millisecond
+	})
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		statuses   []int // Returned in turn, the last one repeated.
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "no policy does not retry", statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "backoff policy retries 503", policy: &BackoffRetryPolicy{RetryMax: 2, WaitMin: time.Millisecond, WaitMax: time.Millisecond}, statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "custom policy retries 409", policy: conflictsOnly, statuses: []int{http.StatusConflict, http.StatusOK}, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "custom policy gives up on 409", policy: conflictsOnly, statuses: []int{http.StatusConflict}, wantStatus: http.StatusConflict, wantCalls: 3},
+		{name: "custom policy does not retry 503", policy: conflictsOnly, statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != `{"k":"v"}` {
+					t.Errorf("attempt %d: body = %s, want the original body", calls+1, body)
+				}
+				w.WriteHeader(tt.statuses[min(calls, len(tt.statuses)-1)])
+				calls++
+			}))
+			defer server.Close()
+
+			client, err := NewNPClient(testRetryConfig())
+			if err != nil {
+				t.Fatalf("NewNPClient() error = %v", err)
+			}
+			client.SetRetryPolicy(tt.policy)
+			resp, err := client.Call(context.Background(), server.URL, "search", []byte(`{"k":"v"}`))
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Call() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_Call_ConfigRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryMax   int
+		codes      []int
+		statuses   []int // Returned in turn, the last one repeated.
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "retries disabled", statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "retries 503", retryMax: 2, statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "gives up after retryMax", retryMax: 2, statuses: []int{http.StatusServiceUnavailable}, wantStatus: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "retries configured status codes", retryMax: 2, codes: []int{http.StatusConflict}, statuses: []int{http.StatusConflict, http.StatusOK}, wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "does not retry other status codes", retryMax: 2, codes: []int{http.StatusConflict}, statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[min(calls, len(tt.statuses)-1)])
+				calls++
+			}))
+			defer server.Close()
+
+			cfg := testRetryConfig()
+			cfg.RetryMax = tt.retryMax
+			cfg.RetryWaitMin = time.Millisecond
+			cfg.RetryWaitMax = time.Millisecond
+			cfg.RetryableStatusCodes = tt.codes
+			client, err := NewNPClient(cfg)
+			if err != nil {
+				t.Fatalf("NewNPClient() error = %v", err)
+			}
+			resp, err := client.Call(context.Background(), server.URL, "search", []byte(`{}`))
+			if err != nil {
+				t.Fatalf("Call() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Call() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewNPClient_InvalidRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *NPClientConfig)
+		wantErr string
+	}{
+		{name: "negative retryMax", modify: func(cfg *NPClientConfig) { cfg.RetryMax = -1 }, wantErr: "retryMax cannot be negative"},
+		{name: "non-error status code", modify: func(cfg *NPClientConfig) { cfg.RetryableStatusCodes = []int{http.StatusOK} }, wantErr: "retryableStatusCodes: 200 is not an error status"},
+		{name: "jitter out of range", modify: func(cfg *NPClientConfig) { cfg.RetryJitter = 1.5 }, wantErr: "jitter must be between 0 and 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testRetryConfig()
+			tt.modify(&cfg)
+			if _, err := NewNPClient(cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewNPClient() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_Call_RetryPolicy_ResponseTooLarge(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, "0123456789")
+	}))
+	defer server.Close()
+
+	cfg := testRetryConfig()
+	cfg.MaxResponseBytes = 5
+	client, err := NewNPClient(cfg)
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+	client.SetRetryPolicy(RetryPolicyFunc(func(*http.Request, *http.Response, error, int) (bool, time.Duration) {
+		return true, 0
+	}))
+	if _, err := client.Call(context.Background(), server.URL, "search", nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Call() error = %v, want %v", err, ErrResponseTooLarge)
+	}
+	if calls != 1 {
+		t.Errorf("server received %d requests, want 1", calls)
+	}
+}
+
+func TestHttpNPClient_Call_UnknownAction(t *testing.T) {
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+	if _, err := client.Call(context.Background(), "http://np.example.com", "unknown", nil); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("Call() error = %v, want %v", err, ErrUnknownAction)
+	}
+}
+
+func TestNewNPClient_InvalidActionRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes map[string]ActionRoute
+	}{
+		{
+			name:   "empty action",
+			routes: map[string]ActionRoute{"": {Path: "/search"}},
+		},
+		{
+			name:   "relative path",
+			routes: map[string]ActionRoute{"search": {Path: "search"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testRetryConfig()
+			cfg.ActionRoutes = tt.routes
+			if _, err := NewNPClient(cfg); err == nil || !strings.Contains(err.Error(), "invalid actionRoutes") {
+				t.Errorf("NewNPClient() error = %v, want error containing %q", err, "invalid actionRoutes")
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_Call_MaxResponseBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		bodySize int
+		wantErr  error
+	}{
+		{
+			name:     "within limit",
+			bodySize: 10,
+		},
+		{
+			name:     "exactly at limit",
+			bodySize: 16,
+		},
+		{
+			name:     "exceeds limit",
+			bodySize: 17,
+			wantErr:  ErrResponseTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(strings.Repeat("a", tt.bodySize)))
+			}))
+			defer server.Close()
+
+			cfg := testRetryConfig()
+			cfg.MaxResponseBytes = 16
+			client, err := NewNPClient(cfg)
+			if err != nil {
+				t.Fatalf("NewNPClient() error = %v", err)
+			}
+
+			resp, err := client.Call(context.Background(), server.URL, "search", []byte(`{}`))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Call() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(resp.Body) != tt.bodySize {
+				t.Errorf("Call() body length = %d, want %d", len(resp.Body), tt.bodySize)
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_OnSubscribe_ResponseTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"answer":"` + strings.Repeat("a", defaultMaxResponseBytes) + `"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+
+	resp, err := client.OnSubscribe(context.Background(), server.URL, &model.OnSubscribeRequest{Challenge: "test_challenge"})
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("OnSubscribe() error = %v, want %v", err, ErrResponseTooLarge)
+	}
+	if resp != nil {
+		t.Errorf("OnSubscribe() response = %+v, want nil", resp)
+	}
+}
+
+func TestHttpNPClient_OnSubscribe_CallbackStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"30001","message":"unknown challenge"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+
+	_, err = client.OnSubscribe(context.Background(), server.URL, &model.OnSubscribeRequest{Challenge: "test_challenge"})
+	var statusErr *CallbackStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("OnSubscribe() error = %v, want a *CallbackStatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("StatusCode = %d, want %d", statusErr.StatusCode, http.StatusBadRequest)
+	}
+	if want := `{"error":{"code":"30001","message":"unknown challenge"}}`; string(statusErr.Body) != want {
+		t.Errorf("Body = %q, want %q", statusErr.Body, want)
+	}
+}
+
+func TestHttpNPClient_Probe(t *testing.T) {
+	var gotMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		// Any answer means the participant is reachable.
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+	if err := client.Probe(context.Background(), server.URL); err != nil {
+		t.Errorf("Probe() error = %v, want nil", err)
+	}
+	if gotMethod != http.MethodOptions {
+		t.Errorf("Probe() method = %q, want %q", gotMethod, http.MethodOptions)
+	}
+}
+
+func TestHttpNPClient_Probe_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := server.URL
+	server.Close()
+
+	client, err := NewNPClient(testRetryConfig())
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+	if err := client.Probe(context.Background(), baseURL); err == nil {
+		t.Error("Probe() error = nil, want an error for an unreachable participant")
+	}
+}
+
+func TestNewNPClient_InvalidMaxResponseBytes(t *testing.T) {
+	cfg := testRetryConfig()
+	cfg.MaxResponseBytes = -1
+	if _, err := NewNPClient(cfg); err == nil || !strings.Contains(err.Error(), "invalid maxResponseBytes") {
+		t.Errorf("NewNPClient() error = %v, want error containing %q", err, "invalid maxResponseBytes")
+	}
+}
+
+func TestParseOnSubscribeResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ChallengeResponseFormat
+		body   string
+		want   string
+	}{
+		{name: "plain", format: ChallengeResponseFormatPlain, body: `{"answer":"42"}`, want: "42"},
+		{name: "plain by default", body: `{"answer":"42"}`, want: "42"},
+		{name: "plain with unrelated message", body: `{"answer":"42","message":"ok"}`, want: "42"},
+		{name: "plain empty answer", body: `{"answer":""}`, want: ""},
+		{name: "beckn", format: ChallengeResponseFormatBeckn, body: `{"context":{"action":"on_subscribe"},"message":{"answer":"42"}}`, want: "42"},
+		{name: "auto plain", format: ChallengeResponseFormatAuto, body: `{"answer":"42"}`, want: "42"},
+		{name: "auto beckn", format: ChallengeResponseFormatAuto, body: `{"context":{},"message":{"answer":"42"}}`, want: "42"},
+		{name: "auto prefers plain", format: ChallengeResponseFormatAuto, body: `{"answer":"42","message":{"answer":"7"}}`, want: "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOnSubscribeResponse([]byte(tt.body), tt.format)
+			if err != nil {
+				t.Fatalf("parseOnSubscribeResponse() error = %v", err)
+			}
+			if got.Answer != tt.want {
+				t.Errorf("parseOnSubscribeResponse() answer = %q, want %q", got.Answer, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOnSubscribeResponse_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  ChallengeResponseFormat
+		body    string
+		wantErr error
+		wantMsg string
+	}{
+		{name: "plain given beckn", format: ChallengeResponseFormatPlain, body: `{"message":{"answer":"42"}}`, wantErr: ErrUnrecognizedChallengeResponse, wantMsg: "expected the answer in answer"},
+		{name: "beckn given plain", format: ChallengeResponseFormatBeckn, body: `{"answer":"42"}`, wantErr: ErrUnrecognizedChallengeResponse, wantMsg: "expected the answer in message.answer"},
+		{name: "beckn message not an object", format: ChallengeResponseFormatBeckn, body: `{"message":"42"}`, wantErr: ErrUnrecognizedChallengeResponse, wantMsg: "expected the answer in message.answer"},
+		{name: "auto given neither", format: ChallengeResponseFormatAuto, body: `{"message":{"ack":{"status":"ACK"}}}`, wantErr: ErrUnrecognizedChallengeResponse, wantMsg: "expected the answer in answer or message.answer"},
+		{name: "invalid JSON", format: ChallengeResponseFormatAuto, body: `{"answer":`, wantMsg: "failed to decode NP response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOnSubscribeResponse([]byte(tt.body), tt.format)
+			if err == nil {
+				t.Fatalf("parseOnSubscribeResponse() = %+v, want error", got)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseOnSubscribeResponse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("parseOnSubscribeResponse() error = %q, want error containing %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestHttpNPClient_OnSubscribe_BecknFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"context":{"action":"on_subscribe"},"message":{"answer":"correct_answer"}}`))
+	}))
+	defer server.Close()
+
+	cfg := testRetryConfig()
+	cfg.ChallengeResponseFormat = ChallengeResponseFormatBeckn
+	client, err := NewNPClient(cfg)
+	if err != nil {
+		t.Fatalf("NewNPClient() error = %v", err)
+	}
+
+	resp, err := client.OnSubscribe(context.Background(), server.URL, &model.OnSubscribeRequest{Challenge: "test_challenge"})
+	if err != nil {
+		t.Fatalf("OnSubscribe() error = %v", err)
+	}
+	if resp.Answer != "correct_answer" {
+		t.Errorf("response Answer = %q, want %q", resp.Answer, "correct_answer")
+	}
+}
+
+func TestNewNPClient_InvalidChallengeResponseFormat(t *testing.T) {
+	cfg := testRetryConfig()
+	cfg.ChallengeResponseFormat = "xml"
+	if _, err := NewNPClient(cfg); err == nil || !strings.Contains(err.Error(), "invalid challengeResponseFormat") {
+		t.Errorf("NewNPClient() error = %v, want error containing %q", err, "invalid challengeResponseFormat")
+	}
+}
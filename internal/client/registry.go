@@ -18,34 +18,95 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 const (
-	lookupPath        = "/lookup"
-	subscribePath     = "/subscribe"
-	operationsPathFmt = "/operations/%s" // Format string for operation ID
+	lookupPath          = "/lookup"
+	subscribePath       = "/subscribe"
+	unsubscribePath     = "/unsubscribe"
+	operationsPathFmt   = "/operations/%s"         // Format string for operation ID
+	subscriptionPathFmt = subscribePath + "/%s/%s" // Format string for subscriber ID and key ID
 )
 
+// ErrRegistryUnavailable is wrapped by errors of requests that could not reach the Registry,
+// or that it kept answering with a retryable status. Such requests may succeed later.
+var ErrRegistryUnavailable = errors.New("registry unavailable")
+
+// ErrRegistryRejected is wrapped by errors of requests the Registry definitively rejected,
+// answering with a 4xx status that is not retried. Such requests were not applied.
+var ErrRegistryRejected = errors.New("registry rejected the request")
+
+// ErrSubscriptionNotFound is returned when the Registry has no subscription to delete or look up.
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// statusError is returned when the Registry answers with an unexpected status.
+type statusError struct {
+	action     string
+	statusCode int
+	body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("registry %s failed with status %d: %s", e.action, e.statusCode, e.body)
+}
+
+// subscriptionNotFound reports whether e is a 404 whose body is an error with ErrorCodeSubscriptionNotFound,
+// the Registry's answer for a subscription it does not hold, rather than for a route it does not serve.
+func (e *statusError) subscriptionNotFound() bool {
+	if e.statusCode != http.StatusNotFound {
+		return false
+	}
+	var errResp model.ErrorResponse
+	return json.Unmarshal([]byte(e.body), &errResp) == nil && errResp.Error.Code == model.ErrorCodeSubscriptionNotFound
+}
+
 // RegistryClientConfig holds configuration for the retryable HTTP client for the Registry.
 type RegistryClientConfig struct {
-	Timeout             time.Duration `yaml:"timeout"` // Timeout for each individual HTTP request attempt.
-	BaseURL             string        `yaml:"baseURL"` // Base URL of the registry service (e.g., "http://localhost:8080")
-	MaxIdleConns        int           `yaml:"maxIdleConns"`
-	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost"`
-	MaxConnsPerHost     int           `yaml:"maxConnsPerHost"`
-	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout"`
+	Timeout             time.Duration     `yaml:"timeout"` // Timeout for each individual HTTP request attempt.
+	BaseURL             string            `yaml:"baseURL"` // Base URL of the registry service (e.g., "http://localhost:8080")
+	MaxIdleConns        int               `yaml:"maxIdleConns"`
+	MaxIdleConnsPerHost int               `yaml:"maxIdleConnsPerHost"`
+	MaxConnsPerHost     int               `yaml:"maxConnsPerHost"`
+	IdleConnTimeout     time.Duration     `yaml:"idleConnTimeout"`
+	HostOverrides       map[string]string `yaml:"hostOverrides"` // Optional static hostname to IP mapping, bypasses DNS for the listed hosts.
+	// DomainBaseURLs optionally maps network domains to the base URL of the Registry serving them.
+	// Lookups for a listed domain go to its Registry, all other requests go to BaseURL.
+	DomainBaseURLs map[string]string `yaml:"domainBaseURLs"`
+
+	// Retry settings. Requests are not retried if RetryMax is 0.
+	RetryMax      int                   `yaml:"retryMax"`      // Maximum number of retries.
+	RetryWaitMin  time.Duration         `yaml:"waitMin"`       // Base backoff, doubled on every retry.
+	RetryWaitMax  time.Duration         `yaml:"waitMax"`       // Upper bound of the backoff.
+	StatusBackoff map[int]time.Duration `yaml:"statusBackoff"` // Per-status-code base backoff overriding waitMin, e.g. {429: 5s}.
+	// RetryableStatusCodes are the status codes retried, 429 and 5xx other than 501 by default.
+	RetryableStatusCodes []int `yaml:"retryableStatusCodes"`
+	// RetryJitter is the fraction, from 0 to 1, of each backoff randomly taken off it, so that
+	// clients failing together do not retry together. 0 means no jitter.
+	RetryJitter float64 `yaml:"jitter"`
+	// RetryUpdates retries the requests changing subscriptions, i.e. creates, updates and
+	// unsubscribes, which are not idempotent. Defaults to false: only lookups, GET and DELETE
+	// requests are retried.
+	RetryUpdates bool `yaml:"retryUpdates"`
 }
 
 type httpRegistryClient struct {
-	client  *http.Client
-	baseURL string
+	client         *http.Client
+	baseURL        string
+	domainBaseURLs map[string]string // Base URL of the Registry serving each listed domain's lookups.
+
+	retry        RetryPolicy
+	defaultRetry *BackoffRetryPolicy // Built from the config, restored by SetRetryPolicy(nil).
+	retryUpdates bool                // Whether requests that are not idempotent are retried.
 }
 
 // NewRegistryClient creates a new RegistryClient that uses a retryable HTTP client.
@@ -78,30 +139,162 @@ func NewRegistryClient(cfg *RegistryClientConfig) (*httpRegistryClient, error) {
 	if cfg.IdleConnTimeout > 0 {
 		transport.IdleConnTimeout = cfg.IdleConnTimeout
 	}
+	if err := ApplyHostOverrides(transport, cfg.HostOverrides); err != nil {
+		return nil, fmt.Errorf("invalid hostOverrides in RegistryClientConfig: %w", err)
+	}
+	for domain, baseURL := range cfg.DomainBaseURLs {
+		if domain == "" || baseURL == "" {
+			return nil, fmt.Errorf("invalid domainBaseURLs in RegistryClientConfig: domain %q and its base URL %q cannot be empty", domain, baseURL)
+		}
+	}
+	retry := &BackoffRetryPolicy{
+		RetryMax:      cfg.RetryMax,
+		WaitMin:       cfg.RetryWaitMin,
+		WaitMax:       cfg.RetryWaitMax,
+		StatusBackoff: cfg.StatusBackoff,
+		StatusCodes:   cfg.RetryableStatusCodes,
+		Jitter:        cfg.RetryJitter,
+	}
+	if err := retry.validate(); err != nil {
+		return nil, fmt.Errorf("invalid retry settings in RegistryClientConfig: %w", err)
+	}
+	retry.applyDefaults()
 
 	client := &http.Client{
 		Timeout:   cfg.Timeout,
 		Transport: transport,
 	}
 	return &httpRegistryClient{
-		client:  client,
-		baseURL: cfg.BaseURL,
+		client:         client,
+		baseURL:        cfg.BaseURL,
+		domainBaseURLs: cfg.DomainBaseURLs,
+		retry:          retry,
+		defaultRetry:   retry,
+		retryUpdates:   cfg.RetryUpdates,
 	}, nil
 }
 
-// doAPIRequest is a helper function to handle common logic for making API requests.
+// SetRetryPolicy replaces the policy deciding which failed requests are retried, by default a
+// BackoffRetryPolicy built from the retry settings of the config. A nil p restores the default.
+func (c *httpRegistryClient) SetRetryPolicy(p RetryPolicy) {
+	if p == nil {
+		c.retry = c.defaultRetry
+		return
+	}
+	c.retry = p
+}
+
+// send makes a single attempt of req and returns the response along with its body.
+func (c *httpRegistryClient) send(req *http.Request, logAction string) (*http.Response, []byte, error) {
+	ctx := req.Context()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to rewind Registry %s request body: %w", logAction, err)
+		}
+		req = req.Clone(ctx)
+		req.Body = body
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "RegistryClient: Failed to send request", "action", logAction, "url", req.URL.String(), "error", err)
+		return nil, nil, fmt.Errorf("HTTP request to Registry %s failed: %w", logAction, err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "RegistryClient: Failed to read response body", "action", logAction, "url", req.URL.String(), "error", err)
+		return nil, nil, fmt.Errorf("failed to read Registry %s response body: %w", logAction, err)
+	}
+	return resp, responseBody, nil
+}
+
+// errRetryInterrupted is returned by doWithRetry when the context of the request is done while waiting to retry it.
+var errRetryInterrupted = errors.New("retry interrupted")
+
+// doWithRetry sends req and, if retry is set, sends it again for as long as the retry policy decides,
+// waiting between attempts. It returns the last response along with its body, or the last error.
+func (c *httpRegistryClient) doWithRetry(req *http.Request, logAction string, retry bool) (*http.Response, []byte, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		slog.DebugContext(ctx, "RegistryClient: Sending request", "action", logAction, "url", req.URL.String(), "attempt", attempt+1)
+		resp, responseBody, err := c.send(req, logAction)
+		if !retry || ctx.Err() != nil {
+			return resp, responseBody, err
+		}
+		again, wait := c.retry.ShouldRetry(req, resp, err, attempt)
+		if !again {
+			return resp, responseBody, err
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "RegistryClient: Retrying failed request", "action", logAction, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "error", err)
+		} else {
+			slog.WarnContext(ctx, "RegistryClient: Retrying request after status", "action", logAction, "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "status_code", resp.StatusCode)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, errRetryInterrupted
+		case <-timer.C:
+		}
+	}
+}
+
+// retryableResponse reports whether the retry policy of c retries resp, as told by the policy
+// if it is a StatusClassifier, or else if it would retry resp on a first attempt.
+func (c *httpRegistryClient) retryableResponse(req *http.Request, resp *http.Response) bool {
+	if sc, ok := c.retry.(StatusClassifier); ok {
+		return sc.Retryable(resp.StatusCode)
+	}
+	again, _ := c.retry.ShouldRetry(req, resp, nil, 0)
+	return again
+}
+
+// lookupBaseURL returns the base URL of the Registry serving the lookups of domain,
+// the configured base URL unless the domain has its own Registry.
+func (c *httpRegistryClient) lookupBaseURL(domain string) string {
+	if baseURL, ok := c.domainBaseURLs[domain]; ok {
+		return baseURL
+	}
+	return c.baseURL
+}
+
+// doAPIRequest is a helper function to handle common logic for making API requests to the configured Registry.
 func (c *httpRegistryClient) doAPIRequest(
 	ctx context.Context,
 	method string,
 	pathFormat string,
 	pathArgs []any,
+	requestData any,
+	responseData any,
+	expectedStatusCode int,
+	logAction string,
+	idempotent bool,
+	authHeaderName string,
+	authHeader string,
+) error {
+	return c.doAPIRequestTo(ctx, c.baseURL, method, pathFormat, pathArgs, requestData, responseData, expectedStatusCode, logAction, idempotent, authHeaderName, authHeader)
+}
+
+// doAPIRequestTo makes an API request like doAPIRequest to the Registry at baseURL.
+func (c *httpRegistryClient) doAPIRequestTo(
+	ctx context.Context,
+	baseURL string,
+	method string,
+	pathFormat string,
+	pathArgs []any,
 	requestData any, // Will be marshalled to JSON if not nil
 	responseData any, // Pointer to struct to unmarshal JSON response
 	expectedStatusCode int,
 	logAction string, // e.g., "POST /subscribe"
+	idempotent bool, // Whether the request can be sent twice with the effect of sending it once
+	authHeaderName string, // Header carrying authHeader, e.g., model.AuthHeaderSubscriber
 	authHeader string,
 ) error {
-	fullURL := c.baseURL + fmt.Sprintf(pathFormat, pathArgs...)
+	fullURL := baseURL + fmt.Sprintf(pathFormat, pathArgs...)
 	slog.DebugContext(ctx, "RegistryClient: Preparing request", "action", logAction, "url", fullURL)
 
 	var reqBodyReader io.Reader
@@ -120,29 +313,32 @@ func (c *httpRegistryClient) doAPIRequest(
 		return fmt.Errorf("failed to create HTTP request for %s: %w", logAction, err)
 	}
 	if authHeader != "" {
-		req.Header.Set(model.AuthHeaderSubscriber, authHeader)
+		req.Header.Set(authHeaderName, authHeader)
 	}
 	if requestData != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	slog.DebugContext(ctx, "RegistryClient: Sending request", "action", logAction, "url", fullURL)
-	resp, err := c.client.Do(req)
-	if err != nil {
-		slog.ErrorContext(ctx, "RegistryClient: Failed to send request", "action", logAction, "url", fullURL, "error", err)
-		return fmt.Errorf("HTTP request to Registry %s failed: %w", logAction, err)
+	// A retry of a request that is not idempotent may apply it twice, e.g. create two operations,
+	// so such requests are only retried if configured.
+	resp, responseBody, err := c.doWithRetry(req, logAction, idempotent || c.retryUpdates)
+	if errors.Is(err, errRetryInterrupted) {
+		return fmt.Errorf("HTTP request to Registry %s failed: %w", logAction, ctx.Err())
 	}
-	defer resp.Body.Close()
-
-	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		slog.ErrorContext(ctx, "RegistryClient: Failed to read response body", "action", logAction, "url", fullURL, "error", err)
-		return fmt.Errorf("failed to read Registry %s response body: %w", logAction, err)
+		return fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
 	}
 
 	if resp.StatusCode != expectedStatusCode {
 		slog.WarnContext(ctx, "RegistryClient: Endpoint returned unexpected status", "action", logAction, "url", fullURL, "status_code", resp.StatusCode, "expected_status_code", expectedStatusCode, "response_body", string(responseBody))
-		return fmt.Errorf("registry %s failed with status %d: %s", logAction, resp.StatusCode, string(responseBody))
+		err := &statusError{action: logAction, statusCode: resp.StatusCode, body: string(responseBody)}
+		if c.retryableResponse(req, resp) {
+			return fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
+		}
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout {
+			return fmt.Errorf("%w: %w", ErrRegistryRejected, err)
+		}
+		return err
 	}
 
 	if responseData != nil {
@@ -157,19 +353,106 @@ func (c *httpRegistryClient) doAPIRequest(
 }
 
 // Lookup sends a POST request to the Registry's /lookup endpoint.
+// It requests the pages of a paginated result in turn and returns all their subscriptions.
 func (c *httpRegistryClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
 	var subscriptions []model.Subscription
-	err := c.doAPIRequest(ctx, http.MethodPost, lookupPath, nil, request, &subscriptions, http.StatusOK, "POST /lookup", "")
+	seen := make(map[string]bool)
+	for token := ""; ; {
+		page, err := c.LookupPaged(ctx, request, token, 0)
+		if err != nil {
+			return nil, err
+		}
+		if subscriptions == nil {
+			subscriptions = page.Subscriptions
+		} else {
+			subscriptions = append(subscriptions, page.Subscriptions...)
+		}
+		if page.NextPageToken == "" {
+			return subscriptions, nil
+		}
+		if seen[page.NextPageToken] {
+			slog.ErrorContext(ctx, "RegistryClient: Registry returned a page token twice", "page_token", page.NextPageToken)
+			return nil, fmt.Errorf("registry POST /lookup returned page token %q twice", page.NextPageToken)
+		}
+		seen[page.NextPageToken] = true
+		token = page.NextPageToken
+		slog.DebugContext(ctx, "RegistryClient: Fetching next lookup page", "page_token", token, "subscriptions", len(subscriptions))
+	}
+}
+
+// LookupPaged sends a POST request to the Registry's /lookup endpoint for the page of at most
+// pageSize subscriptions at pageToken. An empty pageToken requests the first page and a pageSize
+// of 0 leaves the size to the Registry. A Registry without pagination returns a single page.
+// The request is sent to the Registry configured for its domain, if any.
+func (c *httpRegistryClient) LookupPaged(ctx context.Context, request *model.Subscription, pageToken string, pageSize int) (*model.LookupPage, error) {
+	if pageSize < 0 {
+		return nil, fmt.Errorf("invalid lookup page size %d, must not be negative", pageSize)
+	}
+	q := url.Values{}
+	if pageToken != "" {
+		q.Set("page_token", pageToken)
+	}
+	if pageSize > 0 {
+		q.Set("page_size", strconv.Itoa(pageSize))
+	}
+	var query string
+	if len(q) > 0 {
+		query = "?" + q.Encode()
+	}
+	var page model.LookupPage
+	// The query is passed as an argument since it may hold escaped characters.
+	var domain string
+	if request != nil {
+		domain = request.Domain
+	}
+	err := c.doAPIRequestTo(ctx, c.lookupBaseURL(domain), http.MethodPost, lookupPath+"%s", []any{query}, request, &page, http.StatusOK, "POST /lookup", true, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// LookupWithKeys looks up subscriptions like Lookup, keeping only those that carry both
+// their signing and encryption public keys, so that callers can verify the participants they resolve.
+// Subscriptions only hold public key material; private keys are never part of a lookup result.
+func (c *httpRegistryClient) LookupWithKeys(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	subscriptions, err := c.Lookup(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	withKeys := make([]model.Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		if sub.SigningPublicKey == "" || sub.EncrPublicKey == "" {
+			slog.WarnContext(ctx, "RegistryClient: Dropping lookup result without public keys", "subscriber_id", sub.SubscriberID, "key_id", sub.KeyID)
+			continue
+		}
+		withKeys = append(withKeys, sub)
+	}
+	return withKeys, nil
+}
+
+// LookupByKeyID looks up the subscription of subscriberID holding keyID, e.g. to find the owner
+// of a key while debugging a key rotation. It returns ErrSubscriptionNotFound if the Registry has
+// none, and an error if it returns more than one.
+func (c *httpRegistryClient) LookupByKeyID(ctx context.Context, subscriberID, keyID string) (*model.Subscription, error) {
+	subscriptions, err := c.Lookup(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: subscriberID}, KeyID: keyID})
 	if err != nil {
 		return nil, err
 	}
-	return subscriptions, nil
+	switch len(subscriptions) {
+	case 0:
+		return nil, fmt.Errorf("%w: subscriber_id '%s', key_id '%s'", ErrSubscriptionNotFound, subscriberID, keyID)
+	case 1:
+		return &subscriptions[0], nil
+	}
+	slog.ErrorContext(ctx, "RegistryClient: Lookup by key id matched several subscriptions", "subscriber_id", subscriberID, "key_id", keyID, "matches", len(subscriptions))
+	return nil, fmt.Errorf("lookup of subscriber_id '%s', key_id '%s' is ambiguous: registry returned %d subscriptions", subscriberID, keyID, len(subscriptions))
 }
 
 // CreateSubscription sends a POST request to the Registry's /subscribe endpoint to create a new subscription.
 func (c *httpRegistryClient) CreateSubscription(ctx context.Context, request *model.SubscriptionRequest) (*model.SubscriptionResponse, error) {
 	var subResponse model.SubscriptionResponse
-	err := c.doAPIRequest(ctx, http.MethodPost, subscribePath, nil, request, &subResponse, http.StatusOK, "POST /subscribe", "")
+	err := c.doAPIRequest(ctx, http.MethodPost, subscribePath, nil, request, &subResponse, http.StatusOK, "POST /subscribe", false, "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -178,9 +461,14 @@ func (c *httpRegistryClient) CreateSubscription(ctx context.Context, request *mo
 }
 
 // UpdateSubscription sends a PATCH request to the Registry's /subscribe endpoint to update an existing subscription.
-func (c *httpRegistryClient) UpdateSubscription(ctx context.Context, request *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+// The auth scheme selects the header authHeader is sent in.
+func (c *httpRegistryClient) UpdateSubscription(ctx context.Context, request *model.SubscriptionRequest, scheme model.AuthScheme, authHeader string) (*model.SubscriptionResponse, error) {
+	headerName, err := scheme.HeaderName()
+	if err != nil {
+		return nil, err
+	}
 	var subResponse model.SubscriptionResponse
-	err := c.doAPIRequest(ctx, http.MethodPatch, subscribePath, nil, request, &subResponse, http.StatusOK, "PATCH /subscribe", authHeader)
+	err = c.doAPIRequest(ctx, http.MethodPatch, subscribePath, nil, request, &subResponse, http.StatusOK, "PATCH /subscribe", false, headerName, authHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -188,11 +476,47 @@ func (c *httpRegistryClient) UpdateSubscription(ctx context.Context, request *mo
 	return &subResponse, nil
 }
 
+// Unsubscribe sends a POST request to the Registry's /unsubscribe endpoint to move a subscription
+// to UNSUBSCRIBED, authorized by authHeader. It returns ErrSubscriptionNotFound if the Registry
+// reports that it has no such subscription.
+func (c *httpRegistryClient) Unsubscribe(ctx context.Context, request *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+	var subResponse model.SubscriptionResponse
+	err := c.doAPIRequest(ctx, http.MethodPost, unsubscribePath, nil, request, &subResponse, http.StatusOK, "POST /unsubscribe", false, model.AuthHeaderSubscriber, authHeader)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.subscriptionNotFound() {
+		return nil, fmt.Errorf("%w: %w", ErrSubscriptionNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "RegistryClient: Successfully received POST /unsubscribe response", "url", c.baseURL+unsubscribePath, "message_id", subResponse.MessageID)
+	return &subResponse, nil
+}
+
+// DeleteSubscription sends req in a DELETE request to the Registry's /subscribe/{subscriber_id}/{key_id}
+// endpoint to remove the subscription it names, authorized by authHeader signing req. It returns ErrSubscriptionNotFound if the Registry
+// reports that it has no such subscription. Other 404s, e.g. from a Registry without the route, are
+// returned as plain status errors.
+func (c *httpRegistryClient) DeleteSubscription(ctx context.Context, req *model.DeleteSubscriptionRequest, authHeader string) error {
+	subscriberID, keyID := req.SubscriberID, req.KeyID
+	logAction := fmt.Sprintf("DELETE /subscribe/%s/%s", subscriberID, keyID)
+	err := c.doAPIRequest(ctx, http.MethodDelete, subscriptionPathFmt, []any{url.PathEscape(subscriberID), url.PathEscape(keyID)}, req, nil, http.StatusOK, logAction, true, model.AuthHeaderSubscriber, authHeader)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.subscriptionNotFound() {
+		return fmt.Errorf("%w: %w", ErrSubscriptionNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "RegistryClient: Successfully received DELETE /subscribe response", "subscriber_id", subscriberID, "key_id", keyID)
+	return nil
+}
+
 // GetOperation sends a GET request to the Registry's /operations/{operation_id} endpoint to retrieve LRO status.
 func (c *httpRegistryClient) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
 	var lro model.LRO
 	logAction := fmt.Sprintf("GET /operations/%s", operationID)
-	err := c.doAPIRequest(ctx, http.MethodGet, operationsPathFmt, []any{operationID}, nil, &lro, http.StatusOK, logAction, "")
+	err := c.doAPIRequest(ctx, http.MethodGet, operationsPathFmt, []any{operationID}, nil, &lro, http.StatusOK, logAction, true, "", "")
 	if err != nil {
 		return nil, err
 	}
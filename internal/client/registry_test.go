@@ -22,6 +22,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -106,6 +107,37 @@ func TestNewRegistryClient(t *testing.T) {
 		}
 	})
 
+	t.Run("host overrides route to configured address", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("[]"))
+		}))
+		defer server.Close()
+		serverURL, _ := url.Parse(server.URL)
+
+		cfg := &RegistryClientConfig{
+			BaseURL:       "http://registry.invalid:" + serverURL.Port(),
+			HostOverrides: map[string]string{"registry.invalid": serverURL.Hostname()},
+		}
+		client, err := NewRegistryClient(cfg)
+		if err != nil {
+			t.Fatalf("NewRegistryClient() error = %v, wantErr false", err)
+		}
+		if _, err := client.Lookup(context.Background(), &model.Subscription{}); err != nil {
+			t.Errorf("Lookup() through host override error = %v, want nil", err)
+		}
+	})
+
+	t.Run("invalid host override", func(t *testing.T) {
+		cfg := &RegistryClientConfig{
+			BaseURL:       "http://localhost:8080",
+			HostOverrides: map[string]string{"registry.example.com": "not-an-ip"},
+		}
+		if _, err := NewRegistryClient(cfg); err == nil || !strings.Contains(err.Error(), "invalid hostOverrides") {
+			t.Errorf("NewRegistryClient() error = %v, want error containing %q", err, "invalid hostOverrides")
+		}
+	})
+
 	t.Run("default timeout", func(t *testing.T) {
 		cfg := &RegistryClientConfig{
 			BaseURL: "http://localhost:8080",
@@ -135,6 +167,46 @@ func TestNewRegistryClient(t *testing.T) {
 				config:  &RegistryClientConfig{},
 				wantErr: "BaseURL cannot be empty in RegistryClientConfig",
 			},
+			{
+				name:    "negative retryMax",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", RetryMax: -1},
+				wantErr: "retryMax cannot be negative",
+			},
+			{
+				name:    "waitMin greater than waitMax",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", RetryWaitMin: 2 * time.Second, RetryWaitMax: time.Second},
+				wantErr: "waitMin (2s) cannot be greater than waitMax (1s)",
+			},
+			{
+				name:    "statusBackoff for non-retryable status",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", StatusBackoff: map[int]time.Duration{404: time.Second}},
+				wantErr: "statusBackoff for status 404: status is never retried",
+			},
+			{
+				name:    "retryableStatusCodes with a non-error status",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", RetryableStatusCodes: []int{503, 200}},
+				wantErr: "retryableStatusCodes: 200 is not an error status",
+			},
+			{
+				name:    "statusBackoff for status not in retryableStatusCodes",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", RetryableStatusCodes: []int{503}, StatusBackoff: map[int]time.Duration{429: time.Second}},
+				wantErr: "statusBackoff for status 429: status is never retried",
+			},
+			{
+				name:    "jitter above 1",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", RetryJitter: 1.5},
+				wantErr: "jitter must be between 0 and 1, got 1.5",
+			},
+			{
+				name:    "non-positive statusBackoff",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", StatusBackoff: map[int]time.Duration{429: 0}},
+				wantErr: "statusBackoff for status 429 must be positive",
+			},
+			{
+				name:    "empty domain base URL",
+				config:  &RegistryClientConfig{BaseURL: "http://localhost:8080", DomainBaseURLs: map[string]string{"ONDC:RET10": ""}},
+				wantErr: `domain "ONDC:RET10" and its base URL "" cannot be empty`,
+			},
 		}
 
 		for _, tc := range testCases {
@@ -160,6 +232,10 @@ func runErrorTests(t *testing.T, testName string, clientCall func(context.Contex
 		ctx        context.Context
 		wantErrMsg string
 		setup      func(cfg *RegistryClientConfig)
+		// wantUnavailable is whether the error wraps ErrRegistryUnavailable.
+		wantUnavailable bool
+		// wantRejected is whether the error wraps ErrRegistryRejected.
+		wantRejected bool
 	}{
 		{
 			name: "server returns 500",
@@ -169,8 +245,21 @@ func runErrorTests(t *testing.T, testName string, clientCall func(context.Contex
 					t.Fatalf("failed to write response: %v", err)
 				}
 			},
-			ctx:        context.Background(),
-			wantErrMsg: fmt.Sprintf("registry %s failed with status 500: internal error", logAction),
+			ctx:             context.Background(),
+			wantErrMsg:      fmt.Sprintf("registry %s failed with status 500: internal error", logAction),
+			wantUnavailable: true,
+		},
+		{
+			name: "server returns 400",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				if _, err := io.WriteString(w, "bad request"); err != nil {
+					t.Fatalf("failed to write response: %v", err)
+				}
+			},
+			ctx:          context.Background(),
+			wantErrMsg:   fmt.Sprintf("registry %s failed with status 400: bad request", logAction),
+			wantRejected: true,
 		},
 		{
 			name: "response body is not valid JSON",
@@ -195,7 +284,8 @@ func runErrorTests(t *testing.T, testName string, clientCall func(context.Contex
 				_ = cancel
 				return ctx
 			}(),
-			wantErrMsg: "context deadline exceeded",
+			wantErrMsg:      "context deadline exceeded",
+			wantUnavailable: true,
 		},
 		{
 			name: "network error",
@@ -207,6 +297,7 @@ func runErrorTests(t *testing.T, testName string, clientCall func(context.Contex
 				// Use an invalid URL to simulate a network error
 				cfg.BaseURL = "http://unreachable-host:9999"
 			},
+			wantUnavailable: true,
 		},
 	}
 
@@ -233,6 +324,12 @@ func runErrorTests(t *testing.T, testName string, clientCall func(context.Contex
 			if tc.wantErrMsg != "" && !strings.Contains(err.Error(), tc.wantErrMsg) {
 				t.Errorf("%s() error = %q, want error containing %q", testName, err.Error(), tc.wantErrMsg)
 			}
+			if got := errors.Is(err, ErrRegistryUnavailable); got != tc.wantUnavailable {
+				t.Errorf("errors.Is(%s() error, ErrRegistryUnavailable) = %t, want %t", testName, got, tc.wantUnavailable)
+			}
+			if got := errors.Is(err, ErrRegistryRejected); got != tc.wantRejected {
+				t.Errorf("errors.Is(%s() error, ErrRegistryRejected) = %t, want %t", testName, got, tc.wantRejected)
+			}
 			if !isNil(resp) {
 				t.Errorf("%s() response should be nil on error, but got %+v", testName, resp)
 			}
@@ -331,6 +428,389 @@ func TestHttpRegistryClient_Lookup_MarshalError(t *testing.T) {
 		"POST /lookup")
 }
 
+func TestHttpRegistryClient_LookupPaged(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageToken string
+		pageSize  int
+		response  string
+		wantQuery url.Values
+		want      *model.LookupPage
+	}{
+		{
+			name:      "first page",
+			pageSize:  2,
+			response:  `{"subscriptions":[{"subscriber_id":"a"},{"subscriber_id":"b"}],"next_page_token":"t1"}`,
+			wantQuery: url.Values{"page_size": {"2"}},
+			want: &model.LookupPage{
+				Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "a"}}, {Subscriber: model.Subscriber{SubscriberID: "b"}}},
+				NextPageToken: "t1",
+			},
+		},
+		{
+			name:      "last page",
+			pageToken: "t/1+",
+			pageSize:  2,
+			response:  `{"subscriptions":[{"subscriber_id":"c"}]}`,
+			wantQuery: url.Values{"page_token": {"t/1+"}, "page_size": {"2"}},
+			want:      &model.LookupPage{Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "c"}}}},
+		},
+		{
+			name:      "registry without pagination",
+			response:  `[{"subscriber_id":"a"}]`,
+			wantQuery: url.Values{},
+			want:      &model.LookupPage{Subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "a"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != lookupPath || r.Method != http.MethodPost {
+					t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, lookupPath)
+				}
+				if diff := cmp.Diff(tt.wantQuery, r.URL.Query()); diff != "" {
+					t.Errorf("query mismatch (-want +got):\n%s", diff)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+			got, err := client.LookupPaged(context.Background(), &model.Subscription{}, tt.pageToken, tt.pageSize)
+			if err != nil {
+				t.Fatalf("LookupPaged() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LookupPaged() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_LookupPaged_NegativePageSize(t *testing.T) {
+	client, _ := NewRegistryClient(testRegistryClientConfig("http://localhost"))
+	if _, err := client.LookupPaged(context.Background(), &model.Subscription{}, "", -1); err == nil {
+		t.Error("LookupPaged() error = nil, want an error for a negative page size")
+	}
+}
+
+func TestHttpRegistryClient_Lookup_Pages(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"subscriptions":[{"subscriber_id":"a"}],"next_page_token":"t1"}`,
+		"t1": `{"subscriptions":[{"subscriber_id":"b"}],"next_page_token":"t2"}`,
+		"t2": `{"subscriptions":[{"subscriber_id":"c"}]}`,
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req model.Subscription
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SubscriberID != "filter" {
+			t.Errorf("page request body = %+v, %v, want the lookup filter", req, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, pages[r.URL.Query().Get("page_token")])
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	got, err := client.Lookup(context.Background(), &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "filter"}})
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	want := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "a"}},
+		{Subscriber: model.Subscriber{SubscriberID: "b"}},
+		{Subscriber: model.Subscriber{SubscriberID: "c"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lookup() mismatch (-want +got):\n%s", diff)
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
+
+func TestHttpRegistryClient_Lookup_RepeatedPageToken(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"subscriptions":[{"subscriber_id":"a"}],"next_page_token":"loop"}`)
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	if _, err := client.Lookup(context.Background(), &model.Subscription{}); err == nil || !strings.Contains(err.Error(), `page token "loop" twice`) {
+		t.Errorf("Lookup() error = %v, want a repeated page token error", err)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestHttpRegistryClient_Lookup_DomainBaseURLs(t *testing.T) {
+	newRegistry := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `[{"subscriber_id":%q}]`, name)
+		}))
+	}
+	defaultRegistry, retailRegistry, mobilityRegistry := newRegistry("default"), newRegistry("retail"), newRegistry("mobility")
+	defer defaultRegistry.Close()
+	defer retailRegistry.Close()
+	defer mobilityRegistry.Close()
+
+	cfg := testRegistryClientConfig(defaultRegistry.URL)
+	cfg.DomainBaseURLs = map[string]string{
+		"ONDC:RET10": retailRegistry.URL,
+		"ONDC:TRV10": mobilityRegistry.URL,
+	}
+	client, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		want   string
+	}{
+		{name: "retail domain", domain: "ONDC:RET10", want: "retail"},
+		{name: "mobility domain", domain: "ONDC:TRV10", want: "mobility"},
+		{name: "unlisted domain", domain: "ONDC:FIS12", want: "default"},
+		{name: "no domain", domain: "", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Lookup(context.Background(), &model.Subscription{Subscriber: model.Subscriber{Domain: tt.domain}})
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if len(got) != 1 || got[0].SubscriberID != tt.want {
+				t.Errorf("Lookup() = %+v, want the subscription of the %s registry", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_DeleteSubscription(t *testing.T) {
+	req := &model.DeleteSubscriptionRequest{Method: http.MethodDelete, SubscriberID: "sub/1", KeyID: "key-1", Nonce: "n1"}
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{name: "deleted", status: http.StatusOK},
+		{name: "subscription not found", status: http.StatusNotFound, body: `{"error":{"type":"NOT_FOUND","code":"SUBSCRIPTION_NOT_FOUND","message":"Subscription not found."}}`, wantErr: true, wantNotFound: true},
+		{name: "route not found", status: http.StatusNotFound, body: "404 page not found", wantErr: true},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete {
+					t.Errorf("method = %q, want %q", r.Method, http.MethodDelete)
+				}
+				if want := "/subscribe/sub%2F1/key-1"; r.URL.EscapedPath() != want {
+					t.Errorf("path = %q, want %q", r.URL.EscapedPath(), want)
+				}
+				if got := r.Header.Get(model.AuthHeaderSubscriber); got != "signed" {
+					t.Errorf("auth header = %q, want %q", got, "signed")
+				}
+				var gotReq model.DeleteSubscriptionRequest
+				if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				if diff := cmp.Diff(*req, gotReq); diff != "" {
+					t.Errorf("request body mismatch (-want +got):\n%s", diff)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			cfg := testRegistryClientConfig(server.URL)
+			cfg.RetryMax = 0
+			client, _ := NewRegistryClient(cfg)
+			err := client.DeleteSubscription(context.Background(), req, "signed")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrSubscriptionNotFound); got != tt.wantNotFound {
+				t.Errorf("DeleteSubscription() error = %v, is ErrSubscriptionNotFound = %v, want %v", err, got, tt.wantNotFound)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_Unsubscribe(t *testing.T) {
+	expectedRequest := &model.SubscriptionRequest{
+		MessageID:    "msg-789",
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub1"}, KeyID: "key-1", Status: model.SubscriptionStatusUnsubscribed},
+	}
+	expectedResponse := &model.SubscriptionResponse{MessageID: "msg-789", Status: model.SubscriptionStatusUnderSubscription}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != unsubscribePath {
+			t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, http.MethodPost, unsubscribePath)
+		}
+		if got := r.Header.Get(model.AuthHeaderSubscriber); got != "signed" {
+			t.Errorf("auth header = %q, want %q", got, "signed")
+		}
+		var gotRequest model.SubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&gotRequest); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if diff := cmp.Diff(expectedRequest, &gotRequest); diff != "" {
+			t.Errorf("request body mismatch (-want +got):\n%s", diff)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expectedResponse)
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	resp, err := client.Unsubscribe(context.Background(), expectedRequest, "signed")
+	if err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if diff := cmp.Diff(expectedResponse, resp); diff != "" {
+		t.Errorf("Unsubscribe() response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHttpRegistryClient_Unsubscribe_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	if _, err := client.Unsubscribe(context.Background(), &model.SubscriptionRequest{}, "signed"); err == nil {
+		t.Error("Unsubscribe() error = nil, want an error")
+	}
+}
+
+func TestHttpRegistryClient_Unsubscribe_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"type":"NOT_FOUND","code":"SUBSCRIPTION_NOT_FOUND","message":"Subscription not found."}}`)
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	if _, err := client.Unsubscribe(context.Background(), &model.SubscriptionRequest{}, "signed"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("Unsubscribe() error = %v, want %v", err, ErrSubscriptionNotFound)
+	}
+}
+
+func TestHttpRegistryClient_LookupWithKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// A misbehaving registry sending private material must not have it surface in the results.
+		w.Write([]byte(`[
+			{"subscriber_id":"with-keys","key_id":"k1","signing_public_key":"sign1","encr_public_key":"encr1","signing_private_key":"secret","encr_private_key":"secret"},
+			{"subscriber_id":"no-signing-key","key_id":"k2","encr_public_key":"encr2"},
+			{"subscriber_id":"no-encr-key","key_id":"k3","signing_public_key":"sign3"}
+		]`))
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	got, err := client.LookupWithKeys(context.Background(), &model.Subscription{})
+	if err != nil {
+		t.Fatalf("LookupWithKeys() error = %v", err)
+	}
+
+	want := []model.Subscription{{
+		Subscriber:       model.Subscriber{SubscriberID: "with-keys"},
+		KeyID:            "k1",
+		SigningPublicKey: "sign1",
+		EncrPublicKey:    "encr1",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LookupWithKeys() mismatch (-want +got):\n%s", diff)
+	}
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(b), "private") || strings.Contains(string(b), "secret") {
+		t.Errorf("LookupWithKeys() results leak private material: %s", b)
+	}
+}
+
+func TestHttpRegistryClient_LookupWithKeys_Error(t *testing.T) {
+	runErrorTests(t, "LookupWithKeys",
+		func(ctx context.Context, client *httpRegistryClient) (any, error) {
+			return client.LookupWithKeys(ctx, &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-sub"}})
+		},
+		"POST /lookup", true)
+}
+
+func TestHttpRegistryClient_LookupByKeyID(t *testing.T) {
+	sub := func(domain string) string {
+		return `{"subscriber_id":"test-sub","key_id":"k1","domain":"` + domain + `","signing_public_key":"sign1"}`
+	}
+	tests := []struct {
+		name     string
+		response string
+		want     *model.Subscription
+		wantErr  error
+		wantMsg  string
+	}{
+		{
+			name:     "single match",
+			response: "[" + sub("retail") + "]",
+			want:     &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-sub", Domain: "retail"}, KeyID: "k1", SigningPublicKey: "sign1"},
+		},
+		{name: "no match", response: "[]", wantErr: ErrSubscriptionNotFound},
+		{name: "several matches", response: "[" + sub("retail") + "," + sub("mobility") + "]", wantMsg: "ambiguous: registry returned 2 subscriptions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var gotRequest model.Subscription
+				if err := json.NewDecoder(r.Body).Decode(&gotRequest); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				wantRequest := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-sub"}, KeyID: "k1"}
+				if diff := cmp.Diff(wantRequest, gotRequest); diff != "" {
+					t.Errorf("request body mismatch (-want +got):\n%s", diff)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+			got, err := client.LookupByKeyID(context.Background(), "test-sub", "k1")
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LookupByKeyID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.wantMsg)) {
+				t.Errorf("LookupByKeyID() error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LookupByKeyID() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_LookupByKeyID_Error(t *testing.T) {
+	runErrorTests(t, "LookupByKeyID",
+		func(ctx context.Context, client *httpRegistryClient) (any, error) {
+			return client.LookupByKeyID(ctx, "test-sub", "k1")
+		},
+		"POST /lookup", true)
+}
+
 // --- CreateSubscription Tests ---
 
 func TestHttpRegistryClient_CreateSubscription_Success(t *testing.T) {
@@ -427,7 +907,7 @@ func TestHttpRegistryClient_UpdateSubscription_Success(t *testing.T) {
 	defer server.Close()
 
 	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
-	resp, err := client.UpdateSubscription(context.Background(), expectedRequest, authHeader)
+	resp, err := client.UpdateSubscription(context.Background(), expectedRequest, model.AuthSchemeSubscriber, authHeader)
 
 	if err != nil {
 		t.Fatalf("UpdateSubscription() returned an unexpected error: %v", err)
@@ -437,10 +917,70 @@ func TestHttpRegistryClient_UpdateSubscription_Success(t *testing.T) {
 	}
 }
 
+func TestHttpRegistryClient_UpdateSubscription_AuthScheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheme      model.AuthScheme
+		wantHeader  string
+		unsetHeader string
+	}{
+		{
+			name:        "default scheme",
+			wantHeader:  model.AuthHeaderSubscriber,
+			unsetHeader: model.AuthHeaderGateway,
+		},
+		{
+			name:        "subscriber scheme",
+			scheme:      model.AuthSchemeSubscriber,
+			wantHeader:  model.AuthHeaderSubscriber,
+			unsetHeader: model.AuthHeaderGateway,
+		},
+		{
+			name:        "gateway scheme",
+			scheme:      model.AuthSchemeGateway,
+			wantHeader:  model.AuthHeaderGateway,
+			unsetHeader: model.AuthHeaderSubscriber,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get(tt.wantHeader); got != "signed" {
+					t.Errorf("header %s = %q, want %q", tt.wantHeader, got, "signed")
+				}
+				if got := r.Header.Get(tt.unsetHeader); got != "" {
+					t.Errorf("header %s = %q, want unset", tt.unsetHeader, got)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(&model.SubscriptionResponse{MessageID: "msg-789"})
+			}))
+			defer server.Close()
+
+			client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+			if _, err := client.UpdateSubscription(context.Background(), &model.SubscriptionRequest{}, tt.scheme, "signed"); err != nil {
+				t.Fatalf("UpdateSubscription() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_UpdateSubscription_InvalidAuthScheme(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent with an invalid auth scheme")
+	}))
+	defer server.Close()
+
+	client, _ := NewRegistryClient(testRegistryClientConfig(server.URL))
+	if _, err := client.UpdateSubscription(context.Background(), &model.SubscriptionRequest{}, "BEARER", "signed"); err == nil {
+		t.Error("UpdateSubscription() error = nil, want error")
+	}
+}
+
 func TestHttpRegistryClient_UpdateSubscription_Error(t *testing.T) {
 	runErrorTests(t, "UpdateSubscription",
 		func(ctx context.Context, client *httpRegistryClient) (any, error) {
-			return client.UpdateSubscription(ctx, &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub"}}}, "auth")
+			return client.UpdateSubscription(ctx, &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub"}}}, model.AuthSchemeSubscriber, "auth")
 		},
 		"PATCH /subscribe", true)
 }
@@ -448,7 +988,7 @@ func TestHttpRegistryClient_UpdateSubscription_Error(t *testing.T) {
 func TestHttpRegistryClient_UpdateSubscription_MarshalError(t *testing.T) {
 	runMarshalErrorTest(t, "UpdateSubscription",
 		func(ctx context.Context, client *httpRegistryClient) (any, error) {
-			return client.UpdateSubscription(ctx, &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub"}}}, "auth")
+			return client.UpdateSubscription(ctx, &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "update-sub"}}}, model.AuthSchemeSubscriber, "auth")
 		},
 		"PATCH /subscribe")
 }
@@ -494,3 +1034,510 @@ func TestHttpRegistryClient_GetOperation_Error(t *testing.T) {
 		return client.GetOperation(ctx, operationID)
 	}, logAction, false)
 }
+
+// --- Retry Tests ---
+
+func TestHttpRegistryClient_DefaultRetryPolicy(t *testing.T) {
+	client, err := NewRegistryClient(&RegistryClientConfig{
+		BaseURL:       "http://localhost:8080",
+		RetryMax:      3,
+		RetryWaitMin:  100 * time.Millisecond,
+		RetryWaitMax:  10 * time.Second,
+		StatusBackoff: map[int]time.Duration{http.StatusTooManyRequests: 20 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	response := func(code int, retryAfter string) *http.Response {
+		h := http.Header{}
+		if retryAfter != "" {
+			h.Set("Retry-After", retryAfter)
+		}
+		return &http.Response{StatusCode: code, Header: h}
+	}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		resp      *http.Response
+		err       error
+		wantWait  time.Duration
+		wantRetry bool
+	}{
+		{
+			name:      "503 uses default backoff",
+			resp:      response(http.StatusServiceUnavailable, ""),
+			wantWait:  100 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "503 default backoff grows per attempt",
+			attempt:   2,
+			resp:      response(http.StatusServiceUnavailable, ""),
+			wantWait:  400 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name:      "503 uses Retry-After",
+			resp:      response(http.StatusServiceUnavailable, "3"),
+			wantWait:  3 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "503 Retry-After capped at waitMax",
+			resp:      response(http.StatusServiceUnavailable, "3600"),
+			wantWait:  10 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "429 uses Retry-After",
+			resp:      response(http.StatusTooManyRequests, "7"),
+			wantWait:  7 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "429 Retry-After capped at its longer base backoff",
+			resp:      response(http.StatusTooManyRequests, "3600"),
+			wantWait:  20 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "429 without Retry-After uses its longer base backoff",
+			resp:      response(http.StatusTooManyRequests, ""),
+			wantWait:  20 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "429 with invalid Retry-After uses its longer base backoff",
+			resp:      response(http.StatusTooManyRequests, "soon"),
+			wantWait:  20 * time.Second,
+			wantRetry: true,
+		},
+		{
+			name:      "network error uses default backoff",
+			attempt:   1,
+			err:       errors.New("connection refused"),
+			wantWait:  200 * time.Millisecond,
+			wantRetry: true,
+		},
+		{
+			name: "non-retryable status",
+			resp: response(http.StatusBadRequest, ""),
+		},
+		{
+			name: "501 is not retried",
+			resp: response(http.StatusNotImplemented, ""),
+		},
+		{
+			name:    "retries exhausted",
+			attempt: 3,
+			resp:    response(http.StatusServiceUnavailable, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, wait := client.retry.ShouldRetry(nil, tt.resp, tt.err, tt.attempt)
+			if retry != tt.wantRetry {
+				t.Fatalf("ShouldRetry() retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if wait != tt.wantWait {
+				t.Errorf("ShouldRetry() wait = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_RetryableStatusCodes(t *testing.T) {
+	client, err := NewRegistryClient(&RegistryClientConfig{
+		BaseURL:              "http://localhost:8080",
+		RetryMax:             3,
+		RetryWaitMin:         100 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusConflict, http.StatusServiceUnavailable},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	for code, want := range map[int]bool{
+		http.StatusConflict:            true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusTooManyRequests:     false,
+		http.StatusInternalServerError: false,
+	} {
+		if retry, _ := client.retry.ShouldRetry(nil, &http.Response{StatusCode: code}, nil, 0); retry != want {
+			t.Errorf("ShouldRetry(%d) retry = %v, want %v", code, retry, want)
+		}
+	}
+}
+
+func TestHttpRegistryClient_RetryJitter(t *testing.T) {
+	client, err := NewRegistryClient(&RegistryClientConfig{
+		BaseURL:       "http://localhost:8080",
+		RetryMax:      3,
+		RetryWaitMin:  time.Second,
+		RetryJitter:   0.5,
+		StatusBackoff: map[int]time.Duration{http.StatusTooManyRequests: time.Second},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	failures := []struct {
+		name string
+		resp *http.Response
+		err  error
+	}{
+		{name: "status", resp: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+		{name: "network error", err: errors.New("connection refused")},
+	}
+	for _, f := range failures {
+		jittered := false
+		for i := 0; i < 100; i++ {
+			_, wait := client.retry.ShouldRetry(nil, f.resp, f.err, 1)
+			if wait < time.Second || wait > 2*time.Second {
+				t.Fatalf("ShouldRetry() after %s wait = %v, want between 1s and 2s", f.name, wait)
+			}
+			jittered = jittered || wait < 2*time.Second
+		}
+		if !jittered {
+			t.Errorf("ShouldRetry() after %s always waited 2s, want jitter taken off", f.name)
+		}
+	}
+	h := http.Header{"Retry-After": {"7"}}
+	if _, wait := client.retry.ShouldRetry(nil, &http.Response{StatusCode: http.StatusTooManyRequests, Header: h}, nil, 0); wait != 7*time.Second {
+		t.Errorf("ShouldRetry() wait = %v, want the Retry-After of 7s without jitter", wait)
+	}
+}
+
+func TestHttpRegistryClient_RetryUpdates(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryUpdates bool
+		call         func(c *httpRegistryClient) error
+		wantErr      string
+		wantCalls    int
+	}{
+		{
+			name: "updates not retried by default",
+			call: func(c *httpRegistryClient) error {
+				_, err := c.UpdateSubscription(context.Background(), &model.SubscriptionRequest{}, model.AuthSchemeSubscriber, "signed")
+				return err
+			},
+			wantErr:   "registry PATCH /subscribe failed with status 503: unavailable",
+			wantCalls: 1,
+		},
+		{
+			name: "creates not retried by default",
+			call: func(c *httpRegistryClient) error {
+				_, err := c.CreateSubscription(context.Background(), &model.SubscriptionRequest{})
+				return err
+			},
+			wantErr:   "registry POST /subscribe failed with status 503: unavailable",
+			wantCalls: 1,
+		},
+		{
+			name: "unsubscribes not retried by default",
+			call: func(c *httpRegistryClient) error {
+				_, err := c.Unsubscribe(context.Background(), &model.SubscriptionRequest{}, "signed")
+				return err
+			},
+			wantErr:   "registry POST /unsubscribe failed with status 503: unavailable",
+			wantCalls: 1,
+		},
+		{
+			name:         "updates retried if enabled",
+			retryUpdates: true,
+			call: func(c *httpRegistryClient) error {
+				_, err := c.UpdateSubscription(context.Background(), &model.SubscriptionRequest{}, model.AuthSchemeSubscriber, "signed")
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name:         "creates retried if enabled",
+			retryUpdates: true,
+			call: func(c *httpRegistryClient) error {
+				_, err := c.CreateSubscription(context.Background(), &model.SubscriptionRequest{})
+				return err
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					io.WriteString(w, "unavailable")
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				io.WriteString(w, `{"message_id":"m1"}`)
+			}))
+			defer server.Close()
+
+			cfg := testRegistryClientConfig(server.URL)
+			cfg.RetryMax = 2
+			cfg.RetryWaitMin = time.Millisecond
+			cfg.RetryUpdates = tt.retryUpdates
+			client, err := NewRegistryClient(cfg)
+			if err != nil {
+				t.Fatalf("NewRegistryClient() error = %v", err)
+			}
+			err = tt.call(client)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrRegistryUnavailable) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("call error = %v, want %v containing %q", err, ErrRegistryUnavailable, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("call error = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_Retry(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req model.Subscription
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SubscriberID != "test-sub" {
+			t.Errorf("attempt %d: request body = %+v, %v, want the original request", calls, req, err)
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "[]")
+	}))
+	defer server.Close()
+
+	cfg := testRegistryClientConfig(server.URL)
+	cfg.RetryMax = 2
+	cfg.RetryWaitMin = time.Millisecond
+	client, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	if _, err := client.Lookup(context.Background(), &model.Subscription{Subscriber: model.Subscriber{SubscriberID: "test-sub"}}); err != nil {
+		t.Fatalf("Lookup() error = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("server received %d requests, want 3", calls)
+	}
+}
+
+func TestHttpRegistryClient_SetRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    RetryPolicy
+		status    int // Returned by the first attempt, later ones succeed.
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "default retries 503", status: http.StatusServiceUnavailable, wantCalls: 2},
+		{name: "default does not retry 409", status: http.StatusConflict, wantErr: true, wantCalls: 1},
+		{
+			name: "custom policy forces retry of 409",
+			policy: RetryPolicyFunc(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+				return attempt == 0 && resp != nil && resp.StatusCode == http.StatusConflict, time.Millisecond
+			}),
+			status:    http.StatusConflict,
+			wantCalls: 2,
+		},
+		{
+			name: "custom policy suppresses retry of 503",
+			policy: RetryPolicyFunc(func(*http.Request, *http.Response, error, int) (bool, time.Duration) {
+				return false, 0
+			}),
+			status:    http.StatusServiceUnavailable,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				io.WriteString(w, "[]")
+			}))
+			defer server.Close()
+
+			cfg := testRegistryClientConfig(server.URL)
+			cfg.RetryMax = 2
+			cfg.RetryWaitMin = time.Millisecond
+			client, err := NewRegistryClient(cfg)
+			if err != nil {
+				t.Fatalf("NewRegistryClient() error = %v", err)
+			}
+			client.SetRetryPolicy(tt.policy)
+			_, err = client.Lookup(context.Background(), &model.Subscription{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_SetRetryPolicy_Classification(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    RetryPolicy
+		status    int // Returned by every attempt.
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "default rejects 409", status: http.StatusConflict, wantErr: ErrRegistryRejected, wantCalls: 1},
+		{
+			name: "custom policy retrying 409 reports it unavailable",
+			policy: RetryPolicyFunc(func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+				return attempt < 1 && resp != nil && resp.StatusCode == http.StatusConflict, time.Millisecond
+			}),
+			status:    http.StatusConflict,
+			wantErr:   ErrRegistryUnavailable,
+			wantCalls: 2,
+		},
+		{
+			name:      "status classifier retrying 409 reports it unavailable",
+			policy:    &BackoffRetryPolicy{StatusCodes: []int{http.StatusConflict}},
+			status:    http.StatusConflict,
+			wantErr:   ErrRegistryUnavailable,
+			wantCalls: 1,
+		},
+		{
+			name: "custom policy not retrying 429 reports it rejected",
+			policy: RetryPolicyFunc(func(*http.Request, *http.Response, error, int) (bool, time.Duration) {
+				return false, 0
+			}),
+			status:    http.StatusTooManyRequests,
+			wantErr:   ErrRegistryRejected,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := NewRegistryClient(testRegistryClientConfig(server.URL))
+			if err != nil {
+				t.Fatalf("NewRegistryClient() error = %v", err)
+			}
+			client.SetRetryPolicy(tt.policy)
+			_, err = client.Lookup(context.Background(), &model.Subscription{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHttpRegistryClient_Retry_Exhausted(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "unavailable")
+	}))
+	defer server.Close()
+
+	cfg := testRegistryClientConfig(server.URL)
+	cfg.RetryMax = 2
+	cfg.RetryWaitMin = time.Millisecond
+	client, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	_, err = client.Lookup(context.Background(), &model.Subscription{})
+	wantErr := "registry POST /lookup failed with status 503: unavailable"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("Lookup() error = %v, want error containing %q", err, wantErr)
+	}
+	if calls != 3 {
+		t.Errorf("server received %d requests, want 3", calls)
+	}
+}
+
+func TestHttpRegistryClient_Retry_RetryAfter(t *testing.T) {
+	var first time.Time
+	var gap time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if first.IsZero() {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		gap = time.Since(first)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "[]")
+	}))
+	defer server.Close()
+
+	cfg := testRegistryClientConfig(server.URL)
+	cfg.Timeout = 5 * time.Second
+	cfg.RetryMax = 1
+	cfg.RetryWaitMin = time.Millisecond
+	cfg.StatusBackoff = map[int]time.Duration{http.StatusTooManyRequests: 10 * time.Millisecond}
+	client, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	if _, err := client.Lookup(context.Background(), &model.Subscription{}); err != nil {
+		t.Fatalf("Lookup() error = %v, want nil", err)
+	}
+	if gap < time.Second {
+		t.Errorf("retry was sent %v after the 429, want at least the Retry-After of 1s", gap)
+	}
+}
+
+func TestHttpRegistryClient_Retry_ContextCancelledDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cfg := testRegistryClientConfig(server.URL)
+	cfg.RetryMax = 1
+	cfg.StatusBackoff = map[int]time.Duration{http.StatusTooManyRequests: time.Second}
+	client, err := NewRegistryClient(cfg)
+	if err != nil {
+		t.Fatalf("NewRegistryClient() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Lookup(ctx, &model.Subscription{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lookup() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Lookup() returned after %v, want it to stop waiting when the context is done", elapsed)
+	}
+}
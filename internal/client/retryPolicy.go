@@ -0,0 +1,185 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt of an HTTP request is retried.
+type RetryPolicy interface {
+	// ShouldRetry reports whether req is sent again after its attempt-th attempt, counted from 0,
+	// returned resp or failed with err, and how long to wait before doing so. Exactly one of resp
+	// and err is set. The body of resp has already been read and closed.
+	ShouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration)
+}
+
+// RetryPolicyFunc adapts an ordinary function to a RetryPolicy.
+type RetryPolicyFunc func(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration)
+
+// ShouldRetry returns f(req, resp, err, attempt).
+func (f RetryPolicyFunc) ShouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+	return f(req, resp, err, attempt)
+}
+
+// StatusClassifier is implemented by retry policies telling which response statuses they retry,
+// whatever the attempt. The Registry client reports a response it gave up on as ErrRegistryUnavailable
+// if its policy retries the status, and asks a policy that is not a StatusClassifier whether it would
+// retry the response on a first attempt instead.
+type StatusClassifier interface {
+	Retryable(statusCode int) bool
+}
+
+// BackoffRetryPolicy retries network errors and retryable statuses, by default 429 and 5xx other
+// than 501, with an exponential backoff. It is the default policy of the Registry client, and of
+// the NP client if its config enables retries.
+type BackoffRetryPolicy struct {
+	RetryMax      int                   // Maximum number of retries.
+	WaitMin       time.Duration         // Base backoff, doubled on every retry.
+	WaitMax       time.Duration         // Upper bound of the backoff.
+	StatusBackoff map[int]time.Duration // Per-status-code base backoff and Retry-After cap overriding WaitMin and WaitMax.
+	StatusCodes   []int                 // Retryable status codes, overriding the default ones if not empty.
+	Jitter        float64               // Fraction, from 0 to 1, of each backoff randomly taken off it.
+}
+
+const (
+	defaultRetryWaitMin = 1 * time.Second
+	defaultRetryWaitMax = 30 * time.Second
+)
+
+// validate checks the retry settings of p, as read from a config.
+func (p *BackoffRetryPolicy) validate() error {
+	if p.RetryMax < 0 {
+		return fmt.Errorf("retryMax cannot be negative, got %d", p.RetryMax)
+	}
+	if p.WaitMin < 0 || p.WaitMax < 0 {
+		return fmt.Errorf("waitMin and waitMax cannot be negative")
+	}
+	if p.WaitMax > 0 && p.WaitMin > p.WaitMax {
+		return fmt.Errorf("waitMin (%s) cannot be greater than waitMax (%s)", p.WaitMin, p.WaitMax)
+	}
+	for _, code := range p.StatusCodes {
+		if code < 400 || code > 599 {
+			return fmt.Errorf("retryableStatusCodes: %d is not an error status", code)
+		}
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	for code, base := range p.StatusBackoff {
+		if !p.Retryable(code) {
+			return fmt.Errorf("statusBackoff for status %d: status is never retried", code)
+		}
+		if base <= 0 {
+			return fmt.Errorf("statusBackoff for status %d must be positive, got %s", code, base)
+		}
+	}
+	return nil
+}
+
+// applyDefaults sets the backoffs of p left unset in the config.
+func (p *BackoffRetryPolicy) applyDefaults() {
+	if p.WaitMin == 0 {
+		p.WaitMin = defaultRetryWaitMin
+	}
+	if p.WaitMax == 0 {
+		p.WaitMax = max(defaultRetryWaitMax, p.WaitMin)
+	}
+}
+
+// ShouldRetry implements RetryPolicy. Retryable responses wait for their Retry-After header if
+// present, capped at WaitMax so that a server cannot stall the client indefinitely. Otherwise
+// status codes listed in StatusBackoff use their own base backoff, and all other retryable
+// failures use the default backoff. Status codes listed in StatusBackoff cap Retry-After at
+// their base backoff instead, if it is greater than WaitMax.
+func (p *BackoffRetryPolicy) ShouldRetry(_ *http.Request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+	if attempt >= p.RetryMax {
+		return false, 0
+	}
+	if err != nil {
+		return true, p.jitter(exponentialBackoff(p.WaitMin, p.WaitMax, attempt))
+	}
+	if !p.Retryable(resp.StatusCode) {
+		return false, 0
+	}
+	base, limit := p.WaitMin, p.WaitMax
+	if b, ok := p.StatusBackoff[resp.StatusCode]; ok {
+		base, limit = b, max(b, p.WaitMax)
+	}
+	// The server asked for this wait, it is not shortened by jitter.
+	if wait, ok := retryAfter(resp.Header); ok {
+		return true, min(wait, limit)
+	}
+	return true, p.jitter(exponentialBackoff(base, limit, attempt))
+}
+
+// Retryable implements StatusClassifier.
+func (p *BackoffRetryPolicy) Retryable(code int) bool {
+	return retryableStatus(p.StatusCodes, code)
+}
+
+// jitter randomly takes up to the Jitter fraction off d.
+func (p *BackoffRetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return d
+	}
+	return d - time.Duration(p.Jitter*rand.Float64()*float64(d))
+}
+
+// retryableStatus reports whether a response with the given status code is retried, given
+// the configured retryable codes, which override the default ones if not empty.
+func retryableStatus(codes []int, code int) bool {
+	if len(codes) > 0 {
+		return slices.Contains(codes, code)
+	}
+	return isRetryableStatus(code)
+}
+
+// isRetryableStatus reports whether a response with the given status code is retried by default.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// exponentialBackoff returns base doubled attempt times, capped at limit.
+func exponentialBackoff(base, limit time.Duration, attempt int) time.Duration {
+	wait := base
+	for range attempt {
+		if wait >= limit {
+			break
+		}
+		wait *= 2
+	}
+	return min(wait, limit)
+}
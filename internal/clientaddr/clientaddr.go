@@ -0,0 +1,49 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clientaddr carries the network address a request came from through its context,
+// so that services can tell the sources of requests apart without trusting their content.
+package clientaddr
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// addrKey is the context key of the client address of a request.
+type addrKey struct{}
+
+// NewContext returns a copy of ctx carrying the client address addr.
+func NewContext(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, addrKey{}, addr)
+}
+
+// FromContext returns the client address of ctx, or "" if it has none.
+func FromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(addrKey{}).(string)
+	return addr
+}
+
+// Middleware sets the client address of each request to the host of its RemoteAddr. Placed after
+// chi's RealIP middleware, this is the address reported by the proxy in front of the service.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), addr)))
+	})
+}
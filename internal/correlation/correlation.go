@@ -0,0 +1,66 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package correlation carries the correlation id of a request through its context,
+// so that asynchronous work such as published events can be tied back to the request.
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header carrying the correlation id of a request and its response.
+const Header = "X-Correlation-ID"
+
+// maxIDLength bounds the length of a correlation id accepted from a client.
+const maxIDLength = 128
+
+// idKey is the context key of the correlation id of a request.
+type idKey struct{}
+
+// NewContext returns a copy of ctx carrying the correlation id id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the correlation id of ctx, or "" if it has none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Middleware sets the correlation id of each request to its X-Correlation-ID header, falling
+// back to the chi request id and then to a new UUID, and reports it in the response header.
+// A header longer than 128 characters is ignored.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(Header))
+		if len(id) > maxIDLength {
+			id = ""
+		}
+		if id == "" {
+			id = middleware.GetReqID(r.Context())
+		}
+		if id == "" {
+			id = uuid.NewString()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		requestID bool
+		want      string
+	}{
+		{name: "header", header: "corr-1", want: "corr-1"},
+		{name: "header with spaces", header: " corr-1 ", want: "corr-1"},
+		{name: "header preferred to request id", header: "corr-1", requestID: true, want: "corr-1"},
+		{name: "request id", requestID: true, want: "req-1"},
+		{name: "overlong header falls back to request id", header: strings.Repeat("a", maxIDLength+1), requestID: true, want: "req-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCtx string
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCtx = FromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			if tt.requestID {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			if gotCtx != tt.want {
+				t.Errorf("FromContext() = %q, want %q", gotCtx, tt.want)
+			}
+			if got := rr.Header().Get(Header); got != tt.want {
+				t.Errorf("%s header = %q, want %q", Header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_GeneratesID(t *testing.T) {
+	var gotCtx string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCtx = FromContext(r.Context())
+	}))
+	rr := httptest.NewRecorder()
+
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/subscribe", nil))
+
+	if _, err := uuid.Parse(gotCtx); err != nil {
+		t.Errorf("FromContext() = %q, want a UUID: %v", gotCtx, err)
+	}
+	if got := rr.Header().Get(Header); got != gotCtx {
+		t.Errorf("%s header = %q, want %q", Header, got, gotCtx)
+	}
+}
+
+func TestFromContext_NoID(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("FromContext() = %q, want empty", got)
+	}
+}
@@ -47,6 +47,11 @@ type EventPublisher struct {
 	OnSubscribeRecievedMsgID string
 	// OnSubscribeRecievedErr is the error to return for PublishOnSubscribeRecievedEvent.
 	OnSubscribeRecievedErr error
+
+	// SignatureFailureAlertMsgID is the message ID to return for PublishSignatureFailureAlertEvent.
+	SignatureFailureAlertMsgID string
+	// SignatureFailureAlertErr is the error to return for PublishSignatureFailureAlertEvent.
+	SignatureFailureAlertErr error
 }
 
 // PublishNewSubscriptionRequestEvent mocks the publishing of a new subscription request event.
@@ -73,3 +78,8 @@ func (m *EventPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Con
 func (m *EventPublisher) PublishOnSubscribeRecievedEvent(ctx context.Context, lroID string) (string, error) {
 	return m.OnSubscribeRecievedMsgID, m.OnSubscribeRecievedErr
 }
+
+// PublishSignatureFailureAlertEvent mocks the publishing of a signature failure alert event.
+func (m *EventPublisher) PublishSignatureFailureAlertEvent(ctx context.Context, alert *model.SignatureFailureAlert) (string, error) {
+	return m.SignatureFailureAlertMsgID, m.SignatureFailureAlertErr
+}
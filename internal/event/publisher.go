@@ -21,11 +21,16 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"cloud.google.com/go/pubsub" //lint:ignore SA1019 v2 is not yet available in google3, see yaqs/2071311681450934272
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -40,6 +45,27 @@ var (
 
 	// ErrMissingConfig occurs if the config is nil.
 	ErrMissingConfig = errors.New("missing config")
+
+	// ErrInvalidOrderingFallback occurs if the ordering fallback is not one of the supported values.
+	ErrInvalidOrderingFallback = errors.New("invalid ordering fallback")
+
+	// ErrInvalidTimeout occurs if the publish timeout is negative.
+	ErrInvalidTimeout = errors.New("invalid publish timeout")
+
+	// ErrOrderingUnsupported occurs if an ordered publish fails because the topic does not support message ordering
+	// and the ordering fallback is OrderingFallbackFail.
+	ErrOrderingUnsupported = errors.New("pubsub topic does not support message ordering")
+)
+
+// OrderingFallback selects how the publisher reacts when the topic does not support message ordering.
+type OrderingFallback string
+
+const (
+	// OrderingFallbackDisable logs the failure, stops setting ordering keys and republishes the message unordered.
+	// It is the default, so that a misconfigured topic does not lose events.
+	OrderingFallbackDisable OrderingFallback = "DISABLE"
+	// OrderingFallbackFail returns the publish error wrapped in ErrOrderingUnsupported.
+	OrderingFallbackFail OrderingFallback = "FAIL"
 )
 
 // Config describes the connection config for a list given CloudPubSub topics.
@@ -50,6 +76,20 @@ type Config struct {
 	// Target project to be used.
 	ProjectID string `yaml:"projectID"`
 
+	// EnableMessageOrdering publishes the events of an operation with its ID as the ordering key.
+	EnableMessageOrdering bool `yaml:"enableMessageOrdering"`
+
+	// OrderingFallback applies when ordered publishing fails because the topic does not support it.
+	// Defaults to OrderingFallbackDisable.
+	OrderingFallback OrderingFallback `yaml:"orderingFallback"`
+
+	// IncludeCorrelationID adds the correlation id of the triggering request, if any,
+	// to each event as its correlation_id attribute and payload field.
+	IncludeCorrelationID bool `yaml:"includeCorrelationID"`
+
+	// Timeout bounds the wait for each event to be published. 0 means no timeout.
+	Timeout time.Duration `yaml:"timeout"`
+
 	// Client Option, If provided, these will be used.
 	// otherwise it will be populated with defaults.
 	Opts []option.ClientOption
@@ -57,8 +97,12 @@ type Config struct {
 
 // publisher is wrapper around Cloud PubSub client.
 type publisher struct {
-	client *pubsub.Client
-	topic  *pubsub.Topic
+	client           *pubsub.Client
+	topic            *pubsub.Topic
+	ordering         atomic.Bool // Whether ordering keys are set, cleared by OrderingFallbackDisable.
+	orderingFallback OrderingFallback
+	correlation      bool          // Whether correlation ids are added to events.
+	timeout          time.Duration // Bounds the wait for each publish, 0 means no timeout.
 }
 
 // NewPublisher creates a new Publisher.
@@ -85,10 +129,18 @@ func NewPublisher(ctx context.Context, cfg *Config) (*publisher, func(), error)
 	if err != nil {
 		return nil, nil, fmt.Errorf("conn(%v): %w", cfg, err)
 	}
+	tp.EnableMessageOrdering = cfg.EnableMessageOrdering
 	p := &publisher{
-		client: cl,
-		topic:  tp,
+		client:           cl,
+		topic:            tp,
+		orderingFallback: cfg.OrderingFallback,
+		correlation:      cfg.IncludeCorrelationID,
+		timeout:          cfg.Timeout,
 	}
+	if p.orderingFallback == "" {
+		p.orderingFallback = OrderingFallbackDisable
+	}
+	p.ordering.Store(cfg.EnableMessageOrdering)
 	slog.DebugContext(ctx, "Successfully initialized publisher")
 	return p, func() {
 		tp.Stop()
@@ -97,9 +149,39 @@ func NewPublisher(ctx context.Context, cfg *Config) (*publisher, func(), error)
 }
 
 // Publish publishes the provided message to the configured topics in Cloud PubSub.
+// If the message has an ordering key and the topic does not support message ordering,
+// the configured OrderingFallback decides whether the message is republished unordered or the publish fails.
 func (p *publisher) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
 	res := p.topic.Publish(ctx, msg)
-	return res.Get(ctx)
+	id, err := res.Get(ctx)
+	if err == nil || msg.OrderingKey == "" || !orderingUnsupported(err) {
+		return id, err
+	}
+
+	// Publishing is paused for an ordering key after a failure until it is resumed.
+	p.topic.ResumePublish(msg.OrderingKey)
+	if p.orderingFallback == OrderingFallbackFail {
+		slog.ErrorContext(ctx, "Publisher: Topic does not support message ordering", "topic", p.topic.ID(), "error", err)
+		return "", fmt.Errorf("%w: %v", ErrOrderingUnsupported, err)
+	}
+	if p.ordering.Swap(false) {
+		slog.WarnContext(ctx, "Publisher: Topic does not support message ordering, publishing unordered", "topic", p.topic.ID(), "error", err)
+	}
+	return p.topic.Publish(ctx, &pubsub.Message{Data: msg.Data, Attributes: msg.Attributes}).Get(ctx)
+}
+
+// orderingUnsupported reports whether err is a publish failure caused by the topic not supporting message ordering.
+func orderingUnsupported(err error) bool {
+	switch status.Code(err) {
+	case codes.FailedPrecondition, codes.InvalidArgument:
+		return strings.Contains(strings.ToLower(err.Error()), "ordering")
+	}
+	return false
 }
 
 func initPS(ctx context.Context, pID string, tID string, opts []option.ClientOption) (*pubsub.Client, *pubsub.Topic, error) {
@@ -136,11 +218,21 @@ func validate(c *Config) error {
 	if strings.TrimSpace(c.TopicID) == "" {
 		return ErrMissingTopicID
 	}
+	switch c.OrderingFallback {
+	case "", OrderingFallbackDisable, OrderingFallbackFail:
+	default:
+		return ErrInvalidOrderingFallback
+	}
+	if c.Timeout < 0 {
+		return ErrInvalidTimeout
+	}
 
 	return nil
 }
 
-func (p *publisher) publishMsg(ctx context.Context, tp model.EventType, data any) (string, error) {
+// publishMsg publishes data as an event of type tp. If ordering is enabled,
+// events with the same orderingKey are delivered in the order they were published.
+func (p *publisher) publishMsg(ctx context.Context, tp model.EventType, orderingKey string, data any) (string, error) {
 	b, err := json.Marshal(data)
 	if err != nil {
 		return "", fmt.Errorf("json.Marshal(%v): %w", data, err)
@@ -149,27 +241,56 @@ func (p *publisher) publishMsg(ctx context.Context, tp model.EventType, data any
 		Attributes: map[string]string{"event_type": string(tp)},
 		Data:       b,
 	}
+	if id := correlation.FromContext(ctx); p.correlation && id != "" {
+		if msg.Data, err = withCorrelationID(b, id); err != nil {
+			return "", err
+		}
+		msg.Attributes[correlationIDKey] = id
+	}
+	if p.ordering.Load() {
+		msg.OrderingKey = orderingKey
+	}
 	return p.Publish(ctx, msg)
 }
 
+// correlationIDKey is the attribute and payload field carrying the correlation id of an event.
+const correlationIDKey = "correlation_id"
+
+// withCorrelationID adds id as the first field of the JSON object b, keeping the other fields as they are.
+func withCorrelationID(b []byte, id string) ([]byte, error) {
+	if len(b) < 2 || b[0] != '{' {
+		return nil, fmt.Errorf("event payload is not a JSON object, cannot add %s", correlationIDKey)
+	}
+	field, err := json.Marshal(map[string]string{correlationIDKey: id})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal(%s): %w", correlationIDKey, err)
+	}
+	// field is {"correlation_id":"..."}; splice its content into b.
+	out := append([]byte{}, field[:len(field)-1]...)
+	if rest := b[1:]; len(rest) > 0 && rest[0] != '}' {
+		out = append(out, ',')
+	}
+	return append(out, b[1:]...), nil
+}
+
 // PublishNewSubscriptionRequestEvent publishes a new subscription request event to PubSub.
 func (p *publisher) PublishNewSubscriptionRequestEvent(ctx context.Context, req *model.SubscriptionRequest) (string, error) {
-	return p.publishMsg(ctx, model.EventTypeNewSubscriptionRequest, req)
+	return p.publishMsg(ctx, model.EventTypeNewSubscriptionRequest, req.MessageID, req)
 }
 
 // PublishUpdateSubscriptionRequestEvent publishes an update subscription request event to PubSub.
 func (p *publisher) PublishUpdateSubscriptionRequestEvent(ctx context.Context, req *model.SubscriptionRequest) (string, error) {
-	return p.publishMsg(ctx, model.EventTypeUpdateSubscriptionRequest, req)
+	return p.publishMsg(ctx, model.EventTypeUpdateSubscriptionRequest, req.MessageID, req)
 }
 
 // PublishSubscriptionRequestApprovedEvent publishes a subscription request approved event to PubSub.
 func (p *publisher) PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error) {
-	return p.publishMsg(ctx, model.EventTypeSubscriptionRequestApproved, req)
+	return p.publishMsg(ctx, model.EventTypeSubscriptionRequestApproved, req.OperationID, req)
 }
 
 // PublishSubscriptionRequestRejectedEvent publishes a subscription request rejected event to PubSub.
 func (p *publisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error) {
-	return p.publishMsg(ctx, model.EventTypeSubscriptionRequestRejected, req)
+	return p.publishMsg(ctx, model.EventTypeSubscriptionRequestRejected, req.OperationID, req)
 }
 
 type OnSubscribeRecievedEvent struct {
@@ -177,5 +298,10 @@ type OnSubscribeRecievedEvent struct {
 }
 
 func (p *publisher) PublishOnSubscribeRecievedEvent(ctx context.Context, lroID string) (string, error) {
-	return p.publishMsg(ctx, model.EventTypeOnSubscribeRecieved, &OnSubscribeRecievedEvent{OperationID: lroID})
+	return p.publishMsg(ctx, model.EventTypeOnSubscribeRecieved, lroID, &OnSubscribeRecievedEvent{OperationID: lroID})
+}
+
+// PublishSignatureFailureAlertEvent publishes an alert of repeated signature failures of a subscriber to PubSub.
+func (p *publisher) PublishSignatureFailureAlertEvent(ctx context.Context, alert *model.SignatureFailureAlert) (string, error) {
+	return p.publishMsg(ctx, model.EventTypeSignatureFailureAlert, alert.SubscriberID, alert)
 }
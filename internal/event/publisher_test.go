@@ -17,9 +17,12 @@ package event
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"cloud.google.com/go/pubsub/pstest"
@@ -229,6 +232,155 @@ func TestPublishError(t *testing.T) {
 	}
 }
 
+// slowReactor delays every publish by delay before it is handled normally.
+type slowReactor struct {
+	delay time.Duration
+}
+
+func (r slowReactor) React(req any) (bool, any, error) {
+	time.Sleep(r.delay)
+	return false, nil, nil
+}
+
+func TestPublishTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{name: "publish exceeds timeout", timeout: 20 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "no timeout", wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, opts, cleanup := setUpTestPubsub(ctx, t, testTopic, pstest.ServerReactorOption{FuncName: "Publish", Reactor: slowReactor{delay: 200 * time.Millisecond}})
+			defer cleanup()
+			cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, Timeout: tt.timeout}
+			publisher, closer, err := NewPublisher(ctx, cfg)
+			if err != nil {
+				t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+			}
+			defer closer()
+
+			if _, err := publisher.Publish(ctx, &pubsub.Message{Data: []byte("data")}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Publish() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// orderingUnsupportedReactor rejects messages with an ordering key, like a topic that does not support message ordering.
+type orderingUnsupportedReactor struct{}
+
+func (orderingUnsupportedReactor) React(req any) (bool, any, error) {
+	for _, m := range req.(*pb.PublishRequest).GetMessages() {
+		if m.GetOrderingKey() != "" {
+			return true, &pb.PublishResponse{}, status.Error(codes.FailedPrecondition, "message ordering is not enabled for this topic")
+		}
+	}
+	return false, nil, nil
+}
+
+func TestPublishOrdering(t *testing.T) {
+	ctx := context.Background()
+	psSrv, opts, cleanup := setUpTestPubsub(ctx, t, testTopic)
+	defer cleanup()
+	cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, EnableMessageOrdering: true}
+	publisher, closer, err := NewPublisher(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+	}
+	defer closer()
+
+	if _, err := publisher.PublishSubscriptionRequestApprovedEvent(ctx, &model.LRO{OperationID: "op-1"}); err != nil {
+		t.Fatalf("PublishSubscriptionRequestApprovedEvent() returned an unexpected error: %v", err)
+	}
+	if got := psSrv.Messages()[0].OrderingKey; got != "op-1" {
+		t.Errorf("published OrderingKey = %q, want %q", got, "op-1")
+	}
+}
+
+func TestPublishOrderingUnsupported(t *testing.T) {
+	tests := []struct {
+		name         string
+		fallback     OrderingFallback
+		wantErr      error
+		wantMsgs     int
+		wantOrdering bool
+	}{
+		{
+			name:     "default fallback publishes unordered",
+			wantMsgs: 2,
+		},
+		{
+			name:     "disable fallback publishes unordered",
+			fallback: OrderingFallbackDisable,
+			wantMsgs: 2,
+		},
+		{
+			name:         "fail fallback returns error",
+			fallback:     OrderingFallbackFail,
+			wantErr:      ErrOrderingUnsupported,
+			wantOrdering: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			psSrv, opts, cleanup := setUpTestPubsub(ctx, t, testTopic, pstest.ServerReactorOption{FuncName: "Publish", Reactor: orderingUnsupportedReactor{}})
+			defer cleanup()
+			cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, EnableMessageOrdering: true, OrderingFallback: tt.fallback}
+			publisher, closer, err := NewPublisher(ctx, cfg)
+			if err != nil {
+				t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+			}
+			defer closer()
+
+			for _, id := range []string{"op-1", "op-2"} {
+				_, err := publisher.PublishOnSubscribeRecievedEvent(ctx, id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("PublishOnSubscribeRecievedEvent(%q) error = %v, want %v", id, err, tt.wantErr)
+				}
+			}
+
+			msgs := psSrv.Messages()
+			if len(msgs) != tt.wantMsgs {
+				t.Fatalf("published %d messages, want %d", len(msgs), tt.wantMsgs)
+			}
+			for _, m := range msgs {
+				if m.OrderingKey != "" {
+					t.Errorf("published OrderingKey = %q, want none after falling back", m.OrderingKey)
+				}
+			}
+			if got := publisher.ordering.Load(); got != tt.wantOrdering {
+				t.Errorf("ordering enabled = %v, want %v", got, tt.wantOrdering)
+			}
+		})
+	}
+}
+
+func TestOrderingUnsupported(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: status.Error(codes.FailedPrecondition, "message ordering is not enabled"), want: true},
+		{err: status.Error(codes.InvalidArgument, "Ordering key is not supported"), want: true},
+		{err: status.Error(codes.FailedPrecondition, "topic is being deleted")},
+		{err: status.Error(codes.Unavailable, "ordering backend unavailable")},
+		{err: errors.New("ordering")},
+	}
+
+	for _, tt := range tests {
+		if got := orderingUnsupported(tt.err); got != tt.want {
+			t.Errorf("orderingUnsupported(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
 func TestValidateFailure(t *testing.T) {
 	tc := []struct {
 		name      string
@@ -249,6 +401,16 @@ func TestValidateFailure(t *testing.T) {
 			cfg:       &Config{TopicID: "missing-topic"},
 			wantError: ErrMissingProjectID,
 		},
+		{
+			name:      "invalid_ordering_fallback",
+			cfg:       &Config{ProjectID: testProject, TopicID: "test-topic", OrderingFallback: "RETRY"},
+			wantError: ErrInvalidOrderingFallback,
+		},
+		{
+			name:      "negative_timeout",
+			cfg:       &Config{ProjectID: testProject, TopicID: "test-topic", Timeout: -time.Second},
+			wantError: ErrInvalidTimeout,
+		},
 	}
 
 	for _, tc := range tc {
@@ -294,7 +456,7 @@ func TestPublishMsg(t *testing.T) {
 	psSrv.AddPublishResponse(&pb.PublishResponse{MessageIds: []string{testMsgID}}, nil)
 	psSrv.SetAutoPublishResponse(false)
 	testData := &model.SubscriptionResponse{MessageID: "testMessageID"}
-	got, err := publisher.publishMsg(ctx, model.EventTypeNewSubscriptionRequest, testData)
+	got, err := publisher.publishMsg(ctx, model.EventTypeNewSubscriptionRequest, testData.MessageID, testData)
 	if err != nil {
 		t.Fatalf("publishMsg(%v, %v) returned error: %v, want nil", model.EventTypeNewSubscriptionRequest, testData, err)
 	}
@@ -406,6 +568,33 @@ func TestPublishSubscriptionRequestRejectedEvent(t *testing.T) {
 	}
 }
 
+func TestPublishSignatureFailureAlertEvent(t *testing.T) {
+	ctx := context.Background()
+	publisher, psSrv, cleanup := setUpPublisher(ctx, t)
+	defer cleanup()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alert := &model.SignatureFailureAlert{SubscriberID: "bap.example.com", Failures: 5, WindowStart: start, LastFailure: start.Add(time.Minute)}
+
+	byts, err := json.Marshal(alert)
+	if err != nil {
+		t.Fatalf("failed to marshal testData: %v", err)
+	}
+	want := &pstest.Message{
+		Attributes: map[string]string{
+			"event_type": "SIGNATURE_FAILURE_ALERT",
+		},
+		Topic: testTopicName,
+		Data:  byts,
+	}
+	if _, err := publisher.PublishSignatureFailureAlertEvent(ctx, alert); err != nil {
+		t.Fatalf("PublishSignatureFailureAlertEvent() returned an unexpected error: %v", err)
+	}
+	got := psSrv.Messages()[0]
+	if d := cmp.Diff(want, got, msgCmpOpts...); d != "" {
+		t.Errorf("PublishSignatureFailureAlertEvent(%v) returned diff (-want +got):\n%s", alert, d)
+	}
+}
+
 func TestPublishOnSubscribeRecievedEvent(t *testing.T) {
 	ctx := context.Background()
 	publisher, psSrv, cleanup := setUpPublisher(ctx, t)
@@ -435,3 +624,86 @@ func TestPublishOnSubscribeRecievedEvent(t *testing.T) {
 		t.Errorf("PublishOnSubscribeRecievedEvent(%v) returned diff (-want +got):\n%s", lroID, d)
 	}
 }
+
+func TestPublishCorrelationID(t *testing.T) {
+	tests := []struct {
+		name          string
+		include       bool
+		correlationID string
+		wantAttrs     map[string]string
+		wantData      string
+	}{
+		{
+			name:          "included",
+			include:       true,
+			correlationID: "corr-1",
+			wantAttrs:     map[string]string{"event_type": "ON_SUBSCRIBE_RECIEVED", "correlation_id": "corr-1"},
+			wantData:      `{"correlation_id":"corr-1","operation_id":"op-1"}`,
+		},
+		{
+			name:      "included without correlation id",
+			include:   true,
+			wantAttrs: map[string]string{"event_type": "ON_SUBSCRIBE_RECIEVED"},
+			wantData:  `{"operation_id":"op-1"}`,
+		},
+		{
+			name:          "not included",
+			correlationID: "corr-1",
+			wantAttrs:     map[string]string{"event_type": "ON_SUBSCRIBE_RECIEVED"},
+			wantData:      `{"operation_id":"op-1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			psSrv, opts, cleanup := setUpTestPubsub(ctx, t, testTopic)
+			defer cleanup()
+			cfg := &Config{TopicID: testTopic, ProjectID: testProject, Opts: opts, IncludeCorrelationID: tt.include}
+			publisher, closer, err := NewPublisher(ctx, cfg)
+			if err != nil {
+				t.Fatalf("NewPublisher(%v) = %v, want nil", cfg, err)
+			}
+			defer closer()
+			if tt.correlationID != "" {
+				ctx = correlation.NewContext(ctx, tt.correlationID)
+			}
+
+			if _, err := publisher.PublishOnSubscribeRecievedEvent(ctx, "op-1"); err != nil {
+				t.Fatalf("PublishOnSubscribeRecievedEvent() returned an unexpected error: %v", err)
+			}
+			got := psSrv.Messages()[0]
+			if d := cmp.Diff(tt.wantAttrs, got.Attributes); d != "" {
+				t.Errorf("published Attributes diff (-want +got):\n%s", d)
+			}
+			if string(got.Data) != tt.wantData {
+				t.Errorf("published Data = %s, want %s", got.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "object", in: `{"operation_id":"op-1"}`, want: `{"correlation_id":"corr-1","operation_id":"op-1"}`},
+		{name: "empty object", in: `{}`, want: `{"correlation_id":"corr-1"}`},
+		{name: "not an object", in: `["op-1"]`, wantErr: true},
+		{name: "empty", in: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withCorrelationID([]byte(tt.in), "corr-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("withCorrelationID(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("withCorrelationID(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/correlation"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// Headers of webhook requests.
+const (
+	// WebhookEventTypeHeader carries the event type of a webhook request.
+	WebhookEventTypeHeader = "X-Onix-Event-Type"
+	// WebhookTimestampHeader carries the unix time a webhook request was signed at.
+	WebhookTimestampHeader = "X-Onix-Timestamp"
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256, keyed with the signing
+	// secret, of the timestamp, a dot and the body of a webhook request.
+	WebhookSignatureHeader = "X-Onix-Signature"
+)
+
+// Defaults of WebhookConfig.
+const (
+	DefaultWebhookMaxRetries = 3
+	DefaultWebhookRetryDelay = time.Second
+	DefaultWebhookTimeout    = 10 * time.Second
+)
+
+var (
+	// ErrMissingWebhookURL occurs if no webhook URL is configured.
+	ErrMissingWebhookURL = errors.New("missing webhook url")
+
+	// ErrInvalidWebhookURL occurs if a webhook URL is not an absolute http(s) URL.
+	ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+	// ErrMissingWebhookSecret occurs if no Secret Manager secret holding the webhook signing secret is configured.
+	ErrMissingWebhookSecret = errors.New("missing webhook signing secret id")
+)
+
+// WebhookConfig configures the HTTP webhooks notified of subscription changes.
+type WebhookConfig struct {
+	// URLs are the webhook endpoints each event is POSTed to.
+	URLs []string `yaml:"urls"`
+
+	// SigningSecretID is the Secret Manager secret whose latest version keys the HMAC signature of
+	// each webhook request, so that receivers can verify its origin.
+	SigningSecretID string `yaml:"signingSecretID"`
+
+	// ProjectID is the project of the signing secret.
+	ProjectID string `yaml:"projectID"`
+
+	// MaxRetries is how many times a failed delivery is retried, 0 for none.
+	// Defaults to DefaultWebhookMaxRetries if unset.
+	MaxRetries *int `yaml:"maxRetries"`
+
+	// RetryDelay is the delay before the first retry, doubled for each further retry.
+	// Defaults to DefaultWebhookRetryDelay.
+	RetryDelay time.Duration `yaml:"retryDelay"`
+
+	// Timeout bounds each delivery attempt. Defaults to DefaultWebhookTimeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Validate returns an error if c has no URLs, a URL that is not absolute http(s), no signing
+// secret id or project, or a negative retry count, delay or timeout.
+func (c *WebhookConfig) Validate() error {
+	if c == nil {
+		return ErrMissingConfig
+	}
+	if len(c.URLs) == 0 {
+		return ErrMissingWebhookURL
+	}
+	for _, raw := range c.URLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidWebhookURL, raw)
+		}
+	}
+	if c.SigningSecretID == "" {
+		return ErrMissingWebhookSecret
+	}
+	if c.ProjectID == "" {
+		return errors.New("missing webhook projectID")
+	}
+	if (c.MaxRetries != nil && *c.MaxRetries < 0) || c.RetryDelay < 0 || c.Timeout < 0 {
+		return errors.New("webhook maxRetries, retryDelay and timeout must not be negative")
+	}
+	return nil
+}
+
+// webhookPayload is the body of a webhook request.
+type webhookPayload struct {
+	EventType     model.EventType `json:"event_type"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Data          any             `json:"data"`
+}
+
+// secretAccessor reads secret versions, like secretmanager.Client.
+type secretAccessor interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// webhookNotifier POSTs signed subscription events to webhooks, retrying failed deliveries.
+// Deliveries run in the background, so that slow webhooks do not delay the requests raising events.
+type webhookNotifier struct {
+	urls       []string
+	secret     []byte
+	maxRetries int
+	retryDelay time.Duration
+	timeout    time.Duration
+	client     *http.Client
+	now        func() time.Time
+
+	wg        sync.WaitGroup
+	closed    chan struct{} // Closed by Close to stop waiting for retries.
+	closeOnce sync.Once
+}
+
+// NewWebhookNotifier creates a webhookNotifier delivering with client, http.DefaultClient if it is nil.
+// The signing secret is read from secrets.
+func NewWebhookNotifier(ctx context.Context, cfg *WebhookConfig, client *http.Client, secrets secretAccessor) (*webhookNotifier, error) {
+	if err := cfg.Validate(); err != nil {
+		slog.Error("NewWebhookNotifier: Invalid config", "error", err)
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	if secrets == nil {
+		return nil, errors.New("secret manager cannot be nil")
+	}
+	secret, err := webhookSecret(ctx, secrets, cfg.ProjectID, cfg.SigningSecretID)
+	if err != nil {
+		slog.ErrorContext(ctx, "NewWebhookNotifier: Failed to load the signing secret", "secret_id", cfg.SigningSecretID, "error", err)
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	n := &webhookNotifier{
+		urls:       cfg.URLs,
+		secret:     secret,
+		maxRetries: DefaultWebhookMaxRetries,
+		retryDelay: cfg.RetryDelay,
+		timeout:    cfg.Timeout,
+		client:     client,
+		now:        time.Now,
+		closed:     make(chan struct{}),
+	}
+	if cfg.MaxRetries != nil {
+		n.maxRetries = *cfg.MaxRetries
+	}
+	if n.retryDelay == 0 {
+		n.retryDelay = DefaultWebhookRetryDelay
+	}
+	if n.timeout == 0 {
+		n.timeout = DefaultWebhookTimeout
+	}
+	return n, nil
+}
+
+// webhookSecret reads the latest version of the signing secret secretID from Secret Manager.
+func webhookSecret(ctx context.Context, secrets secretAccessor, projectID, secretID string) ([]byte, error) {
+	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, secretID)
+	res, err := secrets.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: secretName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to access webhook signing secret %s: %w", secretName, err)
+	}
+	secret := res.GetPayload().GetData()
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("webhook signing secret %s is empty", secretName)
+	}
+	return secret, nil
+}
+
+// PublishSubscriptionRequestApprovedEvent notifies the webhooks that a subscription request was approved,
+// as a subscription updated event for an update request.
+// It returns an empty id, the event is delivered in the background.
+func (n *webhookNotifier) PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	if req != nil && req.Type == model.OperationTypeUpdateSubscription {
+		return "", n.notify(ctx, model.EventTypeSubscriptionUpdated, req)
+	}
+	return "", n.notify(ctx, model.EventTypeSubscriptionRequestApproved, req)
+}
+
+// PublishSubscriptionRequestRejectedEvent notifies the webhooks that a subscription request was rejected.
+// It returns an empty id, the event is delivered in the background.
+func (n *webhookNotifier) PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	return "", n.notify(ctx, model.EventTypeSubscriptionRequestRejected, req)
+}
+
+// Close waits for the delivery attempts in progress to finish. Deliveries waiting to be
+// retried give up.
+func (n *webhookNotifier) Close() {
+	n.closeOnce.Do(func() { close(n.closed) })
+	n.wg.Wait()
+}
+
+// notify starts the delivery of data as an event of type tp to every webhook.
+func (n *webhookNotifier) notify(ctx context.Context, tp model.EventType, data any) error {
+	body, err := json.Marshal(webhookPayload{EventType: tp, CorrelationID: correlation.FromContext(ctx), Data: data})
+	if err != nil {
+		return fmt.Errorf("json.Marshal(%v): %w", data, err)
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, u := range n.urls {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			if err := n.deliver(ctx, u, tp, body); err != nil {
+				slog.ErrorContext(ctx, "WebhookNotifier: Failed to deliver event", "url", u, "event_type", tp, "error", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// deliver POSTs body to u, retrying network errors, 429 and 5xx responses with a doubling delay
+// until the notifier is closed.
+func (n *webhookNotifier) deliver(ctx context.Context, u string, tp model.EventType, body []byte) error {
+	delay := n.retryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = n.post(ctx, u, tp, body); err == nil {
+			slog.InfoContext(ctx, "WebhookNotifier: Delivered event", "url", u, "event_type", tp, "attempts", attempt+1)
+			return nil
+		}
+		if !retry || attempt >= n.maxRetries {
+			return fmt.Errorf("after %d attempts: %w", attempt+1, err)
+		}
+		slog.WarnContext(ctx, "WebhookNotifier: Delivery failed, retrying", "url", u, "event_type", tp, "attempt", attempt+1, "retry_in", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-n.closed:
+			timer.Stop()
+			return fmt.Errorf("notifier closed after %d attempts: %w", attempt+1, err)
+		}
+		delay *= 2
+	}
+}
+
+// post sends one signed delivery of body to u, reporting whether a failure may be retried.
+func (n *webhookNotifier) post(ctx context.Context, u string, tp model.EventType, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	ts := strconv.FormatInt(n.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventTypeHeader, string(tp))
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+n.sign(ts, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// sign returns the hex HMAC-SHA256 of ts, a dot and body.
+func (n *webhookNotifier) sign(ts string, body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscriptionEventPublisher publishes the events of approved and rejected subscription requests.
+type subscriptionEventPublisher interface {
+	PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error)
+	PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error)
+}
+
+// webhookPublisher publishes subscription events with another publisher, e.g. the Pub/Sub
+// publisher, and notifies the webhooks of them alongside.
+type webhookPublisher struct {
+	pub      subscriptionEventPublisher
+	webhooks *webhookNotifier
+}
+
+// WithWebhooks returns a publisher publishing subscription events with pub and notifying webhooks of them.
+// The webhooks are notified even if pub fails, whose result is returned. If webhooks is nil, events are
+// only published with pub.
+func WithWebhooks(pub subscriptionEventPublisher, webhooks *webhookNotifier) *webhookPublisher {
+	return &webhookPublisher{pub: pub, webhooks: webhooks}
+}
+
+// PublishSubscriptionRequestApprovedEvent publishes a subscription request approved event and notifies the webhooks.
+func (p *webhookPublisher) PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	if p.webhooks == nil {
+		return p.pub.PublishSubscriptionRequestApprovedEvent(ctx, req)
+	}
+	if _, err := p.webhooks.PublishSubscriptionRequestApprovedEvent(ctx, req); err != nil {
+		slog.ErrorContext(ctx, "WebhookPublisher: Failed to notify webhooks", "error", err)
+	}
+	return p.pub.PublishSubscriptionRequestApprovedEvent(ctx, req)
+}
+
+// PublishSubscriptionRequestRejectedEvent publishes a subscription request rejected event and notifies the webhooks.
+func (p *webhookPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	if p.webhooks == nil {
+		return p.pub.PublishSubscriptionRequestRejectedEvent(ctx, req)
+	}
+	if _, err := p.webhooks.PublishSubscriptionRequestRejectedEvent(ctx, req); err != nil {
+		slog.ErrorContext(ctx, "WebhookPublisher: Failed to notify webhooks", "error", err)
+	}
+	return p.pub.PublishSubscriptionRequestRejectedEvent(ctx, req)
+}
@@ -0,0 +1,335 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// webhookRequest is a request received by a webhookServer.
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer is a webhook answering with statuses in turn, 200 once they run out.
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, webhookRequest{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.requests...)
+}
+
+// mockSecretManager is a secretAccessor answering with a fixed response.
+type mockSecretManager struct {
+	resp       *secretmanagerpb.AccessSecretVersionResponse
+	err        error
+	calledWith *secretmanagerpb.AccessSecretVersionRequest
+}
+
+func (m *mockSecretManager) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	m.calledWith = req
+	return m.resp, m.err
+}
+
+// webhookSecrets returns a mockSecretManager holding secret.
+func webhookSecrets(secret string) *mockSecretManager {
+	return &mockSecretManager{resp: &secretmanagerpb.AccessSecretVersionResponse{Payload: &secretmanagerpb.SecretPayload{Data: []byte(secret)}}}
+}
+
+func TestNewWebhookNotifier(t *testing.T) {
+	sm := webhookSecrets("secret")
+	n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}, SigningSecretID: "webhook-secret", ProjectID: "p1"}, nil, sm)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	if got, want := sm.calledWith.GetName(), "projects/p1/secrets/webhook-secret/versions/latest"; got != want {
+		t.Errorf("AccessSecretVersion() name = %q, want %q", got, want)
+	}
+	if got := string(n.secret); got != "secret" {
+		t.Errorf("secret = %q, want %q", got, "secret")
+	}
+	if n.maxRetries != DefaultWebhookMaxRetries {
+		t.Errorf("maxRetries = %d, want %d", n.maxRetries, DefaultWebhookMaxRetries)
+	}
+}
+
+func TestNewWebhookNotifier_Error(t *testing.T) {
+	validCfg := &WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}, SigningSecretID: "webhook-secret", ProjectID: "p1"}
+	tests := []struct {
+		name    string
+		cfg     *WebhookConfig
+		secrets secretAccessor
+		wantErr string
+	}{
+		{name: "invalid config", cfg: &WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}}, secrets: webhookSecrets("secret"), wantErr: "invalid webhook config: missing webhook signing secret id"},
+		{name: "nil secret manager", cfg: validCfg, wantErr: "secret manager cannot be nil"},
+		{name: "secret access fails", cfg: validCfg, secrets: &mockSecretManager{err: errors.New("permission denied")}, wantErr: "failed to access webhook signing secret projects/p1/secrets/webhook-secret/versions/latest: permission denied"},
+		{name: "empty secret", cfg: validCfg, secrets: webhookSecrets(""), wantErr: "webhook signing secret projects/p1/secrets/webhook-secret/versions/latest is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookNotifier(context.Background(), tt.cfg, nil, tt.secrets)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("NewWebhookNotifier() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier_Approved(t *testing.T) {
+	srv := newWebhookServer(t)
+	n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{srv.URL}, SigningSecretID: "webhook-secret", ProjectID: "p1"}, srv.Client(), webhookSecrets("secret"))
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	n.now = func() time.Time { return time.Unix(1700000000, 0) }
+	lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved, Type: model.OperationTypeCreateSubscription}
+
+	if _, err := n.PublishSubscriptionRequestApprovedEvent(context.Background(), lro); err != nil {
+		t.Fatalf("PublishSubscriptionRequestApprovedEvent() error = %v", err)
+	}
+	n.Close()
+
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("webhook received %d requests, want 1", len(reqs))
+	}
+	var got struct {
+		EventType model.EventType `json:"event_type"`
+		Data      model.LRO       `json:"data"`
+	}
+	if err := json.Unmarshal(reqs[0].body, &got); err != nil {
+		t.Fatalf("failed to unmarshal webhook body %q: %v", reqs[0].body, err)
+	}
+	if got.EventType != model.EventTypeSubscriptionRequestApproved {
+		t.Errorf("event_type = %s, want %s", got.EventType, model.EventTypeSubscriptionRequestApproved)
+	}
+	if diff := cmp.Diff(*lro, got.Data); diff != "" {
+		t.Errorf("data mismatch (-want +got):\n%s", diff)
+	}
+	h := reqs[0].header
+	if got := h.Get(WebhookEventTypeHeader); got != string(model.EventTypeSubscriptionRequestApproved) {
+		t.Errorf("%s = %q, want %q", WebhookEventTypeHeader, got, model.EventTypeSubscriptionRequestApproved)
+	}
+	if got := h.Get(WebhookTimestampHeader); got != "1700000000" {
+		t.Errorf("%s = %q, want 1700000000", WebhookTimestampHeader, got)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(reqs[0].body)))
+	if got, want := h.Get(WebhookSignatureHeader), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("%s = %q, want %q", WebhookSignatureHeader, got, want)
+	}
+}
+
+func intPtr(i int) *int { return &i }
+
+func TestWebhookNotifier_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   *int
+		statuses     []int
+		wantRequests int
+	}{
+		{name: "server error retried until delivered", maxRetries: intPtr(2), statuses: []int{http.StatusInternalServerError, http.StatusServiceUnavailable}, wantRequests: 3},
+		{name: "too many requests retried", maxRetries: intPtr(2), statuses: []int{http.StatusTooManyRequests}, wantRequests: 2},
+		{name: "retries exhausted", maxRetries: intPtr(2), statuses: []int{500, 500, 500, 500, 500}, wantRequests: 3},
+		{name: "default retries exhausted", statuses: []int{500, 500, 500, 500, 500}, wantRequests: DefaultWebhookMaxRetries + 1},
+		{name: "retries disabled", maxRetries: intPtr(0), statuses: []int{500, 500}, wantRequests: 1},
+		{name: "client error not retried", maxRetries: intPtr(2), statuses: []int{http.StatusBadRequest}, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newWebhookServer(t, tt.statuses...)
+			n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{srv.URL}, SigningSecretID: "webhook-secret", ProjectID: "p1", MaxRetries: tt.maxRetries, RetryDelay: time.Millisecond}, srv.Client(), webhookSecrets("secret"))
+			if err != nil {
+				t.Fatalf("NewWebhookNotifier() error = %v", err)
+			}
+
+			if _, err := n.PublishSubscriptionRequestRejectedEvent(context.Background(), &model.LRO{OperationID: "op-1"}); err != nil {
+				t.Fatalf("PublishSubscriptionRequestRejectedEvent() error = %v", err)
+			}
+			n.wg.Wait() // Close would drop the retries.
+
+			if got := len(srv.received()); got != tt.wantRequests {
+				t.Errorf("webhook received %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier_Updated(t *testing.T) {
+	srv := newWebhookServer(t)
+	n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{srv.URL}, SigningSecretID: "webhook-secret", ProjectID: "p1"}, srv.Client(), webhookSecrets("secret"))
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	lro := &model.LRO{OperationID: "op-1", Status: model.LROStatusApproved, Type: model.OperationTypeUpdateSubscription}
+
+	if _, err := n.PublishSubscriptionRequestApprovedEvent(context.Background(), lro); err != nil {
+		t.Fatalf("PublishSubscriptionRequestApprovedEvent() error = %v", err)
+	}
+	n.Close()
+
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("webhook received %d requests, want 1", len(reqs))
+	}
+	if got := reqs[0].header.Get(WebhookEventTypeHeader); got != string(model.EventTypeSubscriptionUpdated) {
+		t.Errorf("%s = %q, want %q", WebhookEventTypeHeader, got, model.EventTypeSubscriptionUpdated)
+	}
+}
+
+func TestWebhookNotifier_CloseStopsRetries(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusInternalServerError)
+	n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{srv.URL}, SigningSecretID: "webhook-secret", ProjectID: "p1", RetryDelay: time.Hour}, srv.Client(), webhookSecrets("secret"))
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+
+	if _, err := n.PublishSubscriptionRequestRejectedEvent(context.Background(), &model.LRO{OperationID: "op-1"}); err != nil {
+		t.Fatalf("PublishSubscriptionRequestRejectedEvent() error = %v", err)
+	}
+	for len(srv.received()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	closed := make(chan struct{})
+	go func() {
+		n.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close() still waiting for the retry delay")
+	}
+	if got := len(srv.received()); got != 1 {
+		t.Errorf("webhook received %d requests, want 1", got)
+	}
+}
+
+func TestWebhookConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *WebhookConfig
+		wantErr error
+	}{
+		{name: "nil", cfg: nil, wantErr: ErrMissingConfig},
+		{name: "no urls", cfg: &WebhookConfig{SigningSecretID: "s", ProjectID: "p1"}, wantErr: ErrMissingWebhookURL},
+		{name: "relative url", cfg: &WebhookConfig{URLs: []string{"/hooks"}, SigningSecretID: "s", ProjectID: "p1"}, wantErr: ErrInvalidWebhookURL},
+		{name: "unsupported scheme", cfg: &WebhookConfig{URLs: []string{"ftp://crm.example.com"}, SigningSecretID: "s", ProjectID: "p1"}, wantErr: ErrInvalidWebhookURL},
+		{name: "no secret", cfg: &WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}, ProjectID: "p1"}, wantErr: ErrMissingWebhookSecret},
+		{name: "valid", cfg: &WebhookConfig{URLs: []string{"https://crm.example.com/hooks"}, SigningSecretID: "s", ProjectID: "p1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// recordingPublisher is a subscriptionEventPublisher recording the operations of its events.
+type recordingPublisher struct {
+	published []string
+	err       error
+}
+
+func (p *recordingPublisher) PublishSubscriptionRequestApprovedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	p.published = append(p.published, req.OperationID)
+	return "msg-1", p.err
+}
+
+func (p *recordingPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, req *model.LRO) (string, error) {
+	p.published = append(p.published, req.OperationID)
+	return "msg-1", p.err
+}
+
+func TestWithWebhooks(t *testing.T) {
+	srv := newWebhookServer(t)
+	n, err := NewWebhookNotifier(context.Background(), &WebhookConfig{URLs: []string{srv.URL}, SigningSecretID: "webhook-secret", ProjectID: "p1"}, srv.Client(), webhookSecrets("secret"))
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	pubErr := errors.New("pubsub unavailable")
+	pub := &recordingPublisher{err: pubErr}
+
+	id, err := WithWebhooks(pub, n).PublishSubscriptionRequestApprovedEvent(context.Background(), &model.LRO{OperationID: "op-1"})
+	n.Close()
+	if id != "msg-1" || !errors.Is(err, pubErr) {
+		t.Errorf("PublishSubscriptionRequestApprovedEvent() = %q, %v, want the publisher's result", id, err)
+	}
+	if diff := cmp.Diff([]string{"op-1"}, pub.published); diff != "" {
+		t.Errorf("published mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(srv.received()); got != 1 {
+		t.Errorf("webhook received %d requests, want 1 even though the publisher failed", got)
+	}
+}
+
+func TestWithWebhooks_NoWebhooks(t *testing.T) {
+	pub := &recordingPublisher{}
+
+	id, err := WithWebhooks(pub, nil).PublishSubscriptionRequestRejectedEvent(context.Background(), &model.LRO{OperationID: "op-1"})
+	if id != "msg-1" || err != nil {
+		t.Errorf("PublishSubscriptionRequestRejectedEvent() = %q, %v, want \"msg-1\", nil", id, err)
+	}
+	if diff := cmp.Diff([]string{"op-1"}, pub.published); diff != "" {
+		t.Errorf("published mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AccessLogConfig configures the access log emitted for every HTTP request.
+type AccessLogConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// SampleRate is the fraction of requests logged, between 0 and 1. Defaults to 1 (every request).
+	SampleRate float64 `yaml:"sampleRate"`
+}
+
+// accessLogKey is the context key of the fields handlers add to the access log of a request.
+type accessLogKey struct{}
+
+// accessLogFields holds the fields that handlers add to the access log of a request.
+type accessLogFields struct {
+	subscriberID string
+}
+
+// sampleFloat returns a pseudo-random number in [0, 1) used for sampling. Replaced in tests.
+var sampleFloat = rand.Float64
+
+// SetSubscriberID records the subscriber ID a request is about in its access log.
+// It is a no-op if the request is not access logged.
+func SetSubscriberID(ctx context.Context, subscriberID string) {
+	if f, ok := ctx.Value(accessLogKey{}).(*accessLogFields); ok {
+		f.subscriberID = subscriberID
+	}
+}
+
+// NewAccessLogMiddleware returns a middleware that logs the method, path, status, latency
+// and subscriber ID of each request once it completes.
+// A nil or disabled config returns a middleware that does not log.
+func NewAccessLogMiddleware(cfg *AccessLogConfig) (func(http.Handler) http.Handler, error) {
+	if cfg == nil || !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid access log sampleRate: %v, must be between 0 and 1", cfg.SampleRate)
+	}
+	sampleRate := cfg.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sampleRate < 1 && sampleFloat() >= sampleRate {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			fields := &accessLogFields{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, fields)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // Handler wrote nothing, net/http sends 200.
+			}
+			slog.InfoContext(r.Context(), "Access log",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"latency", time.Since(start),
+				"bytes", ww.BytesWritten(),
+				"subscriber_id", fields.subscriberID,
+			)
+		})
+	}, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureAccessLogs routes the default logger to a buffer and returns the decoded access log lines.
+func captureAccessLogs(t *testing.T) func() []map[string]any {
+	t.Helper()
+	restore := saveAndRestoreDefaultSlog(t)
+	t.Cleanup(restore)
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	return func() []map[string]any {
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("failed to decode log line %q: %v", line, err)
+			}
+			if entry["msg"] == "Access log" {
+				lines = append(lines, entry)
+			}
+		}
+		return lines
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus float64
+		wantSubID  string
+	}{
+		{
+			name: "explicit status with subscriber",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				SetSubscriberID(r.Context(), "bap.example.com")
+				w.WriteHeader(http.StatusAccepted)
+			},
+			wantStatus: http.StatusAccepted,
+			wantSubID:  "bap.example.com",
+		},
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "bad", http.StatusBadRequest)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "implicit 200",
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureAccessLogs(t)
+			mw, err := NewAccessLogMiddleware(&AccessLogConfig{Enabled: true})
+			if err != nil {
+				t.Fatalf("NewAccessLogMiddleware() error = %v", err)
+			}
+
+			mw(tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/subscribe", nil))
+
+			lines := logs()
+			if len(lines) != 1 {
+				t.Fatalf("got %d access log lines, want 1", len(lines))
+			}
+			got := lines[0]
+			if got["method"] != http.MethodPost {
+				t.Errorf("method = %v, want %s", got["method"], http.MethodPost)
+			}
+			if got["path"] != "/subscribe" {
+				t.Errorf("path = %v, want /subscribe", got["path"])
+			}
+			if got["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got["status"], tt.wantStatus)
+			}
+			if _, ok := got["latency"]; !ok {
+				t.Error("access log has no latency")
+			}
+			if got["subscriber_id"] != tt.wantSubID {
+				t.Errorf("subscriber_id = %v, want %q", got["subscriber_id"], tt.wantSubID)
+			}
+		})
+	}
+}
+
+func TestAccessLogMiddleware_Disabled(t *testing.T) {
+	for _, cfg := range []*AccessLogConfig{nil, {Enabled: false, SampleRate: 1}} {
+		logs := captureAccessLogs(t)
+		mw, err := NewAccessLogMiddleware(cfg)
+		if err != nil {
+			t.Fatalf("NewAccessLogMiddleware(%+v) error = %v", cfg, err)
+		}
+		rr := httptest.NewRecorder()
+
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		if rr.Code != http.StatusTeapot {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusTeapot)
+		}
+		if lines := logs(); len(lines) != 0 {
+			t.Errorf("NewAccessLogMiddleware(%+v) logged %d lines, want 0", cfg, len(lines))
+		}
+	}
+}
+
+func TestAccessLogMiddleware_Sampling(t *testing.T) {
+	origSample := sampleFloat
+	t.Cleanup(func() { sampleFloat = origSample })
+
+	mw, err := NewAccessLogMiddleware(&AccessLogConfig{Enabled: true, SampleRate: 0.5})
+	if err != nil {
+		t.Fatalf("NewAccessLogMiddleware() error = %v", err)
+	}
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tc := range []struct {
+		sample float64
+		want   int
+	}{
+		{sample: 0.2, want: 1},
+		{sample: 0.7, want: 0},
+	} {
+		logs := captureAccessLogs(t)
+		sampleFloat = func() float64 { return tc.sample }
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+		if got := len(logs()); got != tc.want {
+			t.Errorf("sample %v logged %d lines, want %d", tc.sample, got, tc.want)
+		}
+	}
+}
+
+func TestNewAccessLogMiddleware_Error(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.5} {
+		if _, err := NewAccessLogMiddleware(&AccessLogConfig{Enabled: true, SampleRate: rate}); err == nil {
+			t.Errorf("NewAccessLogMiddleware(sampleRate %v) error = nil, want error", rate)
+		}
+	}
+}
+
+func TestSetSubscriberID_NotLogged(t *testing.T) {
+	// Must not panic when the request is not access logged.
+	SetSubscriberID(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "bap.example.com")
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics keeps in-process counters, gauges and histograms and serves
+// JSON snapshots of them, for deployments that do not scrape Prometheus.
+package metrics
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Collector holds the metrics of a service. It is safe for concurrent use.
+type Collector struct {
+	mu         sync.Mutex
+	counters   map[string]uint64
+	gauges     map[string]func() float64
+	histograms map[string]*HistogramSnapshot
+	now        func() time.Time
+}
+
+// HistogramSnapshot summarises the values observed for a histogram.
+type HistogramSnapshot struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// Snapshot is the state of all metrics of a Collector at a point in time.
+type Snapshot struct {
+	Timestamp  time.Time                    `json:"timestamp"`
+	Counters   map[string]uint64            `json:"counters"`
+	Gauges     map[string]float64           `json:"gauges"`
+	Histograms map[string]HistogramSnapshot `json:"histograms"`
+}
+
+// NewCollector creates an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		counters:   make(map[string]uint64),
+		gauges:     make(map[string]func() float64),
+		histograms: make(map[string]*HistogramSnapshot),
+		now:        time.Now,
+	}
+}
+
+// Inc increments the counter name by one.
+func (c *Collector) Inc(name string) {
+	c.Add(name, 1)
+}
+
+// Add increments the counter name by delta.
+func (c *Collector) Add(name string, delta uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[name] += delta
+}
+
+// Observe records v in the histogram name.
+func (c *Collector) Observe(name string, v float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.histograms[name]
+	if !ok {
+		h = &HistogramSnapshot{Min: v, Max: v}
+		c.histograms[name] = h
+	}
+	h.Count++
+	h.Sum += v
+	h.Min = min(h.Min, v)
+	h.Max = max(h.Max, v)
+}
+
+// SetGauge registers the gauge name, whose value is read from f whenever a snapshot is taken.
+// f must not call back into the Collector.
+func (c *Collector) SetGauge(name string, f func() float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gauges[name] = f
+}
+
+// Snapshot returns the current value of every metric.
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := Snapshot{
+		Timestamp:  c.now().UTC(),
+		Counters:   make(map[string]uint64, len(c.counters)),
+		Gauges:     make(map[string]float64, len(c.gauges)),
+		Histograms: make(map[string]HistogramSnapshot, len(c.histograms)),
+	}
+	for name, v := range c.counters {
+		s.Counters[name] = v
+	}
+	for name, f := range c.gauges {
+		s.Gauges[name] = f()
+	}
+	for name, h := range c.histograms {
+		s.Histograms[name] = *h
+	}
+	return s
+}
+
+// ServeHTTP writes a JSON snapshot of the metrics.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.Snapshot()); err != nil {
+		slog.ErrorContext(r.Context(), "Metrics: Failed to write snapshot", "error", err)
+	}
+}
+
+// Middleware counts the requests to each route by status code and records their duration.
+// Requests that match no route are counted under the path "unmatched".
+func (c *Collector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := c.now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		c.Inc("http_requests_total{method=" + r.Method + ",route=" + route + ",status=" + strconv.Itoa(status) + "}")
+		c.Observe("http_request_duration_seconds{method="+r.Method+",route="+route+"}", c.now().Sub(start).Seconds())
+	})
+}
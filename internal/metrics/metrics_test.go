@@ -0,0 +1,110 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollector_Snapshot(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := NewCollector()
+	c.now = func() time.Time { return now }
+
+	c.Inc("requests")
+	c.Add("requests", 2)
+	c.Observe("latency", 0.5)
+	c.Observe("latency", 0.1)
+	c.Observe("latency", 0.3)
+	depth := 4
+	c.SetGauge("depth", func() float64 { return float64(depth) })
+	depth = 7
+
+	want := Snapshot{
+		Timestamp:  now,
+		Counters:   map[string]uint64{"requests": 3},
+		Gauges:     map[string]float64{"depth": 7},
+		Histograms: map[string]HistogramSnapshot{"latency": {Count: 3, Sum: 0.9, Min: 0.1, Max: 0.5}},
+	}
+	got := c.Snapshot()
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 })); diff != "" {
+		t.Errorf("Snapshot() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollector_Snapshot_Empty(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewCollector().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+
+	want := map[string]any{"counters": map[string]any{}, "gauges": map[string]any{}, "histograms": map[string]any{}}
+	var got map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+	}
+	delete(got, "timestamp")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ServeHTTP() body mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollector_Middleware(t *testing.T) {
+	c := NewCollector()
+	router := chi.NewRouter()
+	router.Use(c.Middleware)
+	router.Post("/search/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Get("/debug/metrics", c.ServeHTTP)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/search/1", nil),
+		httptest.NewRequest(http.MethodPost, "/search/2", nil),
+		httptest.NewRequest(http.MethodGet, "/health", nil),
+		httptest.NewRequest(http.MethodGet, "/missing", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want %q", got, "application/json")
+	}
+	var got Snapshot
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, body %s", err, rr.Body)
+	}
+	wantCounters := map[string]uint64{
+		"http_requests_total{method=POST,route=/search/{id},status=202}": 2,
+		"http_requests_total{method=GET,route=/health,status=200}":       1,
+		"http_requests_total{method=GET,route=unmatched,status=404}":     1,
+	}
+	if diff := cmp.Diff(wantCounters, got.Counters); diff != "" {
+		t.Errorf("counters mismatch (-want +got):\n%s", diff)
+	}
+	if h := got.Histograms["http_request_duration_seconds{method=POST,route=/search/{id}}"]; h.Count != 2 {
+		t.Errorf("duration histogram count = %d, want 2", h.Count)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package onixctl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportDB   repository.Config
+	exportFile string
+)
+
+// exportCmd exports the registry's subscriptions as newline-delimited JSON.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export registry subscriptions as NDJSON for backup.",
+	Long: `export streams all subscriptions from the registry database as newline-delimited JSON.
+The first line is a header record with export metadata, followed by one subscription per line.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExport(cmd.Context(), exportFile, cmd.OutOrStdout()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			OsExit(1)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportDB.ConnectionName, "connectionName", "", "Cloud SQL connection name of the registry database")
+	exportCmd.Flags().StringVar(&exportDB.User, "dbUser", "", "Database user")
+	exportCmd.Flags().StringVar(&exportDB.Name, "dbName", "", "Database name")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "File to write the export to (default stdout)")
+	RootCmd.AddCommand(exportCmd)
+}
+
+// subscriptionExporter writes subscriptions to w.
+type subscriptionExporter interface {
+	Export(ctx context.Context, w io.Writer) (int, error)
+}
+
+// newExporter connects to the registry database. It is a variable so that tests can replace it.
+var newExporter = func(ctx context.Context) (subscriptionExporter, func() error, error) {
+	db, cleanup, err := repository.NewConnectionPool(ctx, &exportDB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the registry database: %w", err)
+	}
+	repo, err := repository.NewRegistry(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to create registry repository: %w", err)
+	}
+	exporter, err := service.NewSubscriptionExporter(repo)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to create subscription exporter: %w", err)
+	}
+	return exporter, cleanup, nil
+}
+
+// runExport writes the export to file, or to stdout if file is empty.
+func runExport(ctx context.Context, file string, stdout io.Writer) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	exporter, cleanup, err := newExporter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	out := stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to close export file: %w", closeErr)
+			}
+		}()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	count, err := exporter.Export(ctx, w)
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if file != "" {
+		fmt.Printf("✅ Exported %d subscriptions to %s\n", count, file)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package onixctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeExporter writes fixed content.
+type fakeExporter struct {
+	content string
+	err     error
+}
+
+func (f *fakeExporter) Export(ctx context.Context, w io.Writer) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	_, err := io.WriteString(w, f.content)
+	return strings.Count(f.content, "\n") - 1, err
+}
+
+// useExporter replaces newExporter for the duration of the test and reports whether cleanup ran.
+func useExporter(t *testing.T, exporter subscriptionExporter, newErr error) *bool {
+	t.Helper()
+	cleaned := new(bool)
+	original := newExporter
+	newExporter = func(context.Context) (subscriptionExporter, func() error, error) {
+		if newErr != nil {
+			return nil, nil, newErr
+		}
+		return exporter, func() error { *cleaned = true; return nil }, nil
+	}
+	t.Cleanup(func() { newExporter = original })
+	return cleaned
+}
+
+const testExport = "{\"kind\":\"subscriptions\"}\n{\"subscriber_id\":\"sub1\"}\n"
+
+func TestRunExport_Stdout(t *testing.T) {
+	cleaned := useExporter(t, &fakeExporter{content: testExport}, nil)
+
+	var stdout bytes.Buffer
+	if err := runExport(context.Background(), "", &stdout); err != nil {
+		t.Fatalf("runExport() error = %v, want nil", err)
+	}
+	if got := stdout.String(); got != testExport {
+		t.Errorf("runExport() wrote %q to stdout, want %q", got, testExport)
+	}
+	if !*cleaned {
+		t.Error("runExport() did not close the database connection")
+	}
+}
+
+func TestRunExport_File(t *testing.T) {
+	useExporter(t, &fakeExporter{content: testExport}, nil)
+	file := filepath.Join(t.TempDir(), "backup.ndjson")
+
+	var stdout bytes.Buffer
+	if err := runExport(context.Background(), file, &stdout); err != nil {
+		t.Fatalf("runExport() error = %v, want nil", err)
+	}
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read export file: %v", err)
+	}
+	if string(got) != testExport {
+		t.Errorf("export file content = %q, want %q", got, testExport)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("runExport() wrote %q to stdout, want nothing", stdout.String())
+	}
+}
+
+func TestRunExport_Error(t *testing.T) {
+	connErr := errors.New("connection refused")
+	exportErr := errors.New("export failed")
+	tests := []struct {
+		name     string
+		exporter subscriptionExporter
+		newErr   error
+		file     string
+		wantErr  string
+	}{
+		{
+			name:    "database connection fails",
+			newErr:  connErr,
+			wantErr: "connection refused",
+		},
+		{
+			name:     "export fails",
+			exporter: &fakeExporter{err: exportErr},
+			wantErr:  "export failed",
+		},
+		{
+			name:     "file cannot be created",
+			exporter: &fakeExporter{content: testExport},
+			file:     filepath.Join("missing-dir", "backup.ndjson"),
+			wantErr:  "failed to create export file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useExporter(t, tt.exporter, tt.newErr)
+			file := tt.file
+			if file != "" {
+				file = filepath.Join(t.TempDir(), file)
+			}
+			err := runExport(context.Background(), file, &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runExport() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
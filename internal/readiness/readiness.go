@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package readiness reports whether a service's dependencies are available, so that
+// a service is not sent traffic it cannot serve.
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a check may take when the Checker has no timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Check returns an error if a dependency is not available.
+type Check func(ctx context.Context) error
+
+// Response is the body of a readiness response.
+type Response struct {
+	Status string            `json:"status"`           // "ready" or "not_ready".
+	Failed map[string]string `json:"failed,omitempty"` // Errors of the failed checks by dependency.
+}
+
+// Checker runs the checks of named dependencies.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+// NewChecker creates a Checker giving each check timeout to complete, DefaultTimeout if it is not positive.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add adds the check of the dependency name, replacing any check already added for it.
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Check runs all checks concurrently and returns the errors of the failed ones by dependency.
+func (c *Checker) Check(ctx context.Context) map[string]error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	for _, name := range c.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.checks[name](ctx); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+// ServeHTTP answers 200 if all checks pass and 503 listing the failed dependencies otherwise.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	failed := c.Check(r.Context())
+	resp := Response{Status: "ready"}
+	code := http.StatusOK
+	if len(failed) > 0 {
+		resp = Response{Status: "not_ready", Failed: make(map[string]string, len(failed))}
+		code = http.StatusServiceUnavailable
+		names := make([]string, 0, len(failed))
+		for name, err := range failed {
+			resp.Failed[name] = err.Error()
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		slog.WarnContext(r.Context(), "Readiness: Dependencies not available", "dependencies", names)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "Readiness: Failed to encode response", "error", err)
+	}
+}
+
+// Cached returns check reusing each result for ttl, so that frequent probes do not load
+// a dependency with costly or rate-limited checks. A ttl of 0 or less returns check.
+func Cached(check Check, ttl time.Duration) Check {
+	if ttl <= 0 {
+		return check
+	}
+	var mu sync.Mutex
+	var err error
+	var until time.Time
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Now().Before(until) {
+			return err
+		}
+		err = check(ctx)
+		until = time.Now().Add(ttl)
+		return err
+	}
+}
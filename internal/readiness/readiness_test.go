@@ -0,0 +1,109 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func TestChecker_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   map[string]Check
+		wantCode int
+		wantResp Response
+	}{
+		{
+			name:     "no checks",
+			wantCode: http.StatusOK,
+			wantResp: Response{Status: "ready"},
+		},
+		{
+			name:     "all dependencies available",
+			checks:   map[string]Check{"redis": ok, "key_manager": ok},
+			wantCode: http.StatusOK,
+			wantResp: Response{Status: "ready"},
+		},
+		{
+			name: "key manager probe fails",
+			checks: map[string]Check{
+				"redis":       ok,
+				"key_manager": func(ctx context.Context) error { return errors.New("failed to reach secret manager: denied") },
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantResp: Response{Status: "not_ready", Failed: map[string]string{"key_manager": "failed to reach secret manager: denied"}},
+		},
+		{
+			name: "check times out",
+			checks: map[string]Check{
+				"key_manager": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantResp: Response{Status: "not_ready", Failed: map[string]string{"key_manager": context.DeadlineExceeded.Error()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(10 * time.Millisecond)
+			for name, check := range tt.checks {
+				c.Add(name, check)
+			}
+			rr := httptest.NewRecorder()
+			c.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("ServeHTTP() status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			var got Response
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to unmarshal response %q: %v", rr.Body.String(), err)
+			}
+			if diff := cmp.Diff(tt.wantResp, got); diff != "" {
+				t.Errorf("ServeHTTP() response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(func(ctx context.Context) error {
+		calls++
+		return errors.New("unavailable")
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if err := check(context.Background()); err == nil {
+			t.Fatalf("check() error = nil, want the cached error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("checks run = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrInvalidGeoFilter is returned for a lookup whose location circle cannot be parsed.
+var ErrInvalidGeoFilter = errors.New("invalid location circle")
+
+// earthRadiusMeters is the mean radius of the Earth used for distances.
+const earthRadiusMeters = 6371008.8
+
+// geoPoint is a GPS coordinate in degrees.
+type geoPoint struct {
+	lat, lon float64
+}
+
+// geoCircle is a parsed location circle of a lookup filter.
+type geoCircle struct {
+	center       geoPoint
+	radiusMeters float64
+}
+
+// parseGps parses a Beckn GPS string of the form "lat,lon".
+func parseGps(gps model.Gps) (geoPoint, error) {
+	lat, lon, err := gps.Parse()
+	if err != nil {
+		return geoPoint{}, err
+	}
+	return geoPoint{lat: lat, lon: lon}, nil
+}
+
+// parseCircle parses the circle of a lookup filter. It returns nil for a nil or empty circle.
+// The radius unit is "m" (the default) or "km".
+func parseCircle(c *model.Circle) (*geoCircle, error) {
+	if c == nil || (c.Gps == "" && c.Radius == nil) {
+		return nil, nil
+	}
+	if c.Gps == "" || c.Radius == nil {
+		return nil, fmt.Errorf("%w: both gps and radius are required", ErrInvalidGeoFilter)
+	}
+	center, err := parseGps(c.Gps)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeoFilter, err)
+	}
+	radius, err := strconv.ParseFloat(c.Radius.Value, 64)
+	if err != nil || radius < 0 {
+		return nil, fmt.Errorf("%w: radius %q is not a non-negative number", ErrInvalidGeoFilter, c.Radius.Value)
+	}
+	switch strings.ToLower(c.Radius.Unit) {
+	case "", "m":
+	case "km":
+		radius *= 1000
+	default:
+		return nil, fmt.Errorf("%w: unsupported radius unit %q", ErrInvalidGeoFilter, c.Radius.Unit)
+	}
+	return &geoCircle{center: center, radiusMeters: radius}, nil
+}
+
+// distanceMeters returns the great-circle distance between a and b.
+func distanceMeters(a, b geoPoint) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.lat - a.lat)
+	dLon := toRad(b.lon - a.lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(a.lat))*math.Cos(toRad(b.lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// subscriptionPoint returns the GPS coordinate of a subscription's location, taken from
+// its gps or else the center of its circle. ok is false if the subscription has no usable coordinate.
+func subscriptionPoint(sub *model.Subscription) (p geoPoint, ok bool) {
+	loc := sub.Location
+	if loc == nil {
+		return geoPoint{}, false
+	}
+	gps := loc.Gps
+	if gps == "" && loc.Circle != nil {
+		gps = loc.Circle.Gps
+	}
+	if gps == "" {
+		return geoPoint{}, false
+	}
+	p, err := parseGps(gps)
+	return p, err == nil
+}
+
+// withinCircle returns the subscriptions located inside c, in their original order.
+// Subscriptions without a usable location are skipped rather than failing the lookup.
+func withinCircle(subs []model.Subscription, c *geoCircle) (in []model.Subscription, skipped int) {
+	in = []model.Subscription{}
+	for i := range subs {
+		p, ok := subscriptionPoint(&subs[i])
+		if !ok {
+			skipped++
+			continue
+		}
+		if distanceMeters(c.center, p) <= c.radiusMeters {
+			in = append(in, subs[i])
+		}
+	}
+	return in, skipped
+}
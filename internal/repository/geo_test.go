@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCircle(t *testing.T) {
+	tests := []struct {
+		name   string
+		circle *model.Circle
+		want   *geoCircle
+	}{
+		{name: "nil circle"},
+		{name: "empty circle", circle: &model.Circle{}},
+		{
+			name:   "meters by default",
+			circle: &model.Circle{Gps: "12.5, 77.5", Radius: &model.Scalar{Value: "500"}},
+			want:   &geoCircle{center: geoPoint{lat: 12.5, lon: 77.5}, radiusMeters: 500},
+		},
+		{
+			name:   "kilometers",
+			circle: &model.Circle{Gps: "-33.8,151.2", Radius: &model.Scalar{Value: "2.5", Unit: "KM"}},
+			want:   &geoCircle{center: geoPoint{lat: -33.8, lon: 151.2}, radiusMeters: 2500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCircle(tt.circle)
+			if err != nil {
+				t.Fatalf("parseCircle() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(geoCircle{}, geoPoint{})); diff != "" {
+				t.Errorf("parseCircle() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseCircle_Error(t *testing.T) {
+	tests := []struct {
+		name   string
+		circle *model.Circle
+	}{
+		{name: "missing radius", circle: &model.Circle{Gps: "12.5,77.5"}},
+		{name: "missing gps", circle: &model.Circle{Radius: &model.Scalar{Value: "5"}}},
+		{name: "gps without comma", circle: &model.Circle{Gps: "12.5 77.5", Radius: &model.Scalar{Value: "5"}}},
+		{name: "latitude out of range", circle: &model.Circle{Gps: "91,77.5", Radius: &model.Scalar{Value: "5"}}},
+		{name: "longitude not a number", circle: &model.Circle{Gps: "12.5,east", Radius: &model.Scalar{Value: "5"}}},
+		{name: "negative radius", circle: &model.Circle{Gps: "12.5,77.5", Radius: &model.Scalar{Value: "-5"}}},
+		{name: "unknown unit", circle: &model.Circle{Gps: "12.5,77.5", Radius: &model.Scalar{Value: "5", Unit: "mi"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseCircle(tt.circle); !errors.Is(err, ErrInvalidGeoFilter) {
+				t.Errorf("parseCircle() error = %v, want %v", err, ErrInvalidGeoFilter)
+			}
+		})
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Bengaluru to Mumbai is about 845 km.
+	got := distanceMeters(geoPoint{lat: 12.9716, lon: 77.5946}, geoPoint{lat: 19.0760, lon: 72.8777})
+	if math.Abs(got-845000) > 5000 {
+		t.Errorf("distanceMeters() = %v, want about 845000", got)
+	}
+	if got := distanceMeters(geoPoint{lat: 1, lon: 2}, geoPoint{lat: 1, lon: 2}); got != 0 {
+		t.Errorf("distanceMeters() of the same point = %v, want 0", got)
+	}
+}
+
+func TestWithinCircle_CircleLocation(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "circle", Location: &model.Location{Circle: &model.Circle{Gps: "12.9716,77.5946"}}}},
+		{Subscriber: model.Subscriber{SubscriberID: "bad-gps", Location: &model.Location{Gps: "unknown"}}},
+	}
+
+	got, skipped := withinCircle(subs, &geoCircle{center: geoPoint{lat: 12.9716, lon: 77.5946}, radiusMeters: 1})
+
+	if len(got) != 1 || got[0].SubscriberID != "circle" {
+		t.Errorf("withinCircle() = %+v, want only the subscription located by its circle", got)
+	}
+	if skipped != 1 {
+		t.Errorf("withinCircle() skipped = %d, want 1", skipped)
+	}
+}
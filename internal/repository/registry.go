@@ -48,6 +48,10 @@ var (
 	ErrSubscriberKeyNotFound = errors.New("subscriber signing key not found")
 	ErrSubscriptionConflict  = errors.New("subscription already exists or conflicts with an existing one")
 	ErrOperationNotFound     = errors.New("operation not found")
+	ErrNoPendingOperation    = errors.New("no pending operation")
+	ErrOperationConflict     = errors.New("operation is no longer in the expected status")
+	ErrSubscriptionNotFound  = errors.New("subscription not found")
+	ErrPendingOperationLimit = errors.New("pending operation limit reached")
 )
 
 // subscriptionsTableName defines the name of the database table for subscriptions.
@@ -62,10 +66,32 @@ type Config struct {
 	MaxIdleConns    int           `yaml:"maxIdleConns"`    // Maximum number of connections in the idle connection pool.
 	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"` // Maximum amount of time a connection may be idle.
 	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"` // Maximum amount of time a connection may be reused.
+	Timeout         time.Duration `yaml:"timeout"`         // Timeout for connecting and for each statement. 0 means no timeout.
+}
+
+// Validate returns an error naming the first invalid pool or timeout setting.
+func (c *Config) Validate() error {
+	if c.MaxOpenConns < 0 {
+		return fmt.Errorf("maxOpenConns %d must not be negative", c.MaxOpenConns)
+	}
+	if c.MaxIdleConns < 0 || (c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns) {
+		return fmt.Errorf("maxIdleConns %d must not be negative or greater than maxOpenConns %d", c.MaxIdleConns, c.MaxOpenConns)
+	}
+	if c.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("connMaxIdleTime %s must not be negative", c.ConnMaxIdleTime)
+	}
+	if c.ConnMaxLifetime < 0 {
+		return fmt.Errorf("connMaxLifetime %s must not be negative", c.ConnMaxLifetime)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout %s must not be negative", c.Timeout)
+	}
+	return nil
 }
 
 type registry struct {
-	db *sqlx.DB // Use sqlx.DB for enhanced functionality.
+	db           *sqlx.DB      // Use sqlx.DB for enhanced functionality.
+	queryTimeout time.Duration // Bounds each call, including the wait for a connection. 0 means no timeout.
 }
 
 // NewRegistry creates a new PostgresSubscriberRepository.
@@ -79,8 +105,20 @@ func NewRegistry(db *sql.DB) (*registry, error) {
 
 // Lookup retrieves subscriptions based on the provided filter criteria.
 func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	slog.Info("Repository: Executing Lookup query", "filter", filter)
 
+	var circle *geoCircle
+	if filter.Location != nil {
+		c, err := parseCircle(filter.Location.Circle)
+		if err != nil {
+			slog.Error("Repository: Invalid location circle in lookup filter", "error", err)
+			return nil, err
+		}
+		circle = c
+	}
+
 	// Create a new goqu dataset for the "subscriptions" table.
 	// We'll select all columns, and sqlx will map them to the Subscription struct.
 	dataset := goqu.From(subscriptionsTableName).Select(
@@ -114,10 +152,50 @@ func (r *registry) Lookup(ctx context.Context, filter *model.Subscription) ([]mo
 		return nil, fmt.Errorf("failed to execute lookup query: %w", err)
 	}
 
+	if circle != nil {
+		// Location-less participants cannot be placed in the circle, so they are skipped
+		// rather than failing the lookup. Non-geo lookups still return them.
+		var skipped int
+		subscriptions, skipped = withinCircle(subscriptions, circle)
+		if skipped > 0 {
+			slog.Info("Repository: Skipped subscriptions without a location in geo lookup", "skipped", skipped)
+		}
+	}
+
 	slog.Info("Repository: Lookup query successful", "count", len(subscriptions))
 	return subscriptions, nil
 }
 
+const forEachSubscriptionQuery = `
+	SELECT subscriber_id, url, type, domain, location, key_id, signing_public_key, encr_public_key,
+		valid_from, valid_until, status, created_at, updated_at
+	FROM subscriptions
+	ORDER BY subscriber_id, domain, type`
+
+// ForEachSubscription calls fn for every subscription in the table, one row at a time,
+// so the full table is never held in memory. Iteration stops at the first error returned by fn.
+func (r *registry) ForEachSubscription(ctx context.Context, fn func(*model.Subscription) error) error {
+	rows, err := r.db.QueryxContext(ctx, forEachSubscriptionQuery)
+	if err != nil {
+		return fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub model.Subscription
+		if err := rows.StructScan(&sub); err != nil {
+			return fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate subscriptions: %w", err)
+	}
+	return nil
+}
+
 // buildLookupConditions creates a slice of goqu expressions based on the model.Subscription filter.
 // This centralizes the logic for building the WHERE clause, making the main Lookup method cleaner.
 func buildLookupConditions(filter *model.Subscription) []goqu.Expression {
@@ -213,12 +291,48 @@ func buildLocationConditions(locationFilter *model.Location) []goqu.Expression {
 		}
 	}
 
+	// The distance to a circle is computed after the query, only participants with a location can match it.
+	if c := locationFilter.Circle; c != nil && (c.Gps != "" || c.Radius != nil) {
+		conditions = append(conditions, goqu.C("location").IsNotNull())
+	}
+
 	return conditions
 }
 
+// SetQueryTimeout bounds each call to the registry by d through its context, so that calls waiting
+// for a connection of an exhausted pool fail instead of piling up. ForEachSubscription, which streams
+// all subscriptions, is not bounded. 0 means no timeout.
+func (r *registry) SetQueryTimeout(d time.Duration) {
+	r.queryTimeout = d
+}
+
+// withQueryTimeout returns ctx bounded by the query timeout, if one is set.
+func (r *registry) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
 var pgxv5Registerer = pgxv5.RegisterDriver
 var sqlOpen = sql.Open
 
+// dsn returns the data source name of cfg. A timeout bounds both connecting
+// and, through the statement_timeout session setting, every statement.
+func dsn(cfg *Config) string {
+	dsn := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=disable",
+		cfg.ConnectionName,
+		cfg.User,
+		cfg.Name,
+	)
+	if cfg.Timeout > 0 {
+		// connect_timeout has a resolution of seconds, rounded up so that it is never 0, which means no timeout.
+		secs := (cfg.Timeout + time.Second - 1) / time.Second
+		dsn += fmt.Sprintf(" connect_timeout=%d statement_timeout=%d", secs, cfg.Timeout.Milliseconds())
+	}
+	return dsn
+}
+
 // NewConnectionPool creates a new database connection pool.
 func NewConnectionPool(ctx context.Context, cfg *Config) (*sql.DB, func() error, error) {
 	if cfg.ConnectionName == "" {
@@ -230,19 +344,16 @@ func NewConnectionPool(ctx context.Context, cfg *Config) (*sql.DB, func() error,
 	if cfg.Name == "" {
 		return nil, nil, fmt.Errorf("db.name is required in config")
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid db config: %w", err)
+	}
 
 	cleanup, err := pgxv5Registerer("cloudsql-iam-postgres", cloudsqlconn.WithIAMAuthN())
 	if err != nil {
 		return nil, nil, fmt.Errorf("pgxv5.RegisterDriver: %w", err)
 	}
 
-	dsn := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=disable",
-		cfg.ConnectionName,
-		cfg.User,
-		cfg.Name,
-	)
-
-	db, err := sqlOpen("cloudsql-iam-postgres", dsn)
+	db, err := sqlOpen("cloudsql-iam-postgres", dsn(cfg))
 	if err != nil {
 		return nil, nil, fmt.Errorf("sql.Open: %w", err)
 	}
@@ -293,6 +404,17 @@ const updateOperationQuery = `
 	WHERE operation_id = $1
 	RETURNING created_at, updated_at, type, request_json;`
 
+// updateOperationIfStatusQuery is updateOperationQuery applied only if the operation has the status $6.
+const updateOperationIfStatusQuery = `
+	UPDATE Operations
+	SET status = $2, result_json = $3, error_data_json = $4, retry_count = $5
+	WHERE operation_id = $1 AND status = $6
+	RETURNING created_at, updated_at, type, request_json;`
+
+const operationStatusQuery = `
+	SELECT status FROM Operations
+	WHERE operation_id = $1;`
+
 // upsertSubscriptionQuery lets the DB handle created_at (on insert) and updated_at (on update via trigger).
 const upsertSubscriptionQuery = `
 	INSERT INTO subscriptions (subscriber_id, url, type, domain, location, key_id, signing_public_key, encr_public_key, valid_from, valid_until, status)
@@ -335,6 +457,8 @@ func validateLRO(lro *model.LRO) error {
 
 // InsertOperation inserts a new operation into the Operations table.
 func (r *registry) InsertOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	if err := validateLRO(lro); err != nil {
 		return nil, fmt.Errorf("LRO validation failed: %w", err)
 	}
@@ -370,6 +494,8 @@ func validateSubscriptionForInsert(sub *model.Subscription) error {
 // InsertSubscription inserts a new subscription record into the database.
 // It expects the database to handle 'created_at' and 'updated_at' timestamps.
 func (r *registry) InsertSubscription(ctx context.Context, sub *model.Subscription) (*model.Subscription, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	if err := validateSubscriptionForInsert(sub); err != nil {
 		return nil, fmt.Errorf("subscription validation failed: %w", err)
 	}
@@ -397,6 +523,52 @@ func (r *registry) InsertSubscription(ctx context.Context, sub *model.Subscripti
 	return sub, nil
 }
 
+const deleteSubscriptionQuery = `
+	DELETE FROM subscriptions
+	WHERE subscriber_id = $1 AND key_id = $2`
+
+// DeleteSubscription deletes the subscriptions of subscriberID holding keyID.
+// It returns ErrSubscriptionNotFound if there is none.
+func (r *registry) DeleteSubscription(ctx context.Context, subscriberID, keyID string) error {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.db.ExecContext(ctx, deleteSubscriptionQuery, subscriberID, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription for subscriber_id '%s', key_id '%s': %w", subscriberID, keyID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read deleted subscriptions for subscriber_id '%s', key_id '%s': %w", subscriberID, keyID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: subscriber_id '%s', key_id '%s'", ErrSubscriptionNotFound, subscriberID, keyID)
+	}
+	return nil
+}
+
+const unsubscribeSubscriptionQuery = `
+	UPDATE subscriptions SET status = 'UNSUBSCRIBED'
+	WHERE subscriber_id = $1 AND domain = $2 AND type = $3 AND key_id = $4`
+
+// UnsubscribeSubscription moves the subscription of subscriberID for domain and role holding keyID
+// to UNSUBSCRIBED. It returns ErrSubscriptionNotFound if there is none.
+func (r *registry) UnsubscribeSubscription(ctx context.Context, subscriberID, domain string, role model.Role, keyID string) error {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	res, err := r.db.ExecContext(ctx, unsubscribeSubscriptionQuery, subscriberID, domain, role, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe subscription for subscriber_id '%s', key_id '%s': %w", subscriberID, keyID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read unsubscribed subscriptions for subscriber_id '%s', key_id '%s': %w", subscriberID, keyID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: subscriber_id '%s', domain '%s', type '%s', key_id '%s'", ErrSubscriptionNotFound, subscriberID, domain, role, keyID)
+	}
+	return nil
+}
+
 const getSubscriberSigningKeyQuery = `
 	SELECT signing_public_key FROM subscriptions
 	WHERE subscriber_id = $1 AND domain = $2 AND type = $3 AND key_id = $4 AND status = 'SUBSCRIBED'
@@ -404,6 +576,8 @@ const getSubscriberSigningKeyQuery = `
 
 // GetSubscriberSigningKey fetches the signing public key for a given subscriber_id and key_id.
 func (r *registry) GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (string, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	var publicKey string
 	err := r.db.QueryRowContext(ctx, getSubscriberSigningKeyQuery, subscriberID, domain, role, keyID).Scan(&publicKey)
 	if err != nil {
@@ -422,6 +596,8 @@ const getOperationQuery = `
 
 // GetOperation retrieves a specific LRO from the database by its ID. (No changes needed here)
 func (r *registry) GetOperation(ctx context.Context, id string) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	lro := &model.LRO{}
 	var resultJSON, errorDataJSON sql.NullString
 
@@ -451,6 +627,70 @@ func (r *registry) GetOperation(ctx context.Context, id string) (*model.LRO, err
 	return lro, nil
 }
 
+const countPendingOperationsQuery = `
+	SELECT COUNT(*) FROM Operations
+	WHERE status IN ('PENDING', 'IN_PROGRESS') AND request_json->>'subscriber_id' = $1`
+
+// PendingOperationsCount returns the number of PENDING or IN_PROGRESS operations raised by a given subscriber_id.
+func (r *registry) PendingOperationsCount(ctx context.Context, subscriberID string) (int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	var count int
+	if err := r.db.QueryRowContext(ctx, countPendingOperationsQuery, subscriberID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending operations for subscriber_id '%s': %w", subscriberID, err)
+	}
+	return count, nil
+}
+
+// lockSubscriberOperationsQuery serializes the inserts of operations raised by the subscriber $1
+// until the end of the transaction, so that its pending operations cannot change between the count
+// and the insert.
+const lockSubscriberOperationsQuery = `SELECT pg_advisory_xact_lock(hashtext($1))`
+
+// InsertOperationWithinLimit is InsertOperation applied only if subscriberID has fewer than max
+// PENDING or IN_PROGRESS operations. Otherwise nothing is written and ErrPendingOperationLimit is
+// returned. Concurrent inserts for the same subscriber are serialized, so the limit holds under load.
+func (r *registry) InsertOperationWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	if err := validateLRO(lro); err != nil {
+		return nil, fmt.Errorf("LRO validation failed: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, lockSubscriberOperationsQuery, subscriberID); err != nil {
+		return nil, fmt.Errorf("failed to lock operations of subscriber_id '%s': %w", subscriberID, err)
+	}
+	var count int
+	if err := tx.QueryRowContext(ctx, countPendingOperationsQuery, subscriberID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count pending operations for subscriber_id '%s': %w", subscriberID, err)
+	}
+	if count >= max {
+		return nil, fmt.Errorf("%w: subscriber_id '%s' has %d pending operations (max %d)", ErrPendingOperationLimit, subscriberID, count, max)
+	}
+	err = tx.QueryRowContext(ctx, insertOperationQuery, lro.OperationID, lro.Status, lro.Type, lro.RequestJSON).Scan(&lro.CreatedAt, &lro.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("%w: %s", ErrOperationAlreadyExists, lro.OperationID)
+		}
+		return nil, fmt.Errorf("failed to insert operation with ID %s: %w", lro.OperationID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return lro, nil
+}
+
 const getSubscriberEncryptionKeyQuery = `
 	SELECT encr_public_key FROM subscriptions
 	WHERE subscriber_id = $1 AND key_id = $2 AND status = 'SUBSCRIBED'
@@ -458,6 +698,8 @@ const getSubscriberEncryptionKeyQuery = `
 
 // EncryptionKey fetches the encryption public key for a given subscriber_id and key_id.
 func (r *registry) EncryptionKey(ctx context.Context, subscriberID string, keyID string) (string, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	var publicKey string
 	err := r.db.QueryRowContext(ctx, getSubscriberEncryptionKeyQuery, subscriberID, keyID).Scan(&publicKey)
 	if err != nil {
@@ -469,8 +711,148 @@ func (r *registry) EncryptionKey(ctx context.Context, subscriberID string, keyID
 	return publicKey, nil
 }
 
+// claimNextPendingOperationQuery moves the oldest PENDING operation to IN_PROGRESS.
+// SKIP LOCKED lets concurrent claimers pass over a row another transaction is claiming,
+// so each operation is returned to exactly one caller.
+const claimNextPendingOperationQuery = `
+	UPDATE Operations
+	SET status = 'IN_PROGRESS'
+	WHERE operation_id = (
+		SELECT operation_id FROM Operations
+		WHERE status = 'PENDING'
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING operation_id, status, type, request_json, result_json, error_data_json, retry_count, created_at, updated_at`
+
+// claimOperationQuery moves the operation $1 to IN_PROGRESS if it is PENDING or FAILURE,
+// the statuses an approval may start from.
+const claimOperationQuery = `
+	UPDATE Operations
+	SET status = 'IN_PROGRESS'
+	WHERE operation_id = $1 AND status IN ('PENDING', 'FAILURE')
+	RETURNING operation_id, status, type, request_json, result_json, error_data_json, retry_count, created_at, updated_at`
+
+// claimStaleOperationQuery claims again the IN_PROGRESS operation least recently updated before $1,
+// whose claimer presumably stopped. The update refreshes its updated_at through the trigger.
+const claimStaleOperationQuery = `
+	UPDATE Operations
+	SET status = 'IN_PROGRESS'
+	WHERE operation_id = (
+		SELECT operation_id FROM Operations
+		WHERE status = 'IN_PROGRESS' AND updated_at < $1
+		ORDER BY updated_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING operation_id, status, type, request_json, result_json, error_data_json, retry_count, created_at, updated_at`
+
+// ClaimNextPendingOperation atomically marks the oldest PENDING operation as IN_PROGRESS and returns it.
+// It returns ErrNoPendingOperation if there is no operation left to claim.
+func (r *registry) ClaimNextPendingOperation(ctx context.Context) (*model.LRO, error) {
+	return r.claimOperation(ctx, "next pending operation", claimNextPendingOperationQuery)
+}
+
+// ClaimOperation atomically marks the operation operationID as IN_PROGRESS and returns it, if it is
+// PENDING or FAILURE. It returns ErrNoPendingOperation if it has another status or does not exist.
+func (r *registry) ClaimOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	return r.claimOperation(ctx, "operation "+operationID, claimOperationQuery, operationID)
+}
+
+// ClaimStaleOperation atomically claims again the IN_PROGRESS operation least recently updated
+// before claimedBefore and returns it. It returns ErrNoPendingOperation if there is none.
+func (r *registry) ClaimStaleOperation(ctx context.Context, claimedBefore time.Time) (*model.LRO, error) {
+	return r.claimOperation(ctx, "stale operation", claimStaleOperationQuery, claimedBefore)
+}
+
+// claimOperation runs query, which claims at most one operation, and returns the claimed operation.
+func (r *registry) claimOperation(ctx context.Context, what, query string, args ...any) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	lro := &model.LRO{}
+	var resultJSON, errorDataJSON sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+		&lro.OperationID,
+		&lro.Status,
+		&lro.Type,
+		&lro.RequestJSON,
+		&resultJSON,
+		&errorDataJSON,
+		&lro.RetryCount,
+		&lro.CreatedAt,
+		&lro.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoPendingOperation
+		}
+		return nil, fmt.Errorf("failed to claim %s: %w", what, err)
+	}
+	if resultJSON.Valid {
+		lro.ResultJSON = []byte(resultJSON.String)
+	}
+	if errorDataJSON.Valid {
+		lro.ErrorDataJSON = []byte(errorDataJSON.String)
+	}
+	return lro, nil
+}
+
+// rejectStaleOperationsQuery rejects the PENDING operations created before $1 with the error data $2.
+// Operations claimed in the meantime are no longer PENDING and are left alone.
+const rejectStaleOperationsQuery = `
+	UPDATE Operations
+	SET status = 'REJECTED', error_data_json = $2
+	WHERE status = 'PENDING' AND created_at < $1
+	RETURNING operation_id, status, type, request_json, result_json, error_data_json, retry_count, created_at, updated_at`
+
+// RejectStaleOperations atomically rejects the PENDING operations created before createdBefore,
+// storing errorDataJSON as their error data, and returns the rejected operations.
+func (r *registry) RejectStaleOperations(ctx context.Context, createdBefore time.Time, errorDataJSON []byte) ([]model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, rejectStaleOperationsQuery, createdBefore, string(errorDataJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject stale operations: %w", err)
+	}
+	defer rows.Close()
+
+	var lros []model.LRO
+	for rows.Next() {
+		var lro model.LRO
+		var resultJSON, errorDataJSON sql.NullString
+		if err := rows.Scan(
+			&lro.OperationID,
+			&lro.Status,
+			&lro.Type,
+			&lro.RequestJSON,
+			&resultJSON,
+			&errorDataJSON,
+			&lro.RetryCount,
+			&lro.CreatedAt,
+			&lro.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rejected operation: %w", err)
+		}
+		if resultJSON.Valid {
+			lro.ResultJSON = []byte(resultJSON.String)
+		}
+		if errorDataJSON.Valid {
+			lro.ErrorDataJSON = []byte(errorDataJSON.String)
+		}
+		lros = append(lros, lro)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rejected operations: %w", err)
+	}
+	return lros, nil
+}
+
 // UpdateOperation updates an existing LRO record in the database.
 func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	if lro == nil {
 		return nil, errors.New("lro cannot be nil")
 	}
@@ -499,9 +881,43 @@ func (r *registry) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.
 	return lro, nil
 }
 
+// UpdateOperationIfStatus is UpdateOperation applied only if the LRO still has the status expected.
+// Otherwise nothing is written and ErrOperationConflict is returned.
+func (r *registry) UpdateOperationIfStatus(ctx context.Context, lro *model.LRO, expected model.LROStatus) (*model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	if lro == nil {
+		return nil, errors.New("lro cannot be nil")
+	}
+	if lro.OperationID == "" {
+		return nil, errors.New("lro OperationID cannot be empty for update")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	if err := r.updateLROIfStatus(ctx, tx, lro, expected); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return lro, nil
+}
+
 // UpsertSubscriptionAndLRO performs an upsert on the subscriptions table and an update on the Operations table
 // within the same database transaction. Timestamps are handled by the database.
 func (r *registry) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 	if err := r.validateUpsertInputs(sub, lro); err != nil {
 		return nil, nil, err
 	}
@@ -531,6 +947,42 @@ func (r *registry) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subs
 	return sub, lro, nil
 }
 
+// UpsertSubscriptionAndLROIfStatus is UpsertSubscriptionAndLRO applied only if the operation still has the
+// status expected, e.g. the status it had when an approval read it. Otherwise nothing is written and
+// ErrOperationConflict is returned, so that the caller does not overwrite the result of a concurrent update.
+func (r *registry) UpsertSubscriptionAndLROIfStatus(ctx context.Context, sub *model.Subscription, lro *model.LRO, expected model.LROStatus) (*model.Subscription, *model.LRO, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+	if err := r.validateUpsertInputs(sub, lro); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			slog.ErrorContext(ctx, "transaction rollback failed", "error", err)
+		}
+	}()
+
+	// The LRO is updated first, so that its row stays locked until the subscription is upserted.
+	if err := r.updateLROIfStatus(ctx, tx, lro, expected); err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.upsertSubscription(ctx, tx, sub); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sub, lro, nil
+}
+
 // validateUpsertInputs checks the validity of subscription and LRO inputs.
 func (r *registry) validateUpsertInputs(sub *model.Subscription, lro *model.LRO) error {
 	if sub == nil {
@@ -592,3 +1044,34 @@ func (r *registry) updateLRO(ctx context.Context, tx *sql.Tx, lro *model.LRO) er
 	}
 	return nil
 }
+
+// updateLROIfStatus is updateLRO applied only if the LRO has the status expected.
+func (r *registry) updateLROIfStatus(ctx context.Context, tx *sql.Tx, lro *model.LRO, expected model.LROStatus) error {
+	var resultJSON, errorDataJSON sql.NullString
+	if lro.ResultJSON != nil {
+		resultJSON = sql.NullString{String: string(lro.ResultJSON), Valid: true}
+	}
+	if lro.ErrorDataJSON != nil {
+		errorDataJSON = sql.NullString{String: string(lro.ErrorDataJSON), Valid: true}
+	}
+
+	err := tx.QueryRowContext(ctx, updateOperationIfStatusQuery,
+		lro.OperationID, lro.Status, resultJSON, errorDataJSON, lro.RetryCount, expected,
+	).Scan(&lro.CreatedAt, &lro.UpdatedAt, &lro.Type, &lro.RequestJSON)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to update LRO %s in transaction: %w", lro.OperationID, err)
+	}
+
+	// Nothing was updated: the LRO is either gone or no longer in the expected status.
+	var status model.LROStatus
+	if err := tx.QueryRowContext(ctx, operationStatusQuery, lro.OperationID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to update LRO %s (not found): %w", lro.OperationID, ErrOperationNotFound)
+		}
+		return fmt.Errorf("failed to get status of LRO %s in transaction: %w", lro.OperationID, err)
+	}
+	return fmt.Errorf("%w: LRO %s is %s, expected %s", ErrOperationConflict, lro.OperationID, status, expected)
+}
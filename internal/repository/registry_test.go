@@ -170,6 +170,16 @@ func TestNewConnectionPool(t *testing.T) {
 				mock.ExpectPing()
 			},
 			wantCleanup: true,
+			checkDBConfig: func(t *testing.T, db *sql.DB, cfg *Config) {
+				if got := db.Stats().MaxOpenConnections; got != cfg.MaxOpenConns {
+					t.Errorf("MaxOpenConnections = %d, want %d", got, cfg.MaxOpenConns)
+				}
+			},
+		},
+		{
+			name:       "error on invalid pool settings",
+			config:     &Config{ConnectionName: "conn", User: "user", Name: "db", MaxOpenConns: -1},
+			wantErrMsg: "invalid db config: maxOpenConns -1 must not be negative",
 		},
 		{
 			name:       "error on missing ConnectionName",
@@ -220,7 +230,7 @@ func TestNewConnectionPool(t *testing.T) {
 				tt.setupMocks(mock)
 			}
 
-			_, cleanup, err := NewConnectionPool(ctx, tt.config)
+			gotDB, cleanup, err := NewConnectionPool(ctx, tt.config)
 			if tt.wantErrMsg != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
 					t.Errorf("NewConnectionPool() error = %v, want error containing %q", err, tt.wantErrMsg)
@@ -237,6 +247,10 @@ func TestNewConnectionPool(t *testing.T) {
 				t.Error("NewConnectionPool() cleanup function is not nil, want nil")
 			}
 
+			if tt.checkDBConfig != nil && gotDB != nil {
+				tt.checkDBConfig(t, gotDB, tt.config)
+			}
+
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("there were unfulfilled expectations: %s", err)
 			}
@@ -244,6 +258,75 @@ func TestNewConnectionPool(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "defaults", cfg: Config{}},
+		{name: "all set", cfg: Config{MaxOpenConns: 10, MaxIdleConns: 10, ConnMaxIdleTime: time.Minute, ConnMaxLifetime: time.Hour, Timeout: time.Second}},
+		{name: "idle without open limit", cfg: Config{MaxIdleConns: 5}},
+		{name: "negative maxOpenConns", cfg: Config{MaxOpenConns: -1}, wantErr: "maxOpenConns -1 must not be negative"},
+		{name: "negative maxIdleConns", cfg: Config{MaxIdleConns: -1}, wantErr: "maxIdleConns -1 must not be negative or greater than maxOpenConns 0"},
+		{name: "maxIdleConns above maxOpenConns", cfg: Config{MaxOpenConns: 2, MaxIdleConns: 3}, wantErr: "maxIdleConns 3 must not be negative or greater than maxOpenConns 2"},
+		{name: "negative connMaxIdleTime", cfg: Config{ConnMaxIdleTime: -time.Second}, wantErr: "connMaxIdleTime -1s must not be negative"},
+		{name: "negative connMaxLifetime", cfg: Config{ConnMaxLifetime: -time.Second}, wantErr: "connMaxLifetime -1s must not be negative"},
+		{name: "negative timeout", cfg: Config{Timeout: -time.Second}, wantErr: "timeout -1s must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_SetQueryTimeout(t *testing.T) {
+	repo, mock, _ := newMockRegistry(t)
+	repo.SetQueryTimeout(20 * time.Millisecond)
+	mock.ExpectQuery("SELECT COUNT").WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	start := time.Now()
+	_, err := repo.PendingOperationsCount(context.Background(), "sub1")
+	if err == nil {
+		t.Error("PendingOperationsCount() error = nil, want the query canceled by its timeout")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("PendingOperationsCount() took %s, want it bounded by the query timeout", elapsed)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    string
+	}{
+		{name: "no timeout", want: "host=proj:region:inst user=test-user dbname=test-db sslmode=disable"},
+		{name: "whole seconds", timeout: 30 * time.Second, want: "host=proj:region:inst user=test-user dbname=test-db sslmode=disable connect_timeout=30 statement_timeout=30000"},
+		{name: "sub second", timeout: 500 * time.Millisecond, want: "host=proj:region:inst user=test-user dbname=test-db sslmode=disable connect_timeout=1 statement_timeout=500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{ConnectionName: "proj:region:inst", User: "test-user", Name: "test-db", Timeout: tt.timeout}
+			if got := dsn(cfg); got != tt.want {
+				t.Errorf("dsn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // baseTime is a fixed time for consistent testing of time fields.
 var baseTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 
@@ -545,6 +628,97 @@ func TestRegistry_Lookup_Success(t *testing.T) {
 	}
 }
 
+// locatedRow is a subscription row with its location JSON, or nil for a NULL location.
+type locatedRow struct {
+	id       string
+	location []byte
+}
+
+func TestRegistry_Lookup_Location(t *testing.T) {
+	columns := []string{
+		"subscriber_id", "url", "type", "domain", "location", "key_id",
+		"signing_public_key", "encr_public_key", "valid_from", "valid_until",
+		"status", "created_at", "updated_at",
+	}
+	near := []byte(`{"gps":"12.9716,77.5946"}`)       // Bengaluru.
+	far := []byte(`{"gps":"19.0760,72.8777"}`)        // Mumbai.
+	cityOnly := []byte(`{"city":{"code":"std:080"}}`) // A location without coordinates.
+
+	tests := []struct {
+		name    string
+		filter  *model.Subscription
+		rows    []locatedRow
+		wantIDs []string
+	}{
+		{
+			name: "location-less subscription returned by non-geo lookup",
+			filter: &model.Subscription{Subscriber: model.Subscriber{
+				Domain:   "retail",
+				Type:     model.RoleBPP,
+				Location: &model.Location{},
+			}},
+			rows:    []locatedRow{{"no-location", nil}, {"near", near}},
+			wantIDs: []string{"no-location", "near"},
+		},
+		{
+			name: "geo-radius lookup skips location-less subscriptions",
+			filter: &model.Subscription{Subscriber: model.Subscriber{
+				Domain: "retail",
+				Location: &model.Location{Circle: &model.Circle{
+					Gps:    "12.9352,77.6245",
+					Radius: &model.Scalar{Value: "10", Unit: "km"},
+				}},
+			}},
+			rows:    []locatedRow{{"no-location", nil}, {"city-only", cityOnly}, {"near", near}, {"far", far}},
+			wantIDs: []string{"near"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			rows := sqlmock.NewRows(columns)
+			for _, row := range tt.rows {
+				var loc driver.Value // A nil location is a NULL column.
+				if row.location != nil {
+					loc = row.location
+				}
+				rows.AddRow(row.id, "http://"+row.id, "BPP", "retail", loc, "key", "sign", "encr", baseTime, baseTime, "SUBSCRIBED", baseTime, baseTime)
+			}
+			mock.ExpectQuery(`SELECT .* FROM "subscriptions"`).WillReturnRows(rows)
+
+			got, err := r.Lookup(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			gotIDs := []string{}
+			for _, sub := range got {
+				gotIDs = append(gotIDs, sub.SubscriberID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, gotIDs); diff != "" {
+				t.Errorf("Lookup() subscriber IDs mismatch (-want +got):\n%s", diff)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Lookup_InvalidGeoFilter(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	filter := &model.Subscription{Subscriber: model.Subscriber{Location: &model.Location{Circle: &model.Circle{Gps: "12.9,77.6"}}}}
+
+	if _, err := r.Lookup(context.Background(), filter); !errors.Is(err, ErrInvalidGeoFilter) {
+		t.Errorf("Lookup() error = %v, want %v", err, ErrInvalidGeoFilter)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestRegistry_Lookup_Failure(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -1429,6 +1603,165 @@ func TestRegistry_UpsertSubscriptionAndLRO_Failure(t *testing.T) {
 	}
 }
 
+func TestRegistry_UpdateOperationIfStatus(t *testing.T) {
+	ctx := context.Background()
+	fixedTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	errorDataJSON := []byte(`{"error":"callback failed"}`)
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock, lro *model.LRO)
+		wantErr   error
+	}{
+		{
+			name: "clean update",
+			mockSetup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(updateOperationIfStatusQuery)).
+					WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{String: string(errorDataJSON), Valid: true}, lro.RetryCount, model.LROStatusInProgress).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(fixedTime, fixedTime, model.OperationTypeCreateSubscription, []byte(`{}`)))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "conflicting concurrent update",
+			mockSetup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(updateOperationIfStatusQuery)).
+					WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{String: string(errorDataJSON), Valid: true}, lro.RetryCount, model.LROStatusInProgress).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(operationStatusQuery)).
+					WithArgs(lro.OperationID).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.LROStatusApproved))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrOperationConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{
+				OperationID:   "update-op",
+				Status:        model.LROStatusFailure,
+				ErrorDataJSON: errorDataJSON,
+				RetryCount:    1,
+			}
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.mockSetup(mock, lro)
+
+			got, err := r.UpdateOperationIfStatus(ctx, lro, model.LROStatusInProgress)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateOperationIfStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.UpdatedAt != fixedTime {
+				t.Errorf("UpdateOperationIfStatus() = %+v, want the stored timestamps", got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_UpsertSubscriptionAndLROIfStatus(t *testing.T) {
+	ctx := context.Background()
+	fixedTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	lroRequestJSON, _ := json.Marshal(map[string]string{"req": "upsert_data"})
+
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock, sub *model.Subscription, lro *model.LRO)
+		wantErr   error
+	}{
+		{
+			name: "clean upsert",
+			mockSetup: func(mock sqlmock.Sqlmock, sub *model.Subscription, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(updateOperationIfStatusQuery)).
+					WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, lro.RetryCount, model.LROStatusPending).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "type", "request_json"}).AddRow(fixedTime, fixedTime, lro.Type, lro.RequestJSON))
+				mock.ExpectQuery(regexp.QuoteMeta(upsertSubscriptionQuery)).
+					WithArgs(
+						sub.SubscriberID, sub.URL, sub.Type, sub.Domain, sql.NullString{}, sub.KeyID,
+						sub.SigningPublicKey, sub.EncrPublicKey, sub.ValidFrom, sub.ValidUntil,
+						sub.Status,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(fixedTime, fixedTime))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "conflicting concurrent update",
+			mockSetup: func(mock sqlmock.Sqlmock, sub *model.Subscription, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(updateOperationIfStatusQuery)).
+					WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, lro.RetryCount, model.LROStatusPending).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(operationStatusQuery)).
+					WithArgs(lro.OperationID).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.LROStatusApproved))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrOperationConflict,
+		},
+		{
+			name: "operation not found",
+			mockSetup: func(mock sqlmock.Sqlmock, sub *model.Subscription, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(updateOperationIfStatusQuery)).
+					WithArgs(lro.OperationID, lro.Status, sql.NullString{}, sql.NullString{}, lro.RetryCount, model.LROStatusPending).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(operationStatusQuery)).
+					WithArgs(lro.OperationID).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: ErrOperationNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{
+				Subscriber: model.Subscriber{
+					SubscriberID: "upsert-sub",
+					URL:          "http://upsert.com",
+					Type:         model.RoleBAP,
+					Domain:       "upsert.domain",
+				},
+				KeyID:            "upsert-key",
+				SigningPublicKey: "upsert-sign",
+				EncrPublicKey:    "upsert-encr",
+				ValidFrom:        fixedTime,
+				ValidUntil:       fixedTime.Add(time.Hour),
+				Status:           "SUBSCRIBED",
+			}
+			lro := &model.LRO{
+				OperationID: "upsert-op",
+				Status:      model.LROStatusApproved,
+				Type:        model.OperationTypeCreateSubscription,
+				RequestJSON: lroRequestJSON,
+			}
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.mockSetup(mock, sub, lro)
+
+			gotSub, gotLRO, err := r.UpsertSubscriptionAndLROIfStatus(ctx, sub, lro, model.LROStatusPending)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpsertSubscriptionAndLROIfStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (gotSub.Created != fixedTime || gotLRO.UpdatedAt != fixedTime) {
+				t.Errorf("UpsertSubscriptionAndLROIfStatus() = %+v, %+v, want the stored timestamps", gotSub, gotLRO)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
 func TestRegistry_EncryptionKey_Success(t *testing.T) {
 	ctx := context.Background()
 	subscriberID := "sub-enc-test"
@@ -1527,6 +1860,418 @@ func TestRegistry_EncryptionKey_Failure(t *testing.T) {
 	}
 }
 
+func TestRegistry_PendingOperationsCount_Success(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).
+		WithArgs("sub-pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := r.PendingOperationsCount(context.Background(), "sub-pending")
+	if err != nil {
+		t.Fatalf("PendingOperationsCount() error = %v, want nil", err)
+	}
+	if count != 3 {
+		t.Errorf("PendingOperationsCount() = %d, want 3", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_PendingOperationsCount_Failure(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	dbErr := errors.New("db error")
+	mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).
+		WithArgs("sub-pending").
+		WillReturnError(dbErr)
+
+	if _, err := r.PendingOperationsCount(context.Background(), "sub-pending"); !errors.Is(err, dbErr) {
+		t.Errorf("PendingOperationsCount() error = %v, want %v", err, dbErr)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_InsertOperationWithinLimit(t *testing.T) {
+	requestJSON, _ := json.Marshal(map[string]string{"subscriber_id": "sub-limit"})
+	now := time.Now().Truncate(time.Second)
+	dbErr := errors.New("db error")
+
+	tests := []struct {
+		name    string
+		setup   func(mock sqlmock.Sqlmock, lro *model.LRO)
+		wantErr error
+	}{
+		{
+			name: "under the limit is inserted",
+			setup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(lockSubscriberOperationsQuery)).WithArgs("sub-limit").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).WithArgs("sub-limit").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(insertOperationQuery)).
+					WithArgs(lro.OperationID, lro.Status, lro.Type, lro.RequestJSON).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "at the limit is rejected without insert",
+			setup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(lockSubscriberOperationsQuery)).WithArgs("sub-limit").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).WithArgs("sub-limit").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrPendingOperationLimit,
+		},
+		{
+			name: "lock error",
+			setup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(lockSubscriberOperationsQuery)).WithArgs("sub-limit").WillReturnError(dbErr)
+				mock.ExpectRollback()
+			},
+			wantErr: dbErr,
+		},
+		{
+			name: "duplicate operation",
+			setup: func(mock sqlmock.Sqlmock, lro *model.LRO) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(lockSubscriberOperationsQuery)).WithArgs("sub-limit").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(countPendingOperationsQuery)).WithArgs("sub-limit").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectQuery(regexp.QuoteMeta(insertOperationQuery)).
+					WithArgs(lro.OperationID, lro.Status, lro.Type, lro.RequestJSON).
+					WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			wantErr: ErrOperationAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			lro := &model.LRO{
+				OperationID: "op-limit",
+				Status:      model.LROStatusPending,
+				Type:        model.OperationTypeCreateSubscription,
+				RequestJSON: requestJSON,
+			}
+			tt.setup(mock, lro)
+
+			got, err := r.InsertOperationWithinLimit(context.Background(), lro, "sub-limit", 2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InsertOperationWithinLimit() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !got.CreatedAt.Equal(now) {
+				t.Errorf("InsertOperationWithinLimit() CreatedAt = %v, want %v", got.CreatedAt, now)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_ClaimNextPendingOperation_Success(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(claimNextPendingOperationQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "retry_count", "created_at", "updated_at"}).
+			AddRow("op-1", model.LROStatusInProgress, model.OperationTypeCreateSubscription, []byte(`{"subscriber_id":"sub1"}`), nil, []byte(`{"error":"previous"}`), 1, now, now))
+
+	got, err := r.ClaimNextPendingOperation(context.Background())
+	if err != nil {
+		t.Fatalf("ClaimNextPendingOperation() error = %v, want nil", err)
+	}
+	want := &model.LRO{
+		OperationID:   "op-1",
+		Status:        model.LROStatusInProgress,
+		Type:          model.OperationTypeCreateSubscription,
+		RequestJSON:   []byte(`{"subscriber_id":"sub1"}`),
+		ErrorDataJSON: []byte(`{"error":"previous"}`),
+		RetryCount:    1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ClaimNextPendingOperation() mismatch (-want +got):\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_ClaimNextPendingOperation_Failure(t *testing.T) {
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name    string
+		dbErr   error
+		wantErr error
+	}{
+		{
+			name:    "no pending operation",
+			dbErr:   sql.ErrNoRows,
+			wantErr: ErrNoPendingOperation,
+		},
+		{
+			name:    "db error",
+			dbErr:   dbErr,
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			mock.ExpectQuery(regexp.QuoteMeta(claimNextPendingOperationQuery)).WillReturnError(tt.dbErr)
+
+			if _, err := r.ClaimNextPendingOperation(context.Background()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ClaimNextPendingOperation() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_ClaimOperation(t *testing.T) {
+	now := time.Now()
+	dbErr := errors.New("db error")
+	columns := []string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "retry_count", "created_at", "updated_at"}
+	tests := []struct {
+		name    string
+		query   string
+		arg     driver.Value
+		claim   func(r *registry) (*model.LRO, error)
+		dbErr   error
+		wantErr error
+	}{
+		{
+			name:  "claim by id",
+			query: claimOperationQuery,
+			arg:   "op-1",
+			claim: func(r *registry) (*model.LRO, error) { return r.ClaimOperation(context.Background(), "op-1") },
+		},
+		{
+			name:    "operation not pending",
+			query:   claimOperationQuery,
+			arg:     "op-1",
+			claim:   func(r *registry) (*model.LRO, error) { return r.ClaimOperation(context.Background(), "op-1") },
+			dbErr:   sql.ErrNoRows,
+			wantErr: ErrNoPendingOperation,
+		},
+		{
+			name:  "claim stale",
+			query: claimStaleOperationQuery,
+			arg:   now.Add(-time.Minute),
+			claim: func(r *registry) (*model.LRO, error) {
+				return r.ClaimStaleOperation(context.Background(), now.Add(-time.Minute))
+			},
+		},
+		{
+			name:  "claim stale db error",
+			query: claimStaleOperationQuery,
+			arg:   now.Add(-time.Minute),
+			claim: func(r *registry) (*model.LRO, error) {
+				return r.ClaimStaleOperation(context.Background(), now.Add(-time.Minute))
+			},
+			dbErr:   dbErr,
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			expect := mock.ExpectQuery(regexp.QuoteMeta(tt.query)).WithArgs(tt.arg)
+			if tt.dbErr != nil {
+				expect.WillReturnError(tt.dbErr)
+			} else {
+				expect.WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("op-1", model.LROStatusInProgress, model.OperationTypeCreateSubscription, []byte(`{"subscriber_id":"sub1"}`), nil, nil, 0, now, now))
+			}
+
+			got, err := tt.claim(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("claim error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.OperationID != "op-1" || got.Status != model.LROStatusInProgress) {
+				t.Errorf("claimed LRO = %+v, want op-1 IN_PROGRESS", got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_RejectStaleOperations_Success(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	now := time.Now()
+	createdBefore := now.Add(-time.Hour)
+	errData := []byte(`{"reason":"STALE"}`)
+	mock.ExpectQuery(regexp.QuoteMeta(rejectStaleOperationsQuery)).
+		WithArgs(createdBefore, string(errData)).
+		WillReturnRows(sqlmock.NewRows([]string{"operation_id", "status", "type", "request_json", "result_json", "error_data_json", "retry_count", "created_at", "updated_at"}).
+			AddRow("op-1", model.LROStatusRejected, model.OperationTypeCreateSubscription, []byte(`{"subscriber_id":"sub1"}`), nil, errData, 0, now.Add(-2*time.Hour), now))
+
+	got, err := r.RejectStaleOperations(context.Background(), createdBefore, errData)
+	if err != nil {
+		t.Fatalf("RejectStaleOperations() error = %v, want nil", err)
+	}
+	want := []model.LRO{{
+		OperationID:   "op-1",
+		Status:        model.LROStatusRejected,
+		Type:          model.OperationTypeCreateSubscription,
+		RequestJSON:   []byte(`{"subscriber_id":"sub1"}`),
+		ErrorDataJSON: errData,
+		CreatedAt:     now.Add(-2 * time.Hour),
+		UpdatedAt:     now,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RejectStaleOperations() mismatch (-want +got):\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_RejectStaleOperations_DBError(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+	dbErr := errors.New("db error")
+	mock.ExpectQuery(regexp.QuoteMeta(rejectStaleOperationsQuery)).WillReturnError(dbErr)
+
+	if _, err := r.RejectStaleOperations(context.Background(), time.Now(), []byte(`{}`)); !errors.Is(err, dbErr) {
+		t.Errorf("RejectStaleOperations() error = %v, want %v", err, dbErr)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_ForEachSubscription_Success(t *testing.T) {
+	r, mock, db := newMockRegistry(t)
+	defer db.Close()
+
+	baseTime := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"subscriber_id", "url", "type", "domain", "location", "key_id",
+		"signing_public_key", "encr_public_key", "valid_from", "valid_until",
+		"status", "created_at", "updated_at",
+	}).
+		AddRow("sub1", "http://url1.com", "BAP", "domain1", nil, "key1", "sign1", "encr1", baseTime, baseTime.Add(time.Hour), "SUBSCRIBED", baseTime, baseTime).
+		AddRow("sub2", "http://url2.com", "BPP", "domain2", nil, "key2", "sign2", "encr2", baseTime, baseTime.Add(time.Hour), "SUBSCRIBED", baseTime, baseTime)
+	mock.ExpectQuery(regexp.QuoteMeta(forEachSubscriptionQuery)).WillReturnRows(rows)
+
+	var got []model.Subscription
+	err := r.ForEachSubscription(context.Background(), func(sub *model.Subscription) error {
+		got = append(got, *sub)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachSubscription() error = %v, want nil", err)
+	}
+	want := []model.Subscription{
+		{
+			Subscriber: model.Subscriber{SubscriberID: "sub1", URL: "http://url1.com", Type: model.RoleBAP, Domain: "domain1"},
+			KeyID:      "key1", SigningPublicKey: "sign1", EncrPublicKey: "encr1", ValidFrom: baseTime, ValidUntil: baseTime.Add(time.Hour), Status: "SUBSCRIBED", Created: baseTime, Updated: baseTime,
+		},
+		{
+			Subscriber: model.Subscriber{SubscriberID: "sub2", URL: "http://url2.com", Type: model.RoleBPP, Domain: "domain2"},
+			KeyID:      "key2", SigningPublicKey: "sign2", EncrPublicKey: "encr2", ValidFrom: baseTime, ValidUntil: baseTime.Add(time.Hour), Status: "SUBSCRIBED", Created: baseTime, Updated: baseTime,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ForEachSubscription() mismatch (-want +got):\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRegistry_ForEachSubscription_Failure(t *testing.T) {
+	dbErr := errors.New("db error")
+	fnErr := errors.New("write failed")
+	columns := []string{"subscriber_id", "url", "type", "domain", "location", "key_id", "signing_public_key", "encr_public_key", "valid_from", "valid_until", "status", "created_at", "updated_at"}
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		setup     func(mock sqlmock.Sqlmock)
+		fnErr     error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name: "query fails",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(forEachSubscriptionQuery)).WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+		{
+			name: "row iteration fails",
+			setup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow("sub1", "http://url1.com", "BAP", "domain1", nil, "key1", "sign1", "encr1", now, now, "SUBSCRIBED", now, now).
+					RowError(0, dbErr)
+				mock.ExpectQuery(regexp.QuoteMeta(forEachSubscriptionQuery)).WillReturnRows(rows)
+			},
+			wantErr: dbErr,
+		},
+		{
+			name: "callback error stops iteration",
+			setup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow("sub1", "http://url1.com", "BAP", "domain1", nil, "key1", "sign1", "encr1", now, now, "SUBSCRIBED", now, now).
+					AddRow("sub2", "http://url2.com", "BPP", "domain2", nil, "key2", "sign2", "encr2", now, now, "SUBSCRIBED", now, now)
+				mock.ExpectQuery(regexp.QuoteMeta(forEachSubscriptionQuery)).WillReturnRows(rows)
+			},
+			fnErr:     fnErr,
+			wantErr:   fnErr,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.setup(mock)
+
+			calls := 0
+			err := r.ForEachSubscription(context.Background(), func(*model.Subscription) error {
+				calls++
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ForEachSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("ForEachSubscription() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
 func TestBuildLookupConditions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1741,6 +2486,20 @@ func TestBuildLocationConditions(t *testing.T) {
 				goqu.L("location->>'rating'").Eq("4.5"),
 			},
 		},
+		{
+			name: "Circle filter only",
+			filter: &model.Location{
+				Circle: &model.Circle{Gps: "12.9,77.6", Radius: &model.Scalar{Value: "5", Unit: "km"}},
+			},
+			expected: []goqu.Expression{
+				goqu.C("location").IsNotNull(),
+			},
+		},
+		{
+			name:     "Empty circle filter",
+			filter:   &model.Location{Circle: &model.Circle{}},
+			expected: []goqu.Expression{},
+		},
 		{
 			name: "City filter only",
 			filter: &model.Location{
@@ -2115,3 +2874,93 @@ func TestRegistry_GetOperation_Failure(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry_DeleteSubscription(t *testing.T) {
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "deleted",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(deleteSubscriptionQuery)).WithArgs("np.com", "key1").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(deleteSubscriptionQuery)).WithArgs("np.com", "key1").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrSubscriptionNotFound,
+		},
+		{
+			name: "db error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(deleteSubscriptionQuery)).WithArgs("np.com", "key1").WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.mockSetup(mock)
+
+			err := r.DeleteSubscription(context.Background(), "np.com", "key1")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("DeleteSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRegistry_UnsubscribeSubscription(t *testing.T) {
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name      string
+		mockSetup func(mock sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "unsubscribed",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(unsubscribeSubscriptionQuery)).WithArgs("np.com", "retail", model.RoleBAP, "key1").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(unsubscribeSubscriptionQuery)).WithArgs("np.com", "retail", model.RoleBAP, "key1").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrSubscriptionNotFound,
+		},
+		{
+			name: "db error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(unsubscribeSubscriptionQuery)).WithArgs("np.com", "retail", model.RoleBAP, "key1").WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, mock, db := newMockRegistry(t)
+			defer db.Close()
+			tt.mockSetup(mock)
+
+			err := r.UnsubscribeSubscription(context.Background(), "np.com", "retail", model.RoleBAP, "key1")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("UnsubscribeSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
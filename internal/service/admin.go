@@ -20,12 +20,29 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 var ErrLROAlreadyProcessed = errors.New("LRO_ALREADY_PROCESSED")
 
+// ErrLROInProgress is returned along with ErrLROAlreadyProcessed for an LRO claimed by an approval,
+// which no other action may process until the claim finishes or expires.
+var ErrLROInProgress = errors.New("LRO_IN_PROGRESS")
+
+// ErrCallbackURLConflict is returned when a subscription's callback URL already belongs to another subscriber in the same domain.
+var ErrCallbackURLConflict = errors.New("callback URL already in use by another subscriber in the domain")
+
+// ErrSubscriberDomainNotAllowed is returned when a subscriber_id is outside the domains allowed on the network.
+var ErrSubscriberDomainNotAllowed = errors.New("subscriber_id is not under an allowed domain")
+
 // encrypter defines the methods for encryption.
 type encrypterSrv interface {
 	Encrypt(ctx context.Context, data string, npKey string) (string, error)
@@ -34,18 +51,25 @@ type encrypterSrv interface {
 // npClient defines the interface for communicating with a Network Participant.
 type npClient interface {
 	OnSubscribe(ctx context.Context, callbackURL string, request *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error)
+	Probe(ctx context.Context, baseURL string) error
 }
 
 // challengeSrv handles generation and verification of challenges.
 type challengeSrv interface {
-	NewChallenge() (string, error)
-	Verify(challenge, answer string) bool
+	NewChallenge(policy ChallengePolicy) (string, error)
+	Verify(policy ChallengePolicy, challenge, answer string) bool
 }
 
 type regRepo interface {
 	GetOperation(context.Context, string) (*model.LRO, error)
+	ClaimNextPendingOperation(context.Context) (*model.LRO, error)
+	ClaimOperation(ctx context.Context, operationID string) (*model.LRO, error)
+	ClaimStaleOperation(ctx context.Context, claimedBefore time.Time) (*model.LRO, error)
 	UpdateOperation(context.Context, *model.LRO) (*model.LRO, error)
+	UpdateOperationIfStatus(ctx context.Context, lro *model.LRO, expected model.LROStatus) (*model.LRO, error)
 	UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error)
+	UpsertSubscriptionAndLROIfStatus(ctx context.Context, sub *model.Subscription, lro *model.LRO, expected model.LROStatus) (*model.Subscription, *model.LRO, error)
+	RejectStaleOperations(ctx context.Context, createdBefore time.Time, errorDataJSON []byte) ([]model.LRO, error)
 	Lookup(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error)
 }
 
@@ -61,12 +85,86 @@ type adminService struct {
 	encryptor   encrypterSrv
 	npClient    npClient
 	evPublisher adminEventPublisher
+	approvals   *callbackLimiter // Limits concurrent approvals per subscriber_id.
+
+	approvalQueue *approvalQueue // Queues the approvals of ASYNC mode, nil in SYNC mode.
 }
 
 type AdminConfig struct {
 	OperationRetryMax int `yaml:"operationRetryMax"`
+	// EnforceUniqueCallbackURL rejects a subscription whose callback URL is already used by
+	// another subscribed participant in the same domain. Off by default since some networks share endpoints.
+	EnforceUniqueCallbackURL bool `yaml:"enforceUniqueCallbackURL"`
+	// AllowedSubscriberDomains restricts approval to subscriber ids equal to or under one of
+	// these domains, e.g. "example.org" allows "example.org" and "bap.example.org".
+	// Empty allows any subscriber id, as on an open network.
+	AllowedSubscriberDomains []string `yaml:"allowedSubscriberDomains"`
+	// OperationIDFormat is ANY or UUID, the format operation ids of admin actions must have. Defaults to ANY.
+	OperationIDFormat OperationIDFormat `yaml:"operationIDFormat"`
+	// ResolveMissingEncrPublicKey uses the encryption key registered for the subscriber's key id
+	// when a request omits it, instead of rejecting the request.
+	ResolveMissingEncrPublicKey bool `yaml:"resolveMissingEncrPublicKey"`
+	// ChallengeVerification is STRICT or TOLERANT, how on_subscribe answers are compared with the challenge. Defaults to STRICT.
+	ChallengeVerification ChallengeVerificationMode `yaml:"challengeVerification"`
+	// MaxConcurrentApprovalsPerSubscriber limits how many approvals of the same subscriber_id run
+	// at a time, so a participant does not receive parallel on_subscribe challenges.
+	// Further approvals wait for a running one to finish. 0 means no limit.
+	MaxConcurrentApprovalsPerSubscriber int `yaml:"maxConcurrentApprovalsPerSubscriber"`
+	// ChallengeLength is the number of random bytes of a challenge, at least MinChallengeLength.
+	// 0 means DefaultChallengeLength.
+	ChallengeLength int `yaml:"challengeLength"`
+	// ChallengeAlgorithm is HEX or BASE64, how the random bytes of a challenge are encoded. Defaults to HEX.
+	ChallengeAlgorithm ChallengeAlgorithm `yaml:"challengeAlgorithm"`
+	// ChallengePolicies overrides, per domain, how challenges of subscriptions to that domain are
+	// generated and verified. Unset fields and other domains use ChallengeLength, ChallengeAlgorithm
+	// and ChallengeVerification.
+	ChallengePolicies map[string]ChallengePolicy `yaml:"challengePolicies"`
+	// RecordUpdateDiff stores the fields changed by an approved subscription update as the
+	// result of its operation, returned with the operation. Changes are logged either way.
+	RecordUpdateDiff bool `yaml:"recordUpdateDiff"`
+	// CallbackErrorBodyBytes is how much of a participant's error response to a failed /on_subscribe
+	// callback is stored with the reason of the failure on its operation. Defaults to DefaultCallbackErrorBodyBytes.
+	CallbackErrorBodyBytes int `yaml:"callbackErrorBodyBytes"`
+	// CallbackPreflight checks that the participant's callback URL is reachable before a challenge
+	// is generated, failing the operation with the CALLBACK_UNREACHABLE reason if it is not.
+	CallbackPreflight bool `yaml:"callbackPreflight"`
+	// MaxErrorMessageBytes bounds the error message stored on a failed operation, which is truncated
+	// with an ellipsis beyond it. Defaults to DefaultMaxErrorMessageBytes.
+	MaxErrorMessageBytes int `yaml:"maxErrorMessageBytes"`
+	// ConditionalApproval stores the result of an approval, success or failure, or of a rejection only if
+	// its operation still has the status the action read, so that of two racing actions, e.g. by two admins,
+	// the later one does not overwrite the result of the other. The later one returns the stored operation.
+	ConditionalApproval bool `yaml:"conditionalApproval"`
+	// StaleOperationAge is how long an operation may stay PENDING before RejectStaleOperations
+	// rejects it with the STALE reason. 0 means operations are never rejected for their age.
+	StaleOperationAge time.Duration `yaml:"staleOperationAge"`
+	// ApprovalMode is SYNC or ASYNC. SYNC approvals run the challenge round-trip within the approve
+	// request and return the final status. ASYNC approvals are queued in a task queue and return the
+	// operation still PENDING, approval workers claim and finalize it. Defaults to SYNC.
+	ApprovalMode ApprovalMode `yaml:"approvalMode"`
+	// ApprovalWorkers is the number of workers running ASYNC approvals. Defaults to 1.
+	ApprovalWorkers int `yaml:"approvalWorkers"`
+	// ApprovalQueueSize is how many ASYNC approvals may wait for a worker. Approvals beyond it
+	// fail with ErrApprovalQueueFull. Defaults to DefaultApprovalQueueSize.
+	ApprovalQueueSize int `yaml:"approvalQueueSize"`
+	// ApprovalClaimTimeout is how long an operation may stay IN_PROGRESS before the approval workers
+	// or ApproveNextPendingSubscription take it over, e.g. after the service stopped while approving
+	// it. It must exceed the time an approval runs. Defaults to DefaultApprovalClaimTimeout.
+	ApprovalClaimTimeout time.Duration `yaml:"approvalClaimTimeout"`
 }
 
+// DefaultCallbackErrorBodyBytes is the default of AdminConfig.CallbackErrorBodyBytes.
+const DefaultCallbackErrorBodyBytes = 1024
+
+// DefaultMaxErrorMessageBytes is the default of AdminConfig.MaxErrorMessageBytes.
+const DefaultMaxErrorMessageBytes = 4096
+
+// errorTruncatedMarker ends an error message stored on an operation that was truncated.
+const errorTruncatedMarker = "..."
+
+// staleOperationReason is the reason of operations rejected for staying PENDING longer than AdminConfig.StaleOperationAge.
+const staleOperationReason = "STALE"
+
 // NewAdminService creates a new adminService.
 func NewAdminService(regRepo regRepo, chSrv challengeSrv, encryptor encrypterSrv, npClient npClient, evPub adminEventPublisher, cfg *AdminConfig) (*adminService, error) {
 	if regRepo == nil {
@@ -94,35 +192,154 @@ func NewAdminService(regRepo regRepo, chSrv challengeSrv, encryptor encrypterSrv
 		slog.Error("NewAdminService: OperationRetryMax cannot be zero or negative")
 		return nil, errors.New("AdminConfig.OperationRetryMax cannot be zero or negative")
 	}
+	for _, d := range cfg.AllowedSubscriberDomains {
+		if strings.Trim(d, ".") == "" {
+			slog.Error("NewAdminService: AllowedSubscriberDomains cannot contain an empty domain")
+			return nil, errors.New("AdminConfig.AllowedSubscriberDomains cannot contain an empty domain")
+		}
+	}
+	if !cfg.OperationIDFormat.Valid() {
+		slog.Error("NewAdminService: Unknown OperationIDFormat", "format", cfg.OperationIDFormat)
+		return nil, fmt.Errorf("AdminConfig.OperationIDFormat %q must be one of ANY, UUID", cfg.OperationIDFormat)
+	}
+	if cfg.MaxConcurrentApprovalsPerSubscriber < 0 {
+		slog.Error("NewAdminService: MaxConcurrentApprovalsPerSubscriber cannot be negative")
+		return nil, errors.New("AdminConfig.MaxConcurrentApprovalsPerSubscriber cannot be negative")
+	}
+	if cfg.CallbackErrorBodyBytes < 0 {
+		slog.Error("NewAdminService: CallbackErrorBodyBytes cannot be negative")
+		return nil, errors.New("AdminConfig.CallbackErrorBodyBytes cannot be negative")
+	}
+	if cfg.MaxErrorMessageBytes < 0 {
+		slog.Error("NewAdminService: MaxErrorMessageBytes cannot be negative")
+		return nil, errors.New("AdminConfig.MaxErrorMessageBytes cannot be negative")
+	}
+	if cfg.StaleOperationAge < 0 {
+		slog.Error("NewAdminService: StaleOperationAge cannot be negative")
+		return nil, errors.New("AdminConfig.StaleOperationAge cannot be negative")
+	}
+	if !cfg.ApprovalMode.Valid() {
+		slog.Error("NewAdminService: Unknown ApprovalMode", "mode", cfg.ApprovalMode)
+		return nil, fmt.Errorf("AdminConfig.ApprovalMode %q must be one of SYNC, ASYNC", cfg.ApprovalMode)
+	}
+	if cfg.ApprovalWorkers < 0 || cfg.ApprovalQueueSize < 0 {
+		slog.Error("NewAdminService: ApprovalWorkers and ApprovalQueueSize cannot be negative")
+		return nil, errors.New("AdminConfig.ApprovalWorkers and ApprovalQueueSize cannot be negative")
+	}
+	if cfg.ApprovalClaimTimeout < 0 {
+		slog.Error("NewAdminService: ApprovalClaimTimeout cannot be negative")
+		return nil, errors.New("AdminConfig.ApprovalClaimTimeout cannot be negative")
+	}
+	if err := (ChallengePolicy{Length: cfg.ChallengeLength, Algorithm: cfg.ChallengeAlgorithm}).Validate(); err != nil {
+		slog.Error("NewAdminService: Invalid default challenge policy", "error", err)
+		return nil, fmt.Errorf("AdminConfig.ChallengeLength or ChallengeAlgorithm: %w", err)
+	}
+	for domain, p := range cfg.ChallengePolicies {
+		if err := p.Validate(); err != nil {
+			slog.Error("NewAdminService: Invalid challenge policy", "domain", domain, "error", err)
+			return nil, fmt.Errorf("AdminConfig.ChallengePolicies[%q]: %w", domain, err)
+		}
+	}
 
 	if evPub == nil {
 		slog.Error("NewAdminService: eventPublisher cannot be nil")
 		return nil, errors.New("eventPublisher cannot be nil")
 	}
-	return &adminService{regRepo: regRepo, chSrv: chSrv, encryptor: encryptor, npClient: npClient, evPublisher: evPub, cfg: cfg}, nil
+	s := &adminService{
+		regRepo:     regRepo,
+		chSrv:       chSrv,
+		encryptor:   encryptor,
+		npClient:    npClient,
+		evPublisher: evPub,
+		cfg:         cfg,
+		approvals:   NewCallbackLimiter(cfg.MaxConcurrentApprovalsPerSubscriber),
+	}
+	if cfg.ApprovalMode == ApprovalModeAsync {
+		s.approvalQueue = newApprovalQueue(&approvalProcessor{s: s}, cfg.ApprovalQueueSize, cfg.ApprovalWorkers, cfg.ApprovalClaimTimeout)
+	}
+	return s, nil
 }
 
-// ApproveSubscription approves a pending subscription LRO.
+// ApproveSubscription approves a pending subscription LRO. In ASYNC mode the approval is
+// queued and the LRO is returned PENDING, without a subscription.
 func (s *adminService) ApproveSubscription(ctx context.Context, req *model.OperationActionRequest) (*model.Subscription, *model.LRO, error) {
 	if req == nil {
 		slog.ErrorContext(ctx, "AdminService: OperationActionRequest cannot be nil")
 		return nil, nil, errors.New("OperationActionRequest cannot be nil")
 	}
-	if req.OperationID == "" {
-		slog.ErrorContext(ctx, "AdminService: OperationID cannot be empty")
-		return nil, nil, errors.New("OperationID cannot be empty")
-
+	if err := s.cfg.OperationIDFormat.validate(req.OperationID); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Malformed OperationID", "error", err)
+		return nil, nil, err
 	}
 	slog.InfoContext(ctx, "AdminService: Starting subscription approval process", "operation_id", req.OperationID)
 
 	lro, err := s.lro(ctx, req.OperationID)
+	if s.approvalQueue != nil && errors.Is(err, ErrLROInProgress) {
+		// A repeated approval is not queued twice, its LRO is returned as stored.
+		slog.InfoContext(ctx, "AdminService: Approval already in progress", "operation_id", lro.OperationID)
+		return nil, lro, nil
+	}
 	if err != nil {
 		return nil, nil, err
 	}
+	if s.approvalQueue != nil {
+		lro, err := s.enqueueApproval(ctx, lro)
+		return nil, lro, err
+	}
+	return s.approveLRO(ctx, lro)
+}
+
+// ApproveNextPendingSubscription claims the oldest pending subscription LRO and approves it.
+// Claiming is atomic, so concurrent approval workers never process the same LRO. An LRO whose
+// claim is older than AdminConfig.ApprovalClaimTimeout, e.g. of a worker that stopped, is taken
+// over first. It returns repository.ErrNoPendingOperation if there is nothing left to approve.
+func (s *adminService) ApproveNextPendingSubscription(ctx context.Context) (*model.Subscription, *model.LRO, error) {
+	lro, err := s.regRepo.ClaimStaleOperation(ctx, time.Now().Add(-s.claimTimeout()))
+	if err == nil {
+		slog.WarnContext(ctx, "AdminService: Taking over approval whose claim expired", "operation_id", lro.OperationID, "claimed_at", lro.UpdatedAt)
+	}
+	if errors.Is(err, repository.ErrNoPendingOperation) {
+		lro, err = s.regRepo.ClaimNextPendingOperation(ctx)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrNoPendingOperation) {
+			slog.ErrorContext(ctx, "AdminService: Failed to claim next pending LRO", "error", err)
+		}
+		return nil, nil, fmt.Errorf("failed to claim next pending LRO: %w", err)
+	}
+	slog.InfoContext(ctx, "AdminService: Claimed pending LRO for approval", "operation_id", lro.OperationID)
+
+	if err := s.checkClaimedLRO(ctx, lro); err != nil {
+		// The LRO is now IN_PROGRESS, so it must be moved to a final status to not be stranded.
+		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return nil, lro, err
+	}
+	return s.approveLRO(ctx, lro)
+}
+
+// approveLRO runs the approval flow for a validated subscription LRO.
+func (s *adminService) approveLRO(ctx context.Context, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
 	subReq, err := s.subReq(ctx, lro)
 	if err != nil {
 		return nil, nil, err
 	}
+	if len(s.cfg.AllowedSubscriberDomains) > 0 {
+		if err := s.checkSubscriberDomain(ctx, lro, subReq.SubscriberID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Approvals of the same subscriber, e.g. by two admins, are serialized
+	// from the lookup on so that its challenges and the registry state do not interleave.
+	// Nothing was attempted if the wait is cancelled, so the LRO is left as is to be retried.
+	release, err := s.approvals.acquire(ctx, subReq.SubscriberID)
+	if err != nil {
+		slog.WarnContext(ctx, "AdminService: Gave up waiting for a concurrent approval of the subscriber", "operation_id", lro.OperationID, "subscriber_id", subReq.SubscriberID, "error", err)
+		return nil, nil, fmt.Errorf("waiting for a concurrent approval of subscriber '%s': %w", subReq.SubscriberID, err)
+	}
+	defer release()
 
 	sub := &model.Subscription{
 		Subscriber: model.Subscriber{
@@ -157,8 +374,20 @@ func (s *adminService) ApproveSubscription(ctx context.Context, req *model.Opera
 		}
 		return nil, nil, err
 	}
+	if s.cfg.EnforceUniqueCallbackURL {
+		if err := s.checkCallbackURL(ctx, lro, subReq); err != nil {
+			return nil, nil, err
+		}
+	}
 
-	challenge, encryptedChallenge, err := s.challenge(ctx, lro, subReq.EncrPublicKey)
+	if s.cfg.CallbackPreflight {
+		if err := s.preflight(ctx, lro, subReq); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	policy := s.challengePolicy(subReq.Domain)
+	challenge, encryptedChallenge, err := s.challenge(ctx, lro, policy, subReq.EncrPublicKey)
 	if err != nil {
 		// generateAndEncryptChallenge logs and updates LRO
 		return nil, nil, err
@@ -169,12 +398,16 @@ func (s *adminService) ApproveSubscription(ctx context.Context, req *model.Opera
 		return nil, nil, err
 	}
 
-	if err := s.verifyChallenge(ctx, lro, challenge, onSubscribeResp.Answer); err != nil {
+	if err := s.verifyChallenge(ctx, lro, policy, challenge, onSubscribeResp.Answer); err != nil {
 		// verifyChallengeResponse logs and updates LRO
 		return nil, nil, err
 	}
 
-	return s.approve(ctx, lro, subReq)
+	var prev *model.Subscription
+	if lro.Type == model.OperationTypeUpdateSubscription {
+		prev = &subs[0]
+	}
+	return s.approve(ctx, lro, subReq, prev)
 }
 
 // lro retrieves the LRO and performs initial validations.
@@ -184,22 +417,50 @@ func (s *adminService) lro(ctx context.Context, operationID string) (*model.LRO,
 		slog.ErrorContext(ctx, "AdminService: Failed to get LRO ", "operation_id", operationID, "error", err)
 		return nil, fmt.Errorf("failed to get LRO: %w", err)
 	}
+	if err := s.checkLRO(ctx, lro); err != nil {
+		return lro, err
+	}
+	return lro, nil
+}
+
+// claimTimeout returns how long an approval may hold the claim of an LRO.
+func (s *adminService) claimTimeout() time.Duration {
+	if s.cfg.ApprovalClaimTimeout > 0 {
+		return s.cfg.ApprovalClaimTimeout
+	}
+	return DefaultApprovalClaimTimeout
+}
+
+// checkLRO validates that the LRO can still be processed. An IN_PROGRESS LRO is claimed
+// by an approval and is rejected with ErrLROInProgress.
+func (s *adminService) checkLRO(ctx context.Context, lro *model.LRO) error {
+	if err := s.checkClaimedLRO(ctx, lro); err != nil {
+		return err
+	}
+	if lro.Status == model.LROStatusInProgress {
+		slog.WarnContext(ctx, "AdminService: LRO is claimed by an approval in progress", "operation_id", lro.OperationID)
+		return fmt.Errorf("%w: %w: operation %s is claimed by an approval", ErrLROAlreadyProcessed, ErrLROInProgress, lro.OperationID)
+	}
+	return nil
+}
 
+// checkClaimedLRO is checkLRO for an LRO the caller claimed, which is IN_PROGRESS.
+func (s *adminService) checkClaimedLRO(ctx context.Context, lro *model.LRO) error {
 	if lro.RetryCount > s.cfg.OperationRetryMax {
-		slog.ErrorContext(ctx, "AdminService: Max retries exceeded for operation", "operation_id", operationID, "retry_count", lro.RetryCount)
-		return lro, errors.New("max retries exceeded for operation")
+		slog.ErrorContext(ctx, "AdminService: Max retries exceeded for operation", "operation_id", lro.OperationID, "retry_count", lro.RetryCount)
+		return errors.New("max retries exceeded for operation")
 	}
 
 	if lro.Type != model.OperationTypeCreateSubscription && lro.Type != model.OperationTypeUpdateSubscription {
-		slog.WarnContext(ctx, "AdminService: Attempted to process non-subscription LRO", "operation_id", operationID, "type", lro.Type)
-		return lro, fmt.Errorf("invalid operation type: %s, expected CREATE_SUBSCRIPTION or UPDATE_SUBSCRIPTION", lro.Type)
+		slog.WarnContext(ctx, "AdminService: Attempted to process non-subscription LRO", "operation_id", lro.OperationID, "type", lro.Type)
+		return fmt.Errorf("invalid operation type: %s, expected CREATE_SUBSCRIPTION or UPDATE_SUBSCRIPTION", lro.Type)
 	}
 
 	if lro.Status == model.LROStatusApproved || lro.Status == model.LROStatusRejected {
-		slog.WarnContext(ctx, "AdminService: LRO has already been processed", "operation_id", operationID, "status", lro.Status)
-		return lro, fmt.Errorf("%w: operation %s has status %s", ErrLROAlreadyProcessed, operationID, lro.Status)
+		slog.WarnContext(ctx, "AdminService: LRO has already been processed", "operation_id", lro.OperationID, "status", lro.Status)
+		return fmt.Errorf("%w: operation %s has status %s", ErrLROAlreadyProcessed, lro.OperationID, lro.Status)
 	}
-	return lro, nil
+	return nil
 }
 
 // subReq unmarshals and validates the SubscriptionRequest from LRO.
@@ -222,6 +483,21 @@ func (s *adminService) subReq(ctx context.Context, lro *model.LRO) (*model.Subsc
 		}
 		return nil, err
 	}
+	if subReq.EncrPublicKey == "" && s.cfg.ResolveMissingEncrPublicKey {
+		key, err := s.registeredEncrPublicKey(ctx, &subReq)
+		if err != nil {
+			slog.ErrorContext(ctx, "AdminService: Registered encryption public key lookup failed", "operation_id", lro.OperationID, "error", err)
+			lookupErr := fmt.Errorf("registered encryption public key lookup failed: %w", err)
+			if updateErr := s.updateLROError(ctx, lro, lookupErr, model.LROStatusFailure); updateErr != nil {
+				slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+			}
+			return nil, lookupErr
+		}
+		if key != "" {
+			slog.InfoContext(ctx, "AdminService: Using registered encryption public key missing in subscription request", "operation_id", lro.OperationID, "subscriber_id", subReq.SubscriberID, "key_id", subReq.KeyID)
+			subReq.EncrPublicKey = key
+		}
+	}
 	if subReq.EncrPublicKey == "" {
 		slog.ErrorContext(ctx, "AdminService: Encryption public key missing in subscription request", "operation_id", lro.OperationID)
 		err := errors.New("encryption public key missing")
@@ -233,9 +509,109 @@ func (s *adminService) subReq(ctx context.Context, lro *model.LRO) (*model.Subsc
 	return &subReq, nil
 }
 
+// registeredEncrPublicKey returns the encryption public key registered for the subscriber and
+// key id of subReq, or "" if none is registered.
+func (s *adminService) registeredEncrPublicKey(ctx context.Context, subReq *model.SubscriptionRequest) (string, error) {
+	if subReq.SubscriberID == "" || subReq.KeyID == "" {
+		return "", nil
+	}
+	filter := &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: subReq.SubscriberID, Domain: subReq.Domain, Type: subReq.Type},
+		KeyID:      subReq.KeyID,
+	}
+	subs, err := s.regRepo.Lookup(ctx, filter)
+	if err != nil {
+		return "", err
+	}
+	for _, sub := range subs {
+		if sub.EncrPublicKey != "" {
+			return sub.EncrPublicKey, nil
+		}
+	}
+	return "", nil
+}
+
+// checkCallbackURL rejects the LRO if its callback URL already belongs to another subscribed participant in the same domain.
+func (s *adminService) checkCallbackURL(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) error {
+	filter := &model.Subscription{
+		Subscriber: model.Subscriber{URL: subReq.URL, Domain: subReq.Domain},
+		Status:     model.SubscriptionStatusSubscribed,
+	}
+	subs, err := s.regRepo.Lookup(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminService: callback URL lookup failed", "operation_id", lro.OperationID, "error", err)
+		lookupErr := fmt.Errorf("callback URL lookup failed: %w", err)
+		if updateErr := s.updateLROError(ctx, lro, lookupErr, model.LROStatusFailure); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return lookupErr
+	}
+	for _, sub := range subs {
+		if sub.SubscriberID == subReq.SubscriberID {
+			continue
+		}
+		slog.WarnContext(ctx, "AdminService: Callback URL already in use", "operation_id", lro.OperationID, "url", subReq.URL, "domain", subReq.Domain, "existing_subscriber_id", sub.SubscriberID)
+		err := fmt.Errorf("%w: url '%s' is used by subscriber_id '%s' in domain '%s'", ErrCallbackURLConflict, subReq.URL, sub.SubscriberID, subReq.Domain)
+		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkSubscriberDomain rejects the LRO if the subscriber id is not under one of the allowed domains.
+func (s *adminService) checkSubscriberDomain(ctx context.Context, lro *model.LRO, subscriberID string) error {
+	host := subscriberHost(subscriberID)
+	for _, d := range s.cfg.AllowedSubscriberDomains {
+		d = strings.ToLower(strings.Trim(d, "."))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return nil
+		}
+	}
+	slog.WarnContext(ctx, "AdminService: Subscriber domain not allowed", "operation_id", lro.OperationID, "subscriber_id", subscriberID, "allowed_domains", s.cfg.AllowedSubscriberDomains)
+	err := fmt.Errorf("%w: subscriber_id '%s' must be one of or a subdomain of %s", ErrSubscriberDomainNotAllowed, subscriberID, strings.Join(s.cfg.AllowedSubscriberDomains, ", "))
+	if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+	}
+	return err
+}
+
+// subscriberHost returns the lower-cased host name of a subscriber id,
+// which may be a bare host name or a URL, e.g. "bap.example.org" or "https://bap.example.org/beckn".
+func subscriberHost(subscriberID string) string {
+	id := subscriberID
+	if strings.Contains(id, "://") {
+		if u, err := url.Parse(id); err == nil {
+			id = u.Host
+		}
+	}
+	if i := strings.IndexByte(id, '/'); i >= 0 {
+		id = id[:i]
+	}
+	if host, _, err := net.SplitHostPort(id); err == nil {
+		id = host
+	}
+	return strings.ToLower(strings.TrimSuffix(id, "."))
+}
+
+// challengePolicy returns the challenge policy of domain, falling back to the default policy.
+func (s *adminService) challengePolicy(domain string) ChallengePolicy {
+	def := ChallengePolicy{
+		Length:       s.cfg.ChallengeLength,
+		Algorithm:    s.cfg.ChallengeAlgorithm,
+		Verification: s.cfg.ChallengeVerification,
+	}.withDefaults(ChallengePolicy{
+		Length:       DefaultChallengeLength,
+		Algorithm:    ChallengeAlgorithmHex,
+		Verification: ChallengeVerificationStrict,
+	})
+	return s.cfg.ChallengePolicies[domain].withDefaults(def)
+}
+
 // challenge handles challenge generation and encryption.
-func (s *adminService) challenge(ctx context.Context, lro *model.LRO, subscriberEncrPublicKey string) (string, string, error) {
-	challenge, err := s.chSrv.NewChallenge()
+func (s *adminService) challenge(ctx context.Context, lro *model.LRO, policy ChallengePolicy, subscriberEncrPublicKey string) (string, string, error) {
+	challenge, err := s.chSrv.NewChallenge(policy)
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminService: Failed to generate challenge", "operation_id", lro.OperationID, "error", err)
 		err := fmt.Errorf("failed to generate challenge: %w", err)
@@ -262,9 +638,10 @@ func (s *adminService) onSubscribe(ctx context.Context, lro *model.LRO, subReq *
 	onSubscribeReq := &model.OnSubscribeRequest{Challenge: encryptedChallenge, MessageID: subReq.MessageID}
 	onSubscribeResp, err := s.npClient.OnSubscribe(ctx, subReq.URL, onSubscribeReq)
 	if err != nil {
-		slog.WarnContext(ctx, "AdminService: /on_subscribe callback failed", "operation_id", lro.OperationID, "callback_url", subReq.URL, "error", err)
 		err := fmt.Errorf("network Participant /on_subscribe callback failed: %w", err)
-		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusFailure); updateErr != nil {
+		failure := s.callbackFailure(err)
+		slog.WarnContext(ctx, "AdminService: /on_subscribe callback failed", "operation_id", lro.OperationID, "callback_url", subReq.URL, "reason", failure.Reason, "error", err)
+		if updateErr := s.updateLROErrorData(ctx, lro, err, failure, model.LROStatusFailure); updateErr != nil {
 			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
 		}
 		return nil, err
@@ -272,9 +649,87 @@ func (s *adminService) onSubscribe(ctx context.Context, lro *model.LRO, subReq *
 	return onSubscribeResp, nil
 }
 
+// preflight fails the LRO if the participant's callback URL cannot be reached.
+func (s *adminService) preflight(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) error {
+	err := s.npClient.Probe(ctx, subReq.URL)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("network Participant callback URL unreachable: %w", err)
+	slog.WarnContext(ctx, "AdminService: Callback pre-flight check failed", "operation_id", lro.OperationID, "callback_url", subReq.URL, "error", err)
+	failure := &model.CallbackFailure{Error: s.errorMessage(err), Reason: model.CallbackFailureUnreachable}
+	if updateErr := s.updateLROErrorData(ctx, lro, err, failure, model.LROStatusFailure); updateErr != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+	}
+	return err
+}
+
+// callbackFailure returns the error data of an operation whose /on_subscribe callback failed with err,
+// telling a participant that answered with an error from one that could not be reached.
+func (s *adminService) callbackFailure(err error) *model.CallbackFailure {
+	failure := &model.CallbackFailure{Error: s.errorMessage(err), Reason: model.CallbackFailureNetwork}
+	var statusErr *client.CallbackStatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		failure.StatusCode = statusErr.StatusCode
+		failure.ParticipantError = truncateCallbackBody(statusErr.Body, s.cfg.CallbackErrorBodyBytes)
+		switch {
+		case statusErr.StatusCode >= 400 && statusErr.StatusCode < 500:
+			failure.Reason = model.CallbackFailure4XX
+		case statusErr.StatusCode >= 500 && statusErr.StatusCode < 600:
+			failure.Reason = model.CallbackFailure5XX
+		default:
+			failure.Reason = model.CallbackFailureInvalidResponse
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		failure.Reason = model.CallbackFailureTimeout
+	case errors.Is(err, client.ErrResponseTooLarge), errors.Is(err, client.ErrUnrecognizedChallengeResponse), isJSONError(err):
+		failure.Reason = model.CallbackFailureInvalidResponse
+	}
+	return failure
+}
+
+// truncateCallbackBody returns up to max bytes of body, DefaultCallbackErrorBodyBytes if max is 0,
+// without splitting a UTF-8 character.
+func truncateCallbackBody(body []byte, max int) string {
+	if max == 0 {
+		max = DefaultCallbackErrorBodyBytes
+	}
+	if len(body) <= max {
+		return string(body)
+	}
+	body = body[:max]
+	for len(body) > 0 && !utf8.Valid(body) {
+		body = body[:len(body)-1]
+	}
+	return string(body)
+}
+
+// errorMessage returns the message of err as stored on an operation, truncated to
+// MaxErrorMessageBytes and marked with errorTruncatedMarker if it is longer.
+func (s *adminService) errorMessage(err error) string {
+	max := s.cfg.MaxErrorMessageBytes
+	if max == 0 {
+		max = DefaultMaxErrorMessageBytes
+	}
+	msg := err.Error()
+	if len(msg) <= max {
+		return msg
+	}
+	return truncateCallbackBody([]byte(msg), max) + errorTruncatedMarker
+}
+
+// isJSONError reports whether err is caused by malformed JSON.
+func isJSONError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
 // verifyChallenge verifies the NP's answer to the challenge.
-func (s *adminService) verifyChallenge(ctx context.Context, lro *model.LRO, challenge, answer string) error {
-	if !s.chSrv.Verify(challenge, answer) {
+func (s *adminService) verifyChallenge(ctx context.Context, lro *model.LRO, policy ChallengePolicy, challenge, answer string) error {
+	if !s.chSrv.Verify(policy, challenge, answer) {
 		slog.WarnContext(ctx, "AdminService: Challenge mismatch from /on_subscribe response", "operation_id", lro.OperationID)
 		err := errors.New("challenge verification failed")
 		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusFailure); updateErr != nil {
@@ -286,11 +741,46 @@ func (s *adminService) verifyChallenge(ctx context.Context, lro *model.LRO, chal
 	return nil
 }
 
+// recordUpdateDiff logs the fields an update changes from prev and, if RecordUpdateDiff
+// is enabled, stores them as the result of lro.
+func (s *adminService) recordUpdateDiff(ctx context.Context, lro *model.LRO, prev *model.Subscription, subReq *model.SubscriptionRequest) {
+	changes := subscriptionDiff(prev, &subReq.Subscription)
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		fields[i] = c.Field
+	}
+	slog.InfoContext(ctx, "AdminService: Subscription update changes", "operation_id", lro.OperationID, "subscriber_id", subReq.SubscriberID, "changed_fields", fields)
+	if !s.cfg.RecordUpdateDiff {
+		return
+	}
+	result, err := json.Marshal(model.SubscriptionUpdateResult{Changes: changes})
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to marshal subscription update changes", "operation_id", lro.OperationID, "error", err)
+		return
+	}
+	lro.ResultJSON = result
+}
+
 // approve updates subscription and LRO status to approved/succeeded.
-func (s *adminService) approve(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest) (*model.Subscription, *model.LRO, error) {
+// prev is the subscription an update replaces, nil for a create.
+func (s *adminService) approve(ctx context.Context, lro *model.LRO, subReq *model.SubscriptionRequest, prev *model.Subscription) (*model.Subscription, *model.LRO, error) {
+	if prev != nil {
+		s.recordUpdateDiff(ctx, lro, prev, subReq)
+	}
 	subReq.Status = model.SubscriptionStatusSubscribed
+	expected := lro.Status
 	lro.Status = model.LROStatusApproved
-	sub, updatedLRO, err := s.regRepo.UpsertSubscriptionAndLRO(ctx, &subReq.Subscription, lro)
+	var sub *model.Subscription
+	var updatedLRO *model.LRO
+	var err error
+	if s.cfg.ConditionalApproval {
+		sub, updatedLRO, err = s.regRepo.UpsertSubscriptionAndLROIfStatus(ctx, &subReq.Subscription, lro, expected)
+	} else {
+		sub, updatedLRO, err = s.regRepo.UpsertSubscriptionAndLRO(ctx, &subReq.Subscription, lro)
+	}
+	if errors.Is(err, repository.ErrOperationConflict) {
+		return nil, s.reconcileConflict(ctx, lro, err), err
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminService: Failed to upsert subscription and update LRO", "operation_id", lro.OperationID, "error", err)
 		return nil, lro, err
@@ -304,20 +794,48 @@ func (s *adminService) approve(ctx context.Context, lro *model.LRO, subReq *mode
 	}
 	return sub, updatedLRO, nil
 }
+
+// reconcileConflict returns the stored LRO, whose status was changed concurrently with an approval
+// or rejection of lro failing with err. Its result is left as stored. It returns lro if the stored LRO cannot be read.
+func (s *adminService) reconcileConflict(ctx context.Context, lro *model.LRO, err error) *model.LRO {
+	slog.WarnContext(ctx, "AdminService: LRO changed during approval, not overwriting it", "operation_id", lro.OperationID, "error", err)
+	stored, getErr := s.regRepo.GetOperation(ctx, lro.OperationID)
+	if getErr != nil || stored == nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to get LRO after approval conflict", "operation_id", lro.OperationID, "error", getErr)
+		return lro
+	}
+	return stored
+}
+
 func (s *adminService) updateLROError(ctx context.Context, lro *model.LRO, originalErr error, status model.LROStatus) error {
-	errorPayload := map[string]string{"error": originalErr.Error()}
+	return s.updateLROErrorData(ctx, lro, originalErr, map[string]string{"error": s.errorMessage(originalErr)}, status)
+}
+
+// updateLROErrorData is updateLROError storing errorPayload as the error data of lro.
+func (s *adminService) updateLROErrorData(ctx context.Context, lro *model.LRO, originalErr error, errorPayload any, status model.LROStatus) error {
 	errJson, marshalErr := json.Marshal(errorPayload)
 	if marshalErr != nil {
 		slog.ErrorContext(ctx, "AdminService:updateLROError - failed to marshal error", "error", marshalErr)
 		return fmt.Errorf("AdminService:updateLROError - failed to marshal error : %w", marshalErr)
 	}
+	expected := lro.Status
 	lro.ErrorDataJSON = errJson
 	lro.RetryCount++
 	lro.Status = status
 	if lro.RetryCount > s.cfg.OperationRetryMax {
 		lro.Status = model.LROStatusRejected
 	}
-	_, updateErr := s.regRepo.UpdateOperation(ctx, lro)
+	var updateErr error
+	if s.cfg.ConditionalApproval {
+		_, updateErr = s.regRepo.UpdateOperationIfStatus(ctx, lro, expected)
+	} else {
+		_, updateErr = s.regRepo.UpdateOperation(ctx, lro)
+	}
+	if errors.Is(updateErr, repository.ErrOperationConflict) {
+		// A concurrent approval stored its result first; lro reflects it instead of this failure.
+		*lro = *s.reconcileConflict(ctx, lro, updateErr)
+		return nil
+	}
 	if updateErr != nil {
 		slog.ErrorContext(ctx, "AdminService: CRITICAL ERROR - Failed to update LRO with error status after original failure", "operation_id", lro.OperationID, "original_error", originalErr, "update_error", updateErr)
 		// If this fails, we're in a bad state, but we should still return the original processing error.
@@ -333,9 +851,9 @@ func (s *adminService) RejectSubscription(ctx context.Context, req *model.Operat
 		slog.ErrorContext(ctx, "AdminService: OperationActionRequest cannot be nil")
 		return nil, errors.New("OperationActionRequest cannot be nil")
 	}
-	if req.OperationID == "" {
-		slog.ErrorContext(ctx, "AdminService: OperationID cannot be empty")
-		return nil, errors.New("OperationID cannot be empty")
+	if err := s.cfg.OperationIDFormat.validate(req.OperationID); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Malformed OperationID", "error", err)
+		return nil, err
 	}
 	if req.Reason == "" {
 		slog.ErrorContext(ctx, "AdminService: Reason cannot be empty")
@@ -350,6 +868,7 @@ func (s *adminService) RejectSubscription(ctx context.Context, req *model.Operat
 	if err != nil {
 		return nil, err
 	}
+	expected := lro.Status
 	lro.Status = model.LROStatusRejected
 	errorPayload := map[string]string{"reason": reason}
 	resJson, err := json.Marshal(errorPayload)
@@ -359,7 +878,15 @@ func (s *adminService) RejectSubscription(ctx context.Context, req *model.Operat
 	}
 	lro.ErrorDataJSON = resJson
 
-	updatedLRO, err := s.regRepo.UpdateOperation(ctx, lro)
+	var updatedLRO *model.LRO
+	if s.cfg.ConditionalApproval {
+		updatedLRO, err = s.regRepo.UpdateOperationIfStatus(ctx, lro, expected)
+	} else {
+		updatedLRO, err = s.regRepo.UpdateOperation(ctx, lro)
+	}
+	if errors.Is(err, repository.ErrOperationConflict) {
+		return s.reconcileConflict(ctx, lro, err), err
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "AdminService:RejectSubscription - Failed to update LRO", "operation_id", lro.OperationID, "error", err)
 		return nil, fmt.Errorf("AdminService:RejectSubscription - failed to update LRO error: %w", err)
@@ -371,3 +898,35 @@ func (s *adminService) RejectSubscription(ctx context.Context, req *model.Operat
 	}
 	return updatedLRO, nil
 }
+
+// RejectStaleOperations rejects the operations that stayed PENDING longer than AdminConfig.StaleOperationAge
+// with the STALE reason, so that participants who abandoned their onboarding get a final status.
+// It is a SweepFunc run by the sweeper, and does nothing if StaleOperationAge is not set.
+func (s *adminService) RejectStaleOperations(ctx context.Context) error {
+	age := s.cfg.StaleOperationAge
+	if age <= 0 {
+		return nil
+	}
+	errData, err := json.Marshal(map[string]string{
+		"error":  fmt.Sprintf("operation was not approved or rejected within %s", age),
+		"reason": staleOperationReason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stale operation error data: %w", err)
+	}
+	lros, err := s.regRepo.RejectStaleOperations(ctx, time.Now().Add(-age), errData)
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to reject stale operations", "error", err)
+		return fmt.Errorf("failed to reject stale operations: %w", err)
+	}
+	for i := range lros {
+		lro := &lros[i]
+		slog.InfoContext(ctx, "AdminService: Rejected stale operation", "operation_id", lro.OperationID, "created_at", lro.CreatedAt)
+		if evID, err := s.evPublisher.PublishSubscriptionRequestRejectedEvent(ctx, lro); err != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to publish subscription rejected event", "operation_id", lro.OperationID, "error", err)
+		} else {
+			slog.InfoContext(ctx, "AdminService: Published subscription rejected event", "operation_id", lro.OperationID, "event_id", evID)
+		}
+	}
+	return nil
+}
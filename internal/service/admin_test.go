@@ -19,10 +19,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
@@ -52,46 +55,96 @@ type mockRegRepo struct {
 	lookupSubsToReturn          []model.Subscription
 	lookupErr                   error
 	updatedLROToReturn          *model.LRO // For UpdateOperation and Upsert
+	claimErr                    error
+	lookupFn                    func(sub *model.Subscription) ([]model.Subscription, error) // Overrides lookupSubsToReturn and lookupErr if set.
+	getOperationCalls           int
+	gotUpsertLRO                *model.LRO
+	gotUpsertExpectedStatus     model.LROStatus // Set by UpsertSubscriptionAndLROIfStatus.
+	rejectStaleErr              error
 }
 
 func (m *mockRegRepo) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	m.getOperationCalls++
 	return m.lroToReturn, m.getOperationErr
 }
 
+func (m *mockRegRepo) ClaimNextPendingOperation(ctx context.Context) (*model.LRO, error) {
+	return m.lroToReturn, m.claimErr
+}
+
+func (m *mockRegRepo) ClaimOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	return m.lroToReturn, m.claimErr
+}
+
+func (m *mockRegRepo) ClaimStaleOperation(ctx context.Context, claimedBefore time.Time) (*model.LRO, error) {
+	return nil, repository.ErrNoPendingOperation
+}
+
 func (m *mockRegRepo) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
 	return m.updatedLROToReturn, m.updateOperationErr
 }
 
+func (m *mockRegRepo) UpdateOperationIfStatus(ctx context.Context, lro *model.LRO, expected model.LROStatus) (*model.LRO, error) {
+	return m.UpdateOperation(ctx, lro)
+}
+
 func (m *mockRegRepo) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	m.gotUpsertLRO = lro
 	return m.subToReturn, m.updatedLROToReturn, m.upsertSubscriptionAndLROErr
 }
 
+func (m *mockRegRepo) UpsertSubscriptionAndLROIfStatus(ctx context.Context, sub *model.Subscription, lro *model.LRO, expected model.LROStatus) (*model.Subscription, *model.LRO, error) {
+	m.gotUpsertExpectedStatus = expected
+	return m.UpsertSubscriptionAndLRO(ctx, sub, lro)
+}
+
+func (m *mockRegRepo) RejectStaleOperations(ctx context.Context, createdBefore time.Time, errorDataJSON []byte) ([]model.LRO, error) {
+	return nil, m.rejectStaleErr
+}
+
 func (m *mockRegRepo) Lookup(ctx context.Context, sub *model.Subscription) ([]model.Subscription, error) {
+	if m.lookupFn != nil {
+		return m.lookupFn(sub)
+	}
 	return m.lookupSubsToReturn, m.lookupErr
 }
 
 // mockChallengeSrv is a mock implementation of challengeSrv.
 type mockChallengeSrv struct {
+	mu                sync.Mutex
 	challengeToReturn string
 	newChallengeErr   error
 	verifyResult      bool
+	gotNewPolicy      ChallengePolicy
+	gotVerifyPolicy   ChallengePolicy
 }
 
-func (m *mockChallengeSrv) NewChallenge() (string, error) {
+func (m *mockChallengeSrv) NewChallenge(policy ChallengePolicy) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gotNewPolicy = policy
 	return m.challengeToReturn, m.newChallengeErr
 }
 
-func (m *mockChallengeSrv) Verify(challenge, answer string) bool {
+func (m *mockChallengeSrv) Verify(policy ChallengePolicy, challenge, answer string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gotVerifyPolicy = policy
 	return m.verifyResult
 }
 
 // mockEncryptionSrv is a mock implementation of encrypter.
 type mockEncryptionSrv struct {
+	mu                    sync.Mutex
 	encryptedDataToReturn string
 	encryptErr            error
+	gotNPKey              string
 }
 
 func (m *mockEncryptionSrv) Encrypt(ctx context.Context, data string, npKey string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gotNPKey = npKey
 	return m.encryptedDataToReturn, m.encryptErr
 }
 
@@ -99,12 +152,21 @@ func (m *mockEncryptionSrv) Encrypt(ctx context.Context, data string, npKey stri
 type mockNPClient struct {
 	onSubscribeResponseToReturn *model.OnSubscribeResponse
 	onSubscribeErr              error
+	onSubscribeCalled           bool
+	probeErr                    error
+	probedURL                   string
 }
 
 func (m *mockNPClient) OnSubscribe(ctx context.Context, callbackURL string, request *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
+	m.onSubscribeCalled = true
 	return m.onSubscribeResponseToReturn, m.onSubscribeErr
 }
 
+func (m *mockNPClient) Probe(ctx context.Context, baseURL string) error {
+	m.probedURL = baseURL
+	return m.probeErr
+}
+
 func TestNewAdminService_Success(t *testing.T) {
 	cfg := &AdminConfig{OperationRetryMax: 3}
 	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
@@ -134,6 +196,11 @@ func TestNewAdminService_Error(t *testing.T) {
 		{"nil eventPublisher", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, validCfg, nil, "eventPublisher cannot be nil"},
 		{"nil AdminConfig", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, nil, &mockAdminEventPublisher{}, "AdminConfig cannot be nil"},
 		{"invalid AdminConfig", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, invalidCfg, &mockAdminEventPublisher{}, "AdminConfig.OperationRetryMax cannot be zero or negative"},
+		{"negative CallbackErrorBodyBytes", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &AdminConfig{OperationRetryMax: 3, CallbackErrorBodyBytes: -1}, &mockAdminEventPublisher{}, "AdminConfig.CallbackErrorBodyBytes cannot be negative"},
+		{"negative MaxErrorMessageBytes", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &AdminConfig{OperationRetryMax: 3, MaxErrorMessageBytes: -1}, &mockAdminEventPublisher{}, "AdminConfig.MaxErrorMessageBytes cannot be negative"},
+		{"negative StaleOperationAge", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &AdminConfig{OperationRetryMax: 3, StaleOperationAge: -time.Hour}, &mockAdminEventPublisher{}, "AdminConfig.StaleOperationAge cannot be negative"},
+		{"unknown ApprovalMode", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &AdminConfig{OperationRetryMax: 3, ApprovalMode: "LATER"}, &mockAdminEventPublisher{}, `AdminConfig.ApprovalMode "LATER" must be one of SYNC, ASYNC`},
+		{"negative ApprovalWorkers", &mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &AdminConfig{OperationRetryMax: 3, ApprovalWorkers: -1}, &mockAdminEventPublisher{}, "AdminConfig.ApprovalWorkers and ApprovalQueueSize cannot be negative"},
 	}
 
 	for _, tt := range tests {
@@ -566,171 +633,1642 @@ func TestAdminService_ApproveSubscription_Error(t *testing.T) {
 	}
 }
 
-func TestAdminService_RejectSubscription_Success(t *testing.T) {
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestAdminService_ApproveSubscription_CallbackFailureReason(t *testing.T) {
 	ctx := context.Background()
-	opID := "test-op-reject-success"
-	reason := "Admin rejected"
-	now := time.Now()
-	subReq := &model.SubscriptionRequest{MessageID: opID}
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	}
 	subReqJSON, _ := json.Marshal(subReq)
 
-	initialLRO := &model.LRO{
-		OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending,
-		RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now, RetryCount: 0,
+	tests := []struct {
+		name      string
+		npErr     error
+		bodyBytes int
+		want      model.CallbackFailure
+	}{
+		{
+			name:  "4xx status",
+			npErr: &client.CallbackStatusError{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":"bad challenge"}`)},
+			want: model.CallbackFailure{
+				Error:            "network Participant /on_subscribe callback failed: NP callback failed with status 400",
+				Reason:           model.CallbackFailure4XX,
+				StatusCode:       http.StatusBadRequest,
+				ParticipantError: `{"error":"bad challenge"}`,
+			},
+		},
+		{
+			name:  "5xx status",
+			npErr: &client.CallbackStatusError{StatusCode: http.StatusServiceUnavailable, Body: []byte("maintenance")},
+			want: model.CallbackFailure{
+				Error:            "network Participant /on_subscribe callback failed: NP callback failed with status 503",
+				Reason:           model.CallbackFailure5XX,
+				StatusCode:       http.StatusServiceUnavailable,
+				ParticipantError: "maintenance",
+			},
+		},
+		{
+			name:      "participant error truncated",
+			npErr:     &client.CallbackStatusError{StatusCode: http.StatusInternalServerError, Body: []byte("stack trace")},
+			bodyBytes: 5,
+			want: model.CallbackFailure{
+				Error:            "network Participant /on_subscribe callback failed: NP callback failed with status 500",
+				Reason:           model.CallbackFailure5XX,
+				StatusCode:       http.StatusInternalServerError,
+				ParticipantError: "stack",
+			},
+		},
+		{
+			name:  "unexpected status",
+			npErr: &client.CallbackStatusError{StatusCode: http.StatusFound},
+			want: model.CallbackFailure{
+				Error:      "network Participant /on_subscribe callback failed: NP callback failed with status 302",
+				Reason:     model.CallbackFailureInvalidResponse,
+				StatusCode: http.StatusFound,
+			},
+		},
+		{
+			name:  "deadline exceeded",
+			npErr: fmt.Errorf("HTTP request to NP failed: %w", context.DeadlineExceeded),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: HTTP request to NP failed: context deadline exceeded",
+				Reason: model.CallbackFailureTimeout,
+			},
+		},
+		{
+			name:  "network timeout",
+			npErr: fmt.Errorf("HTTP request to NP failed: %w", timeoutError{}),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: HTTP request to NP failed: i/o timeout",
+				Reason: model.CallbackFailureTimeout,
+			},
+		},
+		{
+			name:  "connection refused",
+			npErr: fmt.Errorf("HTTP request to NP failed: %w", errors.New("connection refused")),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: HTTP request to NP failed: connection refused",
+				Reason: model.CallbackFailureNetwork,
+			},
+		},
+		{
+			name:  "response too large",
+			npErr: fmt.Errorf("%w: body exceeds 10 bytes", client.ErrResponseTooLarge),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: NP response too large: body exceeds 10 bytes",
+				Reason: model.CallbackFailureInvalidResponse,
+			},
+		},
+		{
+			name:  "no answer",
+			npErr: fmt.Errorf("%w: expected the answer in answer", client.ErrUnrecognizedChallengeResponse),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: unrecognized on_subscribe response: expected the answer in answer",
+				Reason: model.CallbackFailureInvalidResponse,
+			},
+		},
+		{
+			name:  "malformed JSON",
+			npErr: fmt.Errorf("failed to decode NP response: %w", json.Unmarshal([]byte("{"), &struct{}{})),
+			want: model.CallbackFailure{
+				Error:  "network Participant /on_subscribe callback failed: failed to decode NP response: unexpected end of JSON input",
+				Reason: model.CallbackFailureInvalidResponse,
+			},
+		},
 	}
 
-	rejectedLRO := &model.LRO{
-		OperationID: opID,
-		Type:        model.OperationTypeCreateSubscription,
-		Status:      model.LROStatusRejected,
-		RequestJSON: subReqJSON,
-		ErrorDataJSON: func() []byte {
-			b, _ := json.Marshal(map[string]string{"reason": reason})
-			return b
-		}(),
-		CreatedAt:  now,
-		UpdatedAt:  now,
-		RetryCount: 0,
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123"}
+			mockNpCli := &mockNPClient{onSubscribeErr: tt.npErr}
+			cfg := &AdminConfig{OperationRetryMax: 3, CallbackErrorBodyBytes: tt.bodyBytes}
+			service, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"}); !errors.Is(err, tt.npErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.npErr)
+			}
+			if lro.Status != model.LROStatusFailure {
+				t.Errorf("LRO status = %s, want %s", lro.Status, model.LROStatusFailure)
+			}
+			var got model.CallbackFailure
+			if err := json.Unmarshal(lro.ErrorDataJSON, &got); err != nil {
+				t.Fatalf("json.Unmarshal(ErrorDataJSON) error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ErrorDataJSON mismatch (-want +got):\n%s", diff)
+			}
+		})
 	}
+}
 
-	mockRepo := &mockRegRepo{
-		lroToReturn:        initialLRO,
-		updatedLROToReturn: rejectedLRO,
+func TestAdminService_ApproveSubscription_CallbackPreflight(t *testing.T) {
+	ctx := context.Background()
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
 	}
-	cfg := &AdminConfig{OperationRetryMax: 3}
-	service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	subReqJSON, _ := json.Marshal(subReq)
+	probeErr := errors.New("HTTP probe of NP failed: connection refused")
 
-	req := &model.OperationActionRequest{OperationID: opID, Reason: reason}
-	gotLRO, err := service.RejectSubscription(ctx, req)
-	if err != nil {
-		t.Fatalf("RejectSubscription() error = %v, wantErr nil", err)
+	tests := []struct {
+		name            string
+		preflight       bool
+		probeErr        error
+		wantProbed      bool
+		wantErr         error
+		wantOnSubscribe bool
+	}{
+		{name: "reachable proceeds", preflight: true, wantProbed: true, wantOnSubscribe: true},
+		{name: "unreachable fails", preflight: true, probeErr: probeErr, wantProbed: true, wantErr: probeErr},
+		{name: "disabled", probeErr: probeErr, wantOnSubscribe: true},
 	}
-	if diff := cmp.Diff(rejectedLRO, gotLRO); diff != "" {
-		t.Errorf("RejectSubscription() LRO mismatch (-want +got):\n%s", diff)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{lroToReturn: lro, subToReturn: &model.Subscription{}, updatedLROToReturn: lro}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}, probeErr: tt.probeErr}
+			cfg := &AdminConfig{OperationRetryMax: 3, CallbackPreflight: tt.preflight}
+			service, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			_, _, err = service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := mockNpCli.probedURL != ""; got != tt.wantProbed {
+				t.Errorf("probed = %v, want %v", got, tt.wantProbed)
+			}
+			if tt.wantProbed && mockNpCli.probedURL != "http://np.com" {
+				t.Errorf("probed URL = %q, want %q", mockNpCli.probedURL, "http://np.com")
+			}
+			if mockNpCli.onSubscribeCalled != tt.wantOnSubscribe {
+				t.Errorf("OnSubscribe called = %v, want %v", mockNpCli.onSubscribeCalled, tt.wantOnSubscribe)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if lro.Status != model.LROStatusFailure {
+				t.Errorf("LRO status = %s, want %s", lro.Status, model.LROStatusFailure)
+			}
+			var got model.CallbackFailure
+			if err := json.Unmarshal(lro.ErrorDataJSON, &got); err != nil {
+				t.Fatalf("json.Unmarshal(ErrorDataJSON) error = %v", err)
+			}
+			want := model.CallbackFailure{
+				Error:  "network Participant callback URL unreachable: HTTP probe of NP failed: connection refused",
+				Reason: model.CallbackFailureUnreachable,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("ErrorDataJSON mismatch (-want +got):\n%s", diff)
+			}
+		})
 	}
 }
 
-func TestAdminService_RejectSubscription_EventPublishError(t *testing.T) {
+func TestAdminService_ErrorMessageTruncated(t *testing.T) {
 	ctx := context.Background()
-	opID := "test-op-reject-event-error"
-	reason := "Admin rejected"
-	now := time.Now()
-	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{MessageID: opID})
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	longErr := errors.New(strings.Repeat("x", 100))
 
-	initialLRO := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now}
-	rejectedLRO := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusRejected, RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now}
+	t.Run("callback failure keeps its reason", func(t *testing.T) {
+		lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+		mockNpCli := &mockNPClient{onSubscribeErr: &client.CallbackStatusError{StatusCode: http.StatusBadGateway, Body: []byte(longErr.Error())}}
+		cfg := &AdminConfig{OperationRetryMax: 3, MaxErrorMessageBytes: 20}
+		service, err := NewAdminService(&mockRegRepo{lroToReturn: lro}, &mockChallengeSrv{challengeToReturn: "challenge123"}, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+		if err != nil {
+			t.Fatalf("NewAdminService() error = %v", err)
+		}
 
-	mockRepo := &mockRegRepo{lroToReturn: initialLRO, updatedLROToReturn: rejectedLRO}
-	mockEvPub := &mockAdminEventPublisher{err: errors.New("event publish failed")} // Simulate event publish error
-	cfg := &AdminConfig{OperationRetryMax: 3}
+		if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"}); err == nil {
+			t.Fatal("ApproveSubscription() error = nil, want the callback error")
+		}
+		var got model.CallbackFailure
+		if err := json.Unmarshal(lro.ErrorDataJSON, &got); err != nil {
+			t.Fatalf("json.Unmarshal(ErrorDataJSON) error = %v", err)
+		}
+		want := model.CallbackFailure{
+			Error:            "network Participant ...",
+			Reason:           model.CallbackFailure5XX,
+			StatusCode:       http.StatusBadGateway,
+			ParticipantError: longErr.Error(),
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ErrorDataJSON mismatch (-want +got):\n%s", diff)
+		}
+	})
 
-	service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, mockEvPub, cfg)
+	t.Run("error message", func(t *testing.T) {
+		lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+		cfg := &AdminConfig{OperationRetryMax: 3, MaxErrorMessageBytes: 20}
+		service, err := NewAdminService(&mockRegRepo{lroToReturn: lro, lookupErr: longErr}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+		if err != nil {
+			t.Fatalf("NewAdminService() error = %v", err)
+		}
 
-	req := &model.OperationActionRequest{OperationID: opID, Reason: reason}
-	_, err := service.RejectSubscription(ctx, req)
-	if err != nil {
-		t.Fatalf("RejectSubscription() unexpected error: %v", err)
-	}
-	// The error is logged, not returned, so we just ensure the function completes without panicking.
+		if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"}); !errors.Is(err, longErr) {
+			t.Fatalf("ApproveSubscription() error = %v, want the full %v", err, longErr)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(lro.ErrorDataJSON, &got); err != nil {
+			t.Fatalf("json.Unmarshal(ErrorDataJSON) error = %v", err)
+		}
+		if want := "lookup failed: xxxxx..."; got["error"] != want {
+			t.Errorf("stored error = %q, want %q", got["error"], want)
+		}
+	})
 }
 
-func TestAdminService_RejectSubscription_Error(t *testing.T) {
-	ctx := context.Background()
-	opID := "test-op-reject-error"
-	reason := "Admin rejected"
-	now := time.Now()
-	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{MessageID: opID})
+func TestAdminService_ErrorMessage_Default(t *testing.T) {
+	service, _ := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+	long := strings.Repeat("a", DefaultMaxErrorMessageBytes+1)
 
-	baseLRO := func() *model.LRO {
-		return &model.LRO{
-			OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending,
-			RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now, RetryCount: 0,
-		}
+	if got, want := service.errorMessage(errors.New(long)), long[:DefaultMaxErrorMessageBytes]+errorTruncatedMarker; got != want {
+		t.Errorf("errorMessage() = %d bytes, want %d bytes", len(got), len(want))
 	}
+	if got := service.errorMessage(errors.New("short")); got != "short" {
+		t.Errorf("errorMessage() = %q, want %q", got, "short")
+	}
+}
 
+func TestTruncateCallbackBody(t *testing.T) {
 	tests := []struct {
-		name               string
-		mockRepoSetup      func(*mockRegRepo)
-		adminCfg           *AdminConfig
-		wantErrMsgContains string
-		req                *model.OperationActionRequest
+		name string
+		body string
+		max  int
+		want string
 	}{
-		{
-			name: "LRO not found",
-			mockRepoSetup: func(m *mockRegRepo) {
-				m.getOperationErr = repository.ErrOperationNotFound
-			},
-			wantErrMsgContains: "failed to get LRO: operation not found",
-			req:                &model.OperationActionRequest{OperationID: "nonexistent-op", Reason: reason},
+		{name: "within limit", body: "error", max: 10, want: "error"},
+		{name: "truncated", body: "error body", max: 5, want: "error"},
+		{name: "default limit", body: strings.Repeat("a", DefaultCallbackErrorBodyBytes+1), want: strings.Repeat("a", DefaultCallbackErrorBodyBytes)},
+		{name: "multi-byte character kept whole", body: "ab\u00e9", max: 3, want: "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateCallbackBody([]byte(tt.body), tt.max); got != tt.want {
+				t.Errorf("truncateCallbackBody(%q, %d) = %q, want %q", tt.body, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_UniqueCallbackURL(t *testing.T) {
+	ctx := context.Background()
+	subReq := &model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
 		},
+	}
+	subReqJSON, _ := json.Marshal(subReq)
+	lookupErr := errors.New("db error")
+
+	tests := []struct {
+		name       string
+		enforce    bool
+		urlSubs    []model.Subscription
+		urlErr     error
+		wantErr    error
+		wantStatus model.LROStatus
+	}{
 		{
-			name: "Max retries exceeded (though not directly applicable to reject, lro() check is common)",
-			mockRepoSetup: func(m *mockRegRepo) {
-				lro := baseLRO()
-				lro.RetryCount = 5
-				m.lroToReturn = lro
-			},
-			adminCfg:           &AdminConfig{OperationRetryMax: 3},
-			wantErrMsgContains: "max retries exceeded for operation",
-			req:                &model.OperationActionRequest{OperationID: opID, Reason: reason},
+			name:    "unique URL is accepted",
+			enforce: true,
 		},
 		{
-			name: "Failed to update LRO during rejection",
-			mockRepoSetup: func(m *mockRegRepo) {
-				m.lroToReturn = baseLRO()
-				m.updateOperationErr = errors.New("db update failed for reject")
-			},
-			wantErrMsgContains: "failed to update LRO error: db update failed for reject",
-			req:                &model.OperationActionRequest{OperationID: opID, Reason: reason},
+			name:    "URL used by the same subscriber is accepted",
+			enforce: true,
+			urlSubs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1", Type: model.RoleBPP}}},
 		},
 		{
-			name: "nil OperationActionRequest",
-			mockRepoSetup: func(m *mockRegRepo) {
-				m.lroToReturn = baseLRO()
-			},
-			req:                nil, // Explicitly test nil request
-			wantErrMsgContains: "OperationActionRequest cannot be nil",
+			name:       "URL used by another subscriber is rejected",
+			enforce:    true,
+			urlSubs:    []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub2"}}},
+			wantErr:    ErrCallbackURLConflict,
+			wantStatus: model.LROStatusRejected,
 		},
 		{
-			name: "empty OperationID",
-			mockRepoSetup: func(m *mockRegRepo) {
-				m.lroToReturn = baseLRO()
-			},
-			wantErrMsgContains: "OperationID cannot be empty",
-			req:                &model.OperationActionRequest{OperationID: "", Reason: reason},
+			name:       "URL lookup fails",
+			enforce:    true,
+			urlErr:     lookupErr,
+			wantErr:    lookupErr,
+			wantStatus: model.LROStatusFailure,
 		},
 		{
-			name: "empty Reason",
-			mockRepoSetup: func(m *mockRegRepo) {
-				m.lroToReturn = baseLRO()
-			},
-			wantErrMsgContains: "reason cannot be empty",
-			req:                &model.OperationActionRequest{OperationID: opID, Reason: ""},
+			name:    "shared URL is accepted when the check is disabled",
+			urlSubs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub2"}}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := &mockRegRepo{}
-			if tt.mockRepoSetup != nil {
-				tt.mockRepoSetup(mockRepo)
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			var urlLookups int
+			mockRepo := &mockRegRepo{
+				lroToReturn:        lro,
+				subToReturn:        &model.Subscription{},
+				updatedLROToReturn: &model.LRO{OperationID: "op1", Status: model.LROStatusApproved},
+				lookupFn: func(filter *model.Subscription) ([]model.Subscription, error) {
+					if filter.URL == "" {
+						return nil, nil // Existence check of the subscription itself.
+					}
+					urlLookups++
+					want := &model.Subscription{
+						Subscriber: model.Subscriber{URL: "http://np.com", Domain: "retail"},
+						Status:     model.SubscriptionStatusSubscribed,
+					}
+					if diff := cmp.Diff(want, filter); diff != "" {
+						t.Errorf("Lookup() filter mismatch (-want +got):\n%s", diff)
+					}
+					return tt.urlSubs, tt.urlErr
+				},
 			}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, EnforceUniqueCallbackURL: tt.enforce}
+			service, _ := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
 
-			cfg := tt.adminCfg
-			if cfg == nil {
-				cfg = &AdminConfig{OperationRetryMax: 3}
+			_, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
 			}
-
-			service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
-
-			_, err := service.RejectSubscription(ctx, tt.req)
-
-			if err == nil {
-				t.Fatalf("RejectSubscription() error = nil, want error containing %q", tt.wantErrMsgContains)
+			if tt.wantStatus != "" && lro.Status != tt.wantStatus {
+				t.Errorf("LRO status = %s, want %s", lro.Status, tt.wantStatus)
 			}
-			if !strings.Contains(err.Error(), tt.wantErrMsgContains) {
-				t.Errorf("RejectSubscription() error = %q, want error containing %q", err.Error(), tt.wantErrMsgContains)
+			if !tt.enforce && urlLookups != 0 {
+				t.Errorf("callback URL lookups = %d with the check disabled, want 0", urlLookups)
 			}
 		})
 	}
 }
+
+func TestAdminService_ApproveSubscription_ResolveMissingEncrPublicKey(t *testing.T) {
+	ctx := context.Background()
+	lookupErr := errors.New("db error")
+
+	tests := []struct {
+		name        string
+		resolve     bool
+		reqKey      string
+		registered  []model.Subscription
+		lookupErr   error
+		wantKey     string
+		wantLookups int
+		wantErr     error
+		wantStatus  model.LROStatus
+	}{
+		{
+			name:    "request key is used",
+			resolve: true,
+			reqKey:  "req-encr-key",
+			wantKey: "req-encr-key",
+		},
+		{
+			name:        "registered key is used when the request omits it",
+			resolve:     true,
+			registered:  []model.Subscription{{EncrPublicKey: ""}, {EncrPublicKey: "registered-encr-key"}},
+			wantKey:     "registered-encr-key",
+			wantLookups: 1,
+		},
+		{
+			name:        "rejected when both are absent",
+			resolve:     true,
+			wantLookups: 1,
+			wantStatus:  model.LROStatusRejected,
+		},
+		{
+			name:        "registered key lookup fails",
+			resolve:     true,
+			lookupErr:   lookupErr,
+			wantLookups: 1,
+			wantErr:     lookupErr,
+			wantStatus:  model.LROStatusFailure,
+		},
+		{
+			name:       "rejected without lookup when resolution is disabled",
+			registered: []model.Subscription{{EncrPublicKey: "registered-encr-key"}},
+			wantStatus: model.LROStatusRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReq := &model.SubscriptionRequest{
+				Subscription: model.Subscription{
+					Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+					KeyID:         "key1",
+					EncrPublicKey: tt.reqKey,
+				},
+			}
+			subReqJSON, _ := json.Marshal(subReq)
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			var keyLookups int
+			mockRepo := &mockRegRepo{
+				lroToReturn:        lro,
+				subToReturn:        &model.Subscription{},
+				updatedLROToReturn: &model.LRO{OperationID: "op1", Status: model.LROStatusApproved},
+				lookupFn: func(filter *model.Subscription) ([]model.Subscription, error) {
+					if filter.KeyID == "" {
+						return nil, nil // Existence check of the subscription itself.
+					}
+					keyLookups++
+					want := &model.Subscription{
+						Subscriber: model.Subscriber{SubscriberID: "sub1", Type: model.RoleBAP, Domain: "retail"},
+						KeyID:      "key1",
+					}
+					if diff := cmp.Diff(want, filter); diff != "" {
+						t.Errorf("Lookup() filter mismatch (-want +got):\n%s", diff)
+					}
+					return tt.registered, tt.lookupErr
+				},
+			}
+			encryptor := &mockEncryptionSrv{}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, ResolveMissingEncrPublicKey: tt.resolve}
+			service, _ := NewAdminService(mockRepo, mockChSrv, encryptor, mockNpCli, &mockAdminEventPublisher{}, cfg)
+
+			_, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantStatus != "":
+				if err == nil {
+					t.Fatal("ApproveSubscription() error = nil, want an error")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+				}
+			}
+			if tt.wantStatus != "" && lro.Status != tt.wantStatus {
+				t.Errorf("LRO status = %s, want %s", lro.Status, tt.wantStatus)
+			}
+			if encryptor.gotNPKey != tt.wantKey {
+				t.Errorf("challenge encrypted with key %q, want %q", encryptor.gotNPKey, tt.wantKey)
+			}
+			if keyLookups != tt.wantLookups {
+				t.Errorf("registered key lookups = %d, want %d", keyLookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_AllowedSubscriberDomains(t *testing.T) {
+	ctx := context.Background()
+	allowed := []string{"example.org", ".partner.net"}
+
+	tests := []struct {
+		name         string
+		subscriberID string
+		allowed      []string
+		wantErr      error
+		wantStatus   model.LROStatus
+	}{
+		{
+			name:         "subdomain of an allowed domain",
+			subscriberID: "bap.example.org",
+			allowed:      allowed,
+			wantStatus:   model.LROStatusApproved,
+		},
+		{
+			name:         "allowed domain itself, different case",
+			subscriberID: "Partner.NET",
+			allowed:      allowed,
+			wantStatus:   model.LROStatusApproved,
+		},
+		{
+			name:         "URL subscriber id under an allowed domain",
+			subscriberID: "https://bpp.example.org:8443/beckn",
+			allowed:      allowed,
+			wantStatus:   model.LROStatusApproved,
+		},
+		{
+			name:         "outside the allowed domains",
+			subscriberID: "bap.other.com",
+			allowed:      allowed,
+			wantErr:      ErrSubscriberDomainNotAllowed,
+			wantStatus:   model.LROStatusRejected,
+		},
+		{
+			name:         "suffix without a dot boundary",
+			subscriberID: "bapexample.org",
+			allowed:      allowed,
+			wantErr:      ErrSubscriberDomainNotAllowed,
+			wantStatus:   model.LROStatusRejected,
+		},
+		{
+			name:         "any subscriber id when no domains are configured",
+			subscriberID: "bap.other.com",
+			wantStatus:   model.LROStatusApproved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+				Subscription: model.Subscription{
+					Subscriber:    model.Subscriber{SubscriberID: tt.subscriberID, URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+					EncrPublicKey: "np-encr-pub-key",
+				},
+			})
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON}
+			mockRepo := &mockRegRepo{
+				lroToReturn:        lro,
+				subToReturn:        &model.Subscription{},
+				updatedLROToReturn: &model.LRO{OperationID: "op1", Status: model.LROStatusApproved},
+			}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, AllowedSubscriberDomains: tt.allowed}
+			service, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			_, gotLRO, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if gotLRO.Status != tt.wantStatus {
+					t.Errorf("ApproveSubscription() LRO status = %s, want %s", gotLRO.Status, tt.wantStatus)
+				}
+				return
+			}
+			if lro.Status != tt.wantStatus {
+				t.Errorf("LRO status = %s, want %s", lro.Status, tt.wantStatus)
+			}
+			if !strings.Contains(string(lro.ErrorDataJSON), tt.subscriberID) {
+				t.Errorf("LRO error data = %s, want it to name subscriber_id %q", lro.ErrorDataJSON, tt.subscriberID)
+			}
+			if mockNpCli.onSubscribeCalled {
+				t.Error("OnSubscribe() called for an out-of-policy subscriber")
+			}
+		})
+	}
+}
+
+func TestNewAdminService_EmptyAllowedSubscriberDomain(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, AllowedSubscriberDomains: []string{"example.org", "."}}
+	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "AllowedSubscriberDomains") {
+		t.Errorf("NewAdminService() error = %v, want error about AllowedSubscriberDomains", err)
+	}
+}
+
+func TestNewAdminService_UnknownOperationIDFormat(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, OperationIDFormat: "ULID"}
+	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "OperationIDFormat") {
+		t.Errorf("NewAdminService() error = %v, want error about OperationIDFormat", err)
+	}
+}
+
+func TestAdminService_OperationIDFormat(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		format      OperationIDFormat
+		operationID string
+		wantErr     error
+	}{
+		{name: "any id by default", operationID: "msg-123", wantErr: repository.ErrOperationNotFound},
+		{name: "uuid", format: OperationIDFormatUUID, operationID: "0b5f3a4e-7a52-4e0e-9d4e-1f2a3b4c5d6e", wantErr: repository.ErrOperationNotFound},
+		{name: "empty", operationID: "", wantErr: ErrInvalidOperationID},
+		{name: "whitespace", operationID: "msg 123", wantErr: ErrInvalidOperationID},
+		{name: "injection attempt", operationID: "1'; DROP TABLE operations;--\n", wantErr: ErrInvalidOperationID},
+		{name: "too long", operationID: strings.Repeat("a", 256), wantErr: ErrInvalidOperationID},
+		{name: "not a uuid", format: OperationIDFormatUUID, operationID: "msg-123", wantErr: ErrInvalidOperationID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range []string{"approve", "reject"} {
+				repo := &mockRegRepo{getOperationErr: repository.ErrOperationNotFound}
+				cfg := &AdminConfig{OperationRetryMax: 3, OperationIDFormat: tt.format}
+				srv, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+				if err != nil {
+					t.Fatalf("NewAdminService() error = %v", err)
+				}
+				req := &model.OperationActionRequest{OperationID: tt.operationID, Reason: "reason"}
+
+				if action == "approve" {
+					_, _, err = srv.ApproveSubscription(ctx, req)
+				} else {
+					_, err = srv.RejectSubscription(ctx, req)
+				}
+
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", action, err, tt.wantErr)
+				}
+				wantCalls := 1
+				if tt.wantErr == ErrInvalidOperationID {
+					wantCalls = 0
+				}
+				if repo.getOperationCalls != wantCalls {
+					t.Errorf("%s called GetOperation %d times, want %d", action, repo.getOperationCalls, wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveNextPendingSubscription_Success(t *testing.T) {
+	ctx := context.Background()
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://np.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	})
+	claimedLRO := &model.LRO{OperationID: "op-claimed", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusInProgress, RequestJSON: subReqJSON}
+	approvedLRO := &model.LRO{OperationID: "op-claimed", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusApproved, RequestJSON: subReqJSON}
+
+	mockRepo := &mockRegRepo{lroToReturn: claimedLRO, subToReturn: &model.Subscription{}, updatedLROToReturn: approvedLRO}
+	mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+	mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+	service, _ := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+
+	_, gotLRO, err := service.ApproveNextPendingSubscription(ctx)
+	if err != nil {
+		t.Fatalf("ApproveNextPendingSubscription() error = %v, wantErr nil", err)
+	}
+	if diff := cmp.Diff(approvedLRO, gotLRO); diff != "" {
+		t.Errorf("ApproveNextPendingSubscription() LRO mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminService_ApproveNextPendingSubscription_Error(t *testing.T) {
+	ctx := context.Background()
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name       string
+		repo       *mockRegRepo
+		wantErr    error
+		wantStatus model.LROStatus
+	}{
+		{
+			name:    "no pending operation",
+			repo:    &mockRegRepo{claimErr: repository.ErrNoPendingOperation},
+			wantErr: repository.ErrNoPendingOperation,
+		},
+		{
+			name:    "claim fails",
+			repo:    &mockRegRepo{claimErr: dbErr},
+			wantErr: dbErr,
+		},
+		{
+			name: "claimed LRO is not a subscription operation",
+			repo: &mockRegRepo{
+				lroToReturn:        &model.LRO{OperationID: "op1", Type: "DELETE_PARTICIPANT", Status: model.LROStatusInProgress},
+				updatedLROToReturn: &model.LRO{},
+			},
+			wantStatus: model.LROStatusRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := NewAdminService(tt.repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+			_, gotLRO, err := service.ApproveNextPendingSubscription(ctx)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("ApproveNextPendingSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			// A claimed LRO that cannot be processed must not be left IN_PROGRESS.
+			if tt.wantStatus != "" && gotLRO.Status != tt.wantStatus {
+				t.Errorf("ApproveNextPendingSubscription() LRO status = %s, want %s", gotLRO.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdminService_ApproveNextPendingSubscription_ClaimExcludesOtherActions(t *testing.T) {
+	ctx := context.Background()
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://sub1.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	})
+	repo := &statusRegRepo{ops: map[string]*model.LRO{
+		"op1": {OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+	}}
+	npCli := &blockingNPClient{entered: make(chan string, 2), proceed: make(chan struct{})}
+	service, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}, &mockEncryptionSrv{}, npCli, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3, ConditionalApproval: true})
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := service.ApproveNextPendingSubscription(ctx)
+		errc <- err
+	}()
+	<-npCli.entered
+
+	// While the worker holds the claim, no other action may process the LRO.
+	if _, _, err := service.ApproveNextPendingSubscription(ctx); !errors.Is(err, repository.ErrNoPendingOperation) {
+		t.Errorf("ApproveNextPendingSubscription() of a second worker error = %v, want %v", err, repository.ErrNoPendingOperation)
+	}
+	if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"}); !errors.Is(err, ErrLROInProgress) || !errors.Is(err, ErrLROAlreadyProcessed) {
+		t.Errorf("ApproveSubscription() of a claimed LRO error = %v, want %v", err, ErrLROInProgress)
+	}
+	if _, err := service.RejectSubscription(ctx, &model.OperationActionRequest{OperationID: "op1", Reason: "spam"}); !errors.Is(err, ErrLROInProgress) {
+		t.Errorf("RejectSubscription() of a claimed LRO error = %v, want %v", err, ErrLROInProgress)
+	}
+
+	close(npCli.proceed)
+	if err := <-errc; err != nil {
+		t.Fatalf("ApproveNextPendingSubscription() error = %v", err)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusApproved {
+		t.Errorf("stored status = %s, want APPROVED", got)
+	}
+	if n := len(npCli.entered); n != 0 {
+		t.Errorf("on_subscribe callbacks of other actions = %d, want 0", n)
+	}
+}
+
+func TestAdminService_ApproveNextPendingSubscription_ClaimExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		claimedAgo time.Duration
+		wantErr    error
+		wantStatus model.LROStatus
+	}{
+		{name: "expired claim is taken over", claimedAgo: time.Hour, wantStatus: model.LROStatusApproved},
+		{name: "fresh claim is kept", claimedAgo: time.Second, wantErr: repository.ErrNoPendingOperation, wantStatus: model.LROStatusInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalClaimTimeout: time.Minute})
+			// A worker claimed the LRO and stopped before approving it.
+			repo.ops["op1"].Status = model.LROStatusInProgress
+			repo.ops["op1"].UpdatedAt = time.Now().Add(-tt.claimedAgo)
+
+			_, _, err := service.ApproveNextPendingSubscription(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveNextPendingSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.storedStatus("op1"); got != tt.wantStatus {
+				t.Errorf("stored status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// operationsRegRepo is a regRepo whose GetOperation returns the LRO of the requested operation.
+type operationsRegRepo struct {
+	mockRegRepo
+	ops map[string]*model.LRO
+}
+
+func (r *operationsRegRepo) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	return r.ops[operationID], nil
+}
+
+func (r *operationsRegRepo) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	return sub, lro, nil
+}
+
+// blockingNPClient is an npClient whose OnSubscribe reports its callback URL on entered
+// and then blocks until proceed is closed.
+type blockingNPClient struct {
+	entered chan string
+	proceed chan struct{}
+}
+
+func (c *blockingNPClient) OnSubscribe(ctx context.Context, callbackURL string, request *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
+	c.entered <- callbackURL
+	<-c.proceed
+	return &model.OnSubscribeResponse{Answer: "challenge123"}, nil
+}
+
+func (c *blockingNPClient) Probe(ctx context.Context, baseURL string) error {
+	return nil
+}
+
+func TestAdminService_ApproveSubscription_MaxConcurrentApprovalsPerSubscriber(t *testing.T) {
+	tests := []struct {
+		name          string
+		subscriberIDs [2]string
+		wantParallel  bool
+	}{
+		{name: "same subscriber serializes", subscriberIDs: [2]string{"sub1", "sub1"}, wantParallel: false},
+		{name: "different subscribers run in parallel", subscriberIDs: [2]string{"sub1", "sub2"}, wantParallel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &operationsRegRepo{ops: map[string]*model.LRO{}}
+			for i, id := range tt.subscriberIDs {
+				subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+					Subscription: model.Subscription{
+						Subscriber:    model.Subscriber{SubscriberID: id, URL: "http://" + id + ".com", Type: model.RoleBAP, Domain: fmt.Sprintf("domain-%d", i)},
+						EncrPublicKey: "np-encr-pub-key",
+					},
+				})
+				opID := fmt.Sprintf("op-%d", i)
+				repo.ops[opID] = &model.LRO{
+					OperationID: opID,
+					Type:        model.OperationTypeCreateSubscription,
+					Status:      model.LROStatusPending,
+					RequestJSON: subReqJSON,
+				}
+			}
+			npCli := &blockingNPClient{entered: make(chan string, 2), proceed: make(chan struct{})}
+			cfg := &AdminConfig{OperationRetryMax: 3, MaxConcurrentApprovalsPerSubscriber: 1}
+			service, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}, &mockEncryptionSrv{}, npCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for opID := range repo.ops {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, _, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: opID}); err != nil {
+						t.Errorf("ApproveSubscription(%s) error = %v", opID, err)
+					}
+				}()
+			}
+
+			<-npCli.entered
+			select {
+			case <-npCli.entered:
+				if !tt.wantParallel {
+					t.Errorf("second on_subscribe callback started while the first was in flight, want serialized")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantParallel {
+					t.Errorf("second on_subscribe callback did not start while the first was in flight, want parallel")
+				}
+			}
+			close(npCli.proceed)
+			wg.Wait()
+		})
+	}
+}
+
+func TestAdminService_ApproveSubscription_ApprovalWaitCancelled(t *testing.T) {
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://sub1.com", Type: model.RoleBAP, Domain: "domain"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	})
+	repo := &operationsRegRepo{ops: map[string]*model.LRO{
+		"op-1": {OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+	}}
+	cfg := &AdminConfig{OperationRetryMax: 3, MaxConcurrentApprovalsPerSubscriber: 1}
+	service, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+	// A concurrent approval of the subscriber holds the only slot.
+	release, err := service.approvals.acquire(context.Background(), "sub1")
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op-1"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ApproveSubscription() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := repo.ops["op-1"].Status; got != model.LROStatusPending {
+		t.Errorf("LRO status = %s, want %s", got, model.LROStatusPending)
+	}
+}
+
+func TestNewAdminService_NegativeMaxConcurrentApprovalsPerSubscriber(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, MaxConcurrentApprovalsPerSubscriber: -1}
+	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "MaxConcurrentApprovalsPerSubscriber") {
+		t.Errorf("NewAdminService() error = %v, want error about MaxConcurrentApprovalsPerSubscriber", err)
+	}
+}
+
+func TestAdminService_ApproveSubscription_ChallengePolicies(t *testing.T) {
+	cfg := &AdminConfig{
+		OperationRetryMax: 3,
+		ChallengePolicies: map[string]ChallengePolicy{
+			"retail":    {Length: 32, Algorithm: ChallengeAlgorithmBase64, Verification: ChallengeVerificationTolerant},
+			"logistics": {Length: 24},
+		},
+	}
+	defaultPolicy := ChallengePolicy{Length: DefaultChallengeLength, Algorithm: ChallengeAlgorithmHex, Verification: ChallengeVerificationStrict}
+	tests := []struct {
+		name   string
+		domain string
+		want   ChallengePolicy
+	}{
+		{name: "custom policy", domain: "retail", want: cfg.ChallengePolicies["retail"]},
+		{name: "partial policy uses default for unset fields", domain: "logistics", want: ChallengePolicy{Length: 24, Algorithm: ChallengeAlgorithmHex, Verification: ChallengeVerificationStrict}},
+		{name: "other domain uses default", domain: "mobility", want: defaultPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+				Subscription: model.Subscription{
+					Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://sub1.com", Type: model.RoleBAP, Domain: tt.domain},
+					EncrPublicKey: "np-encr-pub-key",
+				},
+			})
+			repo := &operationsRegRepo{ops: map[string]*model.LRO{
+				"op-1": {OperationID: "op-1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+			}}
+			chSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			npCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			service, err := NewAdminService(repo, chSrv, &mockEncryptionSrv{}, npCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			if _, _, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op-1"}); err != nil {
+				t.Fatalf("ApproveSubscription() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, chSrv.gotNewPolicy); diff != "" {
+				t.Errorf("NewChallenge() policy mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, chSrv.gotVerifyPolicy); diff != "" {
+				t.Errorf("Verify() policy mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminService_ChallengePolicy_DefaultVerification(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, ChallengeVerification: ChallengeVerificationTolerant}
+	service, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+	want := ChallengePolicy{Length: DefaultChallengeLength, Algorithm: ChallengeAlgorithmHex, Verification: ChallengeVerificationTolerant}
+	if diff := cmp.Diff(want, service.challengePolicy("retail")); diff != "" {
+		t.Errorf("challengePolicy() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminService_ChallengePolicy_DefaultLengthAndAlgorithm(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, ChallengeLength: 48, ChallengeAlgorithm: ChallengeAlgorithmBase64}
+	service, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+	want := ChallengePolicy{Length: 48, Algorithm: ChallengeAlgorithmBase64, Verification: ChallengeVerificationStrict}
+	if diff := cmp.Diff(want, service.challengePolicy("retail")); diff != "" {
+		t.Errorf("challengePolicy() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewAdminService_ShortChallengeLength(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, ChallengeLength: 8}
+	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "length 8 must be at least 16") {
+		t.Errorf("NewAdminService() error = %v, want error about the challenge length", err)
+	}
+}
+
+func TestNewAdminService_InvalidChallengePolicy(t *testing.T) {
+	cfg := &AdminConfig{OperationRetryMax: 3, ChallengePolicies: map[string]ChallengePolicy{"retail": {Algorithm: "SHA256"}}}
+	_, err := NewAdminService(&mockRegRepo{}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), `ChallengePolicies["retail"]`) {
+		t.Errorf("NewAdminService() error = %v, want error about ChallengePolicies[\"retail\"]", err)
+	}
+}
+
+func TestAdminService_ApproveSubscription_RecordUpdateDiff(t *testing.T) {
+	ctx := context.Background()
+	registered := model.Subscription{
+		Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://old.np.com", Type: model.RoleBAP, Domain: "retail"},
+		KeyID:            "key1",
+		SigningPublicKey: "signing-key",
+		EncrPublicKey:    "np-encr-pub-key",
+	}
+
+	tests := []struct {
+		name       string
+		record     bool
+		opType     model.OperationType
+		registered []model.Subscription
+		wantResult string
+	}{
+		{
+			name:       "url-only update recorded",
+			record:     true,
+			opType:     model.OperationTypeUpdateSubscription,
+			registered: []model.Subscription{registered},
+			wantResult: `{"changes":[{"field":"url","old":"http://old.np.com","new":"http://np.com"}]}`,
+		},
+		{
+			name:       "not recorded when disabled",
+			opType:     model.OperationTypeUpdateSubscription,
+			registered: []model.Subscription{registered},
+		},
+		{
+			name:   "create not recorded",
+			record: true,
+			opType: model.OperationTypeCreateSubscription,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReq := &model.SubscriptionRequest{Subscription: registered, MessageID: "op1"}
+			subReq.URL = "http://np.com"
+			subReqJSON, _ := json.Marshal(subReq)
+			mockRepo := &mockRegRepo{
+				lroToReturn:        &model.LRO{OperationID: "op1", Type: tt.opType, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+				subToReturn:        &model.Subscription{},
+				updatedLROToReturn: &model.LRO{OperationID: "op1", Status: model.LROStatusApproved},
+				lookupSubsToReturn: tt.registered,
+			}
+			mockChSrv := &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}
+			mockNpCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+			cfg := &AdminConfig{OperationRetryMax: 3, RecordUpdateDiff: tt.record}
+			service, err := NewAdminService(mockRepo, mockChSrv, &mockEncryptionSrv{}, mockNpCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			if _, _, err := service.ApproveSubscription(ctx, &model.OperationActionRequest{OperationID: "op1"}); err != nil {
+				t.Fatalf("ApproveSubscription() error = %v", err)
+			}
+			if mockRepo.gotUpsertLRO == nil {
+				t.Fatal("UpsertSubscriptionAndLRO() not called")
+			}
+			if got := string(mockRepo.gotUpsertLRO.ResultJSON); got != tt.wantResult {
+				t.Errorf("LRO ResultJSON = %s, want %s", got, tt.wantResult)
+			}
+		})
+	}
+}
+
+// statusRegRepo is a regRepo storing the LROs of operations, whose conditional upsert
+// applies only to an LRO still in the expected status, as the registry does.
+type statusRegRepo struct {
+	mockRegRepo
+	mu      sync.Mutex
+	ops     map[string]*model.LRO
+	upserts int
+}
+
+func (r *statusRegRepo) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lro := *r.ops[operationID]
+	return &lro, nil
+}
+
+func (r *statusRegRepo) UpsertSubscriptionAndLRO(ctx context.Context, sub *model.Subscription, lro *model.LRO) (*model.Subscription, *model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store(lro)
+	return sub, lro, nil
+}
+
+func (r *statusRegRepo) UpsertSubscriptionAndLROIfStatus(ctx context.Context, sub *model.Subscription, lro *model.LRO, expected model.LROStatus) (*model.Subscription, *model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if status := r.ops[lro.OperationID].Status; status != expected {
+		return nil, nil, fmt.Errorf("%w: LRO %s is %s, expected %s", repository.ErrOperationConflict, lro.OperationID, status, expected)
+	}
+	r.store(lro)
+	return sub, lro, nil
+}
+
+func (r *statusRegRepo) UpdateOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *lro
+	r.ops[lro.OperationID] = &stored
+	return lro, nil
+}
+
+func (r *statusRegRepo) UpdateOperationIfStatus(ctx context.Context, lro *model.LRO, expected model.LROStatus) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if status := r.ops[lro.OperationID].Status; status != expected {
+		return nil, fmt.Errorf("%w: LRO %s is %s, expected %s", repository.ErrOperationConflict, lro.OperationID, status, expected)
+	}
+	stored := *lro
+	r.ops[lro.OperationID] = &stored
+	return lro, nil
+}
+
+func (r *statusRegRepo) ClaimOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lro := r.ops[operationID]
+	if lro == nil || (lro.Status != model.LROStatusPending && lro.Status != model.LROStatusFailure) {
+		return nil, repository.ErrNoPendingOperation
+	}
+	lro.Status = model.LROStatusInProgress
+	lro.UpdatedAt = time.Now()
+	claimed := *lro
+	return &claimed, nil
+}
+
+func (r *statusRegRepo) ClaimNextPendingOperation(ctx context.Context) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, lro := range r.ops {
+		if lro.Status == model.LROStatusPending {
+			lro.Status = model.LROStatusInProgress
+			lro.UpdatedAt = time.Now()
+			claimed := *lro
+			return &claimed, nil
+		}
+	}
+	return nil, repository.ErrNoPendingOperation
+}
+
+func (r *statusRegRepo) ClaimStaleOperation(ctx context.Context, claimedBefore time.Time) (*model.LRO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, lro := range r.ops {
+		if lro.Status == model.LROStatusInProgress && lro.UpdatedAt.Before(claimedBefore) {
+			lro.UpdatedAt = time.Now()
+			claimed := *lro
+			return &claimed, nil
+		}
+	}
+	return nil, repository.ErrNoPendingOperation
+}
+
+// store stores a copy of lro. r.mu must be held.
+func (r *statusRegRepo) store(lro *model.LRO) {
+	stored := *lro
+	r.ops[lro.OperationID] = &stored
+	r.upserts++
+}
+
+func TestAdminService_ApproveSubscription_ConditionalApproval(t *testing.T) {
+	tests := []struct {
+		name          string
+		conditional   bool
+		approvals     int
+		wantUpserts   int
+		wantConflicts int
+	}{
+		{name: "clean upsert", conditional: true, approvals: 1, wantUpserts: 1},
+		{name: "concurrent approvals conflict", conditional: true, approvals: 2, wantUpserts: 1, wantConflicts: 1},
+		{name: "concurrent approvals overwrite when disabled", approvals: 2, wantUpserts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+				Subscription: model.Subscription{
+					Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://sub1.com", Type: model.RoleBAP, Domain: "retail"},
+					EncrPublicKey: "np-encr-pub-key",
+				},
+			})
+			repo := &statusRegRepo{ops: map[string]*model.LRO{
+				"op1": {OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+			}}
+			npCli := &blockingNPClient{entered: make(chan string, tt.approvals), proceed: make(chan struct{})}
+			cfg := &AdminConfig{OperationRetryMax: 3, ConditionalApproval: tt.conditional}
+			service, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}, &mockEncryptionSrv{}, npCli, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.approvals)
+			for i := 0; i < tt.approvals; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, lro, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"})
+					if err != nil {
+						if lro == nil || lro.Status != model.LROStatusApproved {
+							t.Errorf("ApproveSubscription() on conflict returned LRO %+v, want the stored approved LRO", lro)
+						}
+						errs <- err
+					}
+				}()
+			}
+			// All approvals read the pending LRO before any of them stores its result.
+			for i := 0; i < tt.approvals; i++ {
+				<-npCli.entered
+			}
+			close(npCli.proceed)
+			wg.Wait()
+			close(errs)
+
+			conflicts := 0
+			for err := range errs {
+				if !errors.Is(err, repository.ErrOperationConflict) {
+					t.Errorf("ApproveSubscription() error = %v, want ErrOperationConflict", err)
+				}
+				conflicts++
+			}
+			if conflicts != tt.wantConflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.wantConflicts)
+			}
+			if repo.upserts != tt.wantUpserts {
+				t.Errorf("upserts = %d, want %d", repo.upserts, tt.wantUpserts)
+			}
+			if got := repo.ops["op1"].Status; got != model.LROStatusApproved {
+				t.Errorf("stored LRO status = %s, want %s", got, model.LROStatusApproved)
+			}
+		})
+	}
+}
+
+func TestAdminService_UpdateLROError_ConditionalApproval(t *testing.T) {
+	tests := []struct {
+		name        string
+		conditional bool
+		stored      model.LROStatus
+		wantStatus  model.LROStatus
+	}{
+		{name: "failure stored", conditional: true, stored: model.LROStatusInProgress, wantStatus: model.LROStatusFailure},
+		{name: "concurrent approval kept", conditional: true, stored: model.LROStatusApproved, wantStatus: model.LROStatusApproved},
+		{name: "concurrent approval overwritten when disabled", stored: model.LROStatusApproved, wantStatus: model.LROStatusFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &statusRegRepo{ops: map[string]*model.LRO{
+				"op1": {OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: tt.stored},
+			}}
+			cfg := &AdminConfig{OperationRetryMax: 3, ConditionalApproval: tt.conditional}
+			service, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			// The approval read the LRO when it claimed it, before any concurrent approval stored its result.
+			lro := &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusInProgress}
+			if err := service.updateLROError(context.Background(), lro, errors.New("callback failed"), model.LROStatusFailure); err != nil {
+				t.Fatalf("updateLROError() error = %v", err)
+			}
+			if got := repo.ops["op1"].Status; got != tt.wantStatus {
+				t.Errorf("stored LRO status = %s, want %s", got, tt.wantStatus)
+			}
+			if lro.Status != tt.wantStatus {
+				t.Errorf("LRO status = %s, want %s", lro.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// racingRegRepo is a statusRegRepo running afterGet once an operation has been read, as an action
+// racing the reader would.
+type racingRegRepo struct {
+	*statusRegRepo
+	afterGet func()
+}
+
+func (r *racingRegRepo) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
+	lro, err := r.statusRegRepo.GetOperation(ctx, operationID)
+	if f := r.afterGet; f != nil {
+		r.afterGet = nil
+		f()
+	}
+	return lro, err
+}
+
+func TestAdminService_RejectSubscription_ConditionalApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		conditional  bool
+		wantConflict bool
+		wantStatus   model.LROStatus
+	}{
+		{name: "concurrent approval kept", conditional: true, wantConflict: true, wantStatus: model.LROStatusApproved},
+		{name: "concurrent approval overwritten when disabled", wantStatus: model.LROStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &racingRegRepo{statusRegRepo: &statusRegRepo{ops: map[string]*model.LRO{
+				"op1": {OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending},
+			}}}
+			// An approval stores its result after the rejection read the pending LRO.
+			repo.afterGet = func() {
+				repo.UpsertSubscriptionAndLRO(context.Background(), &model.Subscription{}, &model.LRO{OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusApproved})
+			}
+			cfg := &AdminConfig{OperationRetryMax: 3, ConditionalApproval: tt.conditional}
+			service, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+			if err != nil {
+				t.Fatalf("NewAdminService() error = %v", err)
+			}
+
+			lro, err := service.RejectSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1", Reason: "rejected"})
+			if got := errors.Is(err, repository.ErrOperationConflict); got != tt.wantConflict {
+				t.Fatalf("RejectSubscription() error = %v, want ErrOperationConflict %v", err, tt.wantConflict)
+			}
+			if lro == nil || lro.Status != tt.wantStatus {
+				t.Errorf("RejectSubscription() LRO = %+v, want status %s", lro, tt.wantStatus)
+			}
+			if got := repo.ops["op1"].Status; got != tt.wantStatus {
+				t.Errorf("stored LRO status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdminService_RejectSubscription_Success(t *testing.T) {
+	ctx := context.Background()
+	opID := "test-op-reject-success"
+	reason := "Admin rejected"
+	now := time.Now()
+	subReq := &model.SubscriptionRequest{MessageID: opID}
+	subReqJSON, _ := json.Marshal(subReq)
+
+	initialLRO := &model.LRO{
+		OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending,
+		RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now, RetryCount: 0,
+	}
+
+	rejectedLRO := &model.LRO{
+		OperationID: opID,
+		Type:        model.OperationTypeCreateSubscription,
+		Status:      model.LROStatusRejected,
+		RequestJSON: subReqJSON,
+		ErrorDataJSON: func() []byte {
+			b, _ := json.Marshal(map[string]string{"reason": reason})
+			return b
+		}(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		RetryCount: 0,
+	}
+
+	mockRepo := &mockRegRepo{
+		lroToReturn:        initialLRO,
+		updatedLROToReturn: rejectedLRO,
+	}
+	cfg := &AdminConfig{OperationRetryMax: 3}
+	service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+
+	req := &model.OperationActionRequest{OperationID: opID, Reason: reason}
+	gotLRO, err := service.RejectSubscription(ctx, req)
+	if err != nil {
+		t.Fatalf("RejectSubscription() error = %v, wantErr nil", err)
+	}
+	if diff := cmp.Diff(rejectedLRO, gotLRO); diff != "" {
+		t.Errorf("RejectSubscription() LRO mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminService_RejectSubscription_EventPublishError(t *testing.T) {
+	ctx := context.Background()
+	opID := "test-op-reject-event-error"
+	reason := "Admin rejected"
+	now := time.Now()
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{MessageID: opID})
+
+	initialLRO := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now}
+	rejectedLRO := &model.LRO{OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusRejected, RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now}
+
+	mockRepo := &mockRegRepo{lroToReturn: initialLRO, updatedLROToReturn: rejectedLRO}
+	mockEvPub := &mockAdminEventPublisher{err: errors.New("event publish failed")} // Simulate event publish error
+	cfg := &AdminConfig{OperationRetryMax: 3}
+
+	service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, mockEvPub, cfg)
+
+	req := &model.OperationActionRequest{OperationID: opID, Reason: reason}
+	_, err := service.RejectSubscription(ctx, req)
+	if err != nil {
+		t.Fatalf("RejectSubscription() unexpected error: %v", err)
+	}
+	// The error is logged, not returned, so we just ensure the function completes without panicking.
+}
+
+func TestAdminService_RejectSubscription_Error(t *testing.T) {
+	ctx := context.Background()
+	opID := "test-op-reject-error"
+	reason := "Admin rejected"
+	now := time.Now()
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{MessageID: opID})
+
+	baseLRO := func() *model.LRO {
+		return &model.LRO{
+			OperationID: opID, Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending,
+			RequestJSON: subReqJSON, CreatedAt: now, UpdatedAt: now, RetryCount: 0,
+		}
+	}
+
+	tests := []struct {
+		name               string
+		mockRepoSetup      func(*mockRegRepo)
+		adminCfg           *AdminConfig
+		wantErrMsgContains string
+		req                *model.OperationActionRequest
+	}{
+		{
+			name: "LRO not found",
+			mockRepoSetup: func(m *mockRegRepo) {
+				m.getOperationErr = repository.ErrOperationNotFound
+			},
+			wantErrMsgContains: "failed to get LRO: operation not found",
+			req:                &model.OperationActionRequest{OperationID: "nonexistent-op", Reason: reason},
+		},
+		{
+			name: "Max retries exceeded (though not directly applicable to reject, lro() check is common)",
+			mockRepoSetup: func(m *mockRegRepo) {
+				lro := baseLRO()
+				lro.RetryCount = 5
+				m.lroToReturn = lro
+			},
+			adminCfg:           &AdminConfig{OperationRetryMax: 3},
+			wantErrMsgContains: "max retries exceeded for operation",
+			req:                &model.OperationActionRequest{OperationID: opID, Reason: reason},
+		},
+		{
+			name: "Failed to update LRO during rejection",
+			mockRepoSetup: func(m *mockRegRepo) {
+				m.lroToReturn = baseLRO()
+				m.updateOperationErr = errors.New("db update failed for reject")
+			},
+			wantErrMsgContains: "failed to update LRO error: db update failed for reject",
+			req:                &model.OperationActionRequest{OperationID: opID, Reason: reason},
+		},
+		{
+			name: "nil OperationActionRequest",
+			mockRepoSetup: func(m *mockRegRepo) {
+				m.lroToReturn = baseLRO()
+			},
+			req:                nil, // Explicitly test nil request
+			wantErrMsgContains: "OperationActionRequest cannot be nil",
+		},
+		{
+			name: "empty OperationID",
+			mockRepoSetup: func(m *mockRegRepo) {
+				m.lroToReturn = baseLRO()
+			},
+			wantErrMsgContains: "OperationID cannot be empty",
+			req:                &model.OperationActionRequest{OperationID: "", Reason: reason},
+		},
+		{
+			name: "empty Reason",
+			mockRepoSetup: func(m *mockRegRepo) {
+				m.lroToReturn = baseLRO()
+			},
+			wantErrMsgContains: "reason cannot be empty",
+			req:                &model.OperationActionRequest{OperationID: opID, Reason: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockRegRepo{}
+			if tt.mockRepoSetup != nil {
+				tt.mockRepoSetup(mockRepo)
+			}
+
+			cfg := tt.adminCfg
+			if cfg == nil {
+				cfg = &AdminConfig{OperationRetryMax: 3}
+			}
+
+			service, _ := NewAdminService(mockRepo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, cfg)
+
+			_, err := service.RejectSubscription(ctx, tt.req)
+
+			if err == nil {
+				t.Fatalf("RejectSubscription() error = nil, want error containing %q", tt.wantErrMsgContains)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrMsgContains) {
+				t.Errorf("RejectSubscription() error = %q, want error containing %q", err.Error(), tt.wantErrMsgContains)
+			}
+		})
+	}
+}
+
+// staleRegRepo is a regRepo storing the LROs of operations, rejecting the PENDING ones created
+// before the given time as the registry does.
+type staleRegRepo struct {
+	mockRegRepo
+	ops map[string]*model.LRO
+}
+
+func (r *staleRegRepo) RejectStaleOperations(ctx context.Context, createdBefore time.Time, errorDataJSON []byte) ([]model.LRO, error) {
+	var rejected []model.LRO
+	for _, lro := range r.ops {
+		if lro.Status == model.LROStatusPending && lro.CreatedAt.Before(createdBefore) {
+			lro.Status = model.LROStatusRejected
+			lro.ErrorDataJSON = errorDataJSON
+			rejected = append(rejected, *lro)
+		}
+	}
+	return rejected, nil
+}
+
+// rejectedEventPublisher records the operations of the published rejected events.
+type rejectedEventPublisher struct {
+	mockAdminEventPublisher
+	rejected []string
+}
+
+func (p *rejectedEventPublisher) PublishSubscriptionRequestRejectedEvent(ctx context.Context, lro *model.LRO) (string, error) {
+	p.rejected = append(p.rejected, lro.OperationID)
+	return "event-1", nil
+}
+
+func TestAdminService_RejectStaleOperations(t *testing.T) {
+	now := time.Now()
+	repo := &staleRegRepo{ops: map[string]*model.LRO{
+		"old":          {OperationID: "old", Status: model.LROStatusPending, CreatedAt: now.Add(-48 * time.Hour)},
+		"fresh":        {OperationID: "fresh", Status: model.LROStatusPending, CreatedAt: now.Add(-time.Hour)},
+		"old-approved": {OperationID: "old-approved", Status: model.LROStatusApproved, CreatedAt: now.Add(-48 * time.Hour)},
+	}}
+	evPub := &rejectedEventPublisher{}
+	service, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, evPub, &AdminConfig{OperationRetryMax: 3, StaleOperationAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+
+	if err := service.RejectStaleOperations(context.Background()); err != nil {
+		t.Fatalf("RejectStaleOperations() error = %v, want nil", err)
+	}
+	wantStatus := map[string]model.LROStatus{
+		"old":          model.LROStatusRejected,
+		"fresh":        model.LROStatusPending,
+		"old-approved": model.LROStatusApproved,
+	}
+	for id, want := range wantStatus {
+		if got := repo.ops[id].Status; got != want {
+			t.Errorf("operation %q status = %s, want %s", id, got, want)
+		}
+	}
+	var errData map[string]string
+	if err := json.Unmarshal(repo.ops["old"].ErrorDataJSON, &errData); err != nil {
+		t.Fatalf("failed to unmarshal error data %q: %v", repo.ops["old"].ErrorDataJSON, err)
+	}
+	if errData["reason"] != "STALE" {
+		t.Errorf("error data reason = %q, want STALE", errData["reason"])
+	}
+	if diff := cmp.Diff([]string{"old"}, evPub.rejected); diff != "" {
+		t.Errorf("rejected events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminService_RejectStaleOperations_Disabled(t *testing.T) {
+	repo := &staleRegRepo{ops: map[string]*model.LRO{
+		"old": {OperationID: "old", Status: model.LROStatusPending, CreatedAt: time.Now().Add(-48 * time.Hour)},
+	}}
+	service, err := NewAdminService(repo, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3})
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+
+	if err := service.RejectStaleOperations(context.Background()); err != nil {
+		t.Fatalf("RejectStaleOperations() error = %v, want nil", err)
+	}
+	if got := repo.ops["old"].Status; got != model.LROStatusPending {
+		t.Errorf("operation status = %s, want PENDING", got)
+	}
+}
+
+func TestAdminService_RejectStaleOperations_RepoError(t *testing.T) {
+	repoErr := errors.New("db error")
+	service, err := NewAdminService(&mockRegRepo{rejectStaleErr: repoErr}, &mockChallengeSrv{}, &mockEncryptionSrv{}, &mockNPClient{}, &mockAdminEventPublisher{}, &AdminConfig{OperationRetryMax: 3, StaleOperationAge: time.Hour})
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+
+	if err := service.RejectStaleOperations(context.Background()); !errors.Is(err, repoErr) {
+		t.Errorf("RejectStaleOperations() error = %v, want %v", err, repoErr)
+	}
+}
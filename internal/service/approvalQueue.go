@@ -0,0 +1,206 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ApprovalMode decides whether an approval finishes within the approve request.
+type ApprovalMode string
+
+const (
+	// ApprovalModeSync runs the challenge round-trip within the approve request,
+	// which returns the final status of the operation. It is the default.
+	ApprovalModeSync ApprovalMode = "SYNC"
+	// ApprovalModeAsync queues the approval in a task queue and returns the operation still PENDING.
+	// Approval workers claim the operation as IN_PROGRESS, run the challenge round-trip and store
+	// the final status.
+	ApprovalModeAsync ApprovalMode = "ASYNC"
+)
+
+// Valid reports whether m is a known mode. The empty mode is valid and means SYNC.
+func (m ApprovalMode) Valid() bool {
+	switch m {
+	case "", ApprovalModeSync, ApprovalModeAsync:
+		return true
+	}
+	return false
+}
+
+// DefaultApprovalQueueSize is the default of AdminConfig.ApprovalQueueSize.
+const DefaultApprovalQueueSize = 100
+
+// DefaultApprovalClaimTimeout is the default of AdminConfig.ApprovalClaimTimeout.
+const DefaultApprovalClaimTimeout = 10 * time.Minute
+
+// ErrApprovalQueueFull is returned when an asynchronous approval cannot be queued.
+var ErrApprovalQueueFull = errors.New("approval queue is full")
+
+// approvalQueue runs the asynchronous approvals: a task queue of APPROVAL tasks, whose workers
+// claim and approve their operations, and a loop taking over the approvals whose claim expired.
+type approvalQueue struct {
+	tasks        *ChannelTaskQueue
+	claimTimeout time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newApprovalQueue creates an approvalQueue of size tasks processed by p in workers workers, taking
+// over claims older than claimTimeout. Values that are not positive use their defaults.
+func newApprovalQueue(p taskProcessor, size, workers int, claimTimeout time.Duration) *approvalQueue {
+	if size <= 0 {
+		size = DefaultApprovalQueueSize
+	}
+	if claimTimeout <= 0 {
+		claimTimeout = DefaultApprovalClaimTimeout
+	}
+	return &approvalQueue{tasks: newApprovalTaskQueue(context.Background(), workers, p, size), claimTimeout: claimTimeout}
+}
+
+// approvalProcessor is the taskProcessor of the APPROVAL tasks of an adminService.
+type approvalProcessor struct {
+	s *adminService
+}
+
+// Process claims the operation of task as IN_PROGRESS and approves it. An operation that can no longer
+// be claimed, e.g. approved by a repeated request or rejected since its approval was queued, is skipped.
+// The failure of an approval is stored on its operation, so it is not returned for the task to be retried.
+func (p *approvalProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
+	var req model.OperationActionRequest
+	if err := json.Unmarshal(task.Body, &req); err != nil {
+		return fmt.Errorf("invalid approval task: %w", err)
+	}
+	lro, err := p.s.regRepo.ClaimOperation(ctx, req.OperationID)
+	if errors.Is(err, repository.ErrNoPendingOperation) {
+		slog.WarnContext(ctx, "AdminService: Skipping queued approval of an operation no longer pending", "operation_id", req.OperationID)
+		return nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminService: Failed to claim LRO for approval", "operation_id", req.OperationID, "error", err)
+		return fmt.Errorf("failed to claim LRO for approval: %w", err)
+	}
+	p.s.runApproval(ctx, lro)
+	return nil
+}
+
+// enqueueApproval queues the approval of lro for the approval workers and returns lro, still PENDING.
+// An approval queued twice, e.g. by a repeated request, runs once: the later one finds the operation
+// claimed or processed and is skipped.
+func (s *adminService) enqueueApproval(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	body, err := json.Marshal(&model.OperationActionRequest{OperationID: lro.OperationID, Action: model.OperationActionApproveSubscription})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal approval task: %w", err)
+	}
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeApproval, Body: body}
+	if _, err := s.approvalQueue.tasks.queueTask(context.WithoutCancel(ctx), task); err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			slog.ErrorContext(ctx, "AdminService: Approval queue is full", "operation_id", lro.OperationID)
+			return nil, fmt.Errorf("%w: operation %s", ErrApprovalQueueFull, lro.OperationID)
+		}
+		slog.ErrorContext(ctx, "AdminService: Failed to queue approval", "operation_id", lro.OperationID, "error", err)
+		return nil, fmt.Errorf("failed to queue approval: %w", err)
+	}
+	slog.InfoContext(ctx, "AdminService: Queued subscription approval", "operation_id", lro.OperationID)
+	return lro, nil
+}
+
+// recoverApprovals approves the operations claimed longer than the claim timeout ago, until there
+// are none left or ctx is done.
+func (s *adminService) recoverApprovals(ctx context.Context) {
+	q := s.approvalQueue
+	for ctx.Err() == nil {
+		lro, err := s.regRepo.ClaimStaleOperation(ctx, time.Now().Add(-q.claimTimeout))
+		if err != nil {
+			if !errors.Is(err, repository.ErrNoPendingOperation) && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "AdminService: Failed to claim stale approval", "error", err)
+			}
+			return
+		}
+		slog.WarnContext(ctx, "AdminService: Taking over approval whose claim expired", "operation_id", lro.OperationID, "claimed_at", lro.UpdatedAt)
+		s.runApproval(ctx, lro)
+	}
+}
+
+// StartApprovalWorkers starts the workers of the approval task queue, along with a loop taking over
+// the expired claims of approvals every half claim timeout until ctx is cancelled. Both run until
+// StopApprovalWorkers is called. It does nothing unless AdminConfig.ApprovalMode is ASYNC.
+func (s *adminService) StartApprovalWorkers(ctx context.Context) {
+	q := s.approvalQueue
+	if q == nil {
+		return
+	}
+	ctx, q.cancel = context.WithCancel(ctx)
+	slog.InfoContext(ctx, "AdminService: Starting approval workers", "num_workers", q.tasks.numWorkers, "claim_timeout", q.claimTimeout)
+	q.tasks.StartWorkers()
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.claimTimeout / 2)
+		defer ticker.Stop()
+		for {
+			s.recoverApprovals(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopApprovalWorkers stops the approval workers and waits for them to return. Approvals still
+// queued are not run and their operations stay PENDING, to be approved again. Approvals cut short
+// leave their operations IN_PROGRESS until their claim expires and the approval workers of a
+// running service take them over.
+func (s *adminService) StopApprovalWorkers() {
+	q := s.approvalQueue
+	if q == nil || q.cancel == nil {
+		return
+	}
+	q.cancel()
+	q.wg.Wait()
+	queued := q.tasks.Depth()
+	q.tasks.StopWorkers()
+	if queued > 0 {
+		slog.Warn("AdminService: Approval workers stopped with queued approvals, their operations stay PENDING", "queued", queued)
+	}
+}
+
+// runApproval approves lro, claimed as IN_PROGRESS by this approval.
+func (s *adminService) runApproval(ctx context.Context, lro *model.LRO) {
+	if err := s.checkClaimedLRO(ctx, lro); err != nil {
+		// The LRO is IN_PROGRESS, so it must be moved to a final status to not be taken over again.
+		if updateErr := s.updateLROError(ctx, lro, err, model.LROStatusRejected); updateErr != nil {
+			slog.ErrorContext(ctx, "AdminService: Failed to update LRO with failure status", "operation_id", lro.OperationID, "update_error", updateErr)
+		}
+		return
+	}
+	if _, _, err := s.approveLRO(ctx, lro); err != nil {
+		slog.ErrorContext(ctx, "AdminService: Queued approval failed", "operation_id", lro.OperationID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "AdminService: Queued approval finished", "operation_id", lro.OperationID)
+}
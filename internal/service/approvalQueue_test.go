@@ -0,0 +1,227 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// newApprovalModeService returns an adminService in mode approving the PENDING operation "op1"
+// stored in the returned repository.
+func newApprovalModeService(t *testing.T, cfg *AdminConfig) (*adminService, *statusRegRepo) {
+	t.Helper()
+	subReqJSON, _ := json.Marshal(&model.SubscriptionRequest{
+		Subscription: model.Subscription{
+			Subscriber:    model.Subscriber{SubscriberID: "sub1", URL: "http://sub1.com", Type: model.RoleBAP, Domain: "retail"},
+			EncrPublicKey: "np-encr-pub-key",
+		},
+	})
+	repo := &statusRegRepo{ops: map[string]*model.LRO{
+		"op1": {OperationID: "op1", Type: model.OperationTypeCreateSubscription, Status: model.LROStatusPending, RequestJSON: subReqJSON},
+	}}
+	npCli := &mockNPClient{onSubscribeResponseToReturn: &model.OnSubscribeResponse{Answer: "challenge123"}}
+	service, err := NewAdminService(repo, &mockChallengeSrv{challengeToReturn: "challenge123", verifyResult: true}, &mockEncryptionSrv{}, npCli, &mockAdminEventPublisher{}, cfg)
+	if err != nil {
+		t.Fatalf("NewAdminService() error = %v", err)
+	}
+	return service, repo
+}
+
+// storedStatus returns the stored status of operation id.
+func (r *statusRegRepo) storedStatus(id string) model.LROStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ops[id].Status
+}
+
+func TestAdminService_ApproveSubscription_SyncMode(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeSync})
+
+	sub, lro, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"})
+	if err != nil {
+		t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+	}
+	if sub == nil {
+		t.Error("ApproveSubscription() subscription = nil, want the approved subscription")
+	}
+	if lro.Status != model.LROStatusApproved {
+		t.Errorf("ApproveSubscription() status = %s, want APPROVED", lro.Status)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusApproved {
+		t.Errorf("stored status = %s, want APPROVED", got)
+	}
+}
+
+func TestAdminService_ApproveSubscription_AsyncMode(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync})
+
+	sub, lro, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"})
+	if err != nil {
+		t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+	}
+	if sub != nil {
+		t.Errorf("ApproveSubscription() subscription = %+v, want nil", sub)
+	}
+	if lro.Status != model.LROStatusPending {
+		t.Errorf("ApproveSubscription() status = %s, want PENDING", lro.Status)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusPending {
+		t.Errorf("stored status before the workers run = %s, want PENDING", got)
+	}
+
+	service.StartApprovalWorkers(context.Background())
+	defer service.StopApprovalWorkers()
+	deadline := time.Now().Add(time.Second)
+	for repo.storedStatus("op1") != model.LROStatusApproved && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusApproved {
+		t.Errorf("stored status after the workers ran = %s, want APPROVED", got)
+	}
+}
+
+func TestAdminService_ApproveSubscription_AsyncDoubleSubmit(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync})
+	req := &model.OperationActionRequest{OperationID: "op1"}
+
+	for i := 0; i < 2; i++ {
+		_, lro, err := service.ApproveSubscription(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ApproveSubscription() #%d error = %v, want nil", i+1, err)
+		}
+		if lro.Status != model.LROStatusPending {
+			t.Errorf("ApproveSubscription() #%d status = %s, want PENDING", i+1, lro.Status)
+		}
+	}
+
+	service.StartApprovalWorkers(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for (service.approvalQueue.tasks.Depth() > 0 || repo.storedStatus("op1") != model.LROStatusApproved) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	service.StopApprovalWorkers()
+	if got := repo.storedStatus("op1"); got != model.LROStatusApproved {
+		t.Errorf("stored status = %s, want APPROVED", got)
+	}
+	if repo.upserts != 1 {
+		t.Errorf("upserts = %d, want 1", repo.upserts)
+	}
+}
+
+func TestAdminService_ApproveSubscription_AsyncInProgress(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync})
+	// A worker claimed the queued approval and is running it.
+	repo.ops["op1"].Status = model.LROStatusInProgress
+	repo.ops["op1"].UpdatedAt = time.Now()
+
+	_, lro, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"})
+	if err != nil {
+		t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+	}
+	if lro.Status != model.LROStatusInProgress {
+		t.Errorf("ApproveSubscription() status = %s, want IN_PROGRESS", lro.Status)
+	}
+	if got := service.approvalQueue.tasks.Depth(); got != 0 {
+		t.Errorf("queued approvals = %d, want 0", got)
+	}
+}
+
+func TestAdminService_ApproveSubscription_AsyncQueueFull(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync, ApprovalQueueSize: 1})
+	op2 := *repo.ops["op1"]
+	op2.OperationID = "op2"
+	repo.ops["op2"] = &op2
+
+	if _, _, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"}); err != nil {
+		t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+	}
+	if _, _, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op2"}); !errors.Is(err, ErrApprovalQueueFull) {
+		t.Errorf("ApproveSubscription() with a full queue error = %v, want %v", err, ErrApprovalQueueFull)
+	}
+	if got := repo.storedStatus("op2"); got != model.LROStatusPending {
+		t.Errorf("stored status of the approval not queued = %s, want PENDING", got)
+	}
+}
+
+func TestAdminService_StartApprovalWorkers_RecoversExpiredClaim(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync, ApprovalClaimTimeout: time.Minute})
+	// A previous instance claimed the approval and stopped before running it.
+	repo.ops["op1"].Status = model.LROStatusInProgress
+	repo.ops["op1"].UpdatedAt = time.Now().Add(-2 * time.Minute)
+
+	service.StartApprovalWorkers(context.Background())
+	defer service.StopApprovalWorkers()
+	deadline := time.Now().Add(time.Second)
+	for repo.storedStatus("op1") != model.LROStatusApproved && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusApproved {
+		t.Errorf("stored status = %s, want APPROVED", got)
+	}
+}
+
+func TestAdminService_StartApprovalWorkers_KeepsFreshClaim(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync, ApprovalClaimTimeout: time.Minute})
+	// Another instance claimed the approval and is running it.
+	repo.ops["op1"].Status = model.LROStatusInProgress
+	repo.ops["op1"].UpdatedAt = time.Now()
+
+	service.StartApprovalWorkers(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	service.StopApprovalWorkers()
+	if repo.upserts != 0 {
+		t.Errorf("upserts = %d, want 0", repo.upserts)
+	}
+}
+
+func TestApprovalProcessor_Process_SkipsProcessedOperation(t *testing.T) {
+	service, repo := newApprovalModeService(t, &AdminConfig{OperationRetryMax: 3, ApprovalMode: ApprovalModeAsync})
+	if _, _, err := service.ApproveSubscription(context.Background(), &model.OperationActionRequest{OperationID: "op1"}); err != nil {
+		t.Fatalf("ApproveSubscription() error = %v, want nil", err)
+	}
+	// An admin rejects the operation before a worker runs the queued approval.
+	repo.ops["op1"].Status = model.LROStatusRejected
+
+	item := <-service.approvalQueue.tasks.taskChannel
+	if item.task.Type != model.AsyncTaskTypeApproval {
+		t.Fatalf("queued task type = %s, want %s", item.task.Type, model.AsyncTaskTypeApproval)
+	}
+	if err := (&approvalProcessor{s: service}).Process(context.Background(), item.task); err != nil {
+		t.Fatalf("Process() error = %v, want nil", err)
+	}
+	if got := repo.storedStatus("op1"); got != model.LROStatusRejected {
+		t.Errorf("stored status = %s, want REJECTED", got)
+	}
+	if repo.upserts != 0 {
+		t.Errorf("upserts = %d, want 0", repo.upserts)
+	}
+}
+
+func TestApprovalMode_Valid(t *testing.T) {
+	for _, m := range []ApprovalMode{"", ApprovalModeSync, ApprovalModeAsync} {
+		if !m.Valid() {
+			t.Errorf("ApprovalMode(%q).Valid() = false, want true", m)
+		}
+	}
+	if m := ApprovalMode("LATER"); m.Valid() {
+		t.Errorf("ApprovalMode(%q).Valid() = true, want false", m)
+	}
+}
@@ -22,7 +22,11 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
@@ -31,6 +35,7 @@ import (
 // subscriptionKeyProvider defines the subset of subscriptionService needed by auth logic.
 type subscriptionKeyProvider interface {
 	GetSigningPublicKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (string, error)
+	GetSigningPublicKeyByKeyID(ctx context.Context, subscriberID, keyID string) (string, error)
 }
 
 // signValidator defines the interface for validating request signatures.
@@ -62,38 +67,121 @@ func parseAuthHeader(authHeader string) (*model.AuthHeader, error) {
 	if len(keyIDComponents) != 3 {
 		return nil, fmt.Errorf("keyId parameter has incorrect format, expected 3 components separated by '|', got %d for '%s'", len(keyIDComponents), keyIDPart)
 	}
+	created, err := unixAuthParam(authHeader, "created")
+	if err != nil {
+		return nil, err
+	}
+	expires, err := unixAuthParam(authHeader, "expires")
+	if err != nil {
+		return nil, err
+	}
 
 	return &model.AuthHeader{
 		SubscriberID: strings.TrimSpace(keyIDComponents[0]),
 		UniqueID:     strings.TrimSpace(keyIDComponents[1]),
 		Algorithm:    strings.TrimSpace(keyIDComponents[2]),
+		Created:      created,
+		Expires:      expires,
 	}, nil
 }
 
-// UnauthorizedHeader creates the WWW-Authenticate header string.
+// authParam returns the unquoted value of the name parameter of authHeader, or "" if it has none.
+func authParam(authHeader, name string) string {
+	params := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(authHeader), "Signature"))
+	for _, p := range strings.Split(params, ",") {
+		k, v, ok := strings.Cut(p, "=")
+		if ok && strings.TrimSpace(k) == name {
+			return strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return ""
+}
+
+// unixAuthParam returns the time of the name parameter of authHeader, a unix timestamp,
+// or the zero time if it has none.
+func unixAuthParam(authHeader, name string) (time.Time, error) {
+	v := authParam(authHeader, name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s parameter '%s' is not a unix timestamp", name, v)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// UnauthorizedHeader creates the value of the challenge header, WWW-Authenticate by default.
 func UnauthorizedHeader(realm string) string {
 	return fmt.Sprintf("Signature realm=\"%s\",headers=\"(created) (expires) digest\"", realm)
 }
 
-// keySet extracts and parses the keyId from the Authorization header.
-func keySet(ctx context.Context, authHeader string) (*model.AuthHeader, *model.AuthError) {
+// signatureHeaderOrDefault returns name, or the Authorization header if name is empty.
+func signatureHeaderOrDefault(name string) string {
+	if name == "" {
+		return model.AuthHeaderSubscriber
+	}
+	return name
+}
+
+// keySet extracts and parses the keyId from authHeader, the value of the headerName signature header.
+// If allowedAlgs is not empty, a header declaring an algorithm outside it, in its keyId or its
+// algorithm parameter, is rejected.
+func keySet(ctx context.Context, headerName, authHeader string, allowedAlgs []string) (*model.AuthHeader, *model.AuthError) {
 	if authHeader == "" {
-		slog.ErrorContext(ctx, "parseAuthHeader: Authorization header missing")
-		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, "Authorization header missing.", "unknown")
+		slog.ErrorContext(ctx, "parseAuthHeader: Signature header missing", "header_name", headerName)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, headerName+" header missing.", "unknown")
 	}
 
 	parsedKeyID, err := parseAuthHeader(authHeader)
 	if err != nil {
-		slog.ErrorContext(ctx, "parseAuthHeader: Failed to parse keyId from Authorization header", "error", err, "header", authHeader)
-		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Invalid Authorization header format: "+err.Error(), "unknown")
+		slog.ErrorContext(ctx, "parseAuthHeader: Failed to parse keyId from signature header", "error", err, "header_name", headerName, "header", authHeader)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Invalid "+headerName+" header format: "+err.Error(), "unknown")
+	}
+	if len(allowedAlgs) > 0 {
+		for _, alg := range []string{parsedKeyID.Algorithm, authParam(authHeader, "algorithm")} {
+			if alg == "" || algorithmAllowed(allowedAlgs, alg) {
+				continue
+			}
+			slog.ErrorContext(ctx, "keySet: Signature algorithm not allowed", "algorithm", alg, "allowed_algorithms", allowedAlgs, "subscriber_id", parsedKeyID.SubscriberID)
+			return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, fmt.Sprintf("Signature algorithm '%s' is not allowed in the %s header.", alg, headerName), parsedKeyID.SubscriberID)
+		}
 	}
 	return parsedKeyID, nil
 }
 
+// algorithmAllowed reports whether alg is one of allowed, ignoring case.
+func algorithmAllowed(allowed []string, alg string) bool {
+	return slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, alg) })
+}
+
+// DefaultSignatureClockSkew is how far the clocks of subscribers may be off by default
+// when the created and expires parameters of a signature are checked.
+const DefaultSignatureClockSkew = 30 * time.Second
+
+// DefaultNonceTTL is how long the nonce of an authenticated request is remembered by default.
+// It should exceed the validity of request signatures, so that a request cannot be replayed
+// once its nonce is forgotten.
+const DefaultNonceTTL = 24 * time.Hour
+
+// NonceStore remembers the nonces of authenticated requests, so that replayed requests can be rejected.
+type NonceStore interface {
+	// Remember remembers nonce for ttl, unless it is already remembered.
+	// It reports whether nonce was fresh, checking and remembering it in a single step.
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
 // subscriptionAuth handles request authentication.
 type subscriptionAuth struct {
 	subService   subscriptionKeyProvider
 	sigValidator signValidator
+	header       string // Name of the signature header, Authorization if empty.
+	failures     *signatureFailureTracker
+	clockSkew    time.Duration // Tolerance of the signature created/expires window.
+	now          func() time.Time
+	nonces       NonceStore // Nonces of authenticated requests, nil disables replay protection.
+	nonceTTL     time.Duration
+	allowedAlgs  []string // Signature algorithms accepted, all if empty.
 }
 
 // NewAuthService creates a new AuthService.
@@ -104,29 +192,104 @@ func NewAuthService(subService subscriptionKeyProvider, sigValidator signValidat
 	if sigValidator == nil {
 		return nil, errors.New("signValidator dependency is nil for AuthService")
 	}
-	return &subscriptionAuth{subService: subService, sigValidator: sigValidator}, nil
+	return &subscriptionAuth{subService: subService, sigValidator: sigValidator, clockSkew: DefaultSignatureClockSkew, now: time.Now}, nil
 }
 
-// AuthenticatedReq handles authorization, signature validation, and request body parsing.
-// It returns the parsed SubscriptionRequest or an AuthError if authentication/parsing fails.
-func (s *subscriptionAuth) AuthenticatedReq(ctx context.Context, body []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
-	slog.DebugContext(ctx, "processAuthenticatedRequest: Processing authentication", "authorization_header_present", authHeader != "")
+// SetClockSkew sets how far the clocks of subscribers may be off when a signature's created and
+// expires parameters are checked. A signature expired more than d ago, or created more than d
+// in the future, is rejected. A negative d restores DefaultSignatureClockSkew.
+func (s *subscriptionAuth) SetClockSkew(d time.Duration) {
+	if d < 0 {
+		d = DefaultSignatureClockSkew
+	}
+	s.clockSkew = d
+}
+
+// SetNonceStore rejects authenticated requests repeating the nonce of an earlier request of
+// the same subscriber, remembering nonces in store for ttl, DefaultNonceTTL if it is not positive.
+// Requests without a nonce are rejected. A nil store disables replay protection.
+func (s *subscriptionAuth) SetNonceStore(store NonceStore, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultNonceTTL
+	}
+	s.nonces, s.nonceTTL = store, ttl
+}
+
+// SetAllowedAlgorithms rejects requests whose signature header declares an algorithm
+// other than algs, before their signing key is looked up. No algs accepts any algorithm.
+func (s *subscriptionAuth) SetAllowedAlgorithms(algs []string) {
+	s.allowedAlgs = algs
+}
+
+// SetSignatureHeader sets the name of the header requests carry their signature in,
+// for networks that do not use Authorization. An empty name restores Authorization.
+func (s *subscriptionAuth) SetSignatureHeader(name string) {
+	s.header = name
+}
 
+// SetSignatureFailureAlerts raises an alert, published with pub if it is not nil, when the requests
+// of a subscriber fail signature validation cfg.Threshold times within cfg.Window, and rejects its
+// requests for cfg.BlockFor if set. A zero cfg.Threshold disables alerts.
+func (s *subscriptionAuth) SetSignatureFailureAlerts(cfg SignatureFailureAlertConfig, pub signatureAlertPublisher) {
+	if cfg.Threshold <= 0 {
+		s.failures = nil
+		return
+	}
+	s.failures = newSignatureFailureTracker(cfg, pub)
+}
+
+// signedReq parses the signature header and the body of a subscription request,
+// and checks that the request is signed by the subscriber it is for.
+func (s *subscriptionAuth) signedReq(ctx context.Context, body []byte, authHeader string) (*model.AuthHeader, *model.SubscriptionRequest, *model.AuthError) {
 	// 1. Parse Auth Header
-	ah, authErr := keySet(ctx, authHeader)
+	ah, authErr := keySet(ctx, signatureHeaderOrDefault(s.header), authHeader, s.allowedAlgs)
 	if authErr != nil {
-		return nil, authErr
+		return nil, nil, authErr
+	}
+	if authErr := s.failures.check(ctx, ah.SubscriberID); authErr != nil {
+		return nil, nil, authErr
+	}
+	if authErr := s.checkSignatureWindow(ctx, ah); authErr != nil {
+		return nil, nil, authErr
 	}
 
 	var subReq model.SubscriptionRequest
 	if err := json.NewDecoder(bytes.NewBuffer(body)).Decode(&subReq); err != nil {
 		slog.ErrorContext(ctx, "decodeRequestBody: Failed to decode request body", "error", err)
-		return nil, model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), "")
+		return nil, nil, model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), "")
 	}
 	// 3. Validate Subscriber ID Match
 	if subReq.SubscriberID != ah.SubscriberID {
 		slog.ErrorContext(ctx, "validateSubscriberIDMatch: SubscriberID in auth header does not match SubscriberID in body", "header_subscriber_id", ah.SubscriberID, "body_subscriber_id", subReq.SubscriberID)
-		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Subscriber ID in auth header and body do not match.", ah.SubscriberID)
+		return nil, nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Subscriber ID in auth header and body do not match.", ah.SubscriberID)
+	}
+	return ah, &subReq, nil
+}
+
+// checkSignatureWindow rejects a signature that expired or was created in the future,
+// give or take the clock skew, so that a captured signature cannot be replayed indefinitely.
+// Signatures without created or expires parameters are left to the signature validator.
+func (s *subscriptionAuth) checkSignatureWindow(ctx context.Context, ah *model.AuthHeader) *model.AuthError {
+	now := s.now()
+	if !ah.Expires.IsZero() && ah.Expires.Add(s.clockSkew).Before(now) {
+		slog.ErrorContext(ctx, "checkSignatureWindow: Signature has expired", "subscriber_id", ah.SubscriberID, "expires", ah.Expires)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Signature has expired.", ah.SubscriberID)
+	}
+	if !ah.Created.IsZero() && ah.Created.After(now.Add(s.clockSkew)) {
+		slog.ErrorContext(ctx, "checkSignatureWindow: Signature created in the future", "subscriber_id", ah.SubscriberID, "created", ah.Created)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Signature created time is in the future.", ah.SubscriberID)
+	}
+	return nil
+}
+
+// AuthenticatedReq handles authorization, signature validation, and request body parsing.
+// It returns the parsed SubscriptionRequest or an AuthError if authentication/parsing fails.
+func (s *subscriptionAuth) AuthenticatedReq(ctx context.Context, body []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	slog.DebugContext(ctx, "processAuthenticatedRequest: Processing authentication", "authorization_header_present", authHeader != "")
+
+	ah, subReq, authErr := s.signedReq(ctx, body, authHeader)
+	if authErr != nil {
+		return nil, authErr
 	}
 
 	// 4. Fetch Signing Public Key
@@ -139,13 +302,152 @@ func (s *subscriptionAuth) AuthenticatedReq(ctx context.Context, body []byte, au
 	// 5. Validate Signature
 	if err := s.sigValidator.Validate(ctx, body, authHeader, publicKey); err != nil {
 		slog.ErrorContext(ctx, "validateSignature: Signature validation failed", "error", err)
+		s.failures.fail(ctx, ah.SubscriberID)
 		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID) // SubscriberID might not be available here if keyID parsing failed earlier, but it's available in the main method. Let's pass it.
 	}
 
 	slog.DebugContext(ctx, "processAuthenticatedRequest: Signature validated successfully", "subscriber_id", ah.SubscriberID)
+	if authErr := s.checkNonce(ctx, ah.SubscriberID, subReq.Nonce); authErr != nil {
+		return nil, authErr
+	}
+	return subReq, nil
+}
+
+// checkNonce rejects a request whose nonce was already used by subscriberID, and remembers it otherwise.
+// It runs once the signature is validated, so that unsigned requests cannot use up the nonces of others.
+func (s *subscriptionAuth) checkNonce(ctx context.Context, subscriberID, nonce string) *model.AuthError {
+	if s.nonces == nil {
+		return nil
+	}
+	if nonce == "" {
+		slog.ErrorContext(ctx, "checkNonce: Request has no nonce", "subscriber_id", subscriberID)
+		return model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "nonce is required.", subscriberID)
+	}
+	key := subscriberID + "|" + nonce
+	fresh, err := s.nonces.Remember(ctx, key, s.nonceTTL)
+	if err != nil {
+		slog.ErrorContext(ctx, "checkNonce: Failed to remember nonce", "error", err, "subscriber_id", subscriberID)
+		return model.NewAuthError(http.StatusServiceUnavailable, model.ErrorTypeInternalError, model.ErrorCodeServiceUnavailable, "Could not check the request nonce, retry later.", subscriberID)
+	}
+	if !fresh {
+		slog.ErrorContext(ctx, "checkNonce: Replayed request", "subscriber_id", subscriberID, "nonce", nonce)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeReplayedRequest, "Replayed request: nonce has already been used.", subscriberID)
+	}
+	return nil
+}
+
+// AuthenticatedGatewayReq is AuthenticatedReq for requests signed by a gateway on behalf of the
+// subscriber they are for, in the X-Gateway-Authorization header. The signer must be subscribed as
+// a gateway in the domain of the request, and is not required to be the subscriber of the request.
+func (s *subscriptionAuth) AuthenticatedGatewayReq(ctx context.Context, body []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	slog.DebugContext(ctx, "AuthenticatedGatewayReq: Processing authentication", "authorization_header_present", authHeader != "")
+
+	ah, authErr := keySet(ctx, model.AuthHeaderGateway, authHeader, s.allowedAlgs)
+	if authErr != nil {
+		return nil, authErr
+	}
+	if authErr := s.failures.check(ctx, ah.SubscriberID); authErr != nil {
+		return nil, authErr
+	}
+	if authErr := s.checkSignatureWindow(ctx, ah); authErr != nil {
+		return nil, authErr
+	}
+	var subReq model.SubscriptionRequest
+	if err := json.NewDecoder(bytes.NewBuffer(body)).Decode(&subReq); err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedGatewayReq: Failed to decode request body", "error", err)
+		return nil, model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), "")
+	}
+	publicKey, err := s.subService.GetSigningPublicKey(ctx, ah.SubscriberID, subReq.Domain, model.RoleGateway, ah.UniqueID)
+	if err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedGatewayReq: Failed to fetch gateway public key for signature validation", "error", err, "gateway_id", ah.SubscriberID, "domain", subReq.Domain)
+		return nil, handleGetSigningKeyError(err, ah.SubscriberID)
+	}
+	if err := s.sigValidator.Validate(ctx, body, authHeader, publicKey); err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedGatewayReq: Signature validation failed", "error", err, "gateway_id", ah.SubscriberID)
+		s.failures.fail(ctx, ah.SubscriberID)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	}
+
+	slog.DebugContext(ctx, "AuthenticatedGatewayReq: Signature validated successfully", "gateway_id", ah.SubscriberID, "subscriber_id", subReq.SubscriberID)
+	if authErr := s.checkNonce(ctx, ah.SubscriberID, subReq.Nonce); authErr != nil {
+		return nil, authErr
+	}
 	return &subReq, nil
 }
 
+// AuthenticatedCreateReq is AuthenticatedReq for requests creating a subscription, which has no
+// signing key registered yet. The signature is validated with the signing public key in the request,
+// proving that the requester holds its private key, and must name the key_id of the request.
+func (s *subscriptionAuth) AuthenticatedCreateReq(ctx context.Context, body []byte, authHeader string) (*model.SubscriptionRequest, *model.AuthError) {
+	slog.DebugContext(ctx, "AuthenticatedCreateReq: Processing authentication", "authorization_header_present", authHeader != "")
+
+	ah, subReq, authErr := s.signedReq(ctx, body, authHeader)
+	if authErr != nil {
+		return nil, authErr
+	}
+	if subReq.SigningPublicKey == "" {
+		slog.ErrorContext(ctx, "AuthenticatedCreateReq: Request has no signing public key", "subscriber_id", ah.SubscriberID)
+		return nil, model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeBadRequest, "signing_public_key is required.", ah.SubscriberID)
+	}
+	if subReq.KeyID != ah.UniqueID {
+		slog.ErrorContext(ctx, "AuthenticatedCreateReq: Key ID in auth header does not match key_id in body", "header_key_id", ah.UniqueID, "body_key_id", subReq.KeyID)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Key ID in auth header and body do not match.", ah.SubscriberID)
+	}
+	if err := s.sigValidator.Validate(ctx, body, authHeader, subReq.SigningPublicKey); err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedCreateReq: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
+		s.failures.fail(ctx, ah.SubscriberID)
+		return nil, model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	}
+
+	slog.DebugContext(ctx, "AuthenticatedCreateReq: Signature validated successfully", "subscriber_id", ah.SubscriberID)
+	return subReq, nil
+}
+
+// AuthenticatedDeleteReq authenticates a request deleting the subscription of subscriberID holding keyID.
+// The body is a model.DeleteSubscriptionRequest naming the DELETE method and the subscription of the path,
+// and its nonce must not have been used before. It must be signed by subscriberID with the key of one of
+// its subscriptions, which need not be keyID: a subscriber that has rotated its keys deletes the
+// subscription of a retired key by signing with its current one. The signed body binds the key_id.
+func (s *subscriptionAuth) AuthenticatedDeleteReq(ctx context.Context, subscriberID, keyID string, body []byte, authHeader string) *model.AuthError {
+	slog.DebugContext(ctx, "AuthenticatedDeleteReq: Processing authentication", "authorization_header_present", authHeader != "")
+
+	ah, authErr := keySet(ctx, signatureHeaderOrDefault(s.header), authHeader, s.allowedAlgs)
+	if authErr != nil {
+		return authErr
+	}
+	if authErr := s.failures.check(ctx, ah.SubscriberID); authErr != nil {
+		return authErr
+	}
+	if authErr := s.checkSignatureWindow(ctx, ah); authErr != nil {
+		return authErr
+	}
+	if ah.SubscriberID != subscriberID {
+		slog.ErrorContext(ctx, "AuthenticatedDeleteReq: SubscriberID in auth header does not match the subscription to delete", "header_subscriber_id", ah.SubscriberID, "subscriber_id", subscriberID, "key_id", keyID)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Subscriber ID in auth header and path do not match.", ah.SubscriberID)
+	}
+	var delReq model.DeleteSubscriptionRequest
+	if err := json.NewDecoder(bytes.NewBuffer(body)).Decode(&delReq); err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedDeleteReq: Failed to decode request body", "error", err)
+		return model.NewAuthError(http.StatusBadRequest, model.ErrorTypeValidationError, model.ErrorCodeInvalidJSON, "Invalid request body: "+err.Error(), ah.SubscriberID)
+	}
+	if delReq.Method != http.MethodDelete || delReq.SubscriberID != subscriberID || delReq.KeyID != keyID {
+		slog.ErrorContext(ctx, "AuthenticatedDeleteReq: Request body does not match the subscription to delete", "method", delReq.Method, "body_subscriber_id", delReq.SubscriberID, "body_key_id", delReq.KeyID, "subscriber_id", subscriberID, "key_id", keyID)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeIDMismatch, "Request body does not name the DELETE of the subscription in the path.", ah.SubscriberID)
+	}
+	publicKey, err := s.subService.GetSigningPublicKeyByKeyID(ctx, ah.SubscriberID, ah.UniqueID)
+	if err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedDeleteReq: Failed to fetch public key for signature validation", "error", err, "subscriber_id", ah.SubscriberID)
+		return handleGetSigningKeyError(err, ah.SubscriberID)
+	}
+	if err := s.sigValidator.Validate(ctx, body, authHeader, publicKey); err != nil {
+		slog.ErrorContext(ctx, "AuthenticatedDeleteReq: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
+		s.failures.fail(ctx, ah.SubscriberID)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	}
+	slog.DebugContext(ctx, "AuthenticatedDeleteReq: Signature validated successfully", "subscriber_id", ah.SubscriberID)
+	return s.checkNonce(ctx, ah.SubscriberID, delReq.Nonce)
+}
+
 func handleGetSigningKeyError(err error, subscriberID string) *model.AuthError {
 	if errors.Is(err, repository.ErrSubscriberKeyNotFound) {
 		return model.NewAuthError(http.StatusNotFound, model.ErrorTypeNotFoundError, model.ErrorCodeSubscriptionNotFound, "Signing key not found for the subscriber.", subscriberID)
@@ -158,9 +460,64 @@ type npKeyProvider interface {
 	LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (signingPublicKey string, encrPublicKey string, err error)
 }
 
+// rotatedKey is the last signing key seen for a participant's key id, along with the key it replaced.
+type rotatedKey struct {
+	Current       string    `json:"current"`
+	Previous      string    `json:"previous,omitempty"`
+	PreviousUntil time.Time `json:"previousUntil,omitempty"`
+}
+
+// rotate records current as the key of k, keeping the key it replaces until now+grace.
+// It reports whether k changed.
+func (k *rotatedKey) rotate(now time.Time, current string, grace time.Duration) bool {
+	if k.Current == current {
+		return false
+	}
+	k.Previous, k.PreviousUntil, k.Current = k.Current, now.Add(grace), current
+	return true
+}
+
+// candidates returns the current key of k and, until its grace period ends at now, the previous one.
+func (k *rotatedKey) candidates(now time.Time) []string {
+	if k.Previous == "" || !now.Before(k.PreviousUntil) {
+		return []string{k.Current}
+	}
+	return []string{k.Current, k.Previous}
+}
+
+// keyRotationStore shares the rotated keys of participants between gateway replicas and restarts.
+type keyRotationStore interface {
+	// GetIfExists returns the value stored at key, reporting false if there is none.
+	GetIfExists(ctx context.Context, key string) (string, bool, error)
+	// Set stores value at key. A zero ttl never expires it.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// keyRotationKeyPrefix namespaces the rotated keys of participants in a keyRotationStore.
+const keyRotationKeyPrefix = "signing_key_rotation:"
+
 type txnSignValidator struct {
-	sv signValidator
-	km npKeyProvider
+	sv     signValidator
+	km     npKeyProvider
+	header string // Name of the signature header, Authorization if empty.
+
+	// bindRequestID requires signatures to be bound to the X-Request-ID of the request.
+	bindRequestID bool
+
+	allowedAlgs []string // Signature algorithms accepted, all if empty.
+
+	clockSkew time.Duration // Tolerance of the created/expires window of signatures covering headers.
+
+	cache *signingKeyCache // Caches the keys of km, nil means every transaction looks its key up.
+
+	failures *signatureFailureTracker // Alerts on repeated signature failures, nil disables alerts.
+
+	// gracePeriod is how long a replaced signing key is still accepted. Zero disables it.
+	gracePeriod time.Duration
+	now         func() time.Time
+	rotations   keyRotationStore // Shares rotated keys, nil keeps them in keys.
+	mu          sync.Mutex
+	keys        map[string]*rotatedKey
 }
 
 // NewTxnSignValidator initializes and returns a new validate sign step.
@@ -173,28 +530,218 @@ func NewTxnSignValidator(sv signValidator, km npKeyProvider) (*txnSignValidator,
 		slog.Error("NewTxnSignValidator: npKeyProvider dependency is nil")
 		return nil, errors.New("npKeyProvider dependency is nil")
 	}
-	return &txnSignValidator{sv: sv, km: km}, nil
+	return &txnSignValidator{sv: sv, km: km, clockSkew: DefaultSignatureClockSkew, now: time.Now, keys: make(map[string]*rotatedKey)}, nil
+}
+
+// SetClockSkew sets how far the clocks of participants may be off when the created and expires
+// parameters of signatures covering HTTP headers are checked. A negative d restores DefaultSignatureClockSkew.
+func (s *txnSignValidator) SetClockSkew(d time.Duration) {
+	if d < 0 {
+		d = DefaultSignatureClockSkew
+	}
+	s.clockSkew = d
+}
+
+// SetKeyRotationGracePeriod keeps accepting signatures made with a participant's previous
+// signing key for d after the registry starts returning a new one, so that transactions
+// signed before a subscription update are not rejected. A zero d disables the grace period.
+func (s *txnSignValidator) SetKeyRotationGracePeriod(d time.Duration) {
+	s.gracePeriod = d
+}
+
+// SetKeyRotationStore keeps the previous signing keys of the grace period in store, such as Redis,
+// instead of process memory, so that they are accepted by every replica and after a restart.
+func (s *txnSignValidator) SetKeyRotationStore(store keyRotationStore) {
+	s.rotations = store
 }
 
-func (s *txnSignValidator) Validate(ctx context.Context, body []byte, authHeader string) *model.AuthError {
-	ah, authErr := keySet(ctx, authHeader)
+// SetSignatureHeader sets the name of the header transactions carry their signature in,
+// for networks that do not use Authorization. An empty name restores Authorization.
+func (s *txnSignValidator) SetSignatureHeader(name string) {
+	s.header = name
+}
+
+// SetAllowedAlgorithms rejects transactions whose signature header declares an algorithm
+// other than algs, before their signing key is looked up. No algs accepts any algorithm.
+func (s *txnSignValidator) SetAllowedAlgorithms(algs []string) {
+	s.allowedAlgs = algs
+}
+
+// SetBindRequestID requires every transaction to carry an X-Request-ID header and a signature
+// bound to it, so that a captured signature cannot be reused on a request with another id.
+func (s *txnSignValidator) SetBindRequestID(enabled bool) {
+	s.bindRequestID = enabled
+}
+
+// SetKeyCache caches the signing keys looked up for cfg.MaxAge, refreshing keys used shortly
+// before they expire in the background. A zero cfg.MaxAge disables the cache.
+func (s *txnSignValidator) SetKeyCache(cfg KeyCacheConfig) {
+	if cfg.MaxAge <= 0 {
+		s.cache = nil
+		return
+	}
+	s.cache = newSigningKeyCache(s.km, cfg)
+}
+
+// SetSignatureFailureAlerts raises an alert, published with pub if it is not nil, when the transactions
+// of a subscriber fail signature validation cfg.Threshold times within cfg.Window, and rejects its
+// transactions for cfg.BlockFor if set. A zero cfg.Threshold disables alerts.
+func (s *txnSignValidator) SetSignatureFailureAlerts(cfg SignatureFailureAlertConfig, pub signatureAlertPublisher) {
+	if cfg.Threshold <= 0 {
+		s.failures = nil
+		return
+	}
+	s.failures = newSignatureFailureTracker(cfg, pub)
+}
+
+// signingKey returns the signing key of subscriberID's keyID, from the key cache if it is enabled.
+func (s *txnSignValidator) signingKey(ctx context.Context, subscriberID, keyID string) (string, error) {
+	if s.cache != nil {
+		return s.cache.get(ctx, subscriberID, keyID)
+	}
+	key, _, err := s.km.LookupNPKeys(ctx, subscriberID, keyID)
+	return key, err
+}
+
+// candidateKeys returns the keys a signature may be verified with: the current key and,
+// within the grace period after a rotation, the key it replaced.
+func (s *txnSignValidator) candidateKeys(ctx context.Context, subscriberID, keyID, current string) []string {
+	if s.gracePeriod <= 0 {
+		return []string{current}
+	}
+	if s.rotations != nil {
+		return s.storedCandidateKeys(ctx, subscriberID, keyID, current)
+	}
+	id := subscriberID + "|" + keyID
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		s.keys[id] = &rotatedKey{Current: current}
+		return []string{current}
+	}
+	now := s.now()
+	k.rotate(now, current, s.gracePeriod)
+	return k.candidates(now)
+}
+
+// storedCandidateKeys is candidateKeys with the rotated key kept in the key rotation store.
+// If the store fails, only the current key is accepted.
+func (s *txnSignValidator) storedCandidateKeys(ctx context.Context, subscriberID, keyID, current string) []string {
+	storeKey := keyRotationKeyPrefix + subscriberID + "|" + keyID
+	value, ok, err := s.rotations.GetIfExists(ctx, storeKey)
+	if err != nil {
+		slog.WarnContext(ctx, "txnSignValidator: Failed to get rotated signing key, accepting only the current key", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+		return []string{current}
+	}
+	var k rotatedKey
+	if ok {
+		if err := json.Unmarshal([]byte(value), &k); err != nil {
+			slog.WarnContext(ctx, "txnSignValidator: Invalid rotated signing key record, replacing it", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+			k = rotatedKey{}
+		}
+	}
+	now := s.now()
+	if k.Previous == current && now.Before(k.PreviousUntil) {
+		// This replica still has the key another one already saw replaced, e.g. in its key cache.
+		return []string{current}
+	}
+	if !k.rotate(now, current, s.gracePeriod) {
+		return k.candidates(now)
+	}
+	if k.Previous == "" {
+		k.PreviousUntil = time.Time{}
+	}
+	b, err := json.Marshal(&k)
+	if err == nil {
+		err = s.rotations.Set(ctx, storeKey, string(b), 0)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "txnSignValidator: Failed to store rotated signing key", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+	}
+	return k.candidates(now)
+}
+
+// Validate validates the signature of a transaction. requestID is its X-Request-ID header,
+// the signature must be bound to it if SetBindRequestID is enabled.
+func (s *txnSignValidator) Validate(ctx context.Context, body []byte, authHeader, requestID string) *model.AuthError {
+	ah, authErr := keySet(ctx, signatureHeaderOrDefault(s.header), authHeader, s.allowedAlgs)
 	if authErr != nil {
 		return authErr
 	}
+	if authErr := s.failures.check(ctx, ah.SubscriberID); authErr != nil {
+		return authErr
+	}
+	signedHeaders, err := extraSignedHeaders(authHeader)
+	if err != nil {
+		slog.ErrorContext(ctx, "txnSignValidator.Validate: Invalid headers parameter", "error", err, "subscriber_id", ah.SubscriberID)
+		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	}
+	if s.bindRequestID {
+		if requestID == "" {
+			slog.ErrorContext(ctx, "txnSignValidator.Validate: Request id header missing", "subscriber_id", ah.SubscriberID)
+			return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeMissingAuthHeader, model.RequestIDHeader+" header missing.", ah.SubscriberID)
+		}
+		if !slices.Contains(signedHeaders, requestIDSignedHeader) {
+			slog.ErrorContext(ctx, "txnSignValidator.Validate: Signature not bound to the request id", "subscriber_id", ah.SubscriberID)
+			s.failures.fail(ctx, ah.SubscriberID)
+			return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+		}
+	}
+	values := http.Header{}
+	if requestID != "" {
+		values.Set(model.RequestIDHeader, requestID)
+	}
 
 	slog.DebugContext(ctx, "txnSignValidator.Validate: Auth header parsed", "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
 
-	key, _, err := s.km.LookupNPKeys(ctx, ah.SubscriberID, ah.UniqueID)
+	key, err := s.signingKey(ctx, ah.SubscriberID, ah.UniqueID)
 	if err != nil {
 		slog.ErrorContext(ctx, "txnSignValidator.Validate: Failed to get signing public key from npKeyProvider", "error", err, "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
 		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeKeyUnavailable, "Failed to retrieve signing key for validation.", ah.SubscriberID)
 	}
 
-	if err := s.sv.Validate(ctx, body, authHeader, key); err != nil {
-		slog.ErrorContext(ctx, "txnSignValidator.Validate: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
-		return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+	keys := s.candidateKeys(ctx, ah.SubscriberID, ah.UniqueID, key)
+	for i, k := range keys {
+		if len(signedHeaders) > 0 {
+			err = verifySignedHeaders(s.now(), s.clockSkew, body, authHeader, k, values)
+		} else {
+			err = s.sv.Validate(ctx, body, authHeader, k)
+		}
+		if err == nil {
+			if i > 0 {
+				slog.InfoContext(ctx, "txnSignValidator.Validate: Signature validated with previous key within grace period", "subscriber_id", ah.SubscriberID, "key_id", ah.UniqueID)
+			}
+			slog.DebugContext(ctx, "txnSignValidator.Validate: Signature validated successfully", "subscriber_id", ah.SubscriberID)
+			return nil
+		}
 	}
+	slog.ErrorContext(ctx, "txnSignValidator.Validate: Signature validation failed", "error", err, "subscriber_id", ah.SubscriberID)
+	s.failures.fail(ctx, ah.SubscriberID)
+	return model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidSignature, "Invalid request signature.", ah.SubscriberID)
+}
 
-	slog.DebugContext(ctx, "txnSignValidator.Validate: Signature validated successfully", "subscriber_id", ah.SubscriberID)
-	return nil
+// KeyCacheWarmUpEntry identifies a network participant whose signing key is pre-fetched on startup.
+type KeyCacheWarmUpEntry struct {
+	SubscriberID string `yaml:"subscriberID"`
+	KeyID        string `yaml:"keyID"`
+}
+
+// WarmUp pre-populates the key cache by looking up the signing keys of the given participants.
+// It is best-effort: failures are logged and skipped. Callers that must not block should run it in a goroutine.
+func (s *txnSignValidator) WarmUp(ctx context.Context, entries []KeyCacheWarmUpEntry) {
+	slog.InfoContext(ctx, "txnSignValidator.WarmUp: Warming up key cache", "count", len(entries))
+	warmed := 0
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			slog.WarnContext(ctx, "txnSignValidator.WarmUp: Context done, stopping warm-up", "error", ctx.Err(), "warmed", warmed)
+			return
+		}
+		if _, err := s.signingKey(ctx, e.SubscriberID, e.KeyID); err != nil {
+			slog.WarnContext(ctx, "txnSignValidator.WarmUp: Failed to fetch signing key", "error", err, "subscriber_id", e.SubscriberID, "key_id", e.KeyID)
+			continue
+		}
+		warmed++
+	}
+	slog.InfoContext(ctx, "txnSignValidator.WarmUp: Key cache warm-up completed", "warmed", warmed, "failed", len(entries)-warmed)
 }
@@ -16,9 +16,12 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
@@ -37,8 +40,9 @@ type signer interface {
 }
 
 type authGenService struct {
-	keyManager signingKM
-	signer     signer
+	keyManager    signingKM
+	signer        signer
+	bindRequestID bool // Binds the signatures of RequestAuthHeader to their request id.
 }
 
 // NewAuthGenService creates a new authGenService.
@@ -57,9 +61,46 @@ func NewAuthGenService(keyManager signingKM, signer signer) (*authGenService, er
 	}, nil
 }
 
+// requestIDSignedHeader is listed in the headers of a signature bound to a request id.
+const requestIDSignedHeader = "x-request-id"
+
+// signBound signs the signing string of body extended with headers using the base64 Ed25519 seed
+// privateKey. The Beckn signer only signs the signing string of a body.
+func signBound(body []byte, privateKey string, created, expires int64, headers ...signedHeader) (string, error) {
+	seed, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("error decoding private key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return "", errors.New("invalid seed length")
+	}
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed), signingString(body, created, expires, headers...))
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
 // AuthHeader signs the provided body using the specified subscriber's key
 // and generates the Authorization header value.
 func (s *authGenService) AuthHeader(ctx context.Context, body []byte, subscriberID string) (string, error) {
+	return s.sign(ctx, body, subscriberID, "")
+}
+
+// SetBindRequestID makes RequestAuthHeader bind signatures to the request id of their request.
+func (s *authGenService) SetBindRequestID(enabled bool) {
+	s.bindRequestID = enabled
+}
+
+// RequestAuthHeader is AuthHeader for a request whose X-Request-ID is requestID. If SetBindRequestID
+// is enabled, the signature is bound to requestID so that it is not valid on a request with
+// another id. Otherwise, or if requestID is empty, it is the same as AuthHeader.
+func (s *authGenService) RequestAuthHeader(ctx context.Context, body []byte, subscriberID, requestID string) (string, error) {
+	if !s.bindRequestID {
+		requestID = ""
+	}
+	return s.sign(ctx, body, subscriberID, requestID)
+}
+
+// sign generates the Authorization header value of body, bound to requestID if it is not empty.
+func (s *authGenService) sign(ctx context.Context, body []byte, subscriberID, requestID string) (string, error) {
 	keySet, err := s.keyManager.Keyset(ctx, subscriberID)
 	if err != nil {
 		slog.ErrorContext(ctx, "AuthGenService: Failed to get keyset for signing", "error", err, "subscriber_id", subscriberID)
@@ -69,12 +110,21 @@ func (s *authGenService) AuthHeader(ctx context.Context, body []byte, subscriber
 	createdAt := time.Now().Unix()
 	expires := time.Now().Add(5 * time.Minute).Unix()
 
-	signature, err := s.signer.Sign(ctx, body, keySet.SigningPrivate, createdAt, expires)
+	headers := strings.Join(defaultSignedHeaders, " ")
+	var signature string
+	if requestID != "" {
+		// The request id is covered as a header of the signing string, so that the digest of the
+		// body stays what every Beckn verifier expects.
+		headers += " " + requestIDSignedHeader
+		signature, err = signBound(body, keySet.SigningPrivate, createdAt, expires, signedHeader{name: requestIDSignedHeader, value: requestID})
+	} else {
+		signature, err = s.signer.Sign(ctx, body, keySet.SigningPrivate, createdAt, expires)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "AuthGenService: Failed to sign body", "error", err)
 		return "", fmt.Errorf("failed to sign body: %w", err)
 	}
 	return fmt.Sprintf(
-		`Signature keyId="%s|%s|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
-		subscriberID, keySet.UniqueKeyID, createdAt, expires, signature), nil
+		`Signature keyId="%s|%s|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="%s",signature="%s"`,
+		subscriberID, keySet.UniqueKeyID, createdAt, expires, headers, signature), nil
 }
@@ -16,9 +16,14 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 )
@@ -162,3 +167,69 @@ func TestAuthHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestAuthHeader(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyset := &model.Keyset{UniqueKeyID: "key-123", SigningPrivate: base64.StdEncoding.EncodeToString(priv.Seed())}
+	body := []byte(`{}`)
+	tests := []struct {
+		name        string
+		bind        bool
+		requestID   string
+		wantHeaders string
+		wantBound   bool // Whether the signature covers the request id rather than being made by the signer.
+	}{
+		{name: "bound", bind: true, requestID: "req-1", wantHeaders: `headers="(created) (expires) digest x-request-id"`, wantBound: true},
+		{name: "binding without request id", bind: true, wantHeaders: `headers="(created) (expires) digest"`},
+		{name: "binding disabled", requestID: "req-1", wantHeaders: `headers="(created) (expires) digest"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &payloadSigner{}
+			authGenService, err := NewAuthGenService(&mockSigningKM{keyset: keyset}, signer)
+			if err != nil {
+				t.Fatalf("NewAuthGenService() error = %v", err)
+			}
+			authGenService.SetBindRequestID(tt.bind)
+
+			got, err := authGenService.RequestAuthHeader(context.Background(), body, "test.subscriber.com", tt.requestID)
+			if err != nil {
+				t.Fatalf("RequestAuthHeader() error = %v", err)
+			}
+			if !strings.Contains(got, tt.wantHeaders) {
+				t.Errorf("RequestAuthHeader() = %q, does not contain %q", got, tt.wantHeaders)
+			}
+			if !tt.wantBound {
+				if string(signer.payload) != string(body) {
+					t.Errorf("RequestAuthHeader() signed %q, want the body %q", signer.payload, body)
+				}
+				return
+			}
+			if signer.payload != nil {
+				t.Errorf("RequestAuthHeader() signed %q with the body signer, want a bound signature", signer.payload)
+			}
+			values := http.Header{}
+			values.Set(requestIDSignedHeader, tt.requestID)
+			if err := verifySignedHeaders(time.Now(), 0, body, got, base64.StdEncoding.EncodeToString(pub), values); err != nil {
+				t.Errorf("verifySignedHeaders() of the bound signature error = %v", err)
+			}
+			values.Set(requestIDSignedHeader, "req-2")
+			if err := verifySignedHeaders(time.Now(), 0, body, got, base64.StdEncoding.EncodeToString(pub), values); err == nil {
+				t.Error("verifySignedHeaders() with another request id error = nil, want an error")
+			}
+		})
+	}
+}
+
+// payloadSigner is a signer recording the payload it signs.
+type payloadSigner struct {
+	payload []byte
+}
+
+func (s *payloadSigner) Sign(ctx context.Context, body []byte, privateKey string, created, expires int64) (string, error) {
+	s.payload = body
+	return "signature", nil
+}
@@ -16,22 +16,42 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	becknmodel "github.com/beckn-one/beckn-onix/pkg/model"
+	becknsigner "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"github.com/google/go-cmp/cmp"
 )
 
 // mockSubscriptionKeyProvider is a mock for subscriptionKeyProvider.
 type mockSubscriptionKeyProvider struct {
 	key string
 	err error
+
+	gotSubscriberID string
+	gotRole         model.Role
 }
 
 func (m *mockSubscriptionKeyProvider) GetSigningPublicKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (string, error) {
+	m.gotSubscriberID, m.gotRole = subscriberID, role
+	return m.key, m.err
+}
+
+func (m *mockSubscriptionKeyProvider) GetSigningPublicKeyByKeyID(ctx context.Context, subscriberID, keyID string) (string, error) {
 	return m.key, m.err
 }
 
@@ -55,6 +75,25 @@ func (m *mockNPKeyProvider) LookupNPKeys(ctx context.Context, subscriberID, uniq
 	return m.signingKey, m.encrKey, m.err
 }
 
+// cachingNPKeyProvider is a mock npKeyProvider that records successfully looked up keys in a cache.
+type cachingNPKeyProvider struct {
+	mu    sync.Mutex
+	keys  map[string]string
+	fail  map[string]bool
+	cache map[string]string
+}
+
+func (m *cachingNPKeyProvider) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail[subscriberID] {
+		return "", "", errors.New("lookup failed")
+	}
+	key := m.keys[subscriberID]
+	m.cache[subscriberID+"_"+uniqueKeyID] = key
+	return key, "", nil
+}
+
 func TestParseAuthHeader(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -65,9 +104,26 @@ func TestParseAuthHeader(t *testing.T) {
 		{
 			name:       "valid header",
 			authHeader: `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",created="1678886400",expires="1678886700",headers="(created) (expires) digest",signature="signature_value"`,
-			want:       &model.AuthHeader{SubscriberID: "bpp.example.com", UniqueID: "key-1", Algorithm: "ed25519"},
+			want:       &model.AuthHeader{SubscriberID: "bpp.example.com", UniqueID: "key-1", Algorithm: "ed25519", Created: time.Unix(1678886400, 0), Expires: time.Unix(1678886700, 0)},
 			wantErr:    "",
 		},
+		{
+			name:       "header without created and expires",
+			authHeader: `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519"`,
+			want:       &model.AuthHeader{SubscriberID: "bpp.example.com", UniqueID: "key-1", Algorithm: "ed25519"},
+		},
+		{
+			name:       "malformed created",
+			authHeader: `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",created="yesterday",expires="1678886700"`,
+			want:       nil,
+			wantErr:    "created parameter 'yesterday' is not a unix timestamp",
+		},
+		{
+			name:       "malformed expires",
+			authHeader: `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",created="1678886400",expires="1678886700.5"`,
+			want:       nil,
+			wantErr:    "expires parameter '1678886700.5' is not a unix timestamp",
+		},
 		{
 			name:       "missing keyId parameter",
 			authHeader: `Signature algorithm="ed25519",created="1678886400"`,
@@ -119,10 +175,11 @@ func TestParseAuthHeader(t *testing.T) {
 func TestKeySet(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
-		name       string
-		authHeader string
-		wantAuthH  *model.AuthHeader
-		wantErr    *model.AuthError
+		name        string
+		authHeader  string
+		allowedAlgs []string
+		wantAuthH   *model.AuthHeader
+		wantErr     *model.AuthError
 	}{
 		{
 			name:       "valid header",
@@ -142,13 +199,31 @@ func TestKeySet(t *testing.T) {
 			wantAuthH:  nil,
 			wantErr:    model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Invalid Authorization header format: keyId parameter has incorrect format", "unknown"),
 		},
+		{
+			name:        "allowed algorithm",
+			authHeader:  `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519"`,
+			allowedAlgs: []string{"rsa-sha256", "ED25519"},
+			wantAuthH:   &model.AuthHeader{SubscriberID: "bpp.example.com", UniqueID: "key-1", Algorithm: "ed25519"},
+		},
+		{
+			name:        "keyId algorithm not allowed",
+			authHeader:  `Signature keyId="bpp.example.com|key-1|rsa-sha256",algorithm="ed25519"`,
+			allowedAlgs: []string{"ed25519"},
+			wantErr:     model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Signature algorithm 'rsa-sha256' is not allowed in the Authorization header.", "bpp.example.com"),
+		},
+		{
+			name:        "algorithm parameter not allowed",
+			authHeader:  `Signature keyId="bpp.example.com|key-1|ed25519",algorithm="hmac-sha256"`,
+			allowedAlgs: []string{"ed25519"},
+			wantErr:     model.NewAuthError(http.StatusUnauthorized, model.ErrorTypeAuthError, model.ErrorCodeInvalidAuthHeader, "Signature algorithm 'hmac-sha256' is not allowed in the Authorization header.", "bpp.example.com"),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotAuthH, gotErr := keySet(ctx, tt.authHeader)
+			gotAuthH, gotErr := keySet(ctx, model.AuthHeaderSubscriber, tt.authHeader, tt.allowedAlgs)
 			if tt.wantErr != nil {
-				if gotErr == nil || gotErr.StatusCode != tt.wantErr.StatusCode || !strings.Contains(gotErr.Message, tt.wantErr.Message) {
+				if gotErr == nil || gotErr.StatusCode != tt.wantErr.StatusCode || gotErr.ErrorCode != tt.wantErr.ErrorCode || !strings.Contains(gotErr.Message, tt.wantErr.Message) {
 					t.Errorf("keySet() error = %v, want %v", gotErr, tt.wantErr)
 				}
 				if gotAuthH != nil {
@@ -417,7 +492,7 @@ func TestTxnSignValidator_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			validator, _ := NewTxnSignValidator(tt.mockSV, tt.mockKM)
-			gotErr := validator.Validate(ctx, tt.body, tt.authHeader)
+			gotErr := validator.Validate(ctx, tt.body, tt.authHeader, "")
 
 			if tt.wantErr != nil {
 				if gotErr == nil || gotErr.StatusCode != tt.wantErr.StatusCode || !strings.Contains(gotErr.Message, tt.wantErr.Message) {
@@ -431,3 +506,971 @@ func TestTxnSignValidator_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestTxnSignValidator_Validate_CustomSignatureHeader(t *testing.T) {
+	ctx := context.Background()
+	validator, err := NewTxnSignValidator(&mockSignValidator{}, &mockNPKeyProvider{signingKey: "mock-signing-key"})
+	if err != nil {
+		t.Fatalf("NewTxnSignValidator() error = %v", err)
+	}
+	validator.SetSignatureHeader("X-Beckn-Signature")
+
+	if gotErr := validator.Validate(ctx, []byte(`{}`), `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`, ""); gotErr != nil {
+		t.Errorf("Validate() unexpected error = %v", gotErr)
+	}
+	gotErr := validator.Validate(ctx, []byte(`{}`), "", "")
+	if gotErr == nil || gotErr.ErrorCode != model.ErrorCodeMissingAuthHeader {
+		t.Fatalf("Validate() error = %v, want error code %s", gotErr, model.ErrorCodeMissingAuthHeader)
+	}
+	if want := "X-Beckn-Signature header missing."; gotErr.Message != want {
+		t.Errorf("Validate() error message = %q, want %q", gotErr.Message, want)
+	}
+}
+
+func TestAuthenticatedReq_CustomSignatureHeader(t *testing.T) {
+	auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	auth.SetSignatureHeader("X-Beckn-Signature")
+
+	_, gotErr := auth.AuthenticatedReq(context.Background(), []byte(`{}`), `Signature keyId="malformed"`)
+	if gotErr == nil || gotErr.ErrorCode != model.ErrorCodeInvalidAuthHeader {
+		t.Fatalf("AuthenticatedReq() error = %v, want error code %s", gotErr, model.ErrorCodeInvalidAuthHeader)
+	}
+	if want := "Invalid X-Beckn-Signature header format"; !strings.HasPrefix(gotErr.Message, want) {
+		t.Errorf("AuthenticatedReq() error message = %q, want prefix %q", gotErr.Message, want)
+	}
+}
+
+func TestAuthenticatedGatewayReq(t *testing.T) {
+	ctx := context.Background()
+	gatewayHeader := `Signature keyId="gateway.com|key1|ed25519",algorithm="ed25519"`
+	body := []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP"}`)
+
+	tests := []struct {
+		name          string
+		authHeader    string
+		keys          *mockSubscriptionKeyProvider
+		sigVal        *mockSignValidator
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:       "signed by a subscribed gateway",
+			authHeader: gatewayHeader,
+			keys:       &mockSubscriptionKeyProvider{key: "gateway-key"},
+			sigVal:     &mockSignValidator{},
+		},
+		{
+			name:          "unsigned",
+			keys:          &mockSubscriptionKeyProvider{key: "gateway-key"},
+			sigVal:        &mockSignValidator{},
+			wantErrorCode: model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:          "signer is not a subscribed gateway",
+			authHeader:    gatewayHeader,
+			keys:          &mockSubscriptionKeyProvider{err: repository.ErrSubscriberKeyNotFound},
+			sigVal:        &mockSignValidator{},
+			wantErrorCode: model.ErrorCodeSubscriptionNotFound,
+		},
+		{
+			name:          "invalid signature",
+			authHeader:    gatewayHeader,
+			keys:          &mockSubscriptionKeyProvider{key: "gateway-key"},
+			sigVal:        &mockSignValidator{err: errors.New("bad signature")},
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuthService(tt.keys, tt.sigVal)
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+
+			subReq, authErr := auth.AuthenticatedGatewayReq(ctx, body, tt.authHeader)
+			if tt.wantErrorCode != "" {
+				if authErr == nil || authErr.ErrorCode != tt.wantErrorCode {
+					t.Errorf("AuthenticatedGatewayReq() error = %v, want error code %s", authErr, tt.wantErrorCode)
+				}
+				return
+			}
+			if authErr != nil {
+				t.Fatalf("AuthenticatedGatewayReq() unexpected error = %v", authErr)
+			}
+			if subReq.SubscriberID != "test.com" {
+				t.Errorf("AuthenticatedGatewayReq() subscriber = %q, want %q", subReq.SubscriberID, "test.com")
+			}
+			if tt.keys.gotSubscriberID != "gateway.com" || tt.keys.gotRole != model.RoleGateway {
+				t.Errorf("GetSigningPublicKey() called for %q as %q, want %q as %q", tt.keys.gotSubscriberID, tt.keys.gotRole, "gateway.com", model.RoleGateway)
+			}
+		})
+	}
+}
+
+func TestAuthenticatedReq_SignatureWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP"}`)
+	header := func(created, expires time.Time) string {
+		return fmt.Sprintf(`Signature keyId="test.com|key1|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="sig"`, created.Unix(), expires.Unix())
+	}
+	tests := []struct {
+		name        string
+		authHeader  string
+		clockSkew   time.Duration
+		wantMessage string
+	}{
+		{name: "within window", authHeader: header(now.Add(-time.Minute), now.Add(time.Minute))},
+		{name: "no created and expires", authHeader: `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`},
+		{name: "expired", authHeader: header(now.Add(-time.Hour), now.Add(-time.Minute)), wantMessage: "Signature has expired."},
+		{name: "expired within clock skew", authHeader: header(now.Add(-time.Hour), now.Add(-time.Minute)), clockSkew: 2 * time.Minute},
+		{name: "created in the future", authHeader: header(now.Add(time.Minute), now.Add(time.Hour)), wantMessage: "Signature created time is in the future."},
+		{name: "created in the future within clock skew", authHeader: header(now.Add(time.Minute), now.Add(time.Hour)), clockSkew: 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, &mockSignValidator{})
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+			auth.SetClockSkew(tt.clockSkew)
+			auth.now = func() time.Time { return now }
+
+			_, gotErr := auth.AuthenticatedReq(context.Background(), body, tt.authHeader)
+			if tt.wantMessage == "" {
+				if gotErr != nil {
+					t.Errorf("AuthenticatedReq() error = %v, want nil", gotErr)
+				}
+				return
+			}
+			if gotErr == nil || gotErr.ErrorCode != model.ErrorCodeInvalidSignature || gotErr.Message != tt.wantMessage {
+				t.Errorf("AuthenticatedReq() error = %v, want %s %q", gotErr, model.ErrorCodeInvalidSignature, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestSubscriptionAuth_SetClockSkew(t *testing.T) {
+	auth, err := NewAuthService(&mockSubscriptionKeyProvider{}, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	if auth.clockSkew != DefaultSignatureClockSkew {
+		t.Errorf("default clockSkew = %s, want %s", auth.clockSkew, DefaultSignatureClockSkew)
+	}
+	auth.SetClockSkew(time.Minute)
+	if auth.clockSkew != time.Minute {
+		t.Errorf("clockSkew = %s, want 1m0s", auth.clockSkew)
+	}
+	auth.SetClockSkew(-time.Second)
+	if auth.clockSkew != DefaultSignatureClockSkew {
+		t.Errorf("clockSkew after negative value = %s, want %s", auth.clockSkew, DefaultSignatureClockSkew)
+	}
+}
+
+func TestAuthenticatedReq_AllowedAlgorithms(t *testing.T) {
+	body := []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP"}`)
+	keys := &mockSubscriptionKeyProvider{err: errors.New("key lookup must not run")}
+	auth, err := NewAuthService(keys, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	auth.SetAllowedAlgorithms([]string{"ed25519"})
+
+	_, authErr := auth.AuthenticatedReq(context.Background(), body, `Signature keyId="test.com|key1|rsa",algorithm="rsa"`)
+	if authErr == nil || authErr.ErrorCode != model.ErrorCodeInvalidAuthHeader {
+		t.Errorf("AuthenticatedReq() error = %v, want %s before the key lookup", authErr, model.ErrorCodeInvalidAuthHeader)
+	}
+}
+
+func TestTxnSignValidator_Validate_AllowedAlgorithms(t *testing.T) {
+	tests := []struct {
+		name       string
+		authHeader string
+		wantCode   model.ErrorCode
+	}{
+		{name: "allowed algorithm", authHeader: `Signature keyId="np.com|key1|ed25519",algorithm="ed25519"`},
+		{name: "disallowed algorithm", authHeader: `Signature keyId="np.com|key1|rsa",algorithm="rsa"`, wantCode: model.ErrorCodeInvalidAuthHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewTxnSignValidator(&mockSignValidator{}, &mockNPKeyProvider{signingKey: "key"})
+			if err != nil {
+				t.Fatalf("NewTxnSignValidator() error = %v", err)
+			}
+			v.SetAllowedAlgorithms([]string{"ed25519"})
+
+			authErr := v.Validate(context.Background(), []byte(`{}`), tt.authHeader, "")
+			if tt.wantCode == "" {
+				if authErr != nil {
+					t.Errorf("Validate() error = %v, want nil", authErr)
+				}
+				return
+			}
+			if authErr == nil || authErr.ErrorCode != tt.wantCode {
+				t.Errorf("Validate() error = %v, want %s", authErr, tt.wantCode)
+			}
+		})
+	}
+}
+
+// memNonceStore is an in-memory NonceStore.
+type memNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Duration
+	err    error
+}
+
+func (m *memNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	if _, ok := m.nonces[nonce]; ok {
+		return false, nil
+	}
+	if m.nonces == nil {
+		m.nonces = make(map[string]time.Duration)
+	}
+	m.nonces[nonce] = ttl
+	return true, nil
+}
+
+func TestAuthenticatedReq_Nonce(t *testing.T) {
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	body := func(nonce string) []byte {
+		return []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP","nonce":"` + nonce + `"}`)
+	}
+	tests := []struct {
+		name       string
+		store      *memNonceStore
+		body       []byte
+		sigErr     error
+		wantStatus int
+		wantCode   model.ErrorCode
+	}{
+		{name: "new nonce", store: &memNonceStore{}, body: body("n1")},
+		{name: "replayed nonce", store: &memNonceStore{nonces: map[string]time.Duration{"test.com|n1": time.Hour}}, body: body("n1"), wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeReplayedRequest},
+		{name: "nonce of another subscriber", store: &memNonceStore{nonces: map[string]time.Duration{"other.com|n1": time.Hour}}, body: body("n1")},
+		{name: "missing nonce", store: &memNonceStore{}, body: body(""), wantStatus: http.StatusBadRequest, wantCode: model.ErrorCodeBadRequest},
+		{name: "store error", store: &memNonceStore{err: errors.New("redis down")}, body: body("n1"), wantStatus: http.StatusServiceUnavailable, wantCode: model.ErrorCodeServiceUnavailable},
+		{name: "invalid signature is not remembered", store: &memNonceStore{}, body: body("n1"), sigErr: errors.New("bad sig"), wantStatus: http.StatusUnauthorized, wantCode: model.ErrorCodeInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, &mockSignValidator{err: tt.sigErr})
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+			auth.SetNonceStore(tt.store, 0)
+			remembered := len(tt.store.nonces)
+
+			_, gotErr := auth.AuthenticatedReq(context.Background(), tt.body, authHeader)
+			if tt.wantCode == "" {
+				if gotErr != nil {
+					t.Fatalf("AuthenticatedReq() error = %v, want nil", gotErr)
+				}
+				if ttl, ok := tt.store.nonces["test.com|n1"]; !ok || ttl != DefaultNonceTTL {
+					t.Errorf("remembered nonce ttl = %s, %t, want %s, true", ttl, ok, DefaultNonceTTL)
+				}
+				return
+			}
+			if gotErr == nil || gotErr.StatusCode != tt.wantStatus || gotErr.ErrorCode != tt.wantCode {
+				t.Errorf("AuthenticatedReq() error = %v, want %d %s", gotErr, tt.wantStatus, tt.wantCode)
+			}
+			if tt.sigErr != nil && len(tt.store.nonces) != remembered {
+				t.Errorf("nonces remembered = %d, want %d", len(tt.store.nonces), remembered)
+			}
+		})
+	}
+}
+
+func TestAuthenticatedReq_NonceReplay(t *testing.T) {
+	auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	auth.SetNonceStore(&memNonceStore{}, time.Minute)
+	body := []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP","nonce":"n1"}`)
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+
+	if _, authErr := auth.AuthenticatedReq(context.Background(), body, authHeader); authErr != nil {
+		t.Fatalf("AuthenticatedReq() error = %v, want nil", authErr)
+	}
+	_, authErr := auth.AuthenticatedReq(context.Background(), body, authHeader)
+	if authErr == nil || authErr.ErrorCode != model.ErrorCodeReplayedRequest {
+		t.Fatalf("AuthenticatedReq() replay error = %v, want %s", authErr, model.ErrorCodeReplayedRequest)
+	}
+	if !strings.Contains(authErr.Message, "Replayed request") {
+		t.Errorf("AuthenticatedReq() replay message = %q, want it to mention the replayed request", authErr.Message)
+	}
+}
+
+func TestAuthenticatedReq_ConcurrentNonce(t *testing.T) {
+	auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, &mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	auth.SetNonceStore(&memNonceStore{}, time.Minute)
+	body := []byte(`{"subscriber_id":"test.com","domain":"test.domain","type":"BAP","nonce":"n1"}`)
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+
+	const requests = 10
+	var wg sync.WaitGroup
+	accepted := make(chan struct{}, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, authErr := auth.AuthenticatedReq(context.Background(), body, authHeader); authErr == nil {
+				accepted <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+	close(accepted)
+	if got := len(accepted); got != 1 {
+		t.Errorf("accepted requests = %d, want 1", got)
+	}
+}
+
+// keyedSignValidator is a signValidator that only accepts signatures verified with signedWith.
+type keyedSignValidator struct {
+	signedWith string
+}
+
+func (m *keyedSignValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	if publicKeyBase64 != m.signedWith {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func TestTxnSignValidator_Validate_KeyRotationGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	body := []byte(`{"message":"test"}`)
+	const grace = 5 * time.Minute
+
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		signedWith  string
+		elapsed     time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "old key right after rotation",
+			gracePeriod: grace,
+			signedWith:  "old-key",
+			wantErr:     false,
+		},
+		{
+			name:        "old key within grace period",
+			gracePeriod: grace,
+			signedWith:  "old-key",
+			elapsed:     grace - time.Second,
+			wantErr:     false,
+		},
+		{
+			name:        "old key after grace period",
+			gracePeriod: grace,
+			signedWith:  "old-key",
+			elapsed:     grace,
+			wantErr:     true,
+		},
+		{
+			name:        "new key after grace period",
+			gracePeriod: grace,
+			signedWith:  "new-key",
+			elapsed:     grace,
+			wantErr:     false,
+		},
+		{
+			name:       "old key without grace period",
+			signedWith: "old-key",
+			wantErr:    true,
+		},
+		{
+			name:        "unknown key within grace period",
+			gracePeriod: grace,
+			signedWith:  "other-key",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := &mockNPKeyProvider{signingKey: "old-key"}
+			sv := &keyedSignValidator{signedWith: "old-key"}
+			validator, err := NewTxnSignValidator(sv, km)
+			if err != nil {
+				t.Fatalf("NewTxnSignValidator() error = %v", err)
+			}
+			validator.SetKeyRotationGracePeriod(tt.gracePeriod)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			validator.now = func() time.Time { return now }
+
+			// A transaction signed with the old key before the subscription update.
+			if authErr := validator.Validate(ctx, body, authHeader, ""); authErr != nil {
+				t.Fatalf("Validate() before rotation error = %v", authErr)
+			}
+
+			// The registry now returns the new key, first seen with a transaction signed with it.
+			km.signingKey = "new-key"
+			sv.signedWith = "new-key"
+			if authErr := validator.Validate(ctx, body, authHeader, ""); authErr != nil {
+				t.Fatalf("Validate() with new key error = %v", authErr)
+			}
+
+			now = now.Add(tt.elapsed)
+			sv.signedWith = tt.signedWith
+			authErr := validator.Validate(ctx, body, authHeader, "")
+			if (authErr != nil) != tt.wantErr {
+				t.Fatalf("Validate() %s after rotation error = %v, wantErr %v", tt.elapsed, authErr, tt.wantErr)
+			}
+			if authErr != nil && authErr.ErrorCode != model.ErrorCodeInvalidSignature {
+				t.Errorf("Validate() error code = %v, want %v", authErr.ErrorCode, model.ErrorCodeInvalidSignature)
+			}
+		})
+	}
+}
+
+// memKeyRotationStore is an in-memory keyRotationStore shared by validators, as Redis is by gateway replicas.
+type memKeyRotationStore struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+}
+
+func (m *memKeyRotationStore) GetIfExists(ctx context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return "", false, m.err
+	}
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *memKeyRotationStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func TestTxnSignValidator_Validate_KeyRotationStore(t *testing.T) {
+	ctx := context.Background()
+	authHeader := `Signature keyId="test.com|key1|ed25519",algorithm="ed25519"`
+	body := []byte(`{"message":"test"}`)
+	const grace = 5 * time.Minute
+
+	tests := []struct {
+		name       string
+		storeErr   error
+		replicaKey string // The key the other replica looks up.
+		signedWith string
+		elapsed    time.Duration
+		wantErr    bool
+	}{
+		{
+			name:       "old key on other replica within grace period",
+			replicaKey: "new-key",
+			signedWith: "old-key",
+			elapsed:    grace - time.Second,
+		},
+		{
+			name:       "old key on other replica after grace period",
+			replicaKey: "new-key",
+			signedWith: "old-key",
+			elapsed:    grace,
+			wantErr:    true,
+		},
+		{
+			name:       "new key on other replica",
+			replicaKey: "new-key",
+			signedWith: "new-key",
+		},
+		{
+			name:       "other replica still looking up the old key",
+			replicaKey: "old-key",
+			signedWith: "old-key",
+		},
+		{
+			name:       "old key on other replica with store unavailable",
+			storeErr:   errors.New("connection refused"),
+			replicaKey: "new-key",
+			signedWith: "old-key",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memKeyRotationStore{values: map[string]string{}}
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			newValidator := func(km npKeyProvider, sv signValidator) *txnSignValidator {
+				v, err := NewTxnSignValidator(sv, km)
+				if err != nil {
+					t.Fatalf("NewTxnSignValidator() error = %v", err)
+				}
+				v.SetKeyRotationGracePeriod(grace)
+				v.SetKeyRotationStore(store)
+				v.now = func() time.Time { return now }
+				return v
+			}
+
+			// One replica sees the rotation from the old key to the new one.
+			km := &mockNPKeyProvider{signingKey: "old-key"}
+			sv := &keyedSignValidator{signedWith: "old-key"}
+			replica := newValidator(km, sv)
+			if authErr := replica.Validate(ctx, body, authHeader, ""); authErr != nil {
+				t.Fatalf("Validate() before rotation error = %v", authErr)
+			}
+			km.signingKey, sv.signedWith = "new-key", "new-key"
+			if authErr := replica.Validate(ctx, body, authHeader, ""); authErr != nil {
+				t.Fatalf("Validate() with new key error = %v", authErr)
+			}
+
+			// Another replica, or the same one after a restart, validates a transaction.
+			store.err = tt.storeErr
+			now = now.Add(tt.elapsed)
+			other := newValidator(&mockNPKeyProvider{signingKey: tt.replicaKey}, &keyedSignValidator{signedWith: tt.signedWith})
+			authErr := other.Validate(ctx, body, authHeader, "")
+			if (authErr != nil) != tt.wantErr {
+				t.Fatalf("Validate() on other replica error = %v, wantErr %v", authErr, tt.wantErr)
+			}
+
+			store.err = nil
+			var stored rotatedKey
+			if err := json.Unmarshal([]byte(store.values[keyRotationKeyPrefix+"test.com|key1"]), &stored); err != nil {
+				t.Fatalf("stored rotated key: %v", err)
+			}
+			if stored.Current != "new-key" || stored.Previous != "old-key" {
+				t.Errorf("stored rotated key = %+v, want current new-key and previous old-key", stored)
+			}
+		})
+	}
+}
+
+func TestTxnSignValidator_WarmUp(t *testing.T) {
+	km := &cachingNPKeyProvider{
+		keys:  map[string]string{"bap.com": "bap-key", "bpp.com": "bpp-key"},
+		fail:  map[string]bool{"down.com": true},
+		cache: map[string]string{},
+	}
+	validator, err := NewTxnSignValidator(&mockSignValidator{}, km)
+	if err != nil {
+		t.Fatalf("NewTxnSignValidator() unexpected error = %v", err)
+	}
+
+	validator.WarmUp(context.Background(), []KeyCacheWarmUpEntry{
+		{SubscriberID: "bap.com", KeyID: "k1"},
+		{SubscriberID: "down.com", KeyID: "k2"},
+		{SubscriberID: "bpp.com", KeyID: "k3"},
+	})
+
+	want := map[string]string{"bap.com_k1": "bap-key", "bpp.com_k3": "bpp-key"}
+	if diff := cmp.Diff(want, km.cache); diff != "" {
+		t.Errorf("WarmUp() cache mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTxnSignValidator_WarmUp_ContextCancelled(t *testing.T) {
+	km := &cachingNPKeyProvider{cache: map[string]string{}}
+	validator, _ := NewTxnSignValidator(&mockSignValidator{}, km)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	validator.WarmUp(ctx, []KeyCacheWarmUpEntry{{SubscriberID: "bap.com", KeyID: "k1"}})
+
+	if len(km.cache) != 0 {
+		t.Errorf("WarmUp() cache = %v, want empty", km.cache)
+	}
+}
+
+func TestTxnSignValidator_Validate_BindRequestID(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, _, err := becknsigner.New(ctx, &becknsigner.Config{})
+	if err != nil {
+		t.Fatalf("signer.New() error = %v", err)
+	}
+	sv, _, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	km := &mockSigningKM{keyset: &becknmodel.Keyset{UniqueKeyID: "key1", SigningPrivate: base64.StdEncoding.EncodeToString(priv.Seed())}}
+	body := []byte(`{"context":{"action":"search"}}`)
+
+	sign := func(bind bool, requestID string) string {
+		t.Helper()
+		authGen, err := NewAuthGenService(km, signer)
+		if err != nil {
+			t.Fatalf("NewAuthGenService() error = %v", err)
+		}
+		authGen.SetBindRequestID(bind)
+		header, err := authGen.RequestAuthHeader(ctx, body, "bap.com", requestID)
+		if err != nil {
+			t.Fatalf("RequestAuthHeader() error = %v", err)
+		}
+		return header
+	}
+
+	tests := []struct {
+		name          string
+		authHeader    string
+		requestID     string
+		bind          bool
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:       "bound signature with its request id",
+			authHeader: sign(true, "req-1"),
+			requestID:  "req-1",
+			bind:       true,
+		},
+		{
+			name:          "bound signature reused with another request id",
+			authHeader:    sign(true, "req-1"),
+			requestID:     "req-2",
+			bind:          true,
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:          "request id missing",
+			authHeader:    sign(true, "req-1"),
+			bind:          true,
+			wantErrorCode: model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:          "unbound signature",
+			authHeader:    sign(false, "req-1"),
+			requestID:     "req-1",
+			bind:          true,
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:       "unbound signature without binding",
+			authHeader: sign(false, "req-1"),
+			requestID:  "req-2",
+		},
+		{
+			name:       "bound signature without binding",
+			authHeader: sign(true, "req-1"),
+			requestID:  "req-1",
+		},
+		{
+			name:          "bound signature without binding reused with another request id",
+			authHeader:    sign(true, "req-1"),
+			requestID:     "req-2",
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewTxnSignValidator(sv, &mockNPKeyProvider{signingKey: base64.StdEncoding.EncodeToString(pub)})
+			if err != nil {
+				t.Fatalf("NewTxnSignValidator() error = %v", err)
+			}
+			validator.SetBindRequestID(tt.bind)
+
+			authErr := validator.Validate(ctx, body, tt.authHeader, tt.requestID)
+			if tt.wantErrorCode == "" {
+				if authErr != nil {
+					t.Errorf("Validate() unexpected error = %v", authErr)
+				}
+				return
+			}
+			if authErr == nil || authErr.ErrorCode != tt.wantErrorCode {
+				t.Errorf("Validate() error = %v, want error code %s", authErr, tt.wantErrorCode)
+			}
+		})
+	}
+}
+
+func TestAuthenticatedCreateReq(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, _, err := becknsigner.New(ctx, &becknsigner.Config{})
+	if err != nil {
+		t.Fatalf("signer.New() error = %v", err)
+	}
+	sv, _, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	authGen, err := NewAuthGenService(&mockSigningKM{keyset: &becknmodel.Keyset{UniqueKeyID: "key1", SigningPrivate: base64.StdEncoding.EncodeToString(priv.Seed())}}, signer)
+	if err != nil {
+		t.Fatalf("NewAuthGenService() error = %v", err)
+	}
+	createBodyWithKeyID := func(subscriberID, keyID string, key ed25519.PublicKey) []byte {
+		var encoded string
+		if key != nil {
+			encoded = base64.StdEncoding.EncodeToString(key)
+		}
+		return []byte(`{"subscriber_id":"` + subscriberID + `","key_id":"` + keyID + `","signing_public_key":"` + encoded + `"}`)
+	}
+	createBody := func(subscriberID string, key ed25519.PublicKey) []byte {
+		return createBodyWithKeyID(subscriberID, "key1", key)
+	}
+	sign := func(body []byte) string {
+		t.Helper()
+		header, err := authGen.AuthHeader(ctx, body, "new.com")
+		if err != nil {
+			t.Fatalf("AuthHeader() error = %v", err)
+		}
+		return header
+	}
+
+	tests := []struct {
+		name          string
+		body          []byte
+		authHeader    func(body []byte) string
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:       "signed with the registered key",
+			body:       createBody("new.com", pub),
+			authHeader: sign,
+		},
+		{
+			name:          "unsigned",
+			body:          createBody("new.com", pub),
+			authHeader:    func([]byte) string { return "" },
+			wantErrorCode: model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:          "signed with another key",
+			body:          createBody("new.com", otherPub),
+			authHeader:    sign,
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:          "signed for another subscriber",
+			body:          createBody("victim.com", pub),
+			authHeader:    sign,
+			wantErrorCode: model.ErrorCodeIDMismatch,
+		},
+		{
+			name:          "signed for another key id",
+			body:          createBodyWithKeyID("new.com", "key2", pub),
+			authHeader:    sign,
+			wantErrorCode: model.ErrorCodeIDMismatch,
+		},
+		{
+			name:          "no signing key",
+			body:          createBody("new.com", nil),
+			authHeader:    sign,
+			wantErrorCode: model.ErrorCodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, err := NewAuthService(&mockSubscriptionKeyProvider{err: errors.New("must not be called")}, sv)
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+
+			subReq, authErr := authService.AuthenticatedCreateReq(ctx, tt.body, tt.authHeader(tt.body))
+			if tt.wantErrorCode == "" {
+				if authErr != nil {
+					t.Fatalf("AuthenticatedCreateReq() unexpected error = %v", authErr)
+				}
+				if subReq.SubscriberID != "new.com" {
+					t.Errorf("AuthenticatedCreateReq() subscriber_id = %q, want %q", subReq.SubscriberID, "new.com")
+				}
+				return
+			}
+			if authErr == nil || authErr.ErrorCode != tt.wantErrorCode {
+				t.Errorf("AuthenticatedCreateReq() error = %v, want error code %s", authErr, tt.wantErrorCode)
+			}
+		})
+	}
+}
+
+func TestAuthenticatedDeleteReq(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, _, err := becknsigner.New(ctx, &becknsigner.Config{})
+	if err != nil {
+		t.Fatalf("signer.New() error = %v", err)
+	}
+	sv, _, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	authGen, err := NewAuthGenService(&mockSigningKM{keyset: &becknmodel.Keyset{UniqueKeyID: "key1", SigningPrivate: base64.StdEncoding.EncodeToString(priv.Seed())}}, signer)
+	if err != nil {
+		t.Fatalf("NewAuthGenService() error = %v", err)
+	}
+	// sign returns the body of a request deleting the subscription of keyID and its auth header.
+	sign := func(method, keyID, nonce string) ([]byte, string) {
+		body, err := json.Marshal(model.DeleteSubscriptionRequest{Method: method, SubscriberID: "np.com", KeyID: keyID, Nonce: nonce})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		header, err := authGen.AuthHeader(ctx, body, "np.com")
+		if err != nil {
+			t.Fatalf("AuthHeader() error = %v", err)
+		}
+		return body, header
+	}
+	body, header := sign(http.MethodDelete, "key1", "n1")
+	retiredBody, retiredHeader := sign(http.MethodDelete, "key0", "n1")
+	postBody, postHeader := sign(http.MethodPost, "key1", "n1")
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	tests := []struct {
+		name          string
+		subscriberID  string
+		keyID         string
+		body          []byte
+		authHeader    string
+		keys          *mockSubscriptionKeyProvider
+		nonces        map[string]time.Duration
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name:         "signed by the subscriber",
+			subscriberID: "np.com",
+			keyID:        "key1",
+			body:         body,
+			authHeader:   header,
+			keys:         &mockSubscriptionKeyProvider{key: key},
+		},
+		{
+			name:         "subscription of a retired key signed with the current key",
+			subscriberID: "np.com",
+			keyID:        "key0",
+			body:         retiredBody,
+			authHeader:   retiredHeader,
+			keys:         &mockSubscriptionKeyProvider{key: key},
+		},
+		{
+			name:          "unsigned",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			body:          body,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeMissingAuthHeader,
+		},
+		{
+			name:          "signed by another subscriber",
+			subscriberID:  "victim.com",
+			keyID:         "key1",
+			body:          body,
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeIDMismatch,
+		},
+		{
+			name:          "signed for another key",
+			subscriberID:  "np.com",
+			keyID:         "key2",
+			body:          body,
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeIDMismatch,
+		},
+		{
+			name:          "body changed to another key",
+			subscriberID:  "np.com",
+			keyID:         "key2",
+			body:          []byte(`{"method":"DELETE","subscriber_id":"np.com","key_id":"key2","nonce":"n1"}`),
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:          "signed for another method",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			body:          postBody,
+			authHeader:    postHeader,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeIDMismatch,
+		},
+		{
+			name:          "empty body",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			wantErrorCode: model.ErrorCodeInvalidJSON,
+		},
+		{
+			name:          "replayed nonce",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			body:          body,
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: key},
+			nonces:        map[string]time.Duration{"np.com|n1": time.Hour},
+			wantErrorCode: model.ErrorCodeReplayedRequest,
+		},
+		{
+			name:          "signed with another key",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			body:          body,
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{key: base64.StdEncoding.EncodeToString(otherPub)},
+			wantErrorCode: model.ErrorCodeInvalidSignature,
+		},
+		{
+			name:          "signing key not found",
+			subscriberID:  "np.com",
+			keyID:         "key1",
+			body:          body,
+			authHeader:    header,
+			keys:          &mockSubscriptionKeyProvider{err: repository.ErrSubscriberKeyNotFound},
+			wantErrorCode: model.ErrorCodeSubscriptionNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, err := NewAuthService(tt.keys, sv)
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+			authService.SetNonceStore(&memNonceStore{nonces: tt.nonces}, 0)
+
+			authErr := authService.AuthenticatedDeleteReq(ctx, tt.subscriberID, tt.keyID, tt.body, tt.authHeader)
+			if tt.wantErrorCode == "" {
+				if authErr != nil {
+					t.Errorf("AuthenticatedDeleteReq() unexpected error = %v", authErr)
+				}
+				return
+			}
+			if authErr == nil || authErr.ErrorCode != tt.wantErrorCode {
+				t.Errorf("AuthenticatedDeleteReq() error = %v, want error code %s", authErr, tt.wantErrorCode)
+			}
+		})
+	}
+}
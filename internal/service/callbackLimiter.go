@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// CallbackLimitRequeueDelay is how long a queued task whose target host is at its callback
+	// limit waits before it is queued again.
+	CallbackLimitRequeueDelay = 100 * time.Millisecond
+	// DefaultCallbackLimiterIdleTTL is how long the limit learnt for a host without calls is kept.
+	DefaultCallbackLimiterIdleTTL = 30 * time.Minute
+)
+
+// ErrCallbackLimitReached is returned for a queued task whose target host is at its callback limit.
+// The task is queued again after CallbackLimitRequeueDelay without counting as a failed attempt.
+var ErrCallbackLimitReached = errors.New("target host is at its callback limit")
+
+// callbackLimiter caps the number of simultaneous in-flight proxy calls to each target host.
+// Every host gets the default limit unless a subscription overrides it.
+type callbackLimiter struct {
+	mu        sync.Mutex
+	def       int                  // Limit of hosts without an override, 0 means no limit.
+	overrides map[string]int       // Per-host limits learnt from subscriptions.
+	lastUsed  map[string]time.Time // When a host with an override was last called or had its limit set.
+	inFlight  map[string]int
+	freed     chan struct{} // Closed and replaced whenever a call completes.
+
+	idleTTL   time.Duration // Overrides of hosts unused for this long are evicted.
+	lastEvict time.Time
+	now       func() time.Time
+}
+
+// NewCallbackLimiter creates a limiter allowing defaultLimit in-flight calls per target host.
+// A defaultLimit of 0 or less means no limit for hosts without an override.
+func NewCallbackLimiter(defaultLimit int) *callbackLimiter {
+	if defaultLimit < 0 {
+		defaultLimit = 0
+	}
+	return &callbackLimiter{
+		def:       defaultLimit,
+		overrides: make(map[string]int),
+		lastUsed:  make(map[string]time.Time),
+		inFlight:  make(map[string]int),
+		freed:     make(chan struct{}),
+		idleTTL:   DefaultCallbackLimiterIdleTTL,
+		now:       time.Now,
+	}
+}
+
+// targetHost returns the host calls to rawURL are limited by, or "" if it cannot be parsed.
+func targetHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// SetTargetLimit overrides the limit of host. A limit of 0 or less restores the default.
+func (l *callbackLimiter) SetTargetLimit(host string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit < 0 {
+		limit = 0
+	}
+	if l.overrides[host] == limit {
+		return
+	}
+	if limit == 0 {
+		delete(l.overrides, host)
+		delete(l.lastUsed, host)
+	} else {
+		l.overrides[host] = limit
+		l.lastUsed[host] = l.now()
+	}
+	// Wake up waiters so that they see a raised limit.
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+// limit returns the limit of host. The caller must hold l.mu.
+func (l *callbackLimiter) limit(host string) int {
+	if n, ok := l.overrides[host]; ok {
+		return n
+	}
+	return l.def
+}
+
+// reserve reserves a call to host if it is below its limit, reporting whether it did.
+// The caller must hold l.mu.
+func (l *callbackLimiter) reserve(host string) bool {
+	now := l.now()
+	l.evictIdle(now)
+	if _, ok := l.overrides[host]; ok {
+		l.lastUsed[host] = now
+	}
+	if n := l.limit(host); n > 0 && l.inFlight[host] >= n {
+		return false
+	}
+	l.inFlight[host]++
+	return true
+}
+
+// evictIdle forgets the overrides of hosts without calls unused for the idle TTL, at most once
+// per idle TTL. A subscription sets the limit of its host again once a lookup returns it.
+// The caller must hold l.mu.
+func (l *callbackLimiter) evictIdle(now time.Time) {
+	if l.idleTTL <= 0 || now.Sub(l.lastEvict) < l.idleTTL {
+		return
+	}
+	l.lastEvict = now
+	for host, used := range l.lastUsed {
+		if l.inFlight[host] == 0 && now.Sub(used) >= l.idleTTL {
+			delete(l.overrides, host)
+			delete(l.lastUsed, host)
+		}
+	}
+}
+
+// tryAcquire reserves a call to host without waiting, returning ErrCallbackLimitReached if it is at its limit.
+// The returned function must be called once the call completes.
+func (l *callbackLimiter) tryAcquire(host string) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.reserve(host) {
+		return nil, ErrCallbackLimitReached
+	}
+	return func() { l.release(host) }, nil
+}
+
+// acquire reserves a call to host, blocking until one is available or ctx is done.
+// The returned function must be called once the call completes.
+func (l *callbackLimiter) acquire(ctx context.Context, host string) (release func(), err error) {
+	for {
+		l.mu.Lock()
+		if l.reserve(host) {
+			l.mu.Unlock()
+			return func() { l.release(host) }, nil
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release ends a call to host and wakes up blocked acquirers.
+func (l *callbackLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[host]--; l.inFlight[host] <= 0 {
+		delete(l.inFlight, host)
+	}
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
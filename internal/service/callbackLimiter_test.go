@@ -0,0 +1,256 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+func TestTargetHost(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "http://bpp.example.com/beckn", want: "bpp.example.com"},
+		{url: "https://bpp.example.com:8443/beckn", want: "bpp.example.com:8443"},
+		{url: "://bad", want: ""},
+	}
+	for _, tt := range tests {
+		if got := targetHost(tt.url); got != tt.want {
+			t.Errorf("targetHost(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCallbackLimiter_Limit(t *testing.T) {
+	tests := []struct {
+		name      string
+		def       int
+		overrides map[string]int
+		host      string
+		want      int
+	}{
+		{name: "default", def: 3, host: "a.com", want: 3},
+		{name: "negative default means no limit", def: -1, host: "a.com", want: 0},
+		{name: "override", def: 3, overrides: map[string]int{"a.com": 5}, host: "a.com", want: 5},
+		{name: "override of another host", def: 3, overrides: map[string]int{"b.com": 5}, host: "a.com", want: 3},
+		{name: "zero override restores default", def: 3, overrides: map[string]int{"a.com": 0}, host: "a.com", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewCallbackLimiter(tt.def)
+			for host, n := range tt.overrides {
+				l.SetTargetLimit(host, 7)
+				l.SetTargetLimit(host, n)
+			}
+			if got := l.limit(tt.host); got != tt.want {
+				t.Errorf("limit(%q) = %d, want %d", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackLimiter_Acquire_ContextCancelled(t *testing.T) {
+	l := NewCallbackLimiter(1)
+	release, err := l.acquire(context.Background(), "a.com")
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer release()
+
+	// Another host is not held up by a.com being at its limit.
+	releaseB, err := l.acquire(context.Background(), "b.com")
+	if err != nil {
+		t.Fatalf("acquire() of another host error = %v", err)
+	}
+	releaseB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "a.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() at limit error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestCallbackLimiter_TryAcquire(t *testing.T) {
+	l := NewCallbackLimiter(1)
+	release, err := l.tryAcquire("a.com")
+	if err != nil {
+		t.Fatalf("tryAcquire() error = %v", err)
+	}
+	if _, err := l.tryAcquire("a.com"); !errors.Is(err, ErrCallbackLimitReached) {
+		t.Errorf("tryAcquire() at limit error = %v, want %v", err, ErrCallbackLimitReached)
+	}
+	releaseB, err := l.tryAcquire("b.com")
+	if err != nil {
+		t.Fatalf("tryAcquire() of another host error = %v", err)
+	}
+	releaseB()
+	release()
+	if _, err := l.tryAcquire("a.com"); err != nil {
+		t.Errorf("tryAcquire() after release error = %v", err)
+	}
+}
+
+func TestCallbackLimiter_EvictIdle(t *testing.T) {
+	const ttl = time.Minute
+	tests := []struct {
+		name     string
+		inFlight bool
+		idle     time.Duration
+		want     int
+	}{
+		{name: "recently used host kept", idle: ttl - time.Second, want: 5},
+		{name: "idle host evicted", idle: ttl, want: 3},
+		{name: "host with calls in flight kept", inFlight: true, idle: ttl, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			l := NewCallbackLimiter(3)
+			l.idleTTL = ttl
+			l.now = func() time.Time { return now }
+			l.SetTargetLimit("a.com", 5)
+			if tt.inFlight {
+				if _, err := l.tryAcquire("a.com"); err != nil {
+					t.Fatalf("tryAcquire() error = %v", err)
+				}
+			}
+
+			now = now.Add(tt.idle)
+			release, err := l.tryAcquire("b.com")
+			if err != nil {
+				t.Fatalf("tryAcquire() error = %v", err)
+			}
+			release()
+			if got := l.limit("a.com"); got != tt.want {
+				t.Errorf("limit(a.com) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackLimiter_Acquire_RaisedLimitWakesWaiter(t *testing.T) {
+	l := NewCallbackLimiter(1)
+	release, err := l.acquire(context.Background(), "a.com")
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer release()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.acquire(context.Background(), "a.com")
+		done <- err
+	}()
+	l.SetTargetLimit("a.com", 2)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("acquire() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire() still blocked after the limit was raised")
+	}
+}
+
+func TestProxyTaskProcessor_FanOut_CallbackLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      int
+		override int
+		want     int32
+	}{
+		{name: "default limit", def: 2, want: 2},
+		{name: "subscription override", def: 2, override: 4, want: 4},
+		{name: "override without default", override: 3, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			client := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				n := atomic.AddInt32(&inFlight, 1)
+				for {
+					m := atomic.LoadInt32(&maxInFlight)
+					if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			limiter := NewCallbackLimiter(tt.def)
+			limiter.SetTargetLimit("bpp.example.com", tt.override)
+			p := &proxyTaskProcessor{client: client, auth: &mockAuthGen{authHeader: "Signature test-auth"}, keyID: "test-key-id"}
+			p.SetCallbackLimiter(limiter)
+
+			// A fan-out waits for callback slots instead of failing its tasks.
+			const burst = 20
+			tasks := make([]*model.AsyncTask, burst)
+			for i := range tasks {
+				tasks[i] = newTestAsyncTask("http://bpp.example.com/search", []byte(`{}`), make(http.Header))
+				tasks[i].Headers.Set(model.AuthHeaderGateway, "Auth test")
+			}
+			if _, err := p.FanOut(context.Background(), tasks, FanOutConfig{}); err != nil {
+				t.Errorf("FanOut() error = %v", err)
+			}
+			if got := atomic.LoadInt32(&maxInFlight); got > tt.want {
+				t.Errorf("max in-flight calls = %d, want at most %d", got, tt.want)
+			}
+			if got := atomic.LoadInt32(&maxInFlight); got < 1 {
+				t.Errorf("max in-flight calls = %d, want at least 1", got)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_CallbackLimitReached(t *testing.T) {
+	limiter := NewCallbackLimiter(1)
+	release, err := limiter.acquire(context.Background(), "bpp.example.com")
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer release()
+	var calls int32
+	client := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+	}}
+	p := &proxyTaskProcessor{client: client, auth: &mockAuthGen{}, keyID: "test-key-id"}
+	p.SetCallbackLimiter(limiter)
+
+	// A queued task does not wait for a slot, so that it does not hold its worker.
+	task := newTestAsyncTask("http://bpp.example.com/search", []byte(`{}`), make(http.Header))
+	task.Headers.Set(model.AuthHeaderGateway, "Auth test")
+	err = p.Process(context.Background(), task)
+
+	var pErr *ProxyTaskError
+	if !errors.As(err, &pErr) || !errors.Is(err, ErrCallbackLimitReached) {
+		t.Errorf("Process() error = %v, want a ProxyTaskError wrapping %v", err, ErrCallbackLimitReached)
+	}
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("calls to target = %d, want 0", got)
+	}
+}
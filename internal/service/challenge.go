@@ -16,10 +16,104 @@ package service
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
+// ChallengeVerificationMode decides which answers to a challenge are accepted.
+type ChallengeVerificationMode string
+
+const (
+	// ChallengeVerificationStrict accepts only the exact challenge. It is the default.
+	ChallengeVerificationStrict ChallengeVerificationMode = "STRICT"
+	// ChallengeVerificationTolerant also accepts the challenge with surrounding whitespace,
+	// in upper case hex, or as the base64 encoding of its bytes or of its text, for
+	// participants whose stacks re-encode the decrypted challenge.
+	ChallengeVerificationTolerant ChallengeVerificationMode = "TOLERANT"
+)
+
+// Valid reports whether m is a known mode. The empty mode is valid and means STRICT.
+func (m ChallengeVerificationMode) Valid() bool {
+	switch m {
+	case "", ChallengeVerificationStrict, ChallengeVerificationTolerant:
+		return true
+	}
+	return false
+}
+
+// ChallengeAlgorithm decides how the random bytes of a challenge are encoded.
+type ChallengeAlgorithm string
+
+const (
+	// ChallengeAlgorithmHex encodes challenges in lower case hex. It is the default.
+	ChallengeAlgorithmHex ChallengeAlgorithm = "HEX"
+	// ChallengeAlgorithmBase64 encodes challenges in unpadded base64url.
+	ChallengeAlgorithmBase64 ChallengeAlgorithm = "BASE64"
+)
+
+// Valid reports whether a is a known algorithm. The empty algorithm is valid and means HEX.
+func (a ChallengeAlgorithm) Valid() bool {
+	switch a {
+	case "", ChallengeAlgorithmHex, ChallengeAlgorithmBase64:
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultChallengeLength is the number of random bytes of a challenge if a policy does not set it.
+	DefaultChallengeLength = 32
+	// MinChallengeLength is the smallest number of random bytes a policy may set.
+	MinChallengeLength = 16
+)
+
+// ChallengePolicy decides how challenges are generated and how their answers are verified.
+// The zero policy generates 32 byte hex challenges verified in STRICT mode.
+type ChallengePolicy struct {
+	// Length is the number of random bytes of a challenge, at least MinChallengeLength.
+	// 0 means DefaultChallengeLength.
+	Length int `yaml:"length"`
+	// Algorithm is HEX or BASE64, how the random bytes are encoded. Defaults to HEX.
+	Algorithm ChallengeAlgorithm `yaml:"algorithm"`
+	// Verification is STRICT or TOLERANT, how answers are compared with the challenge. Defaults to STRICT.
+	Verification ChallengeVerificationMode `yaml:"verification"`
+}
+
+// Validate returns an error if p has a negative length, a length under MinChallengeLength,
+// or an unknown algorithm or verification mode.
+func (p ChallengePolicy) Validate() error {
+	if p.Length < 0 {
+		return fmt.Errorf("length %d must not be negative", p.Length)
+	}
+	if p.Length > 0 && p.Length < MinChallengeLength {
+		return fmt.Errorf("length %d must be at least %d", p.Length, MinChallengeLength)
+	}
+	if !p.Algorithm.Valid() {
+		return fmt.Errorf("algorithm %q must be one of HEX, BASE64", p.Algorithm)
+	}
+	if !p.Verification.Valid() {
+		return fmt.Errorf("verification %q must be one of STRICT, TOLERANT", p.Verification)
+	}
+	return nil
+}
+
+// withDefaults returns p with its unset fields taken from def.
+func (p ChallengePolicy) withDefaults(def ChallengePolicy) ChallengePolicy {
+	if p.Length == 0 {
+		p.Length = def.Length
+	}
+	if p.Algorithm == "" {
+		p.Algorithm = def.Algorithm
+	}
+	if p.Verification == "" {
+		p.Verification = def.Verification
+	}
+	return p
+}
+
 type challengeService struct{}
 
 // NewChallengeService creates a new ChallengeService.
@@ -27,17 +121,65 @@ func NewChallengeService() *challengeService {
 	return &challengeService{}
 }
 
-// NewChallenge generates a new random challenge string.
-// The challenge is a 32-character hex-encoded string.
-func (s *challengeService) NewChallenge() (string, error) {
-	bytes := make([]byte, 16) // 16 bytes = 32 hex characters
+// NewChallenge generates a new random challenge string of the policy's length and algorithm.
+// With the zero policy the challenge is a 64-character hex-encoded string.
+func (s *challengeService) NewChallenge(policy ChallengePolicy) (string, error) {
+	n := policy.Length
+	if n <= 0 {
+		n = DefaultChallengeLength
+	}
+	bytes := make([]byte, n)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", fmt.Errorf("failed to generate random bytes for challenge: %w", err)
 	}
+	if policy.Algorithm == ChallengeAlgorithmBase64 {
+		return base64.RawURLEncoding.EncodeToString(bytes), nil
+	}
 	return hex.EncodeToString(bytes), nil
 }
 
-// Verify checks if the provided answer matches the original challenge.
-func (s *challengeService) Verify(challenge, answer string) bool {
-	return challenge == answer
+// Verify checks if the provided answer matches the original challenge, in the policy's verification mode.
+// Answers are compared in constant time, so that the time taken does not reveal how much of a guess is right.
+func (s *challengeService) Verify(policy ChallengePolicy, challenge, answer string) bool {
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(answer)) == 1 {
+		return true
+	}
+	if policy.Verification != ChallengeVerificationTolerant || challenge == "" {
+		return false
+	}
+	return tolerantMatch(challenge, answer)
+}
+
+// base64Encodings are the encodings tolerated for a base64 answer.
+var base64Encodings = []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding}
+
+// tolerantMatch reports whether answer is challenge after normalizing its whitespace and encoding.
+func tolerantMatch(challenge, answer string) bool {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(answer), []byte(challenge)) == 1 {
+		return true
+	}
+	// Case only does not matter for hex challenges, base64 ones are case sensitive.
+	raw, err := hex.DecodeString(challenge)
+	if err != nil {
+		raw = nil
+	} else if subtle.ConstantTimeCompare([]byte(strings.ToLower(answer)), []byte(challenge)) == 1 {
+		return true
+	}
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(answer)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(decoded, []byte(challenge)) == 1 {
+			return true
+		}
+		if raw != nil && subtle.ConstantTimeCompare(decoded, raw) == 1 {
+			return true
+		}
+	}
+	return false
 }
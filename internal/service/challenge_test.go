@@ -15,6 +15,8 @@
 package service
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"testing"
 )
 
@@ -27,16 +29,16 @@ func TestNewChallengeService(t *testing.T) {
 
 func TestChallengeService_NewChallenge(t *testing.T) {
 	s := NewChallengeService()
-	challenge, err := s.NewChallenge()
+	challenge, err := s.NewChallenge(ChallengePolicy{})
 	if err != nil {
 		t.Fatalf("NewChallenge() error = %v, wantErr nil", err)
 	}
 	if challenge == "" {
 		t.Error("NewChallenge() returned empty challenge, want non-empty")
 	}
-	// Check length (32 hex characters for 16 bytes)
-	if len(challenge) != 32 {
-		t.Errorf("NewChallenge() challenge length = %d, want 32", len(challenge))
+	// Check length (64 hex characters for 32 bytes)
+	if len(challenge) != 64 {
+		t.Errorf("NewChallenge() challenge length = %d, want 64", len(challenge))
 	}
 
 	// Check if it's a valid hex string (basic check, not exhaustive)
@@ -48,7 +50,7 @@ func TestChallengeService_NewChallenge(t *testing.T) {
 	}
 
 	// Generate another challenge to ensure they are different (highly probable for random data)
-	challenge2, err2 := s.NewChallenge()
+	challenge2, err2 := s.NewChallenge(ChallengePolicy{})
 	if err2 != nil {
 		t.Fatalf("NewChallenge() [second call] error = %v, wantErr nil", err2)
 	}
@@ -57,6 +59,23 @@ func TestChallengeService_NewChallenge(t *testing.T) {
 	}
 }
 
+func TestChallengeService_NewChallenge_NoCollisions(t *testing.T) {
+	s := NewChallengeService()
+	for _, p := range []ChallengePolicy{{}, {Length: MinChallengeLength, Algorithm: ChallengeAlgorithmBase64}} {
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			challenge, err := s.NewChallenge(p)
+			if err != nil {
+				t.Fatalf("NewChallenge(%+v) error = %v, wantErr nil", p, err)
+			}
+			if seen[challenge] {
+				t.Fatalf("NewChallenge(%+v) returned %q twice", p, challenge)
+			}
+			seen[challenge] = true
+		}
+	}
+}
+
 func TestChallengeService_Verify(t *testing.T) {
 	s := NewChallengeService()
 	tests := []struct {
@@ -98,9 +117,125 @@ func TestChallengeService_Verify(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := s.Verify(tt.challenge, tt.answer); got != tt.want {
+			if got := s.Verify(ChallengePolicy{}, tt.challenge, tt.answer); got != tt.want {
 				t.Errorf("Verify() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestChallengeService_Verify_Modes(t *testing.T) {
+	const challenge = "00112233445566778899aabbccddeeff"
+	tests := []struct {
+		name         string
+		answer       string
+		wantStrict   bool
+		wantTolerant bool
+	}{
+		{name: "exact answer", answer: challenge, wantStrict: true, wantTolerant: true},
+		{name: "surrounding whitespace", answer: " " + challenge + "\n", wantTolerant: true},
+		{name: "upper case hex", answer: "00112233445566778899AABBCCDDEEFF", wantTolerant: true},
+		{name: "base64 of the challenge bytes", answer: "ABEiM0RVZneImaq7zN3u/w==", wantTolerant: true},
+		{name: "unpadded base64url of the challenge bytes", answer: "ABEiM0RVZneImaq7zN3u_w", wantTolerant: true},
+		{name: "base64 of the challenge text", answer: "MDAxMTIyMzM0NDU1NjY3Nzg4OTlhYWJiY2NkZGVlZmY=", wantTolerant: true},
+		{name: "different challenge", answer: "ffeeddccbbaa99887766554433221100"},
+		{name: "base64 of different bytes", answer: "/+7dzLuqmYh3ZlVEMyIRAA=="},
+		{name: "whitespace only", answer: "   "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []ChallengeVerificationMode{"", ChallengeVerificationStrict, ChallengeVerificationTolerant} {
+				s := NewChallengeService()
+				want := tt.wantStrict
+				if mode == ChallengeVerificationTolerant {
+					want = tt.wantTolerant
+				}
+				if got := s.Verify(ChallengePolicy{Verification: mode}, challenge, tt.answer); got != want {
+					t.Errorf("Verify(%q) in mode %q = %v, want %v", tt.answer, mode, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChallengeVerificationMode_Valid(t *testing.T) {
+	for _, m := range []ChallengeVerificationMode{"", ChallengeVerificationStrict, ChallengeVerificationTolerant} {
+		if !m.Valid() {
+			t.Errorf("ChallengeVerificationMode(%q).Valid() = false, want true", m)
+		}
+	}
+	if m := ChallengeVerificationMode("LOOSE"); m.Valid() {
+		t.Errorf("ChallengeVerificationMode(%q).Valid() = true, want false", m)
+	}
+}
+
+func TestChallengeService_NewChallenge_Policy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    ChallengePolicy
+		decode    func(string) ([]byte, error)
+		wantBytes int
+	}{
+		{name: "default", policy: ChallengePolicy{}, decode: hex.DecodeString, wantBytes: 32},
+		{name: "minimum length", policy: ChallengePolicy{Length: MinChallengeLength}, decode: hex.DecodeString, wantBytes: 16},
+		{name: "hex length", policy: ChallengePolicy{Length: 32, Algorithm: ChallengeAlgorithmHex}, decode: hex.DecodeString, wantBytes: 32},
+		{name: "base64 length", policy: ChallengePolicy{Length: 24, Algorithm: ChallengeAlgorithmBase64}, decode: base64.RawURLEncoding.DecodeString, wantBytes: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, err := NewChallengeService().NewChallenge(tt.policy)
+			if err != nil {
+				t.Fatalf("NewChallenge() error = %v, wantErr nil", err)
+			}
+			b, err := tt.decode(challenge)
+			if err != nil {
+				t.Fatalf("NewChallenge() = %q, failed to decode: %v", challenge, err)
+			}
+			if len(b) != tt.wantBytes {
+				t.Errorf("NewChallenge() = %d bytes, want %d", len(b), tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestChallengeService_Verify_TolerantBase64Challenge(t *testing.T) {
+	s := NewChallengeService()
+	p := ChallengePolicy{Algorithm: ChallengeAlgorithmBase64, Verification: ChallengeVerificationTolerant}
+	if !s.Verify(p, "AbC-_d", " AbC-_d\n") {
+		t.Error("Verify() with surrounding whitespace = false, want true")
+	}
+	if s.Verify(p, "AbC-_d", "abc-_d") {
+		t.Error("Verify() with different case = true, want false for a base64 challenge")
+	}
+}
+
+func TestChallengePolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ChallengePolicy
+		wantErr string
+	}{
+		{name: "zero", policy: ChallengePolicy{}},
+		{name: "all set", policy: ChallengePolicy{Length: 32, Algorithm: ChallengeAlgorithmBase64, Verification: ChallengeVerificationTolerant}},
+		{name: "negative length", policy: ChallengePolicy{Length: -1}, wantErr: "length -1 must not be negative"},
+		{name: "short length", policy: ChallengePolicy{Length: 8}, wantErr: "length 8 must be at least 16"},
+		{name: "one byte short", policy: ChallengePolicy{Length: MinChallengeLength - 1}, wantErr: "length 15 must be at least 16"},
+		{name: "minimum length", policy: ChallengePolicy{Length: MinChallengeLength}},
+		{name: "unknown algorithm", policy: ChallengePolicy{Algorithm: "SHA256"}, wantErr: `algorithm "SHA256" must be one of HEX, BASE64`},
+		{name: "unknown verification", policy: ChallengePolicy{Verification: "LOOSE"}, wantErr: `verification "LOOSE" must be one of STRICT, TOLERANT`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
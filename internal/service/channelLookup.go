@@ -16,13 +16,19 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"golang.org/x/time/rate"
 )
 
 // taskQueuer defines the interface for queueing tasks.
@@ -33,8 +39,17 @@ type taskQueuer interface {
 // authGen defines the interface for generrating auth header.
 type authGen interface {
 	AuthHeader(ctx context.Context, body []byte, keyID string) (string, error)
+	RequestAuthHeader(ctx context.Context, body []byte, keyID, requestID string) (string, error)
 }
 
+// ErrAmbiguousLookupCriteria is returned for a search whose lookup criteria do not say which BPPs it is for,
+// so that it is rejected rather than fanned out to more BPPs than intended.
+var ErrAmbiguousLookupCriteria = errors.New("ambiguous lookup criteria")
+
+// ErrPartialFanOut is returned for a lookup that failed after queuing proxy tasks for some of its
+// subscribers. The task queues do not retry it, as a retry would search those subscribers again.
+var ErrPartialFanOut = errors.New("lookup partially fanned out")
+
 // lookupClient defines the interface for looking up subscriptions.
 type lookupClient interface {
 	Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error)
@@ -45,13 +60,42 @@ type lookupClient interface {
 type channelLookupProcessor struct {
 	subID          string
 	maxProxyTasks  int // Maximum number of proxy tasks to generate from a lookup
+	dispatchConc   int // Number of proxy tasks of a lookup queued at the same time.
 	registryClient lookupClient
 	authGen        authGen
 	taskQueuer     taskQueuer
+	limiter        *rate.Limiter // Paces proxy task enqueueing, nil means no limit.
+	lookups        chan struct{} // Bounds the concurrent registry lookups, nil means no limit.
+	expiredPolicy  ExpiredSubscriptionPolicy
+	callbacks      *callbackLimiter // Receives the callback limits of looked up subscriptions, may be nil.
+	skipSingleBPP  bool             // Proxies tasks naming both bpp_id and bpp_uri without a lookup once the pair is verified.
+	strictVersion  bool             // Drops looked up subscriptions not advertising the task's version.
+	headerLimits   HeaderLimits     // Bounds the headers forwarded to each target.
+	sigHeader      string           // Signature header kept when headers are truncated, besides Authorization.
+
+	emptyTTL   time.Duration // How long empty lookup results are cached, 0 means not cached.
+	emptyMu    sync.Mutex
+	emptyUntil map[string]time.Time // Expiry of cached empty results by lookup criteria.
+
+	verifiedMu    sync.Mutex
+	verifiedUntil map[string]time.Time // Expiry of verified single BPP targets by domain, bpp_id and bpp_uri.
+
+	staleMaxAge time.Duration // How old a last-known result served on a failed lookup may be, 0 disables it.
+	staleMu     sync.Mutex
+	lastKnown   map[string]lastKnownLookup // Last successful results by lookup criteria.
+
+	now func() time.Time
+}
+
+// lastKnownLookup is the result of a successful lookup and when it was looked up.
+type lastKnownLookup struct {
+	subscriptions []model.Subscription
+	at            time.Time
 }
 
 // NewLookupTaskProcessor creates a new LookupTaskProcessor.
-func NewChannelLookupProcessor(registryClient lookupClient, authGen authGen, tq taskQueuer, subID string, maxProxyTasks int) (*channelLookupProcessor, error) {
+// The proxy tasks of a lookup are queued dispatchConcurrency at a time, one at a time if it is not positive.
+func NewChannelLookupProcessor(registryClient lookupClient, authGen authGen, tq taskQueuer, subID string, maxProxyTasks, dispatchConcurrency int) (*channelLookupProcessor, error) {
 	if registryClient == nil {
 		slog.Error("NewLookupTaskProcessor: registryClient cannot be nil")
 		return nil, fmt.Errorf("registryClient cannot be nil")
@@ -73,16 +117,220 @@ func NewChannelLookupProcessor(registryClient lookupClient, authGen authGen, tq
 		slog.Warn("NewChannelLookupProcessor: maxProxyTasks is not positive, defaulting to no limit (effectively unlimited)", "provided_max_proxy_tasks", maxProxyTasks)
 		maxProxyTasks = 0 // 0 or negative means no limit
 	}
+	if dispatchConcurrency <= 0 {
+		dispatchConcurrency = 1
+	}
 
 	return &channelLookupProcessor{
 		registryClient: registryClient,
 		authGen:        authGen,
 		taskQueuer:     tq,
 		maxProxyTasks:  maxProxyTasks,
+		dispatchConc:   dispatchConcurrency,
 		subID:          subID,
+		emptyUntil:     make(map[string]time.Time),
+		verifiedUntil:  make(map[string]time.Time),
+		lastKnown:      make(map[string]lastKnownLookup),
+		now:            time.Now,
 	}, nil
 }
 
+// SetProxyTaskRateLimit limits the rate at which proxy tasks are enqueued to tasksPerSecond,
+// spreading the fan-out of a large lookup over time. The limit is shared by all lookups.
+// A value of 0 or less removes the limit.
+func (p *channelLookupProcessor) SetProxyTaskRateLimit(tasksPerSecond float64) {
+	if tasksPerSecond <= 0 {
+		p.limiter = nil
+		return
+	}
+	p.limiter = rate.NewLimiter(rate.Limit(tasksPerSecond), 1)
+}
+
+// SetMaxConcurrentLookups limits the registry lookups in flight at the same time across all tasks
+// to n, so that a burst of LOOKUP tasks does not overwhelm the registry. Tasks beyond the limit wait
+// for a lookup to complete. A value of 0 or less removes the limit. It must be called before tasks are processed.
+func (p *channelLookupProcessor) SetMaxConcurrentLookups(n int) {
+	if n <= 0 {
+		p.lookups = nil
+		return
+	}
+	p.lookups = make(chan struct{}, n)
+}
+
+// SetExpiredSubscriptionPolicy sets how subscriptions whose ValidUntil has passed are handled.
+// With REJECT they are skipped, with WARN they are logged and proxied to anyway.
+func (p *channelLookupProcessor) SetExpiredSubscriptionPolicy(policy ExpiredSubscriptionPolicy) {
+	p.expiredPolicy = policy
+}
+
+// SetCallbackLimiter applies the max_concurrent_callbacks extended attribute of every
+// looked up subscription to limiter, as the limit of the subscription's host.
+func (p *channelLookupProcessor) SetCallbackLimiter(limiter *callbackLimiter) {
+	p.callbacks = limiter
+}
+
+// singleBPPVerifiedTTL is how long a bpp_uri found to be the URL of a subscription of its bpp_id
+// is trusted without another lookup.
+const singleBPPVerifiedTTL = 5 * time.Minute
+
+// SetSkipLookupForSingleBPP proxies tasks whose context names both a bpp_id and a bpp_uri
+// directly to that bpp_uri, saving the registry lookup. The bpp_uri must be the URL of a subscription
+// of the bpp_id, which is verified with a lookup of that bpp_id and then trusted for singleBPPVerifiedTTL.
+// Tasks with only one of them are still looked up.
+func (p *channelLookupProcessor) SetSkipLookupForSingleBPP(enabled bool) {
+	p.skipSingleBPP = enabled
+}
+
+// verifySingleBPP reports whether the bpp_uri of reqCtx is the URL of a subscription of its bpp_id,
+// so that callers cannot have the gateway sign and send requests to an arbitrary URL.
+func (p *channelLookupProcessor) verifySingleBPP(ctx context.Context, reqCtx *model.Context) (bool, error) {
+	key := reqCtx.Domain + "|" + reqCtx.BppID + "|" + reqCtx.BppURI
+	p.verifiedMu.Lock()
+	until, ok := p.verifiedUntil[key]
+	p.verifiedMu.Unlock()
+	if ok && p.now().Before(until) {
+		return true, nil
+	}
+
+	byID := model.Context{Domain: reqCtx.Domain, BppID: reqCtx.BppID}
+	subscriptions, err := p.lookup(ctx, &byID)
+	if err != nil {
+		return false, err
+	}
+	for _, sub := range subscriptions {
+		if sub.SubscriberID == reqCtx.BppID && sub.URL == reqCtx.BppURI {
+			p.verifiedMu.Lock()
+			defer p.verifiedMu.Unlock()
+			now := p.now()
+			for k, until := range p.verifiedUntil {
+				if !now.Before(until) {
+					delete(p.verifiedUntil, k)
+				}
+			}
+			p.verifiedUntil[key] = now.Add(singleBPPVerifiedTTL)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetStrictVersionMatch drops the looked up subscriptions whose advertised version differs from
+// the version of the task's context. Subscriptions advertising no version, like all those of this
+// repo's registry, are kept. Tasks without a version are proxied to all subscriptions.
+func (p *channelLookupProcessor) SetStrictVersionMatch(enabled bool) {
+	p.strictVersion = enabled
+}
+
+// SetForwardedHeaderLimits bounds the headers of a task forwarded to each of its targets, so that
+// a request with large headers does not multiply its memory across a fan-out. signatureHeader is
+// the header transactions are signed in, which is never dropped by truncation, like Authorization,
+// X-Request-ID and Content-Type.
+func (p *channelLookupProcessor) SetForwardedHeaderLimits(limits HeaderLimits, signatureHeader string) {
+	p.headerLimits = limits
+	p.sigHeader = signatureHeader
+}
+
+// proxyHeaders returns the headers of the proxy tasks of originalTask, within the forwarded
+// header limits and signed by the gateway with authHeader.
+func (p *channelLookupProcessor) proxyHeaders(ctx context.Context, originalTask *model.AsyncTask, authHeader string) (http.Header, error) {
+	protected := []string{model.AuthHeaderSubscriber, model.RequestIDHeader, "Content-Type"}
+	if p.sigHeader != "" {
+		protected = append(protected, p.sigHeader)
+	}
+	headers, dropped, err := limitHeaders(originalTask.Headers, p.headerLimits, protected)
+	if err != nil {
+		slog.ErrorContext(ctx, "LookupTaskProcessor: Rejecting task with oversized headers", "error", err)
+		return nil, err
+	}
+	if len(dropped) > 0 {
+		slog.WarnContext(ctx, "LookupTaskProcessor: Dropped headers exceeding the forwarded header limits", "dropped", dropped)
+	}
+	headers = headers.Clone()
+	headers.Set(model.AuthHeaderGateway, authHeader)
+	return headers, nil
+}
+
+// SetNegativeLookupCacheTTL caches lookups that find no subscribers for ttl, so that repeated
+// searches for an unpopulated domain do not query the registry again until ttl has passed.
+// Lookups finding subscribers are never cached. A ttl of 0 or less disables the cache.
+func (p *channelLookupProcessor) SetNegativeLookupCacheTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	p.emptyTTL = ttl
+}
+
+// cachedEmpty reports whether the lookup identified by key recently found no subscribers.
+func (p *channelLookupProcessor) cachedEmpty(key string) bool {
+	p.emptyMu.Lock()
+	defer p.emptyMu.Unlock()
+	until, ok := p.emptyUntil[key]
+	if !ok {
+		return false
+	}
+	if p.now().Before(until) {
+		return true
+	}
+	delete(p.emptyUntil, key)
+	return false
+}
+
+// cacheEmpty records that the lookup identified by key found no subscribers,
+// dropping the entries that have expired.
+func (p *channelLookupProcessor) cacheEmpty(key string) {
+	p.emptyMu.Lock()
+	defer p.emptyMu.Unlock()
+	now := p.now()
+	for k, until := range p.emptyUntil {
+		if !now.Before(until) {
+			delete(p.emptyUntil, k)
+		}
+	}
+	p.emptyUntil[key] = now.Add(p.emptyTTL)
+}
+
+// SetStaleLookupMaxAge serves the last successful result of a lookup with the same criteria, marked
+// as stale in the logs, when the registry lookup fails and that result is at most maxAge old, so that
+// a registry outage does not fail every fan-out. A maxAge of 0 or less disables it.
+func (p *channelLookupProcessor) SetStaleLookupMaxAge(maxAge time.Duration) {
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	p.staleMaxAge = maxAge
+}
+
+// lastKnownResult returns the last successful result of the lookup identified by key and its age,
+// if it is at most the stale max age old.
+func (p *channelLookupProcessor) lastKnownResult(key string) ([]model.Subscription, time.Duration, bool) {
+	p.staleMu.Lock()
+	defer p.staleMu.Unlock()
+	r, ok := p.lastKnown[key]
+	if !ok {
+		return nil, 0, false
+	}
+	age := p.now().Sub(r.at)
+	if age > p.staleMaxAge {
+		delete(p.lastKnown, key)
+		return nil, 0, false
+	}
+	return slices.Clone(r.subscriptions), age, true
+}
+
+// storeLastKnown records subscriptions as the last successful result of the lookup identified by key,
+// dropping the results too old to be served.
+func (p *channelLookupProcessor) storeLastKnown(key string, subscriptions []model.Subscription) {
+	p.staleMu.Lock()
+	defer p.staleMu.Unlock()
+	now := p.now()
+	for k, r := range p.lastKnown {
+		if now.Sub(r.at) > p.staleMaxAge {
+			delete(p.lastKnown, k)
+		}
+	}
+	// Cloned, as the fan-out shuffles the subscriptions it is given.
+	p.lastKnown[key] = lastKnownLookup{subscriptions: slices.Clone(subscriptions), at: now}
+}
+
 // validateTask checks if the AsyncTask is valid for processing.
 func (p *channelLookupProcessor) validateTask(ctx context.Context, task *model.AsyncTask) error {
 	if task == nil {
@@ -100,6 +348,43 @@ func (p *channelLookupProcessor) validateTask(ctx context.Context, task *model.A
 	return nil
 }
 
+// matchVersion returns the subscriptions advertising version or no version at all, since a registry
+// not storing versions cannot tell which ones the subscriber supports. subscriptions is not modified,
+// since it may be a cached result.
+func matchVersion(ctx context.Context, subscriptions []model.Subscription, version string) []model.Subscription {
+	matched := make([]model.Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		if sub.Version == "" || sub.Version == version {
+			matched = append(matched, sub)
+		}
+	}
+	if filtered := len(subscriptions) - len(matched); filtered > 0 {
+		slog.InfoContext(ctx, "LookupTaskProcessor: Filtered out subscriptions not matching the request version", "version", version, "filtered", filtered, "remaining", len(matched))
+	}
+	return matched
+}
+
+// ValidateLookupCriteria checks the criteria of a search fanned out with a registry lookup, one
+// without a bpp_uri, so that the gateway can reject it before acknowledging it. A bpp_id selects a
+// single BPP and a location the BPPs serving it; given together, the search is for that BPP if it
+// serves the location. A location naming no place would silently widen the lookup to every BPP of
+// the domain, so it is rejected with ErrAmbiguousLookupCriteria.
+func ValidateLookupCriteria(reqCtx *model.Context) error {
+	if reqCtx.Action != "search" || reqCtx.BppURI != "" {
+		return nil
+	}
+	if reqCtx.Location != nil && *reqCtx.Location == (model.Location{}) {
+		return fmt.Errorf("%w: location names no place", ErrAmbiguousLookupCriteria)
+	}
+	return nil
+}
+
+// singleBPP reports whether the request context fully specifies a single target BPP,
+// so that it can be proxied to without a lookup.
+func singleBPP(reqCtx *model.Context) bool {
+	return reqCtx.BppID != "" && reqCtx.BppURI != ""
+}
+
 // lookup unmarshals the task body and looks up subscriptions.
 func (p *channelLookupProcessor) lookup(ctx context.Context, reqCtx *model.Context) ([]model.Subscription, error) {
 	lookupCriteria := &model.Subscription{
@@ -110,42 +395,116 @@ func (p *channelLookupProcessor) lookup(ctx context.Context, reqCtx *model.Conte
 			Location:     reqCtx.Location,
 		}}
 
+	var cacheKey string
+	if p.emptyTTL > 0 || p.staleMaxAge > 0 {
+		key, err := json.Marshal(lookupCriteria)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal lookup criteria: %w", err)
+		}
+		cacheKey = string(key)
+	}
+	if p.emptyTTL > 0 {
+		if p.cachedEmpty(cacheKey) {
+			slog.DebugContext(ctx, "LookupTaskProcessor: Lookup recently found no subscribers, skipping registry", "criteria", lookupCriteria)
+			return nil, nil
+		}
+	}
+
+	if p.lookups != nil {
+		select {
+		case p.lookups <- struct{}{}:
+			defer func() { <-p.lookups }()
+		case <-ctx.Done():
+			slog.ErrorContext(ctx, "LookupTaskProcessor: Cancelled while waiting for a lookup slot", "error", ctx.Err())
+			return nil, fmt.Errorf("failed to lookup subscribers: %w", ctx.Err())
+		}
+	}
 	slog.DebugContext(ctx, "LookupTaskProcessor: Performing lookup with criteria", "criteria", lookupCriteria)
 	subscriptions, err := p.registryClient.Lookup(ctx, lookupCriteria)
 	if err != nil {
+		if p.staleMaxAge > 0 {
+			if stale, age, ok := p.lastKnownResult(cacheKey); ok {
+				slog.WarnContext(ctx, "LookupTaskProcessor: Failed to lookup subscribers from registry, serving last-known subscribers", "error", err, "stale", true, "age", age, "count", len(stale))
+				return stale, nil
+			}
+		}
 		slog.ErrorContext(ctx, "LookupTaskProcessor: Failed to lookup subscribers from registry", "error", err)
 		return nil, fmt.Errorf("failed to lookup subscribers: %w", err)
 	}
+	if len(subscriptions) == 0 && p.emptyTTL > 0 {
+		p.cacheEmpty(cacheKey)
+	}
+	if p.staleMaxAge > 0 {
+		p.storeLastKnown(cacheKey, subscriptions)
+	}
 	return subscriptions, nil
 }
 
 // enqueueProxyTasks iterates through subscriptions, prepares, and enqueues proxy tasks
 // using the configured taskQueuer.
 func (p *channelLookupProcessor) enqueueProxyTasks(ctx context.Context, subscriptions []model.Subscription, originalTask *model.AsyncTask) error {
-	authHeader, err := p.authGen.AuthHeader(ctx, originalTask.Body, p.subID)
+	authHeader, err := p.authGen.RequestAuthHeader(ctx, originalTask.Body, p.subID, originalTask.Headers.Get(model.RequestIDHeader))
 	if err != nil {
 		slog.ErrorContext(ctx, "LookupTaskProcessor: Failed to prepare signed headers for proxy tasks", "error", err)
 		return fmt.Errorf("failed to prepare signed headers for proxy tasks: %w", err)
 	}
 
-	headersForProxy := originalTask.Headers.Clone()
-	headersForProxy.Set(model.AuthHeaderGateway, authHeader)
+	headersForProxy, err := p.proxyHeaders(ctx, originalTask, authHeader)
+	if err != nil {
+		return err
+	}
 
 	// Randomize the order of subscriptions to distribute load, especially when maxProxyTasks is used.
 	rand.Shuffle(len(subscriptions), func(i, j int) {
 		subscriptions[i], subscriptions[j] = subscriptions[j], subscriptions[i]
 	})
 
-	successfulPublications := 0
-	skipped := 0
-	var firstError error
+	// Up to dispatchConc tasks are queued at a time. A subscription is dispatched only while the
+	// queued and in-flight tasks stay within maxProxyTasks, so that failures still let others take their place.
+	var (
+		mu                     sync.Mutex
+		done                   = sync.NewCond(&mu)
+		wg                     sync.WaitGroup
+		inFlight               int
+		successfulPublications int
+		skipped                int
+		firstError             error
+	)
 
 	for i, sub := range subscriptions {
 		if sub.URL == "" {
 			slog.WarnContext(ctx, "LookupTaskProcessor: Skipping subscriber due to empty URL", "subscriber_id", sub.SubscriberID)
+			mu.Lock()
 			skipped++
+			mu.Unlock()
 			continue
 		}
+		if p.expiredPolicy.enabled() && subscriptionExpired(&sub, time.Now()) {
+			if p.expiredPolicy == ExpiredSubscriptionPolicyReject {
+				slog.WarnContext(ctx, "LookupTaskProcessor: Skipping subscriber with expired subscription", "subscriber_id", sub.SubscriberID, "valid_until", sub.ValidUntil)
+				mu.Lock()
+				skipped++
+				mu.Unlock()
+				continue
+			}
+			slog.WarnContext(ctx, "LookupTaskProcessor: Proxying to subscriber with expired subscription", "subscriber_id", sub.SubscriberID, "valid_until", sub.ValidUntil)
+		}
+
+		mu.Lock()
+		for inFlight >= p.dispatchConc || (p.maxProxyTasks > 0 && inFlight > 0 && successfulPublications+inFlight >= p.maxProxyTasks) {
+			done.Wait()
+		}
+		if p.maxProxyTasks > 0 && successfulPublications >= p.maxProxyTasks {
+			mu.Unlock()
+			slog.InfoContext(ctx, "LookupTaskProcessor: Reached maxProxyTasks limit, stopping further proxy task creation for this lookup.", "limit", p.maxProxyTasks, "created_count", successfulPublications, "total_subscriptions_found", len(subscriptions), "subscriptions_skipped_due_to_limit", len(subscriptions)-i)
+			break
+		}
+		inFlight++
+		mu.Unlock()
+
+		if p.callbacks != nil {
+			p.applyCallbackLimit(ctx, &sub)
+		}
 
 		// Prepare a model.Context for this specific proxy task.
 		// QueueTxn will use this to determine task type (PROXY) and target.
@@ -156,29 +515,90 @@ func (p *channelLookupProcessor) enqueueProxyTasks(ctx context.Context, subscrip
 			"target_bpp_uri", proxyTaskModelContext.BppURI,
 			"action_for_queue", proxyTaskModelContext.Action)
 
-		// QueueTxn will create the AsyncTask, set its Type to PROXY, and Target based on BppURI + "/search" (or other action path)
-		_, err := p.taskQueuer.QueueTxn(ctx, &proxyTaskModelContext, originalTask.Body, headersForProxy)
-		if err != nil {
-			errMsg := fmt.Errorf("failed to queue proxy task for subscriber %s (URL: %s): %w", sub.SubscriberID, sub.URL, err)
-			slog.ErrorContext(ctx, "LookupTaskProcessor: Error enqueuing proxy task", "error", errMsg)
-			if firstError == nil {
-				firstError = errMsg // Capture the first error
+		if p.limiter != nil {
+			if err := p.limiter.Wait(ctx); err != nil {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				wg.Wait()
+				slog.WarnContext(ctx, "LookupTaskProcessor: Stopped enqueuing proxy tasks while waiting for rate limiter", "error", err, "created_count", successfulPublications, "remaining", len(subscriptions)-i)
+				if successfulPublications > 0 {
+					return fmt.Errorf("%w: proxy task enqueueing stopped after %d tasks: %w", ErrPartialFanOut, successfulPublications, err)
+				}
+				return fmt.Errorf("proxy task enqueueing stopped after %d tasks: %w", successfulPublications, err)
 			}
-			skipped++
-			continue
 		}
-		slog.InfoContext(ctx, "LookupTaskProcessor: Successfully queued proxy task", "subscriber_id", sub.SubscriberID, "target_bpp_uri", sub.URL)
-		successfulPublications++
-		if p.maxProxyTasks > 0 && successfulPublications >= p.maxProxyTasks {
-			slog.InfoContext(ctx, "LookupTaskProcessor: Reached maxProxyTasks limit, stopping further proxy task creation for this lookup.", "limit", p.maxProxyTasks, "created_count", successfulPublications, "total_subscriptions_found", len(subscriptions), "subscriptions_skipped_due_to_limit", len(subscriptions)-(i+1))
-			break
-		}
-
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// QueueTxn will create the AsyncTask, set its Type to PROXY, and Target based on BppURI + "/search" (or other action path)
+			_, err := p.taskQueuer.QueueTxn(ctx, &proxyTaskModelContext, originalTask.Body, headersForProxy)
+			mu.Lock()
+			defer mu.Unlock()
+			defer done.Broadcast()
+			inFlight--
+			if err != nil {
+				errMsg := fmt.Errorf("failed to queue proxy task for subscriber %s (URL: %s): %w", sub.SubscriberID, sub.URL, err)
+				slog.ErrorContext(ctx, "LookupTaskProcessor: Error enqueuing proxy task", "error", errMsg)
+				if firstError == nil {
+					firstError = errMsg // Capture the first error
+				}
+				skipped++
+				return
+			}
+			slog.InfoContext(ctx, "LookupTaskProcessor: Successfully queued proxy task", "subscriber_id", sub.SubscriberID, "target_bpp_uri", sub.URL)
+			successfulPublications++
+		}()
 	}
+	wg.Wait()
 	slog.InfoContext(ctx, "LookupTaskProcessor: Finished enqueuing proxy tasks", "successful_count", successfulPublications, "skipped_or_failed", skipped)
+	if firstError != nil && successfulPublications > 0 {
+		// The subscribers already searched must not be searched again by a retry of the lookup.
+		return fmt.Errorf("%w: %d proxy tasks queued: %w", ErrPartialFanOut, successfulPublications, firstError)
+	}
 	return firstError // Return the first error encountered, or nil if all successful
 }
 
+// enqueueDirectProxyTask enqueues a single proxy task to the bpp_uri of the original task's context.
+func (p *channelLookupProcessor) enqueueDirectProxyTask(ctx context.Context, originalTask *model.AsyncTask) error {
+	authHeader, err := p.authGen.RequestAuthHeader(ctx, originalTask.Body, p.subID, originalTask.Headers.Get(model.RequestIDHeader))
+	if err != nil {
+		slog.ErrorContext(ctx, "LookupTaskProcessor: Failed to prepare signed headers for proxy task", "error", err)
+		return fmt.Errorf("failed to prepare signed headers for proxy task: %w", err)
+	}
+	headersForProxy, err := p.proxyHeaders(ctx, originalTask, authHeader)
+	if err != nil {
+		return err
+	}
+
+	if p.limiter != nil {
+		if err := p.limiter.Wait(ctx); err != nil {
+			slog.WarnContext(ctx, "LookupTaskProcessor: Stopped enqueuing proxy task while waiting for rate limiter", "error", err)
+			return fmt.Errorf("proxy task enqueueing stopped: %w", err)
+		}
+	}
+	proxyTaskModelContext := originalTask.Context
+	if _, err := p.taskQueuer.QueueTxn(ctx, &proxyTaskModelContext, originalTask.Body, headersForProxy); err != nil {
+		slog.ErrorContext(ctx, "LookupTaskProcessor: Error enqueuing direct proxy task", "error", err, "bpp_id", proxyTaskModelContext.BppID)
+		return fmt.Errorf("failed to queue proxy task for subscriber %s (URL: %s): %w", proxyTaskModelContext.BppID, proxyTaskModelContext.BppURI, err)
+	}
+	slog.InfoContext(ctx, "LookupTaskProcessor: Successfully queued direct proxy task", "subscriber_id", proxyTaskModelContext.BppID, "target_bpp_uri", proxyTaskModelContext.BppURI)
+	return nil
+}
+
+// applyCallbackLimit sets the callback limit of the host of sub from its extended attributes.
+// Invalid attributes are logged and leave the current limit unchanged.
+func (p *channelLookupProcessor) applyCallbackLimit(ctx context.Context, sub *model.Subscription) {
+	n, err := sub.MaxConcurrentCallbacks()
+	if err != nil {
+		slog.WarnContext(ctx, "LookupTaskProcessor: Ignoring invalid callback limit", "subscriber_id", sub.SubscriberID, "error", err)
+		return
+	}
+	if host := targetHost(sub.URL); host != "" {
+		p.callbacks.SetTargetLimit(host, n)
+	}
+}
+
 // Process handles the given LOOKUP asynchronous task.
 // It looks up subscribers based on the task body and queues individual PROXY tasks for each.
 func (p *channelLookupProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
@@ -187,10 +607,26 @@ func (p *channelLookupProcessor) Process(ctx context.Context, task *model.AsyncT
 	}
 	slog.InfoContext(ctx, "LookupTaskProcessor: Processing lookup task", "task.context", task.Context)
 
+	if p.skipSingleBPP && singleBPP(&task.Context) {
+		verified, err := p.verifySingleBPP(ctx, &task.Context)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			slog.WarnContext(ctx, "LookupTaskProcessor: bpp_uri is not the URL of a subscription of bpp_id, dropping task", "bpp_id", task.Context.BppID, "bpp_uri", task.Context.BppURI)
+			return nil
+		}
+		slog.InfoContext(ctx, "LookupTaskProcessor: Context names a verified single BPP, skipping lookup", "bpp_id", task.Context.BppID, "bpp_uri", task.Context.BppURI)
+		return p.enqueueDirectProxyTask(ctx, task)
+	}
+
 	subscriptions, err := p.lookup(ctx, &task.Context)
 	if err != nil {
 		return err
 	}
+	if p.strictVersion && task.Context.Version != "" {
+		subscriptions = matchVersion(ctx, subscriptions, task.Context.Version)
+	}
 
 	// If no subscribers found, nothing more to do.
 	if len(subscriptions) == 0 {
@@ -17,21 +17,30 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // mockLookupClient is a mock for the lookupClient interface.
 type mockLookupClient struct {
 	subscriptions []model.Subscription
 	err           error
+	request       *model.Subscription // Last lookup criteria received.
+	calls         int
 }
 
 func (m *mockLookupClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	m.request = request
+	m.calls++
 	return m.subscriptions, m.err
 }
 
@@ -131,7 +140,7 @@ func TestNewChannelLookupProcessor(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := NewChannelLookupProcessor(tt.registryClient, tt.authGen, tt.taskQueuer, tt.subID, tt.maxProxyTasks)
+			_, err := NewChannelLookupProcessor(tt.registryClient, tt.authGen, tt.taskQueuer, tt.subID, tt.maxProxyTasks, 1)
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
 					t.Errorf("NewChannelLookupProcessor() error = %v, want %q", err, tt.wantErr)
@@ -168,6 +177,7 @@ func TestChannelLookupProcessor_Process(t *testing.T) {
 		setupMocks     func(mockLookup *mockLookupClient, mockAuth *mockAuthGen, mockQueuer *mockTaskQueuer)
 		wantErrMsg     string
 		wantQueueCalls int
+		wantPartial    bool // Whether the error is ErrPartialFanOut.
 	}{
 		{
 			name:          "success - multiple subscribers found and queued",
@@ -279,6 +289,7 @@ func TestChannelLookupProcessor_Process(t *testing.T) {
 			},
 			wantErrMsg:     "failed to queue proxy task for subscriber", // More generic check
 			wantQueueCalls: 2,
+			wantPartial:    true,
 		},
 	}
 
@@ -297,7 +308,7 @@ func TestChannelLookupProcessor_Process(t *testing.T) {
 				maxTasks = 10 // Default for tests not specifying it
 			}
 
-			processor, _ := NewChannelLookupProcessor(mockLookup, mockAuth, mockQueuer, "test-id", maxTasks)
+			processor, _ := NewChannelLookupProcessor(mockLookup, mockAuth, mockQueuer, "test-id", maxTasks, 1)
 			err := processor.Process(ctx, tt.task)
 
 			if tt.wantErrMsg != "" {
@@ -313,6 +324,770 @@ func TestChannelLookupProcessor_Process(t *testing.T) {
 			if mockQueuer.callCount != tt.wantQueueCalls {
 				t.Errorf("Process() taskQueuer was called %d times, want %d", mockQueuer.callCount, tt.wantQueueCalls)
 			}
+			if got := errors.Is(err, ErrPartialFanOut); got != tt.wantPartial {
+				t.Errorf("Process() error is ErrPartialFanOut = %v, want %v", got, tt.wantPartial)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_RateLimit(t *testing.T) {
+	const numTasks, tasksPerSecond = 5, 20.0
+	subs := make([]model.Subscription, numTasks)
+	for i := range subs {
+		subs[i] = model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp", URL: "http://bpp.com"}}
+	}
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}}
+
+	tests := []struct {
+		name        string
+		rate        float64
+		wantMinTime time.Duration
+	}{
+		{
+			name: "no rate limit",
+		},
+		{
+			name: "rate limit paces enqueueing",
+			rate: tasksPerSecond,
+			// The first task is enqueued immediately, every further one waits 1/rate.
+			wantMinTime: time.Duration(float64(numTasks-1) / tasksPerSecond * float64(time.Second)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueuer := &mockTaskQueuer{}
+			processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: subs}, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetProxyTaskRateLimit(tt.rate)
+
+			start := time.Now()
+			if err := processor.Process(context.Background(), task); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			elapsed := time.Since(start)
+
+			if mockQueuer.callCount != numTasks {
+				t.Errorf("Process() queued %d tasks, want %d", mockQueuer.callCount, numTasks)
+			}
+			if elapsed < tt.wantMinTime {
+				t.Errorf("Process() took %v, want at least %v", elapsed, tt.wantMinTime)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_RateLimitContextCancelled(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp2", URL: "http://bpp2.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp3", URL: "http://bpp3.com"}},
+	}
+	mockQueuer := &mockTaskQueuer{}
+	processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: subs}, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+	if err != nil {
+		t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+	}
+	processor.SetProxyTaskRateLimit(0.1) // One task every 10s.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = processor.Process(ctx, &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}})
+	if err == nil || !strings.Contains(err.Error(), "proxy task enqueueing stopped after 1 tasks") {
+		t.Errorf("Process() error = %v, want error containing %q", err, "proxy task enqueueing stopped after 1 tasks")
+	}
+	if mockQueuer.callCount != 1 {
+		t.Errorf("Process() queued %d tasks, want 1", mockQueuer.callCount)
+	}
+}
+
+// concurrentLookupClient is a lookupClient recording the most lookups in flight at the same time.
+type concurrentLookupClient struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	calls    int
+	delay    time.Duration
+}
+
+func (c *concurrentLookupClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.calls++
+	c.max = max(c.max, c.inFlight)
+	c.mu.Unlock()
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return nil, nil
+}
+
+func TestChannelLookupProcessor_Process_StrictVersionMatch(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp-v1", URL: "http://bpp-v1.com", Version: "1.1.0"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp-v2", URL: "http://bpp-v2.com", Version: "2.0.0"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp-none", URL: "http://bpp-none.com"}},
+	}
+
+	tests := []struct {
+		name    string
+		strict  bool
+		version string
+		want    []string
+	}{
+		{name: "disabled", version: "1.1.0", want: []string{"http://bpp-none.com", "http://bpp-v1.com", "http://bpp-v2.com"}},
+		{name: "matching or unadvertised version only", strict: true, version: "1.1.0", want: []string{"http://bpp-none.com", "http://bpp-v1.com"}},
+		{name: "no advertised version matches", strict: true, version: "0.9.4", want: []string{"http://bpp-none.com"}},
+		{name: "request without version", strict: true, want: []string{"http://bpp-none.com", "http://bpp-v1.com", "http://bpp-v2.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			mockQueuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+				got = append(got, reqCtx.BppURI)
+				return &model.AsyncTask{}, nil
+			}}
+			looked := append([]model.Subscription(nil), subs...)
+			processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: looked}, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetStrictVersionMatch(tt.strict)
+
+			task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: model.Context{Action: "search", Version: tt.version}}
+			if err := processor.Process(context.Background(), task); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("proxied targets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// concurrentTaskQueuer is a taskQueuer safe for concurrent use that records the calls in flight.
+type concurrentTaskQueuer struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	queued      int
+	failEvery   int // If positive, every failEvery-th call fails.
+	calls       int
+}
+
+func (q *concurrentTaskQueuer) QueueTxn(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+	q.mu.Lock()
+	q.calls++
+	fail := q.failEvery > 0 && q.calls%q.failEvery == 0
+	q.inFlight++
+	q.maxInFlight = max(q.maxInFlight, q.inFlight)
+	q.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inFlight--
+	if fail {
+		return nil, errors.New("queue full")
+	}
+	q.queued++
+	return &model.AsyncTask{}, nil
+}
+
+func TestChannelLookupProcessor_Process_DispatchConcurrency(t *testing.T) {
+	subs := make([]model.Subscription, 100)
+	for i := range subs {
+		subs[i] = model.Subscription{Subscriber: model.Subscriber{SubscriberID: fmt.Sprintf("bpp%d", i), URL: fmt.Sprintf("http://bpp%d.com", i)}}
+	}
+
+	tests := []struct {
+		name          string
+		concurrency   int
+		maxProxyTasks int
+		failEvery     int
+		wantQueued    int
+		wantErr       bool
+	}{
+		{name: "all subscriptions queued", concurrency: 8, wantQueued: 100},
+		{name: "maxProxyTasks preserved", concurrency: 8, maxProxyTasks: 30, wantQueued: 30},
+		{name: "failures replaced within maxProxyTasks", concurrency: 8, maxProxyTasks: 30, failEvery: 3, wantQueued: 30, wantErr: true},
+		{name: "serial", concurrency: 1, maxProxyTasks: 30, wantQueued: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &concurrentTaskQueuer{failEvery: tt.failEvery}
+			processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: append([]model.Subscription(nil), subs...)}, &mockAuthGen{}, q, "test-id", tt.maxProxyTasks, tt.concurrency)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+
+			err = processor.Process(context.Background(), &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: model.Context{Action: "search"}})
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if q.queued != tt.wantQueued {
+				t.Errorf("queued proxy tasks = %d, want %d", q.queued, tt.wantQueued)
+			}
+			if q.maxInFlight > tt.concurrency {
+				t.Errorf("QueueTxn calls in flight = %d, want at most %d", q.maxInFlight, tt.concurrency)
+			}
+			if tt.concurrency > 1 && q.maxInFlight < 2 {
+				t.Errorf("QueueTxn calls in flight = %d, want them to run concurrently", q.maxInFlight)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_MaxConcurrentLookups(t *testing.T) {
+	const numTasks = 8
+	tests := []struct {
+		name       string
+		limit      int
+		wantAtMost int
+	}{
+		{name: "limited", limit: 2, wantAtMost: 2},
+		{name: "single", limit: 1, wantAtMost: 1},
+		{name: "no limit", limit: 0, wantAtMost: numTasks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &concurrentLookupClient{delay: 20 * time.Millisecond}
+			processor, err := NewChannelLookupProcessor(client, &mockAuthGen{}, &mockTaskQueuer{}, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetMaxConcurrentLookups(tt.limit)
+
+			var wg sync.WaitGroup
+			for i := 0; i < numTasks; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}}
+					if err := processor.Process(context.Background(), task); err != nil {
+						t.Errorf("Process() error = %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if client.calls != numTasks {
+				t.Errorf("lookups = %d, want %d", client.calls, numTasks)
+			}
+			if client.max > tt.wantAtMost {
+				t.Errorf("simultaneous lookups = %d, want at most %d", client.max, tt.wantAtMost)
+			}
+			if tt.limit > 0 && client.max != tt.limit {
+				t.Errorf("simultaneous lookups = %d, want the limit %d to be reached", client.max, tt.limit)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_MaxConcurrentLookupsContextCancelled(t *testing.T) {
+	processor, err := NewChannelLookupProcessor(&mockLookupClient{}, &mockAuthGen{}, &mockTaskQueuer{}, "test-id", 0, 1)
+	if err != nil {
+		t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+	}
+	processor.SetMaxConcurrentLookups(1)
+	processor.lookups <- struct{}{} // The only lookup slot is taken.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = processor.Process(ctx, &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Process() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestChannelLookupProcessor_Process_ExpiredSubscriptions(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "expired-bpp", URL: "http://expired.com"}, ValidUntil: time.Now().Add(-time.Hour)},
+		{Subscriber: model.Subscriber{SubscriberID: "valid-bpp", URL: "http://valid.com"}, ValidUntil: time.Now().Add(time.Hour)},
+		{Subscriber: model.Subscriber{SubscriberID: "no-expiry-bpp", URL: "http://no-expiry.com"}},
+	}
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}}
+
+	tests := []struct {
+		name       string
+		policy     ExpiredSubscriptionPolicy
+		wantQueued []string
+	}{
+		{
+			name:       "off proxies to expired subscription",
+			wantQueued: []string{"http://expired.com", "http://no-expiry.com", "http://valid.com"},
+		},
+		{
+			name:       "warn proxies to expired subscription",
+			policy:     ExpiredSubscriptionPolicyWarn,
+			wantQueued: []string{"http://expired.com", "http://no-expiry.com", "http://valid.com"},
+		},
+		{
+			name:       "reject skips expired subscription",
+			policy:     ExpiredSubscriptionPolicyReject,
+			wantQueued: []string{"http://no-expiry.com", "http://valid.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queued []string
+			mockQueuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+				queued = append(queued, reqCtx.BppURI)
+				return &model.AsyncTask{}, nil
+			}}
+			processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: append([]model.Subscription(nil), subs...)}, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetExpiredSubscriptionPolicy(tt.policy)
+
+			if err := processor.Process(context.Background(), task); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantQueued, queued, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Errorf("Process() queued targets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_CallbackLimits(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "limited-bpp", URL: "http://limited.com/beckn"}, ExtendedAttributes: []byte(`{"max_concurrent_callbacks":3}`)},
+		{Subscriber: model.Subscriber{SubscriberID: "default-bpp", URL: "http://default.com/beckn"}},
+		{Subscriber: model.Subscriber{SubscriberID: "invalid-bpp", URL: "http://invalid.com/beckn"}, ExtendedAttributes: []byte(`{"max_concurrent_callbacks":-1}`)},
+	}
+	task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}}
+	mockQueuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+		return &model.AsyncTask{}, nil
+	}}
+	processor, err := NewChannelLookupProcessor(&mockLookupClient{subscriptions: subs}, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+	if err != nil {
+		t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+	}
+	limiter := NewCallbackLimiter(1)
+	limiter.SetTargetLimit("invalid.com", 5)
+	processor.SetCallbackLimiter(limiter)
+
+	if err := processor.Process(context.Background(), task); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	want := map[string]int{"limited.com": 3, "default.com": 1, "invalid.com": 5}
+	for host, n := range want {
+		if got := limiter.limit(host); got != n {
+			t.Errorf("limit(%q) = %d, want %d", host, got, n)
+		}
+	}
+}
+
+func TestChannelLookupProcessor_Process_Criteria(t *testing.T) {
+	location := &model.Location{City: &model.City{Code: "std:080"}}
+	subs := []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}}}
+
+	tests := []struct {
+		name         string
+		reqCtx       model.Context
+		wantCriteria *model.Subscription
+	}{
+		{
+			name:   "specific bpp_id",
+			reqCtx: model.Context{Domain: "retail", BppID: "bpp1"},
+			wantCriteria: &model.Subscription{Subscriber: model.Subscriber{
+				Domain:       "retail",
+				Type:         model.RoleBPP,
+				SubscriberID: "bpp1",
+			}},
+		},
+		{
+			name:   "location only",
+			reqCtx: model.Context{Domain: "retail", Location: location},
+			wantCriteria: &model.Subscription{Subscriber: model.Subscriber{
+				Domain:   "retail",
+				Type:     model.RoleBPP,
+				Location: location,
+			}},
+		},
+		{
+			name:   "bpp_id with empty location",
+			reqCtx: model.Context{Domain: "retail", BppID: "bpp1", Location: &model.Location{}},
+			wantCriteria: &model.Subscription{Subscriber: model.Subscriber{
+				Domain:       "retail",
+				Type:         model.RoleBPP,
+				SubscriberID: "bpp1",
+				Location:     &model.Location{},
+			}},
+		},
+		{
+			name:   "bpp_id with location looks up the bpp in the location",
+			reqCtx: model.Context{Domain: "retail", BppID: "bpp1", Location: location},
+			wantCriteria: &model.Subscription{Subscriber: model.Subscriber{
+				Domain:       "retail",
+				Type:         model.RoleBPP,
+				SubscriberID: "bpp1",
+				Location:     location,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLookup := &mockLookupClient{subscriptions: subs}
+			mockQueuer := &mockTaskQueuer{}
+			processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: tt.reqCtx}
+
+			if err := processor.Process(context.Background(), task); err != nil {
+				t.Fatalf("Process() error = %v, want nil", err)
+			}
+			if diff := cmp.Diff(tt.wantCriteria, mockLookup.request); diff != "" {
+				t.Errorf("Lookup() criteria mismatch (-want +got):\n%s", diff)
+			}
+			if mockQueuer.callCount != len(subs) {
+				t.Errorf("Process() queued %d tasks, want %d", mockQueuer.callCount, len(subs))
+			}
+		})
+	}
+}
+
+func TestValidateLookupCriteria(t *testing.T) {
+	location := &model.Location{City: &model.City{Code: "std:080"}}
+	tests := []struct {
+		name    string
+		reqCtx  model.Context
+		wantErr error
+	}{
+		{name: "specific bpp_id", reqCtx: model.Context{Action: "search", Domain: "retail", BppID: "bpp1"}},
+		{name: "location only", reqCtx: model.Context{Action: "search", Domain: "retail", Location: location}},
+		{name: "bpp_id with location", reqCtx: model.Context{Action: "search", Domain: "retail", BppID: "bpp1", Location: location}},
+		{name: "empty location", reqCtx: model.Context{Action: "search", Domain: "retail", Location: &model.Location{}}, wantErr: ErrAmbiguousLookupCriteria},
+		{name: "empty location with bpp_uri is not looked up", reqCtx: model.Context{Action: "search", Domain: "retail", BppURI: "http://bpp1.com", Location: &model.Location{}}},
+		{name: "empty location of on_search is not looked up", reqCtx: model.Context{Action: "on_search", Domain: "retail", Location: &model.Location{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLookupCriteria(&tt.reqCtx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateLookupCriteria() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_SkipLookupForSingleBPP(t *testing.T) {
+	location := &model.Location{City: &model.City{Code: "std:080"}}
+	subs := []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}}}
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		reqCtx     model.Context
+		wantLookup bool
+		wantQueued []string
+	}{
+		{
+			name:       "fully specified context is verified and proxied directly",
+			enabled:    true,
+			reqCtx:     model.Context{Domain: "retail", BppID: "bpp1", BppURI: "http://bpp1.com"},
+			wantLookup: true,
+			wantQueued: []string{"http://bpp1.com"},
+		},
+		{
+			name:       "bpp_uri not subscribed by bpp_id is dropped",
+			enabled:    true,
+			reqCtx:     model.Context{Domain: "retail", BppID: "bpp1", BppURI: "http://attacker.com"},
+			wantLookup: true,
+		},
+		{
+			name:       "disabled looks up",
+			reqCtx:     model.Context{Domain: "retail", BppID: "bpp1", BppURI: "http://other.com"},
+			wantLookup: true,
+			wantQueued: []string{"http://bpp1.com"},
+		},
+		{
+			name:       "bpp_id only looks up",
+			enabled:    true,
+			reqCtx:     model.Context{Domain: "retail", BppID: "bpp1"},
+			wantLookup: true,
+			wantQueued: []string{"http://bpp1.com"},
+		},
+		{
+			name:       "bpp_uri only looks up",
+			enabled:    true,
+			reqCtx:     model.Context{Domain: "retail", BppURI: "http://other.com"},
+			wantLookup: true,
+			wantQueued: []string{"http://bpp1.com"},
+		},
+		{
+			name:       "bpp with location is verified and proxied directly",
+			enabled:    true,
+			reqCtx:     model.Context{Domain: "retail", BppID: "bpp1", BppURI: "http://bpp1.com", Location: location},
+			wantLookup: true,
+			wantQueued: []string{"http://bpp1.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLookup := &mockLookupClient{subscriptions: append([]model.Subscription(nil), subs...)}
+			var queued []string
+			mockQueuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+				if h.Get(model.AuthHeaderGateway) == "" {
+					t.Errorf("QueueTxn() headers missing %s", model.AuthHeaderGateway)
+				}
+				queued = append(queued, reqCtx.BppURI)
+				return &model.AsyncTask{}, nil
+			}}
+			processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{authHeader: "gateway-sig"}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetSkipLookupForSingleBPP(tt.enabled)
+			task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: tt.reqCtx}
+
+			if err := processor.Process(context.Background(), task); err != nil {
+				t.Fatalf("Process() error = %v, want nil", err)
+			}
+			if gotLookup := mockLookup.request != nil; gotLookup != tt.wantLookup {
+				t.Errorf("Process() made lookup = %v, want %v", gotLookup, tt.wantLookup)
+			}
+			if diff := cmp.Diff(tt.wantQueued, queued); diff != "" {
+				t.Errorf("Process() queued targets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_SingleBPPVerificationCached(t *testing.T) {
+	mockLookup := &mockLookupClient{subscriptions: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}}}}
+	mockQueuer := &mockTaskQueuer{}
+	processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{authHeader: "gateway-sig"}, mockQueuer, "test-id", 0, 1)
+	if err != nil {
+		t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+	}
+	processor.SetSkipLookupForSingleBPP(true)
+	now := time.Now()
+	processor.now = func() time.Time { return now }
+	reqCtx := model.Context{Domain: "retail", BppID: "bpp1", BppURI: "http://bpp1.com"}
+	process := func() {
+		t.Helper()
+		task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: reqCtx}
+		if err := processor.Process(context.Background(), task); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+
+	process()
+	process()
+	if mockLookup.calls != 1 {
+		t.Errorf("lookups within ttl = %d, want 1", mockLookup.calls)
+	}
+	if mockLookup.request.Location != nil || mockLookup.request.SubscriberID != "bpp1" {
+		t.Errorf("verification lookup criteria = %+v, want bpp_id only", mockLookup.request.Subscriber)
+	}
+	now = now.Add(singleBPPVerifiedTTL)
+	process()
+	if mockLookup.calls != 2 {
+		t.Errorf("lookups after ttl = %d, want 2", mockLookup.calls)
+	}
+	if mockQueuer.callCount != 3 {
+		t.Errorf("queued tasks = %d, want 3", mockQueuer.callCount)
+	}
+}
+
+func TestChannelLookupProcessor_Process_NegativeLookupCache(t *testing.T) {
+	retail := model.Context{Domain: "retail"}
+	mobility := model.Context{Domain: "mobility"}
+	sub := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}}
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		subs      []model.Subscription
+		lookupErr error
+		second    model.Context
+		elapsed   time.Duration // Time between the two lookups.
+		wantCalls int
+	}{
+		{name: "empty result cached within ttl", ttl: time.Minute, second: retail, elapsed: 30 * time.Second, wantCalls: 1},
+		{name: "empty result expires after ttl", ttl: time.Minute, second: retail, elapsed: time.Minute, wantCalls: 2},
+		{name: "cache disabled", second: retail, wantCalls: 2},
+		{name: "other criteria not cached", ttl: time.Minute, second: mobility, wantCalls: 2},
+		{name: "non-empty result not cached", ttl: time.Minute, subs: []model.Subscription{sub}, second: retail, wantCalls: 2},
+		{name: "lookup error not cached", ttl: time.Minute, lookupErr: errors.New("registry down"), second: retail, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLookup := &mockLookupClient{subscriptions: tt.subs, err: tt.lookupErr}
+			processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{authHeader: "gateway-sig"}, &mockTaskQueuer{}, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetNegativeLookupCacheTTL(tt.ttl)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			processor.now = func() time.Time { return now }
+
+			first := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: retail}
+			processor.Process(context.Background(), first)
+			now = now.Add(tt.elapsed)
+			second := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: tt.second}
+			if err := processor.Process(context.Background(), second); err != nil && tt.lookupErr == nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if mockLookup.calls != tt.wantCalls {
+				t.Errorf("registry lookups = %d, want %d", mockLookup.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_StaleLookup(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp2", URL: "http://bpp2.com"}},
+	}
+	retail := model.Context{Domain: "retail"}
+	mobility := model.Context{Domain: "mobility"}
+
+	tests := []struct {
+		name      string
+		maxAge    time.Duration
+		second    model.Context
+		elapsed   time.Duration // Time between the successful and the failed lookup.
+		wantErr   bool
+		wantTasks int // Proxy tasks queued by the failed lookup.
+	}{
+		{name: "stale result served within max age", maxAge: time.Minute, second: retail, elapsed: time.Minute, wantTasks: 2},
+		{name: "stale result not served beyond max age", maxAge: time.Minute, second: retail, elapsed: time.Minute + time.Second, wantErr: true},
+		{name: "disabled", second: retail, elapsed: time.Second, wantErr: true},
+		{name: "other criteria not served", maxAge: time.Minute, second: mobility, elapsed: time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLookup := &mockLookupClient{subscriptions: subs}
+			tq := &mockTaskQueuer{}
+			processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{authHeader: "gateway-sig"}, tq, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetStaleLookupMaxAge(tt.maxAge)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			processor.now = func() time.Time { return now }
+
+			first := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: retail}
+			if err := processor.Process(context.Background(), first); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			mockLookup.subscriptions, mockLookup.err = nil, errors.New("registry down")
+			tq.callCount = 0
+			now = now.Add(tt.elapsed)
+
+			second := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: http.Header{}, Context: tt.second}
+			err = processor.Process(context.Background(), second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tq.callCount != tt.wantTasks {
+				t.Errorf("proxy tasks queued = %d, want %d", tq.callCount, tt.wantTasks)
+			}
+		})
+	}
+}
+
+func TestChannelLookupProcessor_Process_ForwardedHeaderLimits(t *testing.T) {
+	subs := []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "http://bpp1.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp2", URL: "http://bpp2.com"}},
+	}
+	headers := http.Header{
+		"X-Beckn-Authorization": {"custom-sig"},
+		"X-Request-Id":          {"req-1"},
+		"X-Large":               {strings.Repeat("x", 1024)},
+	}
+
+	tests := []struct {
+		name        string
+		limits      HeaderLimits
+		wantErr     error
+		wantHeaders []string // Canonical names of the forwarded headers, besides the gateway signature.
+	}{
+		{
+			name:        "no limits",
+			wantHeaders: []string{"X-Beckn-Authorization", "X-Large", "X-Request-Id"},
+		},
+		{
+			name:    "oversized headers rejected",
+			limits:  HeaderLimits{MaxBytes: 512},
+			wantErr: ErrHeadersTooLarge,
+		},
+		{
+			name:        "oversized headers truncated",
+			limits:      HeaderLimits{MaxBytes: 512, Policy: HeaderLimitPolicyTruncate},
+			wantHeaders: []string{"X-Beckn-Authorization", "X-Request-Id"},
+		},
+		{
+			name:        "too many headers truncated",
+			limits:      HeaderLimits{MaxCount: 2, Policy: HeaderLimitPolicyTruncate},
+			wantHeaders: []string{"X-Beckn-Authorization", "X-Request-Id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queued []http.Header
+			mockQueuer := &mockTaskQueuer{QueueTxnFunc: func(ctx context.Context, reqCtx *model.Context, msg []byte, h http.Header) (*model.AsyncTask, error) {
+				queued = append(queued, h)
+				return &model.AsyncTask{}, nil
+			}}
+			mockLookup := &mockLookupClient{subscriptions: append([]model.Subscription(nil), subs...)}
+			processor, err := NewChannelLookupProcessor(mockLookup, &mockAuthGen{authHeader: "gateway-sig"}, mockQueuer, "test-id", 0, 1)
+			if err != nil {
+				t.Fatalf("NewChannelLookupProcessor() error = %v", err)
+			}
+			processor.SetForwardedHeaderLimits(tt.limits, "X-Beckn-Authorization")
+			task := &model.AsyncTask{Type: model.AsyncTaskTypeLookup, Body: []byte(`{}`), Headers: headers.Clone(), Context: model.Context{Domain: "retail"}}
+
+			err = processor.Process(context.Background(), task)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(queued) != 0 {
+					t.Errorf("Process() queued %d proxy tasks, want none", len(queued))
+				}
+				return
+			}
+			if len(queued) != len(subs) {
+				t.Fatalf("Process() queued %d proxy tasks, want %d", len(queued), len(subs))
+			}
+			for _, h := range queued {
+				if got := h.Get(model.AuthHeaderGateway); got != "gateway-sig" {
+					t.Errorf("%s = %q, want %q", model.AuthHeaderGateway, got, "gateway-sig")
+				}
+				var got []string
+				for name := range h {
+					if name != model.AuthHeaderGateway {
+						got = append(got, name)
+					}
+				}
+				if diff := cmp.Diff(tt.wantHeaders, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("forwarded headers mismatch (-want +got):\n%s", diff)
+				}
+			}
 		})
 	}
 }
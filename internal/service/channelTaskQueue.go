@@ -16,25 +16,141 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
+// ErrQueueFull is returned by QueueTxn for a task dropped because the queue is full.
+var ErrQueueFull = errors.New("task queue is full")
+
 // taskProcessor is an interface that task processors (like proxyProcessor or lookupProcessor) should implement.
 // This is typically defined where your processors are, or can be defined here if it's a shared concept.
 type taskProcessor interface {
 	Process(ctx context.Context, task *model.AsyncTask) error
 }
 
+// dedupCache records keys that expire after a TTL.
+type dedupCache interface {
+	// SetNX stores key only if it is not already set, reporting whether it was stored.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+}
+
 // channelQueueItem wraps an AsyncTask with its original request context.
 type channelQueueItem struct {
 	originalCtx context.Context
 	task        *model.AsyncTask
+	bytes       int64 // Body bytes reserved in the queue's byte budget, if any.
+	attempts    int   // Failed attempts to process the task.
+}
+
+// byteBudget bounds the total size of the bodies of queued tasks.
+type byteBudget struct {
+	mu    sync.Mutex
+	max   int64
+	used  int64
+	freed chan struct{} // Closed and replaced whenever bytes are released.
+}
+
+func newByteBudget(maxBytes int64) *byteBudget {
+	return &byteBudget{max: maxBytes, freed: make(chan struct{})}
+}
+
+// acquire reserves n bytes, blocking until they are available, the request ctx is done or
+// the queue stops with workerCtx. A task larger than the whole budget is admitted once nothing
+// else is reserved, so that it cannot block forever.
+func (b *byteBudget) acquire(ctx, workerCtx context.Context, n int64) error {
+	for {
+		b.mu.Lock()
+		if b.used == 0 || b.used+n <= b.max {
+			b.used += n
+			b.mu.Unlock()
+			return nil
+		}
+		freed := b.freed
+		b.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for queue memory: %w", ctx.Err())
+		case <-workerCtx.Done():
+			return fmt.Errorf("worker is shutting down, cannot queue task")
+		}
+	}
+}
+
+// release returns n bytes to the budget and wakes up blocked acquirers.
+func (b *byteBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
+
+// reserved returns the number of bytes currently reserved.
+func (b *byteBudget) reserved() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// pauseGate holds workers back from dequeuing while the queue is paused.
+type pauseGate struct {
+	mu      sync.Mutex
+	paused  chan struct{} // Closed while the queue is paused.
+	resumed chan struct{} // Closed while the queue is running.
+}
+
+func newPauseGate() *pauseGate {
+	resumed := make(chan struct{})
+	close(resumed)
+	return &pauseGate{paused: make(chan struct{}), resumed: resumed}
+}
+
+// pause pauses the gate, reporting whether it was running.
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.paused:
+		return false
+	default:
+	}
+	close(g.paused)
+	g.resumed = make(chan struct{})
+	return true
+}
+
+// resume resumes the gate, reporting whether it was paused.
+func (g *pauseGate) resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.resumed:
+		return false
+	default:
+	}
+	close(g.resumed)
+	g.paused = make(chan struct{})
+	return true
+}
+
+// channels returns the current paused and resumed channels.
+func (g *pauseGate) channels() (paused, resumed <-chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused, g.resumed
 }
 
 // ChannelTaskQueue implements an in-memory task queue using Go channels and a worker goroutine.
@@ -44,6 +160,28 @@ type ChannelTaskQueue struct {
 	lookupProcessor taskProcessor
 	numWorkers      int
 
+	// approvalProcessor processes APPROVAL tasks, in the queues of approval workers.
+	approvalProcessor taskProcessor
+
+	dedup             dedupCache
+	dedupTTL          time.Duration
+	duplicatesDropped atomic.Uint64
+
+	dropOnFull   bool
+	tasksDropped atomic.Uint64
+
+	defaultOnSearchLocation *model.Location
+
+	deadLetters DeadLetterSink
+	targetURLs  TargetURLConfig
+
+	maxTaskRetries int
+	taskRetryDelay time.Duration
+
+	bodyBudget *byteBudget
+
+	gate *pauseGate
+
 	workerCtx    context.Context
 	workerCancel context.CancelFunc
 	wg           sync.WaitGroup
@@ -53,12 +191,14 @@ type ChannelTaskQueue struct {
 // parentCtx is the context for the worker's lifecycle.
 // proxyP and lookupP are the processors for different task types.
 // bufferSize determines the capacity of the task channel.
+// dropOnFull makes QueueTxn drop tasks with ErrQueueFull instead of blocking while the channel is full.
 func NewChannelTaskQueue(
 	parentCtx context.Context,
 	numWorkers int,
 	proxyP taskProcessor,
 	lookupP taskProcessor,
 	bufferSize int,
+	dropOnFull bool,
 ) (*ChannelTaskQueue, error) {
 	if proxyP == nil {
 		slog.Error("NewChannelTaskQueue: proxyProcessor cannot be nil")
@@ -80,11 +220,35 @@ func NewChannelTaskQueue(
 		proxyProcessor:  proxyP,
 		lookupProcessor: lookupP,
 		numWorkers:      numWorkers,
+		dropOnFull:      dropOnFull,
+		gate:            newPauseGate(),
 		workerCtx:       workerCtx,
 		workerCancel:    workerCancel,
 	}, nil
 }
 
+// newApprovalTaskQueue creates a ChannelTaskQueue of bufferSize APPROVAL tasks processed by approvalP
+// in numWorkers workers. It drops tasks with ErrQueueFull while the channel is full.
+// Values that are not positive use the defaults of NewChannelTaskQueue.
+func newApprovalTaskQueue(parentCtx context.Context, numWorkers int, approvalP taskProcessor, bufferSize int) *ChannelTaskQueue {
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	workerCtx, workerCancel := context.WithCancel(parentCtx)
+	return &ChannelTaskQueue{
+		taskChannel:       make(chan channelQueueItem, bufferSize),
+		approvalProcessor: approvalP,
+		numWorkers:        numWorkers,
+		dropOnFull:        true,
+		gate:              newPauseGate(),
+		workerCtx:         workerCtx,
+		workerCancel:      workerCancel,
+	}
+}
+
 // SetLookupProcessor sets the lookup processor for the ChannelTaskQueue.
 // This is used to break the initialization cycle.
 func (ctq *ChannelTaskQueue) SetLookupProcessor(lookupP taskProcessor) {
@@ -94,15 +258,196 @@ func (ctq *ChannelTaskQueue) SetLookupProcessor(lookupP taskProcessor) {
 	ctq.lookupProcessor = lookupP
 }
 
-// QueueTxn creates an AsyncTask based on the request context and body,
-// then sends it to an internal channel for asynchronous processing by a worker goroutine.
-// This method implements the taskQueuer interface.
-func (ctq *ChannelTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header) (*model.AsyncTask, error) {
-	if reqCtx == nil {
-		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: request context (model.Context) cannot be nil")
-		return nil, fmt.Errorf("request context (model.Context) is nil")
+// SetDeduplication drops tasks already queued within ttl, so that retried or redelivered
+// requests are not fanned out twice. Tasks are identified by transaction id, message id,
+// action and target; the target keeps the per-participant tasks of a fan-out distinct.
+func (ctq *ChannelTaskQueue) SetDeduplication(cache dedupCache, ttl time.Duration) {
+	ctq.dedup = cache
+	ctq.dedupTTL = ttl
+}
+
+// SetDefaultOnSearchLocation sets the location recorded in the context of on_search tasks
+// whose request context has none, so that their processing does not depend on the BPP sending one:
+// the proxy processor checks the subscription of the BAP serving that location. The body proxied to the BAP is left as received, since changing it would invalidate its signature.
+func (ctq *ChannelTaskQueue) SetDefaultOnSearchLocation(loc *model.Location) {
+	ctq.defaultOnSearchLocation = loc
+}
+
+// SetDeadLetterSink records the tasks that fail to process in sink, once their retries are exhausted.
+// A nil sink disables recording. It must be called before the workers start.
+func (ctq *ChannelTaskQueue) SetDeadLetterSink(sink DeadLetterSink) {
+	ctq.deadLetters = sink
+}
+
+// SetTargetURLConfig sets how the targets of proxy tasks are built from participant base URIs.
+// It must be called before tasks are queued.
+func (ctq *ChannelTaskQueue) SetTargetURLConfig(cfg TargetURLConfig) {
+	ctq.targetURLs = cfg
+}
+
+// SetTaskRetries queues a task that fails to process again, after delay, up to maxRetries times.
+// Tasks failing with a context error, such as the workers stopping, are not retried. Retried tasks
+// are not counted against SetMaxQueuedBytes. It must be called before the workers start.
+func (ctq *ChannelTaskQueue) SetTaskRetries(maxRetries int, delay time.Duration) {
+	ctq.maxTaskRetries = max(maxRetries, 0)
+	ctq.taskRetryDelay = max(delay, 0)
+}
+
+// SetMaxQueuedBytes bounds the total size of the bodies of queued tasks, in addition to the
+// bufferSize bound on their count. QueueTxn blocks while queuing a task would exceed maxBytes.
+// A non-positive maxBytes disables the bound. It must be called before tasks are queued.
+func (ctq *ChannelTaskQueue) SetMaxQueuedBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		ctq.bodyBudget = nil
+		return
+	}
+	ctq.bodyBudget = newByteBudget(maxBytes)
+}
+
+// dequeued releases the resources an item held while it was in the channel.
+func (ctq *ChannelTaskQueue) dequeued(item channelQueueItem) {
+	if ctq.bodyBudget != nil && item.bytes > 0 {
+		ctq.bodyBudget.release(item.bytes)
+	}
+}
+
+// Depth returns the number of tasks waiting in the queue.
+func (ctq *ChannelTaskQueue) Depth() int {
+	return len(ctq.taskChannel)
+}
+
+// QueuedBytes returns the total size of the bodies of queued tasks,
+// or 0 if the size is not bounded by SetMaxQueuedBytes.
+func (ctq *ChannelTaskQueue) QueuedBytes() int64 {
+	if ctq.bodyBudget == nil {
+		return 0
 	}
+	return ctq.bodyBudget.reserved()
+}
+
+// Pause stops workers from dequeuing tasks until Resume is called. Tasks being processed
+// are completed and QueueTxn keeps accepting tasks, blocking once the queue is full.
+func (ctq *ChannelTaskQueue) Pause() {
+	if ctq.gate.pause() {
+		slog.Warn("ChannelTaskQueue: Paused, tasks are queued but not processed", "depth", ctq.Depth())
+	}
+}
 
+// Resume lets workers dequeue tasks again after Pause.
+func (ctq *ChannelTaskQueue) Resume() {
+	if ctq.gate.resume() {
+		slog.Info("ChannelTaskQueue: Resumed", "depth", ctq.Depth())
+	}
+}
+
+// Paused reports whether the queue is paused.
+func (ctq *ChannelTaskQueue) Paused() bool {
+	paused, _ := ctq.gate.channels()
+	select {
+	case <-paused:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitResumed blocks while the queue is paused. It returns false if the workers are stopped first.
+func (ctq *ChannelTaskQueue) waitResumed() bool {
+	_, resumed := ctq.gate.channels()
+	select {
+	case <-resumed:
+		return true
+	case <-ctq.workerCtx.Done():
+		return false
+	}
+}
+
+// DuplicatesDropped returns the number of tasks dropped as duplicates.
+func (ctq *ChannelTaskQueue) DuplicatesDropped() uint64 {
+	return ctq.duplicatesDropped.Load()
+}
+
+// TasksDropped returns the number of tasks dropped because the queue was full.
+func (ctq *ChannelTaskQueue) TasksDropped() uint64 {
+	return ctq.tasksDropped.Load()
+}
+
+// dedupKey returns the key identifying a task for deduplication.
+func dedupKey(task *model.AsyncTask) string {
+	target := ""
+	if task.Target != nil {
+		target = task.Target.String()
+	}
+	return fmt.Sprintf("task_dedup:%s:%s:%s:%s", task.Context.TransactionID, task.Context.MessageID, task.Context.Action, target)
+}
+
+// isDuplicate reports whether the task was already queued within the deduplication window.
+// Cache failures are logged and the task is treated as new, so that deduplication never drops traffic on its own.
+func (ctq *ChannelTaskQueue) isDuplicate(ctx context.Context, task *model.AsyncTask) bool {
+	if ctq.dedup == nil || ctq.dedupTTL <= 0 {
+		return false
+	}
+	stored, err := ctq.dedup.SetNX(ctx, dedupKey(task), "1", ctq.dedupTTL)
+	if err != nil {
+		slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Deduplication check failed, queuing task", "error", err)
+		return false
+	}
+	return !stored
+}
+
+// releaseDedup forgets a task that isDuplicate recorded but that failed to be queued, whether it was
+// dropped, cancelled or timed out, so that its retry is queued rather than dropped as a duplicate.
+func (ctq *ChannelTaskQueue) releaseDedup(ctx context.Context, task *model.AsyncTask) {
+	if ctq.dedup == nil || ctq.dedupTTL <= 0 {
+		return
+	}
+	if err := ctq.dedup.Delete(context.WithoutCancel(ctx), dedupKey(task)); err != nil {
+		slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to release deduplication key of unqueued task", "error", err)
+	}
+}
+
+// TargetURLConfig configures how the target URL of a proxy task is built from a participant's base URI.
+type TargetURLConfig struct {
+	// DropQuery drops the query parameters of the base URI, which are kept on the target by default.
+	DropQuery bool `yaml:"dropQuery"`
+	// TrailingSlash ends the target path with a slash after the action, for participants whose
+	// routes are registered with one.
+	TrailingSlash bool `yaml:"trailingSlash"`
+}
+
+// resolveTarget builds the target URL for an action from a participant's base URI.
+// The base path is preserved with duplicate and trailing slashes removed, the action is
+// appended as the last path segment, query parameters are kept as-is unless cfg drops them,
+// and fragments are dropped.
+func resolveTarget(baseURI, action string, cfg TargetURLConfig) (*url.URL, error) {
+	base, err := url.Parse(baseURI)
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("URI %q must be absolute", baseURI)
+	}
+	target := &url.URL{
+		Scheme: base.Scheme,
+		User:   base.User,
+		Host:   base.Host,
+		Path:   "/",
+	}
+	if !cfg.DropQuery {
+		target.RawQuery = base.RawQuery
+	}
+	// JoinPath cleans the joined path, collapsing duplicate slashes and dropping trailing ones.
+	target = target.JoinPath(base.EscapedPath(), action)
+	if cfg.TrailingSlash {
+		target = target.JoinPath("/")
+	}
+	return target, nil
+}
+
+// newAsyncTask creates the AsyncTask of a request from its context, body and headers,
+// building the target of proxy tasks with targetURLs. The task of an on_search without a
+// location gets defaultOnSearchLocation, if set, in its context.
+func newAsyncTask(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header, targetURLs TargetURLConfig, defaultOnSearchLocation *model.Location) (*model.AsyncTask, error) {
 	task := &model.AsyncTask{
 		Body:    body, // Store the raw body
 		Headers: h.Clone(),
@@ -116,30 +461,59 @@ func (ctq *ChannelTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context
 			// Target for lookup is not set here; it's determined by the LookupTaskProcessor
 		} else {
 			task.Type = model.AsyncTaskTypeProxy
-			targetURL, err := url.Parse(reqCtx.BppURI)
+			targetURL, err := resolveTarget(reqCtx.BppURI, "search", targetURLs)
 			if err != nil {
-				slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to parse BppURI for search", "error", err, "bpp_uri", reqCtx.BppURI)
+				slog.ErrorContext(ctx, "newAsyncTask: Failed to parse BppURI for search", "error", err, "bpp_uri", reqCtx.BppURI)
 				return nil, fmt.Errorf("failed to parse BppURI for search: %w", err)
 			}
-			task.Target = targetURL.JoinPath("search")
+			task.Target = targetURL
 		}
 	case "on_search":
 		if reqCtx.BapURI == "" {
-			slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: BapURI missing for on_search")
+			slog.ErrorContext(ctx, "newAsyncTask: BapURI missing for on_search")
 			return nil, fmt.Errorf("BapURI is required for /on_search")
 		}
 		task.Type = model.AsyncTaskTypeProxy
-		targetURL, err := url.Parse(reqCtx.BapURI)
+		targetURL, err := resolveTarget(reqCtx.BapURI, "on_search", targetURLs)
 		if err != nil {
-			slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Failed to parse BapURI for on_search", "error", err, "bap_uri", reqCtx.BapURI)
+			slog.ErrorContext(ctx, "newAsyncTask: Failed to parse BapURI for on_search", "error", err, "bap_uri", reqCtx.BapURI)
 			return nil, fmt.Errorf("failed to parse BapURI for on_search: %w", err)
 		}
-		task.Target = targetURL.JoinPath("on_search")
+		task.Target = targetURL
+		if task.Context.Location == nil && defaultOnSearchLocation != nil {
+			loc := *defaultOnSearchLocation
+			task.Context.Location = &loc
+			slog.DebugContext(ctx, "newAsyncTask: Using default location for on_search", "bap_uri", reqCtx.BapURI)
+		}
 	default:
-		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Unknown action type", "action", reqCtx.Action)
+		slog.ErrorContext(ctx, "newAsyncTask: Unknown action type", "action", reqCtx.Action)
 		return nil, fmt.Errorf("unknown action type: %s", reqCtx.Action)
 	}
+	return task, nil
+}
 
+// QueueTxn creates an AsyncTask based on the request context and body,
+// then sends it to an internal channel for asynchronous processing by a worker goroutine.
+// If deduplication is enabled, a task already queued within the window is returned without being queued again.
+// It blocks while the channel is full, unless the queue drops tasks on full with ErrQueueFull, and,
+// if SetMaxQueuedBytes is set, while the queued bodies are at the byte limit.
+// This method implements the taskQueuer interface.
+func (ctq *ChannelTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header) (*model.AsyncTask, error) {
+	if reqCtx == nil {
+		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: request context (model.Context) cannot be nil")
+		return nil, fmt.Errorf("request context (model.Context) is nil")
+	}
+
+	task, err := newAsyncTask(ctx, reqCtx, body, h, ctq.targetURLs, ctq.defaultOnSearchLocation)
+	if err != nil {
+		return nil, err
+	}
+	return ctq.queueTask(ctx, task)
+}
+
+// queueTask sends task to the channel like QueueTxn, for tasks built by their caller such as APPROVAL tasks.
+func (ctq *ChannelTaskQueue) queueTask(ctx context.Context, task *model.AsyncTask) (*model.AsyncTask, error) {
+	reqCtx := &task.Context
 	item := channelQueueItem{
 		originalCtx: ctx, // Propagate the original request's context
 		task:        task,
@@ -153,24 +527,56 @@ func (ctq *ChannelTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context
 		return nil, fmt.Errorf("worker is shutting down, cannot queue task")
 	}
 
+	// A duplicate is acknowledged like a queued task, since the original is already being processed.
+	if ctq.isDuplicate(ctx, task) {
+		ctq.duplicatesDropped.Add(1)
+		slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Dropping duplicate task", "action", reqCtx.Action, "transaction_id", reqCtx.TransactionID, "message_id", reqCtx.MessageID, "target", task.Target, "duplicates_dropped", ctq.DuplicatesDropped())
+		return task, nil
+	}
+	// The task is forgotten unless it is queued, so that its retry is not dropped as a duplicate.
+	queued := false
+	defer func() {
+		if !queued {
+			ctq.releaseDedup(ctx, task)
+		}
+	}()
+
+	if ctq.bodyBudget != nil {
+		item.bytes = int64(len(task.Body))
+		if err := ctq.bodyBudget.acquire(ctx, ctq.workerCtx, item.bytes); err != nil {
+			slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Gave up waiting for queue memory", "action", reqCtx.Action, "error", err)
+			return nil, err
+		}
+	}
+
 	select {
 	case ctq.taskChannel <- item:
+		queued = true
 		slog.InfoContext(ctx, "ChannelTaskQueue.QueueTxn: Task successfully sent to channel", "action", reqCtx.Action, "type", task.Type)
 		return task, nil
 	case <-ctq.workerCtx.Done():
+		ctq.dequeued(item)
 		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Worker is shutting down, cannot queue task", "action", reqCtx.Action)
 		return nil, fmt.Errorf("worker is shutting down, cannot queue task")
 	default:
-		// This case is for a full buffered channel if we want non-blocking behavior.
-		// For now, if the channel is full, it will block until space is available or workerCtx is done.
-		// If non-blocking is desired with task dropping:
-		// slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Task channel is full, dropping task", "action", reqCtx.Action)
-		// return nil, fmt.Errorf("task channel is full, task dropped")
-
-		// Blocking send (current behavior with buffered channel):
-		ctq.taskChannel <- item
+	}
+
+	// The channel is full.
+	if ctq.dropOnFull {
+		ctq.dequeued(item)
+		ctq.tasksDropped.Add(1)
+		slog.WarnContext(ctx, "ChannelTaskQueue.QueueTxn: Task channel is full, dropping task", "action", reqCtx.Action, "type", task.Type, "target", task.Target, "tasks_dropped", ctq.TasksDropped())
+		return nil, fmt.Errorf("%w: %d tasks queued", ErrQueueFull, cap(ctq.taskChannel))
+	}
+	select {
+	case ctq.taskChannel <- item:
+		queued = true
 		slog.InfoContext(ctx, "ChannelTaskQueue.QueueTxn: Task successfully sent to channel (after block)", "action", reqCtx.Action, "type", task.Type)
 		return task, nil
+	case <-ctq.workerCtx.Done():
+		ctq.dequeued(item)
+		slog.ErrorContext(ctx, "ChannelTaskQueue.QueueTxn: Worker is shutting down while waiting for a full queue", "action", reqCtx.Action)
+		return nil, fmt.Errorf("worker is shutting down, cannot queue task")
 	}
 }
 
@@ -183,12 +589,25 @@ func (ctq *ChannelTaskQueue) StartWorkers() {
 			defer ctq.wg.Done()
 			slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Starting...", "worker_id", workerID)
 			for {
+				if !ctq.waitResumed() {
+					slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Context cancelled while paused, stopping.", "worker_id", workerID)
+					return
+				}
+				paused, _ := ctq.gate.channels()
 				select {
+				case <-paused:
+					continue
 				case item, ok := <-ctq.taskChannel:
 					if !ok {
 						slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue Worker: Task channel closed, stopping.", "worker_id", workerID)
 						return
 					}
+					ctq.dequeued(item)
+					// A task received as the queue was paused is held until it is resumed.
+					if !ctq.waitResumed() {
+						slog.WarnContext(item.originalCtx, "ChannelTaskQueue Worker: Context cancelled while paused, dropping task.", "worker_id", workerID, "type", item.task.Type)
+						return
+					}
 					// Log receipt of the task with its original context for correlation
 					slog.InfoContext(item.originalCtx, "ChannelTaskQueue Worker: Received task", "worker_id", workerID, "type", item.task.Type, "target", item.task.Target)
 
@@ -202,20 +621,30 @@ func (ctq *ChannelTaskQueue) StartWorkers() {
 					case model.AsyncTaskTypeProxy:
 						if ctq.proxyProcessor == nil {
 							slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: proxyProcessor is nil, cannot process PROXY task", "worker_id", workerID)
+							recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, errors.New("no processor for PROXY tasks"))
 							continue
 						}
 						err = ctq.proxyProcessor.Process(processingCtx, item.task)
 					case model.AsyncTaskTypeLookup:
 						if ctq.lookupProcessor == nil {
 							slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: lookupProcessor is nil, cannot process LOOKUP task", "worker_id", workerID)
+							recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, errors.New("no processor for LOOKUP tasks"))
 							continue
 						}
 						err = ctq.lookupProcessor.Process(processingCtx, item.task)
+					case model.AsyncTaskTypeApproval:
+						if ctq.approvalProcessor == nil {
+							slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: approvalProcessor is nil, cannot process APPROVAL task", "worker_id", workerID)
+							recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, errors.New("no processor for APPROVAL tasks"))
+							continue
+						}
+						err = ctq.approvalProcessor.Process(processingCtx, item.task)
 					default:
 						slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Unknown task type received", "worker_id", workerID, "type", item.task.Type)
+						err = fmt.Errorf("unknown task type %q", item.task.Type)
 					}
 					if err != nil {
-						slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Error processing task", "worker_id", workerID, "type", item.task.Type, "error", err)
+						ctq.retryOrDeadLetter(item, workerID, err)
 					} else {
 						slog.InfoContext(item.originalCtx, "ChannelTaskQueue Worker: Task processed successfully", "worker_id", workerID, "type", item.task.Type)
 					}
@@ -228,7 +657,54 @@ func (ctq *ChannelTaskQueue) StartWorkers() {
 	}
 }
 
-// StopWorkers signals the worker goroutines to stop and waits for them to finish.
+// retryOrDeadLetter queues item, which failed to process with err, again after the retry delay
+// if it has retries left and err is neither a context error nor ErrPartialFanOut, and records it
+// in the dead-letter sink otherwise. A task deferred with ErrCallbackLimitReached is queued again
+// after CallbackLimitRequeueDelay without counting as an attempt.
+// The wait is tracked by the workers' wait group, so that StopWorkers cuts it short.
+func (ctq *ChannelTaskQueue) retryOrDeadLetter(item channelQueueItem, workerID int, err error) {
+	if errors.Is(err, ErrCallbackLimitReached) {
+		item.bytes = 0
+		ctq.requeueAfter(item, CallbackLimitRequeueDelay, err)
+		return
+	}
+	if item.attempts >= ctq.maxTaskRetries || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPartialFanOut) {
+		slog.ErrorContext(item.originalCtx, "ChannelTaskQueue Worker: Error processing task", "worker_id", workerID, "type", item.task.Type, "attempts", item.attempts+1, "error", err)
+		recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, err)
+		return
+	}
+	item.attempts++
+	item.bytes = 0
+	slog.WarnContext(item.originalCtx, "ChannelTaskQueue Worker: Error processing task, retrying", "worker_id", workerID, "type", item.task.Type, "attempts", item.attempts, "retry_delay", ctq.taskRetryDelay, "error", err)
+	ctq.requeueAfter(item, ctq.taskRetryDelay, err)
+}
+
+// requeueAfter queues item, which failed to process with err, again after delay, without holding a worker.
+// If the workers stop first, it is recorded in the dead-letter sink.
+func (ctq *ChannelTaskQueue) requeueAfter(item channelQueueItem, delay time.Duration, err error) {
+	ctq.wg.Add(1)
+	go func() {
+		defer ctq.wg.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctq.workerCtx.Done():
+			slog.WarnContext(item.originalCtx, "ChannelTaskQueue: Workers stopped before the task was retried", "type", item.task.Type, "attempts", item.attempts)
+			recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, err)
+			return
+		}
+		select {
+		case ctq.taskChannel <- item:
+		case <-ctq.workerCtx.Done():
+			slog.WarnContext(item.originalCtx, "ChannelTaskQueue: Workers stopped before the task was retried", "type", item.task.Type, "attempts", item.attempts)
+			recordDeadLetter(item.originalCtx, ctq.deadLetters, item.task, err)
+		}
+	}()
+}
+
+// StopWorkers signals the worker goroutines to stop and waits for them to finish,
+// including recording the tasks they failed to process in the dead-letter sink.
 func (ctq *ChannelTaskQueue) StopWorkers() {
 	slog.InfoContext(ctq.workerCtx, "ChannelTaskQueue: StopWorkers called, signaling workers to stop.")
 	ctq.workerCancel() // Signal the worker to stop by cancelling its context
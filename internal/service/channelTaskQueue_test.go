@@ -15,8 +15,10 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -126,7 +128,7 @@ func TestNewChannelTaskQueue(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Updated call: ctx as first parameter
-			q, err := NewChannelTaskQueue(tt.parentCtx, tt.numWorkers, tt.proxyP, tt.lookupP, tt.bufferSize)
+			q, err := NewChannelTaskQueue(tt.parentCtx, tt.numWorkers, tt.proxyP, tt.lookupP, tt.bufferSize, false)
 
 			if tt.wantErrMsg != "" {
 				if err == nil || err.Error() != tt.wantErrMsg {
@@ -153,7 +155,7 @@ func TestNewChannelTaskQueue(t *testing.T) {
 
 func TestChannelTaskQueue_SetLookupProcessor(t *testing.T) {
 	// Updated call: ctx as first parameter
-	q, err := NewChannelTaskQueue(context.Background(), 1, &mockTaskProcessor{}, nil, 10)
+	q, err := NewChannelTaskQueue(context.Background(), 1, &mockTaskProcessor{}, nil, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue: %v", err)
 	}
@@ -179,7 +181,7 @@ func TestChannelTaskQueue_SetLookupProcessor(t *testing.T) {
 func TestChannelTaskQueue_QueueTxn(t *testing.T) {
 	ctx := context.Background()
 	// Updated call: ctx as first parameter
-	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10)
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue: %v", err)
 	}
@@ -276,6 +278,22 @@ func TestChannelTaskQueue_QueueTxn(t *testing.T) {
 			},
 			wantErrMsg: "failed to parse BapURI for on_search",
 		},
+		{
+			name: "error - relative BapURI",
+			reqCtx: &model.Context{
+				Action: "on_search",
+				BapURI: "/bap/beckn",
+			},
+			wantErrMsg: "must be absolute",
+		},
+		{
+			name: "error - BapURI without scheme",
+			reqCtx: &model.Context{
+				Action: "on_search",
+				BapURI: "bap.com/beckn",
+			},
+			wantErrMsg: "must be absolute",
+		},
 	}
 
 	for _, tt := range tests {
@@ -310,6 +328,541 @@ func TestChannelTaskQueue_QueueTxn(t *testing.T) {
 	}
 }
 
+func TestChannelTaskQueue_QueueTxn_DefaultOnSearchLocation(t *testing.T) {
+	ctx := context.Background()
+	defaultLoc := &model.Location{City: &model.City{Code: "std:080"}, Country: &model.Country{Code: "IND"}}
+	bapLoc := &model.Location{City: &model.City{Code: "std:011"}, Country: &model.Country{Code: "IND"}}
+
+	tests := []struct {
+		name       string
+		defaultLoc *model.Location
+		reqCtx     *model.Context
+		want       *model.Location
+	}{
+		{
+			name:       "location missing uses default",
+			defaultLoc: defaultLoc,
+			reqCtx:     &model.Context{Action: "on_search", BapURI: "http://bap.com/beckn"},
+			want:       defaultLoc,
+		},
+		{
+			name:       "location present is kept",
+			defaultLoc: defaultLoc,
+			reqCtx:     &model.Context{Action: "on_search", BapURI: "http://bap.com/beckn", Location: bapLoc},
+			want:       bapLoc,
+		},
+		{
+			name:   "location missing without default",
+			reqCtx: &model.Context{Action: "on_search", BapURI: "http://bap.com/beckn"},
+		},
+		{
+			name:       "default not applied to search",
+			defaultLoc: defaultLoc,
+			reqCtx:     &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+			if err != nil {
+				t.Fatalf("Failed to create task queue: %v", err)
+			}
+			defer q.StopWorkers()
+			q.SetDefaultOnSearchLocation(tt.defaultLoc)
+
+			task, err := q.QueueTxn(ctx, tt.reqCtx, []byte(`{}`), http.Header{})
+			if err != nil {
+				t.Fatalf("QueueTxn() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, task.Context.Location); diff != "" {
+				t.Errorf("task location mismatch (-want +got):\n%s", diff)
+			}
+			if tt.reqCtx.Location == nil && task.Context.Location != nil && task.Context.Location == tt.defaultLoc {
+				t.Error("task location aliases the default location, want a copy")
+			}
+		})
+	}
+}
+
+// fakeDedupCache is an in-memory dedupCache with a controllable clock.
+type fakeDedupCache struct {
+	now     time.Time
+	expires map[string]time.Time
+	err     error
+}
+
+func newFakeDedupCache() *fakeDedupCache {
+	return &fakeDedupCache{now: time.Unix(1700000000, 0), expires: make(map[string]time.Time)}
+}
+
+func (c *fakeDedupCache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if exp, ok := c.expires[key]; ok && c.now.Before(exp) {
+		return false, nil
+	}
+	c.expires[key] = c.now.Add(ttl)
+	return true, nil
+}
+
+func (c *fakeDedupCache) Delete(ctx context.Context, key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.expires, key)
+	return nil
+}
+
+// queuedTasks drains and returns the tasks currently in the queue's channel.
+func queuedTasks(q *ChannelTaskQueue) []*model.AsyncTask {
+	var tasks []*model.AsyncTask
+	for {
+		select {
+		case item := <-q.taskChannel:
+			q.dequeued(item)
+			tasks = append(tasks, item.task)
+		default:
+			return tasks
+		}
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_Deduplication(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	cache := newFakeDedupCache()
+	q.SetDeduplication(cache, time.Minute)
+	reqCtx := &model.Context{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp.com/beckn"}
+
+	if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() first call error = %v", err)
+	}
+	if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() duplicate error = %v, want nil", err)
+	}
+	if got := len(queuedTasks(q)); got != 1 {
+		t.Errorf("queued %d tasks within the window, want 1", got)
+	}
+	if got := q.DuplicatesDropped(); got != 1 {
+		t.Errorf("DuplicatesDropped() = %d, want 1", got)
+	}
+
+	cache.now = cache.now.Add(time.Minute)
+	if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() after expiry error = %v", err)
+	}
+	if got := len(queuedTasks(q)); got != 1 {
+		t.Errorf("queued %d tasks after the window expired, want 1", got)
+	}
+	if got := q.DuplicatesDropped(); got != 1 {
+		t.Errorf("DuplicatesDropped() = %d, want 1", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_DeduplicationDistinctTasks(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetDeduplication(newFakeDedupCache(), time.Minute)
+
+	reqCtxs := []*model.Context{
+		{Action: "search", TransactionID: "txn-1", MessageID: "msg-1"},
+		// Fan-out of the same search to two participants.
+		{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp1.com"},
+		{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp2.com"},
+		{Action: "search", TransactionID: "txn-1", MessageID: "msg-2", BppURI: "http://bpp1.com"},
+		{Action: "on_search", TransactionID: "txn-1", MessageID: "msg-1", BapURI: "http://bap.com"},
+	}
+	for _, reqCtx := range reqCtxs {
+		if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+			t.Fatalf("QueueTxn(%+v) error = %v", reqCtx, err)
+		}
+	}
+
+	if got := len(queuedTasks(q)); got != len(reqCtxs) {
+		t.Errorf("queued %d tasks, want %d", got, len(reqCtxs))
+	}
+	if got := q.DuplicatesDropped(); got != 0 {
+		t.Errorf("DuplicatesDropped() = %d, want 0", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_DeduplicationCacheError(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	cache := newFakeDedupCache()
+	cache.err = errors.New("redis down")
+	q.SetDeduplication(cache, time.Minute)
+	reqCtx := &model.Context{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp.com/beckn"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+	if got := len(queuedTasks(q)); got != 2 {
+		t.Errorf("queued %d tasks with a failing cache, want 2", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_DropOnFull(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 1, true)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetMaxQueuedBytes(100)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+
+	if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{"a":1}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		task, err := q.QueueTxn(ctx, reqCtx, []byte(`{"b":2}`), http.Header{})
+		if !errors.Is(err, ErrQueueFull) || task != nil {
+			t.Fatalf("QueueTxn() on a full queue = %v, %v, want nil, %v", task, err, ErrQueueFull)
+		}
+		if got := q.TasksDropped(); got != uint64(i) {
+			t.Errorf("TasksDropped() = %d, want %d", got, i)
+		}
+	}
+	// The dropped tasks do not hold queue memory.
+	if got := q.QueuedBytes(); got != int64(len(`{"a":1}`)) {
+		t.Errorf("QueuedBytes() = %d, want %d", got, len(`{"a":1}`))
+	}
+	if got := len(queuedTasks(q)); got != 1 {
+		t.Errorf("queued %d tasks, want 1", got)
+	}
+
+	if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+		t.Errorf("QueueTxn() after the queue drained error = %v", err)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_DropOnFullReleasesDedup(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 1, true)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetDeduplication(newFakeDedupCache(), time.Minute)
+	first := &model.Context{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp.com/beckn"}
+	retried := &model.Context{Action: "search", TransactionID: "txn-2", MessageID: "msg-2", BppURI: "http://bpp.com/beckn"}
+
+	if _, err := q.QueueTxn(ctx, first, []byte(`{}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	if _, err := q.QueueTxn(ctx, retried, []byte(`{}`), http.Header{}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("QueueTxn() on a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+	queuedTasks(q)
+
+	// The retry of the dropped task is queued, not acknowledged as a duplicate.
+	task, err := q.QueueTxn(ctx, retried, []byte(`{}`), http.Header{})
+	if err != nil || task == nil {
+		t.Fatalf("QueueTxn() of the retry = %v, %v, want a queued task", task, err)
+	}
+	if got := q.DuplicatesDropped(); got != 0 {
+		t.Errorf("DuplicatesDropped() = %d, want 0", got)
+	}
+	if got := len(queuedTasks(q)); got != 1 {
+		t.Errorf("queued %d tasks, want the retry", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_ShutdownReleasesDedup(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 1, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	cache := newFakeDedupCache()
+	q.SetDeduplication(cache, time.Minute)
+	first := &model.Context{Action: "search", TransactionID: "txn-1", MessageID: "msg-1", BppURI: "http://bpp.com/beckn"}
+	blocked := &model.Context{Action: "search", TransactionID: "txn-2", MessageID: "msg-2", BppURI: "http://bpp.com/beckn"}
+
+	if _, err := q.QueueTxn(ctx, first, []byte(`{}`), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := q.QueueTxn(ctx, blocked, []byte(`{}`), http.Header{})
+		errc <- err
+	}()
+	// Let the second task block on the full queue before the workers stop.
+	time.Sleep(20 * time.Millisecond)
+	q.StopWorkers()
+	if err := <-errc; err == nil {
+		t.Fatal("QueueTxn() on a stopped queue error = nil, want an error")
+	}
+
+	if got := len(cache.expires); got != 1 {
+		t.Errorf("deduplication keys = %d, want only the queued task's", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_BlockOnFull(t *testing.T) {
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	tests := []struct {
+		name    string
+		unblock func(q *ChannelTaskQueue)
+		wantErr bool
+	}{
+		{name: "queued once the queue drains", unblock: func(q *ChannelTaskQueue) { queuedTasks(q) }},
+		{name: "fails when the workers stop", unblock: func(q *ChannelTaskQueue) { q.workerCancel() }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 1, false)
+			if err != nil {
+				t.Fatalf("Failed to create task queue: %v", err)
+			}
+			defer q.StopWorkers()
+			if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{}); err != nil {
+				t.Fatalf("QueueTxn() error = %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := q.QueueTxn(ctx, reqCtx, []byte(`{}`), http.Header{})
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				t.Fatalf("QueueTxn() on a full queue returned %v, want it to block", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			tt.unblock(q)
+			select {
+			case err := <-done:
+				if gotErr := err != nil; gotErr != tt.wantErr {
+					t.Errorf("QueueTxn() error = %v, want error %t", err, tt.wantErr)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("QueueTxn() still blocked")
+			}
+			if q.TasksDropped() != 0 {
+				t.Errorf("TasksDropped() = %d, want 0", q.TasksDropped())
+			}
+		})
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_MaxQueuedBytes(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetMaxQueuedBytes(100)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	body := bytes.Repeat([]byte("a"), 40)
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.QueueTxn(ctx, reqCtx, body, http.Header{}); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+
+	// A third body would exceed the byte cap while the channel still has room for 8 more tasks.
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.QueueTxn(ctx, reqCtx, body, http.Header{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("QueueTxn() returned %v before queued bytes were released, want it to block", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := q.Depth(); got != 2 {
+		t.Errorf("queued %d tasks while blocked on bytes, want 2", got)
+	}
+	if got := q.QueuedBytes(); got != 80 {
+		t.Errorf("QueuedBytes() = %d while blocked on bytes, want 80", got)
+	}
+
+	q.dequeued(<-q.taskChannel)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("QueueTxn() still blocked after queued bytes were released")
+	}
+	if got := q.bodyBudget.reserved(); got != 80 {
+		t.Errorf("reserved bytes = %d, want 80", got)
+	}
+	queuedTasks(q)
+	if got := q.bodyBudget.reserved(); got != 0 {
+		t.Errorf("reserved bytes after draining = %d, want 0", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_MaxQueuedBytesOversizedTask(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetMaxQueuedBytes(10)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+
+	// A body larger than the whole budget is admitted into an empty queue rather than blocking forever.
+	if _, err := q.QueueTxn(ctx, reqCtx, bytes.Repeat([]byte("a"), 20), http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	if got := len(queuedTasks(q)); got != 1 {
+		t.Errorf("queued %d tasks, want 1", got)
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_MaxQueuedBytesShutdown(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	q.SetMaxQueuedBytes(10)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	body := bytes.Repeat([]byte("a"), 10)
+	if _, err := q.QueueTxn(ctx, reqCtx, body, http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.QueueTxn(ctx, reqCtx, body, http.Header{})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	q.StopWorkers()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("QueueTxn() error = nil after shutdown, want error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("QueueTxn() still blocked after shutdown")
+	}
+}
+
+func TestChannelTaskQueue_QueueTxn_MaxQueuedBytesRequestCancelled(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewChannelTaskQueue(ctx, 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetMaxQueuedBytes(10)
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	body := bytes.Repeat([]byte("a"), 10)
+	if _, err := q.QueueTxn(ctx, reqCtx, body, http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+
+	reqCtxTimeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.QueueTxn(reqCtxTimeout, reqCtx, body, http.Header{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("QueueTxn() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := q.bodyBudget.reserved(); got != 10 {
+		t.Errorf("reserved bytes = %d, want 10", got)
+	}
+}
+
+func TestChannelTaskQueue_SetMaxQueuedBytesDisabled(t *testing.T) {
+	q, err := NewChannelTaskQueue(context.Background(), 1, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	defer q.StopWorkers()
+	q.SetMaxQueuedBytes(100)
+	q.SetMaxQueuedBytes(0)
+	if q.bodyBudget != nil {
+		t.Error("SetMaxQueuedBytes(0) left a byte budget, want none")
+	}
+	if got := q.QueuedBytes(); got != 0 {
+		t.Errorf("QueuedBytes() = %d without a byte budget, want 0", got)
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURI string
+		action  string
+		cfg     TargetURLConfig
+		want    string
+	}{
+		{name: "host only", baseURI: "http://bpp.com", action: "search", want: "http://bpp.com/search"},
+		{name: "host with trailing slash", baseURI: "http://bpp.com/", action: "search", want: "http://bpp.com/search"},
+		{name: "base path", baseURI: "http://bpp.com/beckn", action: "search", want: "http://bpp.com/beckn/search"},
+		{name: "base path with trailing slash", baseURI: "http://bpp.com/beckn/", action: "search", want: "http://bpp.com/beckn/search"},
+		{name: "duplicate slashes", baseURI: "http://bpp.com//beckn//v1//", action: "on_search", want: "http://bpp.com/beckn/v1/on_search"},
+		{name: "query params are preserved", baseURI: "http://bpp.com/beckn?tenant=a&x=1", action: "search", want: "http://bpp.com/beckn/search?tenant=a&x=1"},
+		{name: "query params with trailing slash", baseURI: "http://bpp.com/beckn/?tenant=a", action: "search", want: "http://bpp.com/beckn/search?tenant=a"},
+		{name: "fragment is dropped", baseURI: "http://bpp.com/beckn#frag", action: "search", want: "http://bpp.com/beckn/search"},
+		{name: "port and escaped path", baseURI: "https://bpp.com:8443/a%2Fb/", action: "search", want: "https://bpp.com:8443/a%2Fb/search"},
+		{name: "query params dropped", baseURI: "http://bpp.com/beckn?tenant=a", action: "search", cfg: TargetURLConfig{DropQuery: true}, want: "http://bpp.com/beckn/search"},
+		{name: "trailing slash", baseURI: "http://bpp.com//beckn/", action: "search", cfg: TargetURLConfig{TrailingSlash: true}, want: "http://bpp.com/beckn/search/"},
+		{name: "trailing slash with query params", baseURI: "http://bpp.com/beckn?tenant=a", action: "on_search", cfg: TargetURLConfig{TrailingSlash: true}, want: "http://bpp.com/beckn/on_search/?tenant=a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTarget(tt.baseURI, tt.action, tt.cfg)
+			if err != nil {
+				t.Fatalf("resolveTarget() unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("resolveTarget() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTarget_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURI string
+	}{
+		{name: "unparsable URI", baseURI: "://invalid-uri"},
+		{name: "missing scheme", baseURI: "bpp.com/beckn"},
+		{name: "missing host", baseURI: "http:///beckn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveTarget(tt.baseURI, "search", TargetURLConfig{}); err == nil {
+				t.Errorf("resolveTarget(%q) error = nil, want error", tt.baseURI)
+			}
+		})
+	}
+}
+
 func TestChannelTaskQueue_WorkerProcessingAndShutdown(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -325,7 +878,7 @@ func TestChannelTaskQueue_WorkerProcessingAndShutdown(t *testing.T) {
 	mockLookupP := &mockTaskProcessor{processFunc: signalFunc}
 
 	// Updated call: ctx as first parameter
-	q, err := NewChannelTaskQueue(ctx, 2, mockProxyP, mockLookupP, 10)
+	q, err := NewChannelTaskQueue(ctx, 2, mockProxyP, mockLookupP, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue: %v", err)
 	}
@@ -373,6 +926,123 @@ func TestChannelTaskQueue_WorkerProcessingAndShutdown(t *testing.T) {
 	}
 }
 
+func TestChannelTaskQueue_PauseResume(t *testing.T) {
+	ctx := context.Background()
+	processed := make(chan struct{}, 10)
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		processed <- struct{}{}
+		return nil
+	}}
+	q, err := NewChannelTaskQueue(ctx, 2, proxyP, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	q.StartWorkers()
+	defer q.StopWorkers()
+
+	q.Pause()
+	if !q.Paused() {
+		t.Fatal("Paused() = false after Pause(), want true")
+	}
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	for i := 0; i < 5; i++ {
+		if _, err := q.QueueTxn(ctx, reqCtx, nil, http.Header{}); err != nil {
+			t.Fatalf("QueueTxn() while paused error = %v", err)
+		}
+	}
+	select {
+	case <-processed:
+		t.Fatal("task processed while the queue was paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := q.Depth(); got != 5 {
+		t.Errorf("Depth() while paused = %d, want 5", got)
+	}
+
+	q.Resume()
+	if q.Paused() {
+		t.Error("Paused() = true after Resume(), want false")
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case <-processed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for task %d to process after Resume()", i)
+		}
+	}
+	if got := proxyP.getCallCount(); got != 5 {
+		t.Errorf("proxyProcessor call count after Resume() = %d, want 5", got)
+	}
+}
+
+func TestChannelTaskQueue_PauseCompletesInFlightTask(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		started <- struct{}{}
+		<-unblock
+		return nil
+	}}
+	q, err := NewChannelTaskQueue(ctx, 1, proxyP, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	q.StartWorkers()
+	defer q.StopWorkers()
+
+	reqCtx := &model.Context{Action: "search", BppURI: "http://bpp.com/beckn"}
+	if _, err := q.QueueTxn(ctx, reqCtx, nil, http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the task to start")
+	}
+
+	q.Pause()
+	if _, err := q.QueueTxn(ctx, reqCtx, nil, http.Header{}); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	close(unblock)
+	select {
+	case <-started:
+		t.Fatal("second task started while the queue was paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := proxyP.getCallCount(); got != 1 {
+		t.Errorf("proxyProcessor call count while paused = %d, want 1", got)
+	}
+
+	q.Resume()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the second task after Resume()")
+	}
+}
+
+func TestChannelTaskQueue_StopWorkersWhilePaused(t *testing.T) {
+	q, err := NewChannelTaskQueue(context.Background(), 2, &mockTaskProcessor{}, &mockTaskProcessor{}, 10, false)
+	if err != nil {
+		t.Fatalf("Failed to create task queue: %v", err)
+	}
+	q.StartWorkers()
+	q.Pause()
+
+	stopped := make(chan struct{})
+	go func() {
+		q.StopWorkers()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StopWorkers() blocked while the queue was paused")
+	}
+}
+
 func TestChannelTaskQueue_ProcessorErrorHandling(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -393,7 +1063,7 @@ func TestChannelTaskQueue_ProcessorErrorHandling(t *testing.T) {
 	mockLookupP := &mockTaskProcessor{processFunc: processor}
 
 	// Updated call: ctx as first parameter
-	q, err := NewChannelTaskQueue(ctx, 1, mockProxyP, mockLookupP, 10)
+	q, err := NewChannelTaskQueue(ctx, 1, mockProxyP, mockLookupP, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue: %v", err)
 	}
@@ -443,7 +1113,7 @@ func TestChannelTaskQueue_WorkerErrorPaths(t *testing.T) {
 
 	// Test 1: Lookup task when lookupProcessor is nil
 	// Create a queue where lookupProcessor is initially nil.
-	qNilLookup, err := NewChannelTaskQueue(ctx, 1, mockProxyP, nil, 10)
+	qNilLookup, err := NewChannelTaskQueue(ctx, 1, mockProxyP, nil, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue with nil lookup processor: %v", err)
 	}
@@ -457,7 +1127,7 @@ func TestChannelTaskQueue_WorkerErrorPaths(t *testing.T) {
 
 	// Test 2: Unknown task type
 	// Use the same queue or a new one. Let's use a new one for clarity.
-	qUnknownType, err := NewChannelTaskQueue(ctx, 1, mockProxyP, mockLookupP, 10)
+	qUnknownType, err := NewChannelTaskQueue(ctx, 1, mockProxyP, mockLookupP, 10, false)
 	if err != nil {
 		t.Fatalf("Failed to create task queue for unknown type test: %v", err)
 	}
@@ -494,3 +1164,88 @@ func TestChannelTaskQueue_WorkerErrorPaths(t *testing.T) {
 		t.Errorf("lookupProcessor call count = %d, want 0", mockLookupP.getCallCount())
 	}
 }
+
+func TestChannelTaskQueue_TaskRetries(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxRetries      int
+		failures        int
+		err             error
+		wantCalls       int
+		wantDeadLetters int
+	}{
+		{name: "retries disabled", maxRetries: 0, failures: 5, err: errors.New("target unavailable"), wantCalls: 1, wantDeadLetters: 1},
+		{name: "succeeds on retry", maxRetries: 3, failures: 2, err: errors.New("target unavailable"), wantCalls: 3},
+		{name: "retries exhausted", maxRetries: 2, failures: 5, err: errors.New("target unavailable"), wantCalls: 3, wantDeadLetters: 1},
+		{name: "context error not retried", maxRetries: 3, failures: 5, err: context.DeadlineExceeded, wantCalls: 1, wantDeadLetters: 1},
+		{name: "partial fan-out not retried", maxRetries: 3, failures: 5, err: fmt.Errorf("%w: 1 proxy tasks queued", ErrPartialFanOut), wantCalls: 1, wantDeadLetters: 1},
+		{name: "callback limit deferred without attempt", maxRetries: 0, failures: 2, err: &ProxyTaskError{Target: "http://bpp.com", Err: ErrCallbackLimitReached}, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			proxyP := &mockTaskProcessor{}
+			proxyP.processFunc = func(ctx context.Context, task *model.AsyncTask) error {
+				// Called with proxyP.mu held.
+				if proxyP.callCount <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}
+			q, err := NewChannelTaskQueue(ctx, 1, proxyP, nil, 10, false)
+			if err != nil {
+				t.Fatalf("NewChannelTaskQueue() error = %v", err)
+			}
+			sink := &MemoryDeadLetterSink{}
+			q.SetDeadLetterSink(sink)
+			q.SetTaskRetries(tt.maxRetries, time.Millisecond)
+			q.StartWorkers()
+
+			if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+				t.Fatalf("QueueTxn() error = %v", err)
+			}
+			waitForCalls(t, proxyP, tt.wantCalls)
+			// Give a wrongly retried task the time to be processed again.
+			time.Sleep(50 * time.Millisecond)
+			q.StopWorkers()
+
+			if got := proxyP.getCallCount(); got != tt.wantCalls {
+				t.Errorf("processor calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := len(sink.Letters()); got != tt.wantDeadLetters {
+				t.Errorf("dead letters = %d, want %d", got, tt.wantDeadLetters)
+			}
+		})
+	}
+}
+
+func TestChannelTaskQueue_TaskRetriesStopPromptly(t *testing.T) {
+	ctx := context.Background()
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		return errors.New("target unavailable")
+	}}
+	q, err := NewChannelTaskQueue(ctx, 1, proxyP, nil, 10, false)
+	if err != nil {
+		t.Fatalf("NewChannelTaskQueue() error = %v", err)
+	}
+	sink := &MemoryDeadLetterSink{}
+	q.SetDeadLetterSink(sink)
+	q.SetTaskRetries(3, time.Hour)
+	q.StartWorkers()
+
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	waitForCalls(t, proxyP, 1)
+
+	start := time.Now()
+	q.StopWorkers()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("StopWorkers() took %v while a retry was pending, want it to return promptly", elapsed)
+	}
+	// The task waiting for its retry is dead-lettered rather than silently lost.
+	if letters := sink.Letters(); len(letters) != 1 || letters[0].Error != "target unavailable" {
+		t.Errorf("dead letters = %+v, want the task waiting for its retry", letters)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// DeadLetterSink records the tasks that failed permanently, so that operators can inspect their payloads.
+type DeadLetterSink interface {
+	// Record records task and the error it failed with.
+	Record(ctx context.Context, task *model.AsyncTask, err error) error
+}
+
+// DeadLetter is a task recorded by a MemoryDeadLetterSink.
+type DeadLetter struct {
+	Task  *model.AsyncTask
+	Error string
+}
+
+// MemoryDeadLetterSink is a DeadLetterSink keeping the recorded tasks in memory.
+type MemoryDeadLetterSink struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// Record implements DeadLetterSink.
+func (s *MemoryDeadLetterSink) Record(ctx context.Context, task *model.AsyncTask, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, DeadLetter{Task: task, Error: err.Error()})
+	return nil
+}
+
+// Letters returns the recorded tasks, oldest first.
+func (s *MemoryDeadLetterSink) Letters() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeadLetter(nil), s.letters...)
+}
+
+// DeadLetterRecord is the record of a task that failed permanently, stored by a cacheDeadLetterSink.
+type DeadLetterRecord struct {
+	TransactionID string              `json:"transaction_id"`
+	MessageID     string              `json:"message_id"`
+	Action        string              `json:"action"`
+	Type          model.AsyncTaskType `json:"type"`
+	Target        string              `json:"target,omitempty"`
+	Headers       http.Header         `json:"headers"` // Headers of the task, with credentials redacted.
+	Body          string              `json:"body"`
+	Error         string              `json:"error"`
+	FailedAt      time.Time           `json:"failed_at" format:"date-time"`
+}
+
+// cacheDeadLetterSink stores dead-letter records as JSON in a cache such as Redis, keyed by
+// transaction id, message id and target like the proxy task archive.
+type cacheDeadLetterSink struct {
+	cache archiveCache
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewCacheDeadLetterSink creates a dead-letter sink that keeps records in cache for ttl.
+func NewCacheDeadLetterSink(cache archiveCache, ttl time.Duration) (*cacheDeadLetterSink, error) {
+	if cache == nil {
+		slog.Error("NewCacheDeadLetterSink: cache cannot be nil")
+		return nil, errors.New("cache cannot be nil")
+	}
+	if ttl <= 0 {
+		slog.Error("NewCacheDeadLetterSink: ttl must be positive", "ttl", ttl)
+		return nil, fmt.Errorf("dead-letter ttl must be positive, got %s", ttl)
+	}
+	return &cacheDeadLetterSink{cache: cache, ttl: ttl, now: time.Now}, nil
+}
+
+// deadLetterKey returns the cache key of a record.
+func deadLetterKey(rec *DeadLetterRecord) string {
+	return fmt.Sprintf("dead_letter:%s:%s:%s", rec.TransactionID, rec.MessageID, rec.Target)
+}
+
+// Record implements DeadLetterSink.
+func (s *cacheDeadLetterSink) Record(ctx context.Context, task *model.AsyncTask, err error) error {
+	rec := &DeadLetterRecord{
+		TransactionID: task.Context.TransactionID,
+		MessageID:     task.Context.MessageID,
+		Action:        task.Context.Action,
+		Type:          task.Type,
+		Headers:       redactHeaders(task.Headers),
+		Body:          string(task.Body),
+		Error:         err.Error(),
+		FailedAt:      s.now().UTC(),
+	}
+	if task.Target != nil {
+		rec.Target = task.Target.String()
+	}
+	b, mErr := json.Marshal(rec)
+	if mErr != nil {
+		return fmt.Errorf("failed to marshal dead-letter record: %w", mErr)
+	}
+	if sErr := s.cache.Set(ctx, deadLetterKey(rec), string(b), s.ttl); sErr != nil {
+		return fmt.Errorf("failed to store dead-letter record: %w", sErr)
+	}
+	return nil
+}
+
+// recordDeadLetter records task, which failed permanently with err, in sink if it is set.
+// The record outlives ctx, so that tasks failing as the workers stop are still recorded.
+func recordDeadLetter(ctx context.Context, sink DeadLetterSink, task *model.AsyncTask, err error) {
+	if sink == nil {
+		return
+	}
+	if recErr := sink.Record(context.WithoutCancel(ctx), task, err); recErr != nil {
+		slog.ErrorContext(ctx, "Failed to record dead-letter task", "type", task.Type, "target", task.Target, "error", recErr, "task_error", err)
+	}
+}
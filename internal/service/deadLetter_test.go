@@ -0,0 +1,242 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// slowDeadLetterSink is a DeadLetterSink taking a while to record each task.
+type slowDeadLetterSink struct {
+	MemoryDeadLetterSink
+	delay time.Duration
+}
+
+func (s *slowDeadLetterSink) Record(ctx context.Context, task *model.AsyncTask, err error) error {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.MemoryDeadLetterSink.Record(ctx, task, err)
+}
+
+// failingDeadLetterSink is a DeadLetterSink failing to record tasks.
+type failingDeadLetterSink struct{}
+
+func (failingDeadLetterSink) Record(ctx context.Context, task *model.AsyncTask, err error) error {
+	return errors.New("sink unavailable")
+}
+
+func TestChannelTaskQueue_DeadLetterSink(t *testing.T) {
+	ctx := context.Background()
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		if task.Context.TransactionID == "fails" {
+			return errors.New("target unavailable")
+		}
+		return nil
+	}}
+	q, err := NewChannelTaskQueue(ctx, 1, proxyP, nil, 10, false)
+	if err != nil {
+		t.Fatalf("NewChannelTaskQueue() error = %v", err)
+	}
+	sink := &MemoryDeadLetterSink{}
+	q.SetDeadLetterSink(sink)
+	q.StartWorkers()
+
+	for _, reqCtx := range []*model.Context{
+		{Action: "search", BppURI: "http://bpp.com", TransactionID: "fails"},
+		{Action: "search", BppURI: "http://bpp.com", TransactionID: "succeeds"},
+		{Action: "search", TransactionID: "no-lookup-processor"},
+	} {
+		if _, err := q.QueueTxn(ctx, reqCtx, []byte(`{"txn":"`+reqCtx.TransactionID+`"}`), nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+	waitForCalls(t, proxyP, 2)
+	q.StopWorkers()
+
+	var got []string
+	for _, l := range sink.Letters() {
+		got = append(got, l.Task.Context.TransactionID+": "+l.Error+": "+string(l.Task.Body))
+	}
+	want := []string{
+		`fails: target unavailable: {"txn":"fails"}`,
+		`no-lookup-processor: no processor for LOOKUP tasks: {"txn":"no-lookup-processor"}`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dead letters mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestChannelTaskQueue_StopWorkersFlushesDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{})
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	q, err := NewChannelTaskQueue(ctx, 1, proxyP, nil, 10, false)
+	if err != nil {
+		t.Fatalf("NewChannelTaskQueue() error = %v", err)
+	}
+	sink := &slowDeadLetterSink{delay: 50 * time.Millisecond}
+	q.SetDeadLetterSink(sink)
+	q.StartWorkers()
+
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	<-started
+	// The task fails because the workers stop, and is recorded although their context is cancelled.
+	q.StopWorkers()
+
+	if letters := sink.Letters(); len(letters) != 1 || letters[0].Error != context.Canceled.Error() {
+		t.Errorf("dead letters after StopWorkers = %+v, want the cancelled task", letters)
+	}
+}
+
+func TestChannelTaskQueue_DeadLetterSinkError(t *testing.T) {
+	ctx := context.Background()
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		return errors.New("target unavailable")
+	}}
+	q, err := NewChannelTaskQueue(ctx, 1, proxyP, nil, 10, false)
+	if err != nil {
+		t.Fatalf("NewChannelTaskQueue() error = %v", err)
+	}
+	q.SetDeadLetterSink(failingDeadLetterSink{})
+	q.StartWorkers()
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+	// A failing sink does not stop the workers from processing later tasks.
+	waitForCalls(t, proxyP, 2)
+	q.StopWorkers()
+}
+
+func TestRedisTaskQueue_DeadLetterSink(t *testing.T) {
+	ctx := context.Background()
+	proxyP := &mockTaskProcessor{processFunc: func(ctx context.Context, task *model.AsyncTask) error {
+		return errors.New("target unavailable")
+	}}
+	q, err := NewRedisTaskQueue(ctx, 1, proxyP, nil, newFakeTaskList(), RedisTaskQueueConfig{PopTimeout: 10 * time.Millisecond, MaxRetries: 2, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	sink := &MemoryDeadLetterSink{}
+	q.SetDeadLetterSink(sink)
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com", TransactionID: "txn-1"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	q.StartWorkers()
+	waitForCalls(t, proxyP, 3)
+	q.StopWorkers()
+
+	// The task is recorded once, after its retries are exhausted.
+	letters := sink.Letters()
+	if len(letters) != 1 || letters[0].Task.Context.TransactionID != "txn-1" || letters[0].Error != "target unavailable" {
+		t.Errorf("dead letters = %+v, want the task after its last attempt", letters)
+	}
+}
+
+func TestNewCacheDeadLetterSink_Error(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache archiveCache
+		ttl   time.Duration
+	}{
+		{name: "nil cache", ttl: time.Hour},
+		{name: "zero ttl", cache: &mockArchiveCache{}},
+		{name: "negative ttl", cache: &mockArchiveCache{}, ttl: -time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCacheDeadLetterSink(tt.cache, tt.ttl); err == nil {
+				t.Error("NewCacheDeadLetterSink() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestCacheDeadLetterSink_Record(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheErr error
+		wantErr  bool
+	}{
+		{name: "stored"},
+		{name: "cache error", cacheErr: errors.New("redis down"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mockArchiveCache{err: tt.cacheErr}
+			sink, err := NewCacheDeadLetterSink(cache, time.Hour)
+			if err != nil {
+				t.Fatalf("NewCacheDeadLetterSink() error = %v", err)
+			}
+			failedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			sink.now = func() time.Time { return failedAt }
+			task := newArchivedTestTask()
+			task.Type = model.AsyncTaskTypeProxy
+			task.Context.Action = "search"
+			task.Headers.Set(model.AuthHeaderSubscriber, "Signature keyId=...")
+
+			err = sink.Record(context.Background(), task, errors.New("target unavailable"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Record() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := "dead_letter:txn-1:msg-1:http://example.com/bpp/search"; cache.key != want {
+				t.Errorf("Record() key = %q, want %q", cache.key, want)
+			}
+			if cache.ttl != time.Hour {
+				t.Errorf("Record() ttl = %s, want %s", cache.ttl, time.Hour)
+			}
+			var got DeadLetterRecord
+			if err := json.Unmarshal([]byte(cache.value), &got); err != nil {
+				t.Fatalf("failed to unmarshal record %q: %v", cache.value, err)
+			}
+			want := DeadLetterRecord{
+				TransactionID: "txn-1",
+				MessageID:     "msg-1",
+				Action:        "search",
+				Type:          model.AsyncTaskTypeProxy,
+				Target:        "http://example.com/bpp/search",
+				Headers:       http.Header{"X-Tenant": []string{"acme"}, model.AuthHeaderSubscriber: []string{redactedHeaderValue}},
+				Body:          `{"data":"test"}`,
+				Error:         "target unavailable",
+				FailedAt:      failedAt,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Record() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
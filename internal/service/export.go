@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// subscriptionStreamer streams all stored subscriptions.
+type subscriptionStreamer interface {
+	ForEachSubscription(ctx context.Context, fn func(*model.Subscription) error) error
+}
+
+// subscriptionExporter writes the registry's subscriptions as newline-delimited JSON.
+type subscriptionExporter struct {
+	repo subscriptionStreamer
+	now  func() time.Time
+}
+
+// NewSubscriptionExporter creates a new subscriptionExporter.
+func NewSubscriptionExporter(repo subscriptionStreamer) (*subscriptionExporter, error) {
+	if repo == nil {
+		slog.Error("NewSubscriptionExporter: repo cannot be nil")
+		return nil, errors.New("repo cannot be nil")
+	}
+	return &subscriptionExporter{repo: repo, now: time.Now}, nil
+}
+
+// Export writes a model.SubscriptionExportHeader line followed by one line per subscription to w.
+// Subscriptions are streamed from the repository as they are read. It returns the number of subscriptions written.
+func (e *subscriptionExporter) Export(ctx context.Context, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	header := &model.SubscriptionExportHeader{
+		Kind:          "subscriptions",
+		FormatVersion: model.SubscriptionExportFormatVersion,
+		ExportedAt:    e.now().UTC(),
+	}
+	if err := enc.Encode(header); err != nil {
+		return 0, fmt.Errorf("failed to write export header: %w", err)
+	}
+
+	count := 0
+	err := e.repo.ForEachSubscription(ctx, func(sub *model.Subscription) error {
+		if err := enc.Encode(sub); err != nil {
+			return fmt.Errorf("failed to write subscription %s: %w", sub.SubscriberID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriptionExporter: export failed", "exported", count, "error", err)
+		return count, fmt.Errorf("subscription export failed after %d records: %w", count, err)
+	}
+	slog.InfoContext(ctx, "SubscriptionExporter: export completed", "exported", count)
+	return count, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockSubscriptionStreamer streams a fixed list of subscriptions.
+type mockSubscriptionStreamer struct {
+	subs    []model.Subscription
+	err     error
+	onVisit func(i int) // Called before each subscription is passed on.
+}
+
+func (m *mockSubscriptionStreamer) ForEachSubscription(ctx context.Context, fn func(*model.Subscription) error) error {
+	for i := range m.subs {
+		if m.onVisit != nil {
+			m.onVisit(i)
+		}
+		if err := fn(&m.subs[i]); err != nil {
+			return err
+		}
+	}
+	return m.err
+}
+
+func TestNewSubscriptionExporter_Error(t *testing.T) {
+	if _, err := NewSubscriptionExporter(nil); err == nil {
+		t.Error("NewSubscriptionExporter(nil) error = nil, want error")
+	}
+}
+
+func TestSubscriptionExporter_Export(t *testing.T) {
+	exportedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	subs := []model.Subscription{
+		{
+			Subscriber: model.Subscriber{SubscriberID: "bap.example.com", URL: "https://bap.example.com", Type: model.RoleBAP, Domain: "retail"},
+			KeyID:      "key1",
+			Status:     model.SubscriptionStatusSubscribed,
+			ValidFrom:  exportedAt.Add(-time.Hour),
+			ValidUntil: exportedAt.Add(time.Hour),
+		},
+		{
+			Subscriber: model.Subscriber{
+				SubscriberID: "bpp.example.com", URL: "https://bpp.example.com", Type: model.RoleBPP, Domain: "mobility",
+				Location: &model.Location{City: &model.City{Code: "std:080"}},
+			},
+			KeyID:  "key2",
+			Status: model.SubscriptionStatusUnderSubscription,
+		},
+	}
+	var buf bytes.Buffer
+	repo := &mockSubscriptionStreamer{
+		subs: subs,
+		// Each subscription must be written before the next one is read.
+		onVisit: func(i int) {
+			if got := strings.Count(buf.String(), "\n"); got != i+1 {
+				t.Errorf("before reading subscription %d, %d lines were written, want %d", i, got, i+1)
+			}
+		},
+	}
+	exporter, err := NewSubscriptionExporter(repo)
+	if err != nil {
+		t.Fatalf("NewSubscriptionExporter() error = %v", err)
+	}
+	exporter.now = func() time.Time { return exportedAt }
+
+	count, err := exporter.Export(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("Export() error = %v, want nil", err)
+	}
+	if count != len(subs) {
+		t.Errorf("Export() count = %d, want %d", count, len(subs))
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	if !scanner.Scan() {
+		t.Fatal("Export() wrote no header line")
+	}
+	var header model.SubscriptionExportHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("header line is not valid JSON: %v", err)
+	}
+	wantHeader := model.SubscriptionExportHeader{Kind: "subscriptions", FormatVersion: model.SubscriptionExportFormatVersion, ExportedAt: exportedAt}
+	if diff := cmp.Diff(wantHeader, header); diff != "" {
+		t.Errorf("header mismatch (-want +got):\n%s", diff)
+	}
+
+	var got []model.Subscription
+	for scanner.Scan() {
+		var sub model.Subscription
+		if err := json.Unmarshal(scanner.Bytes(), &sub); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", len(got)+2, err)
+		}
+		got = append(got, sub)
+	}
+	if diff := cmp.Diff(subs, got); diff != "" {
+		t.Errorf("exported subscriptions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscriptionExporter_Export_Error(t *testing.T) {
+	repoErr := errors.New("db error")
+	repo := &mockSubscriptionStreamer{
+		subs: []model.Subscription{{Subscriber: model.Subscriber{SubscriberID: "sub1"}}},
+		err:  repoErr,
+	}
+	exporter, err := NewSubscriptionExporter(repo)
+	if err != nil {
+		t.Fatalf("NewSubscriptionExporter() error = %v", err)
+	}
+
+	count, err := exporter.Export(context.Background(), &bytes.Buffer{})
+	if !errors.Is(err, repoErr) {
+		t.Errorf("Export() error = %v, want %v", err, repoErr)
+	}
+	if count != 1 {
+		t.Errorf("Export() count = %d, want 1", count)
+	}
+}
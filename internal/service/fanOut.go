@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ErrFanOutFailed is returned when a synchronous fan-out cannot return results: every target
+// failed or, without FanOutConfig.PartialResults, any target failed.
+var ErrFanOutFailed = errors.New("fan-out failed")
+
+// FanOutConfig configures a synchronous fan-out of a request to several targets.
+type FanOutConfig struct {
+	// PartialResults returns the responses of the targets that succeeded when others fail,
+	// along with their failures. Otherwise a failed target fails the whole fan-out.
+	PartialResults bool `yaml:"partialResults"`
+	// Concurrency caps the targets called at the same time, all of them if it is not positive.
+	Concurrency int `yaml:"concurrency"`
+}
+
+// FanOutResponse is the ACK response of a target of a fan-out.
+type FanOutResponse struct {
+	Target   string
+	Response *model.TxnResponse
+}
+
+// FanOutResult holds the responses of the targets of a fan-out that succeeded and the failures
+// of the others, each in the order of their tasks.
+type FanOutResult struct {
+	Responses []FanOutResponse
+	Failures  []*ProxyTaskError
+}
+
+// FailureSummary describes the failed targets, e.g. "1 of 3 targets failed: http://bpp.com/search: ...".
+// It is empty if no target failed.
+func (r *FanOutResult) FailureSummary() string {
+	if len(r.Failures) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d targets failed", len(r.Failures), len(r.Failures)+len(r.Responses))
+	for i, f := range r.Failures {
+		sep := "; "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&b, "%s%s: %v", sep, f.Target, f.Err)
+	}
+	return b.String()
+}
+
+// FanOut proxies tasks, the same request addressed to several targets, and waits for all their
+// responses. A target that is invalid or unreachable, or that does not ACK, is reported in the
+// failures of the result. With cfg.PartialResults, the result is returned without error as long as
+// one target succeeded; otherwise, any failure returns ErrFanOutFailed along with the result.
+func (p *proxyTaskProcessor) FanOut(ctx context.Context, tasks []*model.AsyncTask, cfg FanOutConfig) (*FanOutResult, error) {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 || concurrency > len(tasks) {
+		concurrency = len(tasks)
+	}
+	responses := make([]*FanOutResponse, len(tasks))
+	failures := make([]*ProxyTaskError, len(tasks))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// A fan-out runs in its request, so it waits for callback slots rather than being queued again.
+			resp, err := p.process(ctx, task, true)
+			p.recordOutcome(err)
+			if err == nil {
+				responses[i] = &FanOutResponse{Target: task.Target.String(), Response: resp}
+				return
+			}
+			var taskErr *ProxyTaskError
+			if !errors.As(err, &taskErr) {
+				taskErr = &ProxyTaskError{Err: err}
+				if task != nil && task.Target != nil {
+					taskErr.Target = task.Target.String()
+				}
+			}
+			failures[i] = taskErr
+		}()
+	}
+	wg.Wait()
+
+	res := &FanOutResult{}
+	for i := range tasks {
+		if responses[i] != nil {
+			res.Responses = append(res.Responses, *responses[i])
+		} else {
+			res.Failures = append(res.Failures, failures[i])
+		}
+	}
+	if len(res.Failures) == 0 {
+		return res, nil
+	}
+	summary := res.FailureSummary()
+	if cfg.PartialResults && len(res.Responses) > 0 {
+		slog.WarnContext(ctx, "ProxyTaskProcessor: Returning partial fan-out results", "succeeded", len(res.Responses), "failures", summary)
+		return res, nil
+	}
+	slog.ErrorContext(ctx, "ProxyTaskProcessor: Fan-out failed", "succeeded", len(res.Responses), "failures", summary)
+	return res, fmt.Errorf("%w: %s", ErrFanOutFailed, summary)
+}
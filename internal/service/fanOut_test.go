@@ -0,0 +1,146 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fanOutTargets returns the URLs of a target ACKing, a target failing with a 500 and a target
+// that is not listening.
+func fanOutTargets(t *testing.T) (ack, failing, unreachable string) {
+	t.Helper()
+	ackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	t.Cleanup(ackSrv.Close)
+	failingSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	t.Cleanup(failingSrv.Close)
+	closedSrv := httptest.NewServer(http.NotFoundHandler())
+	closedSrv.Close()
+	return ackSrv.URL + "/search", failingSrv.URL + "/search", closedSrv.URL + "/search"
+}
+
+func newFanOutTask(target string) *model.AsyncTask {
+	return newTestAsyncTask(target, []byte(`{"context":{"action":"search"}}`), http.Header{model.AuthHeaderGateway: []string{"Signature test"}})
+}
+
+func TestProxyTaskProcessor_FanOut(t *testing.T) {
+	ack, failing, unreachable := fanOutTargets(t)
+	invalid := &model.AsyncTask{Type: model.AsyncTaskTypeProxy, Headers: http.Header{}} // No target.
+
+	tests := []struct {
+		name          string
+		tasks         []*model.AsyncTask
+		cfg           FanOutConfig
+		wantResponses []string
+		wantFailures  []string
+		wantErr       bool
+	}{
+		{
+			name:          "all targets succeed",
+			tasks:         []*model.AsyncTask{newFanOutTask(ack), newFanOutTask(ack)},
+			wantResponses: []string{ack, ack},
+		},
+		{
+			name:          "partial results with failing, unreachable and invalid targets",
+			tasks:         []*model.AsyncTask{newFanOutTask(failing), newFanOutTask(ack), newFanOutTask(unreachable), invalid},
+			cfg:           FanOutConfig{PartialResults: true, Concurrency: 2},
+			wantResponses: []string{ack},
+			wantFailures:  []string{failing, unreachable, ""},
+		},
+		{
+			name:          "all or nothing fails on a failed target",
+			tasks:         []*model.AsyncTask{newFanOutTask(ack), newFanOutTask(unreachable)},
+			wantResponses: []string{ack},
+			wantFailures:  []string{unreachable},
+			wantErr:       true,
+		},
+		{
+			name:         "partial results fail when every target fails",
+			tasks:        []*model.AsyncTask{newFanOutTask(failing), newFanOutTask(unreachable)},
+			cfg:          FanOutConfig{PartialResults: true},
+			wantFailures: []string{failing, unreachable},
+			wantErr:      true,
+		},
+		{
+			name: "no targets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProxyTaskProcessor(&mockAuthGen{}, "key1", RetryConfig{})
+			if err != nil {
+				t.Fatalf("NewProxyTaskProcessor() error = %v", err)
+			}
+
+			res, err := p.FanOut(context.Background(), tt.tasks, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FanOut() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrFanOutFailed) {
+				t.Errorf("FanOut() error = %v, want %v", err, ErrFanOutFailed)
+			}
+			var gotResponses, gotFailures []string
+			for _, r := range res.Responses {
+				if r.Response == nil || r.Response.Message.Ack.Status != model.StatusACK {
+					t.Errorf("response of %s = %+v, want an ACK", r.Target, r.Response)
+				}
+				gotResponses = append(gotResponses, r.Target)
+			}
+			for _, f := range res.Failures {
+				if f.Err == nil {
+					t.Errorf("failure of %q has no error", f.Target)
+				}
+				gotFailures = append(gotFailures, f.Target)
+			}
+			if diff := cmp.Diff(tt.wantResponses, gotResponses); diff != "" {
+				t.Errorf("responses mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailures, gotFailures); diff != "" {
+				t.Errorf("failures mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFanOutResult_FailureSummary(t *testing.T) {
+	res := &FanOutResult{
+		Responses: []FanOutResponse{{Target: "http://a.com/search"}},
+		Failures: []*ProxyTaskError{
+			{Target: "http://b.com/search", StatusCode: http.StatusInternalServerError, Err: errors.New("unexpected status code 500")},
+			{Target: "http://c.com/search", Err: errors.New("connection refused")},
+		},
+	}
+
+	got := res.FailureSummary()
+	want := "2 of 3 targets failed: http://b.com/search: unexpected status code 500; http://c.com/search: connection refused"
+	if got != want {
+		t.Errorf("FailureSummary() = %q, want %q", got, want)
+	}
+	if got := (&FanOutResult{}).FailureSummary(); got != "" {
+		t.Errorf("FailureSummary() without failures = %q, want empty", got)
+	}
+}
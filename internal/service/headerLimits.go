@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// HeaderLimitPolicy decides what the gateway does with a request whose headers exceed the limits
+// of the headers forwarded to each target.
+type HeaderLimitPolicy string
+
+const (
+	// HeaderLimitPolicyReject fails the request without proxying it to any target.
+	HeaderLimitPolicyReject HeaderLimitPolicy = "REJECT"
+	// HeaderLimitPolicyTruncate drops headers until the rest are within the limits.
+	HeaderLimitPolicyTruncate HeaderLimitPolicy = "TRUNCATE"
+)
+
+// Valid reports whether p is a known policy. The empty policy is valid and means REJECT.
+func (p HeaderLimitPolicy) Valid() bool {
+	switch p {
+	case "", HeaderLimitPolicyReject, HeaderLimitPolicyTruncate:
+		return true
+	}
+	return false
+}
+
+// ErrHeadersTooLarge is returned for a request whose headers exceed the forwarded header limits under the REJECT policy.
+var ErrHeadersTooLarge = errors.New("forwarded headers exceed the configured limits")
+
+// HeaderLimits bounds the headers of a request forwarded to each target of its fan-out.
+type HeaderLimits struct {
+	MaxBytes int               `yaml:"maxBytes"` // Total size of the names and values, 0 means no limit.
+	MaxCount int               `yaml:"maxCount"` // Number of header values, 0 means no limit.
+	Policy   HeaderLimitPolicy `yaml:"policy"`   // REJECT or TRUNCATE, defaults to REJECT.
+}
+
+// Validate returns an error naming the first invalid setting.
+func (l HeaderLimits) Validate() error {
+	if l.MaxBytes < 0 {
+		return fmt.Errorf("maxBytes %d must not be negative", l.MaxBytes)
+	}
+	if l.MaxCount < 0 {
+		return fmt.Errorf("maxCount %d must not be negative", l.MaxCount)
+	}
+	if !l.Policy.Valid() {
+		return fmt.Errorf("policy %q must be one of REJECT, TRUNCATE", l.Policy)
+	}
+	return nil
+}
+
+// headerSize returns the number of values of the header name and their total size with the name.
+func headerSize(name string, values []string) (count, size int) {
+	for _, v := range values {
+		size += len(name) + len(v)
+	}
+	return len(values), size
+}
+
+// within reports whether count values of the given total size are within l.
+func (l HeaderLimits) within(count, size int) bool {
+	return (l.MaxCount <= 0 || count <= l.MaxCount) && (l.MaxBytes <= 0 || size <= l.MaxBytes)
+}
+
+// limitHeaders returns h within l. Under TRUNCATE, the protected headers are always kept and the
+// others are kept in name order while they fit, whole headers being dropped. It returns the names of
+// the dropped headers. Under REJECT, headers exceeding l return ErrHeadersTooLarge.
+func limitHeaders(h http.Header, l HeaderLimits, protected []string) (http.Header, []string, error) {
+	if l.MaxBytes <= 0 && l.MaxCount <= 0 {
+		return h, nil, nil
+	}
+	var count, size int
+	for name, values := range h {
+		c, s := headerSize(name, values)
+		count, size = count+c, size+s
+	}
+	if l.within(count, size) {
+		return h, nil, nil
+	}
+	if l.Policy != HeaderLimitPolicyTruncate {
+		return nil, nil, fmt.Errorf("%w: %d headers of %d bytes (max %d headers of %d bytes)", ErrHeadersTooLarge, count, size, l.MaxCount, l.MaxBytes)
+	}
+
+	kept := make(http.Header, len(h))
+	count, size = 0, 0
+	for _, name := range protected {
+		name = http.CanonicalHeaderKey(name)
+		if values, ok := h[name]; ok {
+			c, s := headerSize(name, values)
+			kept[name] = values
+			count, size = count+c, size+s
+		}
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		if _, ok := kept[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var dropped []string
+	for _, name := range names {
+		c, s := headerSize(name, h[name])
+		if !l.within(count+c, size+s) {
+			dropped = append(dropped, name)
+			continue
+		}
+		kept[name] = h[name]
+		count, size = count+c, size+s
+	}
+	return kept, dropped, nil
+}
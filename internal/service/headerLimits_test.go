@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeaderLimits_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  HeaderLimits
+		wantErr string
+	}{
+		{name: "no limits", limits: HeaderLimits{}},
+		{name: "valid", limits: HeaderLimits{MaxBytes: 1024, MaxCount: 10, Policy: HeaderLimitPolicyTruncate}},
+		{name: "negative maxBytes", limits: HeaderLimits{MaxBytes: -1}, wantErr: "maxBytes -1 must not be negative"},
+		{name: "negative maxCount", limits: HeaderLimits{MaxCount: -1}, wantErr: "maxCount -1 must not be negative"},
+		{name: "unknown policy", limits: HeaderLimits{Policy: "DROP"}, wantErr: `policy "DROP" must be one of REJECT, TRUNCATE`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLimitHeaders(t *testing.T) {
+	h := http.Header{
+		"Authorization": {"sig"},        // 16 bytes
+		"X-A":           {"aaaaaaaaaa"}, // 13 bytes
+		"X-B":           {"bbbbbbbbbb"}, // 13 bytes
+		"X-C":           {"c1", "c2"},   // 10 bytes
+	}
+	protected := []string{"authorization"}
+
+	tests := []struct {
+		name        string
+		limits      HeaderLimits
+		want        http.Header
+		wantDropped []string
+		wantErr     error
+	}{
+		{name: "no limits", limits: HeaderLimits{}, want: h},
+		{name: "within limits", limits: HeaderLimits{MaxBytes: 52, MaxCount: 5}, want: h},
+		{name: "too many bytes rejected", limits: HeaderLimits{MaxBytes: 51}, wantErr: ErrHeadersTooLarge},
+		{name: "too many values rejected", limits: HeaderLimits{MaxCount: 4, Policy: HeaderLimitPolicyReject}, wantErr: ErrHeadersTooLarge},
+		{
+			name:        "bytes truncated in name order",
+			limits:      HeaderLimits{MaxBytes: 40, Policy: HeaderLimitPolicyTruncate},
+			want:        http.Header{"Authorization": {"sig"}, "X-A": {"aaaaaaaaaa"}, "X-C": {"c1", "c2"}},
+			wantDropped: []string{"X-B"},
+		},
+		{
+			name:        "count truncated keeping protected headers",
+			limits:      HeaderLimits{MaxCount: 2, Policy: HeaderLimitPolicyTruncate},
+			want:        http.Header{"Authorization": {"sig"}, "X-A": {"aaaaaaaaaa"}},
+			wantDropped: []string{"X-B", "X-C"},
+		},
+		{
+			name:        "protected headers kept beyond the limits",
+			limits:      HeaderLimits{MaxBytes: 1, Policy: HeaderLimitPolicyTruncate},
+			want:        http.Header{"Authorization": {"sig"}},
+			wantDropped: []string{"X-A", "X-B", "X-C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped, err := limitHeaders(h, tt.limits, protected)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("limitHeaders() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("limitHeaders() headers mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDropped, dropped); diff != "" {
+				t.Errorf("limitHeaders() dropped mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultKeyCacheRefreshes is the default number of signing keys refreshed at the same time.
+const DefaultKeyCacheRefreshes = 4
+
+// KeyCacheConfig configures the cache of participants' signing keys used to validate transactions.
+type KeyCacheConfig struct {
+	MaxAge                 time.Duration `yaml:"maxAge"`                 // How long a key is used before it is looked up again, 0 disables the cache.
+	RefreshBefore          time.Duration `yaml:"refreshBefore"`          // Keys used within this long of their expiry are refreshed in the background, 0 disables refreshing.
+	MaxConcurrentRefreshes int           `yaml:"maxConcurrentRefreshes"` // Defaults to DefaultKeyCacheRefreshes.
+}
+
+// Validate returns an error naming the first invalid setting.
+func (c KeyCacheConfig) Validate() error {
+	if c.MaxAge < 0 {
+		return fmt.Errorf("maxAge %s must not be negative", c.MaxAge)
+	}
+	if c.RefreshBefore < 0 || (c.MaxAge > 0 && c.RefreshBefore >= c.MaxAge) {
+		return fmt.Errorf("refreshBefore %s must not be negative and must be less than maxAge %s", c.RefreshBefore, c.MaxAge)
+	}
+	if c.MaxConcurrentRefreshes < 0 {
+		return fmt.Errorf("maxConcurrentRefreshes %d must not be negative", c.MaxConcurrentRefreshes)
+	}
+	return nil
+}
+
+// cachedKey is a signing key and when it must be looked up again.
+type cachedKey struct {
+	key        string
+	expiry     time.Time
+	refreshing bool
+}
+
+// signingKeyCache caches the signing keys of participants. Keys expire after their max age, but a key
+// used shortly before it expires is refreshed in the background so that hot keys never expire.
+type signingKeyCache struct {
+	km            npKeyProvider
+	maxAge        time.Duration
+	refreshBefore time.Duration
+	refreshes     chan struct{} // Bounds the concurrent background refreshes.
+	now           func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*cachedKey
+	wg   sync.WaitGroup // Tracks background refreshes.
+}
+
+// newSigningKeyCache creates a cache of the keys of km configured by cfg.
+func newSigningKeyCache(km npKeyProvider, cfg KeyCacheConfig) *signingKeyCache {
+	n := cfg.MaxConcurrentRefreshes
+	if n <= 0 {
+		n = DefaultKeyCacheRefreshes
+	}
+	return &signingKeyCache{
+		km:            km,
+		maxAge:        cfg.MaxAge,
+		refreshBefore: cfg.RefreshBefore,
+		refreshes:     make(chan struct{}, n),
+		now:           time.Now,
+		keys:          make(map[string]*cachedKey),
+	}
+}
+
+// get returns the signing key of subscriberID's keyID, looking it up if it is not cached or has expired.
+func (c *signingKeyCache) get(ctx context.Context, subscriberID, keyID string) (string, error) {
+	id := subscriberID + "|" + keyID
+	c.mu.Lock()
+	now := c.now()
+	if k, ok := c.keys[id]; ok && now.Before(k.expiry) {
+		key := k.key
+		if c.refreshBefore > 0 && !k.refreshing && !now.Before(k.expiry.Add(-c.refreshBefore)) {
+			c.startRefresh(ctx, id, k, subscriberID, keyID)
+		}
+		c.mu.Unlock()
+		return key, nil
+	}
+	delete(c.keys, id)
+	c.mu.Unlock()
+
+	key, _, err := c.km.LookupNPKeys(ctx, subscriberID, keyID)
+	if err != nil {
+		return "", err
+	}
+	c.store(id, key)
+	return key, nil
+}
+
+// store caches key under id for the max age.
+func (c *signingKeyCache) store(id, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[id] = &cachedKey{key: key, expiry: c.now().Add(c.maxAge)}
+}
+
+// startRefresh looks k up again in the background, unless the maximum number of refreshes
+// are already running, in which case k expires normally. The caller must hold c.mu.
+func (c *signingKeyCache) startRefresh(ctx context.Context, id string, k *cachedKey, subscriberID, keyID string) {
+	select {
+	case c.refreshes <- struct{}{}:
+	default:
+		slog.DebugContext(ctx, "signingKeyCache: Too many refreshes in progress, key will expire", "subscriber_id", subscriberID, "key_id", keyID)
+		return
+	}
+	k.refreshing = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() { <-c.refreshes }()
+		// The refresh outlives the request that triggered it.
+		key, _, err := c.km.LookupNPKeys(context.WithoutCancel(ctx), subscriberID, keyID)
+		if err != nil {
+			slog.WarnContext(ctx, "signingKeyCache: Failed to refresh signing key, it will expire", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+			return
+		}
+		c.store(id, key)
+		slog.DebugContext(ctx, "signingKeyCache: Refreshed signing key", "subscriber_id", subscriberID, "key_id", keyID)
+	}()
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingNPKeyProvider is a mock npKeyProvider that counts lookups per subscriber.
+type countingNPKeyProvider struct {
+	mu      sync.Mutex
+	key     string
+	err     error
+	calls   map[string]int
+	release chan struct{} // If not nil, lookups block until it is closed.
+}
+
+func (m *countingNPKeyProvider) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	m.mu.Lock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[subscriberID]++
+	key, err, release := m.key, m.err, m.release
+	m.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	return key, "", err
+}
+
+func (m *countingNPKeyProvider) callCount(subscriberID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[subscriberID]
+}
+
+func (m *countingNPKeyProvider) setKey(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.key = key
+}
+
+func TestKeyCacheConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     KeyCacheConfig
+		wantErr string
+	}{
+		{name: "disabled", cfg: KeyCacheConfig{}},
+		{name: "valid", cfg: KeyCacheConfig{MaxAge: time.Hour, RefreshBefore: time.Minute, MaxConcurrentRefreshes: 2}},
+		{name: "negative maxAge", cfg: KeyCacheConfig{MaxAge: -time.Second}, wantErr: "maxAge -1s must not be negative"},
+		{name: "negative refreshBefore", cfg: KeyCacheConfig{MaxAge: time.Hour, RefreshBefore: -time.Second}, wantErr: "refreshBefore -1s must not be negative and must be less than maxAge 1h0m0s"},
+		{name: "refreshBefore not less than maxAge", cfg: KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: time.Minute}, wantErr: "refreshBefore 1m0s must not be negative and must be less than maxAge 1m0s"},
+		{name: "negative maxConcurrentRefreshes", cfg: KeyCacheConfig{MaxConcurrentRefreshes: -1}, wantErr: "maxConcurrentRefreshes -1 must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSigningKeyCache_HotKeyRefreshedBeforeExpiry(t *testing.T) {
+	km := &countingNPKeyProvider{key: "key-v1"}
+	c := newSigningKeyCache(km, KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: 10 * time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := c.get(ctx, "hot", "k1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	km.setKey("key-v2")
+
+	// Used within refreshBefore of its expiry: served from the cache and refreshed in the background.
+	now = now.Add(55 * time.Second)
+	got, err := c.get(ctx, "hot", "k1")
+	if err != nil || got != "key-v1" {
+		t.Fatalf("get() = %q, %v, want cached key-v1", got, err)
+	}
+	c.wg.Wait()
+	if n := km.callCount("hot"); n != 2 {
+		t.Fatalf("lookups after refresh = %d, want 2", n)
+	}
+
+	// Past the original expiry the refreshed key is served without a lookup.
+	now = now.Add(10 * time.Second)
+	got, err = c.get(ctx, "hot", "k1")
+	if err != nil || got != "key-v2" {
+		t.Errorf("get() after original expiry = %q, %v, want refreshed key-v2", got, err)
+	}
+	if n := km.callCount("hot"); n != 2 {
+		t.Errorf("lookups after original expiry = %d, want 2", n)
+	}
+}
+
+func TestSigningKeyCache_ColdKeyExpires(t *testing.T) {
+	km := &countingNPKeyProvider{key: "key-v1"}
+	c := newSigningKeyCache(km, KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: 10 * time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := c.get(ctx, "cold", "k1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	// Used before the refresh window: served from the cache without a refresh.
+	now = now.Add(30 * time.Second)
+	if _, err := c.get(ctx, "cold", "k1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	c.wg.Wait()
+	if n := km.callCount("cold"); n != 1 {
+		t.Fatalf("lookups before expiry = %d, want 1", n)
+	}
+
+	km.setKey("key-v2")
+	now = now.Add(30 * time.Second)
+	got, err := c.get(ctx, "cold", "k1")
+	if err != nil || got != "key-v2" {
+		t.Errorf("get() after expiry = %q, %v, want key-v2", got, err)
+	}
+	if n := km.callCount("cold"); n != 2 {
+		t.Errorf("lookups after expiry = %d, want 2", n)
+	}
+}
+
+func TestSigningKeyCache_RefreshConcurrencyBounded(t *testing.T) {
+	km := &countingNPKeyProvider{key: "key"}
+	c := newSigningKeyCache(km, KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: 10 * time.Second, MaxConcurrentRefreshes: 1})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, sub := range []string{"a", "b"} {
+		if _, err := c.get(ctx, sub, "k1"); err != nil {
+			t.Fatalf("get(%s) error = %v", sub, err)
+		}
+	}
+
+	release := make(chan struct{})
+	km.mu.Lock()
+	km.release = release
+	km.mu.Unlock()
+	now = now.Add(55 * time.Second)
+	for _, sub := range []string{"a", "b", "a"} {
+		if _, err := c.get(ctx, sub, "k1"); err != nil {
+			t.Fatalf("get(%s) error = %v", sub, err)
+		}
+	}
+	close(release)
+	c.wg.Wait()
+
+	if got := km.callCount("a") + km.callCount("b"); got != 3 {
+		t.Errorf("lookups = %d, want 3 (one refresh at a time)", got)
+	}
+}
+
+func TestSigningKeyCache_FailedRefreshExpires(t *testing.T) {
+	km := &countingNPKeyProvider{key: "key-v1"}
+	c := newSigningKeyCache(km, KeyCacheConfig{MaxAge: time.Minute, RefreshBefore: 10 * time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := c.get(ctx, "sub", "k1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	km.mu.Lock()
+	km.err = errors.New("registry down")
+	km.mu.Unlock()
+	now = now.Add(55 * time.Second)
+	if got, err := c.get(ctx, "sub", "k1"); err != nil || got != "key-v1" {
+		t.Fatalf("get() = %q, %v, want cached key-v1", got, err)
+	}
+	c.wg.Wait()
+
+	now = now.Add(10 * time.Second)
+	if _, err := c.get(ctx, "sub", "k1"); err == nil {
+		t.Error("get() after expiry error = nil, want the lookup error")
+	}
+}
+
+func TestTxnSignValidator_SetKeyCache(t *testing.T) {
+	km := &countingNPKeyProvider{key: "key"}
+	v, err := NewTxnSignValidator(&mockSignValidator{}, km)
+	if err != nil {
+		t.Fatalf("NewTxnSignValidator() error = %v", err)
+	}
+	v.SetKeyCache(KeyCacheConfig{MaxAge: time.Hour})
+	authHeader := `Signature keyId="sub|k1|ed25519",algorithm="ed25519"`
+
+	for i := 0; i < 3; i++ {
+		if authErr := v.Validate(context.Background(), []byte(`{}`), authHeader, ""); authErr != nil {
+			t.Fatalf("Validate() error = %v", authErr)
+		}
+	}
+	if n := km.callCount("sub"); n != 1 {
+		t.Errorf("lookups = %d, want 1", n)
+	}
+}
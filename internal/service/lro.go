@@ -25,6 +25,7 @@ import (
 // lroRepository defines the interface for database operations related to LROs.
 type lroRepository interface {
 	InsertOperation(ctx context.Context, lro *model.LRO) (*model.LRO, error)
+	InsertOperationWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error)
 	GetOperation(ctx context.Context, id string) (*model.LRO, error)
 }
 
@@ -53,6 +54,19 @@ func (s *lroService) Create(ctx context.Context, lro *model.LRO) (*model.LRO, er
 	return createdLRO, nil
 }
 
+// CreateWithinLimit persists a new LRO record if subscriberID has fewer than max pending operations.
+// Otherwise it returns an error wrapping repository.ErrPendingOperationLimit.
+func (s *lroService) CreateWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error) {
+	slog.InfoContext(ctx, "LROService: Creating new LRO within pending limit", "operation_id", lro.OperationID, "type", lro.Type, "subscriber_id", subscriberID, "max_pending", max)
+
+	createdLRO, err := s.repo.InsertOperationWithinLimit(ctx, lro, subscriberID, max)
+	if err != nil {
+		slog.ErrorContext(ctx, "LROService: Failed to insert LRO into repository", "error", err, "operation_id", lro.OperationID)
+		return nil, err
+	}
+	return createdLRO, nil
+}
+
 // Get retrieves an LRO by its ID.
 func (s *lroService) Get(ctx context.Context, id string) (*model.LRO, error) {
 	slog.InfoContext(ctx, "LROService: Getting LRO", "operation_id", id)
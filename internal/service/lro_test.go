@@ -17,6 +17,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -37,6 +38,11 @@ func (m *mockLRORepository) InsertOperation(ctx context.Context, lro *model.LRO)
 	return m.lro, m.err
 }
 
+// InsertOperationWithinLimit mocks the database insertion of an LRO within a pending limit.
+func (m *mockLRORepository) InsertOperationWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error) {
+	return m.lro, m.err
+}
+
 func (m *mockLRORepository) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
 	return m.lro, m.err
 }
@@ -96,6 +102,34 @@ func TestLROService_Create_Success(t *testing.T) {
 	}
 }
 
+func TestLROService_CreateWithinLimit(t *testing.T) {
+	ctx := context.Background()
+	inputLRO := &model.LRO{OperationID: "op-limit", Type: model.OperationTypeCreateSubscription}
+	limitErr := fmt.Errorf("%w: subscriber_id 'sub' has 2 pending operations (max 2)", repository.ErrPendingOperationLimit)
+
+	tests := []struct {
+		name    string
+		repo    *mockLRORepository
+		want    *model.LRO
+		wantErr error
+	}{
+		{name: "under the limit", repo: &mockLRORepository{lro: inputLRO}, want: inputLRO},
+		{name: "at the limit", repo: &mockLRORepository{err: limitErr}, wantErr: repository.ErrPendingOperationLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := NewLROService(tt.repo)
+			got, err := service.CreateWithinLimit(ctx, inputLRO, "sub", 2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateWithinLimit() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CreateWithinLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLROService_Create_Error(t *testing.T) {
 	ctx := context.Background()
 	inputLRO := &model.LRO{
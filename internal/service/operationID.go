@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// OperationIDFormat is the format the operation id of an admin action must have.
+type OperationIDFormat string
+
+const (
+	// OperationIDFormatAny accepts any id of printable characters without spaces that fits
+	// the operation_id column. It is the default, since operation ids are the message ids
+	// chosen by subscribers.
+	OperationIDFormatAny OperationIDFormat = "ANY"
+	// OperationIDFormatUUID accepts only UUIDs, for networks whose subscribers send UUID message ids.
+	OperationIDFormatUUID OperationIDFormat = "UUID"
+)
+
+// maxOperationIDLen is the size of the operation_id column.
+const maxOperationIDLen = 255
+
+// ErrInvalidOperationID is returned for an admin action whose operation id is empty or malformed.
+var ErrInvalidOperationID = errors.New("invalid operation id")
+
+// Valid reports whether f is a known format. The empty format is valid and means ANY.
+func (f OperationIDFormat) Valid() bool {
+	switch f {
+	case "", OperationIDFormatAny, OperationIDFormatUUID:
+		return true
+	}
+	return false
+}
+
+// validate checks that id is a well-formed operation id of format f.
+func (f OperationIDFormat) validate(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: OperationID cannot be empty", ErrInvalidOperationID)
+	}
+	if len(id) > maxOperationIDLen {
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidOperationID, maxOperationIDLen)
+	}
+	for _, r := range id {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("%w: %q contains a space or non-printable character", ErrInvalidOperationID, id)
+		}
+	}
+	if f == OperationIDFormatUUID {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: %q is not a UUID", ErrInvalidOperationID, id)
+		}
+	}
+	return nil
+}
@@ -23,8 +23,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -36,6 +38,12 @@ type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// metricsRecorder records counters and histograms.
+type metricsRecorder interface {
+	Inc(name string)
+	Observe(name string, v float64)
+}
+
 // RetryConfig holds configuration for the retryable HTTP client.
 type RetryConfig struct {
 	RetryMax            int           `yaml:"retryMax"`            // Maximum number of retries.
@@ -46,13 +54,39 @@ type RetryConfig struct {
 	MaxIdleConnsPerHost int           `yaml:"maxIdleConnsPerHost"` // Maximum idle connections per host.
 	MaxConnsPerHost     int           `yaml:"maxConnsPerHost"`     // Maximum connections per host.
 	IdleConnTimeout     time.Duration `yaml:"idleConnTimeout"`     // Timeout for idle connections.
+	// HostOverrides optionally maps participant hostnames to static IPs, bypassing DNS for the listed hosts.
+	HostOverrides map[string]string `yaml:"hostOverrides"`
+}
+
+// ProxyTaskError is the structured failure record of a proxy task.
+// It carries the fully resolved target URL that was attempted, to help diagnose malformed targets.
+type ProxyTaskError struct {
+	Target     string // Fully resolved target URL of the task.
+	StatusCode int    // HTTP status code of the response, or 0 if no response was received.
+	Err        error  // Underlying cause of the failure.
+}
+
+// Error implements the error interface.
+func (e *ProxyTaskError) Error() string {
+	return fmt.Sprintf("proxy task to %s failed: %v", e.Target, e.Err)
+}
+
+// Unwrap returns the underlying cause of the failure.
+func (e *ProxyTaskError) Unwrap() error {
+	return e.Err
 }
 
 // proxyTaskProcessor makes HTTP POST calls for asynchronous proxy tasks.
 type proxyTaskProcessor struct {
-	client httpClient // Changed from *http.Client to httpClient interface
-	auth   authGen
-	keyID  string
+	client  httpClient // Changed from *http.Client to httpClient interface
+	auth    authGen
+	keyID   string
+	archive taskArchive
+	// registry and expiredPolicy guard against proxying to expired subscriptions, see SetExpiredSubscriptionGuard.
+	registry      lookupClient
+	expiredPolicy ExpiredSubscriptionPolicy
+	limiter       *callbackLimiter // Caps in-flight calls per target host, nil means no limit.
+	metrics       metricsRecorder  // Records task outcomes and call durations, may be nil.
 }
 
 // NewProxyTaskProcessor creates a new proxyTaskProcessor.
@@ -85,6 +119,10 @@ func NewProxyTaskProcessor(auth authGen, keyID string, retryCfg RetryConfig) (*p
 	if retryCfg.IdleConnTimeout > 0 {
 		transport.IdleConnTimeout = retryCfg.IdleConnTimeout
 	}
+	if err := client.ApplyHostOverrides(transport, retryCfg.HostOverrides); err != nil {
+		slog.Error("NewProxyTaskProcessor: invalid host overrides", "error", err)
+		return nil, fmt.Errorf("invalid hostOverrides in RetryConfig: %w", err)
+	}
 
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = retryCfg.RetryMax
@@ -98,7 +136,49 @@ func NewProxyTaskProcessor(auth authGen, keyID string, retryCfg RetryConfig) (*p
 		Timeout:   retryCfg.Timeout,
 	}
 
-	return &proxyTaskProcessor{client: retryClient.StandardClient(), auth: auth, keyID: keyID}, nil
+	return &proxyTaskProcessor{
+		client:  retryClient.StandardClient(),
+		auth:    auth,
+		keyID:   keyID,
+		archive: noopTaskArchive{},
+	}, nil
+}
+
+// SetTaskArchive records every proxied payload and its outcome in archive, for audit.
+func (p *proxyTaskProcessor) SetTaskArchive(archive taskArchive) {
+	p.archive = archive
+}
+
+// SetExpiredSubscriptionGuard checks the subscription of the target subscriber in registry
+// before proxying a task addressed to a known subscriber ID. With REJECT, tasks to a subscriber
+// whose subscriptions have all expired fail with ErrSubscriptionExpired; with WARN they are
+// logged and proxied anyway. Lookup failures are logged and do not block the task.
+func (p *proxyTaskProcessor) SetExpiredSubscriptionGuard(registry lookupClient, policy ExpiredSubscriptionPolicy) {
+	p.registry = registry
+	p.expiredPolicy = policy
+}
+
+// SetCallbackLimiter caps the number of simultaneous in-flight calls to each target host with limiter.
+// Tasks to a host at its limit wait for a call to complete.
+func (p *proxyTaskProcessor) SetCallbackLimiter(limiter *callbackLimiter) {
+	p.limiter = limiter
+}
+
+// SetMetrics records the outcome of every task and the duration of every call in m.
+func (p *proxyTaskProcessor) SetMetrics(m metricsRecorder) {
+	p.metrics = m
+}
+
+// recordOutcome counts a processed task as succeeded or failed.
+func (p *proxyTaskProcessor) recordOutcome(err error) {
+	if p.metrics == nil {
+		return
+	}
+	if err != nil {
+		p.metrics.Inc("proxy_tasks_total{result=failure}")
+		return
+	}
+	p.metrics.Inc("proxy_tasks_total{result=success}")
 }
 
 // validateTask checks if the AsyncTask is valid for processing.
@@ -136,7 +216,7 @@ func (p *proxyTaskProcessor) httpReq(ctx context.Context, task *model.AsyncTask)
 		return req, nil
 	}
 	slog.InfoContext(ctx, "ProxyTaskProcessor: Generating auth header", "target", task.Target.String(), "key_id", p.keyID)
-	authHeader, err := p.auth.AuthHeader(ctx, task.Body, p.keyID)
+	authHeader, err := p.auth.RequestAuthHeader(ctx, task.Body, p.keyID, task.Headers.Get(model.RequestIDHeader))
 	if err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to generate auth header", "error", err)
 		return nil, fmt.Errorf("failed to generate auth header: %w", err)
@@ -146,13 +226,14 @@ func (p *proxyTaskProcessor) httpReq(ctx context.Context, task *model.AsyncTask)
 }
 
 // proxy sends the HTTP request, reads, and parses the response.
-func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error {
+// It returns the HTTP status code of the response, or 0 if no response was received, and the ACK response.
+func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) (int, *model.TxnResponse, error) {
 	targetURLStr := req.URL.String()
 	resp, err := p.client.Do(req)
 
 	if err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: HTTP request failed", "error", err, "target", targetURLStr)
-		return fmt.Errorf("HTTP request to %s failed: %w", targetURLStr, err)
+		return 0, nil, fmt.Errorf("HTTP request to %s failed: %w", targetURLStr, err)
 	}
 	defer resp.Body.Close()
 
@@ -161,19 +242,19 @@ func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error
 	if resp.StatusCode != http.StatusOK {
 		respBodyBytes, _ := io.ReadAll(resp.Body) // Read body for error context
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: Unexpected HTTP status code", "target", targetURLStr, "status_code", resp.StatusCode, "response_body", string(respBodyBytes))
-		return fmt.Errorf("unexpected status code %d from %s. Body: %s", resp.StatusCode, targetURLStr, string(respBodyBytes))
+		return resp.StatusCode, nil, fmt.Errorf("unexpected status code %d from %s. Body: %s", resp.StatusCode, targetURLStr, string(respBodyBytes))
 	}
 
 	respBodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to read response body", "error", err, "target", targetURLStr)
-		return fmt.Errorf("failed to read response body from %s: %w", targetURLStr, err)
+		return resp.StatusCode, nil, fmt.Errorf("failed to read response body from %s: %w", targetURLStr, err)
 	}
 
 	var txnResponse model.TxnResponse
 	if err := json.Unmarshal(respBodyBytes, &txnResponse); err != nil {
 		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to unmarshal response body into TxnResponse", "error", err, "target", targetURLStr, "response_body", string(respBodyBytes))
-		return fmt.Errorf("failed to unmarshal response body from %s into model.TxnResponse: %w. Body: %s", targetURLStr, err, string(respBodyBytes))
+		return resp.StatusCode, nil, fmt.Errorf("failed to unmarshal response body from %s into model.TxnResponse: %w. Body: %s", targetURLStr, err, string(respBodyBytes))
 	}
 	if txnResponse.Message.Ack.Status != model.StatusACK {
 		slog.WarnContext(ctx, "ProxyTaskProcessor: Response status is not ACK", "target", targetURLStr, "ack_status", txnResponse.Message.Ack.Status, "response_message", txnResponse.Message)
@@ -181,29 +262,143 @@ func (p *proxyTaskProcessor) proxy(ctx context.Context, req *http.Request) error
 		if txnResponse.Message.Error != nil {
 			errMsg = fmt.Sprintf("response status is NACK from %s: Code=%s, Message=%s", targetURLStr, txnResponse.Message.Error.Code, txnResponse.Message.Error.Message)
 		}
-		return errors.New(errMsg)
+		return resp.StatusCode, nil, errors.New(errMsg)
 	}
-	return nil
+	return resp.StatusCode, &txnResponse, nil
 }
 
 // Process handles the given asynchronous task by making an HTTP POST request
 // to the task's target URL. It expects a 200 OK response with a model.TxnResponse
-// body indicating an ACK status.
-func (p *proxyTaskProcessor) Process(ctx context.Context, task *model.AsyncTask) error {
+// body indicating an ACK status. Request failures are returned as a *ProxyTaskError.
+// A task whose target host is at its callback limit fails with ErrCallbackLimitReached
+// instead of holding the worker, for the task queue to queue it again.
+func (p *proxyTaskProcessor) Process(ctx context.Context, task *model.AsyncTask) (err error) {
+	defer func() {
+		if !errors.Is(err, ErrCallbackLimitReached) {
+			p.recordOutcome(err)
+		}
+	}()
+	_, err = p.process(ctx, task, false)
+	return err
+}
+
+// process proxies task like Process and returns the ACK response of its target.
+// If wait is set, it waits for a callback slot of the target host rather than failing.
+func (p *proxyTaskProcessor) process(ctx context.Context, task *model.AsyncTask, wait bool) (*model.TxnResponse, error) {
 	if err := p.validateTask(ctx, task); err != nil {
-		return err
+		return nil, err
 	}
 	slog.InfoContext(ctx, "ProxyTaskProcessor: Processing task", "target", task.Target.String(), "type", task.Type)
 
+	if err := p.checkTargetSubscription(ctx, task); err != nil {
+		return nil, &ProxyTaskError{Target: task.Target.String(), Err: err}
+	}
+
 	req, err := p.httpReq(ctx, task)
 	if err != nil {
-		return err
+		return nil, &ProxyTaskError{Target: task.Target.String(), Err: err}
 	}
 
-	if err := p.proxy(ctx, req); err != nil {
-		return err
+	release := func() {}
+	if p.limiter != nil && wait {
+		if release, err = p.limiter.acquire(ctx, req.URL.Host); err != nil {
+			slog.WarnContext(ctx, "ProxyTaskProcessor: Stopped waiting for a callback slot", "error", err, "target", req.URL.String())
+			return nil, &ProxyTaskError{Target: req.URL.String(), Err: fmt.Errorf("failed to wait for a callback slot: %w", err)}
+		}
+	} else if p.limiter != nil {
+		if release, err = p.limiter.tryAcquire(req.URL.Host); err != nil {
+			slog.DebugContext(ctx, "ProxyTaskProcessor: No callback slot available, deferring task", "target", req.URL.String())
+			return nil, &ProxyTaskError{Target: req.URL.String(), Err: err}
+		}
+	}
+	start := time.Now()
+	statusCode, resp, err := p.proxy(ctx, req)
+	release()
+	if p.metrics != nil {
+		p.metrics.Observe("proxy_call_duration_seconds", time.Since(start).Seconds())
+	}
+	p.archiveTask(ctx, task, req, statusCode, err)
+	if err != nil {
+		return nil, &ProxyTaskError{Target: req.URL.String(), StatusCode: statusCode, Err: err}
 	}
 
 	slog.InfoContext(ctx, "ProxyTaskProcessor: Task processed successfully and received ACK", "target", task.Target.String())
+	return resp, nil
+}
+
+// targetSubscriber returns the subscriber a task is addressed to: the BAP for callbacks, the BPP otherwise.
+// The BAP of an on_search is the one serving the location of its context, which the task queue sets to
+// the default on_search location when the BAP sent none.
+func targetSubscriber(reqCtx *model.Context) model.Subscriber {
+	if reqCtx.Action == "on_search" {
+		return model.Subscriber{SubscriberID: reqCtx.BapID, Type: model.RoleBAP, Domain: reqCtx.Domain, Location: reqCtx.Location}
+	}
+	if strings.HasPrefix(reqCtx.Action, "on_") {
+		return model.Subscriber{SubscriberID: reqCtx.BapID, Type: model.RoleBAP, Domain: reqCtx.Domain}
+	}
+	return model.Subscriber{SubscriberID: reqCtx.BppID, Type: model.RoleBPP, Domain: reqCtx.Domain}
+}
+
+// checkTargetSubscription applies the expired subscription policy to the target of task.
+// Tasks without a target subscriber ID, such as lookup fan-outs, are not checked here
+// since the lookup processor has already checked the subscriptions they were created from.
+func (p *proxyTaskProcessor) checkTargetSubscription(ctx context.Context, task *model.AsyncTask) error {
+	if p.registry == nil || !p.expiredPolicy.enabled() {
+		return nil
+	}
+	target := targetSubscriber(&task.Context)
+	if target.SubscriberID == "" {
+		return nil
+	}
+	subs, err := p.registry.Lookup(ctx, &model.Subscription{Subscriber: target})
+	if err == nil && len(subs) == 0 && target.Location != nil {
+		// The subscriber is not subscribed for the location, e.g. it is not subscribed per location.
+		slog.DebugContext(ctx, "ProxyTaskProcessor: No subscription found for target in location, looking up without location", "subscriber_id", target.SubscriberID)
+		target.Location = nil
+		subs, err = p.registry.Lookup(ctx, &model.Subscription{Subscriber: target})
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "ProxyTaskProcessor: Failed to look up target subscription, skipping expiry check", "error", err, "subscriber_id", target.SubscriberID)
+		return nil
+	}
+	if len(subs) == 0 {
+		slog.DebugContext(ctx, "ProxyTaskProcessor: No subscription found for target, skipping expiry check", "subscriber_id", target.SubscriberID)
+		return nil
+	}
+	now := time.Now()
+	for i := range subs {
+		if !subscriptionExpired(&subs[i], now) {
+			return nil
+		}
+	}
+	if p.expiredPolicy == ExpiredSubscriptionPolicyReject {
+		slog.WarnContext(ctx, "ProxyTaskProcessor: Rejecting task to subscriber with expired subscription", "subscriber_id", target.SubscriberID, "valid_until", subs[0].ValidUntil)
+		return fmt.Errorf("%w: %s", ErrSubscriptionExpired, target.SubscriberID)
+	}
+	slog.WarnContext(ctx, "ProxyTaskProcessor: Proxying to subscriber with expired subscription", "subscriber_id", target.SubscriberID, "valid_until", subs[0].ValidUntil)
 	return nil
 }
+
+// archiveTask stores the audit record of a proxied task. Archive failures are logged
+// and do not fail the task, since the payload has already been delivered.
+func (p *proxyTaskProcessor) archiveTask(ctx context.Context, task *model.AsyncTask, req *http.Request, statusCode int, proxyErr error) {
+	if p.archive == nil {
+		return
+	}
+	rec := &ProxyTaskRecord{
+		TransactionID: task.Context.TransactionID,
+		MessageID:     task.Context.MessageID,
+		Action:        task.Context.Action,
+		Target:        req.URL.String(),
+		Headers:       redactHeaders(req.Header),
+		Body:          string(task.Body),
+		StatusCode:    statusCode,
+		ProcessedAt:   time.Now(),
+	}
+	if proxyErr != nil {
+		rec.Error = proxyErr.Error()
+	}
+	if err := p.archive.Archive(ctx, rec); err != nil {
+		slog.ErrorContext(ctx, "ProxyTaskProcessor: Failed to archive task", "error", err, "target", rec.Target, "message_id", rec.MessageID)
+	}
+}
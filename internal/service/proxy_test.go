@@ -27,6 +27,7 @@ import (
 
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-retryablehttp"
 )
 
@@ -95,6 +96,13 @@ func TestNewProxyTaskProcessor(t *testing.T) {
 			retryCfg: RetryConfig{},
 			wantErr:  "keyID cannot be empty",
 		},
+		{
+			name:     "invalid host overrides",
+			auth:     mockAuth,
+			keyID:    "test-key-id",
+			retryCfg: RetryConfig{HostOverrides: map[string]string{"bpp.example.com": "not-an-ip"}},
+			wantErr:  `invalid hostOverrides in RetryConfig: host override for "bpp.example.com": "not-an-ip" is not a valid IP address`,
+		},
 		{
 			name:  "full client configuration",
 			auth:  mockAuth,
@@ -226,6 +234,7 @@ func TestProxyTaskProcessor_httpReq(t *testing.T) {
 		authGenErr      error
 		wantAuthHeader  string
 		wantContentType string
+		wantRequestID   string
 		wantErr         string
 	}{
 		{
@@ -256,6 +265,13 @@ func TestProxyTaskProcessor_httpReq(t *testing.T) {
 			wantAuthHeader: "",
 			wantErr:        "failed to generate auth header: auth error",
 		},
+		{
+			name:            "success - signed for the request id",
+			task:            newTestAsyncTask("http://example.com/search", []byte(`{}`), http.Header{http.CanonicalHeaderKey(model.RequestIDHeader): []string{"req-1"}}),
+			wantAuthHeader:  "Signature test-auth",
+			wantContentType: "application/json",
+			wantRequestID:   "req-1",
+		},
 		{
 			name:            "nil task body, no Content-Type set",
 			task:            newTestAsyncTask("http://example.com/search", nil, make(http.Header)),
@@ -268,6 +284,7 @@ func TestProxyTaskProcessor_httpReq(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p.auth.(*mockAuthGen).err = tt.authGenErr // Set mock error
+			p.auth.(*mockAuthGen).gotRequestID = ""
 			req, err := p.httpReq(ctx, tt.task)
 
 			if tt.wantErr != "" {
@@ -290,6 +307,9 @@ func TestProxyTaskProcessor_httpReq(t *testing.T) {
 				if gotCT := req.Header.Get("Content-Type"); gotCT != tt.wantContentType {
 					t.Errorf("httpReq() Content-Type = %q, want %q", gotCT, tt.wantContentType)
 				}
+				if got := p.auth.(*mockAuthGen).gotRequestID; got != tt.wantRequestID {
+					t.Errorf("httpReq() signed for request id %q, want %q", got, tt.wantRequestID)
+				}
 			}
 		})
 	}
@@ -388,7 +408,7 @@ func TestProxyTaskProcessor_proxy(t *testing.T) {
 			mockClient := &mockHttpClient{}
 			tt.mockClient(mockClient)
 			p.client = mockClient
-			err := p.proxy(ctx, req)
+			_, _, err := p.proxy(ctx, req)
 			if tt.wantErr != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 					t.Errorf("proxy() error = %v, want error containing %q", err, tt.wantErr)
@@ -485,3 +505,309 @@ func TestProxyTaskProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyTaskProcessor_Process_FailureRecordsTarget(t *testing.T) {
+	ctx := context.Background()
+	base, _ := url.Parse("http://example.com/bpp/")
+	target := base.JoinPath("search")
+
+	tests := []struct {
+		name           string
+		headers        http.Header
+		mockClient     func(*mockHttpClient)
+		mockAuth       *mockAuthGen
+		wantStatusCode int
+	}{
+		{
+			name:     "auth header generation fails",
+			headers:  make(http.Header),
+			mockAuth: &mockAuthGen{err: errors.New("auth gen failed")},
+		},
+		{
+			name:    "network error",
+			headers: http.Header{model.AuthHeaderGateway: []string{"Auth test"}},
+			mockClient: func(m *mockHttpClient) {
+				m.doFunc = func(r *http.Request) (*http.Response, error) { return nil, errors.New("network unreachable") }
+			},
+			mockAuth: &mockAuthGen{},
+		},
+		{
+			name:    "non-200 status",
+			headers: http.Header{model.AuthHeaderGateway: []string{"Auth test"}},
+			mockClient: func(m *mockHttpClient) {
+				m.doFunc = func(r *http.Request) (*http.Response, error) {
+					return newMockHTTPResponse(http.StatusNotFound, `not found`), nil
+				}
+			},
+			mockAuth:       &mockAuthGen{},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:    "NACK response",
+			headers: http.Header{model.AuthHeaderGateway: []string{"Auth test"}},
+			mockClient: func(m *mockHttpClient) {
+				m.doFunc = func(r *http.Request) (*http.Response, error) {
+					return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"NACK"}}}`), nil
+				}
+			},
+			mockAuth:       &mockAuthGen{},
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHttpClient{}
+			if tt.mockClient != nil {
+				tt.mockClient(mockClient)
+			}
+			p := &proxyTaskProcessor{client: mockClient, auth: tt.mockAuth, keyID: "test-key-id"}
+
+			err := p.Process(ctx, newTestAsyncTask(target.String(), []byte(`{}`), tt.headers))
+
+			var taskErr *ProxyTaskError
+			if !errors.As(err, &taskErr) {
+				t.Fatalf("Process() error = %v, want *ProxyTaskError", err)
+			}
+			if taskErr.Target != "http://example.com/bpp/search" {
+				t.Errorf("ProxyTaskError.Target = %q, want %q", taskErr.Target, "http://example.com/bpp/search")
+			}
+			if taskErr.StatusCode != tt.wantStatusCode {
+				t.Errorf("ProxyTaskError.StatusCode = %d, want %d", taskErr.StatusCode, tt.wantStatusCode)
+			}
+			if !strings.Contains(err.Error(), "http://example.com/bpp/search") {
+				t.Errorf("Process() error = %q, want it to contain the resolved target", err.Error())
+			}
+		})
+	}
+}
+
+// mockMetricsRecorder is a mock implementation of the metricsRecorder interface.
+type mockMetricsRecorder struct {
+	counters     map[string]int
+	observations map[string]int
+}
+
+func (m *mockMetricsRecorder) Inc(name string) {
+	m.counters[name]++
+}
+
+func (m *mockMetricsRecorder) Observe(name string, v float64) {
+	m.observations[name]++
+}
+
+func TestProxyTaskProcessor_Process_Metrics(t *testing.T) {
+	tests := []struct {
+		name             string
+		task             *model.AsyncTask
+		status           int
+		wantCounters     map[string]int
+		wantObservations map[string]int
+	}{
+		{
+			name:             "success",
+			task:             newTestAsyncTask("http://example.com/search", []byte(`{}`), http.Header{model.AuthHeaderGateway: []string{"Auth test"}}),
+			status:           http.StatusOK,
+			wantCounters:     map[string]int{"proxy_tasks_total{result=success}": 1},
+			wantObservations: map[string]int{"proxy_call_duration_seconds": 1},
+		},
+		{
+			name:             "failed call",
+			task:             newTestAsyncTask("http://example.com/search", []byte(`{}`), http.Header{model.AuthHeaderGateway: []string{"Auth test"}}),
+			status:           http.StatusInternalServerError,
+			wantCounters:     map[string]int{"proxy_tasks_total{result=failure}": 1},
+			wantObservations: map[string]int{"proxy_call_duration_seconds": 1},
+		},
+		{
+			name:             "invalid task",
+			wantCounters:     map[string]int{"proxy_tasks_total{result=failure}": 1},
+			wantObservations: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(tt.status, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			m := &mockMetricsRecorder{counters: map[string]int{}, observations: map[string]int{}}
+			p := &proxyTaskProcessor{client: client, auth: &mockAuthGen{}, keyID: "test-key-id"}
+			p.SetMetrics(m)
+
+			p.Process(context.Background(), tt.task)
+
+			if diff := cmp.Diff(tt.wantCounters, m.counters); diff != "" {
+				t.Errorf("counters mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantObservations, m.observations); diff != "" {
+				t.Errorf("observations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_ExpiredTarget(t *testing.T) {
+	expired := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com"}, ValidUntil: time.Now().Add(-time.Hour)}
+	valid := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bpp.example.com"}, ValidUntil: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name     string
+		policy   ExpiredSubscriptionPolicy
+		bppID    string
+		lookup   *mockLookupClient
+		wantErr  error
+		wantSent bool
+	}{
+		{
+			name:    "reject expired target",
+			policy:  ExpiredSubscriptionPolicyReject,
+			bppID:   "bpp.example.com",
+			lookup:  &mockLookupClient{subscriptions: []model.Subscription{expired}},
+			wantErr: ErrSubscriptionExpired,
+		},
+		{
+			name:     "reject proceeds with valid target",
+			policy:   ExpiredSubscriptionPolicyReject,
+			bppID:    "bpp.example.com",
+			lookup:   &mockLookupClient{subscriptions: []model.Subscription{expired, valid}},
+			wantSent: true,
+		},
+		{
+			name:     "warn proceeds with expired target",
+			policy:   ExpiredSubscriptionPolicyWarn,
+			bppID:    "bpp.example.com",
+			lookup:   &mockLookupClient{subscriptions: []model.Subscription{expired}},
+			wantSent: true,
+		},
+		{
+			name:     "off does not check",
+			policy:   ExpiredSubscriptionPolicyOff,
+			bppID:    "bpp.example.com",
+			lookup:   &mockLookupClient{subscriptions: []model.Subscription{expired}},
+			wantSent: true,
+		},
+		{
+			name:     "task without target subscriber is not checked",
+			policy:   ExpiredSubscriptionPolicyReject,
+			lookup:   &mockLookupClient{subscriptions: []model.Subscription{expired}},
+			wantSent: true,
+		},
+		{
+			name:     "lookup failure does not block the task",
+			policy:   ExpiredSubscriptionPolicyReject,
+			bppID:    "bpp.example.com",
+			lookup:   &mockLookupClient{err: errors.New("registry unavailable")},
+			wantSent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := false
+			client := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				sent = true
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			p := &proxyTaskProcessor{client: client, auth: &mockAuthGen{}, keyID: "test-key-id"}
+			p.SetExpiredSubscriptionGuard(tt.lookup, tt.policy)
+			task := newTestAsyncTask("http://bpp.example.com/search", []byte(`{}`), http.Header{})
+			task.Context.BppID = tt.bppID
+
+			err := p.Process(context.Background(), task)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if sent != tt.wantSent {
+				t.Errorf("Process() sent request = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+// locationLookupClient is a lookup client returning byLocation for criteria with a location
+// and withoutLocation otherwise, recording the criteria received.
+type locationLookupClient struct {
+	byLocation      []model.Subscription
+	withoutLocation []model.Subscription
+	requests        []model.Subscriber
+}
+
+func (m *locationLookupClient) Lookup(ctx context.Context, request *model.Subscription) ([]model.Subscription, error) {
+	m.requests = append(m.requests, request.Subscriber)
+	if request.Location != nil {
+		return m.byLocation, nil
+	}
+	return m.withoutLocation, nil
+}
+
+func TestProxyTaskProcessor_Process_OnSearchTargetLocation(t *testing.T) {
+	loc := &model.Location{City: &model.City{Code: "std:080"}}
+	expired := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bap.example.com"}, ValidUntil: time.Now().Add(-time.Hour)}
+	valid := model.Subscription{Subscriber: model.Subscriber{SubscriberID: "bap.example.com"}, ValidUntil: time.Now().Add(time.Hour)}
+	bap := model.Subscriber{SubscriberID: "bap.example.com", Type: model.RoleBAP}
+	bapInLoc := model.Subscriber{SubscriberID: "bap.example.com", Type: model.RoleBAP, Location: loc}
+
+	tests := []struct {
+		name         string
+		location     *model.Location
+		lookup       *locationLookupClient
+		wantErr      error
+		wantRequests []model.Subscriber
+	}{
+		{
+			name:         "subscription of the location is checked",
+			location:     loc,
+			lookup:       &locationLookupClient{byLocation: []model.Subscription{expired}, withoutLocation: []model.Subscription{valid}},
+			wantErr:      ErrSubscriptionExpired,
+			wantRequests: []model.Subscriber{bapInLoc},
+		},
+		{
+			name:         "no subscription in the location falls back to any location",
+			location:     loc,
+			lookup:       &locationLookupClient{withoutLocation: []model.Subscription{valid}},
+			wantRequests: []model.Subscriber{bapInLoc, bap},
+		},
+		{
+			name:         "without location",
+			lookup:       &locationLookupClient{byLocation: []model.Subscription{valid}, withoutLocation: []model.Subscription{expired}},
+			wantErr:      ErrSubscriptionExpired,
+			wantRequests: []model.Subscriber{bap},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockHttpClient{doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			}}
+			p := &proxyTaskProcessor{client: client, auth: &mockAuthGen{}, keyID: "test-key-id"}
+			p.SetExpiredSubscriptionGuard(tt.lookup, ExpiredSubscriptionPolicyReject)
+			task := newTestAsyncTask("http://bap.example.com/on_search", []byte(`{}`), http.Header{})
+			task.Context.Action = "on_search"
+			task.Context.BapID = "bap.example.com"
+			task.Context.Location = tt.location
+
+			if err := p.Process(context.Background(), task); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantRequests, tt.lookup.requests); diff != "" {
+				t.Errorf("lookup criteria mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTargetSubscriber(t *testing.T) {
+	loc := &model.Location{City: &model.City{Code: "std:080"}}
+	reqCtx := &model.Context{Domain: "retail", BapID: "bap.example.com", BppID: "bpp.example.com", Location: loc}
+	for action, want := range map[string]model.Subscriber{
+		"search":    {SubscriberID: "bpp.example.com", Type: model.RoleBPP, Domain: "retail"},
+		"on_search": {SubscriberID: "bap.example.com", Type: model.RoleBAP, Domain: "retail", Location: loc},
+		"on_select": {SubscriberID: "bap.example.com", Type: model.RoleBAP, Domain: "retail"},
+	} {
+		reqCtx.Action = action
+		if got := targetSubscriber(reqCtx); got != want {
+			t.Errorf("targetSubscriber(%s) = %+v, want %+v", action, got, want)
+		}
+	}
+}
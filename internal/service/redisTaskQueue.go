@@ -0,0 +1,452 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// Defaults of the Redis task queue.
+const (
+	DefaultRedisTaskQueueKey        = "gateway_task_queue"
+	DefaultRedisTaskQueuePopTimeout = 1 * time.Second
+	DefaultRedisTaskQueueRetryDelay = 1 * time.Second
+)
+
+// maxRedisTaskRetryDelay caps the exponential backoff of failed tasks.
+const maxRedisTaskRetryDelay = 5 * time.Minute
+
+// taskList is a durable list of serialized tasks, such as a Redis list.
+type taskList interface {
+	// RPush appends values to the tail of the list at key.
+	RPush(ctx context.Context, key string, values ...string) error
+	// BLMove removes the head of the list at source and appends it to the tail of the list at
+	// destination, waiting up to timeout for one. It reports false if source stayed empty.
+	BLMove(ctx context.Context, source, destination string, timeout time.Duration) (string, bool, error)
+	// LMove is BLMove without waiting.
+	LMove(ctx context.Context, source, destination string) (string, bool, error)
+	// LRem removes the first occurrence of value from the list at key.
+	LRem(ctx context.Context, key, value string) error
+	// LLen returns the length of the list at key.
+	LLen(ctx context.Context, key string) (int64, error)
+}
+
+// RedisTaskQueueConfig configures a task queue persisted in a Redis list.
+type RedisTaskQueueConfig struct {
+	Key        string        `yaml:"key"`        // The Redis list holding the tasks, defaults to DefaultRedisTaskQueueKey.
+	PopTimeout time.Duration `yaml:"popTimeout"` // How long a worker waits on an empty list at a time, defaults to DefaultRedisTaskQueuePopTimeout.
+	MaxRetries int           `yaml:"maxRetries"` // How many times a task failing to process is queued again, 0 means never.
+	RetryDelay time.Duration `yaml:"retryDelay"` // Delay before the first retry, doubled on every further one. Defaults to DefaultRedisTaskQueueRetryDelay.
+	// InstanceID names the list holding the tasks this gateway is processing. It must be unique among
+	// the gateways sharing Key and stable across restarts of this one. Defaults to the hostname.
+	InstanceID string `yaml:"instanceID"`
+}
+
+// Validate returns an error naming the first invalid setting.
+func (c RedisTaskQueueConfig) Validate() error {
+	if c.PopTimeout < 0 {
+		return fmt.Errorf("popTimeout %s must not be negative", c.PopTimeout)
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries %d must not be negative", c.MaxRetries)
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("retryDelay %s must not be negative", c.RetryDelay)
+	}
+	return nil
+}
+
+// retryDelay returns how long a task is held back after attempts failed attempts.
+func (c RedisTaskQueueConfig) retryDelay(attempts int) time.Duration {
+	delay := c.RetryDelay
+	for i := 1; i < attempts && delay < maxRedisTaskRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRedisTaskRetryDelay)
+}
+
+// redisQueueItem is the JSON form of a queued task. The target is carried as a string,
+// since a url.URL does not round-trip through JSON.
+type redisQueueItem struct {
+	Task     *model.AsyncTask `json:"task"`
+	Target   string           `json:"target,omitempty"`
+	Attempts int              `json:"attempts"` // Failed attempts to process the task.
+}
+
+// encodeRedisQueueItem serializes task after attempts failed attempts.
+func encodeRedisQueueItem(task *model.AsyncTask, attempts int) (string, error) {
+	t := *task
+	item := redisQueueItem{Task: &t, Attempts: attempts}
+	if task.Target != nil {
+		item.Target = task.Target.String()
+		t.Target = nil
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeRedisQueueItem parses a serialized task.
+func decodeRedisQueueItem(s string) (*redisQueueItem, error) {
+	var item redisQueueItem
+	if err := json.Unmarshal([]byte(s), &item); err != nil {
+		return nil, err
+	}
+	if item.Task == nil {
+		return nil, fmt.Errorf("queued item has no task")
+	}
+	if item.Target != "" {
+		target, err := url.Parse(item.Target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task target %q: %w", item.Target, err)
+		}
+		item.Task.Target = target
+	}
+	return &item, nil
+}
+
+// RedisTaskQueue implements a task queue persisted in a Redis list, so that queued tasks survive
+// a restart of the gateway: workers drain the tasks left in the list when they start.
+// Workers move each task into a processing list of the gateway instance while they process it and
+// remove it once done, so that the tasks in progress when the gateway stops abruptly are queued
+// again when it starts.
+type RedisTaskQueue struct {
+	list            taskList
+	key             string
+	processingKey   string
+	popTimeout      time.Duration
+	maxRetries      int
+	cfg             RedisTaskQueueConfig
+	proxyProcessor  taskProcessor
+	lookupProcessor taskProcessor
+	numWorkers      int
+
+	defaultOnSearchLocation *model.Location
+
+	deadLetters DeadLetterSink
+	targetURLs  TargetURLConfig
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// NewRedisTaskQueue creates a new RedisTaskQueue storing its tasks in list.
+// parentCtx is the context for the workers' lifecycle.
+// proxyP and lookupP are the processors for different task types.
+func NewRedisTaskQueue(
+	parentCtx context.Context,
+	numWorkers int,
+	proxyP taskProcessor,
+	lookupP taskProcessor,
+	list taskList,
+	cfg RedisTaskQueueConfig,
+) (*RedisTaskQueue, error) {
+	if proxyP == nil {
+		slog.Error("NewRedisTaskQueue: proxyProcessor cannot be nil")
+		return nil, fmt.Errorf("proxyProcessor cannot be nil")
+	}
+	if list == nil {
+		slog.Error("NewRedisTaskQueue: task list cannot be nil")
+		return nil, fmt.Errorf("task list cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid redis task queue config: %w", err)
+	}
+	if numWorkers <= 0 {
+		slog.Warn("NewRedisTaskQueue: numWorkers is not positive, defaulting to 1", "provided_num_workers", numWorkers)
+		numWorkers = 1
+	}
+	key := cfg.Key
+	if key == "" {
+		key = DefaultRedisTaskQueueKey
+	}
+	popTimeout := cfg.PopTimeout
+	if popTimeout == 0 {
+		popTimeout = DefaultRedisTaskQueuePopTimeout
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = DefaultRedisTaskQueueRetryDelay
+	}
+	if cfg.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			slog.Error("NewRedisTaskQueue: Failed to get hostname for instance id", "error", err)
+			return nil, fmt.Errorf("failed to get hostname for instance id: %w", err)
+		}
+		cfg.InstanceID = hostname
+	}
+
+	workerCtx, workerCancel := context.WithCancel(parentCtx)
+
+	return &RedisTaskQueue{
+		list:            list,
+		key:             key,
+		processingKey:   key + ":processing:" + cfg.InstanceID,
+		popTimeout:      popTimeout,
+		maxRetries:      cfg.MaxRetries,
+		cfg:             cfg,
+		proxyProcessor:  proxyP,
+		lookupProcessor: lookupP,
+		numWorkers:      numWorkers,
+		workerCtx:       workerCtx,
+		workerCancel:    workerCancel,
+	}, nil
+}
+
+// SetLookupProcessor sets the lookup processor for the RedisTaskQueue.
+// This is used to break the initialization cycle.
+func (rtq *RedisTaskQueue) SetLookupProcessor(lookupP taskProcessor) {
+	if lookupP == nil {
+		slog.Error("RedisTaskQueue.SetLookupProcessor: lookupProcessor cannot be nil when setting")
+	}
+	rtq.lookupProcessor = lookupP
+}
+
+// SetDefaultOnSearchLocation sets the location recorded in the context of on_search tasks
+// whose request context has none, like ChannelTaskQueue.SetDefaultOnSearchLocation.
+func (rtq *RedisTaskQueue) SetDefaultOnSearchLocation(loc *model.Location) {
+	rtq.defaultOnSearchLocation = loc
+}
+
+// SetDeadLetterSink records the tasks that fail to process in sink, once their retries are exhausted.
+// A nil sink disables recording. It must be called before the workers start.
+func (rtq *RedisTaskQueue) SetDeadLetterSink(sink DeadLetterSink) {
+	rtq.deadLetters = sink
+}
+
+// SetTargetURLConfig sets how the targets of proxy tasks are built, like ChannelTaskQueue.SetTargetURLConfig.
+func (rtq *RedisTaskQueue) SetTargetURLConfig(cfg TargetURLConfig) {
+	rtq.targetURLs = cfg
+}
+
+// Depth returns the number of tasks waiting in the queue, or 0 if it cannot be read.
+func (rtq *RedisTaskQueue) Depth() int {
+	n, err := rtq.list.LLen(context.WithoutCancel(rtq.workerCtx), rtq.key)
+	if err != nil {
+		slog.WarnContext(rtq.workerCtx, "RedisTaskQueue: Failed to read queue depth", "error", err)
+		return 0
+	}
+	return int(n)
+}
+
+// QueueTxn creates an AsyncTask based on the request context and body,
+// then appends it to the Redis list for asynchronous processing by a worker goroutine.
+// This method implements the taskQueuer interface.
+func (rtq *RedisTaskQueue) QueueTxn(ctx context.Context, reqCtx *model.Context, body []byte, h http.Header) (*model.AsyncTask, error) {
+	if reqCtx == nil {
+		slog.ErrorContext(ctx, "RedisTaskQueue.QueueTxn: request context (model.Context) cannot be nil")
+		return nil, fmt.Errorf("request context (model.Context) is nil")
+	}
+	task, err := newAsyncTask(ctx, reqCtx, body, h, rtq.targetURLs, rtq.defaultOnSearchLocation)
+	if err != nil {
+		return nil, err
+	}
+	if rtq.workerCtx.Err() != nil {
+		return nil, fmt.Errorf("worker is shutting down, cannot queue task")
+	}
+	if err := rtq.push(ctx, task, 0); err != nil {
+		slog.ErrorContext(ctx, "RedisTaskQueue.QueueTxn: Failed to queue task", "action", reqCtx.Action, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "RedisTaskQueue.QueueTxn: Task successfully queued", "action", reqCtx.Action, "type", task.Type)
+	return task, nil
+}
+
+// push appends task, after attempts failed attempts, to the tail of the list.
+func (rtq *RedisTaskQueue) push(ctx context.Context, task *model.AsyncTask, attempts int) error {
+	value, err := encodeRedisQueueItem(task, attempts)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task: %w", err)
+	}
+	if err := rtq.list.RPush(ctx, rtq.key, value); err != nil {
+		return fmt.Errorf("failed to queue task in redis: %w", err)
+	}
+	return nil
+}
+
+// recoverProcessing queues again the tasks left in the processing list of this instance by a run
+// that stopped while processing them.
+func (rtq *RedisTaskQueue) recoverProcessing() {
+	recovered := 0
+	for {
+		_, ok, err := rtq.list.LMove(rtq.workerCtx, rtq.processingKey, rtq.key)
+		if err != nil {
+			slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue: Failed to recover tasks left in processing", "processing_key", rtq.processingKey, "recovered", recovered, "error", err)
+			return
+		}
+		if !ok {
+			break
+		}
+		recovered++
+	}
+	if recovered > 0 {
+		slog.WarnContext(rtq.workerCtx, "RedisTaskQueue: Recovered tasks left in processing by a previous run", "processing_key", rtq.processingKey, "recovered", recovered)
+	}
+}
+
+// done removes value, a task that was processed, from the processing list.
+func (rtq *RedisTaskQueue) done(workerID int, value string) {
+	if err := rtq.list.LRem(context.WithoutCancel(rtq.workerCtx), rtq.processingKey, value); err != nil {
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Failed to remove task from processing, it will be processed again on restart", "worker_id", workerID, "error", err)
+	}
+}
+
+// requeue queues task again after attempts failed attempts and removes value, its previous form,
+// from the processing list. The task stays in the processing list until it is queued again,
+// so that it is recovered if the gateway stops in between.
+func (rtq *RedisTaskQueue) requeue(workerID int, value string, task *model.AsyncTask, attempts int, cause error) {
+	if err := rtq.push(context.WithoutCancel(rtq.workerCtx), task, attempts); err != nil {
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Failed to queue task again, dropping it", "worker_id", workerID, "type", task.Type, "error", err)
+		recordDeadLetter(rtq.workerCtx, rtq.deadLetters, task, cause)
+	}
+	rtq.done(workerID, value)
+}
+
+// requeueAfter is requeue after delay. The task is queued again without holding the worker.
+// Stopping the workers cuts the delay short and queues it right away for the next run.
+func (rtq *RedisTaskQueue) requeueAfter(workerID int, value string, task *model.AsyncTask, attempts int, delay time.Duration, cause error) {
+	rtq.wg.Add(1)
+	go func() {
+		defer rtq.wg.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-rtq.workerCtx.Done():
+		}
+		rtq.requeue(workerID, value, task, attempts, cause)
+	}()
+}
+
+// StartWorkers launches the background worker goroutines that process tasks from the list,
+// starting with the tasks left in it and in the processing list of this instance by a previous run.
+func (rtq *RedisTaskQueue) StartWorkers() {
+	rtq.recoverProcessing()
+	slog.InfoContext(rtq.workerCtx, "RedisTaskQueue: Starting workers...", "num_workers", rtq.numWorkers, "key", rtq.key, "depth", rtq.Depth())
+	for i := 0; i < rtq.numWorkers; i++ {
+		rtq.wg.Add(1)
+		go func(workerID int) {
+			defer rtq.wg.Done()
+			slog.InfoContext(rtq.workerCtx, "RedisTaskQueue Worker: Starting...", "worker_id", workerID)
+			for rtq.workerCtx.Err() == nil {
+				rtq.processNext(workerID)
+			}
+			slog.InfoContext(rtq.workerCtx, "RedisTaskQueue Worker: Context cancelled, stopping.", "worker_id", workerID)
+		}(i)
+	}
+}
+
+// processNext moves the next task to the processing list, waiting up to the pop timeout for one,
+// and processes it.
+func (rtq *RedisTaskQueue) processNext(workerID int) {
+	value, ok, err := rtq.list.BLMove(rtq.workerCtx, rtq.key, rtq.processingKey, rtq.popTimeout)
+	if err != nil {
+		if rtq.workerCtx.Err() != nil {
+			return
+		}
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Failed to pop task", "worker_id", workerID, "error", err)
+		// Back off so that an unavailable Redis is not polled in a tight loop.
+		select {
+		case <-time.After(rtq.popTimeout):
+		case <-rtq.workerCtx.Done():
+		}
+		return
+	}
+	if !ok {
+		return
+	}
+	item, err := decodeRedisQueueItem(value)
+	if err != nil {
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Dropping malformed task", "worker_id", workerID, "error", err)
+		rtq.done(workerID, value)
+		return
+	}
+	task := item.Task
+	// A task popped as the workers are stopping is put back for the next run.
+	if rtq.workerCtx.Err() != nil {
+		rtq.requeue(workerID, value, task, item.Attempts, rtq.workerCtx.Err())
+		return
+	}
+	slog.InfoContext(rtq.workerCtx, "RedisTaskQueue Worker: Received task", "worker_id", workerID, "type", task.Type, "target", task.Target, "attempts", item.Attempts)
+
+	var processor taskProcessor
+	switch task.Type {
+	case model.AsyncTaskTypeProxy:
+		processor = rtq.proxyProcessor
+	case model.AsyncTaskTypeLookup:
+		processor = rtq.lookupProcessor
+	default:
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Unknown task type received", "worker_id", workerID, "type", task.Type)
+		recordDeadLetter(rtq.workerCtx, rtq.deadLetters, task, fmt.Errorf("unknown task type %q", task.Type))
+		rtq.done(workerID, value)
+		return
+	}
+	if processor == nil {
+		slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: No processor for task type, cannot process task", "worker_id", workerID, "type", task.Type)
+		recordDeadLetter(rtq.workerCtx, rtq.deadLetters, task, fmt.Errorf("no processor for %s tasks", task.Type))
+		rtq.done(workerID, value)
+		return
+	}
+	if err := processor.Process(rtq.workerCtx, task); err != nil {
+		if errors.Is(err, ErrCallbackLimitReached) {
+			// The target host is busy: the task is deferred without counting as an attempt.
+			rtq.requeueAfter(workerID, value, task, item.Attempts, CallbackLimitRequeueDelay, err)
+			return
+		}
+		if errors.Is(err, ErrPartialFanOut) {
+			slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Lookup task partially fanned out, not retrying it", "worker_id", workerID, "type", task.Type, "attempts", item.Attempts+1, "error", err)
+			recordDeadLetter(rtq.workerCtx, rtq.deadLetters, task, err)
+			rtq.done(workerID, value)
+			return
+		}
+		if item.Attempts >= rtq.maxRetries {
+			slog.ErrorContext(rtq.workerCtx, "RedisTaskQueue Worker: Error processing task, retries exhausted, dropping it", "worker_id", workerID, "type", task.Type, "attempts", item.Attempts+1, "error", err)
+			recordDeadLetter(rtq.workerCtx, rtq.deadLetters, task, err)
+			rtq.done(workerID, value)
+			return
+		}
+		delay := rtq.cfg.retryDelay(item.Attempts + 1)
+		slog.WarnContext(rtq.workerCtx, "RedisTaskQueue Worker: Error processing task, queuing it again", "worker_id", workerID, "type", task.Type, "attempts", item.Attempts+1, "retry_delay", delay, "error", err)
+		rtq.requeueAfter(workerID, value, task, item.Attempts+1, delay, err)
+		return
+	}
+	rtq.done(workerID, value)
+	slog.InfoContext(rtq.workerCtx, "RedisTaskQueue Worker: Task processed successfully", "worker_id", workerID, "type", task.Type)
+}
+
+// StopWorkers signals the worker goroutines to stop and waits for them to finish. A worker waiting
+// on an empty list stops at the end of its pop timeout at the latest. Queued tasks and those waiting
+// to be retried stay in the list, and the tasks the workers failed to process are recorded in the
+// dead-letter sink before it returns.
+func (rtq *RedisTaskQueue) StopWorkers() {
+	slog.InfoContext(rtq.workerCtx, "RedisTaskQueue: StopWorkers called, signaling workers to stop.")
+	rtq.workerCancel()
+	rtq.wg.Wait()
+	slog.InfoContext(rtq.workerCtx, "RedisTaskQueue: All workers stopped.")
+}
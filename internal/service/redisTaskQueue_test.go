@@ -0,0 +1,475 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeTaskList is an in-memory taskList.
+type fakeTaskList struct {
+	mu     sync.Mutex
+	lists  map[string][]string
+	pushed chan struct{} // Signaled on every push.
+	err    error
+}
+
+func newFakeTaskList() *fakeTaskList {
+	return &fakeTaskList{lists: make(map[string][]string), pushed: make(chan struct{}, 100)}
+}
+
+func (l *fakeTaskList) RPush(ctx context.Context, key string, values ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	l.lists[key] = append(l.lists[key], values...)
+	select {
+	case l.pushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (l *fakeTaskList) BLMove(ctx context.Context, source, destination string, timeout time.Duration) (string, bool, error) {
+	deadline := time.After(timeout)
+	for {
+		value, ok, err := l.LMove(ctx, source, destination)
+		if err != nil || ok {
+			return value, ok, err
+		}
+		select {
+		case <-l.pushed:
+		case <-deadline:
+			return "", false, nil
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		}
+	}
+}
+
+func (l *fakeTaskList) LMove(ctx context.Context, source, destination string) (string, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return "", false, l.err
+	}
+	values := l.lists[source]
+	if len(values) == 0 {
+		return "", false, nil
+	}
+	l.lists[source] = values[1:]
+	l.lists[destination] = append(l.lists[destination], values[0])
+	return values[0], true, nil
+}
+
+func (l *fakeTaskList) LRem(ctx context.Context, key, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	values := l.lists[key]
+	for i, v := range values {
+		if v == value {
+			l.lists[key] = append(values[:i:i], values[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (l *fakeTaskList) LLen(ctx context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(len(l.lists[key])), l.err
+}
+
+// waitForCalls waits until p was called n times.
+func waitForCalls(t *testing.T, p *mockTaskProcessor, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for p.getCallCount() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("processor calls = %d, want %d", p.getCallCount(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRedisTaskQueueConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RedisTaskQueueConfig
+		wantErr string
+	}{
+		{name: "defaults", cfg: RedisTaskQueueConfig{}},
+		{name: "valid", cfg: RedisTaskQueueConfig{Key: "tasks", PopTimeout: time.Second, MaxRetries: 3}},
+		{name: "negative popTimeout", cfg: RedisTaskQueueConfig{PopTimeout: -time.Second}, wantErr: "popTimeout -1s must not be negative"},
+		{name: "negative maxRetries", cfg: RedisTaskQueueConfig{MaxRetries: -1}, wantErr: "maxRetries -1 must not be negative"},
+		{name: "negative retryDelay", cfg: RedisTaskQueueConfig{RetryDelay: -time.Second}, wantErr: "retryDelay -1s must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRedisTaskQueue(t *testing.T) {
+	q, err := NewRedisTaskQueue(context.Background(), 0, &mockTaskProcessor{}, nil, newFakeTaskList(), RedisTaskQueueConfig{})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	if q.numWorkers != 1 || q.key != DefaultRedisTaskQueueKey || q.popTimeout != DefaultRedisTaskQueuePopTimeout {
+		t.Errorf("NewRedisTaskQueue() = {numWorkers: %d, key: %q, popTimeout: %s}, want the defaults", q.numWorkers, q.key, q.popTimeout)
+	}
+}
+
+func TestNewRedisTaskQueue_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxyP  taskProcessor
+		list    taskList
+		cfg     RedisTaskQueueConfig
+		wantErr string
+	}{
+		{name: "nil proxy processor", list: newFakeTaskList(), wantErr: "proxyProcessor cannot be nil"},
+		{name: "nil list", proxyP: &mockTaskProcessor{}, wantErr: "task list cannot be nil"},
+		{name: "invalid config", proxyP: &mockTaskProcessor{}, list: newFakeTaskList(), cfg: RedisTaskQueueConfig{MaxRetries: -1}, wantErr: "maxRetries -1 must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRedisTaskQueue(context.Background(), 1, tt.proxyP, nil, tt.list, tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRedisTaskQueue() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedisQueueItem_RoundTrip(t *testing.T) {
+	task := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeProxy,
+		Target:  mustParseURL("https://bpp.example.com/beckn/search?x=1"),
+		Body:    []byte(`{"message":{}}`),
+		Headers: http.Header{"Authorization": {"Signature keyId=\"a|b|ed25519\""}},
+		Context: model.Context{Action: "search", TransactionID: "txn-1", BppURI: "https://bpp.example.com/beckn"},
+	}
+	value, err := encodeRedisQueueItem(task, 2)
+	if err != nil {
+		t.Fatalf("encodeRedisQueueItem() error = %v", err)
+	}
+	item, err := decodeRedisQueueItem(value)
+	if err != nil {
+		t.Fatalf("decodeRedisQueueItem() error = %v", err)
+	}
+	if item.Attempts != 2 {
+		t.Errorf("decoded attempts = %d, want 2", item.Attempts)
+	}
+	if diff := cmp.Diff(task, item.Task); diff != "" {
+		t.Errorf("decoded task mismatch (-want +got):\n%s", diff)
+	}
+	if task.Target == nil {
+		t.Error("encodeRedisQueueItem() cleared the target of the queued task")
+	}
+}
+
+func TestDecodeRedisQueueItem_Error(t *testing.T) {
+	for _, value := range []string{`not json`, `{"attempts":1}`, `{"task":{},"target":"http://[::1"}`} {
+		if _, err := decodeRedisQueueItem(value); err == nil {
+			t.Errorf("decodeRedisQueueItem(%q) error = nil, want error", value)
+		}
+	}
+}
+
+func TestRedisTaskQueue_WorkerProcessingAndShutdown(t *testing.T) {
+	ctx := context.Background()
+	list := newFakeTaskList()
+	proxyP, lookupP := &mockTaskProcessor{}, &mockTaskProcessor{}
+	q, err := NewRedisTaskQueue(ctx, 2, proxyP, lookupP, list, RedisTaskQueueConfig{PopTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	q.StartWorkers()
+
+	queued, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, []byte(`{}`), http.Header{"X-Test": {"1"}})
+	if err != nil {
+		t.Fatalf("QueueTxn() PROXY error = %v", err)
+	}
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() LOOKUP error = %v", err)
+	}
+	waitForCalls(t, proxyP, 1)
+	waitForCalls(t, lookupP, 1)
+	q.StopWorkers()
+
+	proxyP.mu.Lock()
+	got := proxyP.tasks[0]
+	proxyP.mu.Unlock()
+	if diff := cmp.Diff(queued, got); diff != "" {
+		t.Errorf("processed task mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search"}, nil, nil); err == nil || !strings.Contains(err.Error(), "worker is shutting down") {
+		t.Errorf("QueueTxn() after stop error = %v, want error containing 'worker is shutting down'", err)
+	}
+}
+
+func TestRedisTaskQueue_DrainsTasksOnStartup(t *testing.T) {
+	ctx := context.Background()
+	list := newFakeTaskList()
+	cfg := RedisTaskQueueConfig{Key: "tasks", PopTimeout: 10 * time.Millisecond}
+
+	// Tasks queued by a gateway that stopped before processing them.
+	before, err := NewRedisTaskQueue(ctx, 1, &mockTaskProcessor{}, nil, list, cfg)
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	for _, uri := range []string{"http://bpp1.com", "http://bpp2.com"} {
+		if _, err := before.QueueTxn(ctx, &model.Context{Action: "search", BppURI: uri}, nil, nil); err != nil {
+			t.Fatalf("QueueTxn() error = %v", err)
+		}
+	}
+	before.StopWorkers()
+
+	proxyP := &mockTaskProcessor{}
+	after, err := NewRedisTaskQueue(ctx, 1, proxyP, nil, list, cfg)
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	if got := after.Depth(); got != 2 {
+		t.Errorf("Depth() before start = %d, want 2", got)
+	}
+	after.StartWorkers()
+	waitForCalls(t, proxyP, 2)
+	after.StopWorkers()
+
+	var targets []string
+	for _, task := range proxyP.tasks {
+		targets = append(targets, task.Target.String())
+	}
+	if diff := cmp.Diff([]string{"http://bpp1.com/search", "http://bpp2.com/search"}, targets); diff != "" {
+		t.Errorf("processed targets mismatch (-want +got):\n%s", diff)
+	}
+	if got := after.Depth(); got != 0 {
+		t.Errorf("Depth() after draining = %d, want 0", got)
+	}
+}
+
+func TestRedisTaskQueue_Retries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		failures   int
+		err        error
+		wantCalls  int
+	}{
+		{name: "no retries", maxRetries: 0, failures: 5, err: errors.New("target unavailable"), wantCalls: 1},
+		{name: "succeeds on retry", maxRetries: 3, failures: 2, err: errors.New("target unavailable"), wantCalls: 3},
+		{name: "retries exhausted", maxRetries: 2, failures: 5, err: errors.New("target unavailable"), wantCalls: 3},
+		{name: "partial fan-out not retried", maxRetries: 3, failures: 5, err: fmt.Errorf("%w: 1 proxy tasks queued", ErrPartialFanOut), wantCalls: 1},
+		{name: "callback limit deferred without attempt", maxRetries: 0, failures: 2, err: &ProxyTaskError{Target: "http://bpp.com", Err: ErrCallbackLimitReached}, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			list := newFakeTaskList()
+			proxyP := &mockTaskProcessor{}
+			proxyP.processFunc = func(ctx context.Context, task *model.AsyncTask) error {
+				// Called with proxyP.mu held.
+				if proxyP.callCount <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}
+			q, err := NewRedisTaskQueue(ctx, 1, proxyP, nil, list, RedisTaskQueueConfig{PopTimeout: 10 * time.Millisecond, MaxRetries: tt.maxRetries, RetryDelay: time.Millisecond})
+			if err != nil {
+				t.Fatalf("NewRedisTaskQueue() error = %v", err)
+			}
+			if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+				t.Fatalf("QueueTxn() error = %v", err)
+			}
+			q.StartWorkers()
+			waitForCalls(t, proxyP, tt.wantCalls)
+			// Give a wrongly requeued task the time to be processed again.
+			time.Sleep(50 * time.Millisecond)
+			q.StopWorkers()
+
+			if got := proxyP.getCallCount(); got != tt.wantCalls {
+				t.Errorf("processor calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := q.Depth(); got != 0 {
+				t.Errorf("Depth() = %d, want 0", got)
+			}
+			if got := len(list.lists[q.processingKey]); got != 0 {
+				t.Errorf("tasks left in processing = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestRedisTaskQueueConfig_RetryDelay(t *testing.T) {
+	cfg := RedisTaskQueueConfig{RetryDelay: time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 30, want: maxRedisTaskRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := cfg.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRedisTaskQueue_RetryIsDelayed(t *testing.T) {
+	ctx := context.Background()
+	list := newFakeTaskList()
+	proxyP := &mockTaskProcessor{}
+	proxyP.processFunc = func(ctx context.Context, task *model.AsyncTask) error {
+		if proxyP.callCount == 1 {
+			return errors.New("target unavailable")
+		}
+		return nil
+	}
+	q, err := NewRedisTaskQueue(ctx, 1, proxyP, nil, list, RedisTaskQueueConfig{PopTimeout: 10 * time.Millisecond, MaxRetries: 1, RetryDelay: time.Hour, InstanceID: "gw-1"})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil); err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	q.StartWorkers()
+	waitForCalls(t, proxyP, 1)
+	time.Sleep(50 * time.Millisecond)
+	if got := proxyP.getCallCount(); got != 1 {
+		t.Errorf("processor calls before the retry delay = %d, want 1", got)
+	}
+	list.mu.Lock()
+	inProcessing := len(list.lists[q.processingKey])
+	list.mu.Unlock()
+	if inProcessing != 1 {
+		t.Errorf("tasks in processing while waiting to retry = %d, want 1", inProcessing)
+	}
+
+	// Stopping cuts the delay short and leaves the task queued for the next run.
+	q.StopWorkers()
+	if got := q.Depth(); got != 1 {
+		t.Errorf("Depth() after stop = %d, want 1", got)
+	}
+	if got := len(list.lists[q.processingKey]); got != 0 {
+		t.Errorf("tasks left in processing after stop = %d, want 0", got)
+	}
+}
+
+func TestRedisTaskQueue_RecoversProcessingOnStartup(t *testing.T) {
+	ctx := context.Background()
+	list := newFakeTaskList()
+	cfg := RedisTaskQueueConfig{Key: "tasks", PopTimeout: 10 * time.Millisecond, InstanceID: "gw-1"}
+	task, err := newAsyncTask(ctx, &model.Context{Action: "search", BppURI: "http://bpp.com"}, nil, nil, TargetURLConfig{}, nil)
+	if err != nil {
+		t.Fatalf("newAsyncTask() error = %v", err)
+	}
+	value, err := encodeRedisQueueItem(task, 0)
+	if err != nil {
+		t.Fatalf("encodeRedisQueueItem() error = %v", err)
+	}
+	// A task being processed by gw-1 when it crashed, and one of another instance.
+	list.lists["tasks:processing:gw-1"] = []string{value}
+	list.lists["tasks:processing:gw-2"] = []string{value}
+
+	proxyP := &mockTaskProcessor{}
+	q, err := NewRedisTaskQueue(ctx, 1, proxyP, nil, list, cfg)
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	q.StartWorkers()
+	waitForCalls(t, proxyP, 1)
+	q.StopWorkers()
+
+	if got := proxyP.tasks[0].Target.String(); got != "http://bpp.com/search" {
+		t.Errorf("recovered task target = %s, want http://bpp.com/search", got)
+	}
+	if got := len(list.lists["tasks:processing:gw-1"]); got != 0 {
+		t.Errorf("tasks left in processing of gw-1 = %d, want 0", got)
+	}
+	if got := len(list.lists["tasks:processing:gw-2"]); got != 1 {
+		t.Errorf("tasks in processing of gw-2 = %d, want 1", got)
+	}
+}
+
+func TestRedisTaskQueue_QueueTxn_Error(t *testing.T) {
+	ctx := context.Background()
+	list := newFakeTaskList()
+	q, err := NewRedisTaskQueue(ctx, 1, &mockTaskProcessor{}, nil, list, RedisTaskQueueConfig{})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+
+	if _, err := q.QueueTxn(ctx, nil, nil, nil); err == nil {
+		t.Error("QueueTxn() with nil context error = nil, want error")
+	}
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "confirm"}, nil, nil); err == nil || !strings.Contains(err.Error(), "unknown action type") {
+		t.Errorf("QueueTxn() with unknown action error = %v, want unknown action type", err)
+	}
+	list.err = errors.New("connection refused")
+	if _, err := q.QueueTxn(ctx, &model.Context{Action: "search"}, nil, nil); err == nil || !strings.Contains(err.Error(), "failed to queue task in redis: connection refused") {
+		t.Errorf("QueueTxn() with redis down error = %v, want failed to queue task in redis", err)
+	}
+}
+
+func TestRedisTaskQueue_QueueTxn_DefaultOnSearchLocation(t *testing.T) {
+	q, err := NewRedisTaskQueue(context.Background(), 1, &mockTaskProcessor{}, nil, newFakeTaskList(), RedisTaskQueueConfig{})
+	if err != nil {
+		t.Fatalf("NewRedisTaskQueue() error = %v", err)
+	}
+	loc := &model.Location{City: &model.City{Code: "std:080"}}
+	q.SetDefaultOnSearchLocation(loc)
+
+	task, err := q.QueueTxn(context.Background(), &model.Context{Action: "on_search", BapURI: "http://bap.com"}, nil, nil)
+	if err != nil {
+		t.Fatalf("QueueTxn() error = %v", err)
+	}
+	if diff := cmp.Diff(loc, task.Context.Location); diff != "" {
+		t.Errorf("task location mismatch (-want +got):\n%s", diff)
+	}
+}
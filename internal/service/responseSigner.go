@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// ResponseSigningConfig identifies the key the registry signs its responses with.
+// The key itself is read from Secret Manager, never from the config.
+type ResponseSigningConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	SubscriberID string `yaml:"subscriberID"` // Subscriber ID of the registry, sent in the signature keyId.
+	KeyID        string `yaml:"keyID"`        // Unique key ID of the registry's signing key.
+	ProjectID    string `yaml:"projectID"`    // Project of the Secret Manager secret holding the key.
+}
+
+// secretAccessor reads secret versions, like secretmanager.Client.
+type secretAccessor interface {
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// staticSigningKM is a signingKM that serves a single configured signing key.
+type staticSigningKM struct {
+	keyset *model.Keyset
+}
+
+// Keyset implements signingKM.
+func (k *staticSigningKM) Keyset(ctx context.Context, subscriberID string) (*model.Keyset, error) {
+	if subscriberID != k.keyset.SubscriberID {
+		return nil, fmt.Errorf("no signing key configured for subscriber %s", subscriberID)
+	}
+	return k.keyset, nil
+}
+
+// responseSigner signs response bodies with the registry's signing key.
+type responseSigner struct {
+	auth         *authGenService
+	subscriberID string
+}
+
+// NewResponseSigner creates a responseSigner using the key identified by cfg. The key is read once
+// from the latest version of its secret in secrets, stored like the keysets of the key manager:
+// as a JSON keyset under the secret ID derived from the key ID, with a base64 encoded ed25519
+// seed as its SigningPrivate.
+func NewResponseSigner(ctx context.Context, cfg *ResponseSigningConfig, signer signer, secrets secretAccessor) (*responseSigner, error) {
+	if cfg == nil {
+		slog.Error("NewResponseSigner: config cannot be nil")
+		return nil, errors.New("config cannot be nil")
+	}
+	if cfg.SubscriberID == "" {
+		return nil, errors.New("missing subscriberID")
+	}
+	if cfg.KeyID == "" {
+		return nil, errors.New("missing keyID")
+	}
+	if cfg.ProjectID == "" {
+		return nil, errors.New("missing projectID")
+	}
+	if secrets == nil {
+		return nil, errors.New("secret manager cannot be nil")
+	}
+	privateKey, err := signingSeed(ctx, secrets, cfg.ProjectID, cfg.KeyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "NewResponseSigner: Failed to load the registry signing key", "key_id", cfg.KeyID, "error", err)
+		return nil, err
+	}
+	km := &staticSigningKM{keyset: &model.Keyset{
+		SubscriberID:   cfg.SubscriberID,
+		UniqueKeyID:    cfg.KeyID,
+		SigningPrivate: privateKey,
+	}}
+	auth, err := NewAuthGenService(km, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &responseSigner{auth: auth, subscriberID: cfg.SubscriberID}, nil
+}
+
+// signingSeed reads the base64 encoded ed25519 signing seed of keyID from Secret Manager.
+func signingSeed(ctx context.Context, secrets secretAccessor, projectID, keyID string) (string, error) {
+	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, generateSecretID(keyID))
+	res, err := secrets.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: secretName})
+	if err != nil {
+		return "", fmt.Errorf("failed to access signing key secret %s: %w", secretName, err)
+	}
+	var keyset model.Keyset
+	if err := json.Unmarshal(res.GetPayload().GetData(), &keyset); err != nil {
+		return "", fmt.Errorf("failed to unmarshal signing key secret %s: %w", secretName, err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(keyset.SigningPrivate)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", fmt.Errorf("signing key secret %s must hold a base64 encoded %d byte ed25519 seed", secretName, ed25519.SeedSize)
+	}
+	return keyset.SigningPrivate, nil
+}
+
+// Sign returns the signature header value for body, in the format of the Beckn Authorization header.
+func (s *responseSigner) Sign(ctx context.Context, body []byte) (string, error) {
+	return s.auth.AuthHeader(ctx, body, s.subscriberID)
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	becknsigner "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// signingKeySecret returns the Secret Manager response holding a keyset with signingPrivate.
+func signingKeySecret(t *testing.T, signingPrivate string) *secretmanagerpb.AccessSecretVersionResponse {
+	t.Helper()
+	data, err := json.Marshal(&model.Keyset{UniqueKeyID: "k1", SigningPrivate: signingPrivate})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{Payload: &secretmanagerpb.SecretPayload{Data: data}}
+}
+
+func TestNewResponseSigner_Error(t *testing.T) {
+	validCfg := &ResponseSigningConfig{SubscriberID: "registry.example.com", KeyID: "k1", ProjectID: "p1"}
+	validKey := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+	tests := []struct {
+		name    string
+		cfg     *ResponseSigningConfig
+		signer  signer
+		secrets secretAccessor
+		wantErr string
+	}{
+		{
+			name:    "nil config",
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, validKey)},
+			wantErr: "config cannot be nil",
+		},
+		{
+			name:    "missing subscriberID",
+			cfg:     &ResponseSigningConfig{KeyID: "k1", ProjectID: "p1"},
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, validKey)},
+			wantErr: "missing subscriberID",
+		},
+		{
+			name:    "missing keyID",
+			cfg:     &ResponseSigningConfig{SubscriberID: "registry.example.com", ProjectID: "p1"},
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, validKey)},
+			wantErr: "missing keyID",
+		},
+		{
+			name:    "missing projectID",
+			cfg:     &ResponseSigningConfig{SubscriberID: "registry.example.com", KeyID: "k1"},
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, validKey)},
+			wantErr: "missing projectID",
+		},
+		{
+			name:    "nil secret manager",
+			cfg:     validCfg,
+			signer:  &mockSigner{},
+			wantErr: "secret manager cannot be nil",
+		},
+		{
+			name:    "secret not found",
+			cfg:     validCfg,
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionErr: status.Error(codes.NotFound, "not found")},
+			wantErr: "failed to access signing key secret projects/p1/secrets/" + generateSecretID("k1") + "/versions/latest",
+		},
+		{
+			name:    "secret not a keyset",
+			cfg:     validCfg,
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: &secretmanagerpb.AccessSecretVersionResponse{Payload: &secretmanagerpb.SecretPayload{Data: []byte("seed")}}},
+			wantErr: "failed to unmarshal signing key secret",
+		},
+		{
+			name:    "private key not base64",
+			cfg:     validCfg,
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, "not base64!")},
+			wantErr: "must hold a base64 encoded 32 byte ed25519 seed",
+		},
+		{
+			name:    "private key wrong size",
+			cfg:     validCfg,
+			signer:  &mockSigner{},
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, base64.StdEncoding.EncodeToString([]byte("short")))},
+			wantErr: "must hold a base64 encoded 32 byte ed25519 seed",
+		},
+		{
+			name:    "nil signer",
+			cfg:     validCfg,
+			secrets: &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, validKey)},
+			wantErr: "signer cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewResponseSigner(context.Background(), tt.cfg, tt.signer, tt.secrets); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewResponseSigner() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResponseSigner_Sign(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	s, _, err := becknsigner.New(ctx, &becknsigner.Config{})
+	if err != nil {
+		t.Fatalf("signer.New() error = %v", err)
+	}
+	sm := &mockSecretManager{accessSecretVersionResp: signingKeySecret(t, base64.StdEncoding.EncodeToString(priv.Seed()))}
+	rs, err := NewResponseSigner(ctx, &ResponseSigningConfig{
+		Enabled:      true,
+		SubscriberID: "registry.example.com",
+		KeyID:        "registry-key-1",
+		ProjectID:    "p1",
+	}, s, sm)
+	if err != nil {
+		t.Fatalf("NewResponseSigner() error = %v", err)
+	}
+	if got, want := sm.accessSecretVersionCalledWith.GetName(), "projects/p1/secrets/"+generateSecretID("registry-key-1")+"/versions/latest"; got != want {
+		t.Errorf("AccessSecretVersion() name = %q, want %q", got, want)
+	}
+	body := []byte(`[{"subscriber_id":"bap.example.com"}]`)
+
+	header, err := rs.Sign(ctx, body)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if want := `keyId="registry.example.com|registry-key-1|ed25519"`; !strings.Contains(header, want) {
+		t.Errorf("Sign() = %q, want header containing %q", header, want)
+	}
+	sv, _, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	pubKey := base64.StdEncoding.EncodeToString(pub)
+	if err := sv.Validate(ctx, body, header, pubKey); err != nil {
+		t.Errorf("Validate() of signed body error = %v, want nil", err)
+	}
+	if err := sv.Validate(ctx, []byte(`[]`), header, pubKey); err == nil {
+		t.Error("Validate() of tampered body error = nil, want error")
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/blake2b"
+)
+
+// UnsupportedAlgorithmError is returned when a signature header names an algorithm no verification routine supports.
+type UnsupportedAlgorithmError struct {
+	Algorithm string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedAlgorithmError) Error() string {
+	return fmt.Sprintf("unsupported signature algorithm %q", e.Algorithm)
+}
+
+// verifyFunc verifies sig of msg with the decoded public key.
+type verifyFunc func(key, msg, sig []byte) error
+
+// algSignValidator validates signatures with the verification routine of the algorithm
+// named in their header. Ed25519 signatures are validated by the Beckn sign validator.
+type algSignValidator struct {
+	ed25519   signValidator
+	verify    map[string]verifyFunc
+	clockSkew time.Duration // Tolerance of the signature created/expires window.
+	now       func() time.Time
+}
+
+// NewAlgSignValidator creates a signValidator validating ed25519 signatures with edValidator,
+// the Beckn sign validator, and RSA and secp256k1 signatures itself.
+func NewAlgSignValidator(edValidator signValidator) (*algSignValidator, error) {
+	if edValidator == nil {
+		slog.Error("NewAlgSignValidator: ed25519 signValidator dependency is nil")
+		return nil, errors.New("ed25519 signValidator dependency is nil")
+	}
+	return &algSignValidator{
+		ed25519: edValidator,
+		verify: map[string]verifyFunc{
+			model.SignatureAlgorithmRSA:       verifyRSA,
+			model.SignatureAlgorithmSecp256k1: verifySecp256k1,
+		},
+		clockSkew: DefaultSignatureClockSkew,
+		now:       time.Now,
+	}, nil
+}
+
+// SetClockSkew sets how far the clocks of signers may be off when the created and expires
+// parameters of RSA and secp256k1 signatures are checked. A negative d restores DefaultSignatureClockSkew.
+func (v *algSignValidator) SetClockSkew(d time.Duration) {
+	if d < 0 {
+		d = DefaultSignatureClockSkew
+	}
+	v.clockSkew = d
+}
+
+// Validate validates the signature in header of body with publicKeyBase64, using the
+// algorithm of the header's keyId. It returns an *UnsupportedAlgorithmError for unknown algorithms.
+func (v *algSignValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	ah, err := parseAuthHeader(header)
+	if err != nil {
+		return fmt.Errorf("error parsing header: %w", err)
+	}
+	alg := strings.ToLower(ah.Algorithm)
+	if alg == model.SignatureAlgorithmEd25519 {
+		// The Beckn sign validator panics on keys of another length.
+		if key, err := base64.StdEncoding.DecodeString(publicKeyBase64); err == nil && len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid ed25519 public key length %d", len(key))
+		}
+		return v.ed25519.Validate(ctx, body, header, publicKeyBase64)
+	}
+	verify, ok := v.verify[alg]
+	if !ok {
+		return &UnsupportedAlgorithmError{Algorithm: ah.Algorithm}
+	}
+
+	if err := checkSignatureTimes(ah, v.now(), v.clockSkew); err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(authParam(header, "signature"))
+	if err != nil || len(sig) == 0 {
+		return errors.New("signature missing or not base64")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return fmt.Errorf("error decoding public key: %w", err)
+	}
+	if err := verify(key, signingString(body, ah.Created.Unix(), ah.Expires.Unix()), sig); err != nil {
+		return fmt.Errorf("%s signature verification failed: %w", alg, err)
+	}
+	return nil
+}
+
+// signingString returns the string signed for body, as the Beckn signer builds it, followed by a
+// "name: value" line for each of headers.
+func signingString(body []byte, created, expires int64, headers ...signedHeader) []byte {
+	digest := blake2b.Sum512(body)
+	s := fmt.Appendf(nil, "(created): %d\n(expires): %d\ndigest: BLAKE-512=%s", created, expires, base64.StdEncoding.EncodeToString(digest[:]))
+	for _, h := range headers {
+		s = fmt.Appendf(s, "\n%s: %s", h.name, h.value)
+	}
+	return s
+}
+
+// defaultSignedHeaders are the headers parameter of a Beckn signature covering only its body.
+var defaultSignedHeaders = []string{"(created)", "(expires)", "digest"}
+
+// signedHeader is an HTTP header covered by a signature, named in lower case as in its headers parameter.
+type signedHeader struct {
+	name, value string
+}
+
+// extraSignedHeaders returns the HTTP headers the headers parameter of header lists after the
+// default ones. A header without a headers parameter covers only its body.
+func extraSignedHeaders(header string) ([]string, error) {
+	listed := strings.Fields(authParam(header, "headers"))
+	if len(listed) == 0 {
+		return nil, nil
+	}
+	if len(listed) < len(defaultSignedHeaders) || strings.Join(listed[:len(defaultSignedHeaders)], " ") != strings.Join(defaultSignedHeaders, " ") {
+		return nil, fmt.Errorf("headers parameter %q must start with %q", strings.Join(listed, " "), strings.Join(defaultSignedHeaders, " "))
+	}
+	if len(listed) == len(defaultSignedHeaders) {
+		return nil, nil
+	}
+	return listed[len(defaultSignedHeaders):], nil
+}
+
+// signatureVerifiers are the verification routines of the algorithms signatures covering
+// HTTP headers can use, by name.
+var signatureVerifiers = map[string]verifyFunc{
+	model.SignatureAlgorithmEd25519:   verifyEd25519,
+	model.SignatureAlgorithmRSA:       verifyRSA,
+	model.SignatureAlgorithmSecp256k1: verifySecp256k1,
+}
+
+// checkSignatureTimes rejects a signature without created and expires parameters, or one created
+// after now or expired before now by more than skew.
+func checkSignatureTimes(ah *model.AuthHeader, now time.Time, skew time.Duration) error {
+	if ah.Created.IsZero() || ah.Expires.IsZero() {
+		return errors.New("created and expires parameters are required")
+	}
+	if ah.Created.After(now.Add(skew)) || now.After(ah.Expires.Add(skew)) {
+		return errors.New("signature is expired or not yet valid")
+	}
+	return nil
+}
+
+// verifySignedHeaders verifies the signature in header of body with publicKeyBase64 at now, give or
+// take skew, for a signature whose headers parameter lists HTTP headers after the default ones. The value
+// of each listed header is taken from values and appended to the signing string in the listed order.
+func verifySignedHeaders(now time.Time, skew time.Duration, body []byte, header, publicKeyBase64 string, values http.Header) error {
+	ah, err := parseAuthHeader(header)
+	if err != nil {
+		return fmt.Errorf("error parsing header: %w", err)
+	}
+	names, err := extraSignedHeaders(header)
+	if err != nil {
+		return err
+	}
+	headers := make([]signedHeader, 0, len(names))
+	for _, name := range names {
+		v, ok := values[http.CanonicalHeaderKey(name)]
+		if !ok || len(v) == 0 {
+			return fmt.Errorf("signed header %s missing", name)
+		}
+		headers = append(headers, signedHeader{name: strings.ToLower(name), value: v[0]})
+	}
+	alg := strings.ToLower(ah.Algorithm)
+	verify, ok := signatureVerifiers[alg]
+	if !ok {
+		return &UnsupportedAlgorithmError{Algorithm: ah.Algorithm}
+	}
+	if err := checkSignatureTimes(ah, now, skew); err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(authParam(header, "signature"))
+	if err != nil || len(sig) == 0 {
+		return errors.New("signature missing or not base64")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return fmt.Errorf("error decoding public key: %w", err)
+	}
+	if err := verify(key, signingString(body, ah.Created.Unix(), ah.Expires.Unix(), headers...), sig); err != nil {
+		return fmt.Errorf("%s signature verification failed: %w", alg, err)
+	}
+	return nil
+}
+
+// verifyEd25519 verifies an Ed25519 signature with a raw Ed25519 public key.
+func verifyEd25519(key, msg, sig []byte) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key length %d", len(key))
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), msg, sig) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// verifyRSA verifies a PKCS #1 v1.5 SHA-256 signature with a DER RSA public key.
+func verifyRSA(key, msg, sig []byte) error {
+	pub, err := parseRSAPublicKey(key)
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256(msg)
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig)
+}
+
+// parseRSAPublicKey parses a PKIX or PKCS #1 DER RSA public key.
+func parseRSAPublicKey(der []byte) (*rsa.PublicKey, error) {
+	if pub, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return pub, nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T, not an RSA key", pub)
+	}
+	return rsaPub, nil
+}
+
+// verifySecp256k1 verifies an ECDSA SHA-256 signature, DER or R and S, with a SEC 1 secp256k1 public key.
+func verifySecp256k1(key, msg, sig []byte) error {
+	pub, err := secp256k1.ParsePubKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid secp256k1 public key: %w", err)
+	}
+	var signature *ecdsa.Signature
+	if len(sig) == 64 {
+		var r, s secp256k1.ModNScalar
+		if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+			return errors.New("signature R or S overflows the curve order")
+		}
+		signature = ecdsa.NewSignature(&r, &s)
+	} else if signature, err = ecdsa.ParseDERSignature(sig); err != nil {
+		return fmt.Errorf("invalid secp256k1 signature: %w", err)
+	}
+	hashed := sha256.Sum256(msg)
+	if !signature.Verify(hashed[:], pub) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/google/go-cmp/cmp"
+)
+
+// testSigner signs signing strings with a key of one algorithm.
+type testSigner struct {
+	alg  string
+	pub  string // Base64 public key.
+	sign func(msg []byte) []byte
+}
+
+func newTestSigners(t *testing.T) map[string]testSigner {
+	t.Helper()
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ed25519 key: %v", err)
+	}
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	rsaPub, err := x509.MarshalPKIXPublicKey(&rsaPriv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal RSA key: %v", err)
+	}
+	k1Priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate secp256k1 key: %v", err)
+	}
+	b64 := base64.StdEncoding.EncodeToString
+	return map[string]testSigner{
+		"ed25519": {alg: model.SignatureAlgorithmEd25519, pub: b64(edPub), sign: func(msg []byte) []byte {
+			return ed25519.Sign(edPriv, msg)
+		}},
+		"rsa": {alg: model.SignatureAlgorithmRSA, pub: b64(rsaPub), sign: func(msg []byte) []byte {
+			hashed := sha256.Sum256(msg)
+			sig, err := rsa.SignPKCS1v15(rand.Reader, rsaPriv, crypto.SHA256, hashed[:])
+			if err != nil {
+				t.Fatalf("rsa.SignPKCS1v15() error = %v", err)
+			}
+			return sig
+		}},
+		"rsa pkcs1 key": {alg: model.SignatureAlgorithmRSA, pub: b64(x509.MarshalPKCS1PublicKey(&rsaPriv.PublicKey)), sign: func(msg []byte) []byte {
+			hashed := sha256.Sum256(msg)
+			sig, _ := rsa.SignPKCS1v15(rand.Reader, rsaPriv, crypto.SHA256, hashed[:])
+			return sig
+		}},
+		"secp256k1 der": {alg: model.SignatureAlgorithmSecp256k1, pub: b64(k1Priv.PubKey().SerializeCompressed()), sign: func(msg []byte) []byte {
+			hashed := sha256.Sum256(msg)
+			return ecdsa.Sign(k1Priv, hashed[:]).Serialize()
+		}},
+		"secp256k1 r and s": {alg: model.SignatureAlgorithmSecp256k1, pub: b64(k1Priv.PubKey().SerializeUncompressed()), sign: func(msg []byte) []byte {
+			hashed := sha256.Sum256(msg)
+			sig := ecdsa.Sign(k1Priv, hashed[:])
+			r, s := sig.R(), sig.S()
+			rb, sb := r.Bytes(), s.Bytes()
+			return append(rb[:], sb[:]...)
+		}},
+	}
+}
+
+// signedHeader returns the signature header of body signed by s, valid from created to expires.
+func (s testSigner) signedHeader(body []byte, created, expires time.Time) string {
+	sig := s.sign(signingString(body, created.Unix(), expires.Unix()))
+	return fmt.Sprintf(`Signature keyId="np.com|key1|%s",algorithm="%s",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
+		s.alg, s.alg, created.Unix(), expires.Unix(), base64.StdEncoding.EncodeToString(sig))
+}
+
+func TestAlgSignValidator_Validate(t *testing.T) {
+	ctx := context.Background()
+	ed, _, err := signvalidator.New(ctx, &signvalidator.Config{})
+	if err != nil {
+		t.Fatalf("signvalidator.New() error = %v", err)
+	}
+	v, err := NewAlgSignValidator(ed)
+	if err != nil {
+		t.Fatalf("NewAlgSignValidator() error = %v", err)
+	}
+	signers := newTestSigners(t)
+	other := newTestSigners(t)
+	body := []byte(`{"subscriber_id":"np.com"}`)
+	now := time.Now()
+
+	for name, s := range signers {
+		tests := []struct {
+			name    string
+			body    []byte
+			header  string
+			pub     string
+			wantErr bool
+		}{
+			{name: "valid", body: body, header: s.signedHeader(body, now.Add(-time.Minute), now.Add(time.Minute)), pub: s.pub},
+			{name: "tampered body", body: []byte(`{"subscriber_id":"evil.com"}`), header: s.signedHeader(body, now.Add(-time.Minute), now.Add(time.Minute)), pub: s.pub, wantErr: true},
+			{name: "other key", body: body, header: s.signedHeader(body, now.Add(-time.Minute), now.Add(time.Minute)), pub: other[name].pub, wantErr: true},
+			{name: "expired", body: body, header: s.signedHeader(body, now.Add(-time.Hour), now.Add(-time.Minute)), pub: s.pub, wantErr: true},
+			{name: "invalid public key", body: body, header: s.signedHeader(body, now.Add(-time.Minute), now.Add(time.Minute)), pub: "bm90IGEga2V5", wantErr: true},
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				err := v.Validate(ctx, tt.body, tt.header, tt.pub)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestAlgSignValidator_Validate_ClockSkew(t *testing.T) {
+	signers := newTestSigners(t)
+	body := []byte(`{"subscriber_id":"np.com"}`)
+	now := time.Now()
+	tests := []struct {
+		name    string
+		skew    time.Duration
+		created time.Time
+		expires time.Time
+		wantErr bool
+	}{
+		{name: "created in the future within the skew", skew: DefaultSignatureClockSkew, created: now.Add(10 * time.Second), expires: now.Add(time.Minute)},
+		{name: "expired within the skew", skew: DefaultSignatureClockSkew, created: now.Add(-time.Minute), expires: now.Add(-10 * time.Second)},
+		{name: "created in the future beyond the skew", skew: DefaultSignatureClockSkew, created: now.Add(time.Minute), expires: now.Add(2 * time.Minute), wantErr: true},
+		{name: "created in the future without skew", skew: 0, created: now.Add(10 * time.Second), expires: now.Add(time.Minute), wantErr: true},
+	}
+	for _, alg := range []string{"rsa", "secp256k1 der"} {
+		for _, tt := range tests {
+			t.Run(alg+"/"+tt.name, func(t *testing.T) {
+				v, err := NewAlgSignValidator(&mockSignValidator{})
+				if err != nil {
+					t.Fatalf("NewAlgSignValidator() error = %v", err)
+				}
+				v.SetClockSkew(tt.skew)
+				v.now = func() time.Time { return now }
+
+				err = v.Validate(context.Background(), body, signers[alg].signedHeader(body, tt.created, tt.expires), signers[alg].pub)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestAlgSignValidator_Validate_UnsupportedAlgorithm(t *testing.T) {
+	v, err := NewAlgSignValidator(&mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAlgSignValidator() error = %v", err)
+	}
+
+	err = v.Validate(context.Background(), []byte(`{}`), `Signature keyId="np.com|key1|dsa",algorithm="dsa",signature="c2ln"`, "a2V5")
+	var unsupported *UnsupportedAlgorithmError
+	if !errors.As(err, &unsupported) || unsupported.Algorithm != "dsa" {
+		t.Errorf("Validate() error = %v, want an UnsupportedAlgorithmError for dsa", err)
+	}
+}
+
+func TestAlgSignValidator_Validate_KeyIDFormat(t *testing.T) {
+	v, err := NewAlgSignValidator(&mockSignValidator{})
+	if err != nil {
+		t.Fatalf("NewAlgSignValidator() error = %v", err)
+	}
+
+	if err := v.Validate(context.Background(), []byte(`{}`), `Signature keyId="np.com|secp256k1",algorithm="secp256k1"`, "a2V5"); err == nil {
+		t.Error("Validate() error = nil, want an error for a keyId of two components")
+	}
+}
+
+func TestNewAlgSignValidator_NilEd25519(t *testing.T) {
+	if _, err := NewAlgSignValidator(nil); err == nil {
+		t.Error("NewAlgSignValidator(nil) error = nil, want error")
+	}
+}
+
+func TestExtraSignedHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no headers parameter", header: `Signature keyId="a|k|ed25519"`},
+		{name: "default headers", header: `Signature keyId="a|k|ed25519",headers="(created) (expires) digest"`},
+		{name: "request id", header: `Signature keyId="a|k|ed25519",headers="(created) (expires) digest x-request-id"`, want: []string{"x-request-id"}},
+		{name: "digest not covered", header: `Signature keyId="a|k|ed25519",headers="(created) (expires) x-request-id"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extraSignedHeaders(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extraSignedHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("extraSignedHeaders() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
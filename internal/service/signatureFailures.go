@@ -0,0 +1,175 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/clientaddr"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// SignatureFailureAlertConfig configures the alerts raised when the requests of a subscriber
+// repeatedly fail signature validation.
+type SignatureFailureAlertConfig struct {
+	Threshold int           `yaml:"threshold"` // Failures within the window raising an alert, 0 disables alerts.
+	Window    time.Duration `yaml:"window"`    // How long failures are counted for, from the first one.
+	BlockFor  time.Duration `yaml:"blockFor"`  // If set, the subscriber's requests are rejected for this long after an alert.
+}
+
+// Validate returns an error naming the first invalid setting.
+func (c SignatureFailureAlertConfig) Validate() error {
+	if c.Threshold < 0 {
+		return fmt.Errorf("threshold %d must not be negative", c.Threshold)
+	}
+	if c.Threshold > 0 && c.Window <= 0 {
+		return fmt.Errorf("window %s must be positive", c.Window)
+	}
+	if c.BlockFor < 0 {
+		return fmt.Errorf("blockFor %s must not be negative", c.BlockFor)
+	}
+	return nil
+}
+
+// signatureAlertPublisher publishes signature failure alerts. This can be implemented by event.Publisher.
+type signatureAlertPublisher interface {
+	PublishSignatureFailureAlertEvent(ctx context.Context, alert *model.SignatureFailureAlert) (string, error)
+}
+
+// failureWindow counts the signature failures of a subscriber from a source since start.
+type failureWindow struct {
+	start    time.Time
+	failures int
+}
+
+// failureKey identifies the requests of a subscriber from a source. Failed requests only claim to be
+// from their subscriber, so failures are counted, and the subscriber blocked, per source: forged
+// requests naming a subscriber cannot block its requests from elsewhere.
+type failureKey struct {
+	subscriberID string
+	source       string // Client address of the requests, see clientaddr.
+}
+
+// signatureFailureTracker counts the signature failures of each subscriber from each source within a
+// window, and raises an alert when they reach a threshold, optionally blocking the subscriber's
+// requests from that source for a while. A nil tracker tracks nothing.
+type signatureFailureTracker struct {
+	threshold int
+	window    time.Duration
+	blockFor  time.Duration
+	pub       signatureAlertPublisher // Nil means alerts are only logged.
+	now       func() time.Time
+	publishes sync.WaitGroup // Alerts being published.
+
+	mu      sync.Mutex
+	windows map[failureKey]*failureWindow
+	blocked map[failureKey]time.Time // Subscribers blocked from a source until the given time.
+}
+
+// newSignatureFailureTracker creates a tracker configured by cfg publishing its alerts with pub, if not nil.
+func newSignatureFailureTracker(cfg SignatureFailureAlertConfig, pub signatureAlertPublisher) *signatureFailureTracker {
+	return &signatureFailureTracker{
+		threshold: cfg.Threshold,
+		window:    cfg.Window,
+		blockFor:  cfg.BlockFor,
+		pub:       pub,
+		now:       time.Now,
+		windows:   make(map[failureKey]*failureWindow),
+		blocked:   make(map[failureKey]time.Time),
+	}
+}
+
+// check returns an error if subscriberID is blocked from the source of ctx after repeated signature failures.
+func (t *signatureFailureTracker) check(ctx context.Context, subscriberID string) *model.AuthError {
+	if t == nil {
+		return nil
+	}
+	key := failureKey{subscriberID: subscriberID, source: clientaddr.FromContext(ctx)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.blocked[key]
+	if !ok {
+		return nil
+	}
+	if !t.now().Before(until) {
+		delete(t.blocked, key)
+		return nil
+	}
+	slog.WarnContext(ctx, "signatureFailureTracker: Rejecting request of blocked subscriber", "subscriber_id", subscriberID, "source", key.source, "blocked_until", until)
+	return model.NewAuthError(http.StatusForbidden, model.ErrorTypeAuthError, model.ErrorCodeSubscriberBlocked, "Subscriber is temporarily blocked after repeated signature failures.", subscriberID)
+}
+
+// fail records a signature failure of subscriberID from the source of ctx. The failure reaching the
+// threshold within the window raises an alert, published in the background, and blocks the subscriber
+// from that source if configured. Failures start being counted again once the window has passed.
+func (t *signatureFailureTracker) fail(ctx context.Context, subscriberID string) {
+	if t == nil {
+		return
+	}
+	key := failureKey{subscriberID: subscriberID, source: clientaddr.FromContext(ctx)}
+	t.mu.Lock()
+	now := t.now()
+	w, ok := t.windows[key]
+	if !ok || !now.Before(w.start.Add(t.window)) {
+		t.purge(now)
+		w = &failureWindow{start: now}
+		t.windows[key] = w
+	}
+	w.failures++
+	if w.failures != t.threshold {
+		t.mu.Unlock()
+		return
+	}
+	alert := &model.SignatureFailureAlert{
+		SubscriberID: subscriberID,
+		Source:       key.source,
+		Failures:     w.failures,
+		WindowStart:  w.start,
+		LastFailure:  now,
+	}
+	if t.blockFor > 0 {
+		until := now.Add(t.blockFor)
+		t.blocked[key] = until
+		alert.BlockedUntil = &until
+	}
+	t.mu.Unlock()
+
+	slog.WarnContext(ctx, "signatureFailureTracker: Repeated signature failures", "subscriber_id", subscriberID, "source", key.source, "failures", alert.Failures, "window_start", alert.WindowStart, "blocked", alert.BlockedUntil != nil)
+	if t.pub == nil {
+		return
+	}
+	t.publishes.Add(1)
+	go func() {
+		defer t.publishes.Done()
+		ctx := context.WithoutCancel(ctx)
+		if _, err := t.pub.PublishSignatureFailureAlertEvent(ctx, alert); err != nil {
+			slog.ErrorContext(ctx, "signatureFailureTracker: Failed to publish signature failure alert", "error", err, "subscriber_id", subscriberID)
+		}
+	}()
+}
+
+// purge drops the windows that have passed. The caller must hold t.mu.
+func (t *signatureFailureTracker) purge(now time.Time) {
+	for key, w := range t.windows {
+		if !now.Before(w.start.Add(t.window)) {
+			delete(t.windows, key)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/internal/clientaddr"
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockSignatureAlertPublisher is a mock signatureAlertPublisher recording the published alerts.
+type mockSignatureAlertPublisher struct {
+	mu     sync.Mutex
+	alerts []*model.SignatureFailureAlert
+	err    error
+}
+
+func (m *mockSignatureAlertPublisher) PublishSignatureFailureAlertEvent(ctx context.Context, alert *model.SignatureFailureAlert) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerts = append(m.alerts, alert)
+	return "msg-id", m.err
+}
+
+func TestSignatureFailureAlertConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SignatureFailureAlertConfig
+		wantErr string
+	}{
+		{name: "disabled", cfg: SignatureFailureAlertConfig{}},
+		{name: "valid", cfg: SignatureFailureAlertConfig{Threshold: 5, Window: time.Minute, BlockFor: time.Hour}},
+		{name: "negative threshold", cfg: SignatureFailureAlertConfig{Threshold: -1}, wantErr: "threshold -1 must not be negative"},
+		{name: "threshold without window", cfg: SignatureFailureAlertConfig{Threshold: 5}, wantErr: "window 0s must be positive"},
+		{name: "negative blockFor", cfg: SignatureFailureAlertConfig{Threshold: 5, Window: time.Minute, BlockFor: -time.Second}, wantErr: "blockFor -1s must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignatureFailureTracker_AlertAtThreshold(t *testing.T) {
+	pub := &mockSignatureAlertPublisher{}
+	tr := newSignatureFailureTracker(SignatureFailureAlertConfig{Threshold: 3, Window: time.Minute}, pub)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	tr.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		tr.fail(ctx, "sub")
+		now = now.Add(10 * time.Second)
+	}
+	tr.publishes.Wait()
+	if len(pub.alerts) != 0 {
+		t.Fatalf("alerts below threshold = %d, want 0", len(pub.alerts))
+	}
+	tr.fail(ctx, "sub")
+	tr.fail(ctx, "sub") // Beyond the threshold, no further alert within the window.
+	want := []*model.SignatureFailureAlert{{SubscriberID: "sub", Failures: 3, WindowStart: start, LastFailure: start.Add(20 * time.Second)}}
+	tr.publishes.Wait()
+	if diff := cmp.Diff(want, pub.alerts); diff != "" {
+		t.Errorf("alerts mismatch (-want +got):\n%s", diff)
+	}
+	if authErr := tr.check(ctx, "sub"); authErr != nil {
+		t.Errorf("check() = %v, want nil without blockFor", authErr)
+	}
+}
+
+func TestSignatureFailureTracker_CounterResetsAfterWindow(t *testing.T) {
+	pub := &mockSignatureAlertPublisher{}
+	tr := newSignatureFailureTracker(SignatureFailureAlertConfig{Threshold: 3, Window: time.Minute}, pub)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	tr.fail(ctx, "sub")
+	tr.fail(ctx, "sub")
+	now = now.Add(time.Minute)
+	tr.fail(ctx, "sub")
+	tr.fail(ctx, "sub")
+	tr.publishes.Wait()
+	if len(pub.alerts) != 0 {
+		t.Fatalf("alerts = %d, want 0 as the failures span two windows", len(pub.alerts))
+	}
+	tr.fail(ctx, "sub")
+	tr.publishes.Wait()
+	if len(pub.alerts) != 1 || pub.alerts[0].WindowStart != now {
+		t.Errorf("alerts = %+v, want one alert for the window starting at %s", pub.alerts, now)
+	}
+	tr.fail(ctx, "other")
+	tr.publishes.Wait()
+	if len(pub.alerts) != 1 {
+		t.Errorf("alerts after another subscriber's failure = %d, want 1", len(pub.alerts))
+	}
+}
+
+func TestSignatureFailureTracker_Block(t *testing.T) {
+	pub := &mockSignatureAlertPublisher{err: errors.New("pubsub down")}
+	tr := newSignatureFailureTracker(SignatureFailureAlertConfig{Threshold: 2, Window: time.Minute, BlockFor: 5 * time.Minute}, pub)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+	ctx := clientaddr.NewContext(context.Background(), "192.0.2.1")
+
+	tr.fail(ctx, "sub")
+	tr.fail(ctx, "sub")
+	until := now.Add(5 * time.Minute)
+	tr.publishes.Wait()
+	if len(pub.alerts) != 1 || pub.alerts[0].BlockedUntil == nil || !pub.alerts[0].BlockedUntil.Equal(until) {
+		t.Fatalf("alerts = %+v, want one alert blocking until %s", pub.alerts, until)
+	}
+	authErr := tr.check(ctx, "sub")
+	if authErr == nil || authErr.StatusCode != http.StatusForbidden || authErr.ErrorCode != model.ErrorCodeSubscriberBlocked {
+		t.Errorf("check() = %v, want %d %s", authErr, http.StatusForbidden, model.ErrorCodeSubscriberBlocked)
+	}
+	if authErr := tr.check(ctx, "other"); authErr != nil {
+		t.Errorf("check(other) = %v, want nil", authErr)
+	}
+	if authErr := tr.check(clientaddr.NewContext(context.Background(), "198.51.100.1"), "sub"); authErr != nil {
+		t.Errorf("check() from another source = %v, want nil", authErr)
+	}
+	now = until
+	if authErr := tr.check(ctx, "sub"); authErr != nil {
+		t.Errorf("check() after the block = %v, want nil", authErr)
+	}
+}
+
+func TestTxnSignValidator_SetSignatureFailureAlerts(t *testing.T) {
+	sv := &mockSignValidator{err: errors.New("bad signature")}
+	v, err := NewTxnSignValidator(sv, &mockNPKeyProvider{signingKey: "key"})
+	if err != nil {
+		t.Fatalf("NewTxnSignValidator() error = %v", err)
+	}
+	pub := &mockSignatureAlertPublisher{}
+	v.SetSignatureFailureAlerts(SignatureFailureAlertConfig{Threshold: 2, Window: time.Minute, BlockFor: time.Minute}, pub)
+	authHeader := `Signature keyId="sub|k1|ed25519",algorithm="ed25519"`
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if authErr := v.Validate(ctx, []byte(`{}`), authHeader, ""); authErr == nil || authErr.ErrorCode != model.ErrorCodeInvalidSignature {
+			t.Fatalf("Validate() = %v, want %s", authErr, model.ErrorCodeInvalidSignature)
+		}
+	}
+	v.failures.publishes.Wait()
+	if len(pub.alerts) != 1 || pub.alerts[0].SubscriberID != "sub" {
+		t.Fatalf("alerts = %+v, want one alert for sub", pub.alerts)
+	}
+	sv.err = nil
+	if authErr := v.Validate(ctx, []byte(`{}`), authHeader, ""); authErr == nil || authErr.ErrorCode != model.ErrorCodeSubscriberBlocked {
+		t.Errorf("Validate() of blocked subscriber = %v, want %s", authErr, model.ErrorCodeSubscriberBlocked)
+	}
+}
+
+func TestSubscriptionAuth_SetSignatureFailureAlerts(t *testing.T) {
+	sv := &mockSignValidator{err: errors.New("bad signature")}
+	auth, err := NewAuthService(&mockSubscriptionKeyProvider{key: "key"}, sv)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	pub := &mockSignatureAlertPublisher{}
+	auth.SetSignatureFailureAlerts(SignatureFailureAlertConfig{Threshold: 2, Window: time.Minute}, pub)
+	authHeader := `Signature keyId="sub|k1|ed25519",algorithm="ed25519"`
+	body := []byte(`{"subscriber_id":"sub","key_id":"k1","signing_public_key":"key"}`)
+	ctx := context.Background()
+
+	if _, authErr := auth.AuthenticatedReq(ctx, body, authHeader); authErr == nil {
+		t.Fatal("AuthenticatedReq() error = nil, want an invalid signature")
+	}
+	if _, authErr := auth.AuthenticatedCreateReq(ctx, body, authHeader); authErr == nil {
+		t.Fatal("AuthenticatedCreateReq() error = nil, want an invalid signature")
+	}
+	auth.failures.publishes.Wait()
+	if len(pub.alerts) != 1 || pub.alerts[0].Failures != 2 {
+		t.Errorf("alerts = %+v, want one alert after 2 failures", pub.alerts)
+	}
+}
+
+func TestSignatureFailureTracker_CountsPerSource(t *testing.T) {
+	pub := &mockSignatureAlertPublisher{}
+	tr := newSignatureFailureTracker(SignatureFailureAlertConfig{Threshold: 2, Window: time.Minute, BlockFor: time.Minute}, pub)
+	attacker := clientaddr.NewContext(context.Background(), "203.0.113.9")
+	victim := clientaddr.NewContext(context.Background(), "192.0.2.1")
+
+	tr.fail(attacker, "sub")
+	tr.fail(victim, "sub")
+	tr.publishes.Wait()
+	if len(pub.alerts) != 0 {
+		t.Fatalf("alerts = %d, want 0 as each source failed once", len(pub.alerts))
+	}
+	tr.fail(attacker, "sub")
+	tr.publishes.Wait()
+	if len(pub.alerts) != 1 || pub.alerts[0].Source != "203.0.113.9" {
+		t.Fatalf("alerts = %+v, want one alert for source 203.0.113.9", pub.alerts)
+	}
+	if authErr := tr.check(attacker, "sub"); authErr == nil {
+		t.Error("check() from the failing source = nil, want blocked")
+	}
+	if authErr := tr.check(victim, "sub"); authErr != nil {
+		t.Errorf("check() from another source = %v, want nil", authErr)
+	}
+}
@@ -20,10 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	becknmodel "github.com/beckn-one/beckn-onix/pkg/model"
 )
@@ -40,15 +44,24 @@ var (
 	ErrKeyFetchFailed          = errors.New("key fetch failed")
 	ErrKeyStoreFailed          = errors.New("key store failed")
 	ErrRegistryOperationFailed = errors.New("registry operation failed")
+	ErrRegistryUnavailable     = errors.New("registry unavailable, retry later")
 	ErrSigningFailed           = errors.New("signing failed")
+	ErrOnSubscribeTimeout      = errors.New("on_subscribe processing timed out")
+	ErrInvalidAuthScheme       = errors.New("invalid auth_scheme")
+	ErrGatewaySchemeDisabled   = errors.New("the GATEWAY auth scheme requires a gateway subscriber ID to be configured")
+	ErrInvalidAuthKeyID        = errors.New("auth_key_id is not the keyset of the auth scheme")
+	ErrSubscriptionNotFound    = errors.New("subscription not found")
+	ErrInvalidValidity         = errors.New("valid_until must be in the future")
 )
 
 // registryClient defines the interface for interacting with the registry component
 // for subscription and LRO management.
 type registryClient interface {
 	CreateSubscription(ctx context.Context, req *model.SubscriptionRequest) (*model.SubscriptionResponse, error)
-	UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error)
+	UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, scheme model.AuthScheme, authHeader string) (*model.SubscriptionResponse, error)
 	GetOperation(ctx context.Context, operationID string) (*model.LRO, error)
+	DeleteSubscription(ctx context.Context, req *model.DeleteSubscriptionRequest, authHeader string) error
+	Unsubscribe(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error)
 }
 
 // onSubscribeEventPublisher defines the interface for publishing an OnSubscribeRecievedEvent.
@@ -79,6 +92,25 @@ type subscriberService struct {
 	authGen  authGen
 	regID    string
 	regKeyID string // Public encryption key of the Registry, used as sender key in decryption
+	// onSubscribeTimeout bounds the time spent processing an OnSubscribe request. Zero means no timeout.
+	onSubscribeTimeout time.Duration
+	// validity is how long subscriptions are valid for when the request has no valid_until.
+	validity      time.Duration
+	keyStoreRetry KeyStoreRetryConfig
+	// gatewayID is the subscriber ID of the gateway whose keyset signs GATEWAY scheme updates.
+	// Empty rejects the GATEWAY scheme.
+	gatewayID string
+}
+
+// DefaultSubscriptionValidity is how long subscriptions are valid for by default.
+const DefaultSubscriptionValidity = 365 * 24 * time.Hour
+
+// KeyStoreRetryConfig configures the retry of transient failures to store a keyset,
+// such as Secret Manager throttling. Permanent failures are never retried.
+type KeyStoreRetryConfig struct {
+	RetryMax     int           `yaml:"retryMax"` // Maximum number of retries. 0 disables retries.
+	RetryWaitMin time.Duration `yaml:"waitMin"`  // Base backoff, doubled on every retry.
+	RetryWaitMax time.Duration `yaml:"waitMax"`  // Upper bound of the backoff. 0 means no bound.
 }
 
 // NewSubscriberService creates a new subscriberService.
@@ -89,6 +121,8 @@ func NewSubscriberService(
 	evPub onSubscribeEventPublisher,
 	authGen authGen,
 	regID, regKeyID string,
+	onSubscribeTimeout time.Duration,
+	validity time.Duration,
 ) (*subscriberService, error) {
 	if registry == nil {
 		return nil, errors.New("registryClient cannot be nil")
@@ -111,17 +145,82 @@ func NewSubscriberService(
 	if regKeyID == "" {
 		return nil, errors.New("regKeyID cannot be empty")
 	}
+	if validity < 0 {
+		return nil, errors.New("validity cannot be negative")
+	}
+	if validity == 0 {
+		validity = DefaultSubscriptionValidity
+	}
 	return &subscriberService{
-		registry: registry,
-		keyMgr:   keyMgr,
-		dec:      dec,
-		evPub:    evPub,
-		regID:    regID,
-		regKeyID: regKeyID,
-		authGen:  authGen,
+		registry:           registry,
+		keyMgr:             keyMgr,
+		dec:                dec,
+		evPub:              evPub,
+		regID:              regID,
+		regKeyID:           regKeyID,
+		authGen:            authGen,
+		onSubscribeTimeout: onSubscribeTimeout,
+		validity:           validity,
 	}, nil
 }
 
+// SetKeyStoreRetry retries transient keyset store failures in CreateSubscription,
+// UpdateSubscription and UpdateStatus per cfg.
+func (s *subscriberService) SetKeyStoreRetry(cfg KeyStoreRetryConfig) {
+	s.keyStoreRetry = cfg
+}
+
+// SetGatewaySubscriberID lets updates use the GATEWAY auth scheme, signed with the keyset of id,
+// the subscriber ID of the gateway this service acts as. An empty id rejects the GATEWAY scheme.
+func (s *subscriberService) SetGatewaySubscriberID(id string) {
+	s.gatewayID = id
+}
+
+// transientKeyStoreErr reports whether a keyset store failure is likely to succeed on retry.
+func transientKeyStoreErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// insertKeyset stores keys under keyID, retrying transient failures per the key store retry config.
+func (s *subscriberService) insertKeyset(ctx context.Context, keyID string, keys *becknmodel.Keyset) error {
+	for attempt := 0; ; attempt++ {
+		err := s.keyMgr.InsertKeyset(ctx, keyID, keys)
+		if err == nil || attempt >= s.keyStoreRetry.RetryMax || !transientKeyStoreErr(err) {
+			return err
+		}
+		wait := s.keyStoreRetry.RetryWaitMin << attempt
+		if s.keyStoreRetry.RetryWaitMax > 0 && wait > s.keyStoreRetry.RetryWaitMax {
+			wait = s.keyStoreRetry.RetryWaitMax
+		}
+		slog.WarnContext(ctx, "SubscriberService: Transient failure to insert keyset, retrying", "key_id", keyID, "attempt", attempt+1, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// discardKeyset deletes the keyset stored under keyID for a request the registry failed with regErr,
+// if the registry definitively rejected it. Otherwise, e.g. on a timeout, the registry may have
+// accepted the request, so the keyset is kept for the subscription that may refer to it.
+// A failure is only logged, since the request has failed already.
+func (s *subscriberService) discardKeyset(ctx context.Context, keyID string, regErr error) {
+	if !errors.Is(regErr, client.ErrRegistryRejected) {
+		slog.WarnContext(ctx, "SubscriberService: Keeping keyset of failed request, the registry may have accepted it", "key_id", keyID, "error", regErr)
+		return
+	}
+	if err := s.keyMgr.DeleteKeyset(ctx, keyID); err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to delete keyset of failed request", "key_id", keyID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "SubscriberService: Deleted keyset of failed request", "key_id", keyID)
+}
+
 func (s *subscriberService) validateSubscriptionRequest(req *model.NpSubscriptionRequest) error {
 	if req.SubscriberID == "" {
 		return ErrMissingSubscriberID
@@ -132,6 +231,9 @@ func (s *subscriberService) validateSubscriptionRequest(req *model.NpSubscriptio
 	if req.Type == "" {
 		return ErrMissingType
 	}
+	if !req.ValidUntil.IsZero() && !req.ValidUntil.After(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrInvalidValidity, req.ValidUntil.Format(time.RFC3339))
+	}
 	return nil
 }
 
@@ -159,8 +261,14 @@ func (s *subscriberService) keySet(ctx context.Context, req *model.NpSubscriptio
 	return keys, nil
 }
 
-func subscriptionRequest(npReq *model.NpSubscriptionRequest, keys *becknmodel.Keyset) *model.SubscriptionRequest {
+// subscriptionRequest builds the registry request of npReq, valid until npReq.ValidUntil if set,
+// else for the configured validity.
+func (s *subscriberService) subscriptionRequest(npReq *model.NpSubscriptionRequest, keys *becknmodel.Keyset) *model.SubscriptionRequest {
 	now := time.Now().UTC()
+	validUntil := now.Add(s.validity)
+	if !npReq.ValidUntil.IsZero() {
+		validUntil = npReq.ValidUntil.UTC()
+	}
 	return &model.SubscriptionRequest{
 		MessageID: npReq.MessageID,
 		Subscription: model.Subscription{
@@ -175,7 +283,7 @@ func subscriptionRequest(npReq *model.NpSubscriptionRequest, keys *becknmodel.Ke
 			SigningPublicKey: keys.SigningPublic,
 			EncrPublicKey:    keys.EncrPublic,
 			ValidFrom:        now,
-			ValidUntil:       now.AddDate(100, 0, 0), // Valid for 100 years
+			ValidUntil:       validUntil,
 			Nonce:            uuid.NewString(),
 		},
 	}
@@ -196,14 +304,19 @@ func (s *subscriberService) CreateSubscription(ctx context.Context, req *model.N
 		return "", err
 	}
 
-	if err := s.keyMgr.InsertKeyset(ctx, req.MessageID, keys); err != nil {
+	if err := s.insertKeyset(ctx, req.MessageID, keys); err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to insert keyset ", "subscriber_id", req.SubscriberID, "key_id", keys.UniqueKeyID, "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
 	}
 
-	resp, err := s.registry.CreateSubscription(ctx, subscriptionRequest(req, keys))
+	resp, err := s.registry.CreateSubscription(ctx, s.subscriptionRequest(req, keys))
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Registry CreateSubscription failed", "error", err)
+		// No operation refers to a rejected keyset, so it would never be cleaned up by UpdateStatus.
+		s.discardKeyset(ctx, req.MessageID, err)
+		if errors.Is(err, client.ErrRegistryUnavailable) {
+			return "", fmt.Errorf("%w: %w: %v", ErrRegistryUnavailable, ErrRegistryOperationFailed, err)
+		}
 		return "", fmt.Errorf("%w: %v", ErrRegistryOperationFailed, err)
 	}
 
@@ -216,6 +329,10 @@ func (s *subscriberService) UpdateSubscription(ctx context.Context, req *model.N
 	if err := s.validateSubscriptionRequest(req); err != nil {
 		return "", err
 	}
+	authKeyID, err := s.authKeyID(req)
+	if err != nil {
+		return "", err
+	}
 	if req.MessageID == "" {
 		req.MessageID = uuid.NewString()
 		slog.InfoContext(ctx, "SubscriberService: Generated new MessageID for UpdateSubscription", "message_id", req.MessageID)
@@ -226,25 +343,144 @@ func (s *subscriberService) UpdateSubscription(ctx context.Context, req *model.N
 		return "", err
 	}
 
-	if err := s.keyMgr.InsertKeyset(ctx, req.MessageID, keys); err != nil {
+	if err := s.insertKeyset(ctx, req.MessageID, keys); err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to insert keyset after registry update", "subscriber_id", req.SubscriberID, "key_id", keys.UniqueKeyID, "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
 	}
-	sreq := subscriptionRequest(req, keys)
-	authHeader, err := s.authHeader(ctx, sreq)
+	sreq := s.subscriptionRequest(req, keys)
+	authHeader, err := s.authHeader(ctx, sreq, authKeyID)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to generate auth header", "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyGenerationFailed, err)
 	}
-	resp, err := s.registry.UpdateSubscription(ctx, sreq, authHeader)
+	resp, err := s.registry.UpdateSubscription(ctx, sreq, req.AuthScheme, authHeader)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Registry UpdateSubscription failed", "error", err)
+		s.discardKeyset(ctx, req.MessageID, err)
+		if errors.Is(err, client.ErrRegistryUnavailable) {
+			return "", fmt.Errorf("%w: %w: %v", ErrRegistryUnavailable, ErrRegistryOperationFailed, err)
+		}
 		return "", fmt.Errorf("%w: %v", ErrRegistryOperationFailed, err)
 	}
 	slog.InfoContext(ctx, "SubscriberService: UpdateSubscription successful", "message_id", resp.MessageID, "status", resp.Status)
 	return resp.MessageID, nil
 }
 
+// DeleteSubscription removes the subscription of subscriberID's keyID from the Registry, signing the
+// request with the subscriber's keyset, and then deletes that keyset. An empty keyID deletes the
+// subscription of the subscriber's keyset. The keyset of another key ID than the subscriber's is kept.
+func (s *subscriberService) DeleteSubscription(ctx context.Context, subscriberID, keyID string) error {
+	if subscriberID == "" {
+		return ErrMissingSubscriberID
+	}
+	keys, err := s.keyMgr.Keyset(ctx, subscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch keyset for delete", "subscriber_id", subscriberID, "error", err)
+		return fmt.Errorf("%w: %v", ErrKeyFetchFailed, err)
+	}
+	if keyID == "" {
+		keyID = keys.UniqueKeyID
+	}
+	dreq := &model.DeleteSubscriptionRequest{
+		Method:       http.MethodDelete,
+		SubscriberID: subscriberID,
+		KeyID:        keyID,
+		Nonce:        uuid.NewString(),
+	}
+	body, err := json.Marshal(dreq)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to marshal request body", "error", err)
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	authHeader, err := s.authGen.AuthHeader(ctx, body, subscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to generate auth header", "error", err)
+		return fmt.Errorf("%w: %v", ErrKeyGenerationFailed, err)
+	}
+	err = s.leaveRegistry(ctx, "DeleteSubscription", subscriberID, keyID, keys, func() error {
+		return s.registry.DeleteSubscription(ctx, dreq, authHeader)
+	})
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "SubscriberService: DeleteSubscription successful", "subscriber_id", subscriberID, "key_id", keyID)
+	return nil
+}
+
+// Unsubscribe asks the Registry to move the subscription of req to UNSUBSCRIBED, signed with the keyset
+// stored under the subscriber ID, and returns the message ID of the request. The keyset is deleted
+// once the Registry has confirmed the request.
+func (s *subscriberService) Unsubscribe(ctx context.Context, req *model.NpSubscriptionRequest) (string, error) {
+	if err := s.validateSubscriptionRequest(req); err != nil {
+		return "", err
+	}
+	if req.MessageID == "" {
+		req.MessageID = uuid.NewString()
+		slog.InfoContext(ctx, "SubscriberService: Generated new MessageID for Unsubscribe", "message_id", req.MessageID)
+	}
+	keys, err := s.keyMgr.Keyset(ctx, req.SubscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch keyset for unsubscribe", "subscriber_id", req.SubscriberID, "error", err)
+		return "", fmt.Errorf("%w: %v", ErrKeyFetchFailed, err)
+	}
+	sreq := &model.SubscriptionRequest{
+		MessageID: req.MessageID,
+		Subscription: model.Subscription{
+			Subscriber: model.Subscriber{
+				SubscriberID: req.SubscriberID,
+				URL:          req.URL,
+				Domain:       req.Domain,
+				Type:         req.Type,
+			},
+			KeyID:  keys.UniqueKeyID,
+			Status: model.SubscriptionStatusUnsubscribed,
+			Nonce:  uuid.NewString(),
+		},
+	}
+	authHeader, err := s.authHeader(ctx, sreq, req.SubscriberID)
+	if err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to generate auth header", "error", err)
+		return "", fmt.Errorf("%w: %v", ErrKeyGenerationFailed, err)
+	}
+	var resp *model.SubscriptionResponse
+	err = s.leaveRegistry(ctx, "Unsubscribe", req.SubscriberID, keys.UniqueKeyID, keys, func() error {
+		var err error
+		resp, err = s.registry.Unsubscribe(ctx, sreq, authHeader)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	slog.InfoContext(ctx, "SubscriberService: Unsubscribe successful", "message_id", resp.MessageID, "status", resp.Status)
+	return resp.MessageID, nil
+}
+
+// leaveRegistry runs call, the Registry request named action that removes the subscription of
+// subscriberID's keyID, and deletes the subscriber's keyset if it holds keyID. The keyset is only
+// deleted once the Registry has confirmed the request: while the subscription may still be
+// SUBSCRIBED, deleting its keys would leave the subscriber unable to sign.
+func (s *subscriberService) leaveRegistry(ctx context.Context, action, subscriberID, keyID string, keys *becknmodel.Keyset, call func() error) error {
+	if err := call(); err != nil {
+		if errors.Is(err, client.ErrSubscriptionNotFound) {
+			slog.WarnContext(ctx, "SubscriberService: Subscription not found in Registry", "action", action, "subscriber_id", subscriberID, "key_id", keyID)
+			return fmt.Errorf("%w: subscriber_id %s, key_id %s", ErrSubscriptionNotFound, subscriberID, keyID)
+		}
+		slog.ErrorContext(ctx, "SubscriberService: Registry request failed", "action", action, "subscriber_id", subscriberID, "key_id", keyID, "error", err)
+		if errors.Is(err, client.ErrRegistryUnavailable) {
+			return fmt.Errorf("%w: %w: %v", ErrRegistryUnavailable, ErrRegistryOperationFailed, err)
+		}
+		return fmt.Errorf("%w: %v", ErrRegistryOperationFailed, err)
+	}
+	if keys.UniqueKeyID != keyID {
+		return nil
+	}
+	if err := s.keyMgr.DeleteKeyset(ctx, subscriberID); err != nil {
+		slog.ErrorContext(ctx, "SubscriberService: Failed to delete keyset of removed subscription", "action", action, "subscriber_id", subscriberID, "key_id", keyID, "error", err)
+		return fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
+	}
+	return nil
+}
+
 // UpdateStatus checks the status of an LRO.
 func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string) (model.LROStatus, error) {
 	if operationID == "" {
@@ -269,10 +505,16 @@ func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string
 
 	keys, err := s.keyMgr.Keyset(ctx, operationID)
 	if err != nil {
+		// The keyset stored under the operation ID is deleted once the subscription is finalized,
+		// so a redelivered status update for the same operation lands here.
+		if s.alreadyFinalized(ctx, lro) {
+			slog.InfoContext(ctx, "SubscriberService: Subscription already finalized, ignoring duplicate status update", "message_id", operationID)
+			return lro.Status, nil
+		}
 		slog.ErrorContext(ctx, "SubscriberService: Failed to fetch keyset for status update", "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyFetchFailed, err)
 	}
-	if err := s.keyMgr.InsertKeyset(ctx, keys.SubscriberID, keys); err != nil {
+	if err := s.insertKeyset(ctx, keys.SubscriberID, keys); err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to insert keyset after update status", "subscriber_id", keys.SubscriberID, "key_id", keys.UniqueKeyID, "error", err)
 		return "", fmt.Errorf("%w: %v", ErrKeyStoreFailed, err)
 	}
@@ -283,10 +525,51 @@ func (s *subscriberService) UpdateStatus(ctx context.Context, operationID string
 	return lro.Status, nil
 }
 
+// alreadyFinalized reports whether the keyset requested in lro is already stored under its subscriber ID,
+// meaning an earlier delivery of the status update finalized the subscription.
+func (s *subscriberService) alreadyFinalized(ctx context.Context, lro *model.LRO) bool {
+	var req model.SubscriptionRequest
+	if err := json.Unmarshal(lro.RequestJSON, &req); err != nil || req.SubscriberID == "" || req.KeyID == "" {
+		return false
+	}
+	keys, err := s.keyMgr.Keyset(ctx, req.SubscriberID)
+	return err == nil && keys != nil && keys.UniqueKeyID == req.KeyID
+}
+
 // OnSubscribe handles an incoming on_subscribe request from the Registry.
 // It decrypts the challenge, publishes an event, and returns the decrypted answer.
+// If an OnSubscribe timeout is configured, processing is abandoned with ErrOnSubscribeTimeout once it elapses,
+// so that a slow backend does not hold the Registry's synchronous request open.
 func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
 	slog.InfoContext(ctx, "SubscriberService: Received OnSubscribe request", "message_id", req.MessageID)
+	if s.onSubscribeTimeout <= 0 {
+		return s.onSubscribe(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.onSubscribeTimeout)
+	defer cancel()
+
+	type result struct {
+		resp *model.OnSubscribeResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := s.onSubscribe(ctx, req)
+		done <- result{resp: resp, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		slog.ErrorContext(ctx, "SubscriberService: OnSubscribe processing timed out", "message_id", req.MessageID, "timeout", s.onSubscribeTimeout)
+		return nil, fmt.Errorf("%w after %s for message_id %s", ErrOnSubscribeTimeout, s.onSubscribeTimeout, req.MessageID)
+	}
+}
+
+// onSubscribe fetches the NP's keys and the Registry's public key, and decrypts the challenge.
+func (s *subscriberService) onSubscribe(ctx context.Context, req *model.OnSubscribeRequest) (*model.OnSubscribeResponse, error) {
 
 	if req.MessageID == "" {
 		slog.ErrorContext(ctx, "SubscriberService: MessageID is required for OnSubscribe")
@@ -342,13 +625,35 @@ func (s *subscriberService) OnSubscribe(ctx context.Context, req *model.OnSubscr
 	return response, nil
 }
 
-func (s *subscriberService) authHeader(ctx context.Context, req *model.SubscriptionRequest) (string, error) {
+// authHeader signs req with the keyset identified by keyID.
+func (s *subscriberService) authHeader(ctx context.Context, req *model.SubscriptionRequest, keyID string) (string, error) {
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriberService: Failed to marshal request body", "error", err)
 		return "", fmt.Errorf("failed to marshal request body: %w", err)
 	}
-	return s.authGen.AuthHeader(ctx, body, req.SubscriberID)
+	return s.authGen.AuthHeader(ctx, body, keyID)
 
 }
+
+// authKeyID checks the auth scheme and signing key requested for an update and returns the ID of
+// the keyset to sign it with: the subscriber's own for the SUBSCRIBER scheme and the configured
+// gateway's for the GATEWAY scheme. A requested AuthKeyID must be that keyset, so that callers
+// cannot sign with any other keyset this service holds.
+func (s *subscriberService) authKeyID(req *model.NpSubscriptionRequest) (string, error) {
+	if _, err := req.AuthScheme.HeaderName(); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAuthScheme, req.AuthScheme)
+	}
+	keyID := req.SubscriberID
+	if req.AuthScheme == model.AuthSchemeGateway {
+		if s.gatewayID == "" {
+			return "", ErrGatewaySchemeDisabled
+		}
+		keyID = s.gatewayID
+	}
+	if req.AuthKeyID != "" && req.AuthKeyID != keyID {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAuthKeyID, req.AuthKeyID)
+	}
+	return keyID, nil
+}
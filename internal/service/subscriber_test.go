@@ -16,12 +16,18 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/client"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	becknmodel "github.com/beckn-one/beckn-onix/pkg/model"
 )
@@ -30,21 +36,44 @@ import (
 type mockRegistryClient struct {
 	createSubResp *model.SubscriptionResponse
 	createSubErr  error
+	gotCreateReq  *model.SubscriptionRequest
 	updateSubResp *model.SubscriptionResponse
 	updateSubErr  error
+	gotScheme     model.AuthScheme
+	gotAuthHeader string
 	getOpResp     *model.LRO
 	getOpErr      error
+	deleteSubErr  error
+	gotDeleteKey  string
+	gotDeleteReq  *model.DeleteSubscriptionRequest
+	unsubResp     *model.SubscriptionResponse
+	unsubErr      error
+	gotUnsubReq   *model.SubscriptionRequest
 }
 
 func (m *mockRegistryClient) CreateSubscription(ctx context.Context, req *model.SubscriptionRequest) (*model.SubscriptionResponse, error) {
+	m.gotCreateReq = req
 	return m.createSubResp, m.createSubErr
 }
-func (m *mockRegistryClient) UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+func (m *mockRegistryClient) UpdateSubscription(ctx context.Context, req *model.SubscriptionRequest, scheme model.AuthScheme, authHeader string) (*model.SubscriptionResponse, error) {
+	m.gotScheme = scheme
+	m.gotAuthHeader = authHeader
 	return m.updateSubResp, m.updateSubErr
 }
 func (m *mockRegistryClient) GetOperation(ctx context.Context, operationID string) (*model.LRO, error) {
 	return m.getOpResp, m.getOpErr
 }
+func (m *mockRegistryClient) Unsubscribe(ctx context.Context, req *model.SubscriptionRequest, authHeader string) (*model.SubscriptionResponse, error) {
+	m.gotUnsubReq = req
+	m.gotAuthHeader = authHeader
+	return m.unsubResp, m.unsubErr
+}
+func (m *mockRegistryClient) DeleteSubscription(ctx context.Context, req *model.DeleteSubscriptionRequest, authHeader string) error {
+	m.gotDeleteReq = req
+	m.gotDeleteKey = req.KeyID
+	m.gotAuthHeader = authHeader
+	return m.deleteSubErr
+}
 
 // mockOnSubscribeEventPublisher is a mock for onSubscribeEventPublisher.
 type mockOnSubscribeEventPublisher struct {
@@ -60,8 +89,12 @@ func (m *mockOnSubscribeEventPublisher) PublishOnSubscribeRecievedEvent(ctx cont
 type mockKeyManager struct {
 	keysetToReturn      *becknmodel.Keyset
 	keysetErr           error
+	keysets             map[string]*becknmodel.Keyset // If set, Keyset looks up keysets by key ID.
+	insertCalls         int
+	deleteCalls         int
 	generateKeysetErr   error
 	insertKeysetErr     error
+	insertKeysetErrs    []error // If set, returned by successive InsertKeyset calls before insertKeysetErr.
 	deleteKeysetErr     error
 	lookupNPKeysSigning string
 	lookupNPKeysEncr    string
@@ -69,6 +102,13 @@ type mockKeyManager struct {
 }
 
 func (m *mockKeyManager) Keyset(ctx context.Context, keyID string) (*becknmodel.Keyset, error) {
+	if m.keysets != nil {
+		keys, ok := m.keysets[keyID]
+		if !ok {
+			return nil, fmt.Errorf("keys for subscriberID: %s not found", keyID)
+		}
+		return keys, nil
+	}
 	return m.keysetToReturn, m.keysetErr
 }
 func (m *mockKeyManager) GenerateKeyset() (*becknmodel.Keyset, error) {
@@ -78,15 +118,38 @@ func (m *mockKeyManager) GenerateKeyset() (*becknmodel.Keyset, error) {
 	return &becknmodel.Keyset{UniqueKeyID: "generated-key", SigningPublic: "gen-sign-pub", EncrPublic: "gen-encr-pub", EncrPrivate: "gen-encr-priv"}, nil
 }
 func (m *mockKeyManager) InsertKeyset(ctx context.Context, keyID string, keyset *becknmodel.Keyset) error {
-	return m.insertKeysetErr
+	m.insertCalls++
+	err := m.insertKeysetErr
+	if len(m.insertKeysetErrs) > 0 {
+		err, m.insertKeysetErrs = m.insertKeysetErrs[0], m.insertKeysetErrs[1:]
+	}
+	if m.keysets != nil && err == nil {
+		m.keysets[keyID] = keyset
+	}
+	return err
 }
 func (m *mockKeyManager) DeleteKeyset(ctx context.Context, keyID string) error {
+	m.deleteCalls++
+	if m.keysets != nil && m.deleteKeysetErr == nil {
+		delete(m.keysets, keyID)
+	}
 	return m.deleteKeysetErr
 }
 func (m *mockKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (signingPublicKey string, encrPublicKey string, err error) {
 	return m.lookupNPKeysSigning, m.lookupNPKeysEncr, m.lookupNPKeysErr
 }
 
+// slowKeyManager is a mockKeyManager whose Keyset call blocks until released.
+type slowKeyManager struct {
+	mockKeyManager
+	release chan struct{}
+}
+
+func (m *slowKeyManager) Keyset(ctx context.Context, keyID string) (*becknmodel.Keyset, error) {
+	<-m.release
+	return m.mockKeyManager.Keyset(ctx, keyID)
+}
+
 // mockDecrypter is a mock for decrypter.
 type mockDecrypter struct {
 	decryptedData string
@@ -99,14 +162,24 @@ func (m *mockDecrypter) Decrypt(ctx context.Context, data string, privateKeyBase
 
 // mockAuthGen is a mock for authGen.
 type mockAuthGen struct {
-	authHeader string
-	err        error
+	authHeader   string
+	err          error
+	gotBody      []byte
+	gotKeyID     string
+	gotRequestID string
 }
 
 func (m *mockAuthGen) AuthHeader(ctx context.Context, body []byte, keyID string) (string, error) {
+	m.gotBody = body
+	m.gotKeyID = keyID
 	return m.authHeader, m.err
 }
 
+func (m *mockAuthGen) RequestAuthHeader(ctx context.Context, body []byte, keyID, requestID string) (string, error) {
+	m.gotRequestID = requestID
+	return m.AuthHeader(ctx, body, keyID)
+}
+
 func TestNewSubscriberService_Success(t *testing.T) {
 	_, err := NewSubscriberService(
 		&mockRegistryClient{},
@@ -114,7 +187,7 @@ func TestNewSubscriberService_Success(t *testing.T) {
 		&mockDecrypter{},
 		&mockOnSubscribeEventPublisher{},
 		&mockAuthGen{},
-		"reg-id", "reg-key-id",
+		"reg-id", "reg-key-id", 0, 0,
 	)
 	if err != nil {
 		t.Fatalf("NewSubscriberService() unexpected error: %v", err)
@@ -144,7 +217,7 @@ func TestNewSubscriberService_Error(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := NewSubscriberService(tt.registry, tt.keyMgr, tt.dec, tt.evPub, tt.authGen, tt.regID, tt.regKeyID)
+			_, err := NewSubscriberService(tt.registry, tt.keyMgr, tt.dec, tt.evPub, tt.authGen, tt.regID, tt.regKeyID, 0, 0)
 			if err == nil || err.Error() != tt.wantErrMsg {
 				t.Errorf("NewSubscriberService() error = %v, want %q", err, tt.wantErrMsg)
 			}
@@ -161,7 +234,7 @@ func TestSubscriberService_CreateSubscription_Success(t *testing.T) {
 	}
 	mockReg := &mockRegistryClient{createSubResp: &model.SubscriptionResponse{MessageID: "some-msg-id", Status: "ACK"}}
 	mockKM := &mockKeyManager{} // Will generate new keyset
-	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
 
 	msgID, err := svc.CreateSubscription(ctx, req)
 	if err != nil {
@@ -181,6 +254,72 @@ func TestSubscriberService_CreateSubscription_Success(t *testing.T) {
 	}
 }
 
+func TestNewSubscriberService_NegativeValidity(t *testing.T) {
+	_, err := NewSubscriberService(&mockRegistryClient{}, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, -time.Hour)
+	if err == nil || err.Error() != "validity cannot be negative" {
+		t.Errorf("NewSubscriberService() error = %v, want %q", err, "validity cannot be negative")
+	}
+}
+
+func TestSubscriberService_CreateSubscription_Validity(t *testing.T) {
+	explicit := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name       string
+		validity   time.Duration
+		validUntil time.Time
+		want       time.Duration // Expected validity from now, if validUntil is not set.
+	}{
+		{name: "default", want: DefaultSubscriptionValidity},
+		{name: "configured", validity: 24 * time.Hour, want: 24 * time.Hour},
+		{name: "explicit valid_until", validity: 24 * time.Hour, validUntil: explicit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReg := &mockRegistryClient{createSubResp: &model.SubscriptionResponse{MessageID: "msg-id"}}
+			svc, err := NewSubscriberService(mockReg, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, tt.validity)
+			if err != nil {
+				t.Fatalf("NewSubscriberService() error = %v", err)
+			}
+			req := &model.NpSubscriptionRequest{
+				Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+				ValidUntil: tt.validUntil,
+			}
+
+			if _, err := svc.CreateSubscription(context.Background(), req); err != nil {
+				t.Fatalf("CreateSubscription() error = %v", err)
+			}
+			got := mockReg.gotCreateReq.ValidUntil
+			if !tt.validUntil.IsZero() {
+				if !got.Equal(tt.validUntil) {
+					t.Errorf("ValidUntil = %v, want %v", got, tt.validUntil)
+				}
+				return
+			}
+			if d := got.Sub(mockReg.gotCreateReq.ValidFrom); d != tt.want {
+				t.Errorf("ValidUntil - ValidFrom = %s, want %s", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_CreateSubscription_PastValidUntil(t *testing.T) {
+	mockReg := &mockRegistryClient{createSubResp: &model.SubscriptionResponse{MessageID: "msg-id"}}
+	svc, _ := NewSubscriberService(mockReg, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+	req := &model.NpSubscriptionRequest{
+		Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+		ValidUntil: time.Now().Add(-time.Hour),
+	}
+
+	_, err := svc.CreateSubscription(context.Background(), req)
+	if !errors.Is(err, ErrInvalidValidity) {
+		t.Errorf("CreateSubscription() error = %v, want %v", err, ErrInvalidValidity)
+	}
+	if mockReg.gotCreateReq != nil {
+		t.Error("CreateSubscription() sent the request to the registry, want it rejected")
+	}
+}
+
 func TestSubscriberService_CreateSubscription_Error(t *testing.T) {
 	ctx := context.Background()
 	baseReq := &model.NpSubscriptionRequest{
@@ -247,7 +386,7 @@ func TestSubscriberService_CreateSubscription_Error(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc, _ := NewSubscriberService(
-				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id",
+				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0,
 			)
 			if svc.registry == nil { // Default to a working mock if not provided
 				svc.registry = &mockRegistryClient{}
@@ -267,6 +406,64 @@ func TestSubscriberService_CreateSubscription_Error(t *testing.T) {
 	}
 }
 
+func TestSubscriberService_RegistryFailureDiscardsKeyset(t *testing.T) {
+	unavailable := fmt.Errorf("%w: HTTP request to Registry POST /subscribe failed: connection refused", client.ErrRegistryUnavailable)
+	rejected := fmt.Errorf("%w: registry POST /subscribe failed with status 400: bad request", client.ErrRegistryRejected)
+	tests := []struct {
+		name            string
+		regErr          error
+		deleteErr       error
+		wantUnavailable bool
+		wantDiscard     bool
+	}{
+		{name: "registry unreachable", regErr: unavailable, wantUnavailable: true},
+		{name: "registry fails without rejecting", regErr: errors.New("registry POST /subscribe failed with status 501: not implemented")},
+		{name: "registry rejects request", regErr: rejected, wantDiscard: true},
+		{name: "keyset delete fails", regErr: rejected, deleteErr: errors.New("delete failed"), wantDiscard: true},
+	}
+
+	for _, tt := range tests {
+		for _, op := range []string{"Create", "Update"} {
+			t.Run(tt.name+"/"+op, func(t *testing.T) {
+				km := &mockKeyManager{keysets: map[string]*becknmodel.Keyset{}, deleteKeysetErr: tt.deleteErr}
+				reg := &mockRegistryClient{createSubErr: tt.regErr, updateSubErr: tt.regErr}
+				svc, err := NewSubscriberService(reg, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+				if err != nil {
+					t.Fatalf("NewSubscriberService() error = %v", err)
+				}
+				req := &model.NpSubscriptionRequest{
+					Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+					MessageID:  "msg1",
+				}
+
+				call := svc.CreateSubscription
+				if op == "Update" {
+					call = svc.UpdateSubscription
+				}
+				_, err = call(context.Background(), req)
+
+				if !errors.Is(err, ErrRegistryOperationFailed) {
+					t.Errorf("%sSubscription() error = %v, want %v", op, err, ErrRegistryOperationFailed)
+				}
+				if got := errors.Is(err, ErrRegistryUnavailable); got != tt.wantUnavailable {
+					t.Errorf("errors.Is(%v, ErrRegistryUnavailable) = %t, want %t", err, got, tt.wantUnavailable)
+				}
+				wantDeleteCalls := 0
+				if tt.wantDiscard {
+					wantDeleteCalls = 1
+				}
+				if km.deleteCalls != wantDeleteCalls {
+					t.Errorf("DeleteKeyset called %d times, want %d", km.deleteCalls, wantDeleteCalls)
+				}
+				wantStored := !tt.wantDiscard || tt.deleteErr != nil
+				if _, ok := km.keysets["msg1"]; ok != wantStored {
+					t.Errorf("keyset msg1 stored = %t after registry failure, want %t", ok, wantStored)
+				}
+			})
+		}
+	}
+}
+
 func TestSubscriberService_UpdateSubscription_Success(t *testing.T) {
 	ctx := context.Background()
 	req := &model.NpSubscriptionRequest{
@@ -277,7 +474,7 @@ func TestSubscriberService_UpdateSubscription_Success(t *testing.T) {
 	mockReg := &mockRegistryClient{updateSubResp: &model.SubscriptionResponse{MessageID: "some-msg-id", Status: "ACK"}}
 	mockKM := &mockKeyManager{}
 	mockAuth := &mockAuthGen{authHeader: "test-auth-header"}
-	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, mockAuth, "reg-id", "reg-key-id")
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, mockAuth, "reg-id", "reg-key-id", 0, 0)
 
 	msgID, err := svc.UpdateSubscription(ctx, req)
 	if err != nil {
@@ -288,6 +485,68 @@ func TestSubscriberService_UpdateSubscription_Success(t *testing.T) {
 	}
 }
 
+func TestSubscriberService_UpdateSubscription_AuthScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		scheme     model.AuthScheme
+		authKeyID  string
+		wantScheme model.AuthScheme
+		wantKeyID  string
+	}{
+		{
+			name:      "default scheme signs with subscriber key",
+			wantKeyID: "sub1",
+		},
+		{
+			name:       "subscriber scheme naming its own key",
+			scheme:     model.AuthSchemeSubscriber,
+			authKeyID:  "sub1",
+			wantScheme: model.AuthSchemeSubscriber,
+			wantKeyID:  "sub1",
+		},
+		{
+			name:       "gateway scheme signs with the gateway key",
+			scheme:     model.AuthSchemeGateway,
+			wantScheme: model.AuthSchemeGateway,
+			wantKeyID:  "gateway.example.com",
+		},
+		{
+			name:       "gateway scheme naming the gateway key",
+			scheme:     model.AuthSchemeGateway,
+			authKeyID:  "gateway.example.com",
+			wantScheme: model.AuthSchemeGateway,
+			wantKeyID:  "gateway.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.NpSubscriptionRequest{
+				Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+				AuthScheme: tt.scheme,
+				AuthKeyID:  tt.authKeyID,
+			}
+			mockReg := &mockRegistryClient{updateSubResp: &model.SubscriptionResponse{MessageID: "msg1"}}
+			mockAuth := &mockAuthGen{authHeader: "signed-header"}
+			svc, _ := NewSubscriberService(mockReg, &mockKeyManager{}, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, mockAuth, "reg-id", "reg-key-id", 0, 0)
+			svc.SetGatewaySubscriberID("gateway.example.com")
+
+			if _, err := svc.UpdateSubscription(context.Background(), req); err != nil {
+				t.Fatalf("UpdateSubscription() unexpected error: %v", err)
+			}
+			if mockAuth.gotKeyID != tt.wantKeyID {
+				t.Errorf("AuthHeader() keyID = %q, want %q", mockAuth.gotKeyID, tt.wantKeyID)
+			}
+			if mockReg.gotScheme != tt.wantScheme {
+				t.Errorf("registry UpdateSubscription() scheme = %q, want %q", mockReg.gotScheme, tt.wantScheme)
+			}
+			if mockReg.gotAuthHeader != "signed-header" {
+				t.Errorf("registry UpdateSubscription() authHeader = %q, want %q", mockReg.gotAuthHeader, "signed-header")
+			}
+		})
+	}
+}
+
 func TestSubscriberService_UpdateSubscription_Error(t *testing.T) {
 	ctx := context.Background()
 	baseReq := &model.NpSubscriptionRequest{
@@ -335,12 +594,37 @@ func TestSubscriberService_UpdateSubscription_Error(t *testing.T) {
 			mockAuth: &mockAuthGen{},
 			wantErr:  fmt.Errorf("%w: %v", ErrRegistryOperationFailed, errors.New("registry update failed")),
 		},
+		{
+			name: "invalid auth scheme",
+			req: &model.NpSubscriptionRequest{
+				Subscriber: baseReq.Subscriber,
+				AuthScheme: "BEARER",
+			},
+			wantErr: ErrInvalidAuthScheme,
+		},
+		{
+			name: "gateway auth scheme without a gateway",
+			req: &model.NpSubscriptionRequest{
+				Subscriber: baseReq.Subscriber,
+				AuthScheme: model.AuthSchemeGateway,
+			},
+			wantErr: ErrGatewaySchemeDisabled,
+		},
+		{
+			name: "subscriber auth scheme with another keyset",
+			req: &model.NpSubscriptionRequest{
+				Subscriber: baseReq.Subscriber,
+				AuthScheme: model.AuthSchemeSubscriber,
+				AuthKeyID:  "other-participant",
+			},
+			wantErr: ErrInvalidAuthKeyID,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc, _ := NewSubscriberService(
-				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, tt.mockAuth, "reg-id", "reg-key-id",
+				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, tt.mockAuth, "reg-id", "reg-key-id", 0, 0,
 			)
 			if svc.registry == nil {
 				svc.registry = &mockRegistryClient{}
@@ -363,12 +647,158 @@ func TestSubscriberService_UpdateSubscription_Error(t *testing.T) {
 	}
 }
 
+func TestSubscriberService_DeleteSubscription(t *testing.T) {
+	notFound := fmt.Errorf("%w: registry DELETE failed with status 404", client.ErrSubscriptionNotFound)
+	unavailable := fmt.Errorf("%w: connection refused", client.ErrRegistryUnavailable)
+
+	tests := []struct {
+		name          string
+		subscriberID  string
+		keyID         string
+		keysetErr     error
+		authErr       error
+		deleteErr     error
+		wantErr       error
+		wantDeleteKey string
+		wantDeleted   bool // Whether the local keyset is deleted.
+	}{
+		{name: "deletes subscription and keyset", subscriberID: "sub1", keyID: "key-1", wantDeleteKey: "key-1", wantDeleted: true},
+		{name: "defaults to the key of the keyset", subscriberID: "sub1", wantDeleteKey: "key-1", wantDeleted: true},
+		{name: "keeps the keyset of another key", subscriberID: "sub1", keyID: "old-key", wantDeleteKey: "old-key"},
+		{name: "missing subscriber ID", wantErr: ErrMissingSubscriberID},
+		{name: "keyset fetch fails", subscriberID: "sub1", keysetErr: errors.New("secret not found"), wantErr: ErrKeyFetchFailed},
+		{name: "auth header fails", subscriberID: "sub1", authErr: errors.New("sign failed"), wantErr: ErrKeyGenerationFailed},
+		{name: "registry fails", subscriberID: "sub1", deleteErr: errors.New("bad request"), wantErr: ErrRegistryOperationFailed, wantDeleteKey: "key-1"},
+		{name: "registry unavailable", subscriberID: "sub1", deleteErr: unavailable, wantErr: ErrRegistryUnavailable, wantDeleteKey: "key-1"},
+		{name: "subscription not found keeps keyset", subscriberID: "sub1", deleteErr: notFound, wantErr: ErrSubscriptionNotFound, wantDeleteKey: "key-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReg := &mockRegistryClient{deleteSubErr: tt.deleteErr}
+			mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"}, keysetErr: tt.keysetErr}
+			authGen := &mockAuthGen{authHeader: "signed", err: tt.authErr}
+			svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, authGen, "reg-id", "reg-key-id", 0, 0)
+
+			err := svc.DeleteSubscription(context.Background(), tt.subscriberID, tt.keyID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if mockReg.gotDeleteKey != tt.wantDeleteKey {
+				t.Errorf("registry DeleteSubscription() key ID = %q, want %q", mockReg.gotDeleteKey, tt.wantDeleteKey)
+			}
+			if tt.wantDeleteKey != "" && (mockReg.gotAuthHeader != "signed" || authGen.gotKeyID != "sub1") {
+				t.Errorf("registry DeleteSubscription() auth header = %q signed with %q, want %q signed with %q", mockReg.gotAuthHeader, authGen.gotKeyID, "signed", "sub1")
+			}
+			if tt.wantDeleteKey != "" {
+				req := mockReg.gotDeleteReq
+				if req.Method != http.MethodDelete || req.SubscriberID != "sub1" || req.Nonce == "" {
+					t.Errorf("registry DeleteSubscription() request = %+v, want a DELETE of sub1 with a nonce", req)
+				}
+				if want, _ := json.Marshal(req); string(authGen.gotBody) != string(want) {
+					t.Errorf("signed body = %s, want the request %s", authGen.gotBody, want)
+				}
+			}
+			if got := mockKM.deleteCalls == 1; got != tt.wantDeleted {
+				t.Errorf("keyset deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_DeleteSubscription_KeysetDeleteFails(t *testing.T) {
+	mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"}, deleteKeysetErr: errors.New("permission denied")}
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+
+	if err := svc.DeleteSubscription(context.Background(), "sub1", ""); !errors.Is(err, ErrKeyStoreFailed) {
+		t.Errorf("DeleteSubscription() error = %v, want %v", err, ErrKeyStoreFailed)
+	}
+}
+
+func TestSubscriberService_Unsubscribe(t *testing.T) {
+	unavailable := fmt.Errorf("%w: connection refused", client.ErrRegistryUnavailable)
+	validReq := model.NpSubscriptionRequest{
+		Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP},
+		MessageID:  "msg-1",
+	}
+
+	tests := []struct {
+		name        string
+		req         model.NpSubscriptionRequest
+		keysetErr   error
+		authErr     error
+		unsubErr    error
+		deleteErr   error
+		wantErr     error
+		wantSent    bool // Whether the request is sent to the registry.
+		wantDeleted bool // Whether the local keyset is deleted.
+	}{
+		{name: "unsubscribes and deletes keyset", req: validReq, wantSent: true, wantDeleted: true},
+		{name: "missing subscriber ID", req: model.NpSubscriptionRequest{Subscriber: model.Subscriber{Domain: "test.com", Type: model.RoleBAP}}, wantErr: ErrMissingSubscriberID},
+		{name: "missing domain", req: model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Type: model.RoleBAP}}, wantErr: ErrMissingDomain},
+		{name: "missing type", req: model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com"}}, wantErr: ErrMissingType},
+		{name: "keyset fetch fails", req: validReq, keysetErr: errors.New("secret not found"), wantErr: ErrKeyFetchFailed},
+		{name: "auth header fails", req: validReq, authErr: errors.New("sign failed"), wantErr: ErrKeyGenerationFailed},
+		{name: "registry fails keeps keyset", req: validReq, unsubErr: errors.New("bad request"), wantErr: ErrRegistryOperationFailed, wantSent: true},
+		{name: "registry unavailable keeps keyset", req: validReq, unsubErr: unavailable, wantErr: ErrRegistryUnavailable, wantSent: true},
+		{name: "subscription not found keeps keyset", req: validReq, unsubErr: fmt.Errorf("%w: status 404", client.ErrSubscriptionNotFound), wantErr: ErrSubscriptionNotFound, wantSent: true},
+		{name: "keyset delete fails", req: validReq, deleteErr: errors.New("permission denied"), wantErr: ErrKeyStoreFailed, wantSent: true, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReg := &mockRegistryClient{unsubResp: &model.SubscriptionResponse{MessageID: "msg-1", Status: "ACK"}, unsubErr: tt.unsubErr}
+			mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"}, keysetErr: tt.keysetErr, deleteKeysetErr: tt.deleteErr}
+			authGen := &mockAuthGen{authHeader: "signed", err: tt.authErr}
+			svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, authGen, "reg-id", "reg-key-id", 0, 0)
+
+			req := tt.req
+			msgID, err := svc.Unsubscribe(context.Background(), &req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unsubscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && msgID != "msg-1" {
+				t.Errorf("Unsubscribe() = %q, want %q", msgID, "msg-1")
+			}
+			if got := mockReg.gotUnsubReq != nil; got != tt.wantSent {
+				t.Fatalf("request sent to registry = %v, want %v", got, tt.wantSent)
+			}
+			if tt.wantSent {
+				got := mockReg.gotUnsubReq
+				if got.KeyID != "key-1" || got.Status != model.SubscriptionStatusUnsubscribed || got.MessageID != "msg-1" {
+					t.Errorf("registry Unsubscribe() request = key %q, status %q, message %q, want key-1, %s, msg-1", got.KeyID, got.Status, got.MessageID, model.SubscriptionStatusUnsubscribed)
+				}
+				if mockReg.gotAuthHeader != "signed" || authGen.gotKeyID != "sub1" {
+					t.Errorf("registry Unsubscribe() auth header = %q signed with %q, want %q signed with %q", mockReg.gotAuthHeader, authGen.gotKeyID, "signed", "sub1")
+				}
+			}
+			if got := mockKM.deleteCalls == 1; got != tt.wantDeleted {
+				t.Errorf("keyset deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestSubscriberService_Unsubscribe_GeneratesMessageID(t *testing.T) {
+	mockReg := &mockRegistryClient{unsubResp: &model.SubscriptionResponse{MessageID: "msg-1"}}
+	mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1", UniqueKeyID: "key-1"}}
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+	req := &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP}}
+
+	if _, err := svc.Unsubscribe(context.Background(), req); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if req.MessageID == "" || mockReg.gotUnsubReq.MessageID != req.MessageID {
+		t.Errorf("registry Unsubscribe() message ID = %q, want generated %q", mockReg.gotUnsubReq.MessageID, req.MessageID)
+	}
+}
+
 func TestSubscriberService_UpdateStatus_Success(t *testing.T) {
 	ctx := context.Background()
 	opID := "op1"
 	mockReg := &mockRegistryClient{getOpResp: &model.LRO{Status: model.LROStatusApproved}}
 	mockKM := &mockKeyManager{keysetToReturn: &becknmodel.Keyset{SubscriberID: "sub1"}}
-	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id")
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
 
 	status, err := svc.UpdateStatus(ctx, opID)
 	if err != nil {
@@ -379,6 +809,70 @@ func TestSubscriberService_UpdateStatus_Success(t *testing.T) {
 	}
 }
 
+func TestSubscriberService_UpdateStatus_Duplicate(t *testing.T) {
+	ctx := context.Background()
+	opID := "op1"
+	reqJSON, err := json.Marshal(&model.SubscriptionRequest{
+		MessageID:    opID,
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub1"}, KeyID: "key-2"},
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	mockReg := &mockRegistryClient{getOpResp: &model.LRO{OperationID: opID, Status: model.LROStatusApproved, RequestJSON: reqJSON}}
+	mockKM := &mockKeyManager{keysets: map[string]*becknmodel.Keyset{
+		"sub1": {SubscriberID: "sub1", UniqueKeyID: "key-1"},
+		opID:   {SubscriberID: "sub1", UniqueKeyID: "key-2"},
+	}}
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+
+	// First delivery finalizes the subscription.
+	status, err := svc.UpdateStatus(ctx, opID)
+	if err != nil {
+		t.Fatalf("UpdateStatus() first delivery unexpected error: %v", err)
+	}
+	if status != model.LROStatusApproved {
+		t.Errorf("UpdateStatus() first delivery got status %q, want %q", status, model.LROStatusApproved)
+	}
+	if got := mockKM.keysets["sub1"].UniqueKeyID; got != "key-2" {
+		t.Errorf("subscriber keyset UniqueKeyID = %q, want %q", got, "key-2")
+	}
+	if mockKM.insertCalls != 1 || mockKM.deleteCalls != 1 {
+		t.Fatalf("first delivery got %d inserts and %d deletes, want 1 and 1", mockKM.insertCalls, mockKM.deleteCalls)
+	}
+
+	// A redelivery of the same event succeeds without touching the keysets.
+	status, err = svc.UpdateStatus(ctx, opID)
+	if err != nil {
+		t.Fatalf("UpdateStatus() duplicate delivery unexpected error: %v", err)
+	}
+	if status != model.LROStatusApproved {
+		t.Errorf("UpdateStatus() duplicate delivery got status %q, want %q", status, model.LROStatusApproved)
+	}
+	if mockKM.insertCalls != 1 || mockKM.deleteCalls != 1 {
+		t.Errorf("duplicate delivery got %d inserts and %d deletes, want no new calls", mockKM.insertCalls, mockKM.deleteCalls)
+	}
+}
+
+func TestSubscriberService_UpdateStatus_MissingKeysetNotFinalized(t *testing.T) {
+	reqJSON, err := json.Marshal(&model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub1"}, KeyID: "key-2"},
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	mockReg := &mockRegistryClient{getOpResp: &model.LRO{Status: model.LROStatusApproved, RequestJSON: reqJSON}}
+	// The subscriber still holds its previous key, so the operation keyset is genuinely missing.
+	mockKM := &mockKeyManager{keysets: map[string]*becknmodel.Keyset{
+		"sub1": {SubscriberID: "sub1", UniqueKeyID: "key-1"},
+	}}
+	svc, _ := NewSubscriberService(mockReg, mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+
+	if _, err := svc.UpdateStatus(context.Background(), "op1"); !errors.Is(err, ErrKeyFetchFailed) {
+		t.Errorf("UpdateStatus() error = %v, want %v", err, ErrKeyFetchFailed)
+	}
+}
+
 func TestSubscriberService_UpdateStatus_Error(t *testing.T) {
 	ctx := context.Background()
 	opID := "op1"
@@ -439,7 +933,7 @@ func TestSubscriberService_UpdateStatus_Error(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc, _ := NewSubscriberService(
-				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id",
+				tt.mockReg, tt.mockKM, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0,
 			)
 			if svc.registry == nil {
 				svc.registry = &mockRegistryClient{}
@@ -474,7 +968,7 @@ func TestSubscriberService_OnSubscribe_Success(t *testing.T) {
 	}
 	mockDec := &mockDecrypter{decryptedData: "decrypted-answer"}
 	mockEvPub := &mockOnSubscribeEventPublisher{eventID: "event1"}
-	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, mockDec, mockEvPub, &mockAuthGen{}, "reg-id", "reg-key-id")
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, mockDec, mockEvPub, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
 
 	resp, err := svc.OnSubscribe(ctx, req)
 	if err != nil {
@@ -551,7 +1045,7 @@ func TestSubscriberService_OnSubscribe_Error(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc, _ := NewSubscriberService(
-				&mockRegistryClient{}, tt.mockKM, tt.mockDec, tt.mockEvPub, &mockAuthGen{}, "reg-id", "reg-key-id",
+				&mockRegistryClient{}, tt.mockKM, tt.mockDec, tt.mockEvPub, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0,
 			)
 			if svc.keyMgr == nil {
 				svc.keyMgr = &mockKeyManager{}
@@ -579,3 +1073,108 @@ func TestSubscriberService_OnSubscribe_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriberService_OnSubscribe_Timeout(t *testing.T) {
+	ctx := context.Background()
+	req := &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"}
+	mockKM := &slowKeyManager{
+		mockKeyManager: mockKeyManager{
+			keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key"},
+			lookupNPKeysEncr: "reg-public-key",
+		},
+		release: make(chan struct{}),
+	}
+	defer close(mockKM.release)
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, &mockDecrypter{decryptedData: "answer"}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 50*time.Millisecond, 0)
+
+	start := time.Now()
+	resp, err := svc.OnSubscribe(ctx, req)
+	if !errors.Is(err, ErrOnSubscribeTimeout) {
+		t.Fatalf("OnSubscribe() error = %v, want %v", err, ErrOnSubscribeTimeout)
+	}
+	if resp != nil {
+		t.Errorf("OnSubscribe() resp = %v, want nil", resp)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("OnSubscribe() took %v, want it to return shortly after the timeout", elapsed)
+	}
+}
+
+func TestSubscriberService_OnSubscribe_WithinTimeout(t *testing.T) {
+	ctx := context.Background()
+	req := &model.OnSubscribeRequest{MessageID: "msg1", Challenge: "encrypted-challenge"}
+	mockKM := &mockKeyManager{
+		keysetToReturn:   &becknmodel.Keyset{EncrPrivate: "np-private-key"},
+		lookupNPKeysEncr: "reg-public-key",
+	}
+	svc, _ := NewSubscriberService(&mockRegistryClient{}, mockKM, &mockDecrypter{decryptedData: "answer"}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", time.Second, 0)
+
+	resp, err := svc.OnSubscribe(ctx, req)
+	if err != nil {
+		t.Fatalf("OnSubscribe() unexpected error: %v", err)
+	}
+	if resp.Answer != "answer" {
+		t.Errorf("OnSubscribe() got answer %q, want %q", resp.Answer, "answer")
+	}
+}
+
+func TestCreateSubscription_KeyStoreRetry(t *testing.T) {
+	throttled := fmt.Errorf("failed to add secret version: %w", status.Error(codes.ResourceExhausted, "quota exceeded"))
+	req := &model.NpSubscriptionRequest{Subscriber: model.Subscriber{SubscriberID: "sub1", Domain: "test.com", Type: model.RoleBAP}}
+
+	tests := []struct {
+		name          string
+		retryMax      int
+		insertErrs    []error
+		insertErr     error
+		wantErr       error
+		wantInsertCnt int
+	}{
+		{
+			name:          "transient failure then success",
+			retryMax:      2,
+			insertErrs:    []error{throttled},
+			wantInsertCnt: 2,
+		},
+		{
+			name:          "permanent failure is not retried",
+			retryMax:      2,
+			insertErr:     status.Error(codes.PermissionDenied, "denied"),
+			wantErr:       ErrKeyStoreFailed,
+			wantInsertCnt: 1,
+		},
+		{
+			name:          "transient failures exhaust retries",
+			retryMax:      2,
+			insertErr:     throttled,
+			wantErr:       ErrKeyStoreFailed,
+			wantInsertCnt: 3,
+		},
+		{
+			name:          "retries disabled by default",
+			insertErrs:    []error{throttled},
+			wantErr:       ErrKeyStoreFailed,
+			wantInsertCnt: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := &mockKeyManager{insertKeysetErrs: tt.insertErrs, insertKeysetErr: tt.insertErr}
+			reg := &mockRegistryClient{createSubResp: &model.SubscriptionResponse{MessageID: "msg-1"}}
+			svc, err := NewSubscriberService(reg, km, &mockDecrypter{}, &mockOnSubscribeEventPublisher{}, &mockAuthGen{}, "reg-id", "reg-key-id", 0, 0)
+			if err != nil {
+				t.Fatalf("NewSubscriberService() error = %v", err)
+			}
+			svc.SetKeyStoreRetry(KeyStoreRetryConfig{RetryMax: tt.retryMax, RetryWaitMin: time.Millisecond, RetryWaitMax: 2 * time.Millisecond})
+
+			_, err = svc.CreateSubscription(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if km.insertCalls != tt.wantInsertCnt {
+				t.Errorf("InsertKeyset called %d times, want %d", km.insertCalls, tt.wantInsertCnt)
+			}
+		})
+	}
+}
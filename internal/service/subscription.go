@@ -20,19 +20,24 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 )
 
 // lroCreator defines the interface for creating LROs.
 type lroCreator interface {
 	Create(ctx context.Context, lro *model.LRO) (*model.LRO, error)
+	CreateWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error)
 }
 
 // subscriptionRepository defines the interface for fetching subscriber data.
 type subscriptionRepository interface {
 	GetSubscriberSigningKey(ctx context.Context, subscriberID string, domain string, subType model.Role, keyID string) (string, error)
 	Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriberID, keyID string) error
+	UnsubscribeSubscription(ctx context.Context, subscriberID, domain string, role model.Role, keyID string) error
 }
 
 // subscriptionEventPublisher defines the interface for publishing subscription events.
@@ -42,14 +47,41 @@ type subscriptionEventPublisher interface {
 	PublishUpdateSubscriptionRequestEvent(ctx context.Context, req *model.SubscriptionRequest) (string, error)
 }
 
+// ErrTooManyPendingOperations is returned when a subscriber already has the maximum allowed number of pending operations.
+var ErrTooManyPendingOperations = errors.New("too many pending operations for subscriber")
+
+// ErrInvalidLocation is returned when the location of a subscription request has invalid coordinates.
+var ErrInvalidLocation = errors.New("invalid location")
+
+// ErrRequestTooLarge is returned when a subscription request is larger than the configured maximum stored with its operation.
+var ErrRequestTooLarge = errors.New("subscription request too large")
+
+// SubscriptionConfig holds the configuration for the subscription service.
+type SubscriptionConfig struct {
+	// MaxPendingOperations is the maximum number of concurrent PENDING operations allowed per subscriber.
+	// A value of 0 or less disables the limit.
+	MaxPendingOperations int `yaml:"maxPendingOperations"`
+	// MaxRequestBytes is the maximum size of the JSON of a request stored with its operation.
+	// A value of 0 or less disables the limit.
+	MaxRequestBytes int `yaml:"maxRequestBytes"`
+	// MaxValidFromSkew is how far in the future the ValidFrom of a request may be. A later
+	// ValidFrom is set to the time of the request. A value of 0 or less disables the check.
+	MaxValidFromSkew time.Duration `yaml:"maxValidFromSkew"`
+	// ValidateGps rejects a request whose location has a gps or circle that is not a valid
+	// "lat,lon" coordinate, instead of storing it to fail geo lookups later.
+	ValidateGps bool `yaml:"validateGps"`
+}
+
 type subscriptionService struct {
 	lroCreator             lroCreator
 	subscriptionRepository subscriptionRepository
 	evPublisher            subscriptionEventPublisher
+	cfg                    *SubscriptionConfig
+	now                    func() time.Time
 }
 
 // NewSubscriptionService creates a new subscriptionService.
-func NewSubscriptionService(lroCreator lroCreator, subscriptionRepository subscriptionRepository, evPub subscriptionEventPublisher) (*subscriptionService, error) {
+func NewSubscriptionService(lroCreator lroCreator, subscriptionRepository subscriptionRepository, evPub subscriptionEventPublisher, cfg *SubscriptionConfig) (*subscriptionService, error) {
 	if lroCreator == nil {
 		slog.Error("NewSubscriptionService: lroCreator cannot be nil")
 		return nil, errors.New("lroCreator cannot be nil")
@@ -62,7 +94,11 @@ func NewSubscriptionService(lroCreator lroCreator, subscriptionRepository subscr
 		slog.Error("NewSubscriptionService: eventPublisher cannot be nil")
 		return nil, errors.New("eventPublisher cannot be nil")
 	}
-	return &subscriptionService{lroCreator: lroCreator, subscriptionRepository: subscriptionRepository, evPublisher: evPub}, nil
+	if cfg == nil {
+		slog.Error("NewSubscriptionService: SubscriptionConfig cannot be nil")
+		return nil, errors.New("SubscriptionConfig cannot be nil")
+	}
+	return &subscriptionService{lroCreator: lroCreator, subscriptionRepository: subscriptionRepository, evPublisher: evPub, cfg: cfg, now: time.Now}, nil
 }
 
 // Lookup retrieves subscriptions based on the provided filter criteria.
@@ -80,13 +116,39 @@ func (s *subscriptionService) Lookup(ctx context.Context, filter *model.Subscrip
 	return subscriptions, nil
 }
 
+// clampValidFrom limits how far in the future the ValidFrom of req is, as configured by MaxValidFromSkew.
+func (s *subscriptionService) clampValidFrom(ctx context.Context, req *model.SubscriptionRequest) {
+	validFrom := req.ValidFrom
+	if clampValidFrom(&req.Subscription, s.now(), s.cfg.MaxValidFromSkew) {
+		slog.WarnContext(ctx, "SubscriptionService: Clamped ValidFrom too far in the future", "subscriber_id", req.SubscriberID, "message_id", req.MessageID, "valid_from", validFrom, "clamped_to", req.ValidFrom, "max_skew", s.cfg.MaxValidFromSkew)
+	}
+}
+
+// validateLocation returns ErrInvalidLocation if ValidateGps is enabled and the location of req has invalid coordinates.
+func (s *subscriptionService) validateLocation(ctx context.Context, req *model.SubscriptionRequest) error {
+	if !s.cfg.ValidateGps || req.Location == nil {
+		return nil
+	}
+	if err := req.Location.ValidateCoordinates(); err != nil {
+		slog.WarnContext(ctx, "SubscriptionService: Invalid location coordinates", "subscriber_id", req.SubscriberID, "message_id", req.MessageID, "error", err)
+		return fmt.Errorf("%w: %v", ErrInvalidLocation, err)
+	}
+	return nil
+}
+
 // createLRO is a helper method to construct and persist an LRO.
+// If MaxPendingOperations is set, the limit is checked by the insert itself, so that concurrent
+// requests of a subscriber cannot exceed it, and ErrTooManyPendingOperations is returned once it is hit.
 func (s *subscriptionService) createLRO(ctx context.Context, operationType model.OperationType, req *model.SubscriptionRequest) (*model.LRO, error) {
 	requestBytes, err := json.Marshal(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriptionService: Failed to marshal request for LRO", "error", err, "operation_id", req.MessageID, "type", operationType)
 		return nil, fmt.Errorf("failed to marshal request for LRO type %s: %w", operationType, err)
 	}
+	if max := s.cfg.MaxRequestBytes; max > 0 && len(requestBytes) > max {
+		slog.WarnContext(ctx, "SubscriptionService: Request too large for LRO", "operation_id", req.MessageID, "type", operationType, "size", len(requestBytes), "max_size", max)
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrRequestTooLarge, len(requestBytes), max)
+	}
 
 	newLRO := &model.LRO{
 		OperationID: req.MessageID,
@@ -95,7 +157,16 @@ func (s *subscriptionService) createLRO(ctx context.Context, operationType model
 		Status:      model.LROStatusPending,
 	}
 
-	createdLRO, err := s.lroCreator.Create(ctx, newLRO)
+	var createdLRO *model.LRO
+	if max := s.cfg.MaxPendingOperations; max > 0 {
+		createdLRO, err = s.lroCreator.CreateWithinLimit(ctx, newLRO, req.SubscriberID, max)
+	} else {
+		createdLRO, err = s.lroCreator.Create(ctx, newLRO)
+	}
+	if errors.Is(err, repository.ErrPendingOperationLimit) {
+		slog.WarnContext(ctx, "SubscriptionService: Subscriber has too many pending operations", "subscriber_id", req.SubscriberID, "max_pending", s.cfg.MaxPendingOperations)
+		return nil, fmt.Errorf("%w: %v", ErrTooManyPendingOperations, err)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "SubscriptionService: Failed to create LRO via lroCreator", "error", err, "operation_id", newLRO.OperationID, "type", newLRO.Type)
 		return nil, fmt.Errorf("failed to initiate LRO type %s: %w", newLRO.Type, err)
@@ -112,6 +183,10 @@ func (s *subscriptionService) Create(ctx context.Context, req *model.Subscriptio
 	}
 	slog.InfoContext(ctx, "SubscriptionService: Handling create subscription request", "message_id", req.MessageID)
 
+	if err := s.validateLocation(ctx, req); err != nil {
+		return nil, err
+	}
+	s.clampValidFrom(ctx, req)
 	createdLRO, err := s.createLRO(ctx, model.OperationTypeCreateSubscription, req)
 	if err != nil {
 		return nil, err
@@ -134,6 +209,10 @@ func (s *subscriptionService) Update(ctx context.Context, req *model.Subscriptio
 	}
 	slog.InfoContext(ctx, "SubscriptionService: Handling update subscription request", "message_id", req.MessageID)
 
+	if err := s.validateLocation(ctx, req); err != nil {
+		return nil, err
+	}
+	s.clampValidFrom(ctx, req)
 	createdLRO, err := s.createLRO(ctx, model.OperationTypeUpdateSubscription, req)
 	if err != nil {
 		return nil, err
@@ -148,6 +227,50 @@ func (s *subscriptionService) Update(ctx context.Context, req *model.Subscriptio
 	return createdLRO, nil
 }
 
+// Delete deletes the subscriptions of subscriberID holding keyID. It returns an error wrapping
+// repository.ErrSubscriptionNotFound if there is none.
+func (s *subscriptionService) Delete(ctx context.Context, subscriberID, keyID string) error {
+	slog.InfoContext(ctx, "SubscriptionService: Handling delete subscription request", "subscriber_id", subscriberID, "key_id", keyID)
+	if err := s.subscriptionRepository.DeleteSubscription(ctx, subscriberID, keyID); err != nil {
+		slog.ErrorContext(ctx, "SubscriptionService: Failed to delete subscription", "error", err, "subscriber_id", subscriberID, "key_id", keyID)
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	slog.InfoContext(ctx, "SubscriptionService: Subscription deleted", "subscriber_id", subscriberID, "key_id", keyID)
+	return nil
+}
+
+// Unsubscribe moves the subscription of req to UNSUBSCRIBED. It returns an error wrapping
+// repository.ErrSubscriptionNotFound if the Registry holds no such subscription.
+func (s *subscriptionService) Unsubscribe(ctx context.Context, req *model.SubscriptionRequest) error {
+	slog.InfoContext(ctx, "SubscriptionService: Handling unsubscribe request", "subscriber_id", req.SubscriberID, "key_id", req.KeyID, "message_id", req.MessageID)
+	if err := s.subscriptionRepository.UnsubscribeSubscription(ctx, req.SubscriberID, req.Domain, req.Type, req.KeyID); err != nil {
+		slog.ErrorContext(ctx, "SubscriptionService: Failed to unsubscribe subscription", "error", err, "subscriber_id", req.SubscriberID, "key_id", req.KeyID)
+		return fmt.Errorf("failed to unsubscribe subscription: %w", err)
+	}
+	slog.InfoContext(ctx, "SubscriptionService: Subscription unsubscribed", "subscriber_id", req.SubscriberID, "key_id", req.KeyID)
+	return nil
+}
+
+// GetSigningPublicKeyByKeyID fetches the public signing key of subscriberID's SUBSCRIBED subscription
+// holding keyID, whatever its domain and type. It returns an error wrapping
+// repository.ErrSubscriberKeyNotFound if there is none.
+func (s *subscriptionService) GetSigningPublicKeyByKeyID(ctx context.Context, subscriberID, keyID string) (string, error) {
+	subs, err := s.subscriptionRepository.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{SubscriberID: subscriberID},
+		KeyID:      keyID,
+		Status:     model.SubscriptionStatusSubscribed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up signing key: %w", err)
+	}
+	for _, sub := range subs {
+		if sub.SigningPublicKey != "" {
+			return sub.SigningPublicKey, nil
+		}
+	}
+	return "", fmt.Errorf("%w: for subscriber_id '%s', key_id '%s'", repository.ErrSubscriberKeyNotFound, subscriberID, keyID)
+}
+
 // GetSigningPublicKey fetches the subscriber's public signing key.
 func (s *subscriptionService) GetSigningPublicKey(ctx context.Context, subscriberID string, domain string, role model.Role, keyID string) (string, error) {
 	slog.InfoContext(ctx, "SubscriptionService: Fetching signing public key", "subscriber_id", subscriberID, "domain", domain, "type", role, "key_id", keyID)
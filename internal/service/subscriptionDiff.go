@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// locationJSON returns loc as JSON, or "" for a nil or empty location.
+func locationJSON(loc *model.Location) string {
+	if loc == nil || *loc == (model.Location{}) {
+		return ""
+	}
+	b, err := json.Marshal(loc)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// subscriptionDiff returns the fields an update changes from old to updated: the url,
+// the location and the keys. Fields are listed in that order and unchanged fields are omitted.
+func subscriptionDiff(old, updated *model.Subscription) []model.SubscriptionChange {
+	changes := []model.SubscriptionChange{}
+	for _, f := range []struct {
+		name     string
+		old, new string
+	}{
+		{"url", old.URL, updated.URL},
+		{"location", locationJSON(old.Location), locationJSON(updated.Location)},
+		{"key_id", old.KeyID, updated.KeyID},
+		{"signing_public_key", old.SigningPublicKey, updated.SigningPublicKey},
+		{"encr_public_key", old.EncrPublicKey, updated.EncrPublicKey},
+	} {
+		if f.old != f.new {
+			changes = append(changes, model.SubscriptionChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return changes
+}
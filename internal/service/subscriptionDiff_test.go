@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSubscriptionDiff(t *testing.T) {
+	old := model.Subscription{
+		Subscriber:       model.Subscriber{SubscriberID: "sub1", URL: "http://old.com", Location: &model.Location{City: &model.City{Code: "std:080"}}},
+		KeyID:            "key1",
+		SigningPublicKey: "signing1",
+		EncrPublicKey:    "encr1",
+	}
+
+	tests := []struct {
+		name   string
+		update func(s *model.Subscription)
+		want   []model.SubscriptionChange
+	}{
+		{
+			name:   "no change",
+			update: func(s *model.Subscription) {},
+			want:   []model.SubscriptionChange{},
+		},
+		{
+			name:   "url only",
+			update: func(s *model.Subscription) { s.URL = "http://new.com" },
+			want:   []model.SubscriptionChange{{Field: "url", Old: "http://old.com", New: "http://new.com"}},
+		},
+		{
+			name:   "equal location at another address",
+			update: func(s *model.Subscription) { s.Location = &model.Location{City: &model.City{Code: "std:080"}} },
+			want:   []model.SubscriptionChange{},
+		},
+		{
+			name:   "location removed",
+			update: func(s *model.Subscription) { s.Location = &model.Location{} },
+			want:   []model.SubscriptionChange{{Field: "location", Old: `{"city":{"code":"std:080"}}`, New: ""}},
+		},
+		{
+			name: "key rotation",
+			update: func(s *model.Subscription) {
+				s.KeyID, s.SigningPublicKey, s.EncrPublicKey = "key2", "signing2", "encr2"
+			},
+			want: []model.SubscriptionChange{
+				{Field: "key_id", Old: "key1", New: "key2"},
+				{Field: "signing_public_key", Old: "signing1", New: "signing2"},
+				{Field: "encr_public_key", Old: "encr1", New: "encr2"},
+			},
+		},
+		{
+			name:   "other fields ignored",
+			update: func(s *model.Subscription) { s.Nonce = "n" },
+			want:   []model.SubscriptionChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := old
+			tt.update(&updated)
+			if diff := cmp.Diff(tt.want, subscriptionDiff(&old, &updated)); diff != "" {
+				t.Errorf("subscriptionDiff() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// ExpiredSubscriptionPolicy decides what the gateway does with a target
+// subscription whose ValidUntil has passed.
+type ExpiredSubscriptionPolicy string
+
+const (
+	// ExpiredSubscriptionPolicyOff proxies to expired subscriptions without checking. It is the default.
+	ExpiredSubscriptionPolicyOff ExpiredSubscriptionPolicy = "OFF"
+	// ExpiredSubscriptionPolicyWarn logs a warning and proxies anyway, for transition periods.
+	ExpiredSubscriptionPolicyWarn ExpiredSubscriptionPolicy = "WARN"
+	// ExpiredSubscriptionPolicyReject does not proxy to expired subscriptions.
+	ExpiredSubscriptionPolicyReject ExpiredSubscriptionPolicy = "REJECT"
+)
+
+// ErrSubscriptionExpired is returned when a payload is not proxied because the target subscription has expired.
+var ErrSubscriptionExpired = errors.New("target subscription has expired")
+
+// Valid reports whether p is a known policy. The empty policy is valid and means OFF.
+func (p ExpiredSubscriptionPolicy) Valid() bool {
+	switch p {
+	case "", ExpiredSubscriptionPolicyOff, ExpiredSubscriptionPolicyWarn, ExpiredSubscriptionPolicyReject:
+		return true
+	}
+	return false
+}
+
+// enabled reports whether p requires target subscriptions to be checked.
+func (p ExpiredSubscriptionPolicy) enabled() bool {
+	return p == ExpiredSubscriptionPolicyWarn || p == ExpiredSubscriptionPolicyReject
+}
+
+// clampValidFrom sets the ValidFrom of sub to now if it is more than maxSkew in the future,
+// so that a clock-skewed request does not leave the subscription dormant. It reports whether
+// ValidFrom was changed. A maxSkew of 0 or less disables the check.
+func clampValidFrom(sub *model.Subscription, now time.Time, maxSkew time.Duration) bool {
+	if maxSkew <= 0 || !sub.ValidFrom.After(now.Add(maxSkew)) {
+		return false
+	}
+	sub.ValidFrom = now
+	return true
+}
+
+// subscriptionExpired reports whether sub is no longer valid at now.
+// A subscription without a ValidUntil never expires.
+func subscriptionExpired(sub *model.Subscription, now time.Time) bool {
+	return !sub.ValidUntil.IsZero() && !now.Before(sub.ValidUntil)
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/google/dpi-accelerator-beckn-onix/internal/event/mock"
+	"github.com/google/dpi-accelerator-beckn-onix/internal/repository"
 	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
 
 	"github.com/google/go-cmp/cmp"
@@ -31,19 +32,56 @@ import (
 
 // mockLROCreator is a mock implementation of lroCreator.
 type mockLROCreator struct {
-	lro *model.LRO
-	err error
+	lro          *model.LRO
+	err          error
+	calls        int
+	created      *model.LRO // Last LRO received.
+	pendingCount int        // Pending operations of the subscriber seen by CreateWithinLimit.
+	limitCalls   int
 }
 
 func (m *mockLROCreator) Create(ctx context.Context, lro *model.LRO) (*model.LRO, error) {
+	m.calls++
+	m.created = lro
 	return m.lro, m.err
 }
 
+func (m *mockLROCreator) CreateWithinLimit(ctx context.Context, lro *model.LRO, subscriberID string, max int) (*model.LRO, error) {
+	m.limitCalls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.pendingCount >= max {
+		return nil, fmt.Errorf("%w: subscriber_id '%s' has %d pending operations (max %d)", repository.ErrPendingOperationLimit, subscriberID, m.pendingCount, max)
+	}
+	m.created = lro
+	return m.lro, nil
+}
+
 // mockSubscriptionRepository is a mock implementation of subscriptionRepository.
 type mockSubscriptionRepository struct {
 	key           string
 	err           error
 	subscriptions []model.Subscription
+	deleteErr     error
+	deleted       []string // subscriber_id|key_id of deleted subscriptions.
+	unsubscribed  []string // subscriber_id|domain|type|key_id of unsubscribed subscriptions.
+}
+
+func (m *mockSubscriptionRepository) UnsubscribeSubscription(ctx context.Context, subscriberID, domain string, role model.Role, keyID string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.unsubscribed = append(m.unsubscribed, subscriberID+"|"+domain+"|"+string(role)+"|"+keyID)
+	return nil
+}
+
+func (m *mockSubscriptionRepository) DeleteSubscription(ctx context.Context, subscriberID, keyID string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = append(m.deleted, subscriberID+"|"+keyID)
+	return nil
 }
 
 func (m *mockSubscriptionRepository) Lookup(ctx context.Context, filter *model.Subscription) ([]model.Subscription, error) {
@@ -57,7 +95,7 @@ func (m *mockSubscriptionRepository) GetSubscriberSigningKey(ctx context.Context
 func TestNewSubscriptionService_Success(t *testing.T) {
 	mockLRO := &mockLROCreator{}
 	mockRepo := &mockSubscriptionRepository{}
-	service, _ := NewSubscriptionService(mockLRO, mockRepo, &mock.EventPublisher{})
+	service, _ := NewSubscriptionService(mockLRO, mockRepo, &mock.EventPublisher{}, &SubscriptionConfig{})
 
 	if service == nil {
 		t.Fatal("NewSubscriptionService() returned nil")
@@ -76,6 +114,7 @@ func TestNewSubscriptionService_Error(t *testing.T) {
 		lroCreator             lroCreator
 		subscriptionRepository subscriptionRepository
 		evPub                  subscriptionEventPublisher
+		cfg                    *SubscriptionConfig
 		expectedErrorMsg       string
 	}{
 		{
@@ -83,6 +122,7 @@ func TestNewSubscriptionService_Error(t *testing.T) {
 			lroCreator:             nil,
 			subscriptionRepository: &mockSubscriptionRepository{},
 			evPub:                  &mock.EventPublisher{},
+			cfg:                    &SubscriptionConfig{},
 			expectedErrorMsg:       "lroCreator cannot be nil",
 		},
 		{
@@ -90,6 +130,7 @@ func TestNewSubscriptionService_Error(t *testing.T) {
 			lroCreator:             &mockLROCreator{},
 			subscriptionRepository: nil,
 			evPub:                  &mock.EventPublisher{},
+			cfg:                    &SubscriptionConfig{},
 			expectedErrorMsg:       "subscriptionRepository cannot be nil",
 		},
 		{
@@ -97,13 +138,22 @@ func TestNewSubscriptionService_Error(t *testing.T) {
 			lroCreator:             &mockLROCreator{},
 			subscriptionRepository: &mockSubscriptionRepository{},
 			evPub:                  nil,
+			cfg:                    &SubscriptionConfig{},
 			expectedErrorMsg:       "eventPublisher cannot be nil",
 		},
+		{
+			name:                   "nil config",
+			lroCreator:             &mockLROCreator{},
+			subscriptionRepository: &mockSubscriptionRepository{},
+			evPub:                  &mock.EventPublisher{},
+			cfg:                    nil,
+			expectedErrorMsg:       "SubscriptionConfig cannot be nil",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := NewSubscriptionService(tt.lroCreator, tt.subscriptionRepository, tt.evPub)
+			_, err := NewSubscriptionService(tt.lroCreator, tt.subscriptionRepository, tt.evPub, tt.cfg)
 			if err == nil || err.Error() != tt.expectedErrorMsg {
 				t.Errorf("NewSubscriptionService() error = %v, want error message %q", err, tt.expectedErrorMsg)
 			}
@@ -168,7 +218,7 @@ func TestSubscriptionServiceLookupSuccess(t *testing.T) {
 				subscriptions: tt.mockRepoSubs,
 				err:           tt.mockRepoErr,
 			}
-			service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+			service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{}, &SubscriptionConfig{})
 			if err != nil {
 				t.Fatalf("NewSubscriptionService() failed: %v", err)
 			}
@@ -209,7 +259,7 @@ func TestSubscriptionServiceLookupError(t *testing.T) {
 				subscriptions: nil,
 				err:           tt.mockRepoErr,
 			}
-			service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+			service, err := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{}, &SubscriptionConfig{})
 			if err != nil {
 				t.Fatalf("NewSubscriptionService() failed: %v", err)
 			}
@@ -251,7 +301,7 @@ func TestSubscriptionService_Create_Success(t *testing.T) {
 	mockLRO := &mockLROCreator{lro: defaultLROWithReqJSON}
 	wantLRO := defaultLROWithReqJSON
 
-	service, _ := NewSubscriptionService(mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+	service, _ := NewSubscriptionService(mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{})
 	gotLRO, err := service.Create(ctx, req)
 
 	if err != nil {
@@ -297,7 +347,7 @@ func TestSubscriptionService_Create_Error(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service, _ := NewSubscriptionService(tt.mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+			service, _ := NewSubscriptionService(tt.mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{})
 			_, err := service.Create(ctx, tt.req)
 
 			if err == nil {
@@ -323,7 +373,7 @@ func TestSubscriptionService_Update_Success(t *testing.T) {
 	mockLRO := &mockLROCreator{lro: defaultLROWithReqJSON}
 	wantLRO := defaultLROWithReqJSON
 
-	service, _ := NewSubscriptionService(mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+	service, _ := NewSubscriptionService(mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{})
 	gotLRO, err := service.Update(ctx, req)
 
 	if err != nil {
@@ -362,7 +412,7 @@ func TestSubscriptionService_Update_Error(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service, _ := NewSubscriptionService(tt.mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{})
+			service, _ := NewSubscriptionService(tt.mockLRO, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{})
 			_, err := service.Update(ctx, tt.req)
 
 			if err == nil {
@@ -375,12 +425,229 @@ func TestSubscriptionService_Update_Error(t *testing.T) {
 	}
 }
 
+func TestSubscriptionService_PendingOperationsLimit(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "pending-sub-id"}},
+		MessageID:    "pending-msg-id",
+	}
+	lro := &model.LRO{OperationID: "pending-msg-id", Status: model.LROStatusPending}
+	insertErr := errors.New("insert failed")
+
+	tests := []struct {
+		name           string
+		cfg            *SubscriptionConfig
+		lroCreator     *mockLROCreator
+		wantErr        error
+		wantLimitCalls int
+	}{
+		{
+			name:           "subscriber under the limit proceeds",
+			cfg:            &SubscriptionConfig{MaxPendingOperations: 2},
+			lroCreator:     &mockLROCreator{lro: lro, pendingCount: 1},
+			wantLimitCalls: 2,
+		},
+		{
+			name:           "subscriber at the limit is rejected",
+			cfg:            &SubscriptionConfig{MaxPendingOperations: 2},
+			lroCreator:     &mockLROCreator{lro: lro, pendingCount: 2},
+			wantErr:        ErrTooManyPendingOperations,
+			wantLimitCalls: 2,
+		},
+		{
+			name:       "limit disabled inserts without the limit",
+			cfg:        &SubscriptionConfig{},
+			lroCreator: &mockLROCreator{lro: lro, pendingCount: 10},
+		},
+		{
+			name:           "insert error is returned",
+			cfg:            &SubscriptionConfig{MaxPendingOperations: 2},
+			lroCreator:     &mockLROCreator{err: insertErr},
+			wantErr:        insertErr,
+			wantLimitCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := NewSubscriptionService(tt.lroCreator, &mockSubscriptionRepository{}, &mock.EventPublisher{}, tt.cfg)
+			if err != nil {
+				t.Fatalf("NewSubscriptionService() failed: %v", err)
+			}
+			ops := map[string]func(context.Context, *model.SubscriptionRequest) (*model.LRO, error){
+				"Create": service.Create,
+				"Update": service.Update,
+			}
+			for name, op := range ops {
+				got, err := op(ctx, req)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+				}
+				if tt.wantErr == nil && got != lro {
+					t.Errorf("%s() LRO = %v, want %v", name, got, lro)
+				}
+			}
+			if tt.lroCreator.limitCalls != tt.wantLimitCalls {
+				t.Errorf("CreateWithinLimit() calls = %d, want %d", tt.lroCreator.limitCalls, tt.wantLimitCalls)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_MaxRequestBytes(t *testing.T) {
+	ctx := context.Background()
+	req := &model.SubscriptionRequest{
+		Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "large-sub-id"}},
+		MessageID:    "large-msg-id",
+	}
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	lro := &model.LRO{OperationID: "large-msg-id", Status: model.LROStatusPending}
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		wantErr  error
+	}{
+		{name: "within the limit", maxBytes: len(reqJSON)},
+		{name: "over the limit", maxBytes: len(reqJSON) - 1, wantErr: ErrRequestTooLarge},
+		{name: "limit disabled", maxBytes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"Create", "Update"} {
+				lroCreator := &mockLROCreator{lro: lro}
+				service, err := NewSubscriptionService(lroCreator, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{MaxRequestBytes: tt.maxBytes})
+				if err != nil {
+					t.Fatalf("NewSubscriptionService() failed: %v", err)
+				}
+				op := service.Create
+				if name == "Update" {
+					op = service.Update
+				}
+				got, err := op(ctx, req)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					if lroCreator.calls != 0 {
+						t.Errorf("%s() persisted an over-limit request %d times, want 0", name, lroCreator.calls)
+					}
+					continue
+				}
+				if got != lro {
+					t.Errorf("%s() LRO = %v, want %v", name, got, lro)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_ValidateGps(t *testing.T) {
+	ctx := context.Background()
+	lro := &model.LRO{OperationID: "gps-msg-id", Status: model.LROStatusPending}
+
+	tests := []struct {
+		name     string
+		validate bool
+		location *model.Location
+		wantErr  error
+	}{
+		{name: "valid gps", validate: true, location: &model.Location{Gps: "12.9716,77.5946"}},
+		{name: "no location", validate: true},
+		{name: "malformed gps", validate: true, location: &model.Location{Gps: "abc"}, wantErr: ErrInvalidLocation},
+		{name: "out of range gps", validate: true, location: &model.Location{Gps: "91,0"}, wantErr: ErrInvalidLocation},
+		{name: "malformed circle gps", validate: true, location: &model.Location{Circle: &model.Circle{Gps: "12.9,abc"}}, wantErr: ErrInvalidLocation},
+		{name: "malformed gps with validation disabled", location: &model.Location{Gps: "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.SubscriptionRequest{
+				Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "gps-sub-id", Location: tt.location}},
+				MessageID:    "gps-msg-id",
+			}
+			for _, name := range []string{"Create", "Update"} {
+				lroCreator := &mockLROCreator{lro: lro}
+				service, err := NewSubscriptionService(lroCreator, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{ValidateGps: tt.validate})
+				if err != nil {
+					t.Fatalf("NewSubscriptionService() failed: %v", err)
+				}
+				op := service.Create
+				if name == "Update" {
+					op = service.Update
+				}
+				_, err = op(ctx, req)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+				}
+				if tt.wantErr != nil && lroCreator.calls != 0 {
+					t.Errorf("%s() persisted a request with an invalid location %d times, want 0", name, lroCreator.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_MaxValidFromSkew(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		maxSkew       time.Duration
+		validFrom     time.Time
+		wantValidFrom time.Time
+	}{
+		{name: "future beyond skew is clamped", maxSkew: 5 * time.Minute, validFrom: now.Add(time.Hour), wantValidFrom: now},
+		{name: "future within skew passes", maxSkew: 5 * time.Minute, validFrom: now.Add(time.Minute), wantValidFrom: now.Add(time.Minute)},
+		{name: "past passes", maxSkew: 5 * time.Minute, validFrom: now.Add(-time.Hour), wantValidFrom: now.Add(-time.Hour)},
+		{name: "unset passes", maxSkew: 5 * time.Minute},
+		{name: "check disabled", validFrom: now.Add(time.Hour), wantValidFrom: now.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"Create", "Update"} {
+				lroCreator := &mockLROCreator{lro: &model.LRO{OperationID: "msg-id", Status: model.LROStatusPending}}
+				service, err := NewSubscriptionService(lroCreator, &mockSubscriptionRepository{}, &mock.EventPublisher{}, &SubscriptionConfig{MaxValidFromSkew: tt.maxSkew})
+				if err != nil {
+					t.Fatalf("NewSubscriptionService() failed: %v", err)
+				}
+				service.now = func() time.Time { return now }
+				op := service.Create
+				if name == "Update" {
+					op = service.Update
+				}
+				req := &model.SubscriptionRequest{
+					Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "sub-id"}, ValidFrom: tt.validFrom},
+					MessageID:    "msg-id",
+				}
+
+				if _, err := op(ctx, req); err != nil {
+					t.Fatalf("%s() error = %v", name, err)
+				}
+				var stored model.SubscriptionRequest
+				if err := json.Unmarshal(lroCreator.created.RequestJSON, &stored); err != nil {
+					t.Fatalf("json.Unmarshal() of stored request error = %v", err)
+				}
+				if !stored.ValidFrom.Equal(tt.wantValidFrom) {
+					t.Errorf("%s() stored ValidFrom = %v, want %v", name, stored.ValidFrom, tt.wantValidFrom)
+				}
+			}
+		})
+	}
+}
+
 func TestSubscriptionService_GetSigningPublicKey_Success(t *testing.T) {
 	ctx := context.Background()
 	wantKey := "test-public-key"
 	mockRepo := &mockSubscriptionRepository{key: wantKey}
 
-	service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+	service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{}, &SubscriptionConfig{})
 	gotKey, err := service.GetSigningPublicKey(ctx, "sub1", "domain1", model.RoleBAP, "key1")
 
 	if err != nil {
@@ -397,7 +664,7 @@ func TestSubscriptionService_GetSigningPublicKey_Error(t *testing.T) {
 	mockRepo := &mockSubscriptionRepository{err: errors.New("db error")}
 
 	t.Run("repository returns error", func(t *testing.T) {
-		service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{})
+		service, _ := NewSubscriptionService(&mockLROCreator{}, mockRepo, &mock.EventPublisher{}, &SubscriptionConfig{})
 		_, err := service.GetSigningPublicKey(ctx, "sub1", "domain1", model.RoleBAP, "key1")
 
 		if err == nil {
@@ -408,3 +675,86 @@ func TestSubscriptionService_GetSigningPublicKey_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestSubscriptionService_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    *mockSubscriptionRepository
+		wantErr error
+	}{
+		{name: "deleted", repo: &mockSubscriptionRepository{}},
+		{name: "not found", repo: &mockSubscriptionRepository{deleteErr: repository.ErrSubscriptionNotFound}, wantErr: repository.ErrSubscriptionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSubscriptionService(&mockLROCreator{}, tt.repo, &mock.EventPublisher{}, &SubscriptionConfig{})
+			if err != nil {
+				t.Fatalf("NewSubscriptionService() error = %v", err)
+			}
+
+			err = s.Delete(context.Background(), "np.com", "key1")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(tt.repo.deleted, []string{"np.com|key1"}) {
+				t.Errorf("deleted = %v, want [np.com|key1]", tt.repo.deleted)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_Unsubscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    *mockSubscriptionRepository
+		wantErr error
+	}{
+		{name: "unsubscribed", repo: &mockSubscriptionRepository{}},
+		{name: "not found", repo: &mockSubscriptionRepository{deleteErr: repository.ErrSubscriptionNotFound}, wantErr: repository.ErrSubscriptionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSubscriptionService(&mockLROCreator{}, tt.repo, &mock.EventPublisher{}, &SubscriptionConfig{})
+			if err != nil {
+				t.Fatalf("NewSubscriptionService() error = %v", err)
+			}
+			req := &model.SubscriptionRequest{Subscription: model.Subscription{Subscriber: model.Subscriber{SubscriberID: "np.com", Domain: "retail", Type: model.RoleBAP}, KeyID: "key1"}}
+
+			err = s.Unsubscribe(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Unsubscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(tt.repo.unsubscribed, []string{"np.com|retail|BAP|key1"}) {
+				t.Errorf("unsubscribed = %v, want [np.com|retail|BAP|key1]", tt.repo.unsubscribed)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_GetSigningPublicKeyByKeyID(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    *mockSubscriptionRepository
+		want    string
+		wantErr error
+	}{
+		{name: "found", repo: &mockSubscriptionRepository{subscriptions: []model.Subscription{{KeyID: "key1", SigningPublicKey: "pub"}}}, want: "pub"},
+		{name: "not found", repo: &mockSubscriptionRepository{}, wantErr: repository.ErrSubscriberKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSubscriptionService(&mockLROCreator{}, tt.repo, &mock.EventPublisher{}, &SubscriptionConfig{})
+			if err != nil {
+				t.Fatalf("NewSubscriptionService() error = %v", err)
+			}
+
+			got, err := s.GetSigningPublicKeyByKeyID(context.Background(), "np.com", "key1")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("GetSigningPublicKeyByKeyID() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetSigningPublicKeyByKeyID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
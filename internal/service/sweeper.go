@@ -0,0 +1,179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SweeperConfig holds the scheduling configuration for the sweeper.
+type SweeperConfig struct {
+	Interval       time.Duration `yaml:"interval"`       // Time between sweeps.
+	MaxConcurrency int           `yaml:"maxConcurrency"` // Maximum number of jobs running at the same time.
+	Jitter         time.Duration `yaml:"jitter"`         // Upper bound of the random delay added to each interval.
+}
+
+// SweepFunc is a reconcile job run periodically by the sweeper,
+// e.g. retrying pending LROs or purging orphaned keys.
+type SweepFunc func(ctx context.Context) error
+
+// ErrSweepJobAlreadyRegistered is returned when a job is registered twice under the same name.
+var ErrSweepJobAlreadyRegistered = errors.New("sweep job already registered")
+
+// sweepJob is a registered job along with its run state.
+type sweepJob struct {
+	name    string
+	fn      SweepFunc
+	running atomic.Bool
+}
+
+// sweeper runs registered reconcile jobs on a fixed interval.
+// A job is never started while its previous run is still in progress,
+// and at most MaxConcurrency jobs run at the same time.
+type sweeper struct {
+	cfg  *SweeperConfig
+	sem  chan struct{}
+	mu   sync.Mutex
+	jobs []*sweepJob
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSweeper creates a new sweeper.
+func NewSweeper(cfg *SweeperConfig) (*sweeper, error) {
+	if cfg == nil {
+		slog.Error("NewSweeper: SweeperConfig cannot be nil")
+		return nil, errors.New("SweeperConfig cannot be nil")
+	}
+	if cfg.Interval <= 0 {
+		slog.Error("NewSweeper: interval must be positive", "interval", cfg.Interval)
+		return nil, fmt.Errorf("sweeper interval must be positive, got %s", cfg.Interval)
+	}
+	if cfg.Jitter < 0 {
+		slog.Error("NewSweeper: jitter cannot be negative", "jitter", cfg.Jitter)
+		return nil, fmt.Errorf("sweeper jitter cannot be negative, got %s", cfg.Jitter)
+	}
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		slog.Warn("NewSweeper: maxConcurrency is not positive, defaulting to 1", "provided_max_concurrency", maxConcurrency)
+		maxConcurrency = 1
+	}
+	return &sweeper{
+		cfg: cfg,
+		sem: make(chan struct{}, maxConcurrency),
+	}, nil
+}
+
+// Register adds a job to be run on every sweep.
+// Jobs registered after Start are picked up from the next sweep.
+func (s *sweeper) Register(name string, fn SweepFunc) error {
+	if name == "" {
+		return errors.New("sweep job name cannot be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("sweep job %q cannot be nil", name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("%w: %s", ErrSweepJobAlreadyRegistered, name)
+		}
+	}
+	s.jobs = append(s.jobs, &sweepJob{name: name, fn: fn})
+	return nil
+}
+
+// Start launches the scheduling loop. The loop runs until ctx is cancelled or Stop is called.
+func (s *sweeper) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		slog.InfoContext(ctx, "Sweeper: started", "interval", s.cfg.Interval, "jitter", s.cfg.Jitter, "max_concurrency", cap(s.sem))
+		for {
+			timer := time.NewTimer(s.nextDelay())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				slog.InfoContext(ctx, "Sweeper: stopping")
+				return
+			case <-timer.C:
+				s.sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduling loop to stop and waits for in-flight jobs to finish.
+func (s *sweeper) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// nextDelay returns the interval plus a random jitter.
+func (s *sweeper) nextDelay() time.Duration {
+	if s.cfg.Jitter <= 0 {
+		return s.cfg.Interval
+	}
+	return s.cfg.Interval + rand.N(s.cfg.Jitter)
+}
+
+// sweep starts every registered job that is not already running.
+func (s *sweeper) sweep(ctx context.Context) {
+	s.mu.Lock()
+	jobs := make([]*sweepJob, len(s.jobs))
+	copy(jobs, s.jobs)
+	s.mu.Unlock()
+
+	for _, j := range jobs {
+		if !j.running.CompareAndSwap(false, true) {
+			slog.WarnContext(ctx, "Sweeper: skipping job, previous run still in progress", "job", j.name)
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer j.running.Store(false)
+			select {
+			case s.sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-s.sem }()
+			s.run(ctx, j)
+		}()
+	}
+}
+
+// run executes a single job, logging its outcome.
+func (s *sweeper) run(ctx context.Context, j *sweepJob) {
+	start := time.Now()
+	if err := j.fn(ctx); err != nil {
+		slog.ErrorContext(ctx, "Sweeper: job failed", "job", j.name, "error", err, "duration", time.Since(start))
+		return
+	}
+	slog.DebugContext(ctx, "Sweeper: job completed", "job", j.name, "duration", time.Since(start))
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyTracker records the current and maximum number of concurrent calls.
+type concurrencyTracker struct {
+	current atomic.Int32
+	max     atomic.Int32
+	calls   atomic.Int32
+}
+
+func (c *concurrencyTracker) enter() {
+	c.calls.Add(1)
+	n := c.current.Add(1)
+	for {
+		m := c.max.Load()
+		if n <= m || c.max.CompareAndSwap(m, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrencyTracker) exit() {
+	c.current.Add(-1)
+}
+
+func TestNewSweeper_Success(t *testing.T) {
+	s, err := NewSweeper(&SweeperConfig{Interval: time.Second})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v, want nil", err)
+	}
+	if cap(s.sem) != 1 {
+		t.Errorf("NewSweeper() concurrency = %d, want default 1", cap(s.sem))
+	}
+}
+
+func TestNewSweeper_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *SweeperConfig
+		wantErr string
+	}{
+		{
+			name:    "nil config",
+			cfg:     nil,
+			wantErr: "SweeperConfig cannot be nil",
+		},
+		{
+			name:    "zero interval",
+			cfg:     &SweeperConfig{},
+			wantErr: "sweeper interval must be positive",
+		},
+		{
+			name:    "negative jitter",
+			cfg:     &SweeperConfig{Interval: time.Second, Jitter: -time.Second},
+			wantErr: "sweeper jitter cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSweeper(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewSweeper() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSweeper_Register_Error(t *testing.T) {
+	s, err := NewSweeper(&SweeperConfig{Interval: time.Second})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v", err)
+	}
+	noop := func(context.Context) error { return nil }
+	if err := s.Register("job", noop); err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+
+	if err := s.Register("job", noop); !errors.Is(err, ErrSweepJobAlreadyRegistered) {
+		t.Errorf("Register() duplicate error = %v, want %v", err, ErrSweepJobAlreadyRegistered)
+	}
+	if err := s.Register("", noop); err == nil {
+		t.Error("Register() with empty name error = nil, want error")
+	}
+	if err := s.Register("nil-job", nil); err == nil {
+		t.Error("Register() with nil func error = nil, want error")
+	}
+}
+
+func TestSweeper_RunsOnInterval(t *testing.T) {
+	s, err := NewSweeper(&SweeperConfig{Interval: 10 * time.Millisecond, Jitter: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v", err)
+	}
+	var runs atomic.Int32
+	if err := s.Register("counter", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	// A failing job must not stop the schedule of the others.
+	if err := s.Register("failing", func(context.Context) error {
+		return errors.New("sweep failed")
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	s.Start(context.Background())
+	time.Sleep(150 * time.Millisecond)
+	s.Stop()
+
+	got := runs.Load()
+	// 150ms at an interval of 10-15ms allows for at most 15 runs.
+	if got < 3 || got > 15 {
+		t.Errorf("job ran %d times in 150ms, want between 3 and 15", got)
+	}
+
+	// No further runs after Stop.
+	time.Sleep(30 * time.Millisecond)
+	if after := runs.Load(); after != got {
+		t.Errorf("job ran %d more times after Stop(), want 0", after-got)
+	}
+}
+
+func TestSweeper_NoOverlappingRuns(t *testing.T) {
+	s, err := NewSweeper(&SweeperConfig{Interval: 2 * time.Millisecond, MaxConcurrency: 4})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v", err)
+	}
+	tracker := &concurrencyTracker{}
+	if err := s.Register("slow", func(context.Context) error {
+		tracker.enter()
+		defer tracker.exit()
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	s.Start(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+
+	if got := tracker.max.Load(); got != 1 {
+		t.Errorf("max concurrent runs of the same job = %d, want 1", got)
+	}
+	if tracker.calls.Load() < 2 {
+		t.Errorf("job ran %d times, want at least 2", tracker.calls.Load())
+	}
+}
+
+func TestSweeper_RespectsConcurrencyLimit(t *testing.T) {
+	const maxConcurrency = 2
+	s, err := NewSweeper(&SweeperConfig{Interval: 5 * time.Millisecond, MaxConcurrency: maxConcurrency})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v", err)
+	}
+	tracker := &concurrencyTracker{}
+	for i := range 5 {
+		if err := s.Register(fmt.Sprintf("job-%d", i), func(context.Context) error {
+			tracker.enter()
+			defer tracker.exit()
+			time.Sleep(15 * time.Millisecond)
+			return nil
+		}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	s.Start(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+
+	if got := tracker.max.Load(); got > maxConcurrency {
+		t.Errorf("max concurrent jobs = %d, want at most %d", got, maxConcurrency)
+	}
+	if got := tracker.max.Load(); got < maxConcurrency {
+		t.Errorf("max concurrent jobs = %d, want the limit %d to be reached", got, maxConcurrency)
+	}
+}
+
+func TestSweeper_StopOnContextCancel(t *testing.T) {
+	s, err := NewSweeper(&SweeperConfig{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewSweeper() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return after context cancellation")
+	}
+}
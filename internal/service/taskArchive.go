@@ -0,0 +1,252 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+)
+
+// redactedHeaderValue replaces the value of credential headers in archived records.
+const redactedHeaderValue = "REDACTED"
+
+// redactedHeaders are the headers whose values are never archived.
+var redactedHeaders = []string{
+	model.AuthHeaderSubscriber,
+	model.AuthHeaderGateway,
+	"Proxy-Authorization",
+	"Cookie",
+}
+
+// ProxyTaskRecord is the audit record of a payload proxied to a network participant.
+type ProxyTaskRecord struct {
+	TransactionID string      `json:"transaction_id"`
+	MessageID     string      `json:"message_id"`
+	Action        string      `json:"action"`
+	Target        string      `json:"target"`
+	Headers       http.Header `json:"headers"` // Headers sent, with credentials redacted.
+	Body          string      `json:"body"`    // Exact body sent.
+	StatusCode    int         `json:"status_code"`
+	Error         string      `json:"error,omitempty"`
+	ProcessedAt   time.Time   `json:"processed_at" format:"date-time"`
+}
+
+// taskArchive stores the audit records of proxied tasks.
+type taskArchive interface {
+	Archive(ctx context.Context, rec *ProxyTaskRecord) error
+}
+
+// noopTaskArchive discards all records. It is the default archive of the proxy processor.
+type noopTaskArchive struct{}
+
+// Archive implements taskArchive.
+func (noopTaskArchive) Archive(context.Context, *ProxyTaskRecord) error {
+	return nil
+}
+
+// archiveCache defines the subset of the cache used to store archived records.
+type archiveCache interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// cacheTaskArchive stores records as JSON in a cache such as Redis, keyed by
+// transaction id, message id and target so that every fan-out target is kept.
+type cacheTaskArchive struct {
+	cache archiveCache
+	ttl   time.Duration
+}
+
+// NewCacheTaskArchive creates a task archive that keeps records in cache for ttl.
+func NewCacheTaskArchive(cache archiveCache, ttl time.Duration) (*cacheTaskArchive, error) {
+	if cache == nil {
+		slog.Error("NewCacheTaskArchive: cache cannot be nil")
+		return nil, errors.New("cache cannot be nil")
+	}
+	if ttl <= 0 {
+		slog.Error("NewCacheTaskArchive: ttl must be positive", "ttl", ttl)
+		return nil, fmt.Errorf("task archive ttl must be positive, got %s", ttl)
+	}
+	return &cacheTaskArchive{cache: cache, ttl: ttl}, nil
+}
+
+// archiveKeyPrefix prefixes the cache keys of archived records.
+const archiveKeyPrefix = "proxy_task_archive:"
+
+// archiveKeySegmentEscaper escapes the separator of the segments of an archive key, so that
+// ids containing ':' cannot produce the key of another message.
+var archiveKeySegmentEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+
+// archiveKey returns the cache key of a record. The target is the last segment and is not escaped.
+func archiveKey(rec *ProxyTaskRecord) string {
+	return fmt.Sprintf("%s%s:%s:%s", archiveKeyPrefix, archiveKeySegmentEscaper.Replace(rec.TransactionID), archiveKeySegmentEscaper.Replace(rec.MessageID), rec.Target)
+}
+
+// Archive implements taskArchive.
+func (a *cacheTaskArchive) Archive(ctx context.Context, rec *ProxyTaskRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal proxy task record: %w", err)
+	}
+	if err := a.cache.Set(ctx, archiveKey(rec), string(b), a.ttl); err != nil {
+		return fmt.Errorf("failed to store proxy task record: %w", err)
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of h with the values of credential headers replaced.
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedHeaderValue)
+		}
+	}
+	return redacted
+}
+
+// ErrArchiveRecordNotFound is returned when no archived record matches a retrieval or replay.
+var ErrArchiveRecordNotFound = errors.New("proxy task record not found")
+
+// archiveStore reads back the records stored by a cacheTaskArchive.
+type archiveStore interface {
+	GetIfExists(ctx context.Context, key string) (string, bool, error)
+	Scan(ctx context.Context, match string) ([]string, error)
+}
+
+// taskArchiveReader retrieves the archived records of proxied tasks and replays them.
+type taskArchiveReader struct {
+	store archiveStore
+	proxy taskProcessor
+}
+
+// NewTaskArchiveReader creates a reader of the records stored in store by a cache task archive,
+// replaying them with proxy.
+func NewTaskArchiveReader(store archiveStore, proxy taskProcessor) (*taskArchiveReader, error) {
+	if store == nil {
+		slog.Error("NewTaskArchiveReader: store cannot be nil")
+		return nil, errors.New("store cannot be nil")
+	}
+	if proxy == nil {
+		slog.Error("NewTaskArchiveReader: proxy cannot be nil")
+		return nil, errors.New("proxy cannot be nil")
+	}
+	return &taskArchiveReader{store: store, proxy: proxy}, nil
+}
+
+// globEscaper escapes the characters of a Redis glob pattern.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Records returns the archived records of the message messageID of transaction transactionID,
+// one per target, ordered by when they were processed. It returns ErrArchiveRecordNotFound if there are none.
+func (a *taskArchiveReader) Records(ctx context.Context, transactionID, messageID string) ([]ProxyTaskRecord, error) {
+	txnSegment := archiveKeySegmentEscaper.Replace(transactionID)
+	msgSegment := archiveKeySegmentEscaper.Replace(messageID)
+	match := fmt.Sprintf("%s%s:%s:*", archiveKeyPrefix, globEscaper.Replace(txnSegment), globEscaper.Replace(msgSegment))
+	keys, err := a.store.Scan(ctx, match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy task records: %w", err)
+	}
+	records := make([]ProxyTaskRecord, 0, len(keys))
+	for _, key := range keys {
+		rec, found, err := a.record(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		// The record expired since it was listed.
+		if !found {
+			continue
+		}
+		// Records written before the key segments were escaped may belong to another message.
+		if rec.TransactionID != transactionID || rec.MessageID != messageID {
+			continue
+		}
+		records = append(records, *rec)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: transaction_id %q, message_id %q", ErrArchiveRecordNotFound, transactionID, messageID)
+	}
+	slices.SortFunc(records, func(x, y ProxyTaskRecord) int {
+		return cmp.Or(x.ProcessedAt.Compare(y.ProcessedAt), strings.Compare(x.Target, y.Target))
+	})
+	return records, nil
+}
+
+// record reads the record stored under key, reporting false if there is none.
+func (a *taskArchiveReader) record(ctx context.Context, key string) (*ProxyTaskRecord, bool, error) {
+	value, found, err := a.store.GetIfExists(ctx, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read proxy task record: %w", err)
+	}
+	if !found {
+		return nil, false, nil
+	}
+	var rec ProxyTaskRecord
+	if err := json.Unmarshal([]byte(value), &rec); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal proxy task record %s: %w", key, err)
+	}
+	return &rec, true, nil
+}
+
+// Replay proxies the archived payload of the message messageID of transaction transactionID to
+// target again. The redacted credentials are not sent: the request is signed anew by the gateway.
+// The outcome of the replay replaces the archived record. It returns ErrArchiveRecordNotFound if
+// no record matches, and the error of the proxy call if it fails.
+func (a *taskArchiveReader) Replay(ctx context.Context, transactionID, messageID, target string) error {
+	key := archiveKey(&ProxyTaskRecord{TransactionID: transactionID, MessageID: messageID, Target: target})
+	rec, found, err := a.record(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: transaction_id %q, message_id %q, target %q", ErrArchiveRecordNotFound, transactionID, messageID, target)
+	}
+	targetURL, err := url.Parse(rec.Target)
+	if err != nil {
+		return fmt.Errorf("invalid target of proxy task record %s: %w", key, err)
+	}
+	headers := rec.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	for _, name := range redactedHeaders {
+		if headers.Get(name) == redactedHeaderValue {
+			headers.Del(name)
+		}
+	}
+	task := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeProxy,
+		Target:  targetURL,
+		Body:    []byte(rec.Body),
+		Headers: headers,
+		Context: model.Context{TransactionID: rec.TransactionID, MessageID: rec.MessageID, Action: rec.Action},
+	}
+	slog.InfoContext(ctx, "TaskArchiveReader: Replaying archived proxy task", "transaction_id", transactionID, "message_id", messageID, "target", rec.Target)
+	if err := a.proxy.Process(ctx, task); err != nil {
+		slog.ErrorContext(ctx, "TaskArchiveReader: Replay failed", "transaction_id", transactionID, "message_id", messageID, "target", rec.Target, "error", err)
+		return err
+	}
+	return nil
+}
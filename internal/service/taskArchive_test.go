@@ -0,0 +1,517 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/dpi-accelerator-beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// recordingTaskArchive records the archived records.
+type recordingTaskArchive struct {
+	records []*ProxyTaskRecord
+	err     error
+}
+
+func (a *recordingTaskArchive) Archive(ctx context.Context, rec *ProxyTaskRecord) error {
+	a.records = append(a.records, rec)
+	return a.err
+}
+
+// mockArchiveCache is a mock implementation of the archiveCache interface.
+type mockArchiveCache struct {
+	key   string
+	value string
+	ttl   time.Duration
+	err   error
+}
+
+func (m *mockArchiveCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.key, m.value, m.ttl = key, value, ttl
+	return m.err
+}
+
+func newArchivedTestTask() *model.AsyncTask {
+	task := newTestAsyncTask("http://example.com/bpp/search", []byte(`{"data":"test"}`), http.Header{"X-Tenant": []string{"acme"}})
+	task.Context.TransactionID = "txn-1"
+	task.Context.MessageID = "msg-1"
+	return task
+}
+
+func TestProxyTaskProcessor_Process_Archive(t *testing.T) {
+	tests := []struct {
+		name       string
+		doFunc     func(r *http.Request) (*http.Response, error)
+		archiveErr error
+		wantErr    bool
+		want       *ProxyTaskRecord
+	}{
+		{
+			name: "success",
+			doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			},
+			want: &ProxyTaskRecord{
+				TransactionID: "txn-1",
+				MessageID:     "msg-1",
+				Action:        "search",
+				Target:        "http://example.com/bpp/search",
+				Headers: http.Header{
+					"X-Tenant":              []string{"acme"},
+					"Content-Type":          []string{"application/json"},
+					model.AuthHeaderGateway: []string{redactedHeaderValue},
+				},
+				Body:       `{"data":"test"}`,
+				StatusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "NACK response",
+			doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"NACK"}}}`), nil
+			},
+			wantErr: true,
+			want: &ProxyTaskRecord{
+				TransactionID: "txn-1",
+				MessageID:     "msg-1",
+				Action:        "search",
+				Target:        "http://example.com/bpp/search",
+				Headers: http.Header{
+					"X-Tenant":              []string{"acme"},
+					"Content-Type":          []string{"application/json"},
+					model.AuthHeaderGateway: []string{redactedHeaderValue},
+				},
+				Body:       `{"data":"test"}`,
+				StatusCode: http.StatusOK,
+				Error:      "response status is not ACK",
+			},
+		},
+		{
+			name: "archive failure does not fail the task",
+			doFunc: func(r *http.Request) (*http.Response, error) {
+				return newMockHTTPResponse(http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`), nil
+			},
+			archiveErr: errors.New("archive unavailable"),
+			want: &ProxyTaskRecord{
+				TransactionID: "txn-1",
+				MessageID:     "msg-1",
+				Action:        "search",
+				Target:        "http://example.com/bpp/search",
+				Headers: http.Header{
+					"X-Tenant":              []string{"acme"},
+					"Content-Type":          []string{"application/json"},
+					model.AuthHeaderGateway: []string{redactedHeaderValue},
+				},
+				Body:       `{"data":"test"}`,
+				StatusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := &recordingTaskArchive{err: tt.archiveErr}
+			p := &proxyTaskProcessor{
+				client: &mockHttpClient{doFunc: tt.doFunc},
+				auth:   &mockAuthGen{authHeader: "Signature secret"},
+				keyID:  "test-key-id",
+			}
+			p.SetTaskArchive(archive)
+
+			err := p.Process(context.Background(), newArchivedTestTask())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(archive.records) != 1 {
+				t.Fatalf("archived %d records, want 1", len(archive.records))
+			}
+			got := archive.records[0]
+			if got.ProcessedAt.IsZero() {
+				t.Error("ProxyTaskRecord.ProcessedAt is zero, want processing time")
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(ProxyTaskRecord{}, "ProcessedAt")); diff != "" {
+				t.Errorf("archived record mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProxyTaskProcessor_Process_NotArchivedWhenNotSent(t *testing.T) {
+	archive := &recordingTaskArchive{}
+	p := &proxyTaskProcessor{
+		client: &mockHttpClient{},
+		auth:   &mockAuthGen{err: errors.New("auth gen failed")},
+		keyID:  "test-key-id",
+	}
+	p.SetTaskArchive(archive)
+
+	if err := p.Process(context.Background(), newArchivedTestTask()); err == nil {
+		t.Fatal("Process() error = nil, want error")
+	}
+	if len(archive.records) != 0 {
+		t.Errorf("archived %d records, want 0 for a task that was never sent", len(archive.records))
+	}
+}
+
+func TestNewCacheTaskArchive_Error(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache archiveCache
+		ttl   time.Duration
+	}{
+		{name: "nil cache", ttl: time.Hour},
+		{name: "zero ttl", cache: &mockArchiveCache{}},
+		{name: "negative ttl", cache: &mockArchiveCache{}, ttl: -time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCacheTaskArchive(tt.cache, tt.ttl); err == nil {
+				t.Error("NewCacheTaskArchive() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestCacheTaskArchive_Archive(t *testing.T) {
+	cache := &mockArchiveCache{}
+	a, err := NewCacheTaskArchive(cache, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCacheTaskArchive() error = %v", err)
+	}
+	rec := &ProxyTaskRecord{
+		TransactionID: "txn-1",
+		MessageID:     "msg-1",
+		Action:        "search",
+		Target:        "http://example.com/bpp/search",
+		Body:          `{"data":"test"}`,
+		StatusCode:    http.StatusOK,
+		ProcessedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	if err := a.Archive(context.Background(), rec); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if want := "proxy_task_archive:txn-1:msg-1:http://example.com/bpp/search"; cache.key != want {
+		t.Errorf("cache key = %q, want %q", cache.key, want)
+	}
+	if cache.ttl != time.Hour {
+		t.Errorf("cache ttl = %v, want %v", cache.ttl, time.Hour)
+	}
+	var got ProxyTaskRecord
+	if err := json.Unmarshal([]byte(cache.value), &got); err != nil {
+		t.Fatalf("failed to unmarshal cached record: %v", err)
+	}
+	if diff := cmp.Diff(rec, &got); diff != "" {
+		t.Errorf("cached record mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCacheTaskArchive_Archive_Error(t *testing.T) {
+	a, err := NewCacheTaskArchive(&mockArchiveCache{err: errors.New("redis down")}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCacheTaskArchive() error = %v", err)
+	}
+	if err := a.Archive(context.Background(), &ProxyTaskRecord{}); err == nil {
+		t.Error("Archive() error = nil, want error")
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{
+		"X-Tenant":                 []string{"acme"},
+		model.AuthHeaderSubscriber: []string{"Signature bap"},
+		model.AuthHeaderGateway:    []string{"Signature bg"},
+	}
+
+	got := redactHeaders(h)
+
+	want := http.Header{
+		"X-Tenant":                 []string{"acme"},
+		model.AuthHeaderSubscriber: []string{redactedHeaderValue},
+		model.AuthHeaderGateway:    []string{redactedHeaderValue},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("redactHeaders() mismatch (-want +got):\n%s", diff)
+	}
+	if h.Get(model.AuthHeaderGateway) != "Signature bg" {
+		t.Error("redactHeaders() modified the original headers")
+	}
+}
+
+// mockArchiveStore is a mock implementation of the archiveStore interface, keeping values in a map.
+type mockArchiveStore struct {
+	values   map[string]string
+	listed   []string // Keys returned by Scan, all keys matching if nil.
+	gotMatch string
+	scanErr  error
+	getErr   error
+}
+
+func (m *mockArchiveStore) GetIfExists(ctx context.Context, key string) (string, bool, error) {
+	if m.getErr != nil {
+		return "", false, m.getErr
+	}
+	v, ok := m.values[key]
+	return v, ok, nil
+}
+
+func (m *mockArchiveStore) Scan(ctx context.Context, match string) ([]string, error) {
+	m.gotMatch = match
+	if m.scanErr != nil {
+		return nil, m.scanErr
+	}
+	if m.listed != nil {
+		return m.listed, nil
+	}
+	prefix := globUnescaper.Replace(strings.TrimSuffix(match, "*"))
+	var keys []string
+	for key := range m.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// globUnescaper reverts globEscaper.
+var globUnescaper = strings.NewReplacer(`\\`, `\`, `\*`, "*", `\?`, "?", `\[`, "[", `\]`, "]")
+
+// archivedStore returns a store holding recs under the keys of a cache task archive.
+func archivedStore(t *testing.T, recs ...*ProxyTaskRecord) *mockArchiveStore {
+	t.Helper()
+	store := &mockArchiveStore{values: map[string]string{}}
+	for _, rec := range recs {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		store.values[archiveKey(rec)] = string(b)
+	}
+	return store
+}
+
+func TestNewTaskArchiveReader_Error(t *testing.T) {
+	tests := []struct {
+		name  string
+		store archiveStore
+		proxy taskProcessor
+	}{
+		{name: "nil store", proxy: &mockTaskProcessor{}},
+		{name: "nil proxy", store: &mockArchiveStore{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTaskArchiveReader(tt.store, tt.proxy); err == nil {
+				t.Error("NewTaskArchiveReader() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTaskArchiveReader_Records(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := &ProxyTaskRecord{TransactionID: "txn-1", MessageID: "msg-1", Action: "search", Target: "http://bpp2.com/search", Body: "{}", StatusCode: http.StatusOK, ProcessedAt: now}
+	second := &ProxyTaskRecord{TransactionID: "txn-1", MessageID: "msg-1", Action: "search", Target: "http://bpp1.com/search", Body: "{}", StatusCode: http.StatusBadGateway, Error: "nack", ProcessedAt: now.Add(time.Second)}
+	otherMsg := &ProxyTaskRecord{TransactionID: "txn-1", MessageID: "msg-2", Target: "http://bpp1.com/search", ProcessedAt: now}
+	r, err := NewTaskArchiveReader(archivedStore(t, second, first, otherMsg), &mockTaskProcessor{})
+	if err != nil {
+		t.Fatalf("NewTaskArchiveReader() error = %v", err)
+	}
+
+	got, err := r.Records(context.Background(), "txn-1", "msg-1")
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if diff := cmp.Diff([]ProxyTaskRecord{*first, *second}, got); diff != "" {
+		t.Errorf("Records() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTaskArchiveReader_Records_EscapesPattern(t *testing.T) {
+	store := &mockArchiveStore{values: map[string]string{}}
+	r, err := NewTaskArchiveReader(store, &mockTaskProcessor{})
+	if err != nil {
+		t.Fatalf("NewTaskArchiveReader() error = %v", err)
+	}
+
+	if _, err := r.Records(context.Background(), "txn*", "msg?[1]"); !errors.Is(err, ErrArchiveRecordNotFound) {
+		t.Errorf("Records() error = %v, want %v", err, ErrArchiveRecordNotFound)
+	}
+	if want := `proxy_task_archive:txn\*:msg\?\[1\]:*`; store.gotMatch != want {
+		t.Errorf("Scan() match = %q, want %q", store.gotMatch, want)
+	}
+}
+
+func TestTaskArchiveReader_Records_IDsWithColon(t *testing.T) {
+	// Joined with ':', both ids would give the key proxy_task_archive:a:b:c:<target>.
+	first := &ProxyTaskRecord{TransactionID: "a:b", MessageID: "c", Target: "http://bpp.com/search", Body: `{"n":1}`}
+	second := &ProxyTaskRecord{TransactionID: "a", MessageID: "b:c", Target: "http://bpp.com/search", Body: `{"n":2}`}
+	store := archivedStore(t, first, second)
+	if len(store.values) != 2 {
+		t.Fatalf("archived keys = %v, want one per message", store.values)
+	}
+	r, err := NewTaskArchiveReader(store, &mockTaskProcessor{})
+	if err != nil {
+		t.Fatalf("NewTaskArchiveReader() error = %v", err)
+	}
+
+	for _, want := range []*ProxyTaskRecord{first, second} {
+		got, err := r.Records(context.Background(), want.TransactionID, want.MessageID)
+		if err != nil {
+			t.Fatalf("Records(%q, %q) error = %v", want.TransactionID, want.MessageID, err)
+		}
+		if diff := cmp.Diff([]ProxyTaskRecord{*want}, got); diff != "" {
+			t.Errorf("Records(%q, %q) mismatch (-want +got):\n%s", want.TransactionID, want.MessageID, diff)
+		}
+	}
+}
+
+func TestTaskArchiveReader_Records_SkipsOtherMessages(t *testing.T) {
+	// A record of another message listed under the key pattern, e.g. written with unescaped ids.
+	b, err := json.Marshal(&ProxyTaskRecord{TransactionID: "a", MessageID: "b:c", Target: "http://bpp.com"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	key := "proxy_task_archive:a:b:c:http://bpp.com"
+	store := &mockArchiveStore{values: map[string]string{key: string(b)}, listed: []string{key}}
+	r, err := NewTaskArchiveReader(store, &mockTaskProcessor{})
+	if err != nil {
+		t.Fatalf("NewTaskArchiveReader() error = %v", err)
+	}
+
+	if _, err := r.Records(context.Background(), "a:b", "c"); !errors.Is(err, ErrArchiveRecordNotFound) {
+		t.Errorf("Records() error = %v, want %v", err, ErrArchiveRecordNotFound)
+	}
+}
+
+func TestTaskArchiveReader_Records_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   *mockArchiveStore
+		wantErr error
+	}{
+		{name: "no records", store: &mockArchiveStore{}, wantErr: ErrArchiveRecordNotFound},
+		{name: "records expired since listed", store: &mockArchiveStore{listed: []string{"proxy_task_archive:txn-1:msg-1:http://bpp.com"}}, wantErr: ErrArchiveRecordNotFound},
+		{name: "scan fails", store: &mockArchiveStore{scanErr: errors.New("redis down")}},
+		{name: "get fails", store: &mockArchiveStore{listed: []string{"k"}, getErr: errors.New("redis down")}},
+		{name: "record not JSON", store: &mockArchiveStore{values: map[string]string{"proxy_task_archive:txn-1:msg-1:http://bpp.com": "{"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewTaskArchiveReader(tt.store, &mockTaskProcessor{})
+			if err != nil {
+				t.Fatalf("NewTaskArchiveReader() error = %v", err)
+			}
+			_, err = r.Records(context.Background(), "txn-1", "msg-1")
+			if err == nil {
+				t.Fatal("Records() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Records() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskArchiveReader_Replay(t *testing.T) {
+	rec := &ProxyTaskRecord{
+		TransactionID: "txn-1",
+		MessageID:     "msg-1",
+		Action:        "search",
+		Target:        "http://bpp.com/search?tenant=a",
+		Headers: http.Header{
+			model.AuthHeaderSubscriber: {redactedHeaderValue},
+			model.AuthHeaderGateway:    {redactedHeaderValue},
+			"Content-Type":             {"application/json"},
+			model.RequestIDHeader:      {"req-1"},
+		},
+		Body:       `{"context":{"action":"search"}}`,
+		StatusCode: http.StatusBadGateway,
+	}
+	proxy := &mockTaskProcessor{}
+	r, err := NewTaskArchiveReader(archivedStore(t, rec), proxy)
+	if err != nil {
+		t.Fatalf("NewTaskArchiveReader() error = %v", err)
+	}
+
+	if err := r.Replay(context.Background(), "txn-1", "msg-1", rec.Target); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	if len(proxy.tasks) != 1 {
+		t.Fatalf("proxy processed %d tasks, want 1", len(proxy.tasks))
+	}
+	want := &model.AsyncTask{
+		Type:    model.AsyncTaskTypeProxy,
+		Target:  mustParseURL(rec.Target),
+		Body:    []byte(rec.Body),
+		Headers: http.Header{"Content-Type": {"application/json"}, model.RequestIDHeader: {"req-1"}},
+		Context: model.Context{TransactionID: "txn-1", MessageID: "msg-1", Action: "search"},
+	}
+	if diff := cmp.Diff(want, proxy.tasks[0]); diff != "" {
+		t.Errorf("replayed task mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTaskArchiveReader_Replay_Error(t *testing.T) {
+	rec := &ProxyTaskRecord{TransactionID: "txn-1", MessageID: "msg-1", Target: "http://bpp.com/search", Body: "{}"}
+	proxyErr := &ProxyTaskError{Target: rec.Target, StatusCode: http.StatusServiceUnavailable, Err: errors.New("unavailable")}
+	tests := []struct {
+		name    string
+		store   *mockArchiveStore
+		target  string
+		proxy   *mockTaskProcessor
+		wantErr error
+	}{
+		{name: "no record", store: archivedStore(t, rec), target: "http://other.com/search", proxy: &mockTaskProcessor{}, wantErr: ErrArchiveRecordNotFound},
+		{name: "store fails", store: &mockArchiveStore{getErr: errors.New("redis down")}, target: rec.Target, proxy: &mockTaskProcessor{}},
+		{
+			name:    "proxy fails",
+			store:   archivedStore(t, rec),
+			target:  rec.Target,
+			proxy:   &mockTaskProcessor{processFunc: func(context.Context, *model.AsyncTask) error { return proxyErr }},
+			wantErr: proxyErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewTaskArchiveReader(tt.store, tt.proxy)
+			if err != nil {
+				t.Fatalf("NewTaskArchiveReader() error = %v", err)
+			}
+			err = r.Replay(context.Background(), "txn-1", "msg-1", tt.target)
+			if err == nil {
+				t.Fatal("Replay() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Replay() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
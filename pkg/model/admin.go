@@ -14,6 +14,8 @@
 
 package model
 
+import "time"
+
 // OperationActionRequest defines the request body for the admin subscription action endpoint.
 type OperationActionRequest struct {
 	// Action specifies the action to perform on the subscription (APPROVE/REJECT).
@@ -26,6 +28,12 @@ type OperationActionRequest struct {
 	Reason string `json:"reason,omitempty"`
 }
 
+// BatchOperationActionRequest defines the request body for the admin batch subscription action endpoint.
+type BatchOperationActionRequest struct {
+	// Actions are the subscription actions to perform, processed in order.
+	Actions []OperationActionRequest `json:"actions"`
+}
+
 // OperationAction defines the possible actions an admin can take on a subscription.
 type OperationAction string
 
@@ -37,3 +45,19 @@ const (
 	// OperationActionRejectSubscription represents the action to reject a subscription.
 	OperationActionRejectSubscription OperationAction = "REJECT_SUBSCRIPTION"
 )
+
+// SubscriptionExportFormatVersion is the version of the NDJSON subscription export format.
+const SubscriptionExportFormatVersion = 1
+
+// SubscriptionExportHeader is the first record of an NDJSON subscription export.
+// Every following line holds one Subscription.
+type SubscriptionExportHeader struct {
+	// Kind identifies the content of the export, always "subscriptions".
+	Kind string `json:"kind"`
+
+	// FormatVersion is the version of the export format.
+	FormatVersion int `json:"format_version"`
+
+	// ExportedAt is the time the export was started.
+	ExportedAt time.Time `json:"exported_at" format:"date-time"`
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "encoding/json"
+
+// BatchItemStatus is the outcome of a single item of a batch operation.
+type BatchItemStatus string
+
+// Defines the valid BatchItemStatus values.
+const (
+	// BatchItemStatusOK indicates that the item was processed successfully.
+	BatchItemStatusOK BatchItemStatus = "OK"
+	// BatchItemStatusError indicates that processing the item failed.
+	BatchItemStatusError BatchItemStatus = "ERROR"
+)
+
+// BatchItemResult is the outcome of a single item of a batch operation.
+// Result is set for successful items and Error for failed ones.
+type BatchItemResult[T any] struct {
+	// ID identifies the item within the batch, e.g. an operation id.
+	ID     string          `json:"id"`
+	Status BatchItemStatus `json:"status"`
+	Result *T              `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// BatchSummary counts the outcomes of the items of a batch operation.
+type BatchSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// BatchResult is the response envelope of batch endpoints.
+// Items are in the order of the request, and a batch is processed in full
+// even if some of its items fail.
+type BatchResult[T any] struct {
+	Items   []BatchItemResult[T] `json:"items"`
+	Summary BatchSummary         `json:"summary"`
+}
+
+// MarshalJSON encodes b with an empty list of items, rather than null, if it has none.
+func (b BatchResult[T]) MarshalJSON() ([]byte, error) {
+	items := b.Items
+	if items == nil {
+		items = []BatchItemResult[T]{}
+	}
+	return json.Marshal(struct {
+		Items   []BatchItemResult[T] `json:"items"`
+		Summary BatchSummary         `json:"summary"`
+	}{items, b.Summary})
+}
+
+// AddResult records a successfully processed item.
+func (b *BatchResult[T]) AddResult(id string, result *T) {
+	b.Items = append(b.Items, BatchItemResult[T]{ID: id, Status: BatchItemStatusOK, Result: result})
+	b.Summary.Total++
+	b.Summary.Succeeded++
+}
+
+// AddError records a failed item.
+func (b *BatchResult[T]) AddError(id string, err Error) {
+	b.Items = append(b.Items, BatchItemResult[T]{ID: id, Status: BatchItemStatusError, Error: &err})
+	b.Summary.Total++
+	b.Summary.Failed++
+}
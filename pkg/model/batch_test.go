@@ -0,0 +1,56 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatchResult_MixedOutcomes(t *testing.T) {
+	var b BatchResult[Subscriber]
+	b.AddResult("sub-1", &Subscriber{SubscriberID: "sub-1"})
+	b.AddError("sub-2", Error{Type: ErrorTypeNotFoundError, Code: ErrorCodeSubscriptionNotFound, Message: "not found"})
+	b.AddResult("sub-3", &Subscriber{SubscriberID: "sub-3"})
+
+	if diff := cmp.Diff(BatchSummary{Total: 3, Succeeded: 2, Failed: 1}, b.Summary); diff != "" {
+		t.Errorf("Summary mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"items":[` +
+		`{"id":"sub-1","status":"OK","result":{"subscriber_id":"sub-1"}},` +
+		`{"id":"sub-2","status":"ERROR","error":{"type":"NOT_FOUND","code":"SUBSCRIPTION_NOT_FOUND","message":"not found"}},` +
+		`{"id":"sub-3","status":"OK","result":{"subscriber_id":"sub-3"}}],` +
+		`"summary":{"total":3,"succeeded":2,"failed":1}}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchResult_Empty(t *testing.T) {
+	got, err := json.Marshal(BatchResult[Subscriber]{})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"items":[],"summary":{"total":0,"succeeded":0,"failed":0}}`; string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
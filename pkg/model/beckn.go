@@ -15,9 +15,13 @@
 package model
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -29,6 +33,9 @@ type Subscriber struct {
 	Type         Role      `json:"type,omitzero" enum:"BAP,BPP,BG" db:"type"`
 	Domain       string    `json:"domain,omitzero" db:"domain"`
 	Location     *Location `json:"location,omitzero" db:"location"`
+	// Version is the Beckn protocol version the participant supports, if its registry advertises it.
+	// It is not stored by this registry, so strict version matching keeps subscriptions without one.
+	Version string `json:"version,omitzero" db:"-"`
 }
 
 // Subscription represents subscription details of a network participant.
@@ -55,6 +62,16 @@ type SubscriptionRequest struct {
 	MessageID string `json:"message_id,omitzero"`
 }
 
+// DeleteSubscriptionRequest is the signed body of a request deleting a subscription. It names the
+// method and the subscription so that its signature cannot authorize any other request, and carries
+// a nonce so that it cannot be replayed.
+type DeleteSubscriptionRequest struct {
+	Method       string `json:"method"`
+	SubscriberID string `json:"subscriber_id"`
+	KeyID        string `json:"key_id"`
+	Nonce        string `json:"nonce"`
+}
+
 // SubscriptionStatus defines the set of possible statuses for a subscription operation's immediate response.
 type SubscriptionStatus string
 
@@ -109,8 +126,42 @@ func (s *SubscriptionStatus) UnmarshalJSON(data []byte) error {
 
 // SubscriptionResponse is the successful response structure for /subscribe POST and PATCH.
 type SubscriptionResponse struct {
-	Status    SubscriptionStatus `json:"status"`
-	MessageID string             `json:"message_id"`
+	Status     SubscriptionStatus `json:"status"`
+	MessageID  string             `json:"message_id"`
+	Created    time.Time          `json:"created,omitzero" format:"date-time"`
+	Updated    time.Time          `json:"updated,omitzero" format:"date-time"`
+	ValidUntil time.Time          `json:"valid_until,omitzero" format:"date-time"`
+}
+
+// LookupPage is a page of the subscriptions matching a lookup.
+type LookupPage struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	NextPageToken string         `json:"next_page_token,omitempty"` // Empty on the last page.
+}
+
+// MarshalJSON encodes p with an empty list of subscriptions, rather than null, if it has none.
+func (p LookupPage) MarshalJSON() ([]byte, error) {
+	type page LookupPage // Without the MarshalJSON method.
+	if p.Subscriptions == nil {
+		p.Subscriptions = []Subscription{}
+	}
+	return json.Marshal(page(p))
+}
+
+// UnmarshalJSON decodes a page, or a plain array of subscriptions as returned by
+// registries without pagination, which is a single last page.
+func (p *LookupPage) UnmarshalJSON(data []byte) error {
+	if b := bytes.TrimSpace(data); len(b) > 0 && b[0] == '[' {
+		*p = LookupPage{}
+		return json.Unmarshal(b, &p.Subscriptions)
+	}
+	type page LookupPage // Without the UnmarshalJSON method.
+	var pg page
+	if err := json.Unmarshal(data, &pg); err != nil {
+		return err
+	}
+	*p = LookupPage(pg)
+	return nil
 }
 
 // AuthHeaderSubscriber is the standard HTTP header key for subscriber authorization.
@@ -121,6 +172,32 @@ const (
 	UnauthorizedHeaderSubscriber string = "WWW-Authenticate"
 	// AuthHeaderGateway
 	AuthHeaderGateway string = "X-Gateway-Authorization"
+	// AuthHeaderRegistry is the HTTP response header carrying the registry's signature of the response body.
+	AuthHeaderRegistry string = "X-Registry-Authorization"
+	// RequestIDHeader is the HTTP request header carrying a per-request id, which signatures can be bound to.
+	RequestIDHeader string = "X-Request-ID"
+)
+
+// MessageIDGeneratedHeader is the HTTP response header telling whether the message ID of an accepted
+// subscription request was generated by the server, "true", or supplied by the client, "false".
+const MessageIDGeneratedHeader string = "X-Message-ID-Generated"
+
+// APIVersionHeader is the HTTP response header carrying the API version of the response shape.
+// AcceptVersionHeader is the HTTP request header a client sets to request an API version.
+const (
+	APIVersionHeader    string = "X-API-Version"
+	AcceptVersionHeader string = "Accept-Version"
+)
+
+// API versions of the response shapes.
+const (
+	// APIVersion1 is the original response shape, in which SubscriptionResponse
+	// carries only the status and message ID.
+	APIVersion1 string = "1"
+	// APIVersion2 adds created, updated and valid_until to SubscriptionResponse.
+	APIVersion2 string = "2"
+	// APIVersionCurrent is the version served when the client does not request one.
+	APIVersionCurrent = APIVersion2
 )
 
 // Role defines the functional type of a participant in the network.
@@ -161,17 +238,42 @@ func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 // Gps represents a GPS coordinate as a string, typically in "latitude,longitude" format.
 type Gps string
 
+// Parse returns the latitude and longitude of g in degrees. g must be of the form "lat,lon",
+// with the latitude within [-90, 90] and the longitude within [-180, 180].
+func (g Gps) Parse() (lat, lon float64, err error) {
+	latStr, lonStr, ok := strings.Cut(string(g), ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("gps %q is not of the form lat,lon", g)
+	}
+	lat, err = strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("gps %q has an invalid latitude", g)
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("gps %q has an invalid longitude", g)
+	}
+	return lat, lon, nil
+}
+
+// Validate returns an error if g is not a valid coordinate, as parsed by Parse.
+func (g Gps) Validate() error {
+	_, _, err := g.Parse()
+	return err
+}
+
 // Location describes the physical location of an entity.
+// An empty location is omitted from JSON, like any other absent optional field.
 type Location struct {
 	ID          string              `json:"id,omitempty"`
 	Descriptor  *LocationDescriptor `json:"descriptor,omitempty"`
 	MapURL      string              `json:"map_url,omitempty" format:"uri"`
 	Gps         Gps                 `json:"gps,omitempty"`
 	Address     string              `json:"address,omitempty"`
-	City        *City               `json:"city,omitempty"`
+	City        *City               `json:"city,omitzero"`
 	District    string              `json:"district,omitempty"`
-	State       *State              `json:"state,omitempty"`
-	Country     *Country            `json:"country,omitempty"`
+	State       *State              `json:"state,omitzero"`
+	Country     *Country            `json:"country,omitzero"`
 	AreaCode    string              `json:"area_code,omitempty"`
 	Circle      *Circle             `json:"circle,omitempty"`
 	Polygon     string              `json:"polygon,omitempty"`
@@ -179,6 +281,29 @@ type Location struct {
 	Rating      string              `json:"rating,omitempty"`
 }
 
+// IsZero reports whether no field of l is set.
+func (l Location) IsZero() bool {
+	return l.ID == "" && l.Descriptor == nil && l.MapURL == "" && l.Gps == "" &&
+		l.Address == "" && l.City == nil && l.District == "" && l.State == nil &&
+		l.Country == nil && l.AreaCode == "" && l.Circle == nil && l.Polygon == "" &&
+		l.ThreeDSpace == "" && l.Rating == ""
+}
+
+// ValidateCoordinates returns an error if the gps or circle of l is set but invalid.
+func (l *Location) ValidateCoordinates() error {
+	if l.Gps != "" {
+		if err := l.Gps.Validate(); err != nil {
+			return err
+		}
+	}
+	if l.Circle != nil {
+		if err := l.Circle.Validate(); err != nil {
+			return fmt.Errorf("circle: %w", err)
+		}
+	}
+	return nil
+}
+
 // Scan implements the sql.Scanner interface for Location.
 // It converts database JSONB ([]byte) into a model.Location struct.
 func (l *Location) Scan(value interface{}) error {
@@ -200,10 +325,7 @@ func (l *Location) Scan(value interface{}) error {
 func (l Location) Value() (driver.Value, error) {
 	// Handle the case where Location is empty/zero-value to store NULL in DB.
 	// This check ensures that if all relevant fields are empty, it stores NULL.
-	if l.ID == "" && l.Descriptor == nil && l.MapURL == "" && l.Gps == "" &&
-		l.Address == "" && l.City == nil && l.District == "" && l.State == nil &&
-		l.Country == nil && l.AreaCode == "" && l.Circle == nil && l.Polygon == "" &&
-		l.ThreeDSpace == "" && l.Rating == "" {
+	if l.IsZero() {
 		return nil, nil
 	}
 	// Otherwise, marshal the Location struct into JSON bytes.
@@ -248,24 +370,53 @@ type City struct {
 	Code string `json:"code,omitempty"`
 }
 
+// IsZero reports whether neither the name nor the code of c is set.
+func (c City) IsZero() bool {
+	return c == City{}
+}
+
 // State represents a bounded geopolitical region of governance within a country.
 type State struct {
 	Name string `json:"name,omitempty"`
 	Code string `json:"code,omitempty"`
 }
 
+// IsZero reports whether neither the name nor the code of s is set.
+func (s State) IsZero() bool {
+	return s == State{}
+}
+
 // Country represents a country, identified by its name and a standardized code.
 type Country struct {
 	Name string `json:"name,omitempty"`
 	Code string `json:"code,omitempty"`
 }
 
+// IsZero reports whether neither the name nor the code of c is set.
+func (c Country) IsZero() bool {
+	return c == Country{}
+}
+
 // Circle describes a circular geographical region defined by a central GPS coordinate and a radius.
 type Circle struct {
 	Gps    Gps     `json:"gps,omitempty"`
 	Radius *Scalar `json:"radius,omitempty"`
 }
 
+// Validate returns an error if the center of c is not a valid coordinate or its radius is set
+// but not a non-negative number.
+func (c *Circle) Validate() error {
+	if err := c.Gps.Validate(); err != nil {
+		return err
+	}
+	if c.Radius != nil {
+		if r, err := strconv.ParseFloat(c.Radius.Value, 64); err != nil || math.IsNaN(r) || r < 0 {
+			return fmt.Errorf("radius %q is not a non-negative number", c.Radius.Value)
+		}
+	}
+	return nil
+}
+
 // Scalar Describes a scalar value with type, value, and optional range.
 type Scalar struct {
 	Type           string       `json:"type,omitempty" enum:"CONSTANT,VARIABLE"`
@@ -299,12 +450,14 @@ type AuthHeader struct {
 	SubscriberID string
 	UniqueID     string
 	Algorithm    string
+	Created      time.Time // When the signature was created, zero if the header has no created parameter.
+	Expires      time.Time // When the signature expires, zero if the header has no expires parameter.
 }
 
 // Context provides a high-level overview of the transaction.
 type Context struct {
 	Domain        string    `json:"domain,omitempty"`         // Domain code
-	Location      *Location `json:"location,omitempty"`       // Transaction fulfillment location
+	Location      *Location `json:"location,omitzero"`        // Transaction fulfillment location
 	Action        string    `json:"action,omitempty"`         // Beckn protocol method
 	Version       string    `json:"version,omitempty"`        // Protocol version
 	BapID         string    `json:"bap_id,omitempty"`         // Subscriber ID of BAP
@@ -288,3 +288,143 @@ func TestLocation_Scan_JsonError(t *testing.T) {
 		t.Error("Scan() got nil error, want error for invalid JSON")
 	}
 }
+
+func TestGps_Parse(t *testing.T) {
+	tests := []struct {
+		gps     Gps
+		lat     float64
+		lon     float64
+		wantErr string
+	}{
+		{gps: "12.9716,77.5946", lat: 12.9716, lon: 77.5946},
+		{gps: " -90 , 180 ", lat: -90, lon: 180},
+		{gps: "90,-180", lat: 90, lon: -180},
+		{gps: "abc", wantErr: `gps "abc" is not of the form lat,lon`},
+		{gps: "", wantErr: `gps "" is not of the form lat,lon`},
+		{gps: "abc,77.5", wantErr: `gps "abc,77.5" has an invalid latitude`},
+		{gps: "12.9,", wantErr: `gps "12.9," has an invalid longitude`},
+		{gps: "90.1,0", wantErr: `gps "90.1,0" has an invalid latitude`},
+		{gps: "0,-180.5", wantErr: `gps "0,-180.5" has an invalid longitude`},
+		{gps: "NaN,0", wantErr: `gps "NaN,0" has an invalid latitude`},
+		{gps: "1,2,3", wantErr: `gps "1,2,3" has an invalid longitude`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.gps), func(t *testing.T) {
+			lat, lon, err := tt.gps.Parse()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				if err := tt.gps.Validate(); err == nil {
+					t.Error("Validate() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if lat != tt.lat || lon != tt.lon {
+				t.Errorf("Parse() = %v, %v, want %v, %v", lat, lon, tt.lat, tt.lon)
+			}
+		})
+	}
+}
+
+func TestLocation_ValidateCoordinates(t *testing.T) {
+	tests := []struct {
+		name    string
+		loc     Location
+		wantErr string
+	}{
+		{name: "no coordinates", loc: Location{City: &City{Name: "C"}}},
+		{name: "valid gps", loc: Location{Gps: "1,2"}},
+		{name: "valid circle", loc: Location{Circle: &Circle{Gps: "1,2", Radius: &Scalar{Value: "500", Unit: "m"}}}},
+		{name: "malformed gps", loc: Location{Gps: "abc"}, wantErr: `gps "abc" is not of the form lat,lon`},
+		{name: "out of range gps", loc: Location{Gps: "1,200"}, wantErr: `gps "1,200" has an invalid longitude`},
+		{name: "circle without gps", loc: Location{Circle: &Circle{Radius: &Scalar{Value: "5"}}}, wantErr: `circle: gps "" is not of the form lat,lon`},
+		{name: "malformed circle gps", loc: Location{Circle: &Circle{Gps: "-91,0"}}, wantErr: `circle: gps "-91,0" has an invalid latitude`},
+		{name: "negative circle radius", loc: Location{Circle: &Circle{Gps: "1,2", Radius: &Scalar{Value: "-1"}}}, wantErr: `circle: radius "-1" is not a non-negative number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.loc.ValidateCoordinates()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateCoordinates() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateCoordinates() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON_AbsentOptionalFields(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			name: "subscription with empty optional fields",
+			v:    Subscription{Subscriber: Subscriber{SubscriberID: "sub-1", Location: &Location{}}, KeyID: "key-1"},
+			want: `{"subscriber_id":"sub-1","key_id":"key-1"}`,
+		},
+		{
+			name: "subscription with a nil location",
+			v:    Subscription{Subscriber: Subscriber{SubscriberID: "sub-1"}},
+			want: `{"subscriber_id":"sub-1"}`,
+		},
+		{
+			name: "location with empty city, state and country",
+			v:    Location{City: &City{}, State: &State{}, Country: &Country{Code: "IND"}},
+			want: `{"country":{"code":"IND"}}`,
+		},
+		{
+			name: "context with an empty location",
+			v:    Context{Action: "search", Location: &Location{}},
+			want: `{"action":"search"}`,
+		},
+		{
+			name: "empty lookup page",
+			v:    LookupPage{},
+			want: `{"subscriptions":[]}`,
+		},
+		{
+			name: "update result without changes",
+			v:    SubscriptionUpdateResult{},
+			want: `{"changes":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLookupPage_MarshalJSON_RoundTrip(t *testing.T) {
+	want := LookupPage{Subscriptions: []Subscription{{Subscriber: Subscriber{SubscriberID: "sub-1"}}}, NextPageToken: "next"}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got LookupPage
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// Signature algorithms of Beckn requests, as named in the keyId of their signature header.
+const (
+	// SignatureAlgorithmEd25519 is Ed25519, the Beckn default.
+	SignatureAlgorithmEd25519 = "ed25519"
+	// SignatureAlgorithmRSA is RSASSA-PKCS1-v1_5 with SHA-256. Keys are base64 DER, PKIX or PKCS #1.
+	SignatureAlgorithmRSA = "rsa-sha256"
+	// SignatureAlgorithmSecp256k1 is ECDSA on secp256k1 with SHA-256. Keys are base64 SEC 1, compressed
+	// or not, and signatures base64 DER or the 64 bytes of R and S.
+	SignatureAlgorithmSecp256k1 = "secp256k1"
+)
+
+// SupportedSignatureAlgorithms returns the signature algorithms requests can be signed with.
+func SupportedSignatureAlgorithms() []string {
+	return []string{SignatureAlgorithmEd25519, SignatureAlgorithmRSA, SignatureAlgorithmSecp256k1}
+}
+
+// Capabilities is a manifest of what a deployment of a service supports,
+// for integrators to discover its actions and features.
+type Capabilities struct {
+	Service             string          `json:"service"`
+	APIVersion          string          `json:"api_version"`  // Version served when a client does not request one.
+	APIVersions         []string        `json:"api_versions"` // Versions a client can request with Accept-Version.
+	Actions             []string        `json:"actions"`      // Beckn actions the service accepts.
+	SignatureAlgorithms []string        `json:"signature_algorithms"`
+	Features            map[string]bool `json:"features"` // Optional features and whether they are enabled.
+}
@@ -16,7 +16,9 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime/debug"
 )
 
 // ErrorType defines the category of the error.
@@ -78,11 +80,17 @@ const (
 	ErrorCodeKeyUnavailable ErrorCode = "AUTH_ERROR_CODE_KEY_UNAVAILABLE"
 	// ErrorCodeInvalidSignature indicates that the request signature is invalid.
 	ErrorCodeInvalidSignature ErrorCode = "AUTH_ERROR_CODE_INVALID_SIGNATURE"
+	// ErrorCodeSubscriberBlocked indicates that the subscriber is temporarily blocked after repeated signature failures.
+	ErrorCodeSubscriberBlocked ErrorCode = "AUTH_ERROR_CODE_SUBSCRIBER_BLOCKED"
+	// ErrorCodeReplayedRequest indicates that the request repeats the nonce of an earlier request.
+	ErrorCodeReplayedRequest ErrorCode = "AUTH_ERROR_CODE_REPLAYED_REQUEST"
 	// Validation Errors
 	// ErrorCodeInvalidJSON indicates that the request body contains malformed or invalid JSON.
 	ErrorCodeInvalidJSON ErrorCode = "VALIDATION_ERROR_INVALID_JSON"
 	// ErrorCodeBadRequest indicates a general validation error with the request.
 	ErrorCodeBadRequest ErrorCode = "VALIDATION_ERROR_BAD_REQUEST" // General validation
+	// ErrorCodeRequestTooLarge indicates that the request is larger than the server accepts.
+	ErrorCodeRequestTooLarge ErrorCode = "VALIDATION_ERROR_REQUEST_TOO_LARGE"
 	// Not Found Errors
 	// ErrorCodeSubscriptionNotFound indicates that a specific subscription was not found.
 	ErrorCodeSubscriptionNotFound ErrorCode = "SUBSCRIPTION_NOT_FOUND"
@@ -91,27 +99,36 @@ const (
 	// Conflict Errors
 	// ErrorCodeDuplicateRequest indicates that the request is a duplicate of a previous one, often identified by a message ID.
 	ErrorCodeDuplicateRequest ErrorCode = "DUPLICATE_REQUEST"
+	// ErrorCodeTooManyPendingOperations indicates that the subscriber already has the maximum allowed number of pending operations.
+	ErrorCodeTooManyPendingOperations ErrorCode = "TOO_MANY_PENDING_OPERATIONS"
 	// Internal Errors
 	// ErrorCodeInternalServerError indicates a generic, unexpected error on the server.
 	ErrorCodeInternalServerError ErrorCode = "INTERNAL_SERVER_ERROR"
+	// ErrorCodeServiceUnavailable indicates that a service the request depends on is temporarily unavailable and the request can be retried.
+	ErrorCodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
 
 	// ErrorCodeTypeInvalidAction indicates that the action performed is invalid.
 	ErrorCodeTypeInvalidAction ErrorCode = "INVALID_ACTION"
 )
 
 var validErrorCodes = map[ErrorCode]bool{
-	ErrorCodeMissingAuthHeader:    true,
-	ErrorCodeInvalidAuthHeader:    true,
-	ErrorCodeIDMismatch:           true,
-	ErrorCodeKeyUnavailable:       true,
-	ErrorCodeInvalidSignature:     true,
-	ErrorCodeInvalidJSON:          true,
-	ErrorCodeBadRequest:           true,
-	ErrorCodeSubscriptionNotFound: true,
-	ErrorCodeDuplicateRequest:     true,
-	ErrorCodeOperationNotFound:    true,
-	ErrorCodeInternalServerError:  true,
-	ErrorCodeTypeInvalidAction:    true,
+	ErrorCodeMissingAuthHeader:        true,
+	ErrorCodeInvalidAuthHeader:        true,
+	ErrorCodeIDMismatch:               true,
+	ErrorCodeKeyUnavailable:           true,
+	ErrorCodeInvalidSignature:         true,
+	ErrorCodeSubscriberBlocked:        true,
+	ErrorCodeReplayedRequest:          true,
+	ErrorCodeInvalidJSON:              true,
+	ErrorCodeBadRequest:               true,
+	ErrorCodeRequestTooLarge:          true,
+	ErrorCodeSubscriptionNotFound:     true,
+	ErrorCodeDuplicateRequest:         true,
+	ErrorCodeTooManyPendingOperations: true,
+	ErrorCodeOperationNotFound:        true,
+	ErrorCodeInternalServerError:      true,
+	ErrorCodeServiceUnavailable:       true,
+	ErrorCodeTypeInvalidAction:        true,
 }
 
 // MarshalJSON implements the json.Marshaler interface for ErrorCode.
@@ -134,10 +151,41 @@ func (ec *ErrorCode) UnmarshalJSON(data []byte) error {
 
 // Error represents the standard error structure.
 type Error struct {
-	Type    ErrorType `json:"type,omitempty"`
-	Code    ErrorCode `json:"code"`
-	Path    string    `json:"path,omitempty"`
-	Message string    `json:"message"`
+	Type    ErrorType     `json:"type,omitempty"`
+	Code    ErrorCode     `json:"code"`
+	Path    string        `json:"path,omitempty"`
+	Message string        `json:"message"`
+	Details *ErrorDetails `json:"details,omitempty"`
+}
+
+// ErrorDetails carries debugging information about an internal error.
+// It is only populated when ErrorDebugConfig is enabled.
+type ErrorDetails struct {
+	Chain []string `json:"chain"`           // Messages of the error and each error it wraps, outermost first.
+	Stack string   `json:"stack,omitempty"` // Stack trace of the goroutine writing the response.
+}
+
+// ErrorDebugConfig controls whether internal error details are exposed in error responses.
+// It is disabled by default and must never be enabled in production.
+type ErrorDebugConfig struct {
+	Enabled    bool `yaml:"enabled"`    // Include the error chain in the response details.
+	StackTrace bool `yaml:"stackTrace"` // Also include a stack trace. Has no effect unless Enabled is set.
+}
+
+// Details returns the debugging details for err.
+// It returns nil if c is nil, debugging is disabled or err is nil.
+func (c *ErrorDebugConfig) Details(err error) *ErrorDetails {
+	if c == nil || !c.Enabled || err == nil {
+		return nil
+	}
+	details := &ErrorDetails{}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		details.Chain = append(details.Chain, e.Error())
+	}
+	if c.StackTrace {
+		details.Stack = string(debug.Stack())
+	}
+	return details
 }
 
 // ErrorResponse wraps the Error.
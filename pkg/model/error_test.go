@@ -16,8 +16,13 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestErrorType_MarshalJSON(t *testing.T) {
@@ -138,6 +143,8 @@ func TestErrorCode_UnmarshalJSON_Success(t *testing.T) {
 		{"SubscriptionNotFound", `"SUBSCRIPTION_NOT_FOUND"`, ErrorCodeSubscriptionNotFound},
 		{"DuplicateRequest", `"DUPLICATE_REQUEST"`, ErrorCodeDuplicateRequest},
 		{"InternalServerError", `"INTERNAL_SERVER_ERROR"`, ErrorCodeInternalServerError},
+		{"RequestTooLarge", `"VALIDATION_ERROR_REQUEST_TOO_LARGE"`, ErrorCodeRequestTooLarge},
+		{"ServiceUnavailable", `"SERVICE_UNAVAILABLE"`, ErrorCodeServiceUnavailable},
 	}
 
 	for _, tt := range tests {
@@ -217,3 +224,80 @@ func TestNewAuthError(t *testing.T) {
 		t.Errorf("NewAuthError() SubscriberID = %q, want %q", authErr.SubscriberID, subscriberID)
 	}
 }
+
+func TestErrorDebugConfig_Details(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("failed to initiate LRO: %w", fmt.Errorf("failed to insert operation: %w", root))
+
+	tests := []struct {
+		name string
+		cfg  *ErrorDebugConfig
+		err  error
+		want *ErrorDetails
+	}{
+		{
+			name: "nil config",
+			cfg:  nil,
+			err:  err,
+			want: nil,
+		},
+		{
+			name: "disabled by default",
+			cfg:  &ErrorDebugConfig{},
+			err:  err,
+			want: nil,
+		},
+		{
+			name: "stack trace without enabled",
+			cfg:  &ErrorDebugConfig{StackTrace: true},
+			err:  err,
+			want: nil,
+		},
+		{
+			name: "nil error",
+			cfg:  &ErrorDebugConfig{Enabled: true},
+			err:  nil,
+			want: nil,
+		},
+		{
+			name: "enabled",
+			cfg:  &ErrorDebugConfig{Enabled: true},
+			err:  err,
+			want: &ErrorDetails{Chain: []string{
+				"failed to initiate LRO: failed to insert operation: connection refused",
+				"failed to insert operation: connection refused",
+				"connection refused",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.Details(tt.err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Details() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrorDebugConfig_Details_StackTrace(t *testing.T) {
+	cfg := &ErrorDebugConfig{Enabled: true, StackTrace: true}
+	got := cfg.Details(errors.New("boom"))
+	if got == nil {
+		t.Fatal("Details() = nil, want details")
+	}
+	if !strings.Contains(got.Stack, "TestErrorDebugConfig_Details_StackTrace") {
+		t.Errorf("Details().Stack = %q, want it to contain the calling test", got.Stack)
+	}
+}
+
+func TestErrorResponse_OmitsEmptyDetails(t *testing.T) {
+	b, err := json.Marshal(ErrorResponse{Error: Error{Code: ErrorCodeInternalServerError, Message: "internal"}})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(b), "details") {
+		t.Errorf("json.Marshal() = %s, want no details field", b)
+	}
+}
@@ -17,6 +17,7 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // EventType defines the type for various events in the system.
@@ -34,6 +35,10 @@ const (
 	EventTypeSubscriptionRequestRejected EventType = "SUBSCRIPTION_REQUEST_REJECTED"
 	// EventTypeOnSubscribeRecieved signals am OnSubscribe call recieved event.
 	EventTypeOnSubscribeRecieved EventType = "ON_SUBSCRIBE_RECIEVED"
+	// EventTypeSignatureFailureAlert signals that a subscriber's requests repeatedly failed signature validation.
+	EventTypeSignatureFailureAlert EventType = "SIGNATURE_FAILURE_ALERT"
+	// EventTypeSubscriptionUpdated signals that an existing subscription was updated by an approved update request.
+	EventTypeSubscriptionUpdated EventType = "SUBSCRIPTION_UPDATED"
 )
 
 var validEventTypes = map[EventType]bool{
@@ -42,6 +47,8 @@ var validEventTypes = map[EventType]bool{
 	EventTypeSubscriptionRequestApproved: true,
 	EventTypeSubscriptionRequestRejected: true,
 	EventTypeOnSubscribeRecieved:         true,
+	EventTypeSignatureFailureAlert:       true,
+	EventTypeSubscriptionUpdated:         true,
 }
 
 // MarshalJSON implements the json.Marshaler interface for EventType.
@@ -61,3 +68,15 @@ func (e *EventType) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// SignatureFailureAlert is the payload of an EventTypeSignatureFailureAlert event. Repeated signature
+// failures usually mean that a participant signs with a key other than its registered one, or an attack.
+// The failed requests only claim to be from SubscriberID, and are counted per Source.
+type SignatureFailureAlert struct {
+	SubscriberID string     `json:"subscriber_id"`
+	Source       string     `json:"source,omitempty"`        // Client address the failed requests came from.
+	Failures     int        `json:"failures"`                // Failures since WindowStart.
+	WindowStart  time.Time  `json:"window_start"`            // Time of the first failure counted.
+	LastFailure  time.Time  `json:"last_failure"`            // Time of the failure that raised the alert.
+	BlockedUntil *time.Time `json:"blocked_until,omitempty"` // Set if the subscriber's requests from Source are rejected until then.
+}
@@ -30,6 +30,7 @@ func TestEventType_MarshalJSON(t *testing.T) {
 		{"SubscriptionRequestApproved", EventTypeSubscriptionRequestApproved, `"SUBSCRIPTION_REQUEST_APPROVED"`},
 		{"SubscriptionRequestRejected", EventTypeSubscriptionRequestRejected, `"SUBSCRIPTION_REQUEST_REJECTED"`},
 		{"OnSubscribeRecieved", EventTypeOnSubscribeRecieved, `"ON_SUBSCRIBE_RECIEVED"`},
+		{"SignatureFailureAlert", EventTypeSignatureFailureAlert, `"SIGNATURE_FAILURE_ALERT"`},
 	}
 
 	for _, tt := range tests {
@@ -56,6 +57,7 @@ func TestEventType_UnmarshalJSON_Success(t *testing.T) {
 		{"SubscriptionRequestApproved", `"SUBSCRIPTION_REQUEST_APPROVED"`, EventTypeSubscriptionRequestApproved},
 		{"SubscriptionRequestRejected", `"SUBSCRIPTION_REQUEST_REJECTED"`, EventTypeSubscriptionRequestRejected},
 		{"OnSubscribeRecieved", `"ON_SUBSCRIBE_RECIEVED"`, EventTypeOnSubscribeRecieved},
+		{"SignatureFailureAlert", `"SIGNATURE_FAILURE_ALERT"`, EventTypeSignatureFailureAlert},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidExtendedAttributes is returned when the extended attributes of a subscription
+// are not a JSON object or hold a value of the wrong type for a key.
+var ErrInvalidExtendedAttributes = errors.New("invalid extended_attributes")
+
+// Known keys of Subscription.ExtendedAttributes.
+const (
+	// ExtAttrWeight is the relative weight of a subscriber when choosing between candidates.
+	ExtAttrWeight = "weight"
+	// ExtAttrMaxFanOut caps the number of subscribers a request from this subscriber is fanned out to.
+	ExtAttrMaxFanOut = "max_fan_out"
+	// ExtAttrHeaders holds additional HTTP headers to send along with requests to this subscriber.
+	ExtAttrHeaders = "headers"
+	// ExtAttrMaxConcurrentCallbacks caps the number of simultaneous in-flight calls the gateway makes to this subscriber.
+	ExtAttrMaxConcurrentCallbacks = "max_concurrent_callbacks"
+)
+
+// DefaultWeight is the weight of a subscriber that does not set ExtAttrWeight.
+const DefaultWeight = 1
+
+// extAttrs parses the extended attributes into a map of raw values.
+// Empty or null attributes yield an empty map.
+func (s *Subscription) extAttrs() (map[string]json.RawMessage, error) {
+	raw := bytes.TrimSpace(s.ExtendedAttributes)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExtendedAttributes, err)
+	}
+	return attrs, nil
+}
+
+// ExtAttr returns the raw value stored under key and whether it is set.
+// A key holding JSON null is reported as not set.
+func (s *Subscription) ExtAttr(key string) (json.RawMessage, bool, error) {
+	attrs, err := s.extAttrs()
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := attrs[key]
+	if !ok || bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+		return nil, false, nil
+	}
+	return v, true, nil
+}
+
+// extAttrValue decodes the value stored under key, returning def if the key is not set.
+func extAttrValue[T any](s *Subscription, key string, def T) (T, error) {
+	raw, ok, err := s.ExtAttr(key)
+	if err != nil || !ok {
+		return def, err
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return def, fmt.Errorf("%w: key %q: %v", ErrInvalidExtendedAttributes, key, err)
+	}
+	return v, nil
+}
+
+// ExtAttrString returns the string stored under key, or def if the key is not set.
+func (s *Subscription) ExtAttrString(key, def string) (string, error) {
+	return extAttrValue(s, key, def)
+}
+
+// ExtAttrInt returns the integer stored under key, or def if the key is not set.
+func (s *Subscription) ExtAttrInt(key string, def int) (int, error) {
+	return extAttrValue(s, key, def)
+}
+
+// ExtAttrBool returns the boolean stored under key, or def if the key is not set.
+func (s *Subscription) ExtAttrBool(key string, def bool) (bool, error) {
+	return extAttrValue(s, key, def)
+}
+
+// Weight returns the ExtAttrWeight attribute, or DefaultWeight if it is not set.
+// The weight must be positive.
+func (s *Subscription) Weight() (int, error) {
+	w, err := s.ExtAttrInt(ExtAttrWeight, DefaultWeight)
+	if err != nil {
+		return DefaultWeight, err
+	}
+	if w <= 0 {
+		return DefaultWeight, fmt.Errorf("%w: %s must be positive, got %d", ErrInvalidExtendedAttributes, ExtAttrWeight, w)
+	}
+	return w, nil
+}
+
+// MaxFanOut returns the ExtAttrMaxFanOut attribute, or 0 (no limit) if it is not set.
+// The value must not be negative.
+func (s *Subscription) MaxFanOut() (int, error) {
+	n, err := s.ExtAttrInt(ExtAttrMaxFanOut, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: %s cannot be negative, got %d", ErrInvalidExtendedAttributes, ExtAttrMaxFanOut, n)
+	}
+	return n, nil
+}
+
+// Headers returns the ExtAttrHeaders attribute, or nil if it is not set.
+// Header names must not be empty.
+func (s *Subscription) Headers() (map[string]string, error) {
+	h, err := extAttrValue[map[string]string](s, ExtAttrHeaders, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name := range h {
+		if name == "" {
+			return nil, fmt.Errorf("%w: %s contains an empty header name", ErrInvalidExtendedAttributes, ExtAttrHeaders)
+		}
+	}
+	return h, nil
+}
+
+// MaxConcurrentCallbacks returns the ExtAttrMaxConcurrentCallbacks attribute, or 0 (the gateway default) if it is not set.
+// The value must not be negative.
+func (s *Subscription) MaxConcurrentCallbacks() (int, error) {
+	n, err := s.ExtAttrInt(ExtAttrMaxConcurrentCallbacks, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: %s cannot be negative, got %d", ErrInvalidExtendedAttributes, ExtAttrMaxConcurrentCallbacks, n)
+	}
+	return n, nil
+}
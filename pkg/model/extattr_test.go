@@ -0,0 +1,229 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func subscriptionWithAttrs(attrs string) *Subscription {
+	return &Subscription{ExtendedAttributes: json.RawMessage(attrs)}
+}
+
+func TestSubscription_ExtAttr(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   string
+		key     string
+		want    string
+		wantSet bool
+	}{
+		{
+			name:    "set key",
+			attrs:   `{"region": "south", "tier": 2}`,
+			key:     "tier",
+			want:    "2",
+			wantSet: true,
+		},
+		{
+			name:  "missing key",
+			attrs: `{"region": "south"}`,
+			key:   "tier",
+		},
+		{
+			name:  "null value",
+			attrs: `{"tier": null}`,
+			key:   "tier",
+		},
+		{
+			name: "no attributes",
+			key:  "tier",
+		},
+		{
+			name:  "null attributes",
+			attrs: `null`,
+			key:   "tier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := subscriptionWithAttrs(tt.attrs).ExtAttr(tt.key)
+			if err != nil {
+				t.Fatalf("ExtAttr() error = %v", err)
+			}
+			if ok != tt.wantSet {
+				t.Errorf("ExtAttr() set = %v, want %v", ok, tt.wantSet)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ExtAttr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscription_ExtAttr_TypedGetters(t *testing.T) {
+	s := subscriptionWithAttrs(`{"region": "south", "tier": 2, "beta": true}`)
+
+	if got, err := s.ExtAttrString("region", "north"); err != nil || got != "south" {
+		t.Errorf("ExtAttrString(region) = %q, %v, want %q, nil", got, err, "south")
+	}
+	if got, err := s.ExtAttrString("zone", "north"); err != nil || got != "north" {
+		t.Errorf("ExtAttrString(zone) = %q, %v, want default %q, nil", got, err, "north")
+	}
+	if got, err := s.ExtAttrInt("tier", 1); err != nil || got != 2 {
+		t.Errorf("ExtAttrInt(tier) = %d, %v, want 2, nil", got, err)
+	}
+	if got, err := s.ExtAttrInt("level", 7); err != nil || got != 7 {
+		t.Errorf("ExtAttrInt(level) = %d, %v, want default 7, nil", got, err)
+	}
+	if got, err := s.ExtAttrBool("beta", false); err != nil || !got {
+		t.Errorf("ExtAttrBool(beta) = %v, %v, want true, nil", got, err)
+	}
+	if got, err := s.ExtAttrBool("alpha", true); err != nil || !got {
+		t.Errorf("ExtAttrBool(alpha) = %v, %v, want default true, nil", got, err)
+	}
+}
+
+func TestSubscription_KnownExtAttrs(t *testing.T) {
+	s := subscriptionWithAttrs(`{"weight": 5, "max_fan_out": 10, "headers": {"X-Tenant": "acme"}, "max_concurrent_callbacks": 3}`)
+
+	if got, err := s.Weight(); err != nil || got != 5 {
+		t.Errorf("Weight() = %d, %v, want 5, nil", got, err)
+	}
+	if got, err := s.MaxConcurrentCallbacks(); err != nil || got != 3 {
+		t.Errorf("MaxConcurrentCallbacks() = %d, %v, want 3, nil", got, err)
+	}
+	if got, err := s.MaxFanOut(); err != nil || got != 10 {
+		t.Errorf("MaxFanOut() = %d, %v, want 10, nil", got, err)
+	}
+	got, err := s.Headers()
+	if err != nil {
+		t.Fatalf("Headers() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"X-Tenant": "acme"}, got); diff != "" {
+		t.Errorf("Headers() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscription_KnownExtAttrs_Defaults(t *testing.T) {
+	s := subscriptionWithAttrs(`{"region": "south"}`)
+
+	if got, err := s.Weight(); err != nil || got != DefaultWeight {
+		t.Errorf("Weight() = %d, %v, want default %d, nil", got, err, DefaultWeight)
+	}
+	if got, err := s.MaxFanOut(); err != nil || got != 0 {
+		t.Errorf("MaxFanOut() = %d, %v, want default 0, nil", got, err)
+	}
+	if got, err := s.MaxConcurrentCallbacks(); err != nil || got != 0 {
+		t.Errorf("MaxConcurrentCallbacks() = %d, %v, want default 0, nil", got, err)
+	}
+	if got, err := s.Headers(); err != nil || got != nil {
+		t.Errorf("Headers() = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestSubscription_ExtAttr_Error(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs string
+		get   func(s *Subscription) error
+	}{
+		{
+			name:  "attributes not an object",
+			attrs: `["weight", 5]`,
+			get: func(s *Subscription) error {
+				_, _, err := s.ExtAttr("weight")
+				return err
+			},
+		},
+		{
+			name:  "malformed JSON",
+			attrs: `{"weight": `,
+			get: func(s *Subscription) error {
+				_, err := s.Weight()
+				return err
+			},
+		},
+		{
+			name:  "string for int",
+			attrs: `{"tier": "two"}`,
+			get: func(s *Subscription) error {
+				_, err := s.ExtAttrInt("tier", 0)
+				return err
+			},
+		},
+		{
+			name:  "fractional int",
+			attrs: `{"weight": 1.5}`,
+			get: func(s *Subscription) error {
+				_, err := s.Weight()
+				return err
+			},
+		},
+		{
+			name:  "zero weight",
+			attrs: `{"weight": 0}`,
+			get: func(s *Subscription) error {
+				_, err := s.Weight()
+				return err
+			},
+		},
+		{
+			name:  "negative max fan-out",
+			attrs: `{"max_fan_out": -1}`,
+			get: func(s *Subscription) error {
+				_, err := s.MaxFanOut()
+				return err
+			},
+		},
+		{
+			name:  "negative max concurrent callbacks",
+			attrs: `{"max_concurrent_callbacks": -2}`,
+			get: func(s *Subscription) error {
+				_, err := s.MaxConcurrentCallbacks()
+				return err
+			},
+		},
+		{
+			name:  "non-string header value",
+			attrs: `{"headers": {"X-Tenant": 1}}`,
+			get: func(s *Subscription) error {
+				_, err := s.Headers()
+				return err
+			},
+		},
+		{
+			name:  "empty header name",
+			attrs: `{"headers": {"": "acme"}}`,
+			get: func(s *Subscription) error {
+				_, err := s.Headers()
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.get(subscriptionWithAttrs(tt.attrs)); !errors.Is(err, ErrInvalidExtendedAttributes) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidExtendedAttributes)
+			}
+		})
+	}
+}
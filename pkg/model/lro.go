@@ -26,6 +26,8 @@ type LROStatus string
 const (
 	// LROStatusPending indicates that the long-running operation is currently in progress.
 	LROStatusPending LROStatus = "PENDING"
+	// LROStatusInProgress indicates that the long-running operation has been claimed by a worker for processing.
+	LROStatusInProgress LROStatus = "IN_PROGRESS"
 	// LROStatusApproved indicates that the long-running operation has been successfully completed and approved.
 	LROStatusApproved LROStatus = "APPROVED"
 	// LROStatusFailure indicates that the long-running operation has failed.
@@ -56,3 +58,54 @@ type LRO struct {
 	CreatedAt     time.Time       `json:"created_at,omitempty"`
 	UpdatedAt     time.Time       `json:"updated_at,omitempty"`
 }
+
+// SubscriptionChange is a field of a subscription changed by an update, with its values before and after.
+// Locations are given as JSON.
+type SubscriptionChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SubscriptionUpdateResult is the result of an approved subscription update.
+type SubscriptionUpdateResult struct {
+	Changes []SubscriptionChange `json:"changes"`
+}
+
+// MarshalJSON encodes r with an empty list of changes, rather than null, if it has none.
+func (r SubscriptionUpdateResult) MarshalJSON() ([]byte, error) {
+	type result SubscriptionUpdateResult // Without the MarshalJSON method.
+	if r.Changes == nil {
+		r.Changes = []SubscriptionChange{}
+	}
+	return json.Marshal(result(r))
+}
+
+// CallbackFailureReason classifies why the /on_subscribe callback of an operation failed.
+type CallbackFailureReason string
+
+// Defines the valid CallbackFailureReason values.
+const (
+	// CallbackFailure4XX signifies that the participant answered with a 4xx status.
+	CallbackFailure4XX CallbackFailureReason = "CALLBACK_4XX"
+	// CallbackFailure5XX signifies that the participant answered with a 5xx status.
+	CallbackFailure5XX CallbackFailureReason = "CALLBACK_5XX"
+	// CallbackFailureTimeout signifies that the participant did not answer in time.
+	CallbackFailureTimeout CallbackFailureReason = "CALLBACK_TIMEOUT"
+	// CallbackFailureNetwork signifies that the participant could not be reached, e.g. the connection was refused.
+	CallbackFailureNetwork CallbackFailureReason = "CALLBACK_NETWORK_ERROR"
+	// CallbackFailureInvalidResponse signifies that the participant's answer could not be used,
+	// e.g. an unexpected status, a body that is too large or holds no answer.
+	CallbackFailureInvalidResponse CallbackFailureReason = "CALLBACK_INVALID_RESPONSE"
+	// CallbackFailureUnreachable signifies that the participant's callback URL failed the pre-flight
+	// check, so no challenge was sent.
+	CallbackFailureUnreachable CallbackFailureReason = "CALLBACK_UNREACHABLE"
+)
+
+// CallbackFailure is the error data of an operation whose /on_subscribe callback failed.
+type CallbackFailure struct {
+	Error            string                `json:"error"`
+	Reason           CallbackFailureReason `json:"reason"`
+	StatusCode       int                   `json:"status_code,omitempty"`       // The participant's status, if it answered.
+	ParticipantError string                `json:"participant_error,omitempty"` // The start of the participant's error response.
+}
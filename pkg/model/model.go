@@ -15,8 +15,11 @@
 package model
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // AsyncTaskType defines the type of asynchronous task.
@@ -27,6 +30,9 @@ const (
 	AsyncTaskTypeProxy AsyncTaskType = "PROXY"
 	// AsyncTaskTypeLookup indicates a task that requires a lookup.
 	AsyncTaskTypeLookup AsyncTaskType = "LOOKUP"
+	// AsyncTaskTypeApproval indicates a task approving a subscription operation, whose body is
+	// the OperationActionRequest of the approval.
+	AsyncTaskTypeApproval AsyncTaskType = "APPROVAL"
 )
 
 // AsyncTask holds the details for an asynchronous task.
@@ -43,4 +49,58 @@ type NpSubscriptionRequest struct {
 	Subscriber `json:",inline"`
 	KeyID      string `json:"key_id"`
 	MessageID  string `json:"message_id"`
+	// AuthScheme selects how the update request to the registry is authenticated. Defaults to AuthSchemeSubscriber.
+	AuthScheme AuthScheme `json:"auth_scheme,omitempty"`
+	// AuthKeyID is the ID of the keyset used to sign the update request. It can only name the keyset of
+	// the scheme, the subscriber ID for AuthSchemeSubscriber and the configured gateway for AuthSchemeGateway.
+	AuthKeyID string `json:"auth_key_id,omitempty"`
+	// ValidUntil is when the subscription expires. Defaults to the validity configured in the subscriber service.
+	ValidUntil time.Time `json:"valid_until,omitzero"`
+}
+
+// AuthScheme defines how an outbound request to the registry is authenticated.
+type AuthScheme string
+
+const (
+	// AuthSchemeSubscriber sends the signature in the Authorization header, as the participant itself.
+	AuthSchemeSubscriber AuthScheme = "SUBSCRIBER"
+	// AuthSchemeGateway sends the signature in the X-Gateway-Authorization header, for a gateway acting on behalf of a participant.
+	AuthSchemeGateway AuthScheme = "GATEWAY"
+)
+
+// HeaderName returns the HTTP header carrying the signature for the scheme.
+// An empty scheme is treated as AuthSchemeSubscriber.
+func (s AuthScheme) HeaderName() (string, error) {
+	switch s {
+	case "", AuthSchemeSubscriber:
+		return AuthHeaderSubscriber, nil
+	case AuthSchemeGateway:
+		return AuthHeaderGateway, nil
+	}
+	return "", fmt.Errorf("unknown auth scheme: %s", s)
+}
+
+// ChallengeHeaderFor returns the response header challenging a request whose signature is
+// carried in authHeader. Authorization is challenged with WWW-Authenticate and a header named
+// <prefix>-Authorization with <prefix>-Authenticate. Any other header gets WWW-Authenticate.
+func ChallengeHeaderFor(authHeader string) string {
+	authHeader = http.CanonicalHeaderKey(authHeader)
+	if prefix, ok := strings.CutSuffix(authHeader, "-Authorization"); ok && prefix != "" {
+		return prefix + "-Authenticate"
+	}
+	return UnauthorizedHeaderSubscriber
+}
+
+// ValidHeaderName reports whether name can be used as an HTTP header name.
+func ValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		// Token characters of RFC 9110.
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+	return true
 }
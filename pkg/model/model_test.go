@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "testing"
+
+func TestChallengeHeaderFor(t *testing.T) {
+	tests := []struct {
+		authHeader string
+		want       string
+	}{
+		{authHeader: "Authorization", want: "WWW-Authenticate"},
+		{authHeader: "authorization", want: "WWW-Authenticate"},
+		{authHeader: "Proxy-Authorization", want: "Proxy-Authenticate"},
+		{authHeader: "x-beckn-authorization", want: "X-Beckn-Authenticate"},
+		{authHeader: "X-Signature", want: "WWW-Authenticate"},
+	}
+	for _, tt := range tests {
+		if got := ChallengeHeaderFor(tt.authHeader); got != tt.want {
+			t.Errorf("ChallengeHeaderFor(%q) = %q, want %q", tt.authHeader, got, tt.want)
+		}
+	}
+}
+
+func TestValidHeaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Authorization", want: true},
+		{name: "X-Beckn-Signature", want: true},
+		{name: "", want: false},
+		{name: "X Signature", want: false},
+		{name: "X-Signature:", want: false},
+		{name: "X-Sïgnature", want: false},
+	}
+	for _, tt := range tests {
+		if got := ValidHeaderName(tt.name); got != tt.want {
+			t.Errorf("ValidHeaderName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default timeouts of the external dependencies of a service.
+const (
+	DefaultRegistryTimeout   = 10 * time.Second
+	DefaultNPCallbackTimeout = 10 * time.Second
+	DefaultDBTimeout         = 30 * time.Second
+	DefaultCacheTimeout      = 3 * time.Second
+	DefaultEventTimeout      = 30 * time.Second
+)
+
+// DependencyTimeouts configures in one place how long a service waits for each of its external dependencies.
+// A zero timeout uses its default. Services ignore the timeouts of dependencies they do not have.
+type DependencyTimeouts struct {
+	Registry   time.Duration `yaml:"registry"`   // Each HTTP request attempt to the Registry.
+	NPCallback time.Duration `yaml:"npCallback"` // Each HTTP request attempt to a Network Participant.
+	DB         time.Duration `yaml:"db"`         // Connecting to the database and each statement.
+	Cache      time.Duration `yaml:"cache"`      // Connecting to the cache and each command.
+	Event      time.Duration `yaml:"event"`      // Publishing each event.
+}
+
+// Validate returns an error naming the first negative timeout. A nil t is valid.
+func (t *DependencyTimeouts) Validate() error {
+	if t == nil {
+		return nil
+	}
+	for _, d := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"registry", t.Registry},
+		{"npCallback", t.NPCallback},
+		{"db", t.DB},
+		{"cache", t.Cache},
+		{"event", t.Event},
+	} {
+		if d.timeout < 0 {
+			return fmt.Errorf("invalid dependencyTimeouts.%s: %s, must not be negative", d.name, d.timeout)
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of t with the default of every unset timeout.
+// A nil t returns all defaults.
+func (t *DependencyTimeouts) WithDefaults() DependencyTimeouts {
+	var r DependencyTimeouts
+	if t != nil {
+		r = *t
+	}
+	if r.Registry == 0 {
+		r.Registry = DefaultRegistryTimeout
+	}
+	if r.NPCallback == 0 {
+		r.NPCallback = DefaultNPCallbackTimeout
+	}
+	if r.DB == 0 {
+		r.DB = DefaultDBTimeout
+	}
+	if r.Cache == 0 {
+		r.Cache = DefaultCacheTimeout
+	}
+	if r.Event == 0 {
+		r.Event = DefaultEventTimeout
+	}
+	return r
+}
+
+// ClientTimeouts points to the timeouts of the client configurations of a service, one per
+// dependency. The timeouts of dependencies the service does not have are nil.
+type ClientTimeouts struct {
+	Registry   *time.Duration
+	NPCallback *time.Duration
+	DB         *time.Duration
+	Event      *time.Duration
+}
+
+// Apply sets each timeout of c to the timeout of its dependency in t, or its default, unless
+// the client configuration sets one itself. A nil t applies all defaults.
+func (t *DependencyTimeouts) Apply(c ClientTimeouts) {
+	d := t.WithDefaults()
+	for _, ct := range []struct {
+		timeout *time.Duration
+		d       time.Duration
+	}{
+		{c.Registry, d.Registry},
+		{c.NPCallback, d.NPCallback},
+		{c.DB, d.DB},
+		{c.Event, d.Event},
+	} {
+		if ct.timeout != nil {
+			*ct.timeout = TimeoutOr(*ct.timeout, ct.d)
+		}
+	}
+}
+
+// TimeoutOr returns override if it is set, else d. It lets the timeout of an individual
+// client configuration take precedence over DependencyTimeouts.
+func TimeoutOr(override, d time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	return d
+}
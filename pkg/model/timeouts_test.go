@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDependencyTimeouts_WithDefaults(t *testing.T) {
+	defaults := DependencyTimeouts{
+		Registry:   DefaultRegistryTimeout,
+		NPCallback: DefaultNPCallbackTimeout,
+		DB:         DefaultDBTimeout,
+		Cache:      DefaultCacheTimeout,
+		Event:      DefaultEventTimeout,
+	}
+	tests := []struct {
+		name string
+		t    *DependencyTimeouts
+		want DependencyTimeouts
+	}{
+		{name: "nil", t: nil, want: defaults},
+		{name: "empty", t: &DependencyTimeouts{}, want: defaults},
+		{
+			name: "partially set",
+			t:    &DependencyTimeouts{Registry: 2 * time.Second, Event: 5 * time.Second},
+			want: DependencyTimeouts{
+				Registry:   2 * time.Second,
+				NPCallback: DefaultNPCallbackTimeout,
+				DB:         DefaultDBTimeout,
+				Cache:      DefaultCacheTimeout,
+				Event:      5 * time.Second,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.t.WithDefaults()); diff != "" {
+				t.Errorf("WithDefaults() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDependencyTimeouts_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       *DependencyTimeouts
+		wantErr string
+	}{
+		{name: "nil", t: nil},
+		{name: "all set", t: &DependencyTimeouts{Registry: time.Second, NPCallback: time.Second, DB: time.Second, Cache: time.Second, Event: time.Second}},
+		{name: "negative registry", t: &DependencyTimeouts{Registry: -time.Second}, wantErr: "invalid dependencyTimeouts.registry: -1s, must not be negative"},
+		{name: "negative npCallback", t: &DependencyTimeouts{NPCallback: -time.Second}, wantErr: "invalid dependencyTimeouts.npCallback: -1s, must not be negative"},
+		{name: "negative db", t: &DependencyTimeouts{DB: -time.Second}, wantErr: "invalid dependencyTimeouts.db: -1s, must not be negative"},
+		{name: "negative cache", t: &DependencyTimeouts{Cache: -time.Second}, wantErr: "invalid dependencyTimeouts.cache: -1s, must not be negative"},
+		{name: "negative event", t: &DependencyTimeouts{Event: -time.Second}, wantErr: "invalid dependencyTimeouts.event: -1s, must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.t.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeoutOr(t *testing.T) {
+	if got := TimeoutOr(0, time.Second); got != time.Second {
+		t.Errorf("TimeoutOr(0, 1s) = %s, want 1s", got)
+	}
+	if got := TimeoutOr(2*time.Second, time.Second); got != 2*time.Second {
+		t.Errorf("TimeoutOr(2s, 1s) = %s, want 2s", got)
+	}
+}
+
+func TestDependencyTimeouts_Apply(t *testing.T) {
+	tests := []struct {
+		name         string
+		t            *DependencyTimeouts
+		registry, db time.Duration
+		wantRegistry time.Duration
+		wantDB       time.Duration
+	}{
+		{name: "nil applies defaults", t: nil, wantRegistry: DefaultRegistryTimeout, wantDB: DefaultDBTimeout},
+		{name: "section", t: &DependencyTimeouts{Registry: 2 * time.Second}, wantRegistry: 2 * time.Second, wantDB: DefaultDBTimeout},
+		{name: "client overrides section", t: &DependencyTimeouts{Registry: 2 * time.Second}, registry: 5 * time.Second, db: time.Second, wantRegistry: 5 * time.Second, wantDB: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, db := tt.registry, tt.db
+			tt.t.Apply(ClientTimeouts{Registry: &registry, DB: &db})
+			if registry != tt.wantRegistry {
+				t.Errorf("Apply() Registry = %s, want %s", registry, tt.wantRegistry)
+			}
+			if db != tt.wantDB {
+				t.Errorf("Apply() DB = %s, want %s", db, tt.wantDB)
+			}
+		})
+	}
+}
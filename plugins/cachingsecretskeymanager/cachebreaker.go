@@ -0,0 +1,221 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachingsecretskeymanager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	plugin "github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Defaults of the cache circuit breaker.
+const (
+	DefaultCacheFailureThreshold = 5
+	DefaultCacheCooldown         = 30 * time.Second
+)
+
+// maxPendingInvalidations bounds the keys remembered for deletion once the cache is back.
+// Beyond it, the whole cache is cleared instead.
+const maxPendingInvalidations = 1000
+
+// errCacheBypassed is returned by the cache while it is bypassed after repeated failures.
+var errCacheBypassed = errors.New("cache bypassed while unavailable")
+
+// cacheBreaker is a circuit breaker around a cache. After threshold consecutive failures
+// it stops calling the cache for cooldown, so that callers fall back to the backing store
+// without waiting on an unreachable cache. Once cooldown has passed, calls go through again
+// and the first success closes the breaker.
+// The keys of writes and deletes that did not reach the cache are deleted before it is used
+// again, so that entries invalidated during an outage are not served until they expire.
+type cacheBreaker struct {
+	cache     plugin.Cache
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu           sync.Mutex
+	failures     int                 // Consecutive failures.
+	openUntil    time.Time           // Zero while the breaker is closed.
+	pending      map[string]struct{} // Keys to delete once the cache answers again.
+	clearPending bool                // The whole cache is to be cleared instead.
+}
+
+// newCacheBreaker wraps cache in a breaker that opens after threshold consecutive failures for cooldown.
+func newCacheBreaker(cache plugin.Cache, threshold int, cooldown time.Duration) *cacheBreaker {
+	return &cacheBreaker{cache: cache, threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether the cache may be called.
+func (b *cacheBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.openUntil.IsZero() || !b.now().Before(b.openUntil)
+}
+
+// record updates the breaker with the result of a cache call.
+// A cache miss is a successful call: the cache answered.
+func (b *cacheBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || errors.Is(err, redis.Nil) {
+		if !b.openUntil.IsZero() {
+			slog.InfoContext(ctx, "Cache recovered, re-enabling caching")
+		}
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.openUntil.IsZero() {
+			slog.WarnContext(ctx, "Cache unavailable, bypassing it", "consecutive_failures", b.failures, "cooldown", b.cooldown, "error", err)
+		}
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// invalidateLater remembers key for deletion once the cache answers again, since a write or delete
+// of it did not reach the cache. Too many keys clear the whole cache instead.
+func (b *cacheBreaker) invalidateLater(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clearPending {
+		return
+	}
+	if len(b.pending) >= maxPendingInvalidations {
+		b.pending, b.clearPending = nil, true
+		return
+	}
+	if b.pending == nil {
+		b.pending = make(map[string]struct{})
+	}
+	b.pending[key] = struct{}{}
+}
+
+// clearLater clears the whole cache once it answers again, since a clear did not reach it.
+func (b *cacheBreaker) clearLater() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending, b.clearPending = nil, true
+}
+
+// isPending reports whether key still has to be deleted from the cache.
+func (b *cacheBreaker) isPending(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.pending[key]
+	return b.clearPending || ok
+}
+
+// flushPending deletes the keys remembered by invalidateLater, or clears the cache, before it is used again.
+// Keys that still cannot be deleted stay pending.
+func (b *cacheBreaker) flushPending(ctx context.Context) {
+	b.mu.Lock()
+	keys, clear := b.pending, b.clearPending
+	b.pending, b.clearPending = nil, false
+	b.mu.Unlock()
+	if clear {
+		err := b.cache.Clear(ctx)
+		b.record(ctx, err)
+		if err != nil {
+			b.clearLater()
+			return
+		}
+		slog.InfoContext(ctx, "Cache cleared of the entries changed while it was unavailable")
+		return
+	}
+	for key := range keys {
+		err := b.cache.Delete(ctx, key)
+		b.record(ctx, err)
+		if err != nil {
+			b.invalidateLater(key)
+		}
+	}
+	if len(keys) > 0 {
+		slog.InfoContext(ctx, "Cache invalidated the entries changed while it was unavailable", "keys", len(keys))
+	}
+}
+
+// Get retrieves a value from the cache unless it is bypassed. A key whose write or delete
+// did not reach the cache is a miss until it has been deleted from it.
+func (b *cacheBreaker) Get(ctx context.Context, key string) (string, error) {
+	if !b.allow() {
+		return "", errCacheBypassed
+	}
+	b.flushPending(ctx)
+	if !b.allow() {
+		return "", errCacheBypassed
+	}
+	if b.isPending(key) {
+		return "", redis.Nil
+	}
+	v, err := b.cache.Get(ctx, key)
+	b.record(ctx, err)
+	return v, err
+}
+
+// Set stores a value in the cache unless it is bypassed.
+func (b *cacheBreaker) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if !b.allow() {
+		b.invalidateLater(key)
+		return errCacheBypassed
+	}
+	b.flushPending(ctx)
+	err := b.cache.Set(ctx, key, value, ttl)
+	b.record(ctx, err)
+	if err != nil {
+		b.invalidateLater(key)
+	}
+	return err
+}
+
+// Delete removes a value from the cache unless it is bypassed.
+func (b *cacheBreaker) Delete(ctx context.Context, key string) error {
+	if !b.allow() {
+		b.invalidateLater(key)
+		return errCacheBypassed
+	}
+	b.flushPending(ctx)
+	err := b.cache.Delete(ctx, key)
+	b.record(ctx, err)
+	if err != nil {
+		b.invalidateLater(key)
+	}
+	return err
+}
+
+// Clear removes all values from the cache unless it is bypassed.
+func (b *cacheBreaker) Clear(ctx context.Context) error {
+	if !b.allow() {
+		b.clearLater()
+		return errCacheBypassed
+	}
+	err := b.cache.Clear(ctx)
+	b.record(ctx, err)
+	if err != nil {
+		b.clearLater()
+		return err
+	}
+	b.mu.Lock()
+	b.pending, b.clearPending = nil, false
+	b.mu.Unlock()
+	return nil
+}
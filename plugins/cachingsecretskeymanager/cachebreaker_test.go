@@ -0,0 +1,291 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachingsecretskeymanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCacheBreaker(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("dial tcp: connection refused")
+	var cacheErr error
+	calls := 0
+	cache := &mockCache{
+		get: func(ctx context.Context, key string) (string, error) {
+			calls++
+			return "", cacheErr
+		},
+		delete: func(ctx context.Context, key string) error {
+			calls++
+			return cacheErr
+		},
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCacheBreaker(cache, 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	// Misses are answers from the cache and do not count as failures.
+	cacheErr = redis.Nil
+	for i := 0; i < 3; i++ {
+		b.Get(ctx, "key")
+	}
+	if !b.allow() {
+		t.Fatal("breaker opened on cache misses")
+	}
+
+	cacheErr = errUnavailable
+	b.Get(ctx, "key")
+	if !b.allow() {
+		t.Fatal("breaker opened before reaching the failure threshold")
+	}
+	b.Get(ctx, "key")
+	if b.allow() {
+		t.Fatal("breaker still closed after reaching the failure threshold")
+	}
+
+	calls = 0
+	if _, err := b.Get(ctx, "key"); !errors.Is(err, errCacheBypassed) {
+		t.Errorf("Get() while open error = %v, want %v", err, errCacheBypassed)
+	}
+	if err := b.Set(ctx, "key", "v", time.Minute); !errors.Is(err, errCacheBypassed) {
+		t.Errorf("Set() while open error = %v, want %v", err, errCacheBypassed)
+	}
+	if calls != 0 {
+		t.Errorf("cache called %d times while the breaker was open, want 0", calls)
+	}
+
+	// After the cooldown the cache is tried again; a further failure reopens the breaker.
+	now = now.Add(time.Minute)
+	b.Get(ctx, "key")
+	if calls != 1 || b.allow() {
+		t.Errorf("after cooldown: calls = %d, open = %t, want 1 call and the breaker reopened", calls, !b.allow())
+	}
+
+	// Once the cache answers again, caching resumes.
+	now = now.Add(time.Minute)
+	cacheErr = nil
+	b.Get(ctx, "key")
+	if !b.allow() {
+		t.Error("breaker still open after the cache recovered")
+	}
+	cacheErr = errUnavailable
+	b.Get(ctx, "key")
+	if !b.allow() {
+		t.Error("breaker opened on a single failure after recovering, want the failure count reset")
+	}
+}
+
+func TestCacheBreaker_InvalidatesOnRecovery(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("dial tcp: connection refused")
+	tests := []struct {
+		name        string
+		whileOpen   func(b *cacheBreaker)
+		wantDeleted []string
+		wantCleared bool
+	}{
+		{
+			name:        "set while bypassed",
+			whileOpen:   func(b *cacheBreaker) { b.Set(ctx, "key", "v", time.Minute) },
+			wantDeleted: []string{"key"},
+		},
+		{
+			name:        "delete while bypassed",
+			whileOpen:   func(b *cacheBreaker) { b.Delete(ctx, "key") },
+			wantDeleted: []string{"key"},
+		},
+		{
+			name:        "clear while bypassed",
+			whileOpen:   func(b *cacheBreaker) { b.Clear(ctx) },
+			wantCleared: true,
+		},
+		{
+			name: "too many keys while bypassed",
+			whileOpen: func(b *cacheBreaker) {
+				for i := 0; i <= maxPendingInvalidations; i++ {
+					b.Delete(ctx, fmt.Sprintf("key%d", i))
+				}
+			},
+			wantCleared: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheErr := errUnavailable
+			var deleted []string
+			cleared := false
+			cache := &mockCache{
+				get: func(ctx context.Context, key string) (string, error) { return "stale", cacheErr },
+				set: func(ctx context.Context, key, value string, ttl time.Duration) error { return cacheErr },
+				delete: func(ctx context.Context, key string) error {
+					if cacheErr == nil {
+						deleted = append(deleted, key)
+					}
+					return cacheErr
+				},
+				clear: func(ctx context.Context) error {
+					cleared = cacheErr == nil
+					return cacheErr
+				},
+			}
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			b := newCacheBreaker(cache, 1, time.Minute)
+			b.now = func() time.Time { return now }
+			b.Get(ctx, "other")
+			if b.allow() {
+				t.Fatal("breaker still closed after reaching the failure threshold")
+			}
+			tt.whileOpen(b)
+
+			// The cache is back: what changed during the outage is invalidated before it is used.
+			now = now.Add(time.Minute)
+			cacheErr = nil
+			if _, err := b.Get(ctx, "other"); err != nil {
+				t.Fatalf("Get() after recovery error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantDeleted, deleted); diff != "" {
+				t.Errorf("deleted keys mismatch (-want +got):\n%s", diff)
+			}
+			if cleared != tt.wantCleared {
+				t.Errorf("cache cleared = %t, want %t", cleared, tt.wantCleared)
+			}
+			if b.isPending("key") {
+				t.Error("key still pending after recovery")
+			}
+		})
+	}
+}
+
+func TestCacheBreaker_PendingKeyIsMiss(t *testing.T) {
+	ctx := context.Background()
+	deleteErr := errors.New("dial tcp: connection refused")
+	cache := &mockCache{
+		get:    func(ctx context.Context, key string) (string, error) { return "stale", nil },
+		set:    func(ctx context.Context, key, value string, ttl time.Duration) error { return nil },
+		delete: func(ctx context.Context, key string) error { return deleteErr },
+	}
+	b := newCacheBreaker(cache, 5, time.Minute)
+
+	// A failed delete leaves the entry in the cache, which must not be served.
+	if err := b.Delete(ctx, "key"); err == nil {
+		t.Fatal("Delete() error = nil, want the cache error")
+	}
+	if _, err := b.Get(ctx, "key"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Get() of a key pending deletion error = %v, want %v", err, redis.Nil)
+	}
+
+	deleteErr = nil
+	if v, err := b.Get(ctx, "key"); err != nil || v != "stale" {
+		t.Errorf("Get() once deleted = %q, %v, want the cached value", v, err)
+	}
+}
+
+func TestKeyset_CacheUnavailable(t *testing.T) {
+	keyset := &model.Keyset{UniqueKeyID: "unique1", SigningPrivate: "signing-private", EncrPrivate: "encr-private"}
+	payload, _ := json.Marshal(keyset)
+	cacheUp := false
+	cacheCalls, backendCalls := 0, 0
+	cache := &mockCache{
+		get: func(ctx context.Context, key string) (string, error) {
+			cacheCalls++
+			if !cacheUp {
+				return "", errors.New("dial tcp: connection refused")
+			}
+			return string(payload), nil
+		},
+		set: func(ctx context.Context, key, value string, ttl time.Duration) error {
+			cacheCalls++
+			if !cacheUp {
+				return errors.New("dial tcp: connection refused")
+			}
+			return nil
+		},
+	}
+	secrets := &mockSecretMgr{
+		accessSecretVersion: func(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+			backendCalls++
+			return &secretmanagerpb.AccessSecretVersionResponse{Payload: &secretmanagerpb.SecretPayload{Data: payload}}, nil
+		},
+	}
+	cfg := &Config{ProjectID: "test-project", SubscriberKeysCache: true, CacheFailureThreshold: 2, CacheCooldown: time.Minute}
+	km, _, err := newWithBackend(cache, &mockRegistry{}, cfg, &gcpSecretBackend{projectID: "test-project", client: secrets})
+	if err != nil {
+		t.Fatalf("newWithBackend() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	km.cache.(*cacheBreaker).now = func() time.Time { return now }
+
+	// Requests are served from the backing store while the cache is down.
+	for i := 0; i < 3; i++ {
+		got, err := km.Keyset(context.Background(), "key1")
+		if err != nil {
+			t.Fatalf("Keyset() with cache down error = %v", err)
+		}
+		if got.UniqueKeyID != keyset.UniqueKeyID {
+			t.Errorf("Keyset() = %+v, want %+v", got, keyset)
+		}
+	}
+	if backendCalls != 3 {
+		t.Errorf("backend called %d times, want 3", backendCalls)
+	}
+	// The first Get and Set fail and open the breaker, which then bypasses the cache.
+	if cacheCalls != 2 {
+		t.Errorf("cache called %d times, want 2 before being bypassed", cacheCalls)
+	}
+
+	// Once the cooldown has passed and the cache is back, keys are served from it again.
+	cacheUp = true
+	now = now.Add(time.Minute)
+	if _, err := km.Keyset(context.Background(), "key1"); err != nil {
+		t.Fatalf("Keyset() after recovery error = %v", err)
+	}
+	if backendCalls != 3 {
+		t.Errorf("backend called %d times after recovery, want the cached keyset served", backendCalls)
+	}
+}
+
+func TestNewErrors_CacheBreakerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr error
+	}{
+		{name: "negative threshold", cfg: &Config{ProjectID: "p", CacheFailureThreshold: -1}, wantErr: ErrInvalidCacheFailureThreshold},
+		{name: "negative cooldown", cfg: &Config{ProjectID: "p", CacheCooldown: -time.Second}, wantErr: ErrInvalidCacheCooldown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newWithBackend(&mockCache{}, &mockRegistry{}, tt.cfg, &gcpSecretBackend{client: &mockSecretMgr{}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("newWithBackend() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -30,15 +30,11 @@ import (
 
 	
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
-	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	plugin "github.com/beckn-one/beckn-onix/pkg/plugin/definition" // Plugin definitions will be imported from here.
 
-	"github.com/googleapis/gax-go/v2"
 	"github.com/google/uuid"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 // Config Required for the module.
@@ -46,19 +42,17 @@ type Config struct {
 	ProjectID           string
 	SubscriberKeysCache bool
 	NetworkKeysCache    bool
-}
-
-type secretMgr interface {
-	CreateSecret(context.Context, *secretmanagerpb.CreateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error)
-	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
-	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
-	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
-	Close() error
+	Backend             BackendType // Defaults to BackendGCP when empty.
+	SecretsDir          string      // Required when Backend is BackendFile.
+	// CacheFailureThreshold is the number of consecutive cache failures after which the cache
+	// is bypassed for CacheCooldown. Defaults to DefaultCacheFailureThreshold when 0.
+	CacheFailureThreshold int
+	// CacheCooldown is how long the cache is bypassed once unavailable. Defaults to DefaultCacheCooldown when 0.
+	CacheCooldown time.Duration
 }
 
 type keyMgr struct {
-	projectID           string
-	secretClient        secretMgr
+	backend             SecretBackend
 	registry            plugin.RegistryLookup
 	cache               plugin.Cache
 	subscriberKeysCache bool
@@ -76,16 +70,32 @@ const (
 
 // New method creates a new KeyManager instance.
 func New(ctx context.Context, cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *Config) (*keyMgr, func() error, error) {
-	secretClient, err := secretmanager.NewClient(ctx)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create secret manager client: %w", err)
+	if err := validateCfg(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	var backend SecretBackend
+	switch cfg.Backend {
+	case BackendFile:
+		fileBackend, err := newFileSecretBackend(cfg.SecretsDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.WarnContext(ctx, "Using file secret backend, private keys are stored unencrypted on disk", "dir", cfg.SecretsDir)
+		backend = fileBackend
+	default:
+		secretClient, err := secretmanager.NewClient(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create secret manager client: %w", err)
+		}
+		backend = &gcpSecretBackend{projectID: cfg.ProjectID, client: secretClient}
 	}
 	// Call the internal, testable constructor.
-	return newWithClient(cache, registryLookup, cfg, secretClient)
+	return newWithBackend(cache, registryLookup, cfg, backend)
 }
 
-// newWithClient is an internal constructor that accepts a secret manager client interface,
-func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *Config, client secretMgr) (*keyMgr, func() error, error) {
+// newWithBackend is an internal constructor that accepts a secret backend interface,
+func newWithBackend(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg *Config, backend SecretBackend) (*keyMgr, func() error, error) {
 	if err := validateCfg(cfg); err != nil {
 		return nil, nil, err
 	}
@@ -94,9 +104,20 @@ func newWithClient(cache plugin.Cache, registryLookup plugin.RegistryLookup, cfg
 		return nil, nil, ErrNilRegistryLookup
 	}
 
+	if cache != nil {
+		threshold := cfg.CacheFailureThreshold
+		if threshold == 0 {
+			threshold = DefaultCacheFailureThreshold
+		}
+		cooldown := cfg.CacheCooldown
+		if cooldown == 0 {
+			cooldown = DefaultCacheCooldown
+		}
+		cache = newCacheBreaker(cache, threshold, cooldown)
+	}
+
 	km := &keyMgr{
-		projectID:           cfg.ProjectID,
-		secretClient:        client,
+		backend:             backend,
 		registry:            registryLookup,
 		cache:               cache,
 		subscriberKeysCache: cfg.SubscriberKeysCache,
@@ -137,7 +158,7 @@ func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 	}, nil
 }
 
-// InsertKeyset stores keyset to the secret manager.
+// InsertKeyset stores keyset to the secret backend.
 func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
@@ -147,24 +168,12 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 	}
 
 	secretID := generateSecretID(keyID)
-	secretName := fmt.Sprintf("projects/%s/secrets/%s", km.projectID, secretID)
 
 	// Create secret.
-	_, err := km.secretClient.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
-		Parent:   fmt.Sprintf("projects/%s", km.projectID),
-		SecretId: secretID,
-		Secret: &secretmanagerpb.Secret{
-			Replication: &secretmanagerpb.Replication{
-				Replication: &secretmanagerpb.Replication_Automatic_{
-					Automatic: &secretmanagerpb.Replication_Automatic{},
-				},
-			},
-		},
-	})
-
+	err := km.backend.CreateSecret(ctx, secretID)
 	if err != nil {
 		// check for already exists error.
-		if status.Code(err) == codes.AlreadyExists {
+		if errors.Is(err, ErrSecretAlreadyExists) {
 			// Delete existing secret with same keyID.
 			if err := km.DeleteKeyset(ctx, keyID); err != nil {
 				return fmt.Errorf("failed to delete existing secret with same keyID: %w", err)
@@ -181,11 +190,7 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 	}
 
 	// Store the secret.
-	_, err = km.secretClient.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
-		Parent:  secretName,
-		Payload: &secretmanagerpb.SecretPayload{Data: payload},
-	})
-	if err != nil {
+	if err := km.backend.AddSecretVersion(ctx, secretID, payload); err != nil {
 		return fmt.Errorf("failed to add secret version: %w", err)
 	}
 
@@ -215,39 +220,35 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 			}
 		}
 	}
-	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", km.projectID, secretID)
-	res, err := km.secretClient.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
-		Name: secretName,
-	})
+	data, err := km.backend.AccessSecretVersion(ctx, secretID)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
+		if errors.Is(err, ErrSecretNotFound) {
 			return nil, model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
 		}
 		return nil, fmt.Errorf("failed to access secret version: %w", err)
 	}
 
 	if km.subscriberKeysCache {
-		err = km.cache.Set(ctx, secretID, string(res.Payload.Data), time.Hour)
+		err = km.cache.Set(ctx, secretID, string(data), time.Hour)
 		if err != nil {
 			slog.WarnContext(ctx, "failed to set subscriber keys in cache after fetch", "error", err, "secretID", secretID)
 		}
 	}
 
 	var keyset *model.Keyset
-	if err := json.Unmarshal(res.Payload.Data, &keyset); err != nil {
+	if err := json.Unmarshal(data, &keyset); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 	return keyset, nil
 }
 
-// DeleteKeyset deletes the private keys from the secret manager.
+// DeleteKeyset deletes the private keys from the secret backend.
 func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
 	}
 
 	secretID := generateSecretID(keyID)
-	secretName := fmt.Sprintf("projects/%s/secrets/%s", km.projectID, secretID)
 
 	if km.subscriberKeysCache {
 		err := km.cache.Delete(ctx, secretID)
@@ -256,9 +257,7 @@ func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 		}
 	}
 
-	if err := km.secretClient.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
-		Name: secretName,
-	}); err != nil {
+	if err := km.backend.DeleteSecret(ctx, secretID); err != nil {
 		return fmt.Errorf("failed to delete secret: %w", err)
 	}
 	return nil
@@ -305,7 +304,7 @@ func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID st
 
 // close closes the connections.
 func (km *keyMgr) close() error {
-	return km.secretClient.Close()
+	return km.backend.Close()
 }
 
 // encodeBase64 encodes byte data to base64.
@@ -364,8 +363,23 @@ func (km *keyMgr) lookupRegistry(ctx context.Context, subscriberID, uniqueKeyID
 
 // validateCfg validates the config.
 func validateCfg(cfg *Config) error {
-	if cfg.ProjectID == "" {
-		return ErrEmptyProjectID
+	switch cfg.Backend {
+	case "", BackendGCP:
+		if cfg.ProjectID == "" {
+			return ErrEmptyProjectID
+		}
+	case BackendFile:
+		if cfg.SecretsDir == "" {
+			return ErrEmptySecretsDir
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+	if cfg.CacheFailureThreshold < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidCacheFailureThreshold, cfg.CacheFailureThreshold)
+	}
+	if cfg.CacheCooldown < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCacheCooldown, cfg.CacheCooldown)
 	}
 	return nil
 }
@@ -382,12 +396,16 @@ func validateParams(subscriberID, uniqueKeyID string) error {
 
 // Error definitions.
 var (
-	ErrEmptyProjectID     = errors.New("invalid config: projectID cannot be empty")
-	ErrNilCache           = errors.New("cache cannot be nil")
-	ErrNilKeySet          = errors.New("keyset cannot be nil")
-	ErrNilRegistryLookup  = errors.New("registry lookup cannot be nil")
-	ErrEmptySubscriberID  = errors.New("subscriberID cannot be empty")
-	ErrEmptyUniqueKeyID   = errors.New("uniqueKeyID cannot be empty")
-	ErrEmptyKeyID         = errors.New("keyID cannot be empty")
-	ErrSubscriberNotFound = errors.New("no subscriber found with given credentials")
+	ErrEmptyProjectID               = errors.New("invalid config: projectID cannot be empty")
+	ErrEmptySecretsDir              = errors.New("invalid config: secretsDir cannot be empty")
+	ErrUnknownBackend               = errors.New("invalid config: unknown secret backend")
+	ErrInvalidCacheFailureThreshold = errors.New("invalid config: cacheFailureThreshold cannot be negative")
+	ErrInvalidCacheCooldown         = errors.New("invalid config: cacheCooldown cannot be negative")
+	ErrNilCache                     = errors.New("cache cannot be nil")
+	ErrNilKeySet                    = errors.New("keyset cannot be nil")
+	ErrNilRegistryLookup            = errors.New("registry lookup cannot be nil")
+	ErrEmptySubscriberID            = errors.New("subscriberID cannot be empty")
+	ErrEmptyUniqueKeyID             = errors.New("uniqueKeyID cannot be empty")
+	ErrEmptyKeyID                   = errors.New("keyID cannot be empty")
+	ErrSubscriberNotFound           = errors.New("no subscriber found with given credentials")
 )
@@ -121,7 +121,7 @@ func TestNew(t *testing.T) {
 		mockClient := &mockSecretMgr{} // Create a mock client
 
 		// Test the internal constructor with the mock to bypass real authentication
-		km, closer, err := newWithClient(cache, reg, cfg, mockClient)
+		km, closer, err := newWithBackend(cache, reg, cfg, &gcpSecretBackend{client: mockClient})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -155,12 +155,26 @@ func TestNewErrors(t *testing.T) {
 			reg:     nil,
 			wantErr: ErrNilRegistryLookup,
 		},
+		{
+			name:    "file backend without secrets dir",
+			cfg:     &Config{Backend: BackendFile},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrEmptySecretsDir,
+		},
+		{
+			name:    "unknown backend",
+			cfg:     &Config{ProjectID: "test-project", Backend: "vault"},
+			cache:   &mockCache{},
+			reg:     &mockRegistry{},
+			wantErr: ErrUnknownBackend,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := &mockSecretMgr{} // Use a mock client here too
-			_, _, err := newWithClient(tt.cache, tt.reg, tt.cfg, mockClient)
+			_, _, err := newWithBackend(tt.cache, tt.reg, tt.cfg, &gcpSecretBackend{client: mockClient})
 			if err == nil {
 				t.Fatalf("expected error, got nil")
 			}
@@ -284,8 +298,7 @@ func TestInsertKeyset(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			km := &keyMgr{
-				projectID:           "test-project",
-				secretClient:        tt.mockSecret,
+				backend:             &gcpSecretBackend{projectID: "test-project", client: tt.mockSecret},
 				cache:               tt.mockCache,
 				subscriberKeysCache: tt.subscriberKeysCache,
 			}
@@ -376,8 +389,7 @@ func TestInsertKeysetErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			km := &keyMgr{
-				projectID:    "test-project",
-				secretClient: tt.mockSecret,
+				backend: &gcpSecretBackend{projectID: "test-project", client: tt.mockSecret},
 			}
 			err := km.InsertKeyset(ctx, tt.keyID, tt.keyset)
 			if err == nil {
@@ -522,8 +534,7 @@ func TestKeyset(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			km := &keyMgr{
-				projectID:           "test-project",
-				secretClient:        tt.mockSecret,
+				backend:             &gcpSecretBackend{projectID: "test-project", client: tt.mockSecret},
 				subscriberKeysCache: tt.subscriberKeysCache,
 				networkKeysCache:    tt.networkKeysCache,
 				cache:               tt.mockCache,
@@ -608,8 +619,7 @@ func TestKeysetErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			km := &keyMgr{
-				projectID:    "test-project",
-				secretClient: tt.mockSecret,
+				backend: &gcpSecretBackend{projectID: "test-project", client: tt.mockSecret},
 			}
 
 			_, err := km.Keyset(ctx, tt.keyID)
@@ -878,8 +888,7 @@ func TestDeleteKeyset(t *testing.T) {
 			},
 		}
 		km := &keyMgr{
-			projectID:    "test-project",
-			secretClient: mockSecret,
+			backend: &gcpSecretBackend{projectID: "test-project", client: mockSecret},
 		}
 		err := km.DeleteKeyset(ctx, keyID)
 		if err != nil {
@@ -914,8 +923,7 @@ func TestDeleteKeysetErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			km := &keyMgr{
-				projectID:    "test-project",
-				secretClient: tt.mockSecret,
+				backend: &gcpSecretBackend{projectID: "test-project", client: tt.mockSecret},
 			}
 			err := km.DeleteKeyset(ctx, tt.keyID)
 			if err == nil {
@@ -933,7 +941,7 @@ func TestClose(t *testing.T) {
 			},
 		}
 		km := &keyMgr{
-			secretClient: mockSecret,
+			backend: &gcpSecretBackend{client: mockSecret},
 		}
 		err := km.close()
 		if err != nil {
@@ -951,7 +959,7 @@ func TestCloseErrors(t *testing.T) {
 			},
 		}
 		km := &keyMgr{
-			secretClient: mockSecret,
+			backend: &gcpSecretBackend{client: mockSecret},
 		}
 		err := km.close()
 		if err == nil {
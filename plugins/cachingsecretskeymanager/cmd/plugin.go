@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	keymgr "github.com/google/dpi-accelerator-beckn-onix/plugins/cachingsecretskeymanager"
 
@@ -49,8 +50,21 @@ func (kp keyMgrProvider) New(ctx context.Context, cache plugin.Cache, registry p
 
 // parseConfig converts the map[string]string to the keyManager.Config struct.
 func parseConfig(config map[string]string) (*keymgr.Config, error) {
+	backend := keymgr.BackendType(config["secretBackend"])
+	secretsDir := config["secretsDir"]
+	switch backend {
+	case "", keymgr.BackendGCP:
+		backend = keymgr.BackendGCP
+	case keymgr.BackendFile:
+		if secretsDir == "" {
+			return &keymgr.Config{}, errors.New("secretsDir not found in config, required for file secret backend")
+		}
+	default:
+		return &keymgr.Config{}, fmt.Errorf("invalid value for secretBackend: %s, must be gcp or file", backend)
+	}
+
 	projectID, exists := config["projectID"]
-	if !exists {
+	if !exists && backend == keymgr.BackendGCP {
 		return &keymgr.Config{}, errors.New("projectID not found in config")
 	}
 
@@ -76,10 +90,32 @@ func parseConfig(config map[string]string) (*keymgr.Config, error) {
 		enableNetworkKeysCache = caching
 	}
 
+	var cacheFailureThreshold int
+	if v, exists := config["cacheFailureThreshold"]; exists {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return &keymgr.Config{}, fmt.Errorf("invalid value for cacheFailureThreshold: %s, must be a non-negative integer", v)
+		}
+		cacheFailureThreshold = n
+	}
+
+	var cacheCooldown time.Duration
+	if v, exists := config["cacheCooldown"]; exists {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return &keymgr.Config{}, fmt.Errorf("invalid value for cacheCooldown: %s, must be a non-negative duration", v)
+		}
+		cacheCooldown = d
+	}
+
 	return &keymgr.Config{
-		ProjectID:           projectID,
-		SubscriberKeysCache: enableSubscriberKeysCache,
-		NetworkKeysCache:    enableNetworkKeysCache,
+		ProjectID:             projectID,
+		SubscriberKeysCache:   enableSubscriberKeysCache,
+		NetworkKeysCache:      enableNetworkKeysCache,
+		Backend:               backend,
+		SecretsDir:            secretsDir,
+		CacheFailureThreshold: cacheFailureThreshold,
+		CacheCooldown:         cacheCooldown,
 	}, nil
 }
 
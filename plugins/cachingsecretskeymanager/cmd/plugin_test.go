@@ -107,6 +107,82 @@ func TestParseConfig(t *testing.T) {
 	}
 }
 
+func TestParseConfig_SecretBackend(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         map[string]string
+		wantBackend    keymgr.BackendType
+		wantSecretsDir string
+	}{
+		{
+			name:        "defaults to gcp",
+			config:      map[string]string{"projectID": "test-project"},
+			wantBackend: keymgr.BackendGCP,
+		},
+		{
+			name:        "explicit gcp",
+			config:      map[string]string{"projectID": "test-project", "secretBackend": "gcp"},
+			wantBackend: keymgr.BackendGCP,
+		},
+		{
+			name:           "file backend without projectID",
+			config:         map[string]string{"secretBackend": "file", "secretsDir": "/tmp/secrets"},
+			wantBackend:    keymgr.BackendFile,
+			wantSecretsDir: "/tmp/secrets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfig(tt.config)
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if got.Backend != tt.wantBackend {
+				t.Errorf("parseConfig() got Backend = %q, want %q", got.Backend, tt.wantBackend)
+			}
+			if got.SecretsDir != tt.wantSecretsDir {
+				t.Errorf("parseConfig() got SecretsDir = %q, want %q", got.SecretsDir, tt.wantSecretsDir)
+			}
+		})
+	}
+}
+
+func TestParseConfig_CacheBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        map[string]string
+		wantThreshold int
+		wantCooldown  time.Duration
+	}{
+		{
+			name:   "defaults left to the key manager",
+			config: map[string]string{"projectID": "test-project"},
+		},
+		{
+			name:          "configured",
+			config:        map[string]string{"projectID": "test-project", "cacheFailureThreshold": "3", "cacheCooldown": "1m"},
+			wantThreshold: 3,
+			wantCooldown:  time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfig(tt.config)
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if got.CacheFailureThreshold != tt.wantThreshold {
+				t.Errorf("parseConfig() got CacheFailureThreshold = %d, want %d", got.CacheFailureThreshold, tt.wantThreshold)
+			}
+			if got.CacheCooldown != tt.wantCooldown {
+				t.Errorf("parseConfig() got CacheCooldown = %s, want %s", got.CacheCooldown, tt.wantCooldown)
+			}
+		})
+	}
+}
+
 func TestParseConfigErrors(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -128,6 +204,22 @@ func TestParseConfigErrors(t *testing.T) {
 			name:   "invalid cachingNetworkKeys value",
 			config: map[string]string{"projectID": "test-project", "cachingNetworkKeys": "not_a_bool"},
 		},
+		{
+			name:   "unknown secretBackend",
+			config: map[string]string{"projectID": "test-project", "secretBackend": "vault"},
+		},
+		{
+			name:   "file secretBackend without secretsDir",
+			config: map[string]string{"secretBackend": "file"},
+		},
+		{
+			name:   "invalid cacheFailureThreshold value",
+			config: map[string]string{"projectID": "test-project", "cacheFailureThreshold": "-1"},
+		},
+		{
+			name:   "invalid cacheCooldown value",
+			config: map[string]string{"projectID": "test-project", "cacheCooldown": "soon"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachingsecretskeymanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// payloadFileName is the name of the file holding the latest secret version.
+const payloadFileName = "latest"
+
+// fileSecretBackend is a SecretBackend that keeps each secret in its own
+// directory under dir. It is intended for local development and testing,
+// payloads are stored unencrypted.
+type fileSecretBackend struct {
+	dir string
+}
+
+// newFileSecretBackend creates a fileSecretBackend rooted at dir, creating the directory if needed.
+func newFileSecretBackend(dir string) (*fileSecretBackend, error) {
+	if dir == "" {
+		return nil, ErrEmptySecretsDir
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	return &fileSecretBackend{dir: dir}, nil
+}
+
+// CreateSecret creates the directory for the secret.
+func (b *fileSecretBackend) CreateSecret(ctx context.Context, secretID string) error {
+	if err := os.Mkdir(b.secretDir(secretID), 0o700); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %s", ErrSecretAlreadyExists, secretID)
+		}
+		return err
+	}
+	return nil
+}
+
+// AddSecretVersion replaces the stored payload of the secret.
+// The payload is written to a temporary file first and renamed into place,
+// so readers never observe a partially written version.
+func (b *fileSecretBackend) AddSecretVersion(ctx context.Context, secretID string, payload []byte) error {
+	dir := b.secretDir(secretID)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, secretID)
+		}
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, payloadFileName+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, payloadFileName))
+}
+
+// AccessSecretVersion reads the stored payload of the secret.
+func (b *fileSecretBackend) AccessSecretVersion(ctx context.Context, secretID string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(b.secretDir(secretID), payloadFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, secretID)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteSecret removes the secret directory and its payload.
+func (b *fileSecretBackend) DeleteSecret(ctx context.Context, secretID string) error {
+	dir := b.secretDir(secretID)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, secretID)
+		}
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// Close is a no-op for the file backend.
+func (b *fileSecretBackend) Close() error {
+	return nil
+}
+
+// secretDir returns the directory holding the given secret.
+func (b *fileSecretBackend) secretDir(secretID string) string {
+	return filepath.Join(b.dir, secretID)
+}
@@ -0,0 +1,217 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachingsecretskeymanager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewFileSecretBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	b, err := newFileSecretBackend(dir)
+	if err != nil {
+		t.Fatalf("newFileSecretBackend() error = %v", err)
+	}
+	if b == nil {
+		t.Fatal("newFileSecretBackend() returned nil backend")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("secrets directory was not created: %v", err)
+	}
+}
+
+func TestNewFileSecretBackendErrors(t *testing.T) {
+	_, err := newFileSecretBackend("")
+	if !errors.Is(err, ErrEmptySecretsDir) {
+		t.Errorf("newFileSecretBackend() error = %v, want %v", err, ErrEmptySecretsDir)
+	}
+}
+
+func TestFileSecretBackend_StoreAndRetrieve(t *testing.T) {
+	ctx := context.Background()
+	b, err := newFileSecretBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("newFileSecretBackend() error = %v", err)
+	}
+
+	if err := b.CreateSecret(ctx, "secret1"); err != nil {
+		t.Fatalf("CreateSecret() error = %v", err)
+	}
+	if err := b.AddSecretVersion(ctx, "secret1", []byte("v1")); err != nil {
+		t.Fatalf("AddSecretVersion() error = %v", err)
+	}
+	got, err := b.AccessSecretVersion(ctx, "secret1")
+	if err != nil {
+		t.Fatalf("AccessSecretVersion() error = %v", err)
+	}
+	if string(got) != "v1" {
+		t.Errorf("AccessSecretVersion() = %q, want %q", got, "v1")
+	}
+
+	// A new version replaces the previous one.
+	if err := b.AddSecretVersion(ctx, "secret1", []byte("v2")); err != nil {
+		t.Fatalf("AddSecretVersion() error = %v", err)
+	}
+	got, err = b.AccessSecretVersion(ctx, "secret1")
+	if err != nil {
+		t.Fatalf("AccessSecretVersion() error = %v", err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("AccessSecretVersion() = %q, want %q", got, "v2")
+	}
+}
+
+func TestFileSecretBackend_Delete(t *testing.T) {
+	ctx := context.Background()
+	b, err := newFileSecretBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("newFileSecretBackend() error = %v", err)
+	}
+	if err := b.CreateSecret(ctx, "secret1"); err != nil {
+		t.Fatalf("CreateSecret() error = %v", err)
+	}
+	if err := b.AddSecretVersion(ctx, "secret1", []byte("payload")); err != nil {
+		t.Fatalf("AddSecretVersion() error = %v", err)
+	}
+
+	if err := b.DeleteSecret(ctx, "secret1"); err != nil {
+		t.Fatalf("DeleteSecret() error = %v", err)
+	}
+	if _, err := b.AccessSecretVersion(ctx, "secret1"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("AccessSecretVersion() after delete error = %v, want %v", err, ErrSecretNotFound)
+	}
+	// The secret can be recreated after deletion.
+	if err := b.CreateSecret(ctx, "secret1"); err != nil {
+		t.Errorf("CreateSecret() after delete error = %v", err)
+	}
+}
+
+func TestFileSecretBackendErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		setup   func(b *fileSecretBackend) error
+		op      func(b *fileSecretBackend) error
+		wantErr error
+	}{
+		{
+			name: "create existing secret",
+			setup: func(b *fileSecretBackend) error {
+				return b.CreateSecret(ctx, "secret1")
+			},
+			op: func(b *fileSecretBackend) error {
+				return b.CreateSecret(ctx, "secret1")
+			},
+			wantErr: ErrSecretAlreadyExists,
+		},
+		{
+			name: "add version to missing secret",
+			op: func(b *fileSecretBackend) error {
+				return b.AddSecretVersion(ctx, "missing", []byte("payload"))
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "access missing secret",
+			op: func(b *fileSecretBackend) error {
+				_, err := b.AccessSecretVersion(ctx, "missing")
+				return err
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "access secret without versions",
+			setup: func(b *fileSecretBackend) error {
+				return b.CreateSecret(ctx, "secret1")
+			},
+			op: func(b *fileSecretBackend) error {
+				_, err := b.AccessSecretVersion(ctx, "secret1")
+				return err
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "delete missing secret",
+			op: func(b *fileSecretBackend) error {
+				return b.DeleteSecret(ctx, "missing")
+			},
+			wantErr: ErrSecretNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := newFileSecretBackend(t.TempDir())
+			if err != nil {
+				t.Fatalf("newFileSecretBackend() error = %v", err)
+			}
+			if tt.setup != nil {
+				if err := tt.setup(b); err != nil {
+					t.Fatalf("setup error = %v", err)
+				}
+			}
+			if err := tt.op(b); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyMgr_FileSecretBackend(t *testing.T) {
+	ctx := context.Background()
+	b, err := newFileSecretBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("newFileSecretBackend() error = %v", err)
+	}
+	cfg := &Config{Backend: BackendFile, SecretsDir: b.dir}
+	km, _, err := newWithBackend(nil, &mockRegistry{}, cfg, b)
+	if err != nil {
+		t.Fatalf("newWithBackend() error = %v", err)
+	}
+
+	want, err := km.GenerateKeyset()
+	if err != nil {
+		t.Fatalf("GenerateKeyset() error = %v", err)
+	}
+	if err := km.InsertKeyset(ctx, "key1", want); err != nil {
+		t.Fatalf("InsertKeyset() error = %v", err)
+	}
+	// Inserting again overwrites the existing keyset.
+	if err := km.InsertKeyset(ctx, "key1", want); err != nil {
+		t.Fatalf("InsertKeyset() overwrite error = %v", err)
+	}
+	got, err := km.Keyset(ctx, "key1")
+	if err != nil {
+		t.Fatalf("Keyset() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Keyset() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := km.DeleteKeyset(ctx, "key1"); err != nil {
+		t.Fatalf("DeleteKeyset() error = %v", err)
+	}
+	var badReq *model.BadReqErr
+	if _, err := km.Keyset(ctx, "key1"); !errors.As(err, &badReq) {
+		t.Errorf("Keyset() after delete error = %v, want BadReqErr", err)
+	}
+}
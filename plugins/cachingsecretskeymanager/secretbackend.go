@@ -0,0 +1,141 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachingsecretskeymanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SecretBackend abstracts the store that holds keyset secrets.
+// Implementations must return errors wrapping ErrSecretAlreadyExists and
+// ErrSecretNotFound so the key manager can handle those cases.
+type SecretBackend interface {
+	// CreateSecret creates an empty secret with the given ID.
+	CreateSecret(ctx context.Context, secretID string) error
+	// AddSecretVersion stores payload as the latest version of the secret.
+	AddSecretVersion(ctx context.Context, secretID string, payload []byte) error
+	// AccessSecretVersion returns the payload of the latest version of the secret.
+	AccessSecretVersion(ctx context.Context, secretID string) ([]byte, error)
+	// DeleteSecret deletes the secret along with all of its versions.
+	DeleteSecret(ctx context.Context, secretID string) error
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// BackendType identifies a SecretBackend implementation.
+type BackendType string
+
+// Supported secret backends.
+const (
+	// BackendGCP stores secrets in Google Cloud Secret Manager. It is the default.
+	BackendGCP BackendType = "gcp"
+	// BackendFile stores secrets on the local filesystem. Meant for local development only.
+	BackendFile BackendType = "file"
+)
+
+// Secret backend errors.
+var (
+	ErrSecretAlreadyExists = errors.New("secret already exists")
+	ErrSecretNotFound      = errors.New("secret not found")
+)
+
+type secretMgr interface {
+	CreateSecret(context.Context, *secretmanagerpb.CreateSecretRequest, ...gax.CallOption) (*secretmanagerpb.Secret, error)
+	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
+	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	Close() error
+}
+
+// gcpSecretBackend is a SecretBackend backed by Google Cloud Secret Manager.
+type gcpSecretBackend struct {
+	projectID string
+	client    secretMgr
+}
+
+// CreateSecret creates an automatically replicated secret in the project.
+func (b *gcpSecretBackend) CreateSecret(ctx context.Context, secretID string) error {
+	_, err := b.client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+		Parent:   fmt.Sprintf("projects/%s", b.projectID),
+		SecretId: secretID,
+		Secret: &secretmanagerpb.Secret{
+			Replication: &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{
+					Automatic: &secretmanagerpb.Replication_Automatic{},
+				},
+			},
+		},
+	})
+	return b.mapErr(err)
+}
+
+// AddSecretVersion adds a new version holding payload to the secret.
+func (b *gcpSecretBackend) AddSecretVersion(ctx context.Context, secretID string, payload []byte) error {
+	_, err := b.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  b.secretName(secretID),
+		Payload: &secretmanagerpb.SecretPayload{Data: payload},
+	})
+	return b.mapErr(err)
+}
+
+// AccessSecretVersion fetches the payload of the latest secret version.
+func (b *gcpSecretBackend) AccessSecretVersion(ctx context.Context, secretID string) ([]byte, error) {
+	res, err := b.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: b.secretName(secretID) + "/versions/latest",
+	})
+	if err != nil {
+		return nil, b.mapErr(err)
+	}
+	return res.Payload.Data, nil
+}
+
+// DeleteSecret deletes the secret from the project.
+func (b *gcpSecretBackend) DeleteSecret(ctx context.Context, secretID string) error {
+	return b.mapErr(b.client.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
+		Name: b.secretName(secretID),
+	}))
+}
+
+// Close closes the underlying Secret Manager client.
+func (b *gcpSecretBackend) Close() error {
+	return b.client.Close()
+}
+
+// secretName returns the fully qualified resource name of the secret.
+func (b *gcpSecretBackend) secretName(secretID string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", b.projectID, secretID)
+}
+
+// mapErr translates Secret Manager status codes into backend errors.
+func (b *gcpSecretBackend) mapErr(err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return err
+	case codes.AlreadyExists:
+		return fmt.Errorf("%w: %v", ErrSecretAlreadyExists, err)
+	case codes.NotFound:
+		return fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+	default:
+		return err
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
 	"sync"
 	"time"
@@ -69,10 +70,41 @@ type fetchResult struct {
 	err    error
 }
 
+// KeyLifecycleEvent identifies the key lifecycle event reported to a KeyLifecycleObserver.
+type KeyLifecycleEvent string
+
+// Defines the key lifecycle events reported by the key manager.
+const (
+	// KeyLifecycleEventGenerated is reported when a new keyset is generated.
+	KeyLifecycleEventGenerated KeyLifecycleEvent = "GENERATED"
+	// KeyLifecycleEventInserted is reported when a keyset is stored in the secret manager.
+	KeyLifecycleEventInserted KeyLifecycleEvent = "INSERTED"
+	// KeyLifecycleEventRotated is reported when a keyset is replaced with a newly generated one.
+	KeyLifecycleEventRotated KeyLifecycleEvent = "ROTATED"
+	// KeyLifecycleEventDeleted is reported when a keyset is deleted from the secret manager.
+	KeyLifecycleEventDeleted KeyLifecycleEvent = "DELETED"
+)
+
+// KeyLifecycleObserver is notified after a key lifecycle event completes successfully.
+// Implementations must be safe for concurrent use and should not block.
+type KeyLifecycleObserver interface {
+	OnKeyLifecycleEvent(ctx context.Context, keyID string, event KeyLifecycleEvent)
+}
+
+// KeysetMetadata describes the version of a keyset stored in the secret manager.
+type KeysetMetadata struct {
+	KeyID     string
+	Version   string    // Secret Manager version ID, e.g. "3".
+	State     string    // Secret Manager version state, e.g. ENABLED, DISABLED or DESTROYED.
+	CreatedAt time.Time // Time the keyset version was stored.
+}
+
 // Config holds the configuration for the key manager.
 type Config struct {
 	ProjectID string
 	CacheTTL  CacheTTL
+	// Observer is notified of key lifecycle events. Optional, events are dropped if nil.
+	Observer KeyLifecycleObserver
 }
 
 // inMemoryCacheItem holds the cached data and its expiration time.
@@ -128,6 +160,7 @@ type secretMgr interface {
 	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
 	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	GetSecretVersion(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	Close() error
 }
 
@@ -140,6 +173,7 @@ type keyMgr struct {
 	publicKeyCacheTTL time.Duration
 	requestMutex      sync.Mutex
 	requests          map[string]*inFlightRequest
+	observer          KeyLifecycleObserver
 }
 
 // Constants for secret ID generation.
@@ -183,13 +217,24 @@ func newWithClient(redisCache plugin.Cache, registryLookup plugin.RegistryLookup
 		inMemoryCache:     inMemCache,
 		publicKeyCacheTTL: time.Duration(cfg.CacheTTL.PublicKeysSeconds) * time.Second,
 		requests:          make(map[string]*inFlightRequest),
+		observer:          cfg.Observer,
 	}
 
 	return km, km.close, nil
 }
 
-// generates new signing and encryption key pairs.
+// GenerateKeyset generates new signing and encryption key pairs.
 func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
+	keyset, err := generateKeyset()
+	if err != nil {
+		return nil, err
+	}
+	km.notify(context.Background(), keyset.UniqueKeyID, KeyLifecycleEventGenerated)
+	return keyset, nil
+}
+
+// generateKeyset generates new signing and encryption key pairs.
+func generateKeyset() (*model.Keyset, error) {
 	// Generate Signing keys.
 	signingPublic, signingPrivate, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
@@ -221,6 +266,31 @@ func (km *keyMgr) GenerateKeyset() (*model.Keyset, error) {
 
 // InsertKeyset stores keyset to the secret manager and caches it in-memory.
 func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
+	if err := km.insertKeyset(ctx, keyID, keyset); err != nil {
+		return err
+	}
+	km.notify(ctx, keyID, KeyLifecycleEventInserted)
+	return nil
+}
+
+// RotateKeyset replaces the keyset stored for keyID with a newly generated one and returns it.
+func (km *keyMgr) RotateKeyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	if keyID == "" {
+		return nil, model.NewBadReqErr(ErrEmptyKeyID)
+	}
+	keyset, err := generateKeyset()
+	if err != nil {
+		return nil, err
+	}
+	if err := km.insertKeyset(ctx, keyID, keyset); err != nil {
+		return nil, fmt.Errorf("failed to rotate keyset: %w", err)
+	}
+	km.notify(ctx, keyID, KeyLifecycleEventRotated)
+	return keyset, nil
+}
+
+// insertKeyset stores keyset to the secret manager and caches it in-memory, replacing any existing keyset.
+func (km *keyMgr) insertKeyset(ctx context.Context, keyID string, keyset *model.Keyset) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
 	}
@@ -248,11 +318,11 @@ func (km *keyMgr) InsertKeyset(ctx context.Context, keyID string, keyset *model.
 		// check for already exists error.
 		if status.Code(err) == codes.AlreadyExists {
 			// Delete existing secret with same keyID.
-			if err := km.DeleteKeyset(ctx, keyID); err != nil {
+			if err := km.deleteKeyset(ctx, keyID); err != nil {
 				return fmt.Errorf("failed to delete existing secret with same keyID: %w", err)
 			}
 
-			return km.InsertKeyset(ctx, keyID, keyset)
+			return km.insertKeyset(ctx, keyID, keyset)
 		}
 		return fmt.Errorf("failed to create secret: %w", err)
 	}
@@ -349,8 +419,58 @@ func (km *keyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 	return req.result.keyset, req.result.err
 }
 
+// KeysetMetadata fetches the version, state and creation time of the latest keyset stored for keyID.
+// It always reads from the secret manager so that rotation and reconciliation see the current state.
+func (km *keyMgr) KeysetMetadata(ctx context.Context, keyID string) (*KeysetMetadata, error) {
+	if keyID == "" {
+		return nil, model.NewBadReqErr(ErrEmptyKeyID)
+	}
+	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", km.projectID, generateSecretID(keyID))
+	version, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, model.NewBadReqErr(fmt.Errorf("keys for subscriberID: %s not found", keyID))
+		}
+		return nil, fmt.Errorf("failed to get secret version: %w", err)
+	}
+	return &KeysetMetadata{
+		KeyID:     keyID,
+		Version:   path.Base(version.GetName()),
+		State:     version.GetState().String(),
+		CreatedAt: version.GetCreateTime().AsTime(),
+	}, nil
+}
+
+// pingSecretID is the secret Ping reads. It need not exist.
+const pingSecretID = "onix-key-manager-ping"
+
+// Ping checks that the secret manager can be reached with the key manager's credentials, by reading
+// the metadata of a secret that need not exist. It returns an error if the secret manager cannot serve
+// keys, e.g. because it is unavailable or access to the project is denied.
+func (km *keyMgr) Ping(ctx context.Context) error {
+	secretName := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", km.projectID, pingSecretID)
+	_, err := km.secretClient.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: secretName,
+	})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to reach secret manager: %w", err)
+	}
+	return nil
+}
+
 // DeleteKeyset deletes the private keys from the secret manager and the in-memory cache.
 func (km *keyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
+	if err := km.deleteKeyset(ctx, keyID); err != nil {
+		return err
+	}
+	km.notify(ctx, keyID, KeyLifecycleEventDeleted)
+	return nil
+}
+
+// deleteKeyset deletes the private keys from the secret manager and the in-memory cache.
+func (km *keyMgr) deleteKeyset(ctx context.Context, keyID string) error {
 	if keyID == "" {
 		return model.NewBadReqErr(ErrEmptyKeyID)
 	}
@@ -409,6 +529,14 @@ func (km *keyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID st
 	return publicKeys.SigningPublic, publicKeys.EncrPublic, nil
 }
 
+// notify reports a key lifecycle event to the configured observer, if any.
+func (km *keyMgr) notify(ctx context.Context, keyID string, event KeyLifecycleEvent) {
+	if km.observer == nil {
+		return
+	}
+	km.observer.OnKeyLifecycleEvent(ctx, keyID, event)
+}
+
 // close closes the connections.
 func (km *keyMgr) close() error {
 	km.securelyWipeAndClearCache()
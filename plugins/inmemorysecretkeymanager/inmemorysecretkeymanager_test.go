@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,6 +29,7 @@ import (
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/beckn-one/beckn-onix/pkg/model"
@@ -37,10 +39,14 @@ import (
 
 // --- Mocks ---
 
+// mockVersionCreateTime is the creation time of every secret version added to mockSecretMgr.
+var mockVersionCreateTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
 // mockSecretMgr implements the secretMgr interface for testing.
 type mockSecretMgr struct {
 	mu                  sync.Mutex
 	secrets             map[string][]byte
+	versions            map[string]*secretmanagerpb.SecretVersion
 	accessCallCount     int32
 	createCallCount     int32
 	deleteCallCount     int32
@@ -49,12 +55,14 @@ type mockSecretMgr struct {
 	addSecretVersionErr error
 	deleteSecretErr     error
 	accessSecretErr     error
+	getVersionErr       error
 	closeErr            error
 }
 
 func newMockSecretMgr(latency time.Duration) *mockSecretMgr {
 	return &mockSecretMgr{
 		secrets:       make(map[string][]byte),
+		versions:      make(map[string]*secretmanagerpb.SecretVersion),
 		accessLatency: latency,
 	}
 }
@@ -88,7 +96,13 @@ func (m *mockSecretMgr) AddSecretVersion(ctx context.Context, req *secretmanager
 		secretName = req.Parent
 	}
 	m.secrets[secretName+"/versions/latest"] = req.Payload.Data
-	return &secretmanagerpb.SecretVersion{}, nil
+	version := &secretmanagerpb.SecretVersion{
+		Name:       secretName + "/versions/1",
+		State:      secretmanagerpb.SecretVersion_ENABLED,
+		CreateTime: timestamppb.New(mockVersionCreateTime),
+	}
+	m.versions[secretName+"/versions/latest"] = version
+	return version, nil
 }
 
 func (m *mockSecretMgr) DeleteSecret(ctx context.Context, req *secretmanagerpb.DeleteSecretRequest, opts ...gax.CallOption) error {
@@ -99,6 +113,7 @@ func (m *mockSecretMgr) DeleteSecret(ctx context.Context, req *secretmanagerpb.D
 		return m.deleteSecretErr
 	}
 	delete(m.secrets, req.Name+"/versions/latest")
+	delete(m.versions, req.Name+"/versions/latest")
 	return nil
 }
 
@@ -120,6 +135,19 @@ func (m *mockSecretMgr) AccessSecretVersion(ctx context.Context, req *secretmana
 	}, nil
 }
 
+func (m *mockSecretMgr) GetSecretVersion(ctx context.Context, req *secretmanagerpb.GetSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getVersionErr != nil {
+		return nil, m.getVersionErr
+	}
+	version, ok := m.versions[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret version not found: %s", req.Name)
+	}
+	return version, nil
+}
+
 func (m *mockSecretMgr) Close() error { return m.closeErr }
 
 // mockCache implements the plugin.Cache interface for testing.
@@ -170,6 +198,23 @@ func (m *mockRegistry) Lookup(ctx context.Context, req *model.Subscription) ([]m
 	return nil, errors.New("lookup function not implemented")
 }
 
+// mockObserver records the key lifecycle events it receives.
+type mockObserver struct {
+	mu     sync.Mutex
+	events []observedEvent
+}
+
+type observedEvent struct {
+	keyID string
+	event KeyLifecycleEvent
+}
+
+func (m *mockObserver) OnKeyLifecycleEvent(ctx context.Context, keyID string, event KeyLifecycleEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, observedEvent{keyID: keyID, event: event})
+}
+
 // --- Test Helper ---
 
 func setupTestKeyManager(t *testing.T, sm secretMgr, rc plugin.Cache, rl plugin.RegistryLookup) *keyMgr {
@@ -552,6 +597,266 @@ func TestDeleteKeyset_Errors(t *testing.T) {
 	}
 }
 
+func TestRotateKeyset(t *testing.T) {
+	ctx := context.Background()
+	keyID := "key-to-rotate"
+	mockSM := newMockSecretMgr(0)
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	oldKeyset := &model.Keyset{UniqueKeyID: "old-key"}
+	if err := km.InsertKeyset(ctx, keyID, oldKeyset); err != nil {
+		t.Fatalf("InsertKeyset() failed: %v", err)
+	}
+
+	keyset, err := km.RotateKeyset(ctx, keyID)
+	if err != nil {
+		t.Fatalf("RotateKeyset() failed: %v", err)
+	}
+	if keyset.UniqueKeyID == "" || keyset.UniqueKeyID == "old-key" {
+		t.Errorf("RotateKeyset() returned UniqueKeyID %q, want a newly generated ID", keyset.UniqueKeyID)
+	}
+	cached, found := km.inMemoryCache.Get(generateSecretID(keyID))
+	if !found || cached.UniqueKeyID != keyset.UniqueKeyID {
+		t.Error("rotated keyset was not cached in-memory")
+	}
+}
+
+func TestRotateKeyset_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name      string
+		keyID     string
+		setupMock func(*mockSecretMgr)
+		wantErr   string
+	}{
+		{"empty keyID", "", nil, ErrEmptyKeyID.Error()},
+		{
+			"insert fails in secret manager", "key-to-rotate",
+			func(m *mockSecretMgr) { m.addSecretVersionErr = errors.New("add failed") },
+			"failed to rotate keyset",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSM := newMockSecretMgr(0)
+			if tc.setupMock != nil {
+				tc.setupMock(mockSM)
+			}
+			km := setupTestKeyManager(t, mockSM, nil, nil)
+			_, err := km.RotateKeyset(ctx, tc.keyID)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestKeysetMetadata(t *testing.T) {
+	ctx := context.Background()
+	keyID := "key-with-metadata"
+	mockSM := newMockSecretMgr(0)
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	if err := km.InsertKeyset(ctx, keyID, &model.Keyset{UniqueKeyID: "key-1"}); err != nil {
+		t.Fatalf("InsertKeyset() failed: %v", err)
+	}
+
+	got, err := km.KeysetMetadata(ctx, keyID)
+	if err != nil {
+		t.Fatalf("KeysetMetadata() failed: %v", err)
+	}
+
+	want := &KeysetMetadata{
+		KeyID:     keyID,
+		Version:   "1",
+		State:     "ENABLED",
+		CreatedAt: mockVersionCreateTime,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("KeysetMetadata() = %+v, want %+v", got, want)
+	}
+}
+
+func TestKeysetMetadata_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name      string
+		keyID     string
+		setupMock func(*mockSecretMgr)
+		wantErr   string
+	}{
+		{"empty keyID", "", nil, ErrEmptyKeyID.Error()},
+		{"keyset not found", "missing-key", nil, "keys for subscriberID: missing-key not found"},
+		{
+			"secret manager error", "key-1",
+			func(m *mockSecretMgr) { m.getVersionErr = errors.New("backend down") },
+			"failed to get secret version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSM := newMockSecretMgr(0)
+			if tc.setupMock != nil {
+				tc.setupMock(mockSM)
+			}
+			km := setupTestKeyManager(t, mockSM, nil, nil)
+			_, err := km.KeysetMetadata(ctx, tc.keyID)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	testCases := []struct {
+		name      string
+		setupMock func(*mockSecretMgr)
+		wantErr   string
+	}{
+		{"reachable", nil, ""},
+		{
+			"permission denied", func(m *mockSecretMgr) { m.getVersionErr = status.Error(codes.PermissionDenied, "denied") },
+			"failed to reach secret manager",
+		},
+		{
+			"unavailable", func(m *mockSecretMgr) { m.getVersionErr = status.Error(codes.Unavailable, "backend down") },
+			"failed to reach secret manager",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSM := newMockSecretMgr(0)
+			if tc.setupMock != nil {
+				tc.setupMock(mockSM)
+			}
+			km := setupTestKeyManager(t, mockSM, nil, nil)
+			err := km.Ping(context.Background())
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("Ping() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Ping() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyLifecycleObserver(t *testing.T) {
+	ctx := context.Background()
+	keyID := "observed-key"
+
+	testCases := []struct {
+		name      string
+		setupMock func(*mockSecretMgr)
+		action    func(km *keyMgr) (string, error)
+		wantEvent KeyLifecycleEvent
+	}{
+		{
+			name: "generate",
+			action: func(km *keyMgr) (string, error) {
+				keyset, err := km.GenerateKeyset()
+				if err != nil {
+					return "", err
+				}
+				return keyset.UniqueKeyID, nil
+			},
+			wantEvent: KeyLifecycleEventGenerated,
+		},
+		{
+			name: "insert",
+			action: func(km *keyMgr) (string, error) {
+				return keyID, km.InsertKeyset(ctx, keyID, &model.Keyset{UniqueKeyID: "k1"})
+			},
+			wantEvent: KeyLifecycleEventInserted,
+		},
+		{
+			name:      "insert replacing existing secret",
+			setupMock: func(m *mockSecretMgr) { m.createSecretErr = status.Error(codes.AlreadyExists, "secret exists") },
+			action: func(km *keyMgr) (string, error) {
+				return keyID, km.InsertKeyset(ctx, keyID, &model.Keyset{UniqueKeyID: "k1"})
+			},
+			wantEvent: KeyLifecycleEventInserted,
+		},
+		{
+			name: "rotate",
+			action: func(km *keyMgr) (string, error) {
+				_, err := km.RotateKeyset(ctx, keyID)
+				return keyID, err
+			},
+			wantEvent: KeyLifecycleEventRotated,
+		},
+		{
+			name: "delete",
+			action: func(km *keyMgr) (string, error) {
+				return keyID, km.DeleteKeyset(ctx, keyID)
+			},
+			wantEvent: KeyLifecycleEventDeleted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSM := newMockSecretMgr(0)
+			if tc.setupMock != nil {
+				tc.setupMock(mockSM)
+			}
+			km := setupTestKeyManager(t, mockSM, nil, nil)
+			obs := &mockObserver{}
+			km.observer = obs
+
+			wantKeyID, err := tc.action(km)
+			if err != nil {
+				t.Fatalf("action failed: %v", err)
+			}
+			want := []observedEvent{{keyID: wantKeyID, event: tc.wantEvent}}
+			if !reflect.DeepEqual(obs.events, want) {
+				t.Errorf("observer events = %+v, want %+v", obs.events, want)
+			}
+		})
+	}
+}
+
+func TestKeyLifecycleObserver_NotCalledOnError(t *testing.T) {
+	ctx := context.Background()
+	mockSM := newMockSecretMgr(0)
+	mockSM.deleteSecretErr = errors.New("delete failed")
+	mockSM.addSecretVersionErr = errors.New("add failed")
+	km := setupTestKeyManager(t, mockSM, nil, nil)
+	obs := &mockObserver{}
+	km.observer = obs
+
+	_ = km.InsertKeyset(ctx, "key", &model.Keyset{})
+	_, _ = km.RotateKeyset(ctx, "key")
+	_ = km.DeleteKeyset(ctx, "key")
+
+	if len(obs.events) != 0 {
+		t.Errorf("observer events = %+v, want none", obs.events)
+	}
+}
+
+func TestNew_WithObserver(t *testing.T) {
+	obs := &mockObserver{}
+	cfg := &Config{
+		ProjectID: "test-project",
+		CacheTTL:  CacheTTL{PrivateKeysSeconds: 60, PublicKeysSeconds: 120},
+		Observer:  obs,
+	}
+	km, _, err := newWithClient(newMockCache(), &mockRegistry{}, cfg, newMockSecretMgr(0))
+	if err != nil {
+		t.Fatalf("newWithClient() failed unexpectedly: %v", err)
+	}
+	if km.observer != obs {
+		t.Error("newWithClient() did not set the configured observer")
+	}
+}
+
 func TestLookupNPKeys(t *testing.T) {
 	ctx := context.Background()
 	subID, keyID := "test-sub", "test-key"
@@ -835,6 +1140,9 @@ func (m *mockBenchSecretMgr) AddSecretVersion(context.Context, *secretmanagerpb.
 func (m *mockBenchSecretMgr) DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error {
 	return nil
 }
+func (m *mockBenchSecretMgr) GetSecretVersion(context.Context, *secretmanagerpb.GetSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	return nil, nil
+}
 func (m *mockBenchSecretMgr) Close() error {
 	return nil
 }
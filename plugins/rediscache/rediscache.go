@@ -16,6 +16,7 @@ package rediscache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -42,12 +43,24 @@ func New(ctx context.Context, config map[string]string) (*cache, func() error, e
 		password = ""
 	}
 
-	client := redisNewClient(&redis.Options{
+	opts := &redis.Options{
 		Addr:     addr,
 		Password: password,
 		//DB: 0 (default) is used for caching simplicity and isolation.
 		DB: 0,
-	})
+	}
+	// timeout optionally bounds dialing and each command, overriding the go-redis defaults.
+	if v, ok := config["timeout"]; ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return nil, nil, fmt.Errorf("invalid config 'timeout': %q", v)
+		}
+		opts.DialTimeout = timeout
+		opts.ReadTimeout = timeout
+		opts.WriteTimeout = timeout
+	}
+
+	client := redisNewClient(opts)
 
 	if _, err := client.Ping(ctx).Result(); err != nil {
 		return nil, nil, fmt.Errorf("failed to connect to redis: %w", err)
@@ -77,11 +90,91 @@ func (c *cache) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+// GetIfExists retrieves a value from Redis, reporting false if key does not exist.
+func (c *cache) GetIfExists(ctx context.Context, key string) (string, bool, error) {
+	value, err := c.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
 // Set stores a value in Redis with a TTL.
 func (c *cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
 	return c.client.Set(ctx, key, value, ttl).Err()
 }
 
+// SetNX stores a value in Redis with a TTL only if the key does not exist.
+// It reports whether the value was stored.
+func (c *cache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// nonceKeyPrefix namespaces the keys of remembered nonces.
+const nonceKeyPrefix = "nonce:"
+
+// Remember remembers nonce for ttl, unless it is already remembered.
+// It reports whether nonce was fresh. SET NX makes the check and the write atomic,
+// so concurrent requests with the same nonce cannot both be accepted.
+func (c *cache) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, nonceKeyPrefix+nonce, "1", ttl).Result()
+}
+
+// RPush appends values to the tail of the list at key.
+func (c *cache) RPush(ctx context.Context, key string, values ...string) error {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return c.client.RPush(ctx, key, args...).Err()
+}
+
+// BLMove removes the head of the list at source and appends it to the tail of the list at destination,
+// waiting up to timeout for one to be pushed. It returns the moved value, and reports false if source stayed empty.
+func (c *cache) BLMove(ctx context.Context, source, destination string, timeout time.Duration) (string, bool, error) {
+	return movedValue(c.client.BLMove(ctx, source, destination, "LEFT", "RIGHT", timeout).Result())
+}
+
+// LMove is BLMove without waiting for a value.
+func (c *cache) LMove(ctx context.Context, source, destination string) (string, bool, error) {
+	return movedValue(c.client.LMove(ctx, source, destination, "LEFT", "RIGHT").Result())
+}
+
+// movedValue converts the reply of a list move, nil if the source list was empty.
+func movedValue(value string, err error) (string, bool, error) {
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// LRem removes the first occurrence of value from the list at key.
+func (c *cache) LRem(ctx context.Context, key, value string) error {
+	return c.client.LRem(ctx, key, 1, value).Err()
+}
+
+// LLen returns the length of the list at key, 0 if it does not exist.
+func (c *cache) LLen(ctx context.Context, key string) (int64, error) {
+	return c.client.LLen(ctx, key).Result()
+}
+
+// Scan returns the keys matching the glob pattern match. It iterates with SCAN rather than
+// KEYS, so that listing a large keyspace does not block Redis.
+func (c *cache) Scan(ctx context.Context, match string) ([]string, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, match, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
 // Delete removes a value from Redis.
 func (c *cache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
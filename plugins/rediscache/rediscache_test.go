@@ -17,6 +17,7 @@ package rediscache
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -81,6 +82,14 @@ func TestNewError(t *testing.T) {
 			},
 			expectedErr: errors.New("missing required config 'addr'"),
 		},
+		{
+			name: "invalid timeout",
+			config: map[string]string{
+				"addr":    "localhost:6379",
+				"timeout": "soon",
+			},
+			expectedErr: errors.New(`invalid config 'timeout': "soon"`),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -93,6 +102,29 @@ func TestNewError(t *testing.T) {
 	}
 }
 
+func TestNewTimeout(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+
+	var gotOpts *redis.Options
+	redisNewClient = func(opt *redis.Options) *redis.Client {
+		gotOpts = opt
+		return redis.NewClient(opt)
+	}
+	defer func() { redisNewClient = redis.NewClient }()
+
+	if _, _, err := New(context.Background(), map[string]string{"addr": s.Addr(), "timeout": "250ms"}); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	want := 250 * time.Millisecond
+	if gotOpts.DialTimeout != want || gotOpts.ReadTimeout != want || gotOpts.WriteTimeout != want {
+		t.Errorf("New() timeouts = dial %s, read %s, write %s, want %s", gotOpts.DialTimeout, gotOpts.ReadTimeout, gotOpts.WriteTimeout, want)
+	}
+}
+
 func TestGetSuccess(t *testing.T) {
 	ctx := context.Background()
 	s, err := miniredis.Run()
@@ -270,6 +302,220 @@ func TestSetError(t *testing.T) {
 	}
 }
 
+func TestGetIfExists(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if value, ok, err := cache.GetIfExists(ctx, "testKey"); err != nil || ok || value != "" {
+		t.Errorf("GetIfExists() on missing key = %q, %v, %v, want \"\", false, nil", value, ok, err)
+	}
+	if err := s.Set("testKey", "testValue"); err != nil {
+		t.Fatalf("failed to set value in miniredis: %v", err)
+	}
+	if value, ok, err := cache.GetIfExists(ctx, "testKey"); err != nil || !ok || value != "testValue" {
+		t.Errorf("GetIfExists() on existing key = %q, %v, %v, want \"testValue\", true, nil", value, ok, err)
+	}
+
+	s.Close()
+	if _, _, err := cache.GetIfExists(ctx, "testKey"); err == nil {
+		t.Errorf("GetIfExists() on closed server: expected error but got nil")
+	}
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	for _, key := range []string{"archive:t1:m1:a", "archive:t1:m1:b", "archive:t1:m2:a", "other"} {
+		if err := s.Set(key, "v"); err != nil {
+			t.Fatalf("failed to set value in miniredis: %v", err)
+		}
+	}
+
+	keys, err := cache.Scan(ctx, "archive:t1:m1:*")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	slices.Sort(keys)
+	if want := []string{"archive:t1:m1:a", "archive:t1:m1:b"}; !slices.Equal(keys, want) {
+		t.Errorf("Scan() = %v, want %v", keys, want)
+	}
+
+	s.Close()
+	if _, err := cache.Scan(ctx, "archive:*"); err == nil {
+		t.Error("Scan() on closed server error = nil, want error")
+	}
+}
+
+func TestSetNX(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	stored, err := cache.SetNX(ctx, "testKey", "first", time.Second)
+	if err != nil || !stored {
+		t.Fatalf("SetNX() on new key = %v, %v, want true, nil", stored, err)
+	}
+	stored, err = cache.SetNX(ctx, "testKey", "second", time.Second)
+	if err != nil || stored {
+		t.Errorf("SetNX() on existing key = %v, %v, want false, nil", stored, err)
+	}
+	if got, _ := s.Get("testKey"); got != "first" {
+		t.Errorf("value in miniredis = %q, want %q", got, "first")
+	}
+
+	s.FastForward(2 * time.Second)
+	stored, err = cache.SetNX(ctx, "testKey", "third", time.Second)
+	if err != nil || !stored {
+		t.Errorf("SetNX() on expired key = %v, %v, want true, nil", stored, err)
+	}
+}
+
+func TestSetNXError(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	s.Close()
+	if _, err := cache.SetNX(ctx, "testKey", "value", time.Second); err == nil {
+		t.Errorf("expected error but got nil")
+	}
+}
+
+func TestNonces(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if fresh, err := cache.Remember(ctx, "sub|n1", time.Second); err != nil || !fresh {
+		t.Fatalf("Remember() = %v, %v, want true, nil", fresh, err)
+	}
+	if fresh, err := cache.Remember(ctx, "sub|n1", time.Second); err != nil || fresh {
+		t.Errorf("Remember() again = %v, %v, want false, nil", fresh, err)
+	}
+	if !s.Exists("nonce:sub|n1") {
+		t.Errorf("nonce not stored under the nonce: prefix")
+	}
+
+	s.FastForward(2 * time.Second)
+	if fresh, err := cache.Remember(ctx, "sub|n1", time.Second); err != nil || !fresh {
+		t.Errorf("Remember() after ttl = %v, %v, want true, nil", fresh, err)
+	}
+
+	s.Close()
+	if _, err := cache.Remember(ctx, "sub|n1", time.Second); err == nil {
+		t.Errorf("Remember() with redis down error = nil, want error")
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer s.Close()
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if err := cache.RPush(ctx, "testList", "first", "second"); err != nil {
+		t.Fatalf("RPush() error = %v", err)
+	}
+	if n, err := cache.LLen(ctx, "testList"); err != nil || n != 2 {
+		t.Errorf("LLen() = %d, %v, want 2, nil", n, err)
+	}
+	if got, ok, err := cache.BLMove(ctx, "testList", "processing", time.Second); err != nil || !ok || got != "first" {
+		t.Errorf("BLMove() = %q, %v, %v, want %q, true, nil", got, ok, err, "first")
+	}
+	if got, ok, err := cache.LMove(ctx, "testList", "processing"); err != nil || !ok || got != "second" {
+		t.Errorf("LMove() = %q, %v, %v, want %q, true, nil", got, ok, err, "second")
+	}
+	if got, ok, err := cache.BLMove(ctx, "testList", "processing", 100*time.Millisecond); err != nil || ok {
+		t.Errorf("BLMove() on empty list = %q, %v, %v, want false, nil", got, ok, err)
+	}
+	if got, ok, err := cache.LMove(ctx, "testList", "processing"); err != nil || ok {
+		t.Errorf("LMove() on empty list = %q, %v, %v, want false, nil", got, ok, err)
+	}
+	if n, err := cache.LLen(ctx, "testList"); err != nil || n != 0 {
+		t.Errorf("LLen() of empty list = %d, %v, want 0, nil", n, err)
+	}
+	if got, err := s.List("processing"); err != nil || !slices.Equal(got, []string{"first", "second"}) {
+		t.Errorf("processing list = %q, %v, want [first second]", got, err)
+	}
+	if err := cache.LRem(ctx, "processing", "first"); err != nil {
+		t.Errorf("LRem() error = %v", err)
+	}
+	if got, err := s.List("processing"); err != nil || !slices.Equal(got, []string{"second"}) {
+		t.Errorf("processing list after LRem() = %q, %v, want [second]", got, err)
+	}
+}
+
+func TestListError(t *testing.T) {
+	ctx := context.Background()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	cache, _, err := New(ctx, map[string]string{"addr": s.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	s.Close()
+	if err := cache.RPush(ctx, "testList", "value"); err == nil {
+		t.Error("RPush() error = nil, want error")
+	}
+	if _, _, err := cache.BLMove(ctx, "testList", "processing", time.Second); err == nil {
+		t.Error("BLMove() error = nil, want error")
+	}
+	if _, _, err := cache.LMove(ctx, "testList", "processing"); err == nil {
+		t.Error("LMove() error = nil, want error")
+	}
+	if err := cache.LRem(ctx, "processing", "value"); err == nil {
+		t.Error("LRem() error = nil, want error")
+	}
+	if _, err := cache.LLen(ctx, "testList"); err == nil {
+		t.Error("LLen() error = nil, want error")
+	}
+}
+
 func TestDeleteSuccess(t *testing.T) {
 	ctx := context.Background()
 	s, err := miniredis.Run()
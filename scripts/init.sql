@@ -22,7 +22,7 @@ BEGIN
         CREATE TYPE subscriber_status_enum AS ENUM ('INITIATED', 'UNDER_SUBSCRIPTION', 'SUBSCRIBED', 'INVALID_SSL', 'UNSUBSCRIBED');
     END IF;
     IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'operation_status_enum') THEN
-        CREATE TYPE operation_status_enum AS ENUM ('PENDING', 'APPROVED', 'REJECTED', 'FAILURE');
+        CREATE TYPE operation_status_enum AS ENUM ('PENDING', 'IN_PROGRESS', 'APPROVED', 'REJECTED', 'FAILURE');
     END IF;
     IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'operation_type_enum') THEN
         CREATE TYPE operation_type_enum AS ENUM ('CREATE_SUBSCRIPTION', 'UPDATE_SUBSCRIPTION');
@@ -32,6 +32,9 @@ BEGIN
     END IF;
 END$$;
 
+-- Databases created before IN_PROGRESS was introduced need the value added.
+ALTER TYPE operation_status_enum ADD VALUE IF NOT EXISTS 'IN_PROGRESS' AFTER 'PENDING';
+
 -- Subscribers Table:
 CREATE TABLE IF NOT EXISTS subscriptions (
     subscriber_id VARCHAR(255) NOT NULL,
@@ -76,6 +79,7 @@ CREATE TABLE IF NOT EXISTS Operations (
 -- Indexes for Operations table:
 CREATE INDEX IF NOT EXISTS Idx_operations_status ON Operations (status);
 CREATE INDEX IF NOT EXISTS Idx_operations_updated_at ON Operations (updated_at);
+CREATE INDEX IF NOT EXISTS Idx_operations_subscriber_id ON Operations ((request_json->>'subscriber_id'));
 
 --------------------------------------------------------------------------------
 -- AUTO-UPDATE TIMESTAMP LOGIC